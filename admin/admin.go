@@ -0,0 +1,309 @@
+// Package admin provides read-only HTTP endpoints for inspecting a running gatot-kaca
+// deployment: configured models, registered tools and their metrics, in-progress and
+// recorded flow runs, and any application-defined state such as sessions, budgets, or circuit breakers.
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/zakirkun/gatot-kaca/agent"
+	"github.com/zakirkun/gatot-kaca/agent/tools"
+	"github.com/zakirkun/gatot-kaca/llm"
+	"github.com/zakirkun/gatot-kaca/workflow"
+)
+
+// InspectFunc returns a JSON-serializable snapshot of some part of the runtime state.
+type InspectFunc func(ctx context.Context) (interface{}, error)
+
+// ModelInfo describes a model configured in an llm.Client.
+type ModelInfo struct {
+	Client   string            `json:"client"`
+	Name     string            `json:"name"`
+	Provider llm.ModelProvider `json:"provider"`
+	Model    string            `json:"model"`
+
+	Capabilities *llm.Capabilities `json:"capabilities,omitempty"`
+}
+
+// ToolInfo describes a tool registered with an agent.
+type ToolInfo struct {
+	Agent       string `json:"agent"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Calls       int    `json:"calls"`
+
+	Metrics tools.ToolMetrics `json:"metrics"`
+}
+
+// requestKey is the context key under which Handler passes the HTTP request to sections,
+// so that they can read query parameters.
+type requestKey struct{}
+
+// Server serves the admin endpoints. Sources are registered with the Add* methods;
+// each registered section is available at <prefix>/<section> and the combined state at <prefix>.
+type Server struct {
+	mu       sync.RWMutex
+	sections map[string]InspectFunc
+
+	clients  map[string]*llm.Client
+	agents   map[string]*agent.Agent
+	trackers map[string]*workflow.RunTracker
+	stores   map[string]workflow.RunStore
+}
+
+// NewServer creates an admin Server with the built-in models, tools, flows, and runs sections.
+func NewServer() *Server {
+	s := &Server{
+		sections: make(map[string]InspectFunc),
+		clients:  make(map[string]*llm.Client),
+		agents:   make(map[string]*agent.Agent),
+		trackers: make(map[string]*workflow.RunTracker),
+		stores:   make(map[string]workflow.RunStore),
+	}
+	s.sections["models"] = s.inspectModels
+	s.sections["tools"] = s.inspectTools
+	s.sections["flows"] = s.inspectFlows
+	s.sections["runs"] = s.inspectRuns
+	return s
+}
+
+// AddClient exposes the models configured in an llm.Client.
+func (s *Server) AddClient(name string, client *llm.Client) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clients[name] = client
+}
+
+// AddAgent exposes the tools registered with an agent, together with their metrics.
+func (s *Server) AddAgent(name string, a *agent.Agent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.agents[name] = a
+}
+
+// AddRunTracker exposes the in-progress runs recorded by a workflow.RunTracker.
+func (s *Server) AddRunTracker(name string, t *workflow.RunTracker) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.trackers[name] = t
+}
+
+// AddRunStore exposes the runs recorded in a workflow.RunStore. The runs section lists the
+// most recent runs of every store and accepts the query parameters flow, failed, limit and
+// offset; <prefix>/runs/<run-id> returns a single run with its steps.
+func (s *Server) AddRunStore(name string, store workflow.RunStore) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stores[name] = store
+}
+
+// Register adds a custom section, e.g. "sessions", "budgets", or "breakers".
+// Registering an existing section name replaces it.
+func (s *Server) Register(section string, fn InspectFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sections[section] = fn
+}
+
+// Snapshot returns the state of every registered section.
+func (s *Server) Snapshot(ctx context.Context) map[string]interface{} {
+	s.mu.RLock()
+	sections := make(map[string]InspectFunc, len(s.sections))
+	for name, fn := range s.sections {
+		sections[name] = fn
+	}
+	s.mu.RUnlock()
+
+	out := make(map[string]interface{}, len(sections))
+	for name, fn := range sections {
+		v, err := fn(ctx)
+		if err != nil {
+			out[name] = map[string]string{"error": err.Error()}
+			continue
+		}
+		out[name] = v
+	}
+	return out
+}
+
+// Handler returns an http.Handler serving the admin endpoints under prefix (e.g. "/admin").
+func (s *Server) Handler(prefix string) http.Handler {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+			return
+		}
+		r = r.WithContext(context.WithValue(r.Context(), requestKey{}, r))
+		section := strings.Trim(strings.TrimPrefix(r.URL.Path, prefix), "/")
+		if section == "" {
+			writeJSON(w, http.StatusOK, s.Snapshot(r.Context()))
+			return
+		}
+		if runID, ok := strings.CutPrefix(section, "runs/"); ok {
+			s.serveRun(w, r, runID)
+			return
+		}
+
+		s.mu.RLock()
+		fn, ok := s.sections[section]
+		s.mu.RUnlock()
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "unknown section: " + section})
+			return
+		}
+		v, err := fn(r.Context())
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, v)
+	})
+}
+
+// MetricsHandler returns an http.Handler serving the tool metrics of every added agent in the
+// Prometheus text format, labelled with the agent name.
+func (s *Server) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.RLock()
+		managers := make(map[string]*tools.Manager, len(s.agents))
+		for name, a := range s.agents {
+			managers[name] = a.Tools()
+		}
+		s.mu.RUnlock()
+		tools.PrometheusHandler(managers).ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) inspectModels(ctx context.Context) (interface{}, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	models := []ModelInfo{}
+	for _, clientName := range sortedKeys(s.clients) {
+		client := s.clients[clientName]
+		names := client.ListModels()
+		sort.Strings(names)
+		for _, name := range names {
+			m, err := client.GetModel(name)
+			if err != nil {
+				continue
+			}
+			info := ModelInfo{
+				Client:   clientName,
+				Name:     name,
+				Provider: m.GetProvider(),
+				Model:    m.GetModelName(),
+			}
+			if caps, ok := client.Capabilities(name); ok {
+				info.Capabilities = &caps
+			}
+			models = append(models, info)
+		}
+	}
+	return models, nil
+}
+
+func (s *Server) inspectTools(ctx context.Context) (interface{}, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	infos := []ToolInfo{}
+	for _, agentName := range sortedKeys(s.agents) {
+		manager := s.agents[agentName].Tools()
+		names := manager.ListTools()
+		sort.Strings(names)
+		metrics := manager.GetMetrics()
+		for _, name := range names {
+			t, err := manager.GetTool(name)
+			if err != nil {
+				continue
+			}
+			infos = append(infos, ToolInfo{
+				Agent:       agentName,
+				Name:        name,
+				Description: t.Description(),
+				Calls:       manager.GetCallCount(name),
+				Metrics:     metrics[name],
+			})
+		}
+	}
+	return infos, nil
+}
+
+func (s *Server) inspectFlows(ctx context.Context) (interface{}, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	runs := map[string][]workflow.RunInfo{}
+	for name, t := range s.trackers {
+		runs[name] = t.Active()
+	}
+	return runs, nil
+}
+
+func (s *Server) inspectRuns(ctx context.Context) (interface{}, error) {
+	var filter workflow.RunFilter
+	if r, ok := ctx.Value(requestKey{}).(*http.Request); ok {
+		q := r.URL.Query()
+		filter.Flow = q.Get("flow")
+		filter.OnlyFailed, _ = strconv.ParseBool(q.Get("failed"))
+		filter.Limit, _ = strconv.Atoi(q.Get("limit"))
+		filter.Offset, _ = strconv.Atoi(q.Get("offset"))
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	runs := map[string][]workflow.RunRecord{}
+	for name, store := range s.stores {
+		list, err := store.ListRuns(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		runs[name] = list
+	}
+	return runs, nil
+}
+
+// serveRun writes the run with the given ID from the first store that has it.
+func (s *Server) serveRun(w http.ResponseWriter, r *http.Request, runID string) {
+	s.mu.RLock()
+	stores := make([]workflow.RunStore, 0, len(s.stores))
+	for _, name := range sortedKeys(s.stores) {
+		stores = append(stores, s.stores[name])
+	}
+	s.mu.RUnlock()
+
+	for _, store := range stores {
+		rec, err := store.GetRun(r.Context(), runID)
+		if errors.Is(err, workflow.ErrRunNotFound) {
+			continue
+		}
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, rec)
+		return
+	}
+	writeJSON(w, http.StatusNotFound, map[string]string{"error": "unknown run: " + runID})
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,88 @@
+package admin_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/zakirkun/gatot-kaca/admin"
+	"github.com/zakirkun/gatot-kaca/llmtest"
+	"github.com/zakirkun/gatot-kaca/workflow"
+)
+
+// get requests path from h and decodes the JSON response into v.
+func get(t *testing.T, h http.Handler, path string, v any) int {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	if v != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+			t.Fatalf("GET %s: %v: %s", path, err, rec.Body)
+		}
+	}
+	return rec.Code
+}
+
+func TestHandler(t *testing.T) {
+	ctx := context.Background()
+	model := llmtest.NewMockModel("mock-model")
+	a := llmtest.NewAgent(model, llmtest.NewMockTool("search", "result"))
+	if _, err := a.Tools().ExecuteTool(ctx, "search", "query"); err != nil {
+		t.Fatal(err)
+	}
+
+	store := workflow.NewMemoryRunStore()
+	fail := &workflow.FuncNode{Process: func(ctx context.Context, input string) (string, error) {
+		if input == "bad" {
+			return "", errors.New("bad input")
+		}
+		return input, nil
+	}}
+	flow := &workflow.Flow{Name: "echo", Nodes: []workflow.Node{fail}, Store: store}
+	flow.Run(ctx, "good")
+	flow.Run(ctx, "bad")
+
+	s := admin.NewServer()
+	s.AddClient("default", llmtest.NewClient(model))
+	s.AddAgent("assistant", a)
+	s.AddRunStore("memory", store)
+	s.Register("sessions", func(ctx context.Context) (interface{}, error) { return []string{"s1"}, nil })
+	h := s.Handler("/admin")
+
+	var models []admin.ModelInfo
+	if get(t, h, "/admin/models", &models); len(models) != 1 || models[0].Model != "mock-model" {
+		t.Errorf("models = %+v", models)
+	}
+	var tools []admin.ToolInfo
+	if get(t, h, "/admin/tools", &tools); len(tools) != 1 || tools[0].Name != "search" || tools[0].Calls != 1 {
+		t.Errorf("tools = %+v", tools)
+	}
+
+	var runs map[string][]workflow.RunRecord
+	get(t, h, "/admin/runs?failed=true", &runs)
+	if len(runs["memory"]) != 1 || runs["memory"][0].Input != "bad" {
+		t.Fatalf("failed runs = %+v", runs)
+	}
+	var run workflow.RunRecord
+	if code := get(t, h, "/admin/runs/"+runs["memory"][0].RunID, &run); code != http.StatusOK ||
+		!strings.Contains(run.Error, "bad input") || len(run.Steps) != 1 {
+		t.Errorf("run = %d %+v", code, run)
+	}
+	if code := get(t, h, "/admin/runs/unknown", nil); code != http.StatusNotFound {
+		t.Errorf("unknown run: status %d, want 404", code)
+	}
+
+	var all map[string]json.RawMessage
+	get(t, h, "/admin", &all)
+	for _, section := range []string{"models", "tools", "flows", "runs", "sessions"} {
+		if _, ok := all[section]; !ok {
+			t.Errorf("snapshot is missing section %q", section)
+		}
+	}
+	if code := get(t, h, "/admin/unknown", nil); code != http.StatusNotFound {
+		t.Errorf("unknown section: status %d, want 404", code)
+	}
+}
@@ -2,19 +2,30 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/zakirkun/gatot-kaca/agent/tools"
+	"github.com/zakirkun/gatot-kaca/budget"
 	"github.com/zakirkun/gatot-kaca/llm"
+	"github.com/zakirkun/gatot-kaca/logging"
+	"github.com/zakirkun/gatot-kaca/prompt"
+	"github.com/zakirkun/gatot-kaca/tokenizer"
 )
 
 // ConversationMessage holds the details of each message in the conversation.
 type ConversationMessage struct {
-	Role    string
-	Content string
-	// (Optional) Timestamp string or any other metadata can be added here.
+	Role      string    `json:"role"`
+	Content   string    `json:"content"`
+	Timestamp time.Time `json:"timestamp"`
+	// TokenCount is the number of tokens in Content for the agent's model. For assistant
+	// responses it is the completion token count reported by the provider, when available.
+	TokenCount int `json:"token_count,omitempty"`
+	// Metadata holds arbitrary per-message data such as evaluation annotations.
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
 }
 
 // Middleware defines an interface to pre- and post-process conversation messages.
@@ -36,12 +47,39 @@ type Agent struct {
 	TopP         float64
 	tools        *tools.Manager
 	systemPrompt string
-	middlewares  []Middleware
+	middlewares  []RequestMiddleware
+	memory       Memory
+
+	// StructuredRetries is the number of retries SendStructured makes when the model's
+	// response does not match the schema; defaults to 2.
+	StructuredRetries int
+
+	systemTemplate   *prompt.Template
+	systemVars       map[string]any
+	promptVersion    string
+	maxParallelTools int
+	toolCatalog      *prompt.Template
+	noTruncation     bool // Return context window errors instead of dropping old messages.
+	toolStrategy     ToolStrategy
+	maxToolSteps     int
+	promptCache      bool // Send the system prompt separately for provider prompt caching.
+	chatMessages     bool // Send the history as separate turns instead of one prompt text.
+	branch           string
+	branches         []*branch // Created on first use; see Fork.
+	metadata         map[string]interface{}
+	titleOptions     *TitleOptions
+	quota            *quotaState
+	logger           logging.Logger
+	hooks            []llm.Hooks        // Passed to the model calls of every request; see WithHooks.
+	retry            *llm.RetryPolicy   // Overrides the client's policy; see WithRetryPolicy.
+	toolHandlers     []ToolEventHandler // Receive the tool events of every request; see WithToolEvents.
+	budget           budget.RunBudget
 }
 
-// NewAgent creates a new Agent instance and initializes its tools manager.
-func NewAgent(client *llm.Client, modelName string) *Agent {
-	return &Agent{
+// NewAgent creates a new Agent instance, initializes its tools manager, and applies the given
+// options on top of the defaults.
+func NewAgent(client *llm.Client, modelName string, opts ...Option) *Agent {
+	a := &Agent{
 		client:      client,
 		modelName:   modelName,
 		history:     []ConversationMessage{},
@@ -49,7 +87,56 @@ func NewAgent(client *llm.Client, modelName string) *Agent {
 		MaxTokens:   150, // Default value; adjust as needed.
 		TopP:        0.9, // Default value; adjust as needed.
 		tools:       tools.NewManager(),
+		memory:      BufferMemory{},
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// Clone returns an independent copy of the agent that shares its client and tool manager but
+// has its own copy of the history and settings, so changes to one do not affect the other.
+// Cloning is cheap, which makes a configured agent a template for per-request agents; its
+// Clone method can be used directly as a factory, e.g. server.Register("assistant", a.Clone).
+// Clone only reads the agent, so concurrent calls are safe while the template is not modified.
+func (a *Agent) Clone() *Agent {
+	c := *a
+	c.history = cloneHistory(a.history)
+	c.branches = cloneBranches(a.branches)
+	if a.quota != nil {
+		// Quotas are per session, so the copy starts with nothing used.
+		c.quota = &quotaState{limits: a.quota.limits}
+	}
+	if a.metadata != nil {
+		c.metadata = make(map[string]interface{}, len(a.metadata))
+		for k, v := range a.metadata {
+			c.metadata[k] = v
+		}
+	}
+	c.middlewares = append([]RequestMiddleware(nil), a.middlewares...)
+	if a.systemVars != nil {
+		c.systemVars = make(map[string]any, len(a.systemVars))
+		for k, v := range a.systemVars {
+			c.systemVars[k] = v
+		}
+	}
+	return &c
+}
+
+// cloneHistory copies messages together with their metadata maps.
+func cloneHistory(history []ConversationMessage) []ConversationMessage {
+	out := make([]ConversationMessage, len(history))
+	for i, msg := range history {
+		out[i] = msg
+		if msg.Metadata != nil {
+			out[i].Metadata = make(map[string]interface{}, len(msg.Metadata))
+			for k, v := range msg.Metadata {
+				out[i].Metadata[k] = v
+			}
+		}
 	}
+	return out
 }
 
 // SetSystemPrompt sets a system-level instruction that will be prepended to every conversation.
@@ -57,77 +144,231 @@ func (a *Agent) SetSystemPrompt(prompt string) {
 	a.systemPrompt = prompt
 }
 
-// RegisterMiddleware registers a middleware to allow pre- and post-processing of conversation messages.
+// SetSystemTemplate sets a system prompt template that is rendered on every request, replacing
+// the static system prompt. Besides vars, the template data holds "History" (the messages
+// selected by the memory strategy) and "Tools" (the agent's tool manager), so a template can
+// use {{history .History}} and {{tools .Tools}}.
+func (a *Agent) SetSystemTemplate(t *prompt.Template, vars map[string]any) {
+	a.systemTemplate = t
+	a.systemVars = vars
+	a.promptVersion = ""
+}
+
+// UsePrompt loads the prompt identified by ref ("name", "name@latest" or "name@3") from the store
+// and uses it as the system prompt template. The resolved version is recorded on every response
+// under PromptVersionKey.
+func (a *Agent) UsePrompt(store prompt.Store, ref string, vars map[string]any) error {
+	t, v, err := prompt.Load(store, ref)
+	if err != nil {
+		return err
+	}
+	a.SetSystemTemplate(t, vars)
+	a.promptVersion = v.Ref()
+	return nil
+}
+
+// ModelName returns the name of the model the agent sends requests to.
+func (a *Agent) ModelName() string {
+	return a.modelName
+}
+
+// Client returns the LLM client of the agent, which is shared with its clones.
+func (a *Agent) Client() *llm.Client {
+	return a.client
+}
+
+// PromptVersion returns the "name@version" reference of the stored prompt in use, if any.
+func (a *Agent) PromptVersion() string {
+	return a.promptVersion
+}
+
+// renderSystemPrompt returns the system prompt for the given history. If the template fails to
+// render, the error is logged and the static system prompt is used instead.
+func (a *Agent) renderSystemPrompt(history []ConversationMessage) string {
+	if a.systemTemplate == nil {
+		return a.systemPrompt
+	}
+	data := make(map[string]any, len(a.systemVars)+2)
+	for k, v := range a.systemVars {
+		data[k] = v
+	}
+	data["History"] = history
+	data["Tools"] = a.tools
+	text, err := a.systemTemplate.Render(data)
+	if err != nil {
+		a.logFor(context.Background()).Warn("failed to render system prompt", logging.KeyComponent, "agent", logging.KeyError, err)
+		return a.systemPrompt
+	}
+	return text
+}
+
+// RegisterMiddleware registers a middleware to allow pre- and post-processing of conversation
+// messages. If m also implements RequestMiddleware, it is called through that interface.
 func (a *Agent) RegisterMiddleware(m Middleware) {
-	a.middlewares = append(a.middlewares, m)
+	a.middlewares = append(a.middlewares, asRequestMiddleware(m))
 }
 
 // AppendMessage adds a new message to the conversation history.
 func (a *Agent) AppendMessage(role, content string) {
 	a.history = append(a.history, ConversationMessage{
-		Role:    role,
-		Content: content,
+		Role:       role,
+		Content:    content,
+		Timestamp:  time.Now(),
+		TokenCount: tokenizer.CountTokens(a.modelName, content),
 	})
 }
 
 // BuildPrompt constructs a prompt from the conversation history,
 // including the system prompt (if set) and applying any registered middleware.
 func (a *Agent) BuildPrompt(ctx context.Context) string {
+	return a.PreviewPrompt(ctx, a.history)
+}
+
+// PreviewPrompt returns the prompt that would be sent for the given conversation history, with
+// the system prompt, memory strategy and middleware applied, without sending it or changing the
+// agent's history.
+func (a *Agent) PreviewPrompt(ctx context.Context, history []ConversationMessage) string {
+	return formatPrompt(a.previewMessages(ctx, history))
+}
+
+// previewMessages returns the messages PreviewPrompt formats.
+func (a *Agent) previewMessages(ctx context.Context, history []ConversationMessage) []ConversationMessage {
 	var modHistory []ConversationMessage
-	// Prepend the system prompt if present.
-	if a.systemPrompt != "" {
-		modHistory = append(modHistory, ConversationMessage{Role: "System", Content: a.systemPrompt})
+	// Append the conversation history selected by the memory strategy.
+	if a.memory != nil {
+		history = a.memory.Messages(history)
 	}
 
-	// Append conversation history.
-	modHistory = append(modHistory, a.history...)
+	// Prepend the system prompt and tool catalog if present.
+	system := a.renderSystemPrompt(history)
+	catalog := a.renderToolCatalog()
+	if a.ToolStrategy() == ToolStrategyPrompt {
+		catalog = a.renderReActInstructions()
+	}
+	if catalog != "" {
+		if system != "" {
+			system += "\n\n"
+		}
+		system += catalog
+	}
+	if system != "" {
+		modHistory = append(modHistory, ConversationMessage{Role: "System", Content: system})
+	}
+	modHistory = append(modHistory, history...)
 
 	// Allow middleware to process/modify the conversation before sending.
-	for _, m := range a.middlewares {
-		modHistory = m.ProcessBeforeSend(ctx, modHistory)
-	}
+	return a.beforeSend(ctx, modHistory)
+}
 
+// formatPrompt renders messages as the prompt text sent to the model.
+func formatPrompt(messages []ConversationMessage) string {
 	var builder strings.Builder
-	for _, msg := range modHistory {
+	for _, msg := range messages {
 		builder.WriteString(msg.Role + ": " + msg.Content + "\n")
 	}
 	return builder.String()
 }
 
+// setPrompt sets the prompt of req from the given history. With prompt caching, a leading system
+// message is sent as req.System instead, so the provider can cache it across requests. With chat
+// messages, the rest of the history is sent as req.Messages.
+func (a *Agent) setPrompt(ctx context.Context, req *llm.ModelRequest, history []ConversationMessage) {
+	messages := a.previewMessages(ctx, history)
+	if a.chatMessages {
+		if len(messages) > 0 && messages[0].Role == "System" {
+			req.System = messages[0].Content
+			req.CacheSystem = a.promptCache
+			messages = messages[1:]
+		}
+		req.Prompt = ""
+		req.Messages = nil
+		for _, msg := range messages {
+			switch msg.Role {
+			case "User":
+				req.Messages = append(req.Messages, llm.Message{Role: llm.RoleUser, Content: msg.Content})
+			case "Assistant":
+				req.Messages = append(req.Messages, llm.Message{Role: llm.RoleAssistant, Content: msg.Content})
+			default:
+				// Tool responses and other roles are shown to the model as user turns.
+				req.Messages = append(req.Messages, llm.Message{Role: llm.RoleUser, Content: msg.Role + ": " + msg.Content})
+			}
+		}
+		return
+	}
+	if a.promptCache && len(messages) > 0 && messages[0].Role == "System" {
+		req.System = messages[0].Content
+		req.CacheSystem = true
+		messages = messages[1:]
+	}
+	req.Prompt = formatPrompt(messages)
+}
+
 // Send sends a user message to the agent, retrieves the LLM response, applies middleware,
 // processes tool commands and updates the conversation history.
 func (a *Agent) Send(ctx context.Context, userInput string) (string, error) {
+	ctx, err := a.startRequest(ctx, userInput)
+	if err != nil {
+		return "", err
+	}
+	ctx, run := budget.Start(ctx, a.budget)
+	defer run.Stop()
 	// Append the user's message.
 	a.AppendMessage("User", userInput)
+	start := len(a.history)
 
-	// Construct the prompt including system prompt and middleware modifications.
-	prompt := a.BuildPrompt(ctx)
+	// Construct the prompt including system prompt and middleware modifications,
+	// and get the response from the LLM client.
+	res, err := a.generate(ctx, nil)
+	if err != nil {
+		return "", run.Err(err, a.partialResponse(start))
+	}
+
+	output, err := a.handleResponse(ctx, res, nil)
+	return output, run.Err(err, a.partialResponse(start))
+}
+
+// partialResponse returns the latest response recorded since the history index from, reported
+// as the partial result of a request that ran out of budget.
+func (a *Agent) partialResponse(from int) string {
+	for i := len(a.history) - 1; i >= from && i >= 0; i-- {
+		if a.history[i].Role == "Assistant" {
+			return a.history[i].Content
+		}
+	}
+	return ""
+}
 
-	// Create the model request using the agent's default parameters.
+// newRequest creates the model request for the current history using the agent's default
+// parameters.
+func (a *Agent) newRequest(ctx context.Context) llm.ModelRequest {
 	req := llm.ModelRequest{
-		Prompt:      prompt,
 		Temperature: a.Temperature,
 		MaxTokens:   a.MaxTokens,
 		TopP:        a.TopP,
 	}
+	a.setPrompt(ctx, &req, a.history)
+	return req
+}
 
-	// Get the response from the LLM client.
-	res, err := a.client.Generate(ctx, a.modelName, req)
+// handleResponse applies middleware post-processing to the LLM response, records it in the
+// history, and runs any embedded tool command. With ToolStrategyPrompt, tool calls in the ReAct
+// format are run until the model gives its final answer, streaming follow-up responses to
+// onChunk if it is not nil.
+func (a *Agent) handleResponse(ctx context.Context, res llm.ModelResponse, onChunk llm.StreamHandler) (string, error) {
+	responseText, err := a.recordResponse(ctx, res)
 	if err != nil {
 		return "", err
 	}
-
-	// Allow middleware to post-process the LLM response.
-	responseText := res.Text
-	for _, m := range a.middlewares {
-		responseText = m.ProcessAfterReceive(ctx, responseText)
+	if a.ToolStrategy() == ToolStrategyPrompt && len(a.tools.ListTools()) > 0 {
+		return a.runReAct(ctx, responseText, onChunk)
 	}
 
-	// Append the assistant's response to the history.
-	a.AppendMessage("Assistant", responseText)
-
 	// Check if the response includes an embedded tool command.
-	if toolOutput, err := a.processToolCommand(ctx, responseText); err == nil && toolOutput != "" {
+	toolOutput, err := a.processToolCommand(ctx, responseText)
+	if errors.Is(err, budget.ErrBudgetExceeded) {
+		return "", err
+	}
+	if err == nil && toolOutput != "" {
 		// Append the tool output automatically.
 		a.AppendMessage("Tool Response", toolOutput)
 		// Return the combined output (initial response + tool output).
@@ -137,9 +378,40 @@ func (a *Agent) Send(ctx context.Context, userInput string) (string, error) {
 	return responseText, nil
 }
 
-// Reset clears the conversation history in the agent.
+// recordResponse applies middleware post-processing to the LLM response and appends it to the
+// history, returning the processed text.
+func (a *Agent) recordResponse(ctx context.Context, res llm.ModelResponse) (string, error) {
+	// Allow middleware to post-process the LLM response.
+	responseText, halted, err := a.afterReceive(ctx, res)
+	if err != nil {
+		return "", err
+	}
+
+	// Append the assistant's response to the history.
+	a.AppendMessage("Assistant", responseText)
+	msg := &a.history[len(a.history)-1]
+	msg.SetMetadata(ModelKey, a.modelName)
+	if halted {
+		msg.SetMetadata(HaltedKey, true)
+	}
+	if a.quota != nil {
+		a.quota.addTokens(time.Now(), res.Usage.TotalTokens)
+	}
+	if responseText == res.Text && res.Usage.CompletionTokens > 0 {
+		msg.TokenCount = res.Usage.CompletionTokens
+	}
+	if a.promptVersion != "" {
+		msg.SetMetadata(PromptVersionKey, a.promptVersion)
+	}
+	a.autoTitle(ctx)
+	return responseText, nil
+}
+
+// Reset clears the conversation history in the agent, together with its title and tags.
 func (a *Agent) Reset() {
 	a.history = []ConversationMessage{}
+	delete(a.metadata, TitleKey)
+	delete(a.metadata, TagsKey)
 }
 
 // RegisterTool registers a new tool with the agent.
@@ -147,25 +419,25 @@ func (a *Agent) RegisterTool(tool tools.Tool) {
 	a.tools.RegisterTool(tool)
 }
 
+// Tools returns the manager holding the tools registered with the agent.
+func (a *Agent) Tools() *tools.Manager {
+	return a.tools
+}
+
 // CallTool executes a registered tool by name with the provided input.
 // It appends both the tool invocation and its response to the conversation history.
+// The output is limited by the tool's output policy (see tools.Manager.SetOutputPolicy).
 func (a *Agent) CallTool(ctx context.Context, toolName, input string) (string, error) {
-	tool, err := a.tools.GetTool(toolName)
-	if err != nil {
+	if _, err := a.tools.GetTool(toolName); err != nil {
 		return "", err
 	}
 
-	// Record the tool invocation.
-	a.AppendMessage("Tool Call ("+toolName+")", input)
-
-	// Execute the tool.
-	result, err := tool.Execute(ctx, input)
+	// Execute the tool and record the invocation and its response.
+	result, err := a.executeTool(ctx, toolName, input)
+	a.recordToolCall(toolName, input, result, err)
 	if err != nil {
 		return "", err
 	}
-
-	// Record the tool's response.
-	a.AppendMessage("Tool Response ("+toolName+")", result)
 	return result, nil
 }
 
@@ -185,3 +457,11 @@ func (a *Agent) processToolCommand(ctx context.Context, response string) (string
 	toolInput := matches[2]
 	return a.CallTool(ctx, toolName, toolInput)
 }
+
+// logFor returns the agent's logger, or that of ctx if it has none.
+func (a *Agent) logFor(ctx context.Context) logging.Logger {
+	if a.logger != nil {
+		return a.logger
+	}
+	return logging.FromContext(ctx)
+}
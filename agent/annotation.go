@@ -0,0 +1,44 @@
+package agent
+
+import (
+	"fmt"
+	"time"
+)
+
+// AnnotationsKey is the message metadata key under which evaluation annotations are stored.
+const AnnotationsKey = "annotations"
+
+// Annotation is an evaluation label attached to a single message in the conversation history.
+type Annotation struct {
+	Evaluator string    `json:"evaluator"`           // Name of the evaluator that produced the label.
+	Score     float64   `json:"score"`               // Normalized score between 0 and 1.
+	Rationale string    `json:"rationale,omitempty"` // Optional explanation of the score.
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// History returns a copy of the conversation history.
+func (a *Agent) History() []ConversationMessage {
+	history := make([]ConversationMessage, len(a.history))
+	copy(history, a.history)
+	return history
+}
+
+// Annotate attaches an evaluation annotation to the message at the given history index.
+// Annotations are stored in the message metadata so that exported transcripts carry them.
+func (a *Agent) Annotate(index int, ann Annotation) error {
+	if index < 0 || index >= len(a.history) {
+		return fmt.Errorf("annotate: message index %d out of range", index)
+	}
+	if ann.CreatedAt.IsZero() {
+		ann.CreatedAt = time.Now()
+	}
+	msg := &a.history[index]
+	msg.SetMetadata(AnnotationsKey, append(msg.Annotations(), ann))
+	return nil
+}
+
+// Annotations returns the evaluation annotations attached to a message.
+func (m ConversationMessage) Annotations() []Annotation {
+	anns, _ := m.Metadata[AnnotationsKey].([]Annotation)
+	return anns
+}
@@ -0,0 +1,199 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/zakirkun/gatot-kaca/agent/tools"
+	"github.com/zakirkun/gatot-kaca/llm"
+)
+
+// DefaultBranch is the name of the branch an agent starts on.
+const DefaultBranch = "main"
+
+// BranchKey is the message metadata key recording the branch a merge summary came from.
+const BranchKey = "branch"
+
+// BranchInfo describes a conversation branch.
+type BranchInfo struct {
+	Name string
+	// Parent is the branch the branch was forked from, and ForkPoint the number of messages
+	// it shared with the parent at that time. Both are empty for DefaultBranch.
+	Parent    string
+	ForkPoint int
+	Messages  int // Number of messages in the branch's history.
+	Current   bool
+	CreatedAt time.Time
+}
+
+// branch holds the history of a branch that is not checked out; the current branch's history
+// lives in Agent.history.
+type branch struct {
+	info    BranchInfo
+	history []ConversationMessage
+}
+
+// currentBranch returns the name of the checked-out branch.
+func (a *Agent) currentBranch() string {
+	if a.branch == "" {
+		return DefaultBranch
+	}
+	return a.branch
+}
+
+// findBranch returns the branch with the given name, creating the default branch entry on
+// first use.
+func (a *Agent) findBranch(name string) *branch {
+	if len(a.branches) == 0 {
+		a.branches = []*branch{{info: BranchInfo{Name: DefaultBranch, CreatedAt: time.Now()}}}
+	}
+	for _, b := range a.branches {
+		if b.info.Name == name {
+			return b
+		}
+	}
+	return nil
+}
+
+// CurrentBranch returns the name of the branch the agent is on.
+func (a *Agent) CurrentBranch() string {
+	return a.currentBranch()
+}
+
+// Fork snapshots the current history into a new branch and switches to it, leaving the branch
+// it was forked from unchanged, so an alternative continuation of the conversation can be
+// explored and abandoned with SwitchBranch. An empty name picks "branch-N".
+func (a *Agent) Fork(name string) (string, error) {
+	parent := a.currentBranch()
+	parentBranch := a.findBranch(parent)
+	if name == "" {
+		for i := len(a.branches); ; i++ {
+			name = fmt.Sprintf("branch-%d", i)
+			if a.findBranch(name) == nil {
+				break
+			}
+		}
+	} else if a.findBranch(name) != nil {
+		return "", fmt.Errorf("fork: branch %q already exists", name)
+	}
+
+	a.branches = append(a.branches, &branch{info: BranchInfo{
+		Name:      name,
+		Parent:    parent,
+		ForkPoint: len(a.history),
+		CreatedAt: time.Now(),
+	}})
+	parentBranch.history = a.history
+	a.history = cloneHistory(a.history)
+	a.branch = name
+	return name, nil
+}
+
+// Branches lists the agent's branches in the order they were created.
+func (a *Agent) Branches() []BranchInfo {
+	current := a.currentBranch()
+	a.findBranch(current)
+	out := make([]BranchInfo, len(a.branches))
+	for i, b := range a.branches {
+		out[i] = b.info
+		out[i].Messages = len(b.history)
+		if b.info.Name == current {
+			out[i].Messages = len(a.history)
+			out[i].Current = true
+		}
+	}
+	return out
+}
+
+// SwitchBranch checks out the named branch, keeping the current branch's history so it can be
+// switched back to later.
+func (a *Agent) SwitchBranch(name string) error {
+	current := a.currentBranch()
+	if name == current {
+		return nil
+	}
+	target := a.findBranch(name)
+	if target == nil {
+		return fmt.Errorf("switch branch: branch %q not found", name)
+	}
+	a.findBranch(current).history = a.history
+	a.history = target.history
+	if a.history == nil {
+		a.history = []ConversationMessage{}
+	}
+	target.history = nil
+	a.branch = name
+	return nil
+}
+
+// DeleteBranch removes a branch. The current branch cannot be deleted.
+func (a *Agent) DeleteBranch(name string) error {
+	if name == a.currentBranch() {
+		return fmt.Errorf("delete branch: %q is the current branch", name)
+	}
+	if a.findBranch(name) == nil {
+		return fmt.Errorf("delete branch: branch %q not found", name)
+	}
+	for i, b := range a.branches {
+		if b.info.Name == name {
+			a.branches = append(a.branches[:i], a.branches[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// MergeBranch summarizes what happened on the named branch since it was forked and appends the
+// summary to the current branch as a system message, so the conversation can continue with
+// the conclusions of an explored alternative without its full transcript. The merged branch
+// is left in place; the summary is returned.
+func (a *Agent) MergeBranch(ctx context.Context, name string) (string, error) {
+	if name == a.currentBranch() {
+		return "", fmt.Errorf("merge branch: cannot merge %q into itself", name)
+	}
+	b := a.findBranch(name)
+	if b == nil {
+		return "", fmt.Errorf("merge branch: branch %q not found", name)
+	}
+	messages := b.history
+	if b.info.Parent == a.currentBranch() && b.info.ForkPoint <= len(messages) {
+		messages = messages[b.info.ForkPoint:]
+	}
+	if len(messages) == 0 {
+		return "", fmt.Errorf("merge branch: branch %q has no new messages", name)
+	}
+
+	summary, err := a.summarizeBranch(ctx, name, messages)
+	if err != nil {
+		return "", fmt.Errorf("merge branch: %w", err)
+	}
+	a.AppendMessage("System", fmt.Sprintf("Summary of branch %q: %s", name, summary))
+	a.setMetadata(len(a.history)-1, BranchKey, name)
+	return summary, nil
+}
+
+// summarizeBranch summarizes a branch's messages using the agent's model.
+func (a *Agent) summarizeBranch(ctx context.Context, name string, messages []ConversationMessage) (string, error) {
+	prompt := fmt.Sprintf("Summarize the following alternative branch %q of a conversation in a few sentences, "+
+		"keeping its conclusions, decisions and any facts that were established.\n\nConversation:\n%s",
+		name, tools.Truncate(formatPrompt(messages), summarizeInputLimit))
+	res, err := a.client.Generate(ctx, a.modelName, llm.ModelRequest{Prompt: prompt, Temperature: 0})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(res.Text), nil
+}
+
+// cloneBranches copies branches together with their histories.
+func cloneBranches(branches []*branch) []*branch {
+	if branches == nil {
+		return nil
+	}
+	out := make([]*branch, len(branches))
+	for i, b := range branches {
+		out[i] = &branch{info: b.info, history: cloneHistory(b.history)}
+	}
+	return out
+}
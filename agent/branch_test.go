@@ -0,0 +1,59 @@
+package agent_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/zakirkun/gatot-kaca/agent"
+	"github.com/zakirkun/gatot-kaca/llmtest"
+)
+
+func TestForkAndMerge(t *testing.T) {
+	model := llmtest.NewMockModel("branch-test", "Hi!", "Try Rust.", "Rust is too slow to learn.")
+	a := llmtest.NewAgent(model)
+	ctx := context.Background()
+
+	if _, err := a.Send(ctx, "Hello"); err != nil {
+		t.Fatal(err)
+	}
+	name, err := a.Fork("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "branch-1" || a.CurrentBranch() != name {
+		t.Fatalf("forked to %q, current %q", name, a.CurrentBranch())
+	}
+	if _, err := a.Send(ctx, "Which language should I learn?"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Fork(name); err == nil {
+		t.Fatal("expected an error forking to an existing branch")
+	}
+
+	if err := a.SwitchBranch(agent.DefaultBranch); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(a.History()); got != 2 {
+		t.Fatalf("main has %d messages, want 2", got)
+	}
+	branches := a.Branches()
+	if len(branches) != 2 || !branches[0].Current || branches[1].Messages != 4 || branches[1].ForkPoint != 2 {
+		t.Fatalf("unexpected branches %+v", branches)
+	}
+
+	summary, err := a.MergeBranch(ctx, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	last, _ := a.LastMessage()
+	if last.Role != "System" || !strings.Contains(last.Content, summary) || last.MetadataString(agent.BranchKey) != name {
+		t.Fatalf("unexpected merge message %+v", last)
+	}
+	if err := a.DeleteBranch(agent.DefaultBranch); err == nil {
+		t.Fatal("expected an error deleting the current branch")
+	}
+	if err := a.DeleteBranch(name); err != nil || len(a.Branches()) != 1 {
+		t.Fatalf("delete: %v, %d branches left", err, len(a.Branches()))
+	}
+}
@@ -0,0 +1,64 @@
+package agent_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zakirkun/gatot-kaca/agent"
+	"github.com/zakirkun/gatot-kaca/budget"
+	"github.com/zakirkun/gatot-kaca/llm"
+	"github.com/zakirkun/gatot-kaca/llmtest"
+)
+
+func TestRunBudgetLLMCalls(t *testing.T) {
+	llm.RegisterCapabilities("budget-test", llm.Capabilities{ContextWindow: 8000})
+	model := llmtest.NewMockModel("budget-test",
+		"Thought: I should search.\nAction: search\nAction Input: {\"input\": \"capital of France\"}",
+		"Thought: I have it.\nFinal Answer: Paris",
+	)
+	search := llmtest.NewMockTool("search", "Paris is the capital of France.")
+	a := llmtest.NewAgent(model, search)
+	agent.WithRunBudget(budget.RunBudget{MaxLLMCalls: 1})(a)
+
+	_, err := a.Send(context.Background(), "What is the capital of France?")
+	var exceeded *budget.BudgetExceeded
+	if !errors.As(err, &exceeded) || !errors.Is(err, budget.ErrBudgetExceeded) {
+		t.Fatalf("got %v, want a budget error", err)
+	}
+	if exceeded.Limit != budget.LimitLLMCalls || exceeded.Usage.LLMCalls != 1 || exceeded.Usage.ToolCalls != 1 {
+		t.Errorf("budget error = %+v", exceeded)
+	}
+	if !strings.Contains(exceeded.Partial, "Action: search") {
+		t.Errorf("partial = %q, want the first response", exceeded.Partial)
+	}
+	model.AssertCalled(t, 1)
+}
+
+func TestRunBudgetToolCalls(t *testing.T) {
+	model := llmtest.NewMockModel("budget-test").Default("hello")
+	a := llmtest.NewAgent(model, llmtest.NewMockTool("search", "result"))
+
+	ctx, run := budget.Start(context.Background(), budget.RunBudget{MaxToolCalls: 1})
+	defer run.Stop()
+	if _, err := a.CallTool(ctx, "search", "first"); err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+	var exceeded *budget.BudgetExceeded
+	if _, err := a.CallTool(ctx, "search", "second"); !errors.As(err, &exceeded) || exceeded.Limit != budget.LimitToolCalls {
+		t.Fatalf("second call: got %v, want a tool calls budget error", err)
+	}
+}
+
+func TestRunBudgetWallTime(t *testing.T) {
+	model := llmtest.NewMockModel("budget-test").Default("hello").WithLatency(time.Second)
+	a := llmtest.NewAgent(model)
+	agent.WithRunBudget(budget.RunBudget{MaxDuration: 20 * time.Millisecond})(a)
+
+	var exceeded *budget.BudgetExceeded
+	if _, err := a.Send(context.Background(), "hi"); !errors.As(err, &exceeded) || exceeded.Limit != budget.LimitWallTime {
+		t.Fatalf("got %v, want a wall time budget error", err)
+	}
+}
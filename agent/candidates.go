@@ -0,0 +1,54 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/zakirkun/gatot-kaca/llm"
+)
+
+// SendN sends a user message and asks the model for n alternative responses in one request
+// (OpenAI n, Gemini candidateCount; other providers are called n times by the client). Each
+// candidate is post-processed by the middleware. Only the first candidate is recorded in the
+// history, and tool commands in the responses are not run.
+func (a *Agent) SendN(ctx context.Context, userInput string, n int) ([]string, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("candidate count must be at least 1, got %d", n)
+	}
+	ctx, err := a.startRequest(ctx, userInput)
+	if err != nil {
+		return nil, err
+	}
+	a.AppendMessage("User", userInput)
+
+	req := a.newRequest(ctx)
+	req.N = n
+	res, err := a.generateRequest(ctx, req, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := res.Candidates
+	if len(candidates) == 0 {
+		candidates = []llm.Candidate{{Text: res.Text, FinishType: res.FinishType}}
+	}
+	out := make([]string, len(candidates))
+	for i, c := range candidates {
+		if i == 0 {
+			first := res
+			first.Text = c.Text
+			if len(candidates) > 1 {
+				// The usage covers every candidate, so count the first one's tokens instead.
+				first.Usage = llm.Usage{}
+			}
+			if out[i], err = a.recordResponse(ctx, first); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if out[i], _, err = a.afterReceive(ctx, llm.ModelResponse{Text: c.Text, FinishType: c.FinishType}); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,38 @@
+package agent
+
+import "github.com/zakirkun/gatot-kaca/llm"
+
+// ToolStrategy is the way the agent lets the model call tools.
+type ToolStrategy string
+
+const (
+	// ToolStrategyAuto picks ToolStrategyNative or ToolStrategyPrompt from the model's
+	// capabilities in the llm registry.
+	ToolStrategyAuto ToolStrategy = ""
+	// ToolStrategyNative is used for models that support function calling and reliably follow
+	// the single-line tool command format.
+	ToolStrategyNative ToolStrategy = "native"
+	// ToolStrategyPrompt is used for models without function calling.
+	ToolStrategyPrompt ToolStrategy = "prompt"
+)
+
+// Capabilities returns the capabilities of the agent's model from the llm registry. The second
+// value is false if the model is unknown.
+func (a *Agent) Capabilities() (llm.Capabilities, bool) {
+	if a.client == nil {
+		return llm.LookupCapabilities(a.modelName)
+	}
+	return a.client.Capabilities(a.modelName)
+}
+
+// ToolStrategy returns the tool calling strategy in use. Unless set with WithToolStrategy, it is
+// ToolStrategyPrompt for models known to lack function calling and ToolStrategyNative otherwise.
+func (a *Agent) ToolStrategy() ToolStrategy {
+	if a.toolStrategy != ToolStrategyAuto {
+		return a.toolStrategy
+	}
+	if caps, ok := a.Capabilities(); ok && !caps.Tools {
+		return ToolStrategyPrompt
+	}
+	return ToolStrategyNative
+}
@@ -0,0 +1,45 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/zakirkun/gatot-kaca/logging"
+	"github.com/zakirkun/gatot-kaca/prompt"
+)
+
+// DefaultToolCatalog is the template used to describe the registered tools in the system prompt
+// when the tool catalog is enabled without a custom template. The template data holds "Tools",
+// the agent's tool manager.
+var DefaultToolCatalog = prompt.Must("tool_catalog", `You can use the following tools:
+{{catalog .Tools}}
+To use a tool, reply with a single line in the format:
+CALL TOOL: <tool-name> <input>`)
+
+// EnableToolCatalog appends a description of the registered tools to the system prompt of every
+// request, rendered with t or DefaultToolCatalog if t is nil. Nothing is added while no tools
+// are registered.
+func (a *Agent) EnableToolCatalog(t *prompt.Template) {
+	if t == nil {
+		t = DefaultToolCatalog
+	}
+	a.toolCatalog = t
+}
+
+// DisableToolCatalog stops adding the tool catalog to the system prompt.
+func (a *Agent) DisableToolCatalog() {
+	a.toolCatalog = nil
+}
+
+// renderToolCatalog returns the tool catalog, or "" if it is disabled, no tools are registered,
+// or the template fails to render, in which case the error is logged.
+func (a *Agent) renderToolCatalog() string {
+	if a.toolCatalog == nil || len(a.tools.ListTools()) == 0 {
+		return ""
+	}
+	text, err := a.toolCatalog.Render(map[string]any{"Tools": a.tools})
+	if err != nil {
+		a.logFor(context.Background()).Warn("failed to render tool catalog", logging.KeyComponent, "agent", logging.KeyError, err)
+		return ""
+	}
+	return text
+}
@@ -0,0 +1,212 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/zakirkun/gatot-kaca/llm"
+	"github.com/zakirkun/gatot-kaca/logging"
+)
+
+// DefaultEntityKeepMessages is the number of latest messages an EntityMemory sends alongside
+// the rendered facts when KeepMessages is zero.
+const DefaultEntityKeepMessages = 4
+
+// EntityMemory keeps a structured store of entities and their facts, such as people, places and
+// projects mentioned in the conversation. After every turn the model extracts new or changed
+// facts from the exchange; before every request the facts are rendered compactly into the
+// system prompt and only the latest KeepMessages messages of the raw history are sent.
+//
+// It is a RequestMiddleware; register it with WithEntityMemory or Agent.Use. It is safe for
+// concurrent use, and it is shared by clones of the agent it is registered with.
+type EntityMemory struct {
+	// Model extracts facts from the conversation; defaults to the agent's model.
+	Model string
+	// KeepMessages is the number of latest messages sent with the facts; defaults to
+	// DefaultEntityKeepMessages. A negative value sends the complete history.
+	KeepMessages int
+
+	mu       sync.Mutex
+	entities map[string]map[string]string
+}
+
+// NewEntityMemory creates an empty entity memory.
+func NewEntityMemory() *EntityMemory {
+	return &EntityMemory{}
+}
+
+// Entities returns a copy of the stored entities and their facts.
+func (m *EntityMemory) Entities() map[string]map[string]string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make(map[string]map[string]string, len(m.entities))
+	for name, facts := range m.entities {
+		out[name] = make(map[string]string, len(facts))
+		for k, v := range facts {
+			out[name][k] = v
+		}
+	}
+	return out
+}
+
+// Set stores a fact about an entity. An empty value removes the fact, and an entity without
+// facts is removed.
+func (m *EntityMemory) Set(entity, key, value string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.set(entity, key, value)
+}
+
+func (m *EntityMemory) set(entity, key, value string) {
+	entity, key, value = strings.TrimSpace(entity), strings.TrimSpace(key), strings.TrimSpace(value)
+	if entity == "" || key == "" {
+		return
+	}
+	if value == "" {
+		delete(m.entities[entity], key)
+		if len(m.entities[entity]) == 0 {
+			delete(m.entities, entity)
+		}
+		return
+	}
+	if m.entities == nil {
+		m.entities = make(map[string]map[string]string)
+	}
+	if m.entities[entity] == nil {
+		m.entities[entity] = make(map[string]string)
+	}
+	m.entities[entity][key] = value
+}
+
+// Render returns the facts as compact text, one entity per line in the form
+// "Alice: city=Jakarta; role=engineer", sorted by entity and key.
+func (m *EntityMemory) Render() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	names := make([]string, 0, len(m.entities))
+	for name := range m.entities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		facts := m.entities[name]
+		keys := make([]string, 0, len(facts))
+		for k := range facts {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		pairs := make([]string, len(keys))
+		for i, k := range keys {
+			pairs[i] = k + "=" + facts[k]
+		}
+		fmt.Fprintf(&b, "%s: %s\n", name, strings.Join(pairs, "; "))
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// MarshalJSON encodes the entities as a JSON object of objects, so the memory can be saved.
+func (m *EntityMemory) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.Entities())
+}
+
+// UnmarshalJSON replaces the entities with those in data, as written by MarshalJSON.
+func (m *EntityMemory) UnmarshalJSON(data []byte) error {
+	var entities map[string]map[string]string
+	if err := json.Unmarshal(data, &entities); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entities = nil
+	for name, facts := range entities {
+		for k, v := range facts {
+			m.set(name, k, v)
+		}
+	}
+	return nil
+}
+
+// BeforeSend implements RequestMiddleware by adding the facts to the system prompt and
+// leaving out the older messages.
+func (m *EntityMemory) BeforeSend(ctx context.Context, rc *RequestContext) error {
+	var system []ConversationMessage
+	rest := rc.Messages
+	if len(rest) > 0 && rest[0].Role == "System" {
+		system = append(system, rest[0])
+		rest = rest[1:]
+	}
+	keep := m.KeepMessages
+	if keep == 0 {
+		keep = DefaultEntityKeepMessages
+	}
+	rest = WindowMemory{MaxMessages: keep}.Messages(rest)
+
+	if facts := m.Render(); facts != "" {
+		facts = "Known entities and facts from the conversation:\n" + facts
+		if len(system) > 0 {
+			system[0].Content += "\n\n" + facts
+		} else {
+			system = append(system, ConversationMessage{Role: "System", Content: facts})
+		}
+	}
+	rc.Messages = append(system, rest...)
+	return nil
+}
+
+// ModifiesResponse implements ResponseModifier; the memory only reads responses.
+func (m *EntityMemory) ModifiesResponse() bool { return false }
+
+// AfterReceive implements RequestMiddleware by extracting facts from the exchange. Failures
+// are logged and do not affect the response.
+func (m *EntityMemory) AfterReceive(ctx context.Context, rc *RequestContext) error {
+	if rc.Input == "" {
+		return nil
+	}
+	if err := m.extract(ctx, rc); err != nil {
+		rc.Agent.logFor(ctx).Warn("entity memory update failed", logging.KeyComponent, "agent", logging.KeyError, err)
+	}
+	return nil
+}
+
+// extract asks the model for the facts that changed in the latest exchange and merges them.
+func (m *EntityMemory) extract(ctx context.Context, rc *RequestContext) error {
+	model := m.Model
+	if model == "" {
+		model = rc.Agent.ModelName()
+	}
+	known, err := m.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	prompt := "You maintain a store of entities (people, places, organizations, projects, things) and facts " +
+		"about them. Given the known facts and the latest exchange, respond with only a JSON object of new or " +
+		"changed facts, in the form {\"entity\": {\"attribute\": \"value\"}}. Use an empty string to remove a fact " +
+		"that is no longer true, and {} if nothing changed.\n\nKnown facts:\n" + string(known) +
+		"\n\nUser: " + rc.Input + "\nAssistant: " + rc.Response
+	res, err := rc.Agent.client.Generate(ctx, model, llm.ModelRequest{Prompt: prompt, Temperature: 0})
+	if err != nil {
+		return fmt.Errorf("extract entities: %w", err)
+	}
+	var updates map[string]map[string]interface{}
+	if err := json.Unmarshal([]byte(extractJSON(res.Text)), &updates); err != nil {
+		return fmt.Errorf("extract entities: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for name, facts := range updates {
+		for k, v := range facts {
+			value := ""
+			if v != nil {
+				value = fmt.Sprint(v)
+			}
+			m.set(name, k, value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// HistoryFormat identifies a transcript format for SaveHistory and LoadHistory.
+type HistoryFormat string
+
+const (
+	// HistoryJSON stores the conversation as a JSON document.
+	HistoryJSON HistoryFormat = "json"
+	// HistoryMarkdown stores the conversation as a readable Markdown transcript. Each message is
+	// preceded by an HTML comment holding its role, timestamp, token count and metadata, so the
+	// transcript can be loaded back without loss.
+	HistoryMarkdown HistoryFormat = "markdown"
+)
+
+// HistoryFormatFromPath returns HistoryMarkdown for .md and .markdown files and HistoryJSON otherwise.
+func HistoryFormatFromPath(path string) HistoryFormat {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown":
+		return HistoryMarkdown
+	default:
+		return HistoryJSON
+	}
+}
+
+// transcript is the JSON representation of a saved conversation.
+type transcript struct {
+	Version  int                   `json:"version"`
+	Model    string                `json:"model,omitempty"`
+	SavedAt  time.Time             `json:"saved_at"`
+	Messages []ConversationMessage `json:"messages"`
+	// Metadata is the session metadata, such as the conversation title and tags.
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// messageHeader is the per-message data embedded in Markdown transcripts.
+type messageHeader struct {
+	Role       string                 `json:"role"`
+	Timestamp  time.Time              `json:"timestamp"`
+	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+	TokenCount int                    `json:"token_count,omitempty"`
+}
+
+const markdownMarker = "<!-- message "
+
+// SaveHistory writes the conversation history, including tool calls, timestamps and
+// metadata, in the given format.
+func (a *Agent) SaveHistory(w io.Writer, format HistoryFormat) error {
+	switch format {
+	case HistoryJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(transcript{Version: 1, Model: a.modelName, SavedAt: time.Now(), Messages: a.history, Metadata: a.metadata})
+	case HistoryMarkdown:
+		bw := bufio.NewWriter(w)
+		if title := a.Title(); title != "" {
+			fmt.Fprintf(bw, "# %s\n\n", title)
+		} else {
+			fmt.Fprintf(bw, "# Conversation with %s\n\n", a.modelName)
+		}
+		for _, msg := range a.history {
+			header, err := json.Marshal(messageHeader{Role: msg.Role, Timestamp: msg.Timestamp, Metadata: msg.Metadata,
+				TokenCount: msg.TokenCount})
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(bw, "%s%s -->\n", markdownMarker, header)
+			heading := "### " + msg.Role
+			if !msg.Timestamp.IsZero() {
+				heading += " · " + msg.Timestamp.Format(time.RFC3339)
+			}
+			fmt.Fprintf(bw, "%s\n\n%s\n\n", heading, msg.Content)
+		}
+		return bw.Flush()
+	default:
+		return fmt.Errorf("unsupported history format %q", format)
+	}
+}
+
+// LoadHistory replaces the conversation history with one previously written by SaveHistory.
+func (a *Agent) LoadHistory(r io.Reader, format HistoryFormat) error {
+	var messages []ConversationMessage
+	var metadata map[string]interface{}
+	switch format {
+	case HistoryJSON:
+		var t transcript
+		if err := json.NewDecoder(r).Decode(&t); err != nil {
+			return fmt.Errorf("failed to parse history: %w", err)
+		}
+		messages = t.Messages
+		metadata = t.Metadata
+	case HistoryMarkdown:
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		if messages, err = parseMarkdownHistory(string(data)); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported history format %q", format)
+	}
+
+	for i := range messages {
+		restoreAnnotations(&messages[i])
+	}
+	if messages == nil {
+		messages = []ConversationMessage{}
+	}
+	a.history = messages
+	if metadata != nil {
+		a.metadata = metadata
+	}
+	return nil
+}
+
+// SaveHistoryFile writes the conversation history to path, choosing the format from its extension.
+func (a *Agent) SaveHistoryFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := a.SaveHistory(f, HistoryFormatFromPath(path)); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadHistoryFile loads the conversation history from path, choosing the format from its extension.
+func (a *Agent) LoadHistoryFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return a.LoadHistory(f, HistoryFormatFromPath(path))
+}
+
+// parseMarkdownHistory parses a transcript written by SaveHistory in Markdown format.
+func parseMarkdownHistory(data string) ([]ConversationMessage, error) {
+	var messages []ConversationMessage
+	chunks := strings.Split(data, "\n"+markdownMarker)
+	// The first chunk is the document title, unless the transcript starts with a message.
+	if strings.HasPrefix(data, markdownMarker) {
+		chunks[0] = strings.TrimPrefix(chunks[0], markdownMarker)
+	} else {
+		chunks = chunks[1:]
+	}
+
+	for i, chunk := range chunks {
+		if i < len(chunks)-1 {
+			// Restore the newline consumed by the split.
+			chunk += "\n"
+		}
+		headerLine, rest, ok := strings.Cut(chunk, " -->\n")
+		if !ok {
+			return nil, fmt.Errorf("malformed message marker in history")
+		}
+		var header messageHeader
+		if err := json.Unmarshal([]byte(headerLine), &header); err != nil {
+			return nil, fmt.Errorf("malformed message header in history: %w", err)
+		}
+		// Skip the heading line and the blank line that follows it.
+		_, content, ok := strings.Cut(rest, "\n\n")
+		if !ok {
+			return nil, fmt.Errorf("malformed message body in history")
+		}
+		messages = append(messages, ConversationMessage{
+			Role:       header.Role,
+			Content:    strings.TrimSuffix(content, "\n\n"),
+			Timestamp:  header.Timestamp,
+			TokenCount: header.TokenCount,
+			Metadata:   header.Metadata,
+		})
+	}
+	return messages, nil
+}
+
+// restoreAnnotations converts annotations decoded as generic JSON values back into []Annotation.
+func restoreAnnotations(msg *ConversationMessage) {
+	raw, ok := msg.Metadata[AnnotationsKey]
+	if !ok {
+		return
+	}
+	if _, typed := raw.([]Annotation); typed {
+		return
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return
+	}
+	var anns []Annotation
+	if json.Unmarshal(data, &anns) == nil {
+		msg.Metadata[AnnotationsKey] = anns
+	}
+}
@@ -0,0 +1,44 @@
+package agent
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestHistoryRoundTrip(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	want := []ConversationMessage{
+		{Role: "User", Content: "hi", Timestamp: ts, TokenCount: 1},
+		{Role: "Assistant", Content: "hello there\n\nhow can I help?", Timestamp: ts.Add(time.Second), TokenCount: 8,
+			Metadata: map[string]interface{}{"source": "test"}},
+	}
+	for _, format := range []HistoryFormat{HistoryJSON, HistoryMarkdown} {
+		t.Run(string(format), func(t *testing.T) {
+			a := NewAgent(nil, "history-test")
+			a.history = append([]ConversationMessage(nil), want...)
+
+			var buf bytes.Buffer
+			if err := a.SaveHistory(&buf, format); err != nil {
+				t.Fatal(err)
+			}
+			loaded := NewAgent(nil, "history-test")
+			if err := loaded.LoadHistory(&buf, format); err != nil {
+				t.Fatal(err)
+			}
+			got := loaded.History()
+			if len(got) != len(want) {
+				t.Fatalf("loaded %d messages, want %d", len(got), len(want))
+			}
+			for i := range want {
+				if got[i].Role != want[i].Role || got[i].Content != want[i].Content ||
+					!got[i].Timestamp.Equal(want[i].Timestamp) || got[i].TokenCount != want[i].TokenCount {
+					t.Errorf("message %d = %+v, want %+v", i, got[i], want[i])
+				}
+			}
+			if loaded.TokenCount() != 9 {
+				t.Errorf("TokenCount() = %d after loading, want 9", loaded.TokenCount())
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package agent
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/zakirkun/gatot-kaca/llm"
+	"github.com/zakirkun/gatot-kaca/logging"
+	"github.com/zakirkun/gatot-kaca/rag"
+)
+
+// MemoryRole is the history role of the message holding the memories recalled by a
+// LongTermMemory.
+const MemoryRole = "Memory"
+
+// Metadata keys of the documents stored by a LongTermMemory.
+const (
+	MemoryUserKey = "user"
+	MemoryKindKey = "kind"
+)
+
+// recalledKey caches the memories recalled for a request in its RequestContext.
+const recalledKey = "agent.recalled_memories"
+
+// LongTermMemory gives an agent recall across sessions. After every response, salient facts
+// about the user are extracted from the exchange by the model and stored as documents in a
+// knowledge base; before every request, the K memories most relevant to the user's message are
+// recalled and shown to the model ahead of it. Set KB.Store to a rag.VectorStore, or save the
+// knowledge base, to keep memories between runs.
+//
+// It is a RequestMiddleware; register it with WithLongTermMemory or Agent.Use.
+type LongTermMemory struct {
+	KB *rag.KnowledgeBase
+	// UserID scopes the stored and recalled memories to one user, so a knowledge base can be
+	// shared by the agents of many users.
+	UserID string
+	// Model extracts facts from the conversation; defaults to the agent's model.
+	Model    string
+	K        int     // Number of memories recalled; defaults to 5.
+	MinScore float64 // Memories scoring below MinScore are not recalled.
+	// ReadOnly disables extraction, e.g. for agents that should only recall.
+	ReadOnly bool
+}
+
+// NewLongTermMemory creates a long-term memory for the given user stored in kb.
+func NewLongTermMemory(kb *rag.KnowledgeBase, userID string) *LongTermMemory {
+	return &LongTermMemory{KB: kb, UserID: userID}
+}
+
+func (m *LongTermMemory) filter() rag.Filter {
+	return rag.Filter{MemoryKindKey: "memory", MemoryUserKey: m.UserID}
+}
+
+// Recall returns the stored memories most relevant to query.
+func (m *LongTermMemory) Recall(ctx context.Context, query string) ([]string, error) {
+	k := m.K
+	if k <= 0 {
+		k = 5
+	}
+	opts := []rag.QueryOption{rag.WithFilter(m.filter())}
+	if m.MinScore > 0 {
+		opts = append(opts, rag.WithMinScore(m.MinScore))
+	}
+	results, err := m.KB.Query(ctx, query, k, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("recall memories: %w", err)
+	}
+	memories := make([]string, len(results))
+	for i, res := range results {
+		memories[i] = res.Doc.Text
+	}
+	return memories, nil
+}
+
+// Remember stores facts about the user. Facts that are already stored are skipped.
+func (m *LongTermMemory) Remember(ctx context.Context, facts ...string) error {
+	var docs []rag.Document
+	for _, fact := range facts {
+		fact = strings.TrimSpace(fact)
+		if fact == "" {
+			continue
+		}
+		sum := sha256.Sum256([]byte(m.UserID + "\x00" + strings.ToLower(fact)))
+		docs = append(docs, rag.Document{
+			ID:       "memory-" + hex.EncodeToString(sum[:8]),
+			Text:     fact,
+			Metadata: map[string]interface{}{MemoryKindKey: "memory", MemoryUserKey: m.UserID},
+		})
+	}
+	if len(docs) == 0 {
+		return nil
+	}
+	if err := m.KB.AddDocuments(ctx, docs); err != nil {
+		return fmt.Errorf("store memories: %w", err)
+	}
+	return nil
+}
+
+// BeforeSend implements RequestMiddleware by inserting the recalled memories before the user's
+// latest message.
+func (m *LongTermMemory) BeforeSend(ctx context.Context, rc *RequestContext) error {
+	if rc.Input == "" {
+		return nil
+	}
+	memories, ok := rc.Value(recalledKey)
+	if !ok {
+		recalled, err := m.Recall(ctx, rc.Input)
+		if err != nil {
+			// Recall is best effort; the request goes on without memories.
+			rc.Agent.logFor(ctx).Warn("failed to recall memories", logging.KeyComponent, "agent", logging.KeyError, err)
+		}
+		memories = recalled
+		rc.Set(recalledKey, recalled)
+	}
+	list := memories.([]string)
+	if len(list) == 0 {
+		return nil
+	}
+
+	last := len(rc.Messages)
+	for i := len(rc.Messages) - 1; i >= 0; i-- {
+		if rc.Messages[i].Role == "User" {
+			last = i
+			break
+		}
+	}
+	msg := ConversationMessage{
+		Role:    MemoryRole,
+		Content: "Things you remember about the user from earlier conversations:\n- " + strings.Join(list, "\n- "),
+	}
+	messages := make([]ConversationMessage, 0, len(rc.Messages)+1)
+	messages = append(messages, rc.Messages[:last]...)
+	messages = append(messages, msg)
+	rc.Messages = append(messages, rc.Messages[last:]...)
+	return nil
+}
+
+// ModifiesResponse implements ResponseModifier; the memory only reads responses.
+func (m *LongTermMemory) ModifiesResponse() bool { return false }
+
+// AfterReceive implements RequestMiddleware by extracting and storing facts from the exchange.
+// Failures are logged and do not affect the response.
+func (m *LongTermMemory) AfterReceive(ctx context.Context, rc *RequestContext) error {
+	if m.ReadOnly || rc.Input == "" {
+		return nil
+	}
+	facts, err := m.extract(ctx, rc)
+	if err == nil {
+		err = m.Remember(ctx, facts...)
+	}
+	if err != nil {
+		rc.Agent.logFor(ctx).Warn("long-term memory update failed", logging.KeyComponent, "agent", logging.KeyError, err)
+	}
+	return nil
+}
+
+// extract asks the model for the facts worth remembering in the latest exchange.
+func (m *LongTermMemory) extract(ctx context.Context, rc *RequestContext) ([]string, error) {
+	model := m.Model
+	if model == "" {
+		model = rc.Agent.ModelName()
+	}
+	prompt := "From the following exchange, list the facts about the user that are worth remembering in future " +
+		"conversations, such as their preferences, personal details, plans and goals. Write each fact as a short " +
+		"standalone sentence about \"the user\". Respond with only a JSON array of strings, or [] if there is nothing " +
+		"worth remembering.\n\nUser: " + rc.Input + "\nAssistant: " + rc.Response
+	res, err := m.KB.Client.Generate(ctx, model, llm.ModelRequest{Prompt: prompt, Temperature: 0})
+	if err != nil {
+		return nil, fmt.Errorf("extract memories: %w", err)
+	}
+	var facts []string
+	if err := json.Unmarshal([]byte(extractJSON(res.Text)), &facts); err != nil {
+		return nil, fmt.Errorf("extract memories: %w", err)
+	}
+	return facts, nil
+}
@@ -0,0 +1,47 @@
+package agent_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/zakirkun/gatot-kaca/agent"
+	"github.com/zakirkun/gatot-kaca/llmtest"
+	"github.com/zakirkun/gatot-kaca/rag"
+)
+
+func TestLongTermMemory(t *testing.T) {
+	model := llmtest.NewMockModel("memory-test").
+		On("worth remembering", `["The user is vegetarian."]`).
+		Default("Noted!")
+	kb := rag.NewKnowledgeBase(llmtest.NewClient(model), "memory-test")
+	ctx := context.Background()
+
+	first := llmtest.NewAgent(model)
+	agent.WithLongTermMemory(agent.NewLongTermMemory(kb, "alice"))(first)
+	if _, err := first.Send(ctx, "I am vegetarian."); err != nil {
+		t.Fatal(err)
+	}
+	if len(kb.Documents) != 1 || kb.Documents[0].Text != "The user is vegetarian." {
+		t.Fatalf("unexpected memories %+v", kb.Documents)
+	}
+
+	// A new session of the same user recalls the memory; another user's does not.
+	second := llmtest.NewAgent(model)
+	agent.WithLongTermMemory(&agent.LongTermMemory{KB: kb, UserID: "alice", ReadOnly: true})(second)
+	if _, err := second.Send(ctx, "Suggest a dinner recipe"); err != nil {
+		t.Fatal(err)
+	}
+	if prompt := model.LastPrompt(); !strings.Contains(prompt, "Memory: ") || !strings.Contains(prompt, "vegetarian") {
+		t.Errorf("memory not recalled: %q", prompt)
+	}
+
+	other := llmtest.NewAgent(model)
+	agent.WithLongTermMemory(&agent.LongTermMemory{KB: kb, UserID: "bob", ReadOnly: true})(other)
+	if _, err := other.Send(ctx, "Suggest a dinner recipe"); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(model.LastPrompt(), "vegetarian") {
+		t.Errorf("recalled another user's memory: %q", model.LastPrompt())
+	}
+}
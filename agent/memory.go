@@ -0,0 +1,60 @@
+package agent
+
+import "github.com/zakirkun/gatot-kaca/tokenizer"
+
+// Memory decides which part of the conversation history is sent to the LLM.
+// Implementations can trim, summarize, or otherwise transform the history
+// before the prompt is built; the agent's stored history is left untouched.
+type Memory interface {
+	// Messages returns the messages that should be included in the next prompt.
+	Messages(history []ConversationMessage) []ConversationMessage
+}
+
+// BufferMemory keeps the complete conversation history.
+type BufferMemory struct{}
+
+// Messages returns the history unchanged.
+func (BufferMemory) Messages(history []ConversationMessage) []ConversationMessage {
+	return history
+}
+
+// WindowMemory keeps only the most recent MaxMessages messages.
+// A MaxMessages value of zero or less keeps the complete history.
+type WindowMemory struct {
+	MaxMessages int
+}
+
+// Messages returns at most MaxMessages of the latest messages.
+func (w WindowMemory) Messages(history []ConversationMessage) []ConversationMessage {
+	if w.MaxMessages <= 0 || len(history) <= w.MaxMessages {
+		return history
+	}
+	return history[len(history)-w.MaxMessages:]
+}
+
+// TokenWindowMemory keeps the most recent messages whose combined size fits
+// within MaxTokens, as counted by the tokenizer for Model. If Model is empty,
+// the TokenCount recorded on each message is used instead of counting again.
+type TokenWindowMemory struct {
+	Model     string
+	MaxTokens int
+}
+
+// Messages returns the longest suffix of the history that fits the token budget.
+func (w TokenWindowMemory) Messages(history []ConversationMessage) []ConversationMessage {
+	if w.MaxTokens <= 0 {
+		return history
+	}
+	total := 0
+	for i := len(history) - 1; i >= 0; i-- {
+		if w.Model == "" && history[i].TokenCount > 0 {
+			total += history[i].TokenCount + tokenizer.Estimate(history[i].Role+": ")
+		} else {
+			total += tokenizer.CountTokens(w.Model, history[i].Role+": "+history[i].Content)
+		}
+		if total > w.MaxTokens {
+			return history[i+1:]
+		}
+	}
+	return history
+}
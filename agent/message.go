@@ -0,0 +1,58 @@
+package agent
+
+import "fmt"
+
+// ModelKey is the message metadata key recording the model that produced an assistant response.
+const ModelKey = "model"
+
+// MetadataValue returns the metadata value stored under key.
+func (m ConversationMessage) MetadataValue(key string) (interface{}, bool) {
+	v, ok := m.Metadata[key]
+	return v, ok
+}
+
+// MetadataString returns the metadata value stored under key if it is a string, or "".
+func (m ConversationMessage) MetadataString(key string) string {
+	s, _ := m.Metadata[key].(string)
+	return s
+}
+
+// SetMetadata stores a metadata value on the message. Middlewares can use it to tag the
+// messages they receive in ProcessBeforeSend.
+func (m *ConversationMessage) SetMetadata(key string, value interface{}) {
+	if m.Metadata == nil {
+		m.Metadata = make(map[string]interface{})
+	}
+	m.Metadata[key] = value
+}
+
+// Message returns the message at the given history index.
+func (a *Agent) Message(index int) (ConversationMessage, bool) {
+	if index < 0 || index >= len(a.history) {
+		return ConversationMessage{}, false
+	}
+	return a.history[index], true
+}
+
+// LastMessage returns the most recent message in the history.
+func (a *Agent) LastMessage() (ConversationMessage, bool) {
+	return a.Message(len(a.history) - 1)
+}
+
+// SetMessageMetadata stores a metadata value on the message at the given history index.
+func (a *Agent) SetMessageMetadata(index int, key string, value interface{}) error {
+	if index < 0 || index >= len(a.history) {
+		return fmt.Errorf("message index %d out of range", index)
+	}
+	a.setMetadata(index, key, value)
+	return nil
+}
+
+// TokenCount returns the total TokenCount of all messages in the history.
+func (a *Agent) TokenCount() int {
+	total := 0
+	for _, msg := range a.history {
+		total += msg.TokenCount
+	}
+	return total
+}
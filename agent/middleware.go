@@ -0,0 +1,212 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/zakirkun/gatot-kaca/llm"
+	"github.com/zakirkun/gatot-kaca/logging"
+)
+
+// HaltedKey is the message metadata key set on responses produced by a middleware halting the
+// request instead of by the model.
+const HaltedKey = "halted"
+
+// RequestMiddleware pre- and post-processes a request with access to its RequestContext, and can
+// fail the request by returning an error or short-circuit it by returning Halt. Middlewares that
+// implement it are called through it rather than through the Middleware methods.
+type RequestMiddleware interface {
+	// BeforeSend is called with rc.Messages set to the messages about to be sent, which it may
+	// modify. Returning Halt skips the model call and replies with the halt response; any other
+	// error is returned from Send.
+	BeforeSend(ctx context.Context, rc *RequestContext) error
+	// AfterReceive is called with rc.Response set to the response text, which it may modify.
+	// Returning Halt replaces the response and skips the remaining middlewares; any other error
+	// is returned from Send and the response is not recorded.
+	AfterReceive(ctx context.Context, rc *RequestContext) error
+}
+
+// ResponseModifier is implemented by RequestMiddlewares that report whether their AfterReceive
+// may change or halt the response. SendStream holds the streamed chunks back until the
+// middlewares have approved the response, unless none of them may change it; middlewares that
+// do not implement ResponseModifier are assumed to.
+type ResponseModifier interface {
+	ModifiesResponse() bool
+}
+
+// RequestContext is the state of one Send call shared by its middlewares. Middlewares that only
+// implement Middleware can reach it with RequestFromContext.
+type RequestContext struct {
+	Agent *Agent
+	Input string // The user message that started the request.
+	// Messages are the messages about to be sent, including the system prompt, during BeforeSend.
+	Messages []ConversationMessage
+	// Response is the response text during AfterReceive, and Result the model response it came from.
+	Response string
+	Result   llm.ModelResponse
+
+	values map[string]interface{}
+	err    error // Returned by BeforeSend; checked before the model is called.
+	halted bool  // The response came from a halt rather than from the model.
+}
+
+// Set stores a request-scoped value, e.g. for a later middleware or AfterReceive to read.
+func (rc *RequestContext) Set(key string, value interface{}) {
+	if rc.values == nil {
+		rc.values = make(map[string]interface{})
+	}
+	rc.values[key] = value
+}
+
+// Value returns the request-scoped value stored under key.
+func (rc *RequestContext) Value(key string) (interface{}, bool) {
+	v, ok := rc.values[key]
+	return v, ok
+}
+
+// HaltError short-circuits a request from a RequestMiddleware; see Halt.
+type HaltError struct {
+	Response string // Replaces the model's response.
+}
+
+func (e *HaltError) Error() string {
+	return "request halted by middleware"
+}
+
+// Halt returns an error that stops the request and replies with response instead, e.g. when a
+// guardrail rejects the user's message.
+func Halt(response string) error {
+	return &HaltError{Response: response}
+}
+
+type requestContextKey struct{}
+
+// RequestFromContext returns the RequestContext of the Send call ctx belongs to, or nil.
+func RequestFromContext(ctx context.Context) *RequestContext {
+	rc, _ := ctx.Value(requestContextKey{}).(*RequestContext)
+	return rc
+}
+
+// startRequest checks the agent's quota and attaches a new RequestContext for userInput, and the
+// agent's logger, hooks and retry policy if it has them, to ctx.
+func (a *Agent) startRequest(ctx context.Context, userInput string) (context.Context, error) {
+	if a.quota != nil {
+		if err := a.quota.admit(time.Now()); err != nil {
+			return ctx, err
+		}
+	}
+	if a.logger != nil {
+		ctx = logging.NewContext(ctx, a.logger)
+	}
+	for _, h := range a.hooks {
+		ctx = llm.ContextWithHooks(ctx, h)
+	}
+	if a.retry != nil {
+		ctx = llm.ContextWithRetryPolicy(ctx, *a.retry)
+	}
+	return context.WithValue(ctx, requestContextKey{}, &RequestContext{Agent: a, Input: userInput}), nil
+}
+
+// requestContext returns the RequestContext of ctx, or a detached one for calls outside Send
+// such as PreviewPrompt.
+func (a *Agent) requestContext(ctx context.Context) *RequestContext {
+	if rc := RequestFromContext(ctx); rc != nil {
+		return rc
+	}
+	return &RequestContext{Agent: a}
+}
+
+// beforeSend runs the middlewares over messages, recording an error or halt in the request
+// context so that the model is not called.
+func (a *Agent) beforeSend(ctx context.Context, messages []ConversationMessage) []ConversationMessage {
+	rc := a.requestContext(ctx)
+	rc.Messages = messages
+	rc.err = nil
+	for _, m := range a.middlewares {
+		if err := m.BeforeSend(ctx, rc); err != nil {
+			rc.err = err
+			break
+		}
+	}
+	return rc.Messages
+}
+
+// haltedResponse returns the response for a request whose BeforeSend failed: the halt response,
+// delivered to onChunk if it is not nil, or the middleware error.
+func (a *Agent) haltedResponse(ctx context.Context, onChunk llm.StreamHandler) (llm.ModelResponse, bool, error) {
+	rc := RequestFromContext(ctx)
+	if rc == nil || rc.err == nil {
+		return llm.ModelResponse{}, false, nil
+	}
+	var halt *HaltError
+	if !errors.As(rc.err, &halt) {
+		return llm.ModelResponse{}, true, fmt.Errorf("middleware: %w", rc.err)
+	}
+	rc.halted = true
+	if onChunk != nil && halt.Response != "" {
+		if err := onChunk(halt.Response); err != nil {
+			return llm.ModelResponse{}, true, err
+		}
+	}
+	return llm.ModelResponse{Text: halt.Response, ModelName: a.modelName, FinishType: HaltedKey}, true, nil
+}
+
+// afterReceive runs the middlewares over a response, reporting whether it was halted.
+func (a *Agent) afterReceive(ctx context.Context, res llm.ModelResponse) (string, bool, error) {
+	rc := a.requestContext(ctx)
+	if rc.halted {
+		return res.Text, true, nil
+	}
+	rc.Result = res
+	rc.Response = res.Text
+	for _, m := range a.middlewares {
+		if err := m.AfterReceive(ctx, rc); err != nil {
+			var halt *HaltError
+			if errors.As(err, &halt) {
+				return halt.Response, true, nil
+			}
+			return "", false, fmt.Errorf("middleware: %w", err)
+		}
+	}
+	return rc.Response, false, nil
+}
+
+// modifiesResponse reports whether any of the agent's middlewares may change or halt responses.
+func (a *Agent) modifiesResponse() bool {
+	for _, m := range a.middlewares {
+		if rm, ok := m.(ResponseModifier); !ok || rm.ModifiesResponse() {
+			return true
+		}
+	}
+	return false
+}
+
+// legacyMiddleware adapts a Middleware to RequestMiddleware.
+type legacyMiddleware struct {
+	Middleware
+}
+
+func (m legacyMiddleware) BeforeSend(ctx context.Context, rc *RequestContext) error {
+	rc.Messages = m.ProcessBeforeSend(ctx, rc.Messages)
+	return nil
+}
+
+func (m legacyMiddleware) AfterReceive(ctx context.Context, rc *RequestContext) error {
+	rc.Response = m.ProcessAfterReceive(ctx, rc.Response)
+	return nil
+}
+
+// asRequestMiddleware returns m as a RequestMiddleware, adapting it if needed.
+func asRequestMiddleware(m Middleware) RequestMiddleware {
+	if rm, ok := m.(RequestMiddleware); ok {
+		return rm
+	}
+	return legacyMiddleware{m}
+}
+
+// Use registers middlewares that can fail or halt requests.
+func (a *Agent) Use(ms ...RequestMiddleware) {
+	a.middlewares = append(a.middlewares, ms...)
+}
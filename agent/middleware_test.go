@@ -0,0 +1,60 @@
+package agent_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/zakirkun/gatot-kaca/agent"
+	"github.com/zakirkun/gatot-kaca/llmtest"
+)
+
+// blocker halts requests mentioning "secret", fails requests mentioning "fail" and tags the
+// responses of the others with a value set in BeforeSend.
+type blocker struct{}
+
+var errRejected = errors.New("rejected")
+
+func (blocker) BeforeSend(ctx context.Context, rc *agent.RequestContext) error {
+	switch {
+	case strings.Contains(rc.Input, "secret"):
+		return agent.Halt("I can't share that.")
+	case strings.Contains(rc.Input, "fail"):
+		return errRejected
+	}
+	rc.Set("tag", "checked")
+	return nil
+}
+
+func (blocker) AfterReceive(ctx context.Context, rc *agent.RequestContext) error {
+	tag, _ := rc.Value("tag")
+	rc.Response += " [" + tag.(string) + "]"
+	return nil
+}
+
+func TestRequestMiddleware(t *testing.T) {
+	model := llmtest.NewMockModel("middleware-test").Default("hello")
+	a := llmtest.NewAgent(model)
+	a.Use(blocker{})
+	ctx := context.Background()
+
+	out, err := a.Send(ctx, "tell me the secret")
+	if err != nil || out != "I can't share that." {
+		t.Fatalf("halted: got %q, %v", out, err)
+	}
+	model.AssertCalled(t, 0)
+	if last, _ := a.LastMessage(); last.Metadata[agent.HaltedKey] != true {
+		t.Errorf("halted response not marked: %+v", last)
+	}
+
+	if _, err := a.Send(ctx, "please fail"); !errors.Is(err, errRejected) {
+		t.Fatalf("expected the middleware error, got %v", err)
+	}
+	model.AssertCalled(t, 0)
+
+	if out, err := a.Send(ctx, "hi"); err != nil || out != "hello [checked]" {
+		t.Fatalf("allowed: got %q, %v", out, err)
+	}
+	model.AssertCalled(t, 1)
+}
@@ -0,0 +1,232 @@
+package agent
+
+import (
+	"github.com/zakirkun/gatot-kaca/agent/tools"
+	"github.com/zakirkun/gatot-kaca/budget"
+	"github.com/zakirkun/gatot-kaca/llm"
+	"github.com/zakirkun/gatot-kaca/logging"
+	"github.com/zakirkun/gatot-kaca/prompt"
+)
+
+// Option configures an Agent at construction time.
+type Option func(*Agent)
+
+// New creates a new Agent and applies the given options on top of the defaults. It is
+// equivalent to NewAgent.
+func New(client *llm.Client, modelName string, opts ...Option) *Agent {
+	return NewAgent(client, modelName, opts...)
+}
+
+// WithHistory starts the agent with a copy of the given conversation history.
+func WithHistory(history []ConversationMessage) Option {
+	return func(a *Agent) {
+		a.history = cloneHistory(history)
+	}
+}
+
+// WithSystemPrompt sets the system-level instruction prepended to every conversation.
+func WithSystemPrompt(prompt string) Option {
+	return func(a *Agent) {
+		a.systemPrompt = prompt
+	}
+}
+
+// WithSystemTemplate sets a system prompt template rendered on every request; see SetSystemTemplate.
+func WithSystemTemplate(t *prompt.Template, vars map[string]any) Option {
+	return func(a *Agent) {
+		a.SetSystemTemplate(t, vars)
+	}
+}
+
+// WithToolCatalog describes the registered tools in the system prompt using t, or
+// DefaultToolCatalog if t is nil; see EnableToolCatalog.
+func WithToolCatalog(t *prompt.Template) Option {
+	return func(a *Agent) {
+		a.EnableToolCatalog(t)
+	}
+}
+
+// WithTools registers the given tools with the agent.
+func WithTools(ts ...tools.Tool) Option {
+	return func(a *Agent) {
+		for _, t := range ts {
+			a.tools.RegisterTool(t)
+		}
+	}
+}
+
+// WithMemory sets the memory strategy used to select history for each prompt.
+func WithMemory(m Memory) Option {
+	return func(a *Agent) {
+		a.memory = m
+	}
+}
+
+// WithTemperature sets the sampling temperature of requests.
+func WithTemperature(t float64) Option {
+	return func(a *Agent) {
+		a.Temperature = t
+	}
+}
+
+// WithTopP sets the nucleus sampling probability of requests.
+func WithTopP(p float64) Option {
+	return func(a *Agent) {
+		a.TopP = p
+	}
+}
+
+// WithStructuredRetries sets the number of retries SendStructured makes when the response does
+// not match the schema.
+func WithStructuredRetries(n int) Option {
+	return func(a *Agent) {
+		a.StructuredRetries = n
+	}
+}
+
+// WithMaxTokens sets the maximum number of tokens requested from the LLM.
+func WithMaxTokens(n int) Option {
+	return func(a *Agent) {
+		a.MaxTokens = n
+	}
+}
+
+// WithMaxParallelTools caps the number of tools CallTools runs at the same time.
+func WithMaxParallelTools(n int) Option {
+	return func(a *Agent) {
+		a.maxParallelTools = n
+	}
+}
+
+// WithMiddlewares registers the given middlewares in order.
+func WithMiddlewares(ms ...Middleware) Option {
+	return func(a *Agent) {
+		for _, m := range ms {
+			a.middlewares = append(a.middlewares, asRequestMiddleware(m))
+		}
+	}
+}
+
+// WithRequestMiddlewares registers middlewares that can fail or halt requests, in order.
+func WithRequestMiddlewares(ms ...RequestMiddleware) Option {
+	return func(a *Agent) {
+		a.middlewares = append(a.middlewares, ms...)
+	}
+}
+
+// WithContextTruncation controls what happens when a prompt exceeds the model's context window:
+// if enabled (the default), the oldest messages are left out of the prompt and the request is
+// retried; otherwise the llm.ContextTooLongError is returned.
+func WithContextTruncation(enabled bool) Option {
+	return func(a *Agent) {
+		a.noTruncation = !enabled
+	}
+}
+
+// WithPromptCache sends the system prompt and tool catalog separately from the conversation and
+// marks them for provider prompt caching (Anthropic cache_control; OpenAI and Gemini cache long
+// prefixes automatically), so they are not billed at the full price on every request. Cached
+// tokens are reported in llm.Usage.CachedTokens.
+func WithPromptCache() Option {
+	return func(a *Agent) {
+		a.promptCache = true
+	}
+}
+
+// WithChatMessages sends the conversation as alternating user and assistant turns, with the
+// system prompt as a separate system instruction, instead of flattening it into one prompt text.
+// Providers that need alternating turns, such as Gemini and Anthropic, get consecutive messages
+// of the same role merged.
+func WithChatMessages() Option {
+	return func(a *Agent) {
+		a.chatMessages = true
+	}
+}
+
+// WithAutoTitle generates a title and topic tags for the conversation once it has
+// opts.AfterTurns user messages; see GenerateTitle.
+func WithAutoTitle(opts TitleOptions) Option {
+	return func(a *Agent) {
+		a.titleOptions = &opts
+	}
+}
+
+// WithLongTermMemory registers m so that the agent recalls and stores memories about the user.
+func WithLongTermMemory(m *LongTermMemory) Option {
+	return func(a *Agent) {
+		a.middlewares = append(a.middlewares, m)
+	}
+}
+
+// WithEntityMemory registers m so that the agent keeps track of entities and their facts and
+// sends them in place of the older history.
+func WithEntityMemory(m *EntityMemory) Option {
+	return func(a *Agent) {
+		a.middlewares = append(a.middlewares, m)
+	}
+}
+
+// WithQuota limits the messages per minute and tokens per hour the agent may use; sends over
+// the limit fail with a *QuotaError. Clones of the agent get their own, unused quota.
+func WithQuota(q Quota) Option {
+	return func(a *Agent) {
+		a.SetQuota(q)
+	}
+}
+
+// WithLogger sets the logger of the agent and its tools. It is also passed through the context
+// to the model calls and middlewares of each request; see the logging package.
+func WithLogger(l logging.Logger) Option {
+	return func(a *Agent) {
+		a.logger = l
+		a.tools.SetLogger(l)
+	}
+}
+
+// WithHooks calls h around every model call the agent makes, in addition to the hooks of its
+// client. Unlike llm.WithHooks, it only applies to this agent, so agents sharing a client can
+// be traced separately.
+func WithHooks(h llm.Hooks) Option {
+	return func(a *Agent) {
+		a.hooks = append(a.hooks[:len(a.hooks):len(a.hooks)], h)
+	}
+}
+
+// WithRetryPolicy retries the agent's failed model calls according to p, instead of the retry
+// policy of its client.
+func WithRetryPolicy(p llm.RetryPolicy) Option {
+	return func(a *Agent) {
+		a.retry = &p
+	}
+}
+
+// WithToolEvents reports the tool calls of every request to h, in addition to the handler of
+// the request's context (see ContextWithToolEvents).
+func WithToolEvents(h ToolEventHandler) Option {
+	return func(a *Agent) {
+		a.toolHandlers = append(a.toolHandlers, h)
+	}
+}
+
+// WithRunBudget limits every Send and SendStream call to the given wall time, LLM calls, tool
+// calls and tokens. A request that runs out fails with a *budget.BudgetExceeded holding the
+// latest response as its partial result.
+func WithRunBudget(b budget.RunBudget) Option {
+	return func(a *Agent) {
+		a.budget = b
+	}
+}
+
+// WithToolStrategy overrides the tool calling strategy chosen from the model's capabilities.
+func WithToolStrategy(s ToolStrategy) Option {
+	return func(a *Agent) {
+		a.toolStrategy = s
+	}
+}
+
+// WithMaxToolSteps caps the number of tool calls made for a single message with ToolStrategyPrompt.
+func WithMaxToolSteps(n int) Option {
+	return func(a *Agent) {
+		a.maxToolSteps = n
+	}
+}
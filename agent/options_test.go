@@ -0,0 +1,46 @@
+package agent_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/zakirkun/gatot-kaca/agent"
+	"github.com/zakirkun/gatot-kaca/llm"
+	"github.com/zakirkun/gatot-kaca/llmtest"
+)
+
+func TestWithHooksAndRetryPolicy(t *testing.T) {
+	model := llmtest.NewMockModel("options-test").Fail(errors.New("overloaded")).Default("hello")
+	client := llmtest.NewClient(model)
+
+	var before, after int
+	var lastErr error
+	a := agent.NewAgent(client, "options-test",
+		agent.WithHooks(llm.Hooks{
+			BeforeGenerate: func(ctx context.Context, model string, req llm.ModelRequest) { before++ },
+			AfterGenerate: func(ctx context.Context, model string, req llm.ModelRequest, resp llm.ModelResponse, err error, d time.Duration) {
+				after++
+				lastErr = err
+			},
+		}),
+		agent.WithRetryPolicy(llm.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}))
+
+	out, err := a.Send(context.Background(), "hi")
+	if err != nil || out != "hello" {
+		t.Fatalf("send = %q, %v; want the retried response", out, err)
+	}
+	if before != 1 || after != 1 || lastErr != nil {
+		t.Errorf("hooks called %d/%d times with error %v, want once around the retried call", before, after, lastErr)
+	}
+
+	// Another agent on the same client has neither the hooks nor the retry policy.
+	model.Fail(errors.New("overloaded"))
+	if _, err := agent.NewAgent(client, "options-test").Send(context.Background(), "hi"); err == nil {
+		t.Error("send without a retry policy succeeded, want the model error")
+	}
+	if before != 1 {
+		t.Errorf("hooks of one agent ran for another: %d calls", before)
+	}
+}
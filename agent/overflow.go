@@ -0,0 +1,66 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/zakirkun/gatot-kaca/llm"
+	"github.com/zakirkun/gatot-kaca/logging"
+)
+
+// generate builds the prompt from the history and sends it, streaming to onChunk if it is not
+// nil. If the client rejects the prompt as too long for the model's context window and
+// truncation is enabled, the oldest messages are dropped from the prompt until it fits and the
+// request is retried once. The history itself is left intact.
+func (a *Agent) generate(ctx context.Context, onChunk llm.StreamHandler) (llm.ModelResponse, error) {
+	return a.generateRequest(ctx, a.newRequest(ctx), onChunk)
+}
+
+// generateRequest is generate for a request already built from the history.
+func (a *Agent) generateRequest(ctx context.Context, req llm.ModelRequest, onChunk llm.StreamHandler) (llm.ModelResponse, error) {
+	res, err := a.send(ctx, req, onChunk)
+
+	var tooLong *llm.ContextTooLongError
+	if err == nil || a.noTruncation || !errors.As(err, &tooLong) {
+		return res, err
+	}
+	history, dropped, ok := a.truncatedHistory(ctx, tooLong)
+	if !ok {
+		return res, err
+	}
+	a.logFor(ctx).Info("prompt exceeds the context window, dropped oldest messages", logging.KeyComponent, "agent",
+		logging.KeyModel, tooLong.Model, "limit", tooLong.Limit, "dropped", dropped)
+	a.setPrompt(ctx, &req, history)
+	return a.send(ctx, req, onChunk)
+}
+
+func (a *Agent) send(ctx context.Context, req llm.ModelRequest, onChunk llm.StreamHandler) (llm.ModelResponse, error) {
+	if res, halted, err := a.haltedResponse(ctx, onChunk); halted {
+		return res, err
+	}
+	if onChunk != nil {
+		return a.client.GenerateStream(ctx, a.modelName, req, onChunk)
+	}
+	return a.client.Generate(ctx, a.modelName, req)
+}
+
+// truncatedHistory returns the longest suffix of the history whose prompt fits the context
+// window, and the number of messages dropped. The latest message is always kept.
+func (a *Agent) truncatedHistory(ctx context.Context, tooLong *llm.ContextTooLongError) ([]ConversationMessage, int, bool) {
+	history := a.history
+	if a.memory != nil {
+		history = a.memory.Messages(history)
+	}
+	fits := func(prompt string) bool {
+		return llm.DefaultTokenCounter(tooLong.Model, prompt)+tooLong.MaxTokens <= tooLong.Limit
+	}
+	// Dropping more messages never makes the prompt longer, so search for the fewest drops.
+	dropped := sort.Search(len(history), func(drop int) bool {
+		return fits(a.PreviewPrompt(ctx, history[drop:]))
+	})
+	if dropped == 0 || dropped >= len(history) {
+		return nil, 0, false
+	}
+	return history[dropped:], dropped, true
+}
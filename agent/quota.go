@@ -0,0 +1,124 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrQuotaExceeded is matched by every *QuotaError with errors.Is.
+var ErrQuotaExceeded = errors.New("agent quota exceeded")
+
+// Quota limits names reported in QuotaError.Limit.
+const (
+	LimitMessagesPerMinute = "messages_per_minute"
+	LimitTokensPerHour     = "tokens_per_hour"
+)
+
+// QuotaError is returned by Send and the other send methods when the agent's quota is used up.
+// The message is not added to the history.
+type QuotaError struct {
+	Limit string // LimitMessagesPerMinute or LimitTokensPerHour.
+	Max   int
+	Used  int
+	// RetryAfter is how long until the request would fit within the limit again.
+	RetryAfter time.Duration
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("agent quota exceeded: %s limit of %d reached (%d used), retry after %v",
+		e.Limit, e.Max, e.Used, e.RetryAfter.Round(time.Second))
+}
+
+// Unwrap returns ErrQuotaExceeded.
+func (e *QuotaError) Unwrap() error {
+	return ErrQuotaExceeded
+}
+
+// Quota limits how much a single agent, typically one user's chat session, can use. Zero
+// fields mean no limit. Tokens are the total tokens reported by the provider for the agent's
+// responses, including tool steps.
+type Quota struct {
+	MessagesPerMinute int
+	TokensPerHour     int
+}
+
+// quotaUse is a timestamped amount counted against a limit.
+type quotaUse struct {
+	at time.Time
+	n  int
+}
+
+// quotaState tracks an agent's use over sliding windows.
+type quotaState struct {
+	limits Quota
+
+	mu       sync.Mutex
+	messages []quotaUse
+	tokens   []quotaUse
+}
+
+// window drops the uses older than d and returns the remaining uses and their total.
+func window(uses []quotaUse, now time.Time, d time.Duration) ([]quotaUse, int) {
+	i := 0
+	for i < len(uses) && now.Sub(uses[i].at) >= d {
+		i++
+	}
+	uses = uses[i:]
+	total := 0
+	for _, u := range uses {
+		total += u.n
+	}
+	return uses, total
+}
+
+// admit records a new message if it fits within the limits.
+func (q *quotaState) admit(now time.Time) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var used int
+	q.tokens, used = window(q.tokens, now, time.Hour)
+	if max := q.limits.TokensPerHour; max > 0 && used >= max {
+		// Wait until enough of the oldest usage leaves the window.
+		retry, freed := time.Duration(0), 0
+		for _, u := range q.tokens {
+			freed += u.n
+			retry = u.at.Add(time.Hour).Sub(now)
+			if used-freed < max {
+				break
+			}
+		}
+		return &QuotaError{Limit: LimitTokensPerHour, Max: max, Used: used, RetryAfter: retry}
+	}
+
+	q.messages, used = window(q.messages, now, time.Minute)
+	if max := q.limits.MessagesPerMinute; max > 0 && used >= max {
+		retry := q.messages[used-max].at.Add(time.Minute).Sub(now)
+		return &QuotaError{Limit: LimitMessagesPerMinute, Max: max, Used: used, RetryAfter: retry}
+	}
+	q.messages = append(q.messages, quotaUse{at: now, n: 1})
+	return nil
+}
+
+// addTokens records tokens used by a response.
+func (q *quotaState) addTokens(now time.Time, n int) {
+	if n <= 0 || q.limits.TokensPerHour <= 0 {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.tokens = append(q.tokens, quotaUse{at: now, n: n})
+}
+
+// SetQuota limits the agent's use; see Quota. Use already counted is kept.
+func (a *Agent) SetQuota(q Quota) {
+	if a.quota == nil {
+		a.quota = &quotaState{limits: q}
+		return
+	}
+	a.quota.mu.Lock()
+	a.quota.limits = q
+	a.quota.mu.Unlock()
+}
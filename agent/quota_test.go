@@ -0,0 +1,54 @@
+package agent_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/zakirkun/gatot-kaca/agent"
+	"github.com/zakirkun/gatot-kaca/llmtest"
+)
+
+func TestQuota(t *testing.T) {
+	model := llmtest.NewMockModel("quota-test").Default("hello")
+	a := llmtest.NewAgent(model)
+	a.SetQuota(agent.Quota{MessagesPerMinute: 1})
+	ctx := context.Background()
+
+	if _, err := a.Send(ctx, "first"); err != nil {
+		t.Fatalf("first send: %v", err)
+	}
+	history := len(a.History())
+
+	_, err := a.Send(ctx, "second")
+	if !errors.Is(err, agent.ErrQuotaExceeded) {
+		t.Fatalf("second send: got %v, want ErrQuotaExceeded", err)
+	}
+	var qerr *agent.QuotaError
+	if !errors.As(err, &qerr) || qerr.Limit != agent.LimitMessagesPerMinute || qerr.RetryAfter <= 0 {
+		t.Errorf("quota error = %#v", qerr)
+	}
+	if got := len(a.History()); got != history {
+		t.Errorf("history grew to %d messages after a rejected send, want %d", got, history)
+	}
+
+	// A clone starts with its own, unused quota.
+	if _, err := a.Clone().Send(ctx, "third"); err != nil {
+		t.Errorf("clone send: %v", err)
+	}
+}
+
+func TestQuotaTokens(t *testing.T) {
+	model := llmtest.NewMockModel("quota-test").Default("hello")
+	a := llmtest.NewAgent(model)
+	a.SetQuota(agent.Quota{TokensPerHour: 1})
+	ctx := context.Background()
+
+	if _, err := a.Send(ctx, "first"); err != nil {
+		t.Fatalf("first send: %v", err)
+	}
+	var qerr *agent.QuotaError
+	if _, err := a.Send(ctx, "second"); !errors.As(err, &qerr) || qerr.Limit != agent.LimitTokensPerHour {
+		t.Fatalf("second send: got %v, want a tokens quota error", err)
+	}
+}
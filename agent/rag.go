@@ -0,0 +1,49 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/zakirkun/gatot-kaca/rag"
+)
+
+// DefaultRAGResults is the number of documents AskWithRAG retrieves.
+const DefaultRAGResults = 3
+
+// RAGContextRole is the history role of the message holding the documents retrieved by
+// AskWithRAG. The message records their IDs under RAGSourcesKey.
+const (
+	RAGContextRole = "Context"
+	RAGSourcesKey  = "rag_sources"
+)
+
+// AskWithRAG retrieves the documents relevant to question from kb, adds them to the history as
+// a numbered context message the model is asked to cite, sends the question and returns the
+// answer together with the retrieved results. rag.Citations turns the results into citations
+// numbered as in the answer. If nothing is retrieved, the question is sent on its own.
+func (a *Agent) AskWithRAG(ctx context.Context, kb *rag.KnowledgeBase, question string, opts ...rag.QueryOption) (string, []rag.RetrievalResult, error) {
+	results, err := kb.Query(ctx, question, DefaultRAGResults, opts...)
+	if err != nil {
+		return "", nil, fmt.Errorf("ask with rag: %w", err)
+	}
+
+	if len(results) > 0 {
+		var b strings.Builder
+		b.WriteString("The following information might be useful:\n")
+		ids := make([]string, len(results))
+		for i, res := range results {
+			fmt.Fprintf(&b, "[%d] (source: %s) %s\n", i+1, rag.Source(res.Doc), strings.TrimSpace(res.Doc.Text))
+			ids[i] = res.Doc.ID
+		}
+		b.WriteString("Cite the information you use by its number in square brackets, for example [1].")
+		a.AppendMessage(RAGContextRole, b.String())
+		a.setMetadata(len(a.history)-1, RAGSourcesKey, ids)
+	}
+
+	answer, err := a.Send(ctx, question)
+	if err != nil {
+		return "", results, err
+	}
+	return answer, results, nil
+}
@@ -0,0 +1,276 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/zakirkun/gatot-kaca/budget"
+	"github.com/zakirkun/gatot-kaca/llm"
+	"github.com/zakirkun/gatot-kaca/logging"
+	"github.com/zakirkun/gatot-kaca/prompt"
+)
+
+// ReActInstructions is appended to the system prompt, instead of the tool catalog, when the agent
+// uses ToolStrategyPrompt. The template data holds "Tools", the agent's tool manager.
+var ReActInstructions = prompt.Must("react_instructions", `You can use the following tools:
+{{catalog .Tools}}
+Answer using this exact format. To use a tool, write:
+Thought: <your reasoning>
+Action: <tool name>
+Action Input: <JSON object, e.g. {"input": "..."}>
+
+Then stop and wait: the result will be given to you in a "Tool Response" message.
+When you know the answer, write:
+Thought: <your reasoning>
+Final Answer: <your answer to the user>`)
+
+// DefaultMaxToolSteps is the number of tool calls the agent makes for a single message with
+// ToolStrategyPrompt unless set with WithMaxToolSteps.
+const DefaultMaxToolSteps = 5
+
+// ReActStep is a parsed model response in the ReAct format. Either Action or FinalAnswer is set,
+// unless the response does not follow the format.
+type ReActStep struct {
+	Thought     string
+	Action      string // Tool name, matched case-insensitively against the known tools.
+	Input       string // Tool input.
+	FinalAnswer string
+}
+
+// reactLabel matches a ReAct label at the start of a line, tolerating list markers, quoting,
+// headings and markdown emphasis around it.
+var reactLabel = regexp.MustCompile(`(?im)^[ \t>*_#-]*(thought|action[ _]input|action|observation|final[ _]answer)[ \t*_]*:[ \t*_]*`)
+
+// ParseReAct parses a model response in the ReAct format. It is tolerant of formatting noise:
+// labels in any case or emphasized, tool names quoted or with a different case, inputs in code
+// fences, JSON with missing closing brackets or trailing commas, and inputs on the Action line.
+// Anything after an "Observation:" written by the model itself is ignored.
+func ParseReAct(text string, toolNames []string) ReActStep {
+	var step ReActStep
+	matches := reactLabel.FindAllStringSubmatchIndex(text, -1)
+	for i, m := range matches {
+		end := len(text)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		value := strings.TrimSpace(text[m[1]:end])
+		label := strings.ToLower(strings.NewReplacer("_", " ").Replace(text[m[2]:m[3]]))
+		switch label {
+		case "thought":
+			if step.Thought == "" {
+				step.Thought = value
+			}
+		case "action":
+			if step.Action != "" {
+				return step
+			}
+			name, rest := splitAction(value)
+			step.Action = matchTool(name, toolNames)
+			if rest != "" {
+				step.Input = parseToolInput(rest)
+			}
+		case "action input":
+			if step.Action != "" && step.Input == "" {
+				step.Input = parseToolInput(value)
+			}
+		case "final answer":
+			if step.Action == "" {
+				step.FinalAnswer = value
+			}
+			return step
+		case "observation":
+			// The model made up a tool result; only the text before it is trusted.
+			return step
+		}
+	}
+	return step
+}
+
+// splitAction separates the tool name from an input written on the Action line, as in
+// "search(golang)", "search: golang" or "search {"input": "golang"}".
+func splitAction(value string) (string, string) {
+	value = strings.TrimSpace(strings.SplitN(value, "\n", 2)[0])
+	if i := strings.IndexAny(value, "([{: \t"); i > 0 {
+		name, rest := value[:i], strings.TrimSpace(value[i:])
+		if strings.HasPrefix(rest, "(") && strings.HasSuffix(rest, ")") {
+			rest = rest[1 : len(rest)-1]
+		}
+		if strings.HasPrefix(rest, "[") && strings.HasSuffix(rest, "]") && !json.Valid([]byte(rest)) {
+			rest = rest[1 : len(rest)-1]
+		}
+		return name, strings.TrimSpace(strings.TrimPrefix(rest, ":"))
+	}
+	return value, ""
+}
+
+// matchTool cleans up a tool name and matches it against the known tools.
+func matchTool(name string, toolNames []string) string {
+	name = strings.Trim(name, " \t`'\"*_[]()")
+	for _, t := range toolNames {
+		if strings.EqualFold(t, name) {
+			return t
+		}
+	}
+	return name
+}
+
+// codeFence matches a markdown code block, capturing its content.
+var codeFence = regexp.MustCompile("(?s)```[a-zA-Z]*\\s*(.*?)\\s*(```|$)")
+
+// parseToolInput turns an Action Input value into the tool input. A JSON object whose only field
+// is "input" yields that field; other JSON values are passed on compacted, and anything else is
+// passed on as plain text.
+func parseToolInput(value string) string {
+	if m := codeFence.FindStringSubmatch(value); m != nil {
+		value = m[1]
+	}
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return ""
+	}
+	switch value[0] {
+	case '{', '[':
+		data, ok := repairJSON(value)
+		if !ok {
+			return value
+		}
+		var obj map[string]interface{}
+		if json.Unmarshal(data, &obj) == nil && len(obj) == 1 {
+			if s, ok := obj["input"].(string); ok {
+				return s
+			}
+		}
+		return string(data)
+	case '"':
+		var s string
+		if json.Unmarshal([]byte(value), &s) == nil {
+			return s
+		}
+	}
+	return value
+}
+
+// trailingComma matches a comma before a closing bracket.
+var trailingComma = regexp.MustCompile(`,\s*([}\]])`)
+
+// repairJSON returns the first JSON value in s in compact form, fixing trailing commas, missing
+// closing brackets and trailing text.
+func repairJSON(s string) ([]byte, bool) {
+	// Find where the value ends, tracking strings and nesting; close what is left open.
+	var stack []byte
+	inString, escaped := false, false
+	end := len(s)
+scan:
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case escaped:
+			escaped = false
+		case inString:
+			if c == '\\' {
+				escaped = true
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '{':
+			stack = append(stack, '}')
+		case c == '[':
+			stack = append(stack, ']')
+		case c == '}' || c == ']':
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			if len(stack) == 0 {
+				end = i + 1
+				break scan
+			}
+		}
+	}
+	fixed := s[:end]
+	if inString {
+		fixed += `"`
+	}
+	for i := len(stack) - 1; i >= 0; i-- {
+		fixed += string(stack[i])
+	}
+
+	fixed = trailingComma.ReplaceAllString(fixed, "$1")
+
+	var b bytes.Buffer
+	if err := json.Compact(&b, []byte(fixed)); err != nil {
+		return nil, false
+	}
+	return b.Bytes(), true
+}
+
+// renderReActInstructions returns the ReAct instructions, or "" if no tools are registered or
+// the template fails to render, in which case the error is logged.
+func (a *Agent) renderReActInstructions() string {
+	if len(a.tools.ListTools()) == 0 {
+		return ""
+	}
+	text, err := ReActInstructions.Render(map[string]any{"Tools": a.tools})
+	if err != nil {
+		a.logFor(context.Background()).Warn("failed to render ReAct instructions", logging.KeyComponent, "agent", logging.KeyError, err)
+		return ""
+	}
+	return text
+}
+
+// MaxToolSteps returns the maximum number of tool calls made for a single message with
+// ToolStrategyPrompt.
+func (a *Agent) MaxToolSteps() int {
+	if a.maxToolSteps <= 0 {
+		return DefaultMaxToolSteps
+	}
+	return a.maxToolSteps
+}
+
+// runReAct drives the ReAct loop for a response: while the model asks for a tool, the tool is
+// called, its result is recorded in the history, and the model is asked to continue. It returns
+// the final answer, or the last response if the model stops following the format.
+func (a *Agent) runReAct(ctx context.Context, response string, onChunk llm.StreamHandler) (string, error) {
+	for steps := 0; ; steps++ {
+		step := ParseReAct(response, a.tools.ListTools())
+		if step.Action == "" {
+			if step.FinalAnswer != "" {
+				return step.FinalAnswer, nil
+			}
+			return response, nil
+		}
+
+		if steps >= a.MaxToolSteps() {
+			a.AppendMessage("System", fmt.Sprintf("The tool limit of %d calls has been reached. "+
+				"Do not call any more tools; give your Final Answer now.", a.MaxToolSteps()))
+		} else if _, err := a.tools.GetTool(step.Action); err != nil {
+			a.AppendMessage("Tool Error", fmt.Sprintf("Unknown tool %q. Available tools: %s.",
+				step.Action, strings.Join(a.tools.ListTools(), ", ")))
+		} else if _, err := a.CallTool(ctx, step.Action, step.Input); errors.Is(err, budget.ErrBudgetExceeded) {
+			return "", err
+		} else if err != nil {
+			a.AppendMessage("Tool Error", err.Error())
+		}
+
+		res, err := a.generate(ctx, onChunk)
+		if err != nil {
+			return "", err
+		}
+		if response, err = a.recordResponse(ctx, res); err != nil {
+			return "", err
+		}
+		if steps >= a.MaxToolSteps() {
+			step := ParseReAct(response, nil)
+			if step.FinalAnswer != "" {
+				return step.FinalAnswer, nil
+			}
+			return response, nil
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package agent_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/zakirkun/gatot-kaca/agent"
+	"github.com/zakirkun/gatot-kaca/llm"
+	"github.com/zakirkun/gatot-kaca/llmtest"
+)
+
+func TestParseReAct(t *testing.T) {
+	tools := []string{"search", "calculator"}
+	for _, tc := range []struct {
+		name string
+		text string
+		want agent.ReActStep
+	}{
+		{
+			name: "strict",
+			text: "Thought: I need to search.\nAction: search\nAction Input: {\"input\": \"golang generics\"}",
+			want: agent.ReActStep{Thought: "I need to search.", Action: "search", Input: "golang generics"},
+		},
+		{
+			name: "emphasis, case and fenced JSON with missing brace",
+			text: "**Thought:** compute it\n**Action:** `Calculator`\n**Action Input:**\n```json\n{\"expression\": \"2+2\", \n```",
+			want: agent.ReActStep{Thought: "compute it", Action: "calculator", Input: `{"expression":"2+2"}`},
+		},
+		{
+			name: "input on the action line, hallucinated observation",
+			text: "Action: search(weather in Jakarta)\nObservation: sunny\nFinal Answer: It is sunny.",
+			want: agent.ReActStep{Action: "search", Input: "weather in Jakarta"},
+		},
+		{
+			name: "final answer",
+			text: "Thought: I know this.\nFinal Answer: 42",
+			want: agent.ReActStep{Thought: "I know this.", FinalAnswer: "42"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := agent.ParseReAct(tc.text, tools); got != tc.want {
+				t.Errorf("got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestReActLoop(t *testing.T) {
+	llm.RegisterCapabilities("react-test", llm.Capabilities{ContextWindow: 8000})
+	model := llmtest.NewMockModel("react-test",
+		"Thought: I should search.\nAction: search\nAction Input: {\"input\": \"capital of France\"}",
+		"Thought: I have it.\nFinal Answer: Paris",
+	)
+	search := llmtest.NewMockTool("search", "Paris is the capital of France.")
+	a := llmtest.NewAgent(model, search)
+	if a.ToolStrategy() != agent.ToolStrategyPrompt {
+		t.Fatalf("expected prompt strategy, got %q", a.ToolStrategy())
+	}
+
+	answer, err := a.Send(context.Background(), "What is the capital of France?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answer != "Paris" {
+		t.Errorf("got %q, want %q", answer, "Paris")
+	}
+	search.AssertCalledWith(t, "capital of France")
+	model.AssertPromptContains(t, "Final Answer:")
+	model.AssertPromptContains(t, "Paris is the capital of France.")
+}
@@ -0,0 +1,47 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/zakirkun/gatot-kaca/budget"
+	"github.com/zakirkun/gatot-kaca/llm"
+)
+
+// SendStream behaves like Send but delivers the LLM response to onChunk as it is generated.
+// Tool commands are applied once the full response has been received, so the returned text
+// and the stored history include their output while the streamed chunks do not.
+//
+// If a middleware may change or halt responses (see ResponseModifier), streaming the raw model
+// output would bypass it, so the response is instead delivered to onChunk in one piece once the
+// middlewares have processed it.
+func (a *Agent) SendStream(ctx context.Context, userInput string, onChunk llm.StreamHandler) (string, error) {
+	ctx, err := a.startRequest(ctx, userInput)
+	if err != nil {
+		return "", err
+	}
+	ctx, run := budget.Start(ctx, a.budget)
+	defer run.Stop()
+	deliver := onChunk
+	if a.modifiesResponse() {
+		onChunk = nil
+	}
+	// Append the user's message.
+	a.AppendMessage("User", userInput)
+	start := len(a.history)
+
+	// Construct the prompt including system prompt and middleware modifications,
+	// and stream the response from the LLM client.
+	res, err := a.generate(ctx, onChunk)
+	if err != nil {
+		return "", run.Err(err, a.partialResponse(start))
+	}
+
+	output, err := a.handleResponse(ctx, res, onChunk)
+	if err != nil {
+		return output, run.Err(err, a.partialResponse(start))
+	}
+	if onChunk == nil && deliver != nil && output != "" {
+		err = deliver(output)
+	}
+	return output, err
+}
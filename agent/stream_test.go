@@ -0,0 +1,78 @@
+package agent_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/zakirkun/gatot-kaca/agent"
+	"github.com/zakirkun/gatot-kaca/llmtest"
+)
+
+// censor replaces a word in responses, or halts them if halt is set.
+type censor struct {
+	word string
+	halt bool
+}
+
+func (c censor) BeforeSend(ctx context.Context, rc *agent.RequestContext) error { return nil }
+
+func (c censor) AfterReceive(ctx context.Context, rc *agent.RequestContext) error {
+	if !strings.Contains(rc.Response, c.word) {
+		return nil
+	}
+	if c.halt {
+		return agent.Halt("blocked")
+	}
+	rc.Response = strings.ReplaceAll(rc.Response, c.word, "***")
+	return nil
+}
+
+// observer reads responses without changing them.
+type observer struct{ seen *string }
+
+func (o observer) ModifiesResponse() bool { return false }
+
+func (o observer) BeforeSend(ctx context.Context, rc *agent.RequestContext) error { return nil }
+
+func (o observer) AfterReceive(ctx context.Context, rc *agent.RequestContext) error {
+	*o.seen = rc.Response
+	return nil
+}
+
+func TestSendStreamMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		middleware agent.RequestMiddleware
+		want       string
+		wantChunks int // Zero if any number of chunks may be streamed.
+	}{
+		{name: "rewritten", middleware: censor{word: "secret"}, want: "the *** code", wantChunks: 1},
+		{name: "halted", middleware: censor{word: "secret", halt: true}, want: "blocked", wantChunks: 1},
+		{name: "observed", middleware: observer{seen: new(string)}, want: "the secret code"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := llmtest.NewAgent(llmtest.NewMockModel("stream-test").Default("the secret code"))
+			a.Use(tt.middleware)
+
+			var chunks []string
+			out, err := a.SendStream(context.Background(), "hi", func(chunk string) error {
+				chunks = append(chunks, chunk)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out != tt.want || strings.Join(chunks, "") != tt.want {
+				t.Errorf("got %q streamed as %q, want %q", out, chunks, tt.want)
+			}
+			if tt.wantChunks > 0 && len(chunks) != tt.wantChunks {
+				t.Errorf("streamed %d chunks, want the processed response in %d", len(chunks), tt.wantChunks)
+			}
+			if tt.wantChunks == 0 && len(chunks) < 2 {
+				t.Errorf("streamed %q, want the response as it is generated", chunks)
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// SendStructured sends userInput and asks the model to answer with JSON matching the schema of
+// out, which must be a non-nil pointer. The response is decoded into out; if it is not valid
+// JSON, contains unknown fields or misses required fields, the error is sent back to the model
+// and the request retried up to StructuredRetries times (2 by default).
+//
+// The JSON schema is derived from the Go type: field names follow `json` tags, fields without
+// omitempty are required, and a `description` tag documents a field.
+func (a *Agent) SendStructured(ctx context.Context, userInput string, out interface{}) error {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New("SendStructured requires a non-nil pointer")
+	}
+	schema, err := json.MarshalIndent(JSONSchema(rv.Type().Elem()), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	retries := a.StructuredRetries
+	if retries <= 0 {
+		retries = 2
+	}
+
+	input := fmt.Sprintf("%s\n\nRespond with only a JSON value that matches this JSON schema, without any other text:\n%s", userInput, schema)
+	var lastErr error
+	for attempt := 0; attempt <= retries; attempt++ {
+		response, err := a.Send(ctx, input)
+		if err != nil {
+			return err
+		}
+		rv.Elem().Set(reflect.Zero(rv.Type().Elem()))
+		if lastErr = decodeStructured(response, rv.Type().Elem(), out); lastErr == nil {
+			return nil
+		}
+		input = fmt.Sprintf("Your previous response was invalid: %v\nRespond again with only the corrected JSON value matching the schema.", lastErr)
+	}
+	return fmt.Errorf("model did not return valid structured output after %d attempts: %w", retries+1, lastErr)
+}
+
+// decodeStructured extracts the JSON value from a response and decodes it into out, checking
+// required fields of a top-level struct.
+func decodeStructured(response string, t reflect.Type, out interface{}) error {
+	data := extractJSON(response)
+	if data == "" {
+		return errors.New("no JSON value found in response")
+	}
+
+	dec := json.NewDecoder(strings.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(out); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t == reflect.TypeOf(time.Time{}) {
+		return nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(data), &fields); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	var missing []string
+	for _, f := range structFields(t) {
+		if !f.omitEmpty {
+			if _, ok := fields[f.name]; !ok {
+				missing = append(missing, f.name)
+			}
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing required fields: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// ExtractJSON returns the JSON value in text, unwrapping Markdown code fences and ignoring any
+// prose around the outermost object or array, or "" if there is none.
+func ExtractJSON(text string) string {
+	return extractJSON(text)
+}
+
+// extractJSON returns the JSON value in text, unwrapping Markdown code fences and
+// ignoring any prose around the outermost object or array.
+func extractJSON(text string) string {
+	text = strings.TrimSpace(text)
+	if start := strings.Index(text, "```"); start >= 0 {
+		rest := text[start+3:]
+		if nl := strings.Index(rest, "\n"); nl >= 0 {
+			rest = rest[nl+1:]
+		}
+		if end := strings.Index(rest, "```"); end >= 0 {
+			text = strings.TrimSpace(rest[:end])
+		}
+	}
+
+	start := strings.IndexAny(text, "{[")
+	if start < 0 {
+		if json.Valid([]byte(text)) {
+			return text
+		}
+		return ""
+	}
+	closer := byte('}')
+	if text[start] == '[' {
+		closer = ']'
+	}
+	end := strings.LastIndexByte(text, closer)
+	if end < start {
+		return ""
+	}
+	candidate := text[start : end+1]
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(candidate)); err != nil {
+		// Return it anyway so the decode error is reported back to the model.
+		return candidate
+	}
+	return buf.String()
+}
+
+// jsonField describes a struct field as seen by encoding/json.
+type jsonField struct {
+	name        string
+	omitEmpty   bool
+	description string
+	typ         reflect.Type
+}
+
+// structFields returns the exported fields of a struct type that encoding/json serializes.
+func structFields(t reflect.Type) []jsonField {
+	var fields []jsonField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name := f.Name
+		omitEmpty := false
+		if tag, ok := f.Tag.Lookup("json"); ok {
+			parts := strings.Split(tag, ",")
+			if parts[0] == "-" && len(parts) == 1 {
+				continue
+			}
+			if parts[0] != "" {
+				name = parts[0]
+			}
+			for _, opt := range parts[1:] {
+				if opt == "omitempty" {
+					omitEmpty = true
+				}
+			}
+		}
+		fields = append(fields, jsonField{
+			name:        name,
+			omitEmpty:   omitEmpty,
+			description: f.Tag.Get("description"),
+			typ:         f.Type,
+		})
+	}
+	return fields
+}
+
+// JSONSchema returns a JSON schema describing how values of type t are encoded by encoding/json.
+func JSONSchema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(time.Time{}) {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]interface{}{"type": "array", "items": JSONSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": JSONSchema(t.Elem())}
+	case reflect.Struct:
+		properties := map[string]interface{}{}
+		required := []string{}
+		for _, f := range structFields(t) {
+			prop := JSONSchema(f.typ)
+			if f.description != "" {
+				prop["description"] = f.description
+			}
+			properties[f.name] = prop
+			if !f.omitEmpty {
+				required = append(required, f.name)
+			}
+		}
+		return map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"required":             required,
+			"additionalProperties": false,
+		}
+	default:
+		return map[string]interface{}{}
+	}
+}
@@ -0,0 +1,149 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/zakirkun/gatot-kaca/agent/tools"
+	"github.com/zakirkun/gatot-kaca/llm"
+	"github.com/zakirkun/gatot-kaca/logging"
+)
+
+// Session metadata keys set by GenerateTitle.
+const (
+	TitleKey = "title"
+	TagsKey  = "tags"
+)
+
+// TitleOptions configure automatic conversation titling.
+type TitleOptions struct {
+	// Model generates the title; a small, cheap model is usually enough. Defaults to the
+	// agent's model.
+	Model string
+	// AfterTurns is the number of user messages after which the title is generated; defaults to 2.
+	AfterTurns int
+	MaxTags    int // Defaults to 5.
+}
+
+// ConversationTitle is a short title and topic tags describing a conversation.
+type ConversationTitle struct {
+	Title string   `json:"title"`
+	Tags  []string `json:"tags"`
+}
+
+// SessionMetadata returns a copy of the conversation-level metadata, such as the title and tags
+// set by GenerateTitle. It is saved with the history in JSON transcripts.
+func (a *Agent) SessionMetadata() map[string]interface{} {
+	out := make(map[string]interface{}, len(a.metadata))
+	for k, v := range a.metadata {
+		out[k] = v
+	}
+	return out
+}
+
+// SetSessionMetadata stores a conversation-level metadata value.
+func (a *Agent) SetSessionMetadata(key string, value interface{}) {
+	if a.metadata == nil {
+		a.metadata = make(map[string]interface{})
+	}
+	a.metadata[key] = value
+}
+
+// Title returns the conversation title set by GenerateTitle, or "".
+func (a *Agent) Title() string {
+	s, _ := a.metadata[TitleKey].(string)
+	return s
+}
+
+// Tags returns the topic tags set by GenerateTitle.
+func (a *Agent) Tags() []string {
+	switch tags := a.metadata[TagsKey].(type) {
+	case []string:
+		return tags
+	case []interface{}:
+		// Tags loaded from a JSON transcript.
+		out := make([]string, 0, len(tags))
+		for _, t := range tags {
+			if s, ok := t.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// GenerateTitle asks the model for a short title and topic tags for the conversation so far and
+// stores them in the session metadata under TitleKey and TagsKey, for conversation listings and
+// retrieval. The title options set with WithAutoTitle are used, if any.
+func (a *Agent) GenerateTitle(ctx context.Context) (ConversationTitle, error) {
+	opts := a.titleOptions
+	if opts == nil {
+		opts = &TitleOptions{}
+	}
+	model := opts.Model
+	if model == "" {
+		model = a.modelName
+	}
+	maxTags := opts.MaxTags
+	if maxTags <= 0 {
+		maxTags = 5
+	}
+	if len(a.history) == 0 {
+		return ConversationTitle{}, fmt.Errorf("generate title: history is empty")
+	}
+
+	prompt := fmt.Sprintf("Give the following conversation a short title of at most 8 words and up to %d "+
+		"lowercase topic tags. Respond with only a JSON object like {\"title\": \"...\", \"tags\": [\"...\"]}.\n\n"+
+		"Conversation:\n%s", maxTags, tools.Truncate(formatPrompt(a.history), summarizeInputLimit))
+	res, err := a.client.Generate(ctx, model, llm.ModelRequest{Prompt: prompt, Temperature: 0, MaxTokens: 100})
+	if err != nil {
+		return ConversationTitle{}, fmt.Errorf("generate title: %w", err)
+	}
+
+	var title ConversationTitle
+	if err := json.Unmarshal([]byte(extractJSON(res.Text)), &title); err != nil || title.Title == "" {
+		// Fall back to the first line of a plain-text answer.
+		title = ConversationTitle{Title: strings.TrimSpace(strings.SplitN(res.Text, "\n", 2)[0])}
+	}
+	title.Title = strings.Trim(title.Title, "\"'# ")
+	if title.Title == "" {
+		return ConversationTitle{}, fmt.Errorf("generate title: empty response")
+	}
+	if len(title.Tags) > maxTags {
+		title.Tags = title.Tags[:maxTags]
+	}
+	for i, tag := range title.Tags {
+		title.Tags[i] = strings.ToLower(strings.TrimSpace(tag))
+	}
+
+	a.SetSessionMetadata(TitleKey, title.Title)
+	a.SetSessionMetadata(TagsKey, title.Tags)
+	return title, nil
+}
+
+// autoTitle generates the title once the conversation has enough user turns, if WithAutoTitle
+// is set and no title exists yet. Failures are logged and retried on the next response.
+func (a *Agent) autoTitle(ctx context.Context) {
+	if a.titleOptions == nil || a.Title() != "" {
+		return
+	}
+	after := a.titleOptions.AfterTurns
+	if after <= 0 {
+		after = 2
+	}
+	turns := 0
+	for _, msg := range a.history {
+		if msg.Role == "User" {
+			turns++
+		}
+	}
+	if turns < after {
+		return
+	}
+	if _, err := a.GenerateTitle(ctx); err != nil {
+		a.logFor(ctx).Warn("failed to generate title", logging.KeyComponent, "agent", logging.KeyError, err)
+	}
+}
@@ -0,0 +1,141 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/zakirkun/gatot-kaca/agent/tools"
+	"github.com/zakirkun/gatot-kaca/llm"
+)
+
+// Message metadata keys used to record tool invocations in the conversation history.
+const (
+	ToolNameKey  = "tool_name"
+	ToolErrorKey = "tool_error"
+)
+
+// PromptVersionKey is the message metadata key recording the stored prompt version
+// (see Agent.UsePrompt) that produced an assistant response.
+const PromptVersionKey = "prompt_version"
+
+// ToolCall describes a single tool invocation made by the agent.
+type ToolCall struct {
+	Name   string `json:"name"`
+	Input  string `json:"input"`
+	Output string `json:"output,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ToolCalls returns the tool invocations recorded in the conversation history, in call order.
+func (a *Agent) ToolCalls() []ToolCall {
+	var calls []ToolCall
+	for i, msg := range a.history {
+		name, ok := msg.Metadata[ToolNameKey].(string)
+		if !ok || !strings.HasPrefix(msg.Role, "Tool Call") {
+			continue
+		}
+		call := ToolCall{Name: name, Input: msg.Content}
+		if errMsg, ok := msg.Metadata[ToolErrorKey].(string); ok {
+			call.Error = errMsg
+		} else if i+1 < len(a.history) && strings.HasPrefix(a.history[i+1].Role, "Tool Response") {
+			call.Output = a.history[i+1].Content
+		}
+		calls = append(calls, call)
+	}
+	return calls
+}
+
+// DefaultMaxParallelTools is the number of tools CallTools runs at the same time
+// unless capped with WithMaxParallelTools.
+const DefaultMaxParallelTools = 4
+
+// MaxParallelTools returns the maximum number of tools CallTools runs at the same time.
+func (a *Agent) MaxParallelTools() int {
+	if a.maxParallelTools <= 0 {
+		return DefaultMaxParallelTools
+	}
+	return a.maxParallelTools
+}
+
+// CallTools executes several tool calls concurrently, at most MaxParallelTools at a time, and
+// returns them with Output or Error filled in. Outputs are limited by the tools' output
+// policies. The calls are recorded in the conversation history in the given order once all of
+// them have finished, so the history does not depend on which tool completes first. Calls to
+// unknown tools fail without being recorded, as with CallTool.
+func (a *Agent) CallTools(ctx context.Context, calls []ToolCall) []ToolCall {
+	results := make([]ToolCall, len(calls))
+	errs := make([]error, len(calls))
+	known := make([]bool, len(calls))
+
+	sem := make(chan struct{}, a.MaxParallelTools())
+	var wg sync.WaitGroup
+	for i, call := range calls {
+		results[i] = ToolCall{Name: call.Name, Input: call.Input}
+		if _, err := a.tools.GetTool(call.Name); err != nil {
+			errs[i] = err
+			continue
+		}
+		known[i] = true
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i].Output, errs[i] = a.executeTool(ctx, results[i].Name, results[i].Input)
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range results {
+		if errs[i] != nil {
+			results[i].Output = ""
+			results[i].Error = errs[i].Error()
+		}
+		if known[i] {
+			a.recordToolCall(results[i].Name, results[i].Input, results[i].Output, errs[i])
+		}
+	}
+	return results
+}
+
+// summarizeInputLimit caps the tool output sent to the model for summarization, so that the
+// summarization request itself fits in the context window.
+const summarizeInputLimit = 32000
+
+// limitToolOutput applies the tool's output policy, summarizing with the agent's model when
+// the policy asks for it.
+func (a *Agent) limitToolOutput(ctx context.Context, name, output string) string {
+	return a.tools.LimitOutput(ctx, name, output, a.summarizeToolOutput)
+}
+
+// summarizeToolOutput implements tools.Summarizer using the agent's model.
+func (a *Agent) summarizeToolOutput(ctx context.Context, name, output string, maxChars int) (string, error) {
+	prompt := fmt.Sprintf("The output of the tool %q is too long. Summarize it in at most %d characters, "+
+		"keeping the details needed to use it, such as names, numbers, identifiers and errors.\n\nOutput:\n%s",
+		name, maxChars, tools.Truncate(output, summarizeInputLimit))
+	res, err := a.client.Generate(ctx, a.modelName, llm.ModelRequest{Prompt: prompt, Temperature: 0})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(res.Text), nil
+}
+
+// recordToolCall appends a tool invocation and, if it succeeded, its response to the history.
+func (a *Agent) recordToolCall(name, input, output string, err error) {
+	a.AppendMessage("Tool Call ("+name+")", input)
+	callIndex := len(a.history) - 1
+	a.setMetadata(callIndex, ToolNameKey, name)
+	if err != nil {
+		a.setMetadata(callIndex, ToolErrorKey, err.Error())
+		return
+	}
+	a.AppendMessage("Tool Response ("+name+")", output)
+	a.setMetadata(len(a.history)-1, ToolNameKey, name)
+}
+
+// setMetadata stores a metadata value on the message at index.
+func (a *Agent) setMetadata(index int, key string, value interface{}) {
+	a.history[index].SetMetadata(key, value)
+}
@@ -0,0 +1,71 @@
+package agent
+
+import (
+	"context"
+	"time"
+
+	"github.com/zakirkun/gatot-kaca/budget"
+)
+
+// Tool event types reported to a ToolEventHandler.
+const (
+	ToolCallStart = "tool_call_start"
+	ToolCallEnd   = "tool_call_end"
+)
+
+// ToolEvent reports a tool invocation starting or ending.
+type ToolEvent struct {
+	Type string // ToolCallStart or ToolCallEnd.
+	// Call holds the tool name and input, and once the call ended its output or error.
+	Call ToolCall
+	// Duration is how long the call took; it is zero for ToolCallStart.
+	Duration time.Duration
+}
+
+// ToolEventHandler receives tool events. Tools run concurrently by CallTools report their events
+// from several goroutines, so the handler must be safe for concurrent use.
+type ToolEventHandler func(ToolEvent)
+
+type toolEventsKey struct{}
+
+// ContextWithToolEvents returns a context whose tool calls, made by Send or directly with
+// CallTool and CallTools, are reported to h.
+func ContextWithToolEvents(ctx context.Context, h ToolEventHandler) context.Context {
+	return context.WithValue(ctx, toolEventsKey{}, h)
+}
+
+// toolEventHandler returns a ToolEventHandler reporting to the agent's handlers and to that of ctx.
+func (a *Agent) toolEventHandler(ctx context.Context) ToolEventHandler {
+	handlers := a.toolHandlers
+	if h, ok := ctx.Value(toolEventsKey{}).(ToolEventHandler); ok && h != nil {
+		handlers = append(handlers[:len(handlers):len(handlers)], h)
+	}
+	return func(e ToolEvent) {
+		for _, h := range handlers {
+			h(e)
+		}
+	}
+}
+
+// executeTool runs a tool, reporting its start and end to the ToolEventHandlers of the agent
+// and ctx.
+func (a *Agent) executeTool(ctx context.Context, name, input string) (string, error) {
+	if err := budget.FromContext(ctx).BeginToolCall(); err != nil {
+		return "", err
+	}
+	emit := a.toolEventHandler(ctx)
+	emit(ToolEvent{Type: ToolCallStart, Call: ToolCall{Name: name, Input: input}})
+	start := time.Now()
+
+	output, err := a.tools.ExecuteTool(ctx, name, input)
+	if err == nil {
+		output = a.limitToolOutput(ctx, name, output)
+	}
+
+	call := ToolCall{Name: name, Input: input, Output: output}
+	if err != nil {
+		call.Output, call.Error = "", err.Error()
+	}
+	emit(ToolEvent{Type: ToolCallEnd, Call: call, Duration: time.Since(start)})
+	return output, err
+}
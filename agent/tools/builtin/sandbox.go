@@ -0,0 +1,256 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// sandboxLanguage describes how to run a snippet of one language.
+type sandboxLanguage struct {
+	file    string
+	command []string
+}
+
+var sandboxLanguages = map[string]sandboxLanguage{
+	"python":     {"main.py", []string{"python3", "main.py"}},
+	"javascript": {"main.js", []string{"node", "main.js"}},
+	"go":         {"main.go", []string{"go", "run", "main.go"}},
+}
+
+var languageAliases = map[string]string{
+	"py": "python", "python3": "python",
+	"js": "javascript", "node": "javascript",
+	"golang": "go",
+}
+
+// CodeTool runs short Python, JavaScript or Go snippets in a constrained subprocess. Each run
+// gets a fresh temporary directory as its working, home and Go cache directory, a minimal
+// environment, a timeout, and CPU, memory and file size limits. On Linux the snippet runs in
+// its own network namespace, so it has no network access unless Network is set; on other
+// platforms Network must be set explicitly, since the isolation is unavailable. Since Go
+// snippets do not share a build cache, they compile the packages they use on every run.
+//
+// The limits make the tool suitable for computation requested by a model, not for running
+// hostile code: file access is not isolated, so the snippet can still read and write the files
+// the host user can.
+//
+// The input is a JSON object or a fenced code block:
+//
+//	{"language": "python", "code": "print(2**100)"}
+type CodeTool struct {
+	Languages []string      // Enabled languages; defaults to python, javascript and go.
+	Timeout   time.Duration // Wall-clock limit; defaults to 10 seconds, or 1 minute for Go.
+	MemoryMB  int           // Address space limit; defaults to 1024. Go and Node need generous limits.
+	Network   bool          // Allow network access.
+	MaxOutput int           // Maximum bytes kept of stdout and of stderr; defaults to 10000.
+}
+
+// NewCodeTool creates a CodeTool with the default limits and no network access.
+func NewCodeTool() *CodeTool {
+	return &CodeTool{}
+}
+
+// Name implements tools.Tool.
+func (t *CodeTool) Name() string { return "run_code" }
+
+// Description implements tools.Tool.
+func (t *CodeTool) Description() string {
+	return fmt.Sprintf("Runs a short %s program in a sandbox and returns its exit code and output.",
+		strings.Join(t.languages(), ", "))
+}
+
+// Schema implements tools.EnhancedTool.
+func (t *CodeTool) Schema() string {
+	langs, _ := json.Marshal(t.languages())
+	return `{"type":"object","properties":{"language":{"type":"string","enum":` + string(langs) +
+		`},"code":{"type":"string","description":"complete program; print the results"}},"required":["language","code"]}`
+}
+
+// Help implements tools.EnhancedTool.
+func (t *CodeTool) Help() string {
+	help := `Pass {"language": "python", "code": "print(1 + 1)"}. Print the values you need; ` +
+		fmt.Sprintf("runs are limited to %s", t.timeout(""))
+	for _, l := range t.languages() {
+		if l == "go" && t.Timeout <= 0 {
+			help += fmt.Sprintf(" (%s for Go)", t.timeout(l))
+		}
+	}
+	if !t.Network {
+		help += " and have no network access"
+	}
+	return help + "."
+}
+
+func (t *CodeTool) languages() []string {
+	if len(t.Languages) > 0 {
+		return t.Languages
+	}
+	return []string{"python", "javascript", "go"}
+}
+
+// timeout returns the wall-clock limit of runs of lang.
+func (t *CodeTool) timeout(lang string) time.Duration {
+	if t.Timeout > 0 {
+		return t.Timeout
+	}
+	if lang == "go" {
+		return time.Minute
+	}
+	return 10 * time.Second
+}
+
+// codeInput is the tool input.
+type codeInput struct {
+	Language string `json:"language"`
+	Code     string `json:"code"`
+}
+
+var fencedCode = regexp.MustCompile("(?s)```([\\w+-]*)\\s*\\n(.*?)```")
+
+// parseCodeInput accepts a JSON object or a fenced code block.
+func parseCodeInput(input string) (codeInput, error) {
+	input = strings.TrimSpace(input)
+	var in codeInput
+	if strings.HasPrefix(input, "{") {
+		if err := json.Unmarshal([]byte(input), &in); err != nil {
+			return in, fmt.Errorf("run_code: invalid input: %w", err)
+		}
+	} else if m := fencedCode.FindStringSubmatch(input); m != nil {
+		in = codeInput{Language: m[1], Code: m[2]}
+	} else {
+		return in, errors.New(`run_code: expected {"language": ..., "code": ...} or a fenced code block`)
+	}
+	in.Language = strings.ToLower(in.Language)
+	if alias, ok := languageAliases[in.Language]; ok {
+		in.Language = alias
+	}
+	return in, nil
+}
+
+// Execute implements tools.Tool. A non-zero exit code is reported in the output, not as an error.
+func (t *CodeTool) Execute(ctx context.Context, input string) (string, error) {
+	in, err := parseCodeInput(input)
+	if err != nil {
+		return "", err
+	}
+	lang, ok := sandboxLanguages[in.Language]
+	enabled := false
+	for _, l := range t.languages() {
+		enabled = enabled || l == in.Language
+	}
+	if !ok || !enabled {
+		return "", fmt.Errorf("run_code: unsupported language %q", in.Language)
+	}
+	if !t.Network && !networkIsolation {
+		return "", errors.New("run_code: network isolation is not supported on this platform; set Network to allow network access")
+	}
+
+	dir, err := os.MkdirTemp("", "gatotkaca-sandbox-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+	if err := os.WriteFile(filepath.Join(dir, lang.file), []byte(in.Code), 0600); err != nil {
+		return "", err
+	}
+
+	memoryMB := t.MemoryMB
+	if memoryMB <= 0 {
+		memoryMB = 1024
+	}
+	timeout := t.timeout(in.Language)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// The shell applies the resource limits and then replaces itself with the interpreter.
+	// The file size limit is in 512-byte blocks and leaves room for the Go linker's output.
+	script := fmt.Sprintf("ulimit -v %d; ulimit -t %d; ulimit -f %d; exec \"$@\"",
+		memoryMB*1024, int(timeout.Seconds())+1, 128*2048)
+	cmd := exec.CommandContext(ctx, "/bin/sh", append([]string{"-c", script, "sh"}, lang.command...)...)
+	cmd.Dir = dir
+	cmd.Env = sandboxEnv(dir)
+	maxOutput := t.MaxOutput
+	if maxOutput <= 0 {
+		maxOutput = 10000
+	}
+	stdout := &limitedBuffer{max: maxOutput}
+	stderr := &limitedBuffer{max: maxOutput}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	configureSandbox(cmd, t.Network)
+
+	err = cmd.Run()
+	exitCode := 0
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		return "", fmt.Errorf("run_code: timed out after %s", timeout)
+	case errors.As(err, &exitErr):
+		exitCode = exitErr.ExitCode()
+	case err != nil:
+		return "", fmt.Errorf("run_code: %w", err)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "exit code: %d\n", exitCode)
+	if stdout.Len() > 0 {
+		b.WriteString("stdout:\n" + stdout.String() + "\n")
+	}
+	if stderr.Len() > 0 {
+		b.WriteString("stderr:\n" + stderr.String() + "\n")
+	}
+	return strings.TrimRight(b.String(), "\n"), nil
+}
+
+// sandboxEnv returns a minimal environment using dir as the home directory and for the Go
+// build cache and GOPATH, so that runs share no state through them.
+func sandboxEnv(dir string) []string {
+	env := []string{
+		"PATH=" + os.Getenv("PATH"),
+		"HOME=" + dir,
+		"TMPDIR=" + dir,
+		"LANG=C.UTF-8",
+		"GO111MODULE=off",
+		"GOCACHE=" + filepath.Join(dir, ".cache", "go-build"),
+		"GOPATH=" + filepath.Join(dir, "go"),
+	}
+	if root := os.Getenv("GOROOT"); root != "" {
+		env = append(env, "GOROOT="+root)
+	}
+	return env
+}
+
+// limitedBuffer keeps the first max bytes written to it and discards the rest.
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.buf.Len(); room < len(p) {
+		b.truncated = true
+		if room > 0 {
+			b.buf.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) Len() int { return b.buf.Len() }
+
+func (b *limitedBuffer) String() string {
+	if b.truncated {
+		return b.buf.String() + "\n... [output truncated]"
+	}
+	return b.buf.String()
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package builtin
+
+import "os/exec"
+
+// networkIsolation reports whether snippets can be run without network access.
+const networkIsolation = false
+
+// configureSandbox has nothing to configure on this platform.
+func configureSandbox(cmd *exec.Cmd, network bool) {}
@@ -0,0 +1,42 @@
+package builtin_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/zakirkun/gatot-kaca/agent/tools/builtin"
+)
+
+func TestCodeToolRunsInOwnDirectories(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 not available")
+	}
+	t.Setenv("GOCACHE", t.TempDir())
+	tool := &builtin.CodeTool{Languages: []string{"python"}, Network: true}
+	code := `{"language": "python", "code": "import os\nprint(os.getcwd())\nprint(os.environ['HOME'])\nprint(os.environ['GOCACHE'])\nprint(os.environ['GOPATH'])"}`
+
+	var dirs []string
+	for i := 0; i < 2; i++ {
+		out, err := tool.Execute(context.Background(), code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimPrefix(out, "exit code: 0\nstdout:\n"), "\n")
+		if len(lines) != 4 {
+			t.Fatalf("unexpected output %q", out)
+		}
+		dir := lines[0]
+		for _, p := range lines[1:] {
+			if p != dir && !strings.HasPrefix(p, dir+string(os.PathSeparator)) {
+				t.Errorf("%q is outside the run directory %q", p, dir)
+			}
+		}
+		dirs = append(dirs, dir)
+	}
+	if dirs[0] == dirs[1] {
+		t.Errorf("both runs used %q", dirs[0])
+	}
+}
@@ -0,0 +1,369 @@
+// Package builtin provides ready-made tools for common agent tasks: querying SQL databases,
+// reading web pages and running code in a sandbox.
+package builtin
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// SQL output formats.
+const (
+	FormatMarkdown = "markdown"
+	FormatJSON     = "json"
+)
+
+// readOnlyStatements are the statements allowed by default.
+var readOnlyStatements = []string{"SELECT", "WITH", "EXPLAIN"}
+
+// writeKeywords may not appear anywhere in a statement in read-only mode, which also rejects
+// data-modifying common table expressions such as "WITH x AS (DELETE ...)".
+var writeKeywords = map[string]bool{
+	"INSERT": true, "UPDATE": true, "DELETE": true, "MERGE": true, "REPLACE": true, "UPSERT": true,
+	"CREATE": true, "ALTER": true, "DROP": true, "TRUNCATE": true, "RENAME": true,
+	"GRANT": true, "REVOKE": true, "ATTACH": true, "DETACH": true, "PRAGMA": true,
+	"VACUUM": true, "COPY": true, "CALL": true, "EXEC": true, "EXECUTE": true, "LOCK": true,
+	"INTO": true, // SELECT ... INTO creates a table in several databases.
+}
+
+// SQLTool runs SQL queries through database/sql and returns the results as a table.
+//
+// The input is either a plain SQL statement or a JSON object with placeholders and their
+// arguments, which are passed to the driver instead of being interpolated into the query:
+//
+//	{"query": "SELECT name FROM users WHERE id = ?", "args": [42]}
+//
+// By default only a single read-only statement is allowed (SELECT, WITH or EXPLAIN, without
+// write keywords); this is a safeguard, and the database user should still only have the
+// privileges the agent needs. Quoting that databases parse differently, such as backslash
+// escapes and dollar quotes, is rejected; values can be passed as placeholders instead.
+type SQLTool struct {
+	DB *sql.DB
+	// Statements lists the allowed leading keywords, e.g. "SELECT" or "INSERT". Empty allows
+	// SELECT, WITH and EXPLAIN. AllowWrites must be set to allow statements that write.
+	Statements  []string
+	AllowWrites bool
+
+	MaxRows      int           // Maximum rows returned; defaults to 100.
+	MaxColumns   int           // Maximum columns returned; defaults to 20.
+	MaxCellChars int           // Maximum characters per value; defaults to 200.
+	Format       string        // FormatMarkdown (default) or FormatJSON.
+	Timeout      time.Duration // Query timeout; defaults to 30 seconds.
+	// Info describes the database to the model, e.g. its tables; it is added to the description.
+	Info string
+}
+
+// NewSQLTool creates a read-only SQL tool for db.
+func NewSQLTool(db *sql.DB) *SQLTool {
+	return &SQLTool{DB: db}
+}
+
+// Name implements tools.Tool.
+func (t *SQLTool) Name() string { return "sql" }
+
+// Description implements tools.Tool.
+func (t *SQLTool) Description() string {
+	desc := "Runs a single SQL query against the database and returns the result as a table."
+	if !t.AllowWrites {
+		desc += " Only read-only queries are allowed."
+	}
+	if t.Info != "" {
+		desc += " " + t.Info
+	}
+	return desc
+}
+
+// Schema implements tools.EnhancedTool.
+func (t *SQLTool) Schema() string {
+	return `{"oneOf":[{"type":"string","description":"SQL statement"},{"type":"object","properties":{"query":{"type":"string","description":"SQL statement with placeholders"},"args":{"type":"array","description":"placeholder values"}},"required":["query"]}]}`
+}
+
+// Help implements tools.EnhancedTool.
+func (t *SQLTool) Help() string {
+	return `Pass a SQL statement, or {"query": "... WHERE id = ?", "args": [42]} to use placeholders.`
+}
+
+// sqlInput is the JSON form of the tool input.
+type sqlInput struct {
+	Query string        `json:"query"`
+	Args  []interface{} `json:"args"`
+}
+
+// Execute implements tools.Tool.
+func (t *SQLTool) Execute(ctx context.Context, input string) (string, error) {
+	in := sqlInput{Query: strings.TrimSpace(input)}
+	if strings.HasPrefix(in.Query, "{") {
+		if err := json.Unmarshal([]byte(in.Query), &in); err != nil {
+			return "", fmt.Errorf("sql: invalid input: %w", err)
+		}
+	}
+	if err := t.CheckStatement(in.Query); err != nil {
+		return "", err
+	}
+
+	timeout := t.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	rows, err := t.DB.QueryContext(ctx, in.Query, in.Args...)
+	if err != nil {
+		return "", fmt.Errorf("sql: %w", err)
+	}
+	defer rows.Close()
+	return t.formatRows(rows)
+}
+
+// CheckStatement returns an error if query is not a single statement allowed by the tool.
+func (t *SQLTool) CheckStatement(query string) error {
+	words, multiple, err := sqlWords(query)
+	if err != nil {
+		return err
+	}
+	if len(words) == 0 {
+		return fmt.Errorf("sql: empty query")
+	}
+	if multiple {
+		return fmt.Errorf("sql: only a single statement is allowed")
+	}
+
+	allowed := t.Statements
+	if len(allowed) == 0 {
+		allowed = readOnlyStatements
+	}
+	ok := false
+	for _, s := range allowed {
+		if strings.EqualFold(s, words[0]) {
+			ok = true
+			break
+		}
+	}
+	if !ok {
+		return fmt.Errorf("sql: %s statements are not allowed", words[0])
+	}
+	if !t.AllowWrites {
+		for _, w := range words {
+			if writeKeywords[w] {
+				return fmt.Errorf("sql: %s is not allowed in read-only mode", w)
+			}
+		}
+	}
+	return nil
+}
+
+// sqlWords returns the upper-cased keywords and identifiers of query, ignoring comments,
+// string literals and quoted identifiers, and reports whether it has more than one statement.
+//
+// Databases disagree on where some literals and comments end, so a query that hides a second
+// statement from one dialect could run it in another. sqlWords returns an error instead of
+// guessing for backslashes in quotes (escapes in MySQL and E'...' strings, but not in standard
+// strings), dollar quotes, nested and MySQL executable comments, # comments and -- not followed
+// by a space.
+func sqlWords(query string) (words []string, multiple bool, err error) {
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			words = append(words, strings.ToUpper(word.String()))
+			word.Reset()
+		}
+	}
+	ended := false
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '-' && i+1 < len(query) && query[i+1] == '-':
+			if i+2 < len(query) && !isSQLSpace(query[i+2]) {
+				return nil, false, fmt.Errorf("sql: -- must be followed by a space to start a comment")
+			}
+			flush()
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+		case c == '#':
+			return nil, false, fmt.Errorf("sql: # is not allowed outside quotes")
+		case c == '/' && i+1 < len(query) && query[i+1] == '*':
+			if i+2 < len(query) && query[i+2] == '!' {
+				return nil, false, fmt.Errorf("sql: executable comments are not allowed")
+			}
+			flush()
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				end = len(query) - i - 2
+			}
+			if strings.Contains(query[i+2:i+2+end], "/*") {
+				return nil, false, fmt.Errorf("sql: nested comments are not allowed")
+			}
+			i += end + 3
+		case c == '\'' || c == '"' || c == '`':
+			flush()
+			for i++; i < len(query); i++ {
+				if query[i] == '\\' {
+					return nil, false, fmt.Errorf("sql: backslashes in quoted strings are not allowed, use placeholders")
+				}
+				if query[i] == c {
+					if i+1 < len(query) && query[i+1] == c {
+						i++ // Escaped quote.
+						continue
+					}
+					break
+				}
+			}
+		case c == '$' && word.Len() == 0 && isDollarQuote(query[i:]):
+			return nil, false, fmt.Errorf("sql: dollar-quoted strings are not allowed, use placeholders")
+		case c == ';':
+			flush()
+			ended = true
+		case c == '_' || c < 0x80 && (unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c))):
+			if ended {
+				return words, true, nil
+			}
+			word.WriteByte(c)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return words, false, nil
+}
+
+// isSQLSpace reports whether c is whitespace that ends a -- comment marker.
+func isSQLSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
+
+// isDollarQuote reports whether s starts with a PostgreSQL dollar quote, $$ or $tag$.
+// Positional parameters such as $1 are not quotes, since tags cannot start with a digit.
+func isDollarQuote(s string) bool {
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '$':
+			return true
+		case c == '_' || c < 0x80 && unicode.IsLetter(rune(c)) || i > 1 && c >= '0' && c <= '9':
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// formatRows reads up to MaxRows rows and formats them.
+func (t *SQLTool) formatRows(rows *sql.Rows) (string, error) {
+	maxRows, maxCols, maxCell := t.MaxRows, t.MaxColumns, t.MaxCellChars
+	if maxRows <= 0 {
+		maxRows = 100
+	}
+	if maxCols <= 0 {
+		maxCols = 20
+	}
+	if maxCell <= 0 {
+		maxCell = 200
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", fmt.Errorf("sql: %w", err)
+	}
+	shown := columns
+	if len(shown) > maxCols {
+		shown = shown[:maxCols]
+	}
+
+	var table [][]string
+	truncated := false
+	values := make([]interface{}, len(columns))
+	ptrs := make([]interface{}, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	for rows.Next() {
+		if len(table) == maxRows {
+			truncated = true
+			break
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return "", fmt.Errorf("sql: %w", err)
+		}
+		row := make([]string, len(shown))
+		for i := range shown {
+			row[i] = truncateCell(formatValue(values[i]), maxCell)
+		}
+		table = append(table, row)
+	}
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("sql: %w", err)
+	}
+
+	var notes []string
+	if truncated {
+		notes = append(notes, fmt.Sprintf("only the first %d rows are shown", maxRows))
+	}
+	if len(columns) > len(shown) {
+		notes = append(notes, fmt.Sprintf("only the first %d of %d columns are shown", len(shown), len(columns)))
+	}
+
+	if t.Format == FormatJSON {
+		records := make([]map[string]string, len(table))
+		for r, row := range table {
+			records[r] = make(map[string]string, len(shown))
+			for i, col := range shown {
+				records[r][col] = row[i]
+			}
+		}
+		out := map[string]interface{}{"columns": shown, "rows": records}
+		if len(notes) > 0 {
+			out["note"] = strings.Join(notes, "; ")
+		}
+		data, err := json.Marshal(out)
+		return string(data), err
+	}
+
+	var b strings.Builder
+	b.WriteString("| " + strings.Join(escapeCells(shown), " | ") + " |\n")
+	b.WriteString("|" + strings.Repeat(" --- |", len(shown)) + "\n")
+	for _, row := range table {
+		b.WriteString("| " + strings.Join(escapeCells(row), " | ") + " |\n")
+	}
+	fmt.Fprintf(&b, "\n%d rows", len(table))
+	if len(notes) > 0 {
+		b.WriteString(" (" + strings.Join(notes, "; ") + ")")
+	}
+	return b.String(), nil
+}
+
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func truncateCell(s string, max int) string {
+	if r := []rune(s); len(r) > max {
+		return string(r[:max]) + "…"
+	}
+	return s
+}
+
+var cellEscaper = strings.NewReplacer("|", `\|`, "\r\n", " ", "\n", " ")
+
+func escapeCells(cells []string) []string {
+	out := make([]string, len(cells))
+	for i, c := range cells {
+		out[i] = cellEscaper.Replace(c)
+	}
+	return out
+}
@@ -0,0 +1,74 @@
+package builtin_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zakirkun/gatot-kaca/agent/tools/builtin"
+)
+
+func TestCheckStatement(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		wantErr string // Empty if the query is allowed.
+	}{
+		{name: "select", query: "SELECT name FROM users WHERE id = ?"},
+		{name: "trailing semicolon", query: "SELECT 1;"},
+		{name: "doubled quote", query: "SELECT 'it''s; DELETE FROM users'"},
+		{name: "comment", query: "SELECT 1 -- DELETE FROM users\n"},
+		{name: "block comment", query: "SELECT /* DELETE */ 1"},
+		{name: "positional parameter", query: "SELECT name FROM users WHERE id = $1"},
+		{name: "dollar in identifier", query: "SELECT a$b$c FROM t"},
+		{name: "quoted keyword", query: `SELECT "delete" FROM t`},
+
+		{name: "empty", query: " -- nothing", wantErr: "empty query"},
+		{name: "second statement", query: "SELECT 1; DELETE FROM users", wantErr: "single statement"},
+		{name: "write statement", query: "DELETE FROM users", wantErr: "DELETE statements are not allowed"},
+		{name: "data-modifying CTE", query: "WITH x AS (DELETE FROM users RETURNING *) SELECT * FROM x",
+			wantErr: "DELETE is not allowed"},
+		{name: "select into", query: "SELECT * INTO copy FROM users", wantErr: "INTO is not allowed"},
+
+		// Literals and comments that end in different places depending on the database.
+		{name: "backslash escape in E string", query: `SELECT E'\'' ; DELETE FROM users; -- '`,
+			wantErr: "backslashes"},
+		{name: "backslash escape in MySQL string", query: `SELECT '\'' ; DELETE FROM users; -- '`,
+			wantErr: "backslashes"},
+		{name: "backslash in standard string", query: `SELECT '\' ; DELETE FROM users; -- '`,
+			wantErr: "backslashes"},
+		{name: "backslash in double quotes", query: `SELECT "\"" ; DELETE FROM users; -- "`,
+			wantErr: "backslashes"},
+		{name: "dollar quote", query: "SELECT $$ ' $$; DELETE FROM users; -- '", wantErr: "dollar-quoted"},
+		{name: "tagged dollar quote", query: "SELECT $x$ ' $x$; DELETE FROM users; -- '",
+			wantErr: "dollar-quoted"},
+		{name: "nested comment", query: "SELECT /* /* */ ' */ ; DELETE FROM users; -- '",
+			wantErr: "nested comments"},
+		{name: "executable comment", query: "SELECT 1 /*! ; DELETE FROM users */", wantErr: "executable comments"},
+		{name: "hash comment", query: "SELECT 1 # '\n' ; DELETE FROM users; -- '", wantErr: "#"},
+		{name: "dashes without space", query: "SELECT 1--'\n'; DELETE FROM users; -- '",
+			wantErr: "followed by a space"},
+	}
+
+	tool := builtin.NewSQLTool(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tool.CheckStatement(tt.query)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("CheckStatement(%q) = %v, want nil", tt.query, err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("CheckStatement(%q) = %v, want an error containing %q", tt.query, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckStatementAllowWrites(t *testing.T) {
+	tool := &builtin.SQLTool{Statements: []string{"SELECT", "INSERT"}, AllowWrites: true}
+	if err := tool.CheckStatement("INSERT INTO log (msg) VALUES ('hi')"); err != nil {
+		t.Errorf("insert = %v, want nil", err)
+	}
+	if err := tool.CheckStatement("UPDATE log SET msg = 'x'"); err == nil {
+		t.Error("update was allowed, want only SELECT and INSERT")
+	}
+}
@@ -0,0 +1,586 @@
+package builtin
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/zakirkun/gatot-kaca/agent/tools"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// FetchTool fetches a web page and returns its main text, with navigation, scripts and other
+// boilerplate removed. It honors robots.txt unless IgnoreRobots is set, including for the
+// targets of redirects.
+//
+// Since the URLs come from the model, the default client refuses to connect to loopback,
+// private and link-local addresses, so that the tool cannot be used to reach internal services
+// or cloud metadata endpoints. The check applies to the resolved address of every connection,
+// including redirects, and no proxy is used. A custom Client is used as is and should apply its
+// own restrictions.
+type FetchTool struct {
+	Client       *http.Client  // Defaults to a client with Timeout that only connects to public addresses.
+	UserAgent    string        // Defaults to "gatot-kaca".
+	MaxBytes     int64         // Maximum response size read; defaults to 2 MiB.
+	MaxChars     int           // Maximum length of the returned text; defaults to 20000.
+	Timeout      time.Duration // Request timeout when Client is nil; defaults to 30 seconds.
+	IgnoreRobots bool
+	// AllowPrivate lets the default client connect to loopback, private and link-local
+	// addresses, e.g. to read an intranet.
+	AllowPrivate bool
+
+	mu            sync.Mutex
+	robots        map[string]*robotsRules // Keyed by scheme and host.
+	defaultClient *http.Client
+}
+
+// NewFetchTool creates a FetchTool with the default limits.
+func NewFetchTool() *FetchTool {
+	return &FetchTool{}
+}
+
+// Name implements tools.Tool.
+func (t *FetchTool) Name() string { return "fetch" }
+
+// Description implements tools.Tool.
+func (t *FetchTool) Description() string {
+	return "Fetches a web page by URL and returns its title and main text content."
+}
+
+// Schema implements tools.EnhancedTool.
+func (t *FetchTool) Schema() string {
+	return `{"type":"string","description":"absolute http or https URL"}`
+}
+
+// Help implements tools.EnhancedTool.
+func (t *FetchTool) Help() string {
+	return "Pass the URL of the page to read, e.g. https://example.com/article."
+}
+
+func (t *FetchTool) client() *http.Client {
+	if t.Client != nil {
+		return t.Client
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.defaultClient == nil {
+		timeout := t.Timeout
+		if timeout <= 0 {
+			timeout = 30 * time.Second
+		}
+		dialer := &net.Dialer{Timeout: timeout}
+		if !t.AllowPrivate {
+			dialer.Control = publicOnly
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.Proxy = nil // Connections through a proxy would bypass the address check.
+		transport.DialContext = dialer.DialContext
+		t.defaultClient = &http.Client{Timeout: timeout, Transport: transport}
+	}
+	return t.defaultClient
+}
+
+// publicOnly is a net.Dialer Control function refusing connections to addresses that are not
+// public: loopback, private, link-local, multicast and unspecified ones.
+func publicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("fetch: %s is not a public address", host)
+	}
+	return nil
+}
+
+// checkRedirect returns a CheckRedirect function for client that also checks the target of
+// each redirect against robots.txt.
+func (t *FetchTool) checkRedirect(client *http.Client) func(*http.Request, []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if client.CheckRedirect != nil {
+			if err := client.CheckRedirect(req, via); err != nil {
+				return err
+			}
+		} else if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		allowed, err := t.allowed(req.Context(), req.URL)
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			return fmt.Errorf("%s is disallowed by robots.txt", req.URL)
+		}
+		return nil
+	}
+}
+
+func (t *FetchTool) userAgent() string {
+	if t.UserAgent != "" {
+		return t.UserAgent
+	}
+	return "gatot-kaca"
+}
+
+// Execute implements tools.Tool.
+func (t *FetchTool) Execute(ctx context.Context, input string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(input))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("fetch: invalid URL %q", input)
+	}
+	if !t.IgnoreRobots {
+		allowed, err := t.allowed(ctx, u)
+		if err != nil {
+			return "", err
+		}
+		if !allowed {
+			return "", fmt.Errorf("fetch: %s is disallowed by robots.txt", u)
+		}
+	}
+
+	body, contentType, err := t.get(ctx, u.String())
+	if err != nil {
+		return "", err
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	var title, text string
+	switch {
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml" || mediaType == "":
+		title, text, err = ExtractText(strings.NewReader(body))
+		if err != nil {
+			return "", fmt.Errorf("fetch: %w", err)
+		}
+	case strings.HasPrefix(mediaType, "text/") || mediaType == "application/json":
+		text = body
+	default:
+		return "", fmt.Errorf("fetch: unsupported content type %s", mediaType)
+	}
+
+	maxChars := t.MaxChars
+	if maxChars <= 0 {
+		maxChars = 20000
+	}
+	var b strings.Builder
+	if title != "" {
+		b.WriteString("Title: " + title + "\n")
+	}
+	b.WriteString("URL: " + u.String() + "\n\n")
+	b.WriteString(tools.Truncate(text, maxChars))
+	return b.String(), nil
+}
+
+// get fetches rawURL, reading at most MaxBytes of the body.
+func (t *FetchTool) get(ctx context.Context, rawURL string) (string, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", "", err
+	}
+	req.Header.Set("User-Agent", t.userAgent())
+	client := t.client()
+	if !t.IgnoreRobots {
+		c := *client
+		c.CheckRedirect = t.checkRedirect(client)
+		client = &c
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", "", fmt.Errorf("fetch: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("fetch: %s returned %s", rawURL, resp.Status)
+	}
+	maxBytes := t.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = 2 << 20
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes))
+	if err != nil {
+		return "", "", fmt.Errorf("fetch: %w", err)
+	}
+	return string(data), resp.Header.Get("Content-Type"), nil
+}
+
+// robotsRules holds the rules of a robots.txt group. A nil value allows everything.
+type robotsRules struct {
+	allow, disallow []*regexp.Regexp
+	allowLen        []int
+	disallowLen     []int
+}
+
+// allowed reports whether robots.txt permits fetching u. A missing robots.txt allows
+// everything; a server error disallows everything, as crawlers conventionally do.
+func (t *FetchTool) allowed(ctx context.Context, u *url.URL) (bool, error) {
+	key := u.Scheme + "://" + u.Host
+	t.mu.Lock()
+	rules, ok := t.robots[key]
+	t.mu.Unlock()
+	if !ok {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, key+"/robots.txt", nil)
+		if err != nil {
+			return false, err
+		}
+		req.Header.Set("User-Agent", t.userAgent())
+		resp, err := t.client().Do(req)
+		if err != nil {
+			return false, fmt.Errorf("fetch: robots.txt: %w", err)
+		}
+		switch {
+		case resp.StatusCode >= 500:
+			resp.Body.Close()
+			return false, nil
+		case resp.StatusCode == http.StatusOK:
+			rules = parseRobots(io.LimitReader(resp.Body, 512<<10), t.userAgent())
+		}
+		resp.Body.Close()
+
+		t.mu.Lock()
+		if t.robots == nil {
+			t.robots = make(map[string]*robotsRules)
+		}
+		t.robots[key] = rules
+		t.mu.Unlock()
+	}
+	return rules.allows(u.EscapedPath()), nil
+}
+
+// allows applies the longest matching rule; Allow wins ties.
+func (r *robotsRules) allows(path string) bool {
+	if r == nil {
+		return true
+	}
+	if path == "" {
+		path = "/"
+	}
+	best, allowed := -1, true
+	for i, re := range r.disallow {
+		if re.MatchString(path) && r.disallowLen[i] > best {
+			best, allowed = r.disallowLen[i], false
+		}
+	}
+	for i, re := range r.allow {
+		if re.MatchString(path) && r.allowLen[i] >= best {
+			best, allowed = r.allowLen[i], true
+		}
+	}
+	return allowed
+}
+
+// parseRobots returns the rules of the group matching userAgent, or of the "*" group.
+func parseRobots(r io.Reader, userAgent string) *robotsRules {
+	agent := strings.ToLower(strings.SplitN(userAgent, "/", 2)[0])
+	groups := map[string]*robotsRules{}
+	var current []string
+	inRules := false
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		switch key {
+		case "user-agent":
+			if inRules {
+				current, inRules = nil, false
+			}
+			current = append(current, strings.ToLower(value))
+		case "allow", "disallow":
+			inRules = true
+			if value == "" {
+				continue // An empty Disallow allows everything.
+			}
+			re := robotsPattern(value)
+			for _, ua := range current {
+				g := groups[ua]
+				if g == nil {
+					g = &robotsRules{}
+					groups[ua] = g
+				}
+				if key == "allow" {
+					g.allow, g.allowLen = append(g.allow, re), append(g.allowLen, len(value))
+				} else {
+					g.disallow, g.disallowLen = append(g.disallow, re), append(g.disallowLen, len(value))
+				}
+			}
+		}
+	}
+	for ua, g := range groups {
+		if ua != "*" && strings.Contains(agent, ua) {
+			return g
+		}
+	}
+	return groups["*"]
+}
+
+// robotsPattern converts a robots.txt path pattern with * and $ wildcards into a regexp.
+func robotsPattern(pattern string) *regexp.Regexp {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+	parts := strings.Split(pattern, "*")
+	for i, p := range parts {
+		parts[i] = regexp.QuoteMeta(p)
+	}
+	expr := "^" + strings.Join(parts, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
+
+// boilerplate matches class and id values of elements that usually hold page chrome.
+var boilerplate = regexp.MustCompile(`(?i)\b(nav|navbar|menu|sidebar|footer|header|comment|comments|cookie|banner|advert|ads?|share|social|related|promo|subscribe|newsletter|popup|modal|breadcrumbs?)\b`)
+
+// skipped elements never hold main content.
+var skipped = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Nav: true, atom.Header: true, atom.Footer: true, atom.Aside: true,
+	atom.Form: true, atom.Button: true, atom.Iframe: true, atom.Svg: true,
+	atom.Select: true, atom.Object: true, atom.Embed: true, atom.Canvas: true,
+}
+
+// block elements are rendered on their own lines.
+var block = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true, atom.Main: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Ul: true, atom.Ol: true, atom.Li: true, atom.Pre: true, atom.Blockquote: true,
+	atom.Table: true, atom.Tr: true, atom.Dl: true, atom.Dt: true, atom.Dd: true,
+	atom.Figure: true, atom.Figcaption: true, atom.Hr: true, atom.Br: true,
+}
+
+// ExtractText parses an HTML document and returns its title and main text, using a
+// readability-style heuristic: boilerplate elements are dropped, and the element whose
+// paragraphs hold the most text (preferring <article> and <main>) is rendered as plain text.
+func ExtractText(r io.Reader) (title, text string, err error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return "", "", err
+	}
+	if n := find(doc, atom.Title); n != nil {
+		title = collapse(textOf(n))
+	}
+	prune(doc)
+
+	root := mainContent(doc)
+	var b strings.Builder
+	render(&b, root)
+	return title, tidy(b.String()), nil
+}
+
+// prune removes boilerplate elements from the tree.
+func prune(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.CommentNode || c.Type == html.ElementNode && isBoilerplate(c) {
+			n.RemoveChild(c)
+		} else {
+			prune(c)
+		}
+		c = next
+	}
+}
+
+func isBoilerplate(n *html.Node) bool {
+	if skipped[n.DataAtom] {
+		return true
+	}
+	if n.DataAtom == atom.Body || n.DataAtom == atom.Html || n.DataAtom == atom.Article || n.DataAtom == atom.Main {
+		return false
+	}
+	for _, a := range n.Attr {
+		switch a.Key {
+		case "class", "id", "role":
+			if boilerplate.MatchString(a.Val) {
+				return true
+			}
+		case "hidden", "aria-hidden":
+			if a.Key == "hidden" || a.Val == "true" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// mainContent picks the element holding the main text.
+func mainContent(doc *html.Node) *html.Node {
+	for _, a := range []atom.Atom{atom.Article, atom.Main} {
+		var best *html.Node
+		bestLen := 0
+		walk(doc, func(n *html.Node) {
+			if n.DataAtom == a {
+				if l := len(collapse(textOf(n))); l > bestLen {
+					best, bestLen = n, l
+				}
+			}
+		})
+		if best != nil && bestLen >= 200 {
+			return best
+		}
+	}
+
+	// Score the parents of paragraphs by the text they hold, discounting link-heavy elements.
+	scores := map[*html.Node]float64{}
+	walk(doc, func(n *html.Node) {
+		if n.DataAtom != atom.P && n.DataAtom != atom.Pre && n.DataAtom != atom.Blockquote {
+			return
+		}
+		t := collapse(textOf(n))
+		if len(t) < 25 {
+			return
+		}
+		score := 1 + float64(strings.Count(t, ",")) + float64(min(len(t)/100, 3))
+		if p := n.Parent; p != nil {
+			scores[p] += score
+			if gp := p.Parent; gp != nil {
+				scores[gp] += score / 2
+			}
+		}
+	})
+	var best *html.Node
+	bestScore := 0.0
+	for n, s := range scores {
+		s *= 1 - linkDensity(n)
+		if s > bestScore {
+			best, bestScore = n, s
+		}
+	}
+	if best != nil {
+		return best
+	}
+	if body := find(doc, atom.Body); body != nil {
+		return body
+	}
+	return doc
+}
+
+// linkDensity returns the fraction of an element's text that is inside links.
+func linkDensity(n *html.Node) float64 {
+	total := len(collapse(textOf(n)))
+	if total == 0 {
+		return 0
+	}
+	links := 0
+	walk(n, func(c *html.Node) {
+		if c.DataAtom == atom.A {
+			links += len(collapse(textOf(c)))
+		}
+	})
+	return float64(links) / float64(total)
+}
+
+func walk(n *html.Node, fn func(*html.Node)) {
+	if n.Type == html.ElementNode {
+		fn(n)
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walk(c, fn)
+	}
+}
+
+func find(n *html.Node, a atom.Atom) *html.Node {
+	var found *html.Node
+	walk(n, func(c *html.Node) {
+		if found == nil && c.DataAtom == a {
+			found = c
+		}
+	})
+	return found
+}
+
+func textOf(n *html.Node) string {
+	var b strings.Builder
+	var visit func(*html.Node)
+	visit = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+			b.WriteByte(' ')
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			visit(c)
+		}
+	}
+	visit(n)
+	return b.String()
+}
+
+// render writes the text of n, putting block elements on their own lines.
+func render(b *strings.Builder, n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		if t := collapse(n.Data); t != "" {
+			if s := b.String(); len(s) > 0 && !strings.HasSuffix(s, "\n") && !strings.HasSuffix(s, " ") {
+				b.WriteByte(' ')
+			}
+			b.WriteString(t)
+		}
+		return
+	case html.ElementNode:
+		if n.DataAtom == atom.Pre {
+			b.WriteString("\n" + strings.TrimRight(textOf(n), " ") + "\n")
+			return
+		}
+		if block[n.DataAtom] {
+			b.WriteByte('\n')
+		}
+		switch n.DataAtom {
+		case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+			b.WriteString(strings.Repeat("#", int(n.Data[1]-'0')) + " ")
+		case atom.Li:
+			b.WriteString("- ")
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		render(b, c)
+	}
+	if n.Type == html.ElementNode && block[n.DataAtom] && !tight[n.DataAtom] {
+		b.WriteByte('\n')
+	}
+}
+
+// tight block elements start a new line but are not followed by a blank line.
+var tight = map[atom.Atom]bool{atom.Li: true, atom.Tr: true, atom.Dt: true, atom.Dd: true, atom.Br: true}
+
+func collapse(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// tidy trims lines and collapses runs of blank lines.
+func tidy(s string) string {
+	lines := strings.Split(s, "\n")
+	out := make([]string, 0, len(lines))
+	blank := true
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			if !blank {
+				out = append(out, "")
+			}
+			blank = true
+			continue
+		}
+		out = append(out, line)
+		blank = false
+	}
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
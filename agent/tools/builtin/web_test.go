@@ -0,0 +1,59 @@
+package builtin_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/zakirkun/gatot-kaca/agent/tools/builtin"
+)
+
+func TestFetchRefusesPrivateAddresses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<p>internal</p>")
+	}))
+	defer srv.Close()
+
+	for _, url := range []string{srv.URL, "http://169.254.169.254/latest/meta-data/"} {
+		_, err := (&builtin.FetchTool{IgnoreRobots: true}).Execute(context.Background(), url)
+		if err == nil || !strings.Contains(err.Error(), "not a public address") {
+			t.Errorf("fetch %s = %v, want a private address error", url, err)
+		}
+	}
+
+	out, err := (&builtin.FetchTool{IgnoreRobots: true, AllowPrivate: true}).Execute(context.Background(), srv.URL)
+	if err != nil || !strings.Contains(out, "internal") {
+		t.Errorf("fetch with AllowPrivate = %q, %v", out, err)
+	}
+}
+
+func TestFetchChecksRobotsOnRedirect(t *testing.T) {
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			fmt.Fprint(w, "User-agent: *\nDisallow: /private\n")
+			return
+		}
+		fmt.Fprint(w, "<p>page "+r.URL.Path+"</p>")
+	}))
+	defer other.Close()
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		http.Redirect(w, r, other.URL+r.URL.Path, http.StatusFound)
+	}))
+	defer origin.Close()
+
+	tool := &builtin.FetchTool{AllowPrivate: true}
+	if out, err := tool.Execute(context.Background(), origin.URL+"/public"); err != nil || !strings.Contains(out, "page /public") {
+		t.Errorf("allowed redirect = %q, %v", out, err)
+	}
+	if _, err := tool.Execute(context.Background(), origin.URL+"/private"); err == nil ||
+		!strings.Contains(err.Error(), "disallowed by robots.txt") {
+		t.Errorf("disallowed redirect = %v, want a robots.txt error", err)
+	}
+}
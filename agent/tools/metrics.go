@@ -0,0 +1,170 @@
+package tools
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultRecordLimit is the number of recent execution records a Manager keeps.
+const DefaultRecordLimit = 100
+
+// ExecutionRecord describes a single tool execution.
+type ExecutionRecord struct {
+	Tool       string        `json:"tool"`
+	Start      time.Time     `json:"start"`
+	Duration   time.Duration `json:"duration"`
+	InputSize  int           `json:"input_size"`  // In bytes.
+	OutputSize int           `json:"output_size"` // In bytes; zero for failed executions.
+	Error      string        `json:"error,omitempty"`
+}
+
+// ToolMetrics aggregates the executions of a tool.
+type ToolMetrics struct {
+	Calls         int           `json:"calls"`
+	Successes     int           `json:"successes"`
+	Failures      int           `json:"failures"`
+	TotalDuration time.Duration `json:"total_duration"`
+	MaxDuration   time.Duration `json:"max_duration"`
+	InputBytes    int64         `json:"input_bytes"`
+	OutputBytes   int64         `json:"output_bytes"`
+	LastCall      time.Time     `json:"last_call,omitempty"`
+	LastError     string        `json:"last_error,omitempty"`
+	LastErrorAt   time.Time     `json:"last_error_at,omitempty"`
+}
+
+// AverageDuration returns the mean execution duration.
+func (t ToolMetrics) AverageDuration() time.Duration {
+	if t.Calls == 0 {
+		return 0
+	}
+	return t.TotalDuration / time.Duration(t.Calls)
+}
+
+// record adds an execution to the metrics and the recent records.
+func (m *Manager) record(rec ExecutionRecord) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tm := m.metrics[rec.Tool]
+	tm.Calls++
+	tm.TotalDuration += rec.Duration
+	if rec.Duration > tm.MaxDuration {
+		tm.MaxDuration = rec.Duration
+	}
+	tm.InputBytes += int64(rec.InputSize)
+	tm.OutputBytes += int64(rec.OutputSize)
+	if rec.Start.After(tm.LastCall) {
+		tm.LastCall = rec.Start
+	}
+	if rec.Error != "" {
+		tm.Failures++
+		tm.LastError = rec.Error
+		tm.LastErrorAt = rec.Start
+	} else {
+		tm.Successes++
+	}
+	m.metrics[rec.Tool] = tm
+
+	m.records = append(m.records, rec)
+	if len(m.records) > DefaultRecordLimit {
+		m.records = m.records[len(m.records)-DefaultRecordLimit:]
+	}
+}
+
+// GetMetrics returns the metrics of every registered tool, keyed by tool name.
+func (m *Manager) GetMetrics() map[string]ToolMetrics {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make(map[string]ToolMetrics, len(m.metrics))
+	for name, tm := range m.metrics {
+		out[name] = tm
+	}
+	return out
+}
+
+// Records returns the most recent executions, oldest first, up to DefaultRecordLimit.
+func (m *Manager) Records() []ExecutionRecord {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]ExecutionRecord(nil), m.records...)
+}
+
+// WritePrometheus writes the tool metrics of the managers in the Prometheus text exposition
+// format. Metrics are labelled with the tool name and, for non-empty keys, with agent="<key>".
+func WritePrometheus(w io.Writer, managers map[string]*Manager) error {
+	type sample struct {
+		labels string
+		tm     ToolMetrics
+	}
+	var samples []sample
+	keys := make([]string, 0, len(managers))
+	for key := range managers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		metrics := managers[key].GetMetrics()
+		names := make([]string, 0, len(metrics))
+		for name := range metrics {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			labels := `tool="` + escapeLabel(name) + `"`
+			if key != "" {
+				labels = `agent="` + escapeLabel(key) + `",` + labels
+			}
+			samples = append(samples, sample{labels, metrics[name]})
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "# HELP gatotkaca_tool_calls_total Tool executions by result.")
+	fmt.Fprintln(bw, "# TYPE gatotkaca_tool_calls_total counter")
+	for _, s := range samples {
+		fmt.Fprintf(bw, "gatotkaca_tool_calls_total{%s,result=\"success\"} %d\n", s.labels, s.tm.Successes)
+		fmt.Fprintf(bw, "gatotkaca_tool_calls_total{%s,result=\"error\"} %d\n", s.labels, s.tm.Failures)
+	}
+	fmt.Fprintln(bw, "# HELP gatotkaca_tool_duration_seconds Tool execution duration.")
+	fmt.Fprintln(bw, "# TYPE gatotkaca_tool_duration_seconds summary")
+	for _, s := range samples {
+		fmt.Fprintf(bw, "gatotkaca_tool_duration_seconds_sum{%s} %g\n", s.labels, s.tm.TotalDuration.Seconds())
+		fmt.Fprintf(bw, "gatotkaca_tool_duration_seconds_count{%s} %d\n", s.labels, s.tm.Calls)
+	}
+	fmt.Fprintln(bw, "# HELP gatotkaca_tool_input_bytes_total Bytes of tool input.")
+	fmt.Fprintln(bw, "# TYPE gatotkaca_tool_input_bytes_total counter")
+	for _, s := range samples {
+		fmt.Fprintf(bw, "gatotkaca_tool_input_bytes_total{%s} %d\n", s.labels, s.tm.InputBytes)
+	}
+	fmt.Fprintln(bw, "# HELP gatotkaca_tool_output_bytes_total Bytes of tool output.")
+	fmt.Fprintln(bw, "# TYPE gatotkaca_tool_output_bytes_total counter")
+	for _, s := range samples {
+		fmt.Fprintf(bw, "gatotkaca_tool_output_bytes_total{%s} %d\n", s.labels, s.tm.OutputBytes)
+	}
+	return bw.Flush()
+}
+
+// MetricsHandler returns an http.Handler serving the manager's metrics in the Prometheus
+// text format.
+func (m *Manager) MetricsHandler() http.Handler {
+	return PrometheusHandler(map[string]*Manager{"": m})
+}
+
+// PrometheusHandler returns an http.Handler serving the metrics of several managers;
+// see WritePrometheus.
+func PrometheusHandler(managers map[string]*Manager) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		WritePrometheus(w, managers)
+	})
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
@@ -0,0 +1,74 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/zakirkun/gatot-kaca/logging"
+)
+
+// OutputPolicy limits the size of tool outputs before they are added to the conversation.
+type OutputPolicy struct {
+	// MaxChars is the maximum output length in characters; zero means unlimited.
+	MaxChars int
+	// Summarize condenses oversized outputs with a Summarizer instead of truncating them.
+	// Outputs are truncated if no summarizer is available or summarizing fails.
+	Summarize bool
+}
+
+// Summarizer condenses a tool output to at most maxChars characters.
+type Summarizer func(ctx context.Context, toolName, output string, maxChars int) (string, error)
+
+// SetOutputPolicy sets the policy applied to tools without a policy of their own.
+func (m *Manager) SetOutputPolicy(p OutputPolicy) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.outputPolicy = p
+}
+
+// SetToolOutputPolicy sets the policy for the named tool, overriding the default policy.
+func (m *Manager) SetToolOutputPolicy(name string, p OutputPolicy) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.outputPolicies[name] = p
+}
+
+// OutputPolicy returns the policy that applies to the named tool.
+func (m *Manager) OutputPolicy(name string) OutputPolicy {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if p, ok := m.outputPolicies[name]; ok {
+		return p
+	}
+	return m.outputPolicy
+}
+
+// LimitOutput applies the named tool's output policy to output. Oversized outputs are
+// summarized with summarize if the policy asks for it, and truncated otherwise.
+func (m *Manager) LimitOutput(ctx context.Context, name, output string, summarize Summarizer) string {
+	p := m.OutputPolicy(name)
+	if p.MaxChars <= 0 || utf8.RuneCountInString(output) <= p.MaxChars {
+		return output
+	}
+	if p.Summarize && summarize != nil {
+		summary, err := summarize(ctx, name, output, p.MaxChars)
+		if err == nil {
+			return Truncate(summary, p.MaxChars)
+		}
+		m.logFor(ctx).Warn("failed to summarize tool output, truncating", logging.KeyComponent, "tools",
+			logging.KeyTool, name, logging.KeyError, err)
+	}
+	return Truncate(output, p.MaxChars)
+}
+
+// Truncate shortens s to its first maxChars characters followed by a note of how many
+// characters were removed. Strings within the limit are returned unchanged.
+func Truncate(s string, maxChars int) string {
+	n := utf8.RuneCountInString(s)
+	if maxChars <= 0 || n <= maxChars {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:maxChars]) + fmt.Sprintf("\n... [truncated %d characters]", n-maxChars)
+}
@@ -0,0 +1,31 @@
+//go:build (linux || darwin || freebsd) && cgo
+
+package tools
+
+import (
+	"fmt"
+	"plugin"
+)
+
+// PluginSymbol is the symbol a Go plugin must export to provide tools: a function with the
+// signature func() []tools.Tool.
+const PluginSymbol = "Tools"
+
+// LoadPlugin opens a Go plugin built with "go build -buildmode=plugin" and returns the tools
+// provided by its PluginSymbol function. The plugin must be built with the same Go version and
+// module versions as the host application.
+func LoadPlugin(path string) ([]Tool, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open plugin %s: %w", path, err)
+	}
+	sym, err := p.Lookup(PluginSymbol)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", path, err)
+	}
+	fn, ok := sym.(func() []Tool)
+	if !ok {
+		return nil, fmt.Errorf("plugin %s: %s has type %T, want func() []tools.Tool", path, PluginSymbol, sym)
+	}
+	return fn(), nil
+}
@@ -0,0 +1,14 @@
+//go:build !((linux || darwin || freebsd) && cgo)
+
+package tools
+
+import "errors"
+
+// PluginSymbol is the symbol a Go plugin must export to provide tools: a function with the
+// signature func() []tools.Tool.
+const PluginSymbol = "Tools"
+
+// LoadPlugin is not supported on this platform; Go plugins require cgo on Linux, macOS or FreeBSD.
+func LoadPlugin(path string) ([]Tool, error) {
+	return nil, errors.New("go plugins are not supported on this platform")
+}
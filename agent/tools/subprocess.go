@@ -0,0 +1,145 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Subprocess tools are external programs that speak a small JSON protocol. For every request,
+// the program is started, reads a single JSON request from stdin and writes a single JSON
+// response to stdout:
+//
+//	{"method": "describe"}
+//	-> {"name": "weather", "description": "...", "schema": {...}, "help": "..."}
+//
+//	{"method": "execute", "input": "Jakarta"}
+//	-> {"output": "31°C, sunny"} or {"error": "city not found"}
+//
+// The schema may be any JSON value and is passed on as is.
+
+// SubprocessRequest is the request written to a subprocess tool's stdin.
+type SubprocessRequest struct {
+	Method string `json:"method"` // "describe" or "execute".
+	Input  string `json:"input,omitempty"`
+}
+
+// SubprocessResponse is the response read from a subprocess tool's stdout.
+type SubprocessResponse struct {
+	Name        string          `json:"name,omitempty"`
+	Description string          `json:"description,omitempty"`
+	Schema      json.RawMessage `json:"schema,omitempty"`
+	Help        string          `json:"help,omitempty"`
+	Output      string          `json:"output,omitempty"`
+	Error       string          `json:"error,omitempty"`
+}
+
+// DefaultSubprocessTimeout bounds a single subprocess tool request.
+const DefaultSubprocessTimeout = 30 * time.Second
+
+// SubprocessTool is a Tool implemented by an external program; see the protocol above.
+type SubprocessTool struct {
+	Command string
+	Args    []string
+	Env     []string      // Additional environment variables, as "KEY=value".
+	Dir     string        // Working directory; defaults to the current one.
+	Timeout time.Duration // Per-request timeout; defaults to DefaultSubprocessTimeout.
+
+	name        string
+	description string
+	schema      string
+	help        string
+}
+
+// NewSubprocessTool creates a tool backed by the given command and asks it to describe itself.
+func NewSubprocessTool(ctx context.Context, command string, args ...string) (*SubprocessTool, error) {
+	t := &SubprocessTool{Command: command, Args: args}
+	if err := t.Describe(ctx); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Describe asks the program for its name, description, schema and help.
+func (t *SubprocessTool) Describe(ctx context.Context) error {
+	resp, err := t.call(ctx, SubprocessRequest{Method: "describe"})
+	if err != nil {
+		return err
+	}
+	if resp.Error != "" {
+		return fmt.Errorf("subprocess tool %s: %s", t.Command, resp.Error)
+	}
+	if resp.Name == "" {
+		return fmt.Errorf("subprocess tool %s: describe returned no name", t.Command)
+	}
+	t.name = resp.Name
+	t.description = resp.Description
+	t.schema = string(resp.Schema)
+	t.help = resp.Help
+	return nil
+}
+
+// Name implements Tool.
+func (t *SubprocessTool) Name() string { return t.name }
+
+// Description implements Tool.
+func (t *SubprocessTool) Description() string { return t.description }
+
+// Schema implements EnhancedTool.
+func (t *SubprocessTool) Schema() string { return t.schema }
+
+// Help implements EnhancedTool.
+func (t *SubprocessTool) Help() string { return t.help }
+
+// Execute implements Tool by running the program with an execute request.
+func (t *SubprocessTool) Execute(ctx context.Context, input string) (string, error) {
+	resp, err := t.call(ctx, SubprocessRequest{Method: "execute", Input: input})
+	if err != nil {
+		return "", err
+	}
+	if resp.Error != "" {
+		return "", errors.New(resp.Error)
+	}
+	return resp.Output, nil
+}
+
+// call runs the program once with req on stdin and decodes its response.
+func (t *SubprocessTool) call(ctx context.Context, req SubprocessRequest) (*SubprocessResponse, error) {
+	timeout := t.Timeout
+	if timeout <= 0 {
+		timeout = DefaultSubprocessTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.CommandContext(ctx, t.Command, t.Args...)
+	cmd.Dir = t.Dir
+	if len(t.Env) > 0 {
+		cmd.Env = append(cmd.Environ(), t.Env...)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("subprocess tool %s: %w: %s", t.Command, err, Truncate(msg, 1000))
+		}
+		return nil, fmt.Errorf("subprocess tool %s: %w", t.Command, err)
+	}
+
+	var resp SubprocessResponse
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return nil, fmt.Errorf("subprocess tool %s: invalid response: %w", t.Command, err)
+	}
+	return &resp, nil
+}
@@ -3,8 +3,10 @@ package tools
 import (
 	"context"
 	"fmt"
-	"log"
+	"sync"
 	"time"
+
+	"github.com/zakirkun/gatot-kaca/logging"
 )
 
 // Tool represents an external helper function that the agent can call.
@@ -26,32 +28,64 @@ type EnhancedTool interface {
 	Help() string
 }
 
-// Manager manages a set of tools that an agent can use.
+// Manager manages a set of tools that an agent can use. It is safe for concurrent use.
 type Manager struct {
+	mu      sync.RWMutex // Guards all fields below.
 	tools   map[string]Tool
-	metrics map[string]int // Track the number of times each tool is executed.
+	metrics map[string]ToolMetrics // Execution metrics of each tool.
+	records []ExecutionRecord      // Most recent executions.
+
+	outputPolicy   OutputPolicy            // Default policy for all tools.
+	outputPolicies map[string]OutputPolicy // Per-tool policies overriding the default.
 
+	logger logging.Logger // Defaults to the logger of the context, see SetLogger.
 }
 
 // NewManager creates a new Manager instance.
 func NewManager() *Manager {
 	return &Manager{
-		tools:   make(map[string]Tool),
-		metrics: make(map[string]int),
+		tools:          make(map[string]Tool),
+		metrics:        make(map[string]ToolMetrics),
+		outputPolicies: make(map[string]OutputPolicy),
 	}
 }
 
 // RegisterTool registers a tool with the manager.
 func (m *Manager) RegisterTool(tool Tool) {
-	fmt.Printf("Registering tool: %s\n", tool.Name())
+	m.logFor(context.Background()).Debug("registering tool", logging.KeyComponent, "tools", logging.KeyTool, tool.Name())
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.tools[tool.Name()] = tool
-	// Initialize call count metric.
-	m.metrics[tool.Name()] = 0
+	// Initialize the metrics so that unused tools are reported too.
+	if _, ok := m.metrics[tool.Name()]; !ok {
+		m.metrics[tool.Name()] = ToolMetrics{}
+	}
+}
+
+// SetLogger sets the logger of the manager. Without one, the manager logs to the logger of the
+// context of each call (see logging.FromContext).
+func (m *Manager) SetLogger(l logging.Logger) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.logger = l
+}
+
+// logFor returns the manager's logger, or that of ctx if it has none.
+func (m *Manager) logFor(ctx context.Context) logging.Logger {
+	m.mu.RLock()
+	l := m.logger
+	m.mu.RUnlock()
+	if l != nil {
+		return l
+	}
+	return logging.FromContext(ctx)
 }
 
 // GetTool retrieves a tool by its name.
 func (m *Manager) GetTool(name string) (Tool, error) {
+	m.mu.RLock()
 	tool, ok := m.tools[name]
+	m.mu.RUnlock()
 	if !ok {
 		return nil, fmt.Errorf("tool not found: %s", name)
 	}
@@ -59,8 +93,8 @@ func (m *Manager) GetTool(name string) (Tool, error) {
 }
 
 // ExecuteTool executes a registered tool by name with the provided input
-// and logs execution details such as duration and errors.
-// It also updates the call metrics for that tool.
+// and records the execution, its duration, sizes and error in the tool's metrics.
+// It is safe to call concurrently.
 func (m *Manager) ExecuteTool(ctx context.Context, name, input string) (string, error) {
 	tool, err := m.GetTool(name)
 	if err != nil {
@@ -68,19 +102,30 @@ func (m *Manager) ExecuteTool(ctx context.Context, name, input string) (string,
 	}
 	start := time.Now()
 	output, err := tool.Execute(ctx, input)
-	duration := time.Since(start)
+	rec := ExecutionRecord{
+		Tool:      name,
+		Start:     start,
+		Duration:  time.Since(start),
+		InputSize: len(input),
+	}
 	if err != nil {
-		log.Printf("[Tool Execution] Tool '%s' failed after %v: %v", name, duration, err)
+		rec.Error = err.Error()
+		m.record(rec)
+		m.logFor(ctx).Debug("tool failed", logging.KeyComponent, "tools", logging.KeyTool, name,
+			logging.KeyDuration, rec.Duration, logging.KeyError, err)
 		return "", err
 	}
-	log.Printf("[Tool Execution] Tool '%s' executed in %v", name, duration)
-	// Increment call count metric.
-	m.metrics[name]++
+	rec.OutputSize = len(output)
+	m.record(rec)
+	m.logFor(ctx).Debug("tool executed", logging.KeyComponent, "tools", logging.KeyTool, name,
+		logging.KeyDuration, rec.Duration)
 	return output, nil
 }
 
 // ListTools returns a slice of all registered tool names.
 func (m *Manager) ListTools() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	names := make([]string, 0, len(m.tools))
 	for name := range m.tools {
 		names = append(names, name)
@@ -91,6 +136,8 @@ func (m *Manager) ListTools() []string {
 // ListDetailedTools returns a detailed description for all registered tools.
 // For tools that implement EnhancedTool, it includes the schema and help information.
 func (m *Manager) ListDetailedTools() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	var result string
 	for name, tool := range m.tools {
 		result += fmt.Sprintf("Tool: %s\n", name)
@@ -105,12 +152,10 @@ func (m *Manager) ListDetailedTools() string {
 	return result
 }
 
-// GetCallCount returns the number of times a tool has been executed.
+// GetCallCount returns the number of times a tool has been executed successfully.
 // If the tool isn't found, it returns a count of 0.
 func (m *Manager) GetCallCount(name string) int {
-	count, ok := m.metrics[name]
-	if !ok {
-		return 0
-	}
-	return count
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.metrics[name].Successes
 }
@@ -0,0 +1,57 @@
+package tools_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/zakirkun/gatot-kaca/agent/tools"
+)
+
+type echoTool struct{ name string }
+
+func (e echoTool) Name() string        { return e.name }
+func (e echoTool) Description() string { return "echoes its input" }
+func (e echoTool) Execute(ctx context.Context, input string) (string, error) {
+	return input, nil
+}
+
+// TestManagerConcurrentUse registers tools and sets policies while other tools run; run it
+// with -race.
+func TestManagerConcurrentUse(t *testing.T) {
+	m := tools.NewManager()
+	m.RegisterTool(echoTool{name: "echo"})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			name := fmt.Sprintf("tool-%d", i)
+			m.RegisterTool(echoTool{name: name})
+			m.SetToolOutputPolicy(name, tools.OutputPolicy{MaxChars: 10})
+			m.SetOutputPolicy(tools.OutputPolicy{MaxChars: 100})
+		}()
+		go func() {
+			defer wg.Done()
+			if out, err := m.ExecuteTool(context.Background(), "echo", "hi"); err != nil || out != "hi" {
+				t.Errorf("ExecuteTool = %q, %v", out, err)
+			}
+			m.ListTools()
+			m.ListDetailedTools()
+			m.OutputPolicy("echo")
+		}()
+	}
+	wg.Wait()
+
+	if got := len(m.ListTools()); got != 9 {
+		t.Errorf("registered %d tools, want 9", got)
+	}
+	if got := m.OutputPolicy("tool-3").MaxChars; got != 10 {
+		t.Errorf("tool-3 policy MaxChars = %d, want 10", got)
+	}
+	if got := m.GetCallCount("echo"); got != 8 {
+		t.Errorf("echo call count = %d, want 8", got)
+	}
+}
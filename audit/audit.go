@@ -0,0 +1,173 @@
+// Package audit records every LLM request and response and every tool invocation to a
+// pluggable sink, such as a JSON lines file, a SQL database or an HTTP endpoint, for deployments
+// that must keep a trail of what was sent to and returned by models. Secrets and personally
+// identifiable information are redacted before records reach the sink.
+//
+//	sink, err := audit.NewFileSink("audit.jsonl")
+//	...
+//	auditor := audit.New(sink, audit.DefaultRedaction())
+//	a := agent.NewAgent(client, "gpt-4o", audit.WithAuditor(auditor))
+package audit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/zakirkun/gatot-kaca/agent"
+	"github.com/zakirkun/gatot-kaca/llm"
+	"github.com/zakirkun/gatot-kaca/logging"
+)
+
+// Record kinds.
+const (
+	KindLLM  = "llm"
+	KindTool = "tool"
+)
+
+// Record is one audited LLM call or tool invocation.
+type Record struct {
+	ID   string    `json:"id"`
+	Time time.Time `json:"time"` // When the call started.
+	Kind string    `json:"kind"` // KindLLM or KindTool.
+	// Model is the model called, for KindLLM records.
+	Model string `json:"model,omitempty"`
+	// Tool is the tool invoked, for KindTool records.
+	Tool string `json:"tool,omitempty"`
+	// System is the system prompt of an LLM request.
+	System string `json:"system,omitempty"`
+	// Request is the conversation and prompt of an LLM request, one "role: content" line per
+	// message, or the input of a tool.
+	Request string `json:"request"`
+	// Response is the model's response text or the tool's output.
+	Response string        `json:"response,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+	Usage    llm.Usage     `json:"usage"`
+}
+
+// Sink stores audit records. Sinks must be safe for concurrent use.
+type Sink interface {
+	Write(ctx context.Context, rec Record) error
+}
+
+// SinkFunc adapts a function to Sink.
+type SinkFunc func(ctx context.Context, rec Record) error
+
+// Write implements Sink.
+func (f SinkFunc) Write(ctx context.Context, rec Record) error {
+	return f(ctx, rec)
+}
+
+// Auditor redacts records and writes them to a sink. Failures to write are logged rather than
+// returned, so that auditing problems do not fail the calls being audited.
+type Auditor struct {
+	Sink      Sink
+	Redaction Redaction
+}
+
+// New creates an Auditor writing to sink with the given redaction.
+func New(sink Sink, redaction Redaction) *Auditor {
+	return &Auditor{Sink: sink, Redaction: redaction}
+}
+
+// Record redacts rec, fills in its ID and time if they are empty, and writes it to the sink.
+func (a *Auditor) Record(ctx context.Context, rec Record) error {
+	if rec.ID == "" {
+		rec.ID = newRecordID()
+	}
+	if rec.Time.IsZero() {
+		rec.Time = time.Now()
+	}
+	rec = a.Redaction.Apply(ctx, rec)
+	if err := a.Sink.Write(ctx, rec); err != nil {
+		return fmt.Errorf("audit: %w", err)
+	}
+	return nil
+}
+
+// record writes rec, logging failures.
+func (a *Auditor) record(ctx context.Context, rec Record) {
+	if err := a.Record(ctx, rec); err != nil {
+		logging.FromContext(ctx).Error("failed to write audit record", logging.KeyComponent, "audit",
+			"kind", rec.Kind, logging.KeyError, err)
+	}
+}
+
+// Hooks returns llm hooks that audit every call of a client; install them with llm.WithHooks
+// or Client.AddHooks.
+func (a *Auditor) Hooks() llm.Hooks {
+	return llm.Hooks{
+		AfterGenerate: func(ctx context.Context, model string, req llm.ModelRequest, resp llm.ModelResponse, err error, duration time.Duration) {
+			rec := Record{
+				Time:     time.Now().Add(-duration),
+				Kind:     KindLLM,
+				Model:    model,
+				System:   req.System,
+				Request:  requestText(req),
+				Response: resp.Text,
+				Duration: duration,
+				Usage:    resp.Usage,
+			}
+			if err != nil {
+				rec.Error = err.Error()
+			}
+			a.record(context.WithoutCancel(ctx), rec)
+		},
+	}
+}
+
+// ToolEvents returns a handler that audits tool invocations when they end; install it with
+// agent.WithToolEvents or agent.ContextWithToolEvents.
+func (a *Auditor) ToolEvents() agent.ToolEventHandler {
+	return func(e agent.ToolEvent) {
+		if e.Type != agent.ToolCallEnd {
+			return
+		}
+		a.record(context.Background(), Record{
+			Time:     time.Now().Add(-e.Duration),
+			Kind:     KindTool,
+			Tool:     e.Call.Name,
+			Request:  e.Call.Input,
+			Response: e.Call.Output,
+			Error:    e.Call.Error,
+			Duration: e.Duration,
+		})
+	}
+}
+
+// WithAuditor audits the tool invocations of the agent and every call of its LLM client. The
+// client is shared with other agents created with it, whose model calls are audited as well.
+func WithAuditor(au *Auditor) agent.Option {
+	return func(a *agent.Agent) {
+		a.Client().AddHooks(au.Hooks())
+		agent.WithToolEvents(au.ToolEvents())(a)
+	}
+}
+
+// requestText renders the messages and prompt of an LLM request, one "role: content" line each.
+func requestText(req llm.ModelRequest) string {
+	var b strings.Builder
+	for _, msg := range req.Messages {
+		fmt.Fprintf(&b, "%s: %s\n", msg.Role, msg.Content)
+	}
+	if req.Prompt != "" {
+		if len(req.Messages) > 0 {
+			b.WriteString(llm.RoleUser + ": ")
+		}
+		b.WriteString(req.Prompt)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// newRecordID returns a random identifier for a record.
+func newRecordID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("audit-%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,133 @@
+package audit
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/zakirkun/gatot-kaca/guardrails"
+)
+
+// Record fields that Redaction.Omit can drop.
+const (
+	FieldSystem   = "system"
+	FieldRequest  = "request"
+	FieldResponse = "response"
+)
+
+// SecretLabel replaces the secrets redacted with Redaction.Secrets.
+const SecretLabel = "SECRET"
+
+// AllPII lists every PII type guardrails.PII detects.
+var AllPII = []guardrails.PIIType{
+	guardrails.PIIEmail, guardrails.PIIPhone, guardrails.PIICreditCard, guardrails.PIISSN, guardrails.PIIIPAddress,
+}
+
+// Redaction configures what is removed from audit records before they are written. Matches are
+// replaced by their label in brackets, e.g. [EMAIL] or [SECRET]. The zero value redacts nothing.
+type Redaction struct {
+	// Secrets redacts API keys and tokens of common providers, bearer tokens, and the values of
+	// key=value or "key": "value" pairs whose key names a secret, such as api_key or password.
+	Secrets bool
+	// PII lists the personally identifiable information to redact; see AllPII.
+	PII []guardrails.PIIType
+	// Patterns redacts the matches of custom expressions, replaced by their label.
+	Patterns map[string]*regexp.Regexp
+	// Omit drops the content of the given fields, e.g. FieldRequest, entirely.
+	Omit []string
+}
+
+// DefaultRedaction redacts secrets and all PII.
+func DefaultRedaction() Redaction {
+	return Redaction{Secrets: true, PII: AllPII}
+}
+
+// Apply returns rec with its system prompt, request, response and error redacted.
+func (r Redaction) Apply(ctx context.Context, rec Record) Record {
+	g := r.guard()
+	redact := func(field, text string) string {
+		for _, omit := range r.Omit {
+			if omit == field && text != "" {
+				return "[OMITTED]"
+			}
+		}
+		if g == nil || text == "" {
+			return text
+		}
+		result, _ := g.Check(ctx, text)
+		return result.Text
+	}
+	rec.System = redact(FieldSystem, rec.System)
+	rec.Request = redact(FieldRequest, rec.Request)
+	rec.Response = redact(FieldResponse, rec.Response)
+	rec.Error = redact("error", rec.Error)
+	return rec
+}
+
+// guard returns a guardrails.Guard redacting everything configured, or nil if nothing is.
+func (r Redaction) guard() *guardrails.Guard {
+	var rules []guardrails.Rule
+	if r.Secrets {
+		rules = append(rules, guardrails.Rule{Validator: secrets{}, Action: guardrails.Redact})
+	}
+	if len(r.PII) > 0 {
+		rules = append(rules, guardrails.Rule{Validator: &guardrails.PII{Types: r.PII}, Action: guardrails.Redact})
+	}
+	if len(r.Patterns) > 0 {
+		rules = append(rules, guardrails.Rule{Validator: patterns(r.Patterns), Action: guardrails.Redact})
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+	return guardrails.New(rules...)
+}
+
+// secretPatterns match secrets as a whole, or in the group named "value" when only part of the
+// match is secret.
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{16,}`),                           // OpenAI, Anthropic
+	regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`),                         // Google
+	regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),                     // AWS access key IDs
+	regexp.MustCompile(`\bx(?:ox[abposr]|app)-[A-Za-z0-9-]{10,}`),           // Slack
+	regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`),                    // GitHub
+	regexp.MustCompile(`(?i)\bbearer\s+(?P<value>[A-Za-z0-9._~+/=-]{16,})`), // Authorization headers
+	regexp.MustCompile(`(?i)\b(?:api[_-]?key|secret|token|password|passwd)["']?\s*[:=]\s*["']?(?P<value>[^\s"',;}]+)`),
+}
+
+// secrets detects API keys, tokens and passwords.
+type secrets struct{}
+
+func (secrets) Name() string { return "secrets" }
+
+func (secrets) Validate(ctx context.Context, text string) []guardrails.Finding {
+	var findings []guardrails.Finding
+	for _, re := range secretPatterns {
+		group := re.SubexpIndex("value")
+		for _, m := range re.FindAllStringSubmatchIndex(text, -1) {
+			start, end := m[0], m[1]
+			if group > 0 && m[2*group] >= 0 {
+				start, end = m[2*group], m[2*group+1]
+			}
+			findings = append(findings, guardrails.Finding{
+				Validator: "secrets", Label: SecretLabel, Message: "found secret", Start: start, End: end,
+			})
+		}
+	}
+	return findings
+}
+
+// patterns detects the matches of labelled custom expressions.
+type patterns map[string]*regexp.Regexp
+
+func (patterns) Name() string { return "patterns" }
+
+func (p patterns) Validate(ctx context.Context, text string) []guardrails.Finding {
+	var findings []guardrails.Finding
+	for label, re := range p {
+		for _, loc := range re.FindAllStringIndex(text, -1) {
+			findings = append(findings, guardrails.Finding{
+				Validator: "patterns", Label: label, Message: "found " + label, Start: loc[0], End: loc[1],
+			})
+		}
+	}
+	return findings
+}
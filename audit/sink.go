@@ -0,0 +1,178 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// FileSink writes records as JSON lines.
+type FileSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewFileSink opens path for appending, creating it if needed, and returns a sink writing to it.
+// The file is created with permissions 0600, since records may hold sensitive data.
+func NewFileSink(path string) (*FileSink, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("audit: %w", err)
+	}
+	return &FileSink{w: f}, nil
+}
+
+// NewWriterSink returns a sink writing JSON lines to w, e.g. os.Stdout.
+func NewWriterSink(w io.Writer) *FileSink {
+	return &FileSink{w: w}
+}
+
+// Write implements Sink.
+func (s *FileSink) Write(ctx context.Context, rec Record) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(data, '\n'))
+	return err
+}
+
+// Close closes the underlying file or writer, if it can be closed.
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok := s.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// HTTPSink posts every record as JSON to an endpoint, e.g. a log collector.
+type HTTPSink struct {
+	URL     string
+	Headers map[string]string // Added to every request, e.g. for authentication.
+	Client  *http.Client      // Defaults to http.DefaultClient.
+}
+
+// Write implements Sink.
+func (s *HTTPSink) Write(ctx context.Context, rec Record) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.Headers {
+		req.Header.Set(k, v)
+	}
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// Dialect describes the SQL differences between the databases supported by SQLSink.
+type Dialect int
+
+const (
+	SQLite   Dialect = iota // "?" placeholders.
+	Postgres                // "$1" placeholders.
+)
+
+// SQLSink stores records in the table <prefix>audit of a SQLite or PostgreSQL database. The
+// driver is not imported; open the *sql.DB with the driver of your choice, e.g.
+// modernc.org/sqlite or github.com/jackc/pgx/v5/stdlib. Times are stored as Unix nanoseconds
+// and durations as nanoseconds.
+type SQLSink struct {
+	DB      *sql.DB
+	Dialect Dialect
+	// Prefix is prepended to the table name; defaults to "gatotkaca_".
+	Prefix string
+}
+
+// NewSQLSink creates a SQLSink and its table if it does not exist yet.
+func NewSQLSink(ctx context.Context, db *sql.DB, dialect Dialect) (*SQLSink, error) {
+	s := &SQLSink{DB: db, Dialect: dialect}
+	if err := s.CreateTable(ctx); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *SQLSink) table() string {
+	prefix := s.Prefix
+	if prefix == "" {
+		prefix = "gatotkaca_"
+	}
+	return prefix + "audit"
+}
+
+// CreateTable creates the table and indexes used by the sink if they do not exist.
+func (s *SQLSink) CreateTable(ctx context.Context) error {
+	t := s.table()
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS ` + t + ` (
+	id TEXT PRIMARY KEY,
+	time BIGINT NOT NULL,
+	kind TEXT NOT NULL,
+	model TEXT NOT NULL,
+	tool TEXT NOT NULL,
+	system TEXT NOT NULL,
+	request TEXT NOT NULL,
+	response TEXT NOT NULL,
+	error TEXT NOT NULL,
+	duration BIGINT NOT NULL,
+	prompt_tokens BIGINT NOT NULL,
+	completion_tokens BIGINT NOT NULL,
+	total_tokens BIGINT NOT NULL
+)`,
+		`CREATE INDEX IF NOT EXISTS ` + t + `_time ON ` + t + ` (time)`,
+	}
+	for _, stmt := range stmts {
+		if _, err := s.DB.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("audit: create table: %w", err)
+		}
+	}
+	return nil
+}
+
+// Write implements Sink.
+func (s *SQLSink) Write(ctx context.Context, rec Record) error {
+	columns := []string{"id", "time", "kind", "model", "tool", "system", "request", "response", "error",
+		"duration", "prompt_tokens", "completion_tokens", "total_tokens"}
+	placeholders := make([]string, len(columns))
+	for i := range placeholders {
+		if s.Dialect == Postgres {
+			placeholders[i] = fmt.Sprintf("$%d", i+1)
+		} else {
+			placeholders[i] = "?"
+		}
+	}
+	query := `INSERT INTO ` + s.table() + ` (` + strings.Join(columns, ", ") + `) VALUES (` + strings.Join(placeholders, ", ") + `)`
+	_, err := s.DB.ExecContext(ctx, query, rec.ID, rec.Time.UnixNano(), rec.Kind, rec.Model, rec.Tool, rec.System,
+		rec.Request, rec.Response, rec.Error, int64(rec.Duration), rec.Usage.PromptTokens, rec.Usage.CompletionTokens,
+		rec.Usage.TotalTokens)
+	return err
+}
@@ -0,0 +1,227 @@
+// Package budget limits the resources of a run, such as an agent's Send or a workflow's Run:
+// its wall time and the number of LLM calls, tool calls and tokens it may use. The budget is
+// carried by the context, so it covers everything the run does, including nested agents and
+// flows, and is checked by the llm client before every model call, by agents before every tool
+// call, and by flows before every node.
+package budget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrBudgetExceeded is matched by every *BudgetExceeded with errors.Is.
+var ErrBudgetExceeded = errors.New("run budget exceeded")
+
+// Limits reported in BudgetExceeded.Limit.
+const (
+	LimitWallTime  = "wall_time"
+	LimitLLMCalls  = "llm_calls"
+	LimitToolCalls = "tool_calls"
+	LimitTokens    = "tokens"
+)
+
+// RunBudget limits a run. Zero fields mean no limit.
+type RunBudget struct {
+	MaxDuration  time.Duration // Wall time, enforced with a context deadline.
+	MaxLLMCalls  int
+	MaxToolCalls int
+	// MaxTokens limits the total tokens reported by the providers. A call that starts within
+	// the limit is allowed to finish, so the run may use somewhat more.
+	MaxTokens int
+}
+
+// IsZero reports whether b sets no limit.
+func (b RunBudget) IsZero() bool {
+	return b == RunBudget{}
+}
+
+// Usage is what a run has used of its budget.
+type Usage struct {
+	Elapsed   time.Duration
+	LLMCalls  int
+	ToolCalls int
+	Tokens    int
+}
+
+// BudgetExceeded is returned when a run runs out of budget. Partial holds the output the run
+// produced before it was stopped, such as the agent's latest response or the output of the
+// last completed node of a flow.
+type BudgetExceeded struct {
+	Limit   string // One of the Limit constants.
+	Usage   Usage
+	Partial string
+
+	run *Run // The run whose budget was exceeded.
+}
+
+func (e *BudgetExceeded) Error() string {
+	return fmt.Sprintf("run budget exceeded: %s limit reached (%d llm calls, %d tool calls, %d tokens in %v)",
+		e.Limit, e.Usage.LLMCalls, e.Usage.ToolCalls, e.Usage.Tokens, e.Usage.Elapsed.Round(time.Millisecond))
+}
+
+// Unwrap returns ErrBudgetExceeded.
+func (e *BudgetExceeded) Unwrap() error {
+	return ErrBudgetExceeded
+}
+
+// Run tracks the usage of a budget. A nil *Run has no budget; its methods do nothing.
+type Run struct {
+	budget   RunBudget
+	start    time.Time
+	deadline time.Time
+	parent   *Run // Enclosing run, whose budget applies too.
+	cancel   context.CancelFunc
+
+	mu    sync.Mutex
+	usage Usage
+}
+
+type contextKey struct{}
+
+// Start attaches a budget to ctx for a run. The budget of an enclosing run carried by ctx still
+// applies. Call Stop when the run ends, and pass its result through Err. A zero budget attaches
+// nothing and returns a nil *Run.
+func Start(ctx context.Context, b RunBudget) (context.Context, *Run) {
+	if b.IsZero() {
+		return ctx, nil
+	}
+	r := &Run{budget: b, start: time.Now(), parent: FromContext(ctx)}
+	if b.MaxDuration > 0 {
+		r.deadline = r.start.Add(b.MaxDuration)
+		ctx, r.cancel = context.WithDeadline(ctx, r.deadline)
+	}
+	return context.WithValue(ctx, contextKey{}, r), r
+}
+
+// FromContext returns the innermost Run carried by ctx, or nil.
+func FromContext(ctx context.Context) *Run {
+	r, _ := ctx.Value(contextKey{}).(*Run)
+	return r
+}
+
+// Stop releases the resources of the run's deadline.
+func (r *Run) Stop() {
+	if r != nil && r.cancel != nil {
+		r.cancel()
+	}
+}
+
+// Usage returns what the run has used so far.
+func (r *Run) Usage() Usage {
+	if r == nil {
+		return Usage{}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u := r.usage
+	u.Elapsed = time.Since(r.start)
+	return u
+}
+
+// Check returns a *BudgetExceeded if the run or an enclosing run is out of wall time or tokens,
+// or has used all of its LLM or tool calls.
+func (r *Run) Check() error {
+	for ; r != nil; r = r.parent {
+		r.mu.Lock()
+		limit := r.exceeded(0, 0)
+		r.mu.Unlock()
+		if limit != "" {
+			return r.errorFor(limit)
+		}
+	}
+	return nil
+}
+
+// BeginLLMCall counts an LLM call, or returns a *BudgetExceeded if the budget does not allow it.
+func (r *Run) BeginLLMCall() error {
+	return r.begin(1, 0)
+}
+
+// BeginToolCall counts a tool call, or returns a *BudgetExceeded if the budget does not allow it.
+func (r *Run) BeginToolCall() error {
+	return r.begin(0, 1)
+}
+
+// begin counts calls against the run and its enclosing runs if all of them allow them.
+func (r *Run) begin(llmCalls, toolCalls int) error {
+	var runs []*Run
+	for p := r; p != nil; p = p.parent {
+		runs = append(runs, p)
+	}
+	for _, p := range runs {
+		p.mu.Lock()
+	}
+	defer func() {
+		for _, p := range runs {
+			p.mu.Unlock()
+		}
+	}()
+	for _, p := range runs {
+		if limit := p.exceeded(llmCalls, toolCalls); limit != "" {
+			u := p.usage
+			u.Elapsed = time.Since(p.start)
+			return &BudgetExceeded{Limit: limit, Usage: u, run: p}
+		}
+	}
+	for _, p := range runs {
+		p.usage.LLMCalls += llmCalls
+		p.usage.ToolCalls += toolCalls
+	}
+	return nil
+}
+
+// AddTokens counts tokens used by an LLM call.
+func (r *Run) AddTokens(n int) {
+	for ; r != nil; r = r.parent {
+		r.mu.Lock()
+		r.usage.Tokens += n
+		r.mu.Unlock()
+	}
+}
+
+// exceeded returns the limit that the run's usage plus the given calls would exceed, or "".
+// The caller must hold r.mu.
+func (r *Run) exceeded(llmCalls, toolCalls int) string {
+	b := r.budget
+	switch {
+	case !r.deadline.IsZero() && !time.Now().Before(r.deadline):
+		return LimitWallTime
+	case b.MaxTokens > 0 && r.usage.Tokens >= b.MaxTokens:
+		return LimitTokens
+	case b.MaxLLMCalls > 0 && llmCalls > 0 && r.usage.LLMCalls+llmCalls > b.MaxLLMCalls:
+		return LimitLLMCalls
+	case b.MaxToolCalls > 0 && toolCalls > 0 && r.usage.ToolCalls+toolCalls > b.MaxToolCalls:
+		return LimitToolCalls
+	}
+	return ""
+}
+
+func (r *Run) errorFor(limit string) *BudgetExceeded {
+	return &BudgetExceeded{Limit: limit, Usage: r.Usage(), run: r}
+}
+
+// Err returns the error to report for err at the end of the run. If the run's own budget was
+// exceeded, including a model or tool call failing because the run's deadline passed, it
+// returns a *BudgetExceeded with Partial set to partial; other errors are returned unchanged.
+func (r *Run) Err(err error, partial string) error {
+	if r == nil || err == nil {
+		return err
+	}
+	var exceeded *BudgetExceeded
+	if errors.As(err, &exceeded) {
+		if exceeded.run == r {
+			exceeded.Partial = partial
+		}
+		return err
+	}
+	if errors.Is(err, context.DeadlineExceeded) && !r.deadline.IsZero() && !time.Now().Before(r.deadline) {
+		e := r.errorFor(LimitWallTime)
+		e.Partial = partial
+		return e
+	}
+	return err
+}
@@ -0,0 +1,69 @@
+package budget_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/zakirkun/gatot-kaca/budget"
+)
+
+func TestRunLimits(t *testing.T) {
+	ctx, outer := budget.Start(context.Background(), budget.RunBudget{MaxLLMCalls: 3, MaxTokens: 100})
+	defer outer.Stop()
+	ctx, inner := budget.Start(ctx, budget.RunBudget{MaxToolCalls: 1})
+	defer inner.Stop()
+	if budget.FromContext(ctx) != inner {
+		t.Fatal("FromContext does not return the innermost run")
+	}
+
+	if err := inner.BeginToolCall(); err != nil {
+		t.Fatal(err)
+	}
+	var exceeded *budget.BudgetExceeded
+	if err := inner.BeginToolCall(); !errors.As(err, &exceeded) || exceeded.Limit != budget.LimitToolCalls {
+		t.Errorf("second tool call: got %v, want the tool call limit", err)
+	}
+
+	// LLM calls of the inner run count against the outer budget.
+	for i := 0; i < 3; i++ {
+		if err := inner.BeginLLMCall(); err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+	}
+	if err := inner.BeginLLMCall(); !errors.As(err, &exceeded) || exceeded.Limit != budget.LimitLLMCalls {
+		t.Errorf("fourth LLM call: got %v, want the outer LLM call limit", err)
+	}
+	if u := outer.Usage(); u.LLMCalls != 3 || u.ToolCalls != 1 {
+		t.Errorf("outer usage = %+v", u)
+	}
+
+	inner.AddTokens(100)
+	if err := inner.Check(); !errors.As(err, &exceeded) || exceeded.Limit != budget.LimitTokens ||
+		!errors.Is(err, budget.ErrBudgetExceeded) {
+		t.Errorf("Check after 100 tokens: got %v, want the token limit", err)
+	}
+}
+
+func TestRunErr(t *testing.T) {
+	ctx, run := budget.Start(context.Background(), budget.RunBudget{MaxDuration: 10 * time.Millisecond})
+	defer run.Stop()
+	<-ctx.Done()
+
+	err := run.Err(fmt.Errorf("calling model: %w", ctx.Err()), "partial answer")
+	var exceeded *budget.BudgetExceeded
+	if !errors.As(err, &exceeded) || exceeded.Limit != budget.LimitWallTime || exceeded.Partial != "partial answer" {
+		t.Errorf("got %v, want the wall time limit with the partial output", err)
+	}
+
+	other := errors.New("other")
+	if err := run.Err(other, ""); err != other {
+		t.Errorf("other errors: got %v, want them unchanged", err)
+	}
+
+	if _, none := budget.Start(context.Background(), budget.RunBudget{}); none != nil || none.Check() != nil {
+		t.Error("a zero budget should start no run")
+	}
+}
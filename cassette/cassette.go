@@ -0,0 +1,266 @@
+// Package cassette records the LLM and tool interactions of a run into a file and replays them
+// later, so that agents and workflows can be tested deterministically without calling APIs.
+//
+// A typical test opens a cassette in ModeAuto, which records on the first run (when the file
+// does not exist yet) and replays afterwards:
+//
+//	c, err := cassette.Open("testdata/summarize.json", cassette.ModeAuto)
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer c.Save()
+//	c.WrapClient(client)
+//	c.WrapTools(a.Tools())
+package cassette
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/zakirkun/gatot-kaca/agent/tools"
+	"github.com/zakirkun/gatot-kaca/llm"
+)
+
+// ErrNoInteraction is returned in replay mode when the cassette has no recorded interaction left
+// for a request.
+var ErrNoInteraction = errors.New("cassette: no recorded interaction")
+
+// Mode selects whether a cassette records or replays.
+type Mode int
+
+const (
+	// ModeAuto replays if the cassette file exists and records otherwise.
+	ModeAuto Mode = iota
+	// ModeRecord calls the real models and tools and records their responses.
+	ModeRecord
+	// ModeReplay returns recorded responses and never calls the real models and tools.
+	ModeReplay
+)
+
+// Kinds of interactions.
+const (
+	KindGenerate  = "generate"
+	KindEmbedding = "embedding"
+	KindTool      = "tool"
+)
+
+// Interaction is a recorded call.
+type Interaction struct {
+	Kind     string          `json:"kind"`
+	Name     string          `json:"name"` // Model or tool name.
+	Request  json.RawMessage `json:"request"`
+	Response json.RawMessage `json:"response,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// key identifies the interactions that answer the same request.
+func (i Interaction) key() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00", i.Kind, i.Name)
+	// The file is indented, so compare requests in compact form.
+	var req bytes.Buffer
+	if err := json.Compact(&req, i.Request); err != nil {
+		req.Write(i.Request)
+	}
+	h.Write(req.Bytes())
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Cassette holds recorded interactions. It is safe for concurrent use.
+//
+// Identical requests are answered in the order they were recorded, so concurrent calls replay
+// deterministically as long as distinct requests differ in content.
+type Cassette struct {
+	path string
+	mode Mode
+
+	mu           sync.Mutex
+	Interactions []Interaction  `json:"interactions"`
+	replayed     map[string]int // Number of interactions replayed per key.
+}
+
+// Open loads the cassette at path for replay, or prepares an empty one for recording, depending
+// on mode.
+func Open(path string, mode Mode) (*Cassette, error) {
+	c := &Cassette{path: path, mode: mode, replayed: make(map[string]int)}
+	if mode == ModeAuto {
+		c.mode = ModeRecord
+		if _, err := os.Stat(path); err == nil {
+			c.mode = ModeReplay
+		}
+	}
+	if c.mode == ModeRecord {
+		return c, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cassette: %w", err)
+	}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("cassette: invalid file %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Mode returns ModeRecord or ModeReplay.
+func (c *Cassette) Mode() Mode {
+	return c.mode
+}
+
+// Save writes the recorded interactions to the cassette file. It does nothing in replay mode.
+func (c *Cassette) Save() error {
+	if c.mode != ModeRecord {
+		return nil
+	}
+	c.mu.Lock()
+	data, err := json.MarshalIndent(c, "", "  ")
+	c.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("cassette: %w", err)
+	}
+	if dir := filepath.Dir(c.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("cassette: %w", err)
+		}
+	}
+	if err := os.WriteFile(c.path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("cassette: %w", err)
+	}
+	return nil
+}
+
+// do records or replays one call. call performs the real call and is only used in record mode;
+// out receives the response.
+func (c *Cassette) do(kind, name string, req interface{}, out interface{}, call func() (interface{}, error)) error {
+	reqData, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("cassette: %w", err)
+	}
+	in := Interaction{Kind: kind, Name: name, Request: reqData}
+
+	if c.mode == ModeReplay {
+		rec, ok := c.next(in.key())
+		if !ok {
+			return fmt.Errorf("%w for %s %q", ErrNoInteraction, kind, name)
+		}
+		if rec.Error != "" {
+			return errors.New(rec.Error)
+		}
+		return json.Unmarshal(rec.Response, out)
+	}
+
+	resp, callErr := call()
+	if callErr != nil {
+		in.Error = callErr.Error()
+	} else if in.Response, err = json.Marshal(resp); err != nil {
+		return fmt.Errorf("cassette: %w", err)
+	}
+	c.mu.Lock()
+	c.Interactions = append(c.Interactions, in)
+	c.mu.Unlock()
+	if callErr != nil {
+		return callErr
+	}
+	return json.Unmarshal(in.Response, out)
+}
+
+// next returns the next unreplayed interaction with the given key.
+func (c *Cassette) next(key string) (Interaction, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	skip := c.replayed[key]
+	for _, in := range c.Interactions {
+		if in.key() != key {
+			continue
+		}
+		if skip > 0 {
+			skip--
+			continue
+		}
+		c.replayed[key]++
+		return in, true
+	}
+	return Interaction{}, false
+}
+
+// Model wraps a model so that its calls go through the cassette under the given name.
+func (c *Cassette) Model(name string, model llm.Model) llm.Model {
+	return &cassetteModel{c: c, name: name, Model: model}
+}
+
+// WrapClient wraps every model of the client, including the fallback model.
+func (c *Cassette) WrapClient(client *llm.Client) {
+	client.WrapModels(c.Model)
+}
+
+// Tool wraps a tool so that its calls go through the cassette. Schema and help of enhanced tools
+// are kept.
+func (c *Cassette) Tool(t tools.Tool) tools.Tool {
+	ct := &cassetteTool{c: c, Tool: t}
+	if et, ok := t.(tools.EnhancedTool); ok {
+		return &cassetteEnhancedTool{cassetteTool: ct, enhanced: et}
+	}
+	return ct
+}
+
+// WrapTools replaces every tool registered with the manager by its wrapped version.
+func (c *Cassette) WrapTools(m *tools.Manager) {
+	for _, name := range m.ListTools() {
+		t, err := m.GetTool(name)
+		if err == nil {
+			m.RegisterTool(c.Tool(t))
+		}
+	}
+}
+
+type cassetteModel struct {
+	llm.Model
+	c    *Cassette
+	name string
+}
+
+func (m *cassetteModel) Generate(ctx context.Context, req llm.ModelRequest) (llm.ModelResponse, error) {
+	var resp llm.ModelResponse
+	err := m.c.do(KindGenerate, m.name, req, &resp, func() (interface{}, error) {
+		return m.Model.Generate(ctx, req)
+	})
+	return resp, err
+}
+
+func (m *cassetteModel) GenerateEmbedding(ctx context.Context, text string) ([]float64, error) {
+	var emb []float64
+	err := m.c.do(KindEmbedding, m.name, text, &emb, func() (interface{}, error) {
+		return m.Model.GenerateEmbedding(ctx, text)
+	})
+	return emb, err
+}
+
+type cassetteTool struct {
+	tools.Tool
+	c *Cassette
+}
+
+func (t *cassetteTool) Execute(ctx context.Context, input string) (string, error) {
+	var output string
+	err := t.c.do(KindTool, t.Name(), input, &output, func() (interface{}, error) {
+		return t.Tool.Execute(ctx, input)
+	})
+	return output, err
+}
+
+type cassetteEnhancedTool struct {
+	*cassetteTool
+	enhanced tools.EnhancedTool
+}
+
+func (t *cassetteEnhancedTool) Schema() string { return t.enhanced.Schema() }
+func (t *cassetteEnhancedTool) Help() string   { return t.enhanced.Help() }
@@ -0,0 +1,65 @@
+package cassette_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/zakirkun/gatot-kaca/cassette"
+	"github.com/zakirkun/gatot-kaca/llm"
+	"github.com/zakirkun/gatot-kaca/llmtest"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "testdata", "run.json")
+	req := llm.ModelRequest{Prompt: "What is 2+3?"}
+
+	rec, err := cassette.Open(path, cassette.ModeAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Mode() != cassette.ModeRecord {
+		t.Fatalf("mode %v, want ModeRecord for a new cassette", rec.Mode())
+	}
+	model := rec.Model("mock", llmtest.NewMockModel("mock", "first", "second"))
+	tool := rec.Tool(llmtest.NewMockTool("calculator").Fail(errors.New("division by zero")))
+	for _, want := range []string{"first", "second"} {
+		if resp, err := model.Generate(ctx, req); err != nil || resp.Text != want {
+			t.Fatalf("recording: got %q, %v; want %q", resp.Text, err, want)
+		}
+	}
+	if _, err := tool.Execute(ctx, "1/0"); err == nil {
+		t.Fatal("recording: want the tool's error")
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	play, err := cassette.Open(path, cassette.ModeAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if play.Mode() != cassette.ModeReplay {
+		t.Fatalf("mode %v, want ModeReplay for a recorded cassette", play.Mode())
+	}
+	real := llmtest.NewMockModel("mock")
+	model = play.Model("mock", real)
+	tool = play.Tool(llmtest.NewMockTool("calculator", "unexpected"))
+	for _, want := range []string{"first", "second"} {
+		if resp, err := model.Generate(ctx, req); err != nil || resp.Text != want {
+			t.Errorf("replay: got %q, %v; want %q", resp.Text, err, want)
+		}
+	}
+	if _, err := model.Generate(ctx, req); !errors.Is(err, cassette.ErrNoInteraction) {
+		t.Errorf("third call: got %v, want ErrNoInteraction", err)
+	}
+	if _, err := model.Generate(ctx, llm.ModelRequest{Prompt: "other"}); !errors.Is(err, cassette.ErrNoInteraction) {
+		t.Errorf("unrecorded request: got %v, want ErrNoInteraction", err)
+	}
+	if _, err := tool.Execute(ctx, "1/0"); err == nil || err.Error() != "division by zero" {
+		t.Errorf("replayed tool error = %v", err)
+	}
+	real.AssertCalled(t, 0)
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/zakirkun/gatot-kaca/agent"
+	"github.com/zakirkun/gatot-kaca/agent/tools"
+)
+
+func runChat(args []string) error {
+	fs := flag.NewFlagSet("chat", flag.ExitOnError)
+	var common commonFlags
+	common.register(fs)
+	system := fs.String("system", "", "system prompt for the agent")
+	maxTokens := fs.Int("max-tokens", 1024, "maximum tokens per response")
+	stream := fs.Bool("stream", true, "stream responses as they are generated")
+	var toolCommands, plugins stringList
+	fs.Var(&toolCommands, "tool", "command of a subprocess tool to register (repeatable)")
+	fs.Var(&plugins, "plugin", "path of a Go plugin providing tools to register (repeatable)")
+	fs.Parse(args)
+
+	client, model, err := common.client()
+	if err != nil {
+		return err
+	}
+
+	a := agent.New(client, model,
+		agent.WithSystemPrompt(*system),
+		agent.WithMaxTokens(*maxTokens),
+	)
+	if err := registerExternalTools(a, toolCommands, plugins); err != nil {
+		return err
+	}
+	if len(toolCommands)+len(plugins) > 0 {
+		a.EnableToolCatalog(nil)
+	}
+
+	fmt.Printf("Chatting with %s. Type /reset to clear the conversation, /save or /load <file> to archive or resume it, /exit to quit.\n", model)
+	ctx := context.Background()
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Print("> ")
+		if !scanner.Scan() {
+			fmt.Println()
+			return scanner.Err()
+		}
+		input := strings.TrimSpace(scanner.Text())
+		switch input {
+		case "":
+			continue
+		case "/exit", "/quit":
+			return nil
+		case "/reset":
+			a.Reset()
+			fmt.Println("Conversation cleared.")
+			continue
+		}
+		if cmd, path, ok := strings.Cut(input, " "); ok && (cmd == "/save" || cmd == "/load") {
+			done := "Conversation saved to " + path + "."
+			if cmd == "/save" {
+				err = a.SaveHistoryFile(path)
+			} else {
+				err = a.LoadHistoryFile(path)
+				done = "Conversation loaded from " + path + "."
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			} else {
+				fmt.Println(done)
+			}
+			continue
+		}
+
+		if *stream {
+			_, err = a.SendStream(ctx, input, func(chunk string) error {
+				fmt.Print(chunk)
+				return nil
+			})
+			fmt.Println()
+		} else {
+			var reply string
+			reply, err = a.Send(ctx, input)
+			fmt.Println(reply)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		}
+	}
+}
+
+// registerExternalTools registers the subprocess tools and plugin tools given on the command line.
+func registerExternalTools(a *agent.Agent, commands, plugins []string) error {
+	for _, command := range commands {
+		fields := strings.Fields(command)
+		if len(fields) == 0 {
+			continue
+		}
+		t, err := tools.NewSubprocessTool(context.Background(), fields[0], fields[1:]...)
+		if err != nil {
+			return err
+		}
+		a.RegisterTool(t)
+	}
+	for _, path := range plugins {
+		ts, err := tools.LoadPlugin(path)
+		if err != nil {
+			return err
+		}
+		for _, t := range ts {
+			a.RegisterTool(t)
+		}
+	}
+	return nil
+}
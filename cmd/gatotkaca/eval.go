@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/zakirkun/gatot-kaca/agent"
+	"github.com/zakirkun/gatot-kaca/eval"
+	"github.com/zakirkun/gatot-kaca/prompt"
+)
+
+func runEval(args []string) error {
+	fs := flag.NewFlagSet("eval", flag.ExitOnError)
+	var common commonFlags
+	common.register(fs)
+	system := fs.String("system", "", "system prompt for the agent")
+	promptRef := fs.String("prompt", "", "stored prompt to use as the system prompt, as name or name@version")
+	promptDir := fs.String("prompts", "prompts", "directory of the prompt store used by -prompt")
+	threshold := fs.Float64("threshold", 1.0, "minimum score for a case to pass")
+	concurrency := fs.Int("concurrency", 4, "number of cases to run in parallel")
+	repeats := fs.Int("repeats", 1, "number of times each case is run")
+	jsonOut := fs.String("json", "", "write the report as JSON to this file")
+	csvOut := fs.String("csv", "", "write the report as CSV to this file")
+	htmlOut := fs.String("html", "", "write the report as an HTML dashboard to this file")
+	baselinePath := fs.String("baseline", "", "JSON report of a previous run to compare against")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gatotkaca eval [flags] <suite.jsonl|suite.csv>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("suite file is required")
+	}
+
+	client, model, err := common.client()
+	if err != nil {
+		return err
+	}
+
+	cases, err := eval.LoadDataset(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if len(cases) == 0 {
+		return fmt.Errorf("suite contains no cases")
+	}
+
+	var store prompt.Store
+	if *promptRef != "" {
+		if store, err = prompt.NewFileStore(*promptDir); err != nil {
+			return err
+		}
+		if _, err := store.Get(*promptRef); err != nil {
+			return err
+		}
+	}
+
+	runner := &eval.Runner{
+		Target: eval.AgentTarget(func() *agent.Agent {
+			a := agent.New(client, model, agent.WithSystemPrompt(*system))
+			if store != nil {
+				// The reference was resolved above, so loading it again only fails if the store changed.
+				if err := a.UsePrompt(store, *promptRef, nil); err != nil {
+					fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+				}
+			}
+			return a
+		}),
+		Concurrency:   *concurrency,
+		Repeats:       *repeats,
+		PassThreshold: *threshold,
+	}
+	report, err := runner.Run(context.Background(), cases)
+	if err != nil {
+		return err
+	}
+
+	for _, res := range report.Results {
+		switch {
+		case res.Err != nil:
+			fmt.Printf("ERROR %-10s %v\n", res.Case.ID, res.Err)
+		case res.Passed:
+			fmt.Printf("PASS  %-10s %v\n", res.Case.ID, res.Scores)
+		default:
+			fmt.Printf("FAIL  %-10s %v\n", res.Case.ID, res.Scores)
+		}
+	}
+	fmt.Printf("\n%d/%d passed (%.1f%%), %d errors, %v\n",
+		report.Passed, report.Total, 100*report.PassRate, report.Errored, report.Duration)
+	for _, name := range report.EvaluatorNames() {
+		if stats, ok := report.Stats[name]; ok {
+			fmt.Printf("  mean %s: %.3f ± %.3f (95%% CI %.3f–%.3f, n=%d)\n",
+				name, stats.Mean, stats.StdDev, stats.CILow, stats.CIHigh, stats.N)
+			continue
+		}
+		fmt.Printf("  mean %s: %.3f\n", name, report.MeanScores[name])
+	}
+
+	var baseline *eval.Report
+	if *baselinePath != "" {
+		baseline, err = eval.LoadReport(*baselinePath)
+		if err != nil {
+			return err
+		}
+		cmp := eval.Compare(baseline, report)
+		fmt.Printf("\nvs baseline: pass rate %+.1f%%, %d regressions, %d fixes\n",
+			100*cmp.PassRateDelta, len(cmp.Regressions), len(cmp.Fixes))
+		if len(cmp.PromptVersions) > 0 || len(cmp.BaselinePromptVersions) > 0 {
+			fmt.Printf("  prompts: %v -> %v\n", cmp.BaselinePromptVersions, cmp.PromptVersions)
+		}
+		for _, id := range cmp.Regressions {
+			fmt.Printf("  regressed: %s\n", id)
+		}
+	}
+
+	outputs := []struct {
+		path  string
+		write func(w io.Writer) error
+	}{
+		{*jsonOut, func(w io.Writer) error { return eval.WriteJSON(w, report) }},
+		{*csvOut, func(w io.Writer) error { return eval.WriteCSV(w, report, baseline) }},
+		{*htmlOut, func(w io.Writer) error { return eval.WriteHTML(w, report, baseline) }},
+	}
+	for _, out := range outputs {
+		if out.path == "" {
+			continue
+		}
+		if err := writeFile(out.path, out.write); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeFile(path string, write func(w io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return f.Close()
+}
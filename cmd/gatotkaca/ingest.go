@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/zakirkun/gatot-kaca/agent/tools/builtin"
+	"github.com/zakirkun/gatot-kaca/rag"
+)
+
+func runIngest(args []string) error {
+	fs := flag.NewFlagSet("ingest", flag.ExitOnError)
+	var common commonFlags
+	common.register(fs)
+	out := fs.String("out", "store.kb", "path of the knowledge base file to write")
+	exts := fs.String("ext", ".txt,.md", "comma-separated file extensions to ingest from directories")
+	cacheDir := fs.String("cache", "", "directory of an embedding cache to reuse embeddings of unchanged files")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gatotkaca ingest [flags] <file-dir-or-url>...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("at least one file, directory or URL is required")
+	}
+
+	client, model, err := common.client()
+	if err != nil {
+		return err
+	}
+	kb := rag.NewKnowledgeBase(client, model)
+	if *cacheDir != "" {
+		if kb.Cache, err = rag.NewDiskCache(*cacheDir); err != nil {
+			return err
+		}
+	}
+
+	// Extend an existing store instead of overwriting it.
+	if _, err := os.Stat(*out); err == nil {
+		if err := kb.LoadFile(*out); err != nil {
+			return fmt.Errorf("failed to read existing store %s: %w", *out, err)
+		}
+	}
+
+	allowed := map[string]bool{}
+	for _, ext := range strings.Split(*exts, ",") {
+		allowed[strings.TrimSpace(ext)] = true
+	}
+
+	var paths, urls []string
+	for _, arg := range fs.Args() {
+		if strings.HasPrefix(arg, "http://") || strings.HasPrefix(arg, "https://") {
+			urls = append(urls, arg)
+			continue
+		}
+		err := filepath.Walk(arg, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+			if path == arg || allowed[filepath.Ext(path)] {
+				paths = append(paths, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	docs := make([]rag.Document, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		docs = append(docs, rag.Document{ID: path, Text: string(data)})
+	}
+	// The URLs come from the command line rather than a model, so intranet pages are allowed.
+	fetch := &builtin.FetchTool{AllowPrivate: true}
+	for _, u := range urls {
+		text, err := fetch.Execute(context.Background(), u)
+		if err != nil {
+			return err
+		}
+		docs = append(docs, rag.Document{ID: u, Text: text})
+	}
+	if err := kb.AddDocuments(context.Background(), docs); err != nil {
+		return err
+	}
+
+	if err := kb.SaveFile(*out); err != nil {
+		return fmt.Errorf("failed to write store: %w", err)
+	}
+	fmt.Printf("%d documents ingested, %d in %s\n", len(docs), len(kb.Documents), *out)
+	return nil
+}
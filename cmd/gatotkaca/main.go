@@ -0,0 +1,120 @@
+// Command gatotkaca is a command-line tool for chatting with agents, running workflows,
+// ingesting documents into a RAG store, and running evaluation suites.
+//
+// Usage:
+//
+//	gatotkaca <command> [flags]
+//
+// Commands:
+//
+//	chat     Start an interactive chat with an agent
+//	run      Execute a workflow defined in a YAML file
+//	ingest   Load documents into a RAG store
+//	eval     Run an evaluation suite against an agent
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/zakirkun/gatot-kaca/config"
+	"github.com/zakirkun/gatot-kaca/llm"
+)
+
+type command struct {
+	name  string
+	short string
+	run   func(args []string) error
+}
+
+var commands = []command{
+	{"chat", "Start an interactive chat with an agent", runChat},
+	{"run", "Execute a workflow defined in a YAML file", runWorkflow},
+	{"ingest", "Load documents into a RAG store", runIngest},
+	{"eval", "Run an evaluation suite against an agent", runEval},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	name := os.Args[1]
+	if name == "help" || name == "-h" || name == "--help" {
+		usage()
+		return
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == name {
+			if err := cmd.run(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "gatotkaca %s: %v\n", name, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "gatotkaca: unknown command %q\n\n", name)
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: gatotkaca <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", cmd.name, cmd.short)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Run 'gatotkaca <command> -h' for command flags.")
+}
+
+// commonFlags holds the flags shared by every command.
+type commonFlags struct {
+	configPath string
+	model      string
+}
+
+func (c *commonFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&c.configPath, "config", "config_llm.json", "path to the LLM configuration file")
+	fs.StringVar(&c.model, "model", "", "model name to use (defaults to the configured default model)")
+}
+
+// client loads the configuration and returns a configured LLM client and the model name to use.
+func (c *commonFlags) client() (*llm.Client, string, error) {
+	cfg, err := config.LoadLLMConfig(c.configPath)
+	if err != nil {
+		return nil, "", err
+	}
+	client, err := config.ConfigureLLMClient(cfg)
+	if err != nil {
+		return nil, "", err
+	}
+
+	model := c.model
+	if model == "" {
+		model = cfg.Default
+	}
+	if model == "" && len(cfg.Models) > 0 {
+		model = cfg.Models[0].ModelName
+	}
+	if model == "" {
+		return nil, "", fmt.Errorf("no model configured in %s", c.configPath)
+	}
+	return client, model, nil
+}
+
+// stringList is a flag that can be repeated, collecting every value.
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
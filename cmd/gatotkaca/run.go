@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/zakirkun/gatot-kaca/agent"
+	"github.com/zakirkun/gatot-kaca/llm"
+	"github.com/zakirkun/gatot-kaca/workflow"
+)
+
+// workflowSpec is the YAML definition of a workflow.
+//
+//	name: summarize
+//	model: gpt-4o
+//	system_prompt: You are a helpful assistant.
+//	nodes:
+//	  - type: llm
+//	    message: "Summarize the following text:"
+//	  - type: retry
+//	    max_retries: 2
+//	    delay: 1s
+//	    node:
+//	      type: llm
+//	      message: "Translate to Indonesian:"
+type workflowSpec struct {
+	Name         string     `yaml:"name"`
+	Model        string     `yaml:"model"`
+	SystemPrompt string     `yaml:"system_prompt"`
+	MaxTokens    int        `yaml:"max_tokens"`
+	Nodes        []nodeSpec `yaml:"nodes"`
+}
+
+// nodeSpec is the YAML definition of a single workflow node.
+type nodeSpec struct {
+	Type string `yaml:"type"` // llm, parallel, or retry
+
+	// llm
+	Message      string `yaml:"message"`
+	Model        string `yaml:"model"`
+	SystemPrompt string `yaml:"system_prompt"`
+
+	// parallel
+	Nodes    []nodeSpec `yaml:"nodes"`
+	FailFast bool       `yaml:"fail_fast"`
+	Merge    string     `yaml:"merge"` // join, first_success, longest, json_array, or synthesize
+
+	// retry
+	Node       *nodeSpec     `yaml:"node"`
+	MaxRetries int           `yaml:"max_retries"`
+	Delay      time.Duration `yaml:"delay"`
+	Backoff    float64       `yaml:"backoff"`
+	MaxDelay   time.Duration `yaml:"max_delay"`
+	Jitter     float64       `yaml:"jitter"`
+}
+
+func runWorkflow(args []string) error {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	var common commonFlags
+	common.register(fs)
+	input := fs.String("input", "", "initial input of the workflow (reads stdin if empty)")
+	verbose := fs.Bool("v", false, "print the output and duration of every step")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gatotkaca run [flags] <workflow.yaml>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("workflow file is required")
+	}
+
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("failed to read workflow: %w", err)
+	}
+	var spec workflowSpec
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return fmt.Errorf("failed to parse workflow: %w", err)
+	}
+
+	if spec.Model != "" && common.model == "" {
+		common.model = spec.Model
+	}
+	client, model, err := common.client()
+	if err != nil {
+		return err
+	}
+
+	b := &flowBuilder{client: client, model: model, spec: spec}
+	flow, err := b.build()
+	if err != nil {
+		return err
+	}
+
+	in := *input
+	if in == "" {
+		raw, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read input: %w", err)
+		}
+		in = strings.TrimSpace(string(raw))
+	}
+
+	var onStep func(workflow.StepResult)
+	if *verbose {
+		onStep = func(step workflow.StepResult) {
+			fmt.Fprintf(os.Stderr, "[step %d] %v\n%s\n\n", step.Index, step.Duration, step.Output)
+		}
+	}
+	result, err := flow.RunDetailedWithCallback(context.Background(), in, onStep)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(result.Output)
+	if *verbose {
+		fmt.Fprintf(os.Stderr, "run %s finished in %v, %d tokens\n", result.RunID, result.Duration, result.Usage.TotalTokens)
+	}
+	return nil
+}
+
+// flowBuilder turns a workflowSpec into a workflow.Flow.
+type flowBuilder struct {
+	client *llm.Client
+	model  string
+	spec   workflowSpec
+}
+
+func (b *flowBuilder) build() (*workflow.Flow, error) {
+	if len(b.spec.Nodes) == 0 {
+		return nil, fmt.Errorf("workflow has no nodes")
+	}
+	nodes := make([]workflow.Node, 0, len(b.spec.Nodes))
+	for i, ns := range b.spec.Nodes {
+		n, err := b.node(ns)
+		if err != nil {
+			return nil, fmt.Errorf("node %d: %w", i, err)
+		}
+		nodes = append(nodes, n)
+	}
+	flow := workflow.NewFlow(nodes)
+	flow.Name = b.spec.Name
+	return flow, nil
+}
+
+func (b *flowBuilder) node(ns nodeSpec) (workflow.Node, error) {
+	switch ns.Type {
+	case "llm", "":
+		model := ns.Model
+		if model == "" {
+			model = b.model
+		}
+		system := ns.SystemPrompt
+		if system == "" {
+			system = b.spec.SystemPrompt
+		}
+		opts := []agent.Option{agent.WithSystemPrompt(system)}
+		if b.spec.MaxTokens > 0 {
+			opts = append(opts, agent.WithMaxTokens(b.spec.MaxTokens))
+		}
+		return &workflow.LLMNode{
+			Agent:   agent.New(b.client, model, opts...),
+			Message: ns.Message,
+		}, nil
+
+	case "parallel":
+		children := make([]workflow.Node, 0, len(ns.Nodes))
+		for i, child := range ns.Nodes {
+			n, err := b.node(child)
+			if err != nil {
+				return nil, fmt.Errorf("parallel child %d: %w", i, err)
+			}
+			children = append(children, n)
+		}
+		merger, err := b.merger(ns)
+		if err != nil {
+			return nil, err
+		}
+		return &workflow.ParallelNode{Nodes: children, FailFast: ns.FailFast, Merger: merger}, nil
+
+	case "retry":
+		if ns.Node == nil {
+			return nil, fmt.Errorf("retry node requires a 'node'")
+		}
+		child, err := b.node(*ns.Node)
+		if err != nil {
+			return nil, err
+		}
+		return &workflow.RetryNode{Node: child, MaxRetries: ns.MaxRetries, Delay: ns.Delay, Backoff: ns.Backoff,
+			MaxDelay: ns.MaxDelay, Jitter: ns.Jitter, ShouldRetry: llm.DefaultRetryable}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown node type %q", ns.Type)
+	}
+}
+
+// merger returns the merge strategy of a parallel node.
+func (b *flowBuilder) merger(ns nodeSpec) (workflow.Merger, error) {
+	switch ns.Merge {
+	case "join", "":
+		return workflow.Join{}, nil
+	case "first_success":
+		return workflow.FirstSuccess{}, nil
+	case "longest":
+		return workflow.Longest{}, nil
+	case "json_array":
+		return workflow.JSONArray{}, nil
+	case "synthesize":
+		model := ns.Model
+		if model == "" {
+			model = b.model
+		}
+		return workflow.Synthesize{Agent: agent.New(b.client, model)}, nil
+	default:
+		return nil, fmt.Errorf("unknown merge strategy %q", ns.Merge)
+	}
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -8,6 +9,8 @@ import (
 	"path/filepath"
 	"strings"
 
+	"gopkg.in/yaml.v3"
+
 	"github.com/zakirkun/gatot-kaca/llm"
 )
 
@@ -17,33 +20,82 @@ type LLMConfig struct {
 	Default string            `json:"default,omitempty"`
 }
 
-// LoadLLMConfig memuat konfigurasi LLM dari file
-func LoadLLMConfig(configPath string) (*LLMConfig, error) {
+// LoadLLMConfig memuat konfigurasi LLM dari file. Format ditentukan dari ekstensi file: .yaml
+// atau .yml untuk YAML, .toml untuk TOML, dan JSON untuk ekstensi lainnya. Semua format memakai
+// nama field yang sama dengan JSON, mis. model_name dan api_key. Referensi ${VAR} dan
+// ${VAR:-default} di semua nilai string diganti dengan variabel lingkungan; variabel yang tidak
+// diatur tanpa default menghasilkan error yang cocok dengan ErrEnvNotSet. Lihat WithSecrets untuk
+// mengambil API key dari secret manager.
+func LoadLLMConfig(configPath string, opts ...LoadOption) (*LLMConfig, error) {
+	var o loadOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	// Baca file konfigurasi
 	data, err := ioutil.ReadFile(configPath)
 	if err != nil {
 		return nil, fmt.Errorf("gagal membaca file konfigurasi: %w", err)
 	}
 
+	// Konversi YAML dan TOML ke JSON agar tag json pada llm.ModelConfig tetap berlaku
+	data, err = toJSON(filepath.Ext(configPath), data)
+	if err != nil {
+		return nil, fmt.Errorf("gagal mem-parse konfigurasi: %w", err)
+	}
+
 	// Parse konfigurasi
 	var config LLMConfig
 	if err := json.Unmarshal(data, &config); err != nil {
 		return nil, fmt.Errorf("gagal mem-parse konfigurasi: %w", err)
 	}
 
-	// Ganti variabel lingkungan dalam string
-	for i := range config.Models {
-		if strings.HasPrefix(config.Models[i].APIKey, "${") && strings.HasSuffix(config.Models[i].APIKey, "}") {
-			envVar := config.Models[i].APIKey[2 : len(config.Models[i].APIKey)-1]
-			config.Models[i].APIKey = os.Getenv(envVar)
+	// Ganti variabel lingkungan ${VAR} dan ${VAR:-default} di semua field string
+	if err := interpolateEnv(&config); err != nil {
+		return nil, err
+	}
+
+	// Ambil API key dari secret resolver
+	if o.secrets != nil {
+		if err := ResolveSecrets(context.Background(), &config, o.secrets); err != nil {
+			return nil, err
 		}
 	}
 
 	return &config, nil
 }
 
-// ConfigureLLMClient mengonfigurasi klien LLM dari konfigurasi
+// toJSON mengonversi isi file berformat YAML atau TOML, berdasarkan ekstensi ext, ke JSON.
+// Isi file berformat lain dikembalikan apa adanya.
+func toJSON(ext string, data []byte) ([]byte, error) {
+	var doc interface{}
+	switch strings.ToLower(ext) {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		if doc == nil {
+			doc = map[string]interface{}{}
+		}
+	case ".toml":
+		table, err := parseTOML(string(data))
+		if err != nil {
+			return nil, err
+		}
+		doc = table
+	default:
+		return data, nil
+	}
+	return json.Marshal(doc)
+}
+
+// ConfigureLLMClient mengonfigurasi klien LLM dari konfigurasi. Konfigurasi divalidasi lebih
+// dulu dengan Validate.
 func ConfigureLLMClient(config *LLMConfig) (*llm.Client, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
 	client := llm.NewClient()
 
 	if err := client.ConfigureFromOptions(config.Models); err != nil {
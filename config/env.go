@@ -0,0 +1,108 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/zakirkun/gatot-kaca/llm"
+)
+
+// ErrEnvNotSet dikembalikan jika konfigurasi merujuk variabel lingkungan yang tidak diatur dan
+// tidak memiliki nilai bawaan
+var ErrEnvNotSet = errors.New("variabel lingkungan tidak diatur")
+
+// expandEnv mengganti setiap ${VAR} dalam s dengan nilai variabel lingkungan VAR. Bentuk
+// ${VAR:-default} memakai default jika VAR tidak diatur atau kosong. Nama variabel yang tidak
+// diatur tanpa default dikembalikan dalam missing.
+func expandEnv(s string) (result string, missing []string) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+	var b strings.Builder
+	for {
+		start := strings.Index(s, "${")
+		if start < 0 {
+			b.WriteString(s)
+			return b.String(), missing
+		}
+		end := strings.IndexByte(s[start:], '}')
+		if end < 0 {
+			b.WriteString(s)
+			return b.String(), missing
+		}
+		b.WriteString(s[:start])
+		expr := s[start+2 : start+end]
+		name, def, hasDefault := strings.Cut(expr, ":-")
+		value, ok := os.LookupEnv(name)
+		switch {
+		case hasDefault && value == "":
+			value = def
+		case !ok:
+			missing = append(missing, name)
+		}
+		b.WriteString(value)
+		s = s[start+end+1:]
+	}
+}
+
+// interpolateEnv menerapkan expandEnv ke semua field string konfigurasi, termasuk nilai di
+// dalam Options. Semua variabel yang tidak diatur dilaporkan dalam satu error beserta path field.
+func interpolateEnv(config *LLMConfig) error {
+	var problems []string
+	expand := func(path string, s *string) {
+		value, missing := expandEnv(*s)
+		for _, name := range missing {
+			problems = append(problems, fmt.Sprintf("%s (%s)", name, path))
+		}
+		*s = value
+	}
+
+	expand("default", &config.Default)
+	for i := range config.Models {
+		m := &config.Models[i]
+		prefix := fmt.Sprintf("models[%d].", i)
+		provider := string(m.Provider)
+		expand(prefix+"provider", &provider)
+		m.Provider = llm.ModelProvider(provider)
+		expand(prefix+"model_name", &m.ModelName)
+		expand(prefix+"api_key", &m.APIKey)
+		expand(prefix+"base_url", &m.BaseURL)
+		for j := range m.SafetySettings {
+			s := &m.SafetySettings[j]
+			category, threshold := string(s.Category), string(s.Threshold)
+			expand(fmt.Sprintf("%ssafety_settings[%d].category", prefix, j), &category)
+			expand(fmt.Sprintf("%ssafety_settings[%d].threshold", prefix, j), &threshold)
+			s.Category, s.Threshold = llm.HarmCategory(category), llm.HarmBlockThreshold(threshold)
+		}
+		for key, value := range m.Options {
+			m.Options[key] = expandValue(prefix+"options."+key, value, expand)
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("%w: %s", ErrEnvNotSet, strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// expandValue menerapkan expand ke string di dalam nilai JSON, secara rekursif
+func expandValue(path string, value interface{}, expand func(string, *string)) interface{} {
+	switch v := value.(type) {
+	case string:
+		expand(path, &v)
+		return v
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = expandValue(path+"."+key, item, expand)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = expandValue(fmt.Sprintf("%s[%d]", path, i), item, expand)
+		}
+	}
+	return value
+}
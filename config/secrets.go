@@ -0,0 +1,180 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SecretResolver mengambil nilai rahasia berdasarkan referensi, mis. nama variabel lingkungan,
+// path file, atau ID rahasia di secret manager
+type SecretResolver interface {
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// SecretResolverFunc mengadaptasi fungsi menjadi SecretResolver
+type SecretResolverFunc func(ctx context.Context, ref string) (string, error)
+
+// Resolve memanggil fungsi
+func (f SecretResolverFunc) Resolve(ctx context.Context, ref string) (string, error) {
+	return f(ctx, ref)
+}
+
+// EnvSecrets mengambil rahasia dari variabel lingkungan bernama ref
+type EnvSecrets struct{}
+
+// Resolve mengimplementasikan SecretResolver
+func (EnvSecrets) Resolve(ctx context.Context, ref string) (string, error) {
+	value, ok := os.LookupEnv(ref)
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrEnvNotSet, ref)
+	}
+	return value, nil
+}
+
+// FileSecrets membaca rahasia dari file di path ref, mis. secret Docker atau Kubernetes yang
+// di-mount. Spasi dan baris baru di awal dan akhir dihapus.
+type FileSecrets struct {
+	// Dir adalah direktori dasar untuk path relatif; bawaan direktori kerja
+	Dir string
+}
+
+// Resolve mengimplementasikan SecretResolver
+func (f FileSecrets) Resolve(ctx context.Context, ref string) (string, error) {
+	path := ref
+	if f.Dir != "" && !strings.HasPrefix(path, "/") {
+		path = f.Dir + "/" + path
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// SecretSchemes memilih SecretResolver berdasarkan skema referensi "skema:ref", mis.
+// "env:OPENAI_API_KEY", "file:/run/secrets/openai", "aws-sm:prod/llm#openai", atau
+// "vault:secret/data/llm#openai"
+type SecretSchemes map[string]SecretResolver
+
+// DefaultSecretSchemes mengembalikan skema "env" dan "file". Tambahkan "aws-sm" dengan
+// AWSSecretsManager dan "vault" dengan VaultSecrets sesuai kebutuhan.
+func DefaultSecretSchemes() SecretSchemes {
+	return SecretSchemes{"env": EnvSecrets{}, "file": FileSecrets{}}
+}
+
+// Resolve mengimplementasikan SecretResolver
+func (s SecretSchemes) Resolve(ctx context.Context, ref string) (string, error) {
+	scheme, rest, ok := s.split(ref)
+	if !ok {
+		return "", fmt.Errorf("skema rahasia tidak dikenal: %s", ref)
+	}
+	return s[scheme].Resolve(ctx, rest)
+}
+
+// split memisahkan skema dari ref jika skema tersebut terdaftar
+func (s SecretSchemes) split(ref string) (scheme, rest string, ok bool) {
+	scheme, rest, found := strings.Cut(ref, ":")
+	if !found {
+		return "", "", false
+	}
+	_, ok = s[scheme]
+	return scheme, rest, ok
+}
+
+// CachedSecrets menyimpan hasil Resolver selama TTL, sehingga rahasia yang dirotasi di secret
+// manager terambil kembali setelah TTL habis, mis. saat konfigurasi dimuat ulang
+type CachedSecrets struct {
+	Resolver SecretResolver
+	TTL      time.Duration // Bawaan 5 menit
+
+	mu    sync.Mutex
+	cache map[string]cachedSecret
+}
+
+type cachedSecret struct {
+	value   string
+	expires time.Time
+}
+
+// NewCachedSecrets membungkus resolver dengan cache
+func NewCachedSecrets(resolver SecretResolver, ttl time.Duration) *CachedSecrets {
+	return &CachedSecrets{Resolver: resolver, TTL: ttl}
+}
+
+// Resolve mengimplementasikan SecretResolver
+func (c *CachedSecrets) Resolve(ctx context.Context, ref string) (string, error) {
+	c.mu.Lock()
+	entry, ok := c.cache[ref]
+	c.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.value, nil
+	}
+
+	value, err := c.Resolver.Resolve(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	ttl := c.TTL
+	if ttl <= 0 {
+		ttl = 5 * time.Minute
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cache == nil {
+		c.cache = make(map[string]cachedSecret)
+	}
+	c.cache[ref] = cachedSecret{value: value, expires: time.Now().Add(ttl)}
+	return value, nil
+}
+
+// Invalidate menghapus ref dari cache, atau seluruh cache jika ref kosong, mis. setelah
+// rahasia dirotasi
+func (c *CachedSecrets) Invalidate(ref string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ref == "" {
+		c.cache = nil
+		return
+	}
+	delete(c.cache, ref)
+}
+
+// ResolveSecrets mengganti setiap API key berbentuk "skema:ref" dengan skema yang terdaftar di
+// schemes dengan nilai rahasianya. API key lain dibiarkan apa adanya.
+func ResolveSecrets(ctx context.Context, config *LLMConfig, schemes SecretSchemes) error {
+	for i := range config.Models {
+		key := config.Models[i].APIKey
+		if _, _, ok := schemes.split(key); !ok {
+			continue
+		}
+		value, err := schemes.Resolve(ctx, key)
+		if err != nil {
+			return fmt.Errorf("gagal mengambil api key models[%d]: %w", i, err)
+		}
+		config.Models[i].APIKey = value
+	}
+	return nil
+}
+
+// LoadOption mengonfigurasi LoadLLMConfig
+type LoadOption func(*loadOptions)
+
+type loadOptions struct {
+	secrets SecretSchemes
+}
+
+// WithSecrets mengambil API key berbentuk "skema:ref" dengan schemes saat konfigurasi dimuat,
+// sehingga API key tidak perlu disimpan di file konfigurasi:
+//
+//	schemes := config.DefaultSecretSchemes()
+//	schemes["vault"] = config.NewCachedSecrets(&config.VaultSecrets{}, 10*time.Minute)
+//	cfg, err := config.LoadLLMConfig("llm.yaml", config.WithSecrets(schemes))
+func WithSecrets(schemes SecretSchemes) LoadOption {
+	return func(o *loadOptions) {
+		o.secrets = schemes
+	}
+}
@@ -0,0 +1,166 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AWSSecretsManager mengambil rahasia dari AWS Secrets Manager dengan API GetSecretValue.
+// Referensi berbentuk "secret-id" atau "secret-id#key"; dengan #key, rahasia dibaca sebagai
+// objek JSON dan nilai key tersebut yang dikembalikan. Field yang kosong diambil dari variabel
+// lingkungan AWS standar.
+type AWSSecretsManager struct {
+	Region          string // Bawaan AWS_REGION atau AWS_DEFAULT_REGION
+	AccessKeyID     string // Bawaan AWS_ACCESS_KEY_ID
+	SecretAccessKey string // Bawaan AWS_SECRET_ACCESS_KEY
+	SessionToken    string // Bawaan AWS_SESSION_TOKEN
+	Endpoint        string // Bawaan https://secretsmanager.<region>.amazonaws.com
+	HTTPClient      *http.Client
+}
+
+// Resolve mengimplementasikan SecretResolver
+func (a *AWSSecretsManager) Resolve(ctx context.Context, ref string) (string, error) {
+	id, key, _ := strings.Cut(ref, "#")
+	region := firstNonEmpty(a.Region, os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"))
+	accessKey := firstNonEmpty(a.AccessKeyID, os.Getenv("AWS_ACCESS_KEY_ID"))
+	secretKey := firstNonEmpty(a.SecretAccessKey, os.Getenv("AWS_SECRET_ACCESS_KEY"))
+	token := firstNonEmpty(a.SessionToken, os.Getenv("AWS_SESSION_TOKEN"))
+	if region == "" || accessKey == "" || secretKey == "" {
+		return "", fmt.Errorf("aws secrets manager: region dan kredensial wajib diisi")
+	}
+	endpoint := firstNonEmpty(a.Endpoint, "https://secretsmanager."+region+".amazonaws.com")
+
+	body, err := json.Marshal(map[string]string{"SecretId": id})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", strings.TrimSuffix(endpoint, "/")+"/", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	if token != "" {
+		req.Header.Set("X-Amz-Security-Token", token)
+	}
+	signV4(req, body, region, "secretsmanager", accessKey, secretKey, time.Now().UTC())
+
+	client := a.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("aws secrets manager: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("aws secrets manager: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("aws secrets manager: status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	var out struct {
+		SecretString string `json:"SecretString"`
+	}
+	if err := json.Unmarshal(respBody, &out); err != nil {
+		return "", fmt.Errorf("aws secrets manager: %w", err)
+	}
+	if key == "" {
+		return out.SecretString, nil
+	}
+	return jsonField(out.SecretString, key)
+}
+
+// signV4 menandatangani req dengan AWS Signature Version 4
+func signV4(req *http.Request, body []byte, region, service, accessKey, secretKey string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("Host", req.URL.Host)
+
+	var names []string
+	for name := range req.Header {
+		names = append(names, strings.ToLower(name))
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		value := req.Header.Get(name)
+		if name == "host" {
+			value = req.URL.Host
+		}
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method, path, req.URL.RawQuery, canonicalHeaders.String(), signedHeaders, hexSHA256(body),
+	}, "\n")
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hexSHA256([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Del("Host")
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func hexSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// jsonField mengambil field string key dari objek JSON s
+func jsonField(s, key string) (string, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(s), &fields); err != nil {
+		return "", fmt.Errorf("rahasia bukan objek JSON: %w", err)
+	}
+	value, ok := fields[key]
+	if !ok {
+		return "", fmt.Errorf("key %q tidak ada di rahasia", key)
+	}
+	str, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("key %q bukan string", key)
+	}
+	return str, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// VaultSecrets mengambil rahasia dari HashiCorp Vault. Referensi berbentuk "path#key", mis.
+// "secret/data/llm#openai"; untuk KV versi 2, data di bawah "data.data" dibaca, dan untuk
+// versi 1 "data". Tanpa #key, rahasia harus memiliki tepat satu field.
+type VaultSecrets struct {
+	Address    string // Bawaan VAULT_ADDR
+	Token      string // Bawaan VAULT_TOKEN
+	Namespace  string // Bawaan VAULT_NAMESPACE (Vault Enterprise)
+	HTTPClient *http.Client
+}
+
+// Resolve mengimplementasikan SecretResolver
+func (v *VaultSecrets) Resolve(ctx context.Context, ref string) (string, error) {
+	path, key, _ := strings.Cut(ref, "#")
+	addr := firstNonEmpty(v.Address, os.Getenv("VAULT_ADDR"))
+	token := firstNonEmpty(v.Token, os.Getenv("VAULT_TOKEN"))
+	if addr == "" || token == "" {
+		return "", fmt.Errorf("vault: alamat dan token wajib diisi")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", strings.TrimSuffix(addr, "/")+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if ns := firstNonEmpty(v.Namespace, os.Getenv("VAULT_NAMESPACE")); ns != "" {
+		req.Header.Set("X-Vault-Namespace", ns)
+	}
+
+	client := v.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("vault: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("vault: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault: status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var out struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &out); err != nil {
+		return "", fmt.Errorf("vault: %w", err)
+	}
+	data := out.Data
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, kv2 := data["metadata"]; kv2 {
+			data = nested
+		}
+	}
+	if key == "" {
+		if len(data) != 1 {
+			return "", fmt.Errorf("vault: rahasia %s memiliki %d field; tentukan #key", path, len(data))
+		}
+		for k := range data {
+			key = k
+		}
+	}
+	value, ok := data[key].(string)
+	if !ok {
+		return "", fmt.Errorf("vault: key %q tidak ada atau bukan string di %s", key, path)
+	}
+	return value, nil
+}
@@ -0,0 +1,473 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseTOML mem-parse subset TOML yang cukup untuk file konfigurasi: pasangan key = value,
+// tabel [a.b], array tabel [[a]], string basic dan literal, bilangan, boolean, array, dan
+// tabel inline. Tanggal dan waktu tidak didukung. Dokumen yang tidak valid menurut spesifikasi
+// TOML, mis. tabel yang didefinisikan dua kali, ditolak. Hasilnya berupa map yang dapat
+// dikonversi ke JSON.
+func parseTOML(data string) (map[string]interface{}, error) {
+	p := &tomlParser{src: data, line: 1, kinds: map[string]tomlKind{}}
+	root := map[string]interface{}{}
+	current, path := root, ""
+	for {
+		p.skipSpaceAndComments(true)
+		if p.eof() {
+			return root, nil
+		}
+		var err error
+		if p.peek() == '[' {
+			current, path, err = p.tableHeader(root)
+		} else {
+			err = p.keyValue(current, path)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("toml baris %d: %w", p.line, err)
+		}
+		p.skipSpaceAndComments(false)
+		if !p.eof() && p.peek() != '\n' {
+			return nil, fmt.Errorf("toml baris %d: karakter tak terduga %q", p.line, p.peek())
+		}
+	}
+}
+
+type tomlParser struct {
+	src  string
+	pos  int
+	line int
+	// kinds mencatat cara setiap tabel dan array didefinisikan, menurut path-nya (lihat
+	// tomlPath), untuk menolak definisi ulang
+	kinds map[string]tomlKind
+}
+
+// tomlKind adalah cara sebuah tabel atau array didefinisikan
+type tomlKind int
+
+const (
+	// tomlImplicit adalah tabel yang dibuat sebagai induk header, mis. a untuk [a.b]. Tabel ini
+	// masih boleh didefinisikan sekali dengan header [a].
+	tomlImplicit tomlKind = iota
+	// tomlHeader adalah tabel yang didefinisikan dengan header [a]
+	tomlHeader
+	// tomlDotted adalah tabel yang dibuat oleh key bertitik, mis. a untuk a.b = 1. Tabel ini
+	// hanya boleh diperluas dengan key bertitik atau diberi sub-tabel dengan header.
+	tomlDotted
+	// tomlInline adalah tabel inline atau array statis, yang tidak dapat diubah lagi
+	tomlInline
+	// tomlArray adalah array tabel [[a]]
+	tomlArray
+)
+
+// tomlPath menambahkan key ke path sebuah tabel
+func tomlPath(path, key string) string {
+	return path + "\x00" + key
+}
+
+func (p *tomlParser) eof() bool  { return p.pos >= len(p.src) }
+func (p *tomlParser) peek() byte { return p.src[p.pos] }
+
+// skipSpaceAndComments melewati spasi dan komentar, termasuk baris baru jika newlines true
+func (p *tomlParser) skipSpaceAndComments(newlines bool) {
+	for !p.eof() {
+		switch c := p.peek(); {
+		case c == ' ' || c == '\t' || c == '\r':
+			p.pos++
+		case c == '\n' && newlines:
+			p.pos++
+			p.line++
+		case c == '#':
+			for !p.eof() && p.peek() != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// tableHeader mem-parse [a.b] atau [[a.b]] dan mengembalikan tabel tujuannya beserta path-nya
+func (p *tomlParser) tableHeader(root map[string]interface{}) (map[string]interface{}, string, error) {
+	p.pos++
+	array := !p.eof() && p.peek() == '['
+	if array {
+		p.pos++
+	}
+	keys, err := p.key()
+	if err != nil {
+		return nil, "", err
+	}
+	closing := "]"
+	if array {
+		closing = "]]"
+	}
+	if !strings.HasPrefix(p.src[p.pos:], closing) {
+		return nil, "", fmt.Errorf("header tabel harus ditutup dengan %s", closing)
+	}
+	p.pos += len(closing)
+
+	table, path := root, ""
+	for i, k := range keys {
+		last := i == len(keys)-1
+		path = tomlPath(path, k)
+		switch v := table[k].(type) {
+		case nil:
+			t := map[string]interface{}{}
+			switch {
+			case last && array:
+				table[k] = []interface{}{t}
+				p.kinds[path] = tomlArray
+				return t, path + "[0]", nil
+			case last:
+				p.kinds[path] = tomlHeader
+			default:
+				p.kinds[path] = tomlImplicit
+			}
+			table[k] = t
+			table = t
+		case map[string]interface{}:
+			kind := p.kinds[path]
+			switch {
+			case kind == tomlInline:
+				return nil, "", fmt.Errorf("tabel inline %q tidak dapat diubah", k)
+			case last && array:
+				return nil, "", fmt.Errorf("%q sudah didefinisikan sebagai tabel", k)
+			case last && kind == tomlHeader:
+				return nil, "", fmt.Errorf("tabel %q didefinisikan dua kali", k)
+			case last && kind == tomlDotted:
+				return nil, "", fmt.Errorf("tabel %q sudah didefinisikan dengan key bertitik", k)
+			case last:
+				p.kinds[path] = tomlHeader
+			}
+			table = v
+		case []interface{}:
+			if p.kinds[path] != tomlArray {
+				return nil, "", fmt.Errorf("array statis %q tidak dapat diubah", k)
+			}
+			if last && !array {
+				return nil, "", fmt.Errorf("%q sudah didefinisikan sebagai array tabel", k)
+			}
+			if last {
+				t := map[string]interface{}{}
+				table[k] = append(v, t)
+				return t, fmt.Sprintf("%s[%d]", path, len(v)), nil
+			}
+			path = fmt.Sprintf("%s[%d]", path, len(v)-1)
+			table = v[len(v)-1].(map[string]interface{})
+		default:
+			return nil, "", fmt.Errorf("%q sudah didefinisikan sebagai nilai", k)
+		}
+	}
+	return table, path, nil
+}
+
+// keyValue mem-parse key = value ke dalam table, yang berada di path
+func (p *tomlParser) keyValue(table map[string]interface{}, path string) error {
+	keys, err := p.key()
+	if err != nil {
+		return err
+	}
+	p.skipSpaceAndComments(false)
+	if p.eof() || p.peek() != '=' {
+		return fmt.Errorf("diharapkan '=' setelah key %q", strings.Join(keys, "."))
+	}
+	p.pos++
+	p.skipSpaceAndComments(false)
+	for _, k := range keys[:len(keys)-1] {
+		path = tomlPath(path, k)
+		switch t := table[k].(type) {
+		case nil:
+			created := map[string]interface{}{}
+			table[k] = created
+			p.kinds[path] = tomlDotted
+			table = created
+		case map[string]interface{}:
+			if p.kinds[path] != tomlDotted {
+				return fmt.Errorf("tabel %q tidak dapat diperluas dengan key bertitik", k)
+			}
+			table = t
+		default:
+			return fmt.Errorf("%q sudah didefinisikan sebagai nilai", k)
+		}
+	}
+	k := keys[len(keys)-1]
+	if _, exists := table[k]; exists {
+		return fmt.Errorf("key %q didefinisikan dua kali", k)
+	}
+	path = tomlPath(path, k)
+	value, err := p.value(path)
+	if err != nil {
+		return err
+	}
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		p.kinds[path] = tomlInline
+	}
+	table[k] = value
+	return nil
+}
+
+// key mem-parse key biasa, key berkutip, atau key bertitik
+func (p *tomlParser) key() ([]string, error) {
+	var keys []string
+	for {
+		p.skipSpaceAndComments(false)
+		if p.eof() {
+			return nil, fmt.Errorf("key tidak lengkap")
+		}
+		switch c := p.peek(); {
+		case c == '"' || c == '\'':
+			s, err := p.str()
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, s)
+		default:
+			start := p.pos
+			for !p.eof() && isBareKeyChar(p.peek()) {
+				p.pos++
+			}
+			if start == p.pos {
+				return nil, fmt.Errorf("karakter key tidak valid %q", c)
+			}
+			keys = append(keys, p.src[start:p.pos])
+		}
+		p.skipSpaceAndComments(false)
+		if p.eof() || p.peek() != '.' {
+			return keys, nil
+		}
+		p.pos++
+	}
+}
+
+func isBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// value mem-parse satu nilai yang akan disimpan di path
+func (p *tomlParser) value(path string) (interface{}, error) {
+	if p.eof() {
+		return nil, fmt.Errorf("nilai tidak ada")
+	}
+	switch c := p.peek(); c {
+	case '"', '\'':
+		return p.str()
+	case '[':
+		return p.array(path)
+	case '{':
+		return p.inlineTable(path)
+	}
+	start := p.pos
+	for !p.eof() && !strings.ContainsRune(" \t\r\n#,]}", rune(p.peek())) {
+		p.pos++
+	}
+	raw := p.src[start:p.pos]
+	switch raw {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	return parseTOMLNumber(raw)
+}
+
+// parseTOMLNumber mem-parse bilangan bulat (desimal, 0x, 0o, atau 0b) atau pecahan, termasuk
+// inf dan nan
+func parseTOMLNumber(raw string) (interface{}, error) {
+	num := strings.ReplaceAll(raw, "_", "")
+	digits := strings.TrimLeft(num, "+-")
+	if digits == "" || len(num)-len(digits) > 1 {
+		return nil, fmt.Errorf("nilai tidak valid %q", raw)
+	}
+	switch digits {
+	case "inf", "nan":
+		f, _ := strconv.ParseFloat(num, 64)
+		return f, nil
+	}
+	prefixed := len(digits) > 1 && digits[0] == '0' && strings.ContainsRune("xob", rune(digits[1]))
+	if prefixed && digits != num {
+		return nil, fmt.Errorf("bilangan %q tidak boleh bertanda", raw)
+	}
+	if !prefixed && len(digits) > 1 && digits[0] == '0' && digits[1] >= '0' && digits[1] <= '9' {
+		return nil, fmt.Errorf("bilangan %q tidak boleh diawali nol", raw)
+	}
+	if prefixed || !strings.ContainsAny(num, ".eE") {
+		i, err := strconv.ParseInt(num, 0, 64)
+		if errors.Is(err, strconv.ErrRange) {
+			return nil, fmt.Errorf("bilangan bulat %q di luar jangkauan", raw)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("nilai tidak valid %q", raw)
+		}
+		return i, nil
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil || strings.ContainsAny(digits, "iInN") {
+		return nil, fmt.Errorf("nilai tidak valid %q", raw)
+	}
+	return f, nil
+}
+
+// str mem-parse string basic atau literal, satu baris maupun multi-baris (tiga tanda kutip)
+func (p *tomlParser) str() (string, error) {
+	quote := p.src[p.pos : p.pos+1]
+	if strings.HasPrefix(p.src[p.pos:], quote+quote+quote) {
+		delim := quote + quote + quote
+		p.pos += 3
+		if strings.HasPrefix(p.src[p.pos:], "\r\n") {
+			p.pos += 2
+		} else if strings.HasPrefix(p.src[p.pos:], "\n") {
+			p.pos++
+		}
+		end := strings.Index(p.src[p.pos:], delim)
+		if end < 0 {
+			return "", fmt.Errorf("string multi-baris tidak ditutup")
+		}
+		// Hingga dua tanda kutip tepat sebelum penutup termasuk isi string
+		for extra := 0; extra < 2 && p.pos+end+3 < len(p.src) && p.src[p.pos+end+3] == quote[0]; extra++ {
+			end++
+		}
+		s := p.src[p.pos : p.pos+end]
+		p.line += strings.Count(s, "\n")
+		p.pos += end + 3
+		if quote == "'" {
+			return s, nil
+		}
+		return unescapeTOML(s, true)
+	}
+
+	p.pos++
+	start := p.pos
+	for !p.eof() && p.peek() != quote[0] {
+		if p.peek() == '\n' {
+			return "", fmt.Errorf("string tidak ditutup")
+		}
+		if p.peek() == '\\' && quote == `"` {
+			p.pos++
+		}
+		p.pos++
+	}
+	if p.eof() {
+		return "", fmt.Errorf("string tidak ditutup")
+	}
+	s := p.src[start:p.pos]
+	p.pos++
+	if quote == "'" {
+		return s, nil
+	}
+	return unescapeTOML(s, false)
+}
+
+// unescapeTOML menerjemahkan escape sequence string basic. Pada string multi-baris, backslash di
+// akhir baris menghapus baris baru dan spasi yang mengikutinya.
+func unescapeTOML(s string, multiline bool) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		if i >= len(s) {
+			return "", fmt.Errorf("escape tidak lengkap")
+		}
+		if multiline {
+			if rest := strings.TrimLeft(s[i:], " \t\r"); strings.HasPrefix(rest, "\n") {
+				rest = strings.TrimLeft(rest, " \t\r\n")
+				i = len(s) - len(rest) - 1
+				continue
+			}
+		}
+		switch s[i] {
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case '"', '\\':
+			b.WriteByte(s[i])
+		case 'u', 'U':
+			n := 4
+			if s[i] == 'U' {
+				n = 8
+			}
+			if i+n >= len(s) {
+				return "", fmt.Errorf("escape unicode tidak lengkap")
+			}
+			r, err := strconv.ParseUint(s[i+1:i+1+n], 16, 32)
+			if err != nil {
+				return "", fmt.Errorf("escape unicode tidak valid: %w", err)
+			}
+			b.WriteRune(rune(r))
+			i += n
+		default:
+			return "", fmt.Errorf("escape tidak dikenal \\%c", s[i])
+		}
+	}
+	return b.String(), nil
+}
+
+// array mem-parse [v1, v2, ...], boleh multi-baris dengan koma di akhir
+func (p *tomlParser) array(path string) ([]interface{}, error) {
+	p.pos++
+	values := []interface{}{}
+	for {
+		p.skipSpaceAndComments(true)
+		if p.eof() {
+			return nil, fmt.Errorf("array tidak ditutup")
+		}
+		if p.peek() == ']' {
+			p.pos++
+			return values, nil
+		}
+		v, err := p.value(fmt.Sprintf("%s[%d]", path, len(values)))
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+		p.skipSpaceAndComments(true)
+		if !p.eof() && p.peek() == ',' {
+			p.pos++
+		} else if p.eof() || p.peek() != ']' {
+			return nil, fmt.Errorf("diharapkan ',' atau ']' dalam array")
+		}
+	}
+}
+
+// inlineTable mem-parse {k = v, ...}
+func (p *tomlParser) inlineTable(path string) (map[string]interface{}, error) {
+	p.pos++
+	table := map[string]interface{}{}
+	for {
+		p.skipSpaceAndComments(false)
+		if p.eof() {
+			return nil, fmt.Errorf("tabel inline tidak ditutup")
+		}
+		if p.peek() == '}' {
+			p.pos++
+			return table, nil
+		}
+		if err := p.keyValue(table, path); err != nil {
+			return nil, err
+		}
+		p.skipSpaceAndComments(false)
+		if !p.eof() && p.peek() == ',' {
+			p.pos++
+		} else if p.eof() || p.peek() != '}' {
+			return nil, fmt.Errorf("diharapkan ',' atau '}' dalam tabel inline")
+		}
+	}
+}
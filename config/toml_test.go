@@ -0,0 +1,180 @@
+package config
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type m = map[string]interface{}
+type a = []interface{}
+
+func TestParseTOML(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want m
+	}{
+		{
+			name: "nilai dasar",
+			src: `# komentar
+title = "gatot" # komentar di akhir baris
+count = 1_000
+hex = 0xff
+octal = 0o17
+binary = 0b101
+negative = -42
+ratio = 0.5
+exp = 1e3
+on = true
+off = false
+`,
+			want: m{"title": "gatot", "count": int64(1000), "hex": int64(255), "octal": int64(15),
+				"binary": int64(5), "negative": int64(-42), "ratio": 0.5, "exp": 1000.0, "on": true, "off": false},
+		},
+		{
+			name: "string",
+			src: `basic = "tab\tdan \"kutip\" \u00e9"
+literal = 'C:\path\*'
+"key berkutip" = 1
+'key literal' = 2
+`,
+			want: m{"basic": "tab\tdan \"kutip\" é", "literal": `C:\path\*`, "key berkutip": int64(1), "key literal": int64(2)},
+		},
+		{
+			name: "string multi-baris",
+			src: `prompt = """
+Baris satu
+Baris "dua"\n"""
+literal = '''
+tanpa \escape
+'''
+folded = """\
+    Kalimat yang \
+    dilipat."""
+quotes = """isi berakhir kutip"""""
+`,
+			want: m{"prompt": "Baris satu\nBaris \"dua\"\n", "literal": "tanpa \\escape\n",
+				"folded": "Kalimat yang dilipat.", "quotes": `isi berakhir kutip""`},
+		},
+		{
+			name: "tabel dan key bertitik",
+			src: `name = "root"
+[llm.openai]
+model = "gpt-4o"
+limits.rpm = 60
+limits.tpm = 1000
+
+[llm]
+default = "openai"
+
+[llm.openai.limits.burst]
+size = 5
+`,
+			want: m{"name": "root", "llm": m{"default": "openai", "openai": m{"model": "gpt-4o",
+				"limits": m{"rpm": int64(60), "tpm": int64(1000), "burst": m{"size": int64(5)}}}}},
+		},
+		{
+			name: "array tabel",
+			src: `[[models]]
+name = "a"
+[models.params]
+temperature = 0.2
+
+[[models]]
+name = "b"
+[[models.fallbacks]]
+name = "c"
+[[models.fallbacks]]
+name = "d"
+`,
+			want: m{"models": a{
+				m{"name": "a", "params": m{"temperature": 0.2}},
+				m{"name": "b", "fallbacks": a{m{"name": "c"}, m{"name": "d"}}},
+			}},
+		},
+		{
+			name: "array dan tabel inline",
+			src: `tags = [ "a", 'b', ]
+matrix = [[1, 2], [3]]
+nested = [
+  { name = "x", opts = { deep.value = true } },  # komentar
+  { name = "y" },
+]
+point = { x = 1, y.z = 2 }
+empty = {}
+`,
+			want: m{"tags": a{"a", "b"}, "matrix": a{a{int64(1), int64(2)}, a{int64(3)}},
+				"nested": a{m{"name": "x", "opts": m{"deep": m{"value": true}}}, m{"name": "y"}},
+				"point":  m{"x": int64(1), "y": m{"z": int64(2)}}, "empty": m{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTOML(tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseTOML =\n%#v\nwant\n%#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTOMLSpecialFloats(t *testing.T) {
+	got, err := parseTOML("a = inf\nb = -inf\nc = nan\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["a"] != math.Inf(1) || got["b"] != math.Inf(-1) || !math.IsNaN(got["c"].(float64)) {
+		t.Errorf("parseTOML = %v", got)
+	}
+}
+
+func TestParseTOMLErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		err  string // Bagian pesan error yang diharapkan, termasuk nomor baris
+	}{
+		{"key ganda", "a = 1\na = 2", "baris 2: key \"a\" didefinisikan dua kali"},
+		{"tabel ganda", "[a]\nx = 1\n\n[a]", "baris 4: tabel \"a\" didefinisikan dua kali"},
+		{"tabel setelah key bertitik", "a.b = 1\n[a]", "baris 2: tabel \"a\" sudah didefinisikan dengan key bertitik"},
+		{"sub-tabel key bertitik", "[x]\na.b.c = 1\n[x.a.b]", "baris 3: tabel \"b\" sudah didefinisikan dengan key bertitik"},
+		{"key bertitik memperluas header", "[a.b]\nc = 1\n[a]\nb.d = 2", "baris 4: tabel \"b\" tidak dapat diperluas"},
+		{"key bertitik memperluas induk header", "[a.b.c]\n[a]\nb.d = 2", "baris 3: tabel \"b\" tidak dapat diperluas"},
+		{"memperluas tabel inline", "a = {x = 1}\na.y = 2", "baris 2: tabel \"a\" tidak dapat diperluas"},
+		{"header tabel inline", "a = {x = 1}\n[a]", "baris 2: tabel inline \"a\" tidak dapat diubah"},
+		{"array tabel dari array statis", "a = []\n[[a]]", "baris 2: array statis \"a\" tidak dapat diubah"},
+		{"sub-tabel array statis", "a = [{x = 1}]\n[a.b]", "baris 2: array statis \"a\" tidak dapat diubah"},
+		{"tabel dari array tabel", "[[a]]\n[a]", "baris 2: \"a\" sudah didefinisikan sebagai array tabel"},
+		{"array tabel dari tabel", "[a]\n[[a]]", "baris 2: \"a\" sudah didefinisikan sebagai tabel"},
+		{"header dari nilai", "a = 1\n[a.b]", "baris 2: \"a\" sudah didefinisikan sebagai nilai"},
+		{"key inline ganda", "a = {x = 1, x = 2}", "baris 1: key \"x\" didefinisikan dua kali"},
+		{"tanpa sama dengan", "\n\nname \"x\"", "baris 3: diharapkan '='"},
+		{"nilai kosong", "a =\n", "baris 1: nilai tidak valid"},
+		{"sisa di baris", "a = 1 2", "baris 1: karakter tak terduga"},
+		{"header tidak ditutup", "[a\nb = 1", "baris 1: header tabel harus ditutup dengan ]"},
+		{"string tidak ditutup", "a = \"abc\nb = 1", "baris 1: string tidak ditutup"},
+		{"string multi-baris tidak ditutup", "a = 1\nb = \"\"\"abc", "baris 2: string multi-baris tidak ditutup"},
+		{"escape tidak dikenal", `a = "\q"`, "escape tidak dikenal"},
+		{"array tidak ditutup", "a = [1, 2\nb = 3", "diharapkan ',' atau ']'"},
+		{"tabel inline multi-baris", "a = {x = 1,\ny = 2}", "baris 1: karakter key tidak valid"},
+		{"nol di depan", "a = 007", "tidak boleh diawali nol"},
+		{"di luar jangkauan", "a = 99999999999999999999", "di luar jangkauan"},
+		{"tanggal", "a = 1979-05-27", "nilai tidak valid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseTOML(tt.src)
+			if err == nil {
+				t.Fatalf("parseTOML berhasil, diharapkan error %q", tt.err)
+			}
+			if !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("error = %q, diharapkan mengandung %q", err, tt.err)
+			}
+		})
+	}
+}
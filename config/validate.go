@@ -0,0 +1,114 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zakirkun/gatot-kaca/llm"
+)
+
+// FieldError adalah satu masalah validasi pada field konfigurasi
+type FieldError struct {
+	Path    string // Path field, mis. "models[1].api_key"
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// ValidationErrors berisi semua masalah yang ditemukan oleh Validate
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Error()
+	}
+	return "konfigurasi tidak valid: " + strings.Join(msgs, "; ")
+}
+
+// Unwrap mengembalikan setiap masalah sebagai error tersendiri
+func (e ValidationErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, fe := range e {
+		errs[i] = fe
+	}
+	return errs
+}
+
+// Validate memeriksa konfigurasi tanpa melakukan panggilan jaringan: nama provider, nama model
+// yang kosong atau duplikat, API key yang kosong, rentang temperature dan top_p di Options, dan
+// keberadaan model default. Semua masalah dikembalikan sekaligus sebagai ValidationErrors.
+func (c *LLMConfig) Validate() error {
+	var errs ValidationErrors
+	add := func(path, format string, args ...interface{}) {
+		errs = append(errs, FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(c.Models) == 0 {
+		add("models", "tidak ada model yang dikonfigurasi")
+	}
+	seen := make(map[string]int)
+	for i, m := range c.Models {
+		prefix := fmt.Sprintf("models[%d]", i)
+		switch m.Provider {
+		case llm.OpenAI, llm.Anthropic, llm.Gemini:
+		case "":
+			add(prefix+".provider", "provider wajib diisi")
+		default:
+			add(prefix+".provider", "provider %q tidak didukung (gunakan %s, %s, atau %s)",
+				m.Provider, llm.OpenAI, llm.Anthropic, llm.Gemini)
+		}
+
+		if m.ModelName == "" {
+			add(prefix+".model_name", "nama model wajib diisi")
+		} else if first, dup := seen[m.ModelName]; dup {
+			add(prefix+".model_name", "nama model %q sudah dipakai di models[%d]", m.ModelName, first)
+		} else {
+			seen[m.ModelName] = i
+		}
+
+		if m.APIKey == "" {
+			add(prefix+".api_key", "api key wajib diisi")
+		}
+
+		checkRange(m.Options, "temperature", 0, 2, prefix, add)
+		checkRange(m.Options, "top_p", 0, 1, prefix, add)
+	}
+
+	if c.Default != "" {
+		if _, ok := seen[c.Default]; !ok {
+			add("default", "model default %q tidak ada di models", c.Default)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// checkRange memeriksa bahwa opsi key, jika ada, berupa angka dalam rentang [min, max]
+func checkRange(options map[string]interface{}, key string, min, max float64, prefix string, add func(string, string, ...interface{})) {
+	value, ok := options[key]
+	if !ok {
+		return
+	}
+	path := prefix + ".options." + key
+	var f float64
+	switch v := value.(type) {
+	case float64:
+		f = v
+	case int:
+		f = float64(v)
+	case int64:
+		f = float64(v)
+	default:
+		add(path, "harus berupa angka, bukan %T", value)
+		return
+	}
+	if f < min || f > max {
+		add(path, "%g di luar rentang %g sampai %g", f, min, max)
+	}
+}
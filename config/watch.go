@@ -0,0 +1,113 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/zakirkun/gatot-kaca/llm"
+	"github.com/zakirkun/gatot-kaca/logging"
+)
+
+// DefaultWatchInterval adalah jeda bawaan antar pemeriksaan file oleh Watch
+const DefaultWatchInterval = 5 * time.Second
+
+// WatchOption mengonfigurasi Watch
+type WatchOption func(*watchOptions)
+
+type watchOptions struct {
+	interval time.Duration
+	load     []LoadOption
+	onReload func(*LLMConfig, error)
+}
+
+// WithWatchInterval menetapkan jeda antar pemeriksaan file
+func WithWatchInterval(d time.Duration) WatchOption {
+	return func(o *watchOptions) {
+		o.interval = d
+	}
+}
+
+// WithLoadOptions meneruskan opsi ke LoadLLMConfig setiap kali file dimuat, mis. WithSecrets
+func WithLoadOptions(opts ...LoadOption) WatchOption {
+	return func(o *watchOptions) {
+		o.load = append(o.load, opts...)
+	}
+}
+
+// OnReload memanggil fn setelah setiap pemuatan ulang, dengan konfigurasi baru jika berhasil
+// atau error jika gagal
+func OnReload(fn func(*LLMConfig, error)) WatchOption {
+	return func(o *watchOptions) {
+		o.onReload = fn
+	}
+}
+
+// Watch memuat konfigurasi di path ke client, lalu memantau file tersebut di background dan
+// mengonfigurasi ulang client secara atomik setiap kali isinya berubah: model ditambah atau
+// dihapus dan model fallback diperbarui, tanpa me-restart layanan. Konfigurasi baru yang gagal
+// dimuat atau divalidasi diabaikan dan client tetap memakai konfigurasi sebelumnya. Pemantauan
+// berhenti ketika ctx dibatalkan. Error hanya dikembalikan jika pemuatan pertama gagal.
+func Watch(ctx context.Context, path string, client *llm.Client, opts ...WatchOption) error {
+	o := watchOptions{interval: DefaultWatchInterval}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.interval <= 0 {
+		o.interval = DefaultWatchInterval
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("gagal membaca file konfigurasi: %w", err)
+	}
+	if _, err := reload(path, client, o); err != nil {
+		return err
+	}
+
+	go func() {
+		ticker := time.NewTicker(o.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			current, err := os.ReadFile(path)
+			if err != nil || bytes.Equal(current, data) {
+				continue
+			}
+			data = current
+			config, err := reload(path, client, o)
+			if err != nil {
+				logging.FromContext(ctx).Warn("config reload failed, keeping the previous config", logging.KeyComponent, "config",
+					"path", path, logging.KeyError, err)
+			} else {
+				logging.FromContext(ctx).Info("config reloaded", logging.KeyComponent, "config", "path", path,
+					"models", len(config.Models))
+			}
+			if o.onReload != nil {
+				o.onReload(config, err)
+			}
+		}
+	}()
+	return nil
+}
+
+// reload memuat, memvalidasi, dan menerapkan konfigurasi di path ke client
+func reload(path string, client *llm.Client, o watchOptions) (*LLMConfig, error) {
+	config, err := LoadLLMConfig(path, o.load...)
+	if err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if err := client.Reconfigure(config.Models, config.Default); err != nil {
+		return nil, fmt.Errorf("gagal mengonfigurasi klien LLM: %w", err)
+	}
+	return config, nil
+}
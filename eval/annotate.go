@@ -0,0 +1,68 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/zakirkun/gatot-kaca/agent"
+)
+
+// ExplainingEvaluator is an optional extension of Evaluator that also returns
+// a human-readable rationale for the score.
+type ExplainingEvaluator interface {
+	Evaluator
+	EvaluateWithRationale(ctx context.Context, input, output string) (float64, string, error)
+}
+
+// EvaluateWithRationale scores the output and lists the keywords that were missing.
+func (r *RuleBasedEvaluator) EvaluateWithRationale(ctx context.Context, input, output string) (float64, string, error) {
+	score, err := r.Evaluate(ctx, input, output)
+	if err != nil {
+		return 0, "", err
+	}
+	normalizedOutput := strings.ToLower(output)
+	var missing []string
+	for _, kw := range r.RequiredKeywords {
+		if !strings.Contains(normalizedOutput, strings.ToLower(kw)) {
+			missing = append(missing, kw)
+		}
+	}
+	if len(missing) == 0 {
+		return score, "all required keywords present", nil
+	}
+	return score, "missing keywords: " + strings.Join(missing, ", "), nil
+}
+
+// AnnotateHistory evaluates every assistant message in the agent's history against the
+// user message that preceded it, and attaches the score (and rationale, if the evaluator
+// provides one) to the assistant message under the given evaluator name.
+func AnnotateHistory(ctx context.Context, a *agent.Agent, name string, evaluator Evaluator) error {
+	history := a.History()
+	lastInput := ""
+	for i, msg := range history {
+		switch msg.Role {
+		case "User":
+			lastInput = msg.Content
+		case "Assistant":
+			ann := agent.Annotation{Evaluator: name}
+			if ee, ok := evaluator.(ExplainingEvaluator); ok {
+				score, rationale, err := ee.EvaluateWithRationale(ctx, lastInput, msg.Content)
+				if err != nil {
+					return fmt.Errorf("failed to evaluate message %d: %w", i, err)
+				}
+				ann.Score, ann.Rationale = score, rationale
+			} else {
+				score, err := evaluator.Evaluate(ctx, lastInput, msg.Content)
+				if err != nil {
+					return fmt.Errorf("failed to evaluate message %d: %w", i, err)
+				}
+				ann.Score = score
+			}
+			if err := a.Annotate(i, ann); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
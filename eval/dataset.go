@@ -0,0 +1,133 @@
+package eval
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// TestCase is a single evaluation case loaded from a dataset.
+type TestCase struct {
+	ID       string            `json:"id,omitempty"`
+	Input    string            `json:"input"`
+	Expected string            `json:"expected,omitempty"` // Optional reference answer.
+	Keywords []string          `json:"keywords,omitempty"` // Keywords that must appear in the output.
+	Labels   []string          `json:"labels,omitempty"`   // Acceptable labels; the output must match one of them.
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// Trajectory optionally describes the tool calls the run is expected to make.
+	Trajectory *TrajectorySpec `json:"trajectory,omitempty"`
+}
+
+// LoadDataset loads test cases from a .jsonl or .csv file, detected by extension.
+func LoadDataset(path string) ([]TestCase, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dataset: %w", err)
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jsonl", ".ndjson":
+		return ReadJSONL(f)
+	case ".csv":
+		return ReadCSV(f)
+	default:
+		return nil, fmt.Errorf("unsupported dataset format: %s", filepath.Ext(path))
+	}
+}
+
+// ReadJSONL reads one JSON-encoded TestCase per line. Blank lines are skipped.
+func ReadJSONL(r io.Reader) ([]TestCase, error) {
+	var cases []TestCase
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var c TestCase
+		if err := json.Unmarshal(scanner.Bytes(), &c); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		cases = append(cases, withDefaultID(c, len(cases)))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return cases, nil
+}
+
+// ReadCSV reads test cases from CSV with a header row. The columns id, input, expected,
+// keywords, and labels are recognized (keywords and labels are separated by ';');
+// every other column is stored in the case metadata.
+func ReadCSV(r io.Reader) ([]TestCase, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+	}
+
+	var cases []TestCase
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		var c TestCase
+		for i, value := range record {
+			if i >= len(header) {
+				break
+			}
+			switch header[i] {
+			case "id":
+				c.ID = value
+			case "input":
+				c.Input = value
+			case "expected":
+				c.Expected = value
+			case "keywords":
+				c.Keywords = splitList(value)
+			case "labels":
+				c.Labels = splitList(value)
+			default:
+				if c.Metadata == nil {
+					c.Metadata = make(map[string]string)
+				}
+				c.Metadata[header[i]] = value
+			}
+		}
+		cases = append(cases, withDefaultID(c, len(cases)))
+	}
+	return cases, nil
+}
+
+func splitList(value string) []string {
+	var out []string
+	for _, item := range strings.Split(value, ";") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func withDefaultID(c TestCase, index int) TestCase {
+	if c.ID == "" {
+		c.ID = strconv.Itoa(index + 1)
+	}
+	return c
+}
@@ -89,14 +89,25 @@ func (c *CompositeEvaluator) Evaluate(ctx context.Context, input, output string)
 
 // ModelGradedEvaluator uses an LLM to grade the output based on a custom prompt.
 // It sends the input and output to an LLM and expects a numerical score (0 to 1) in its response.
+// If a Rubric is set, the LLM grades each criterion separately (see EvaluateRubric).
 type ModelGradedEvaluator struct {
 	Client           *llm.Client
 	ModelName        string
-	EvaluationPrompt string // Optional: custom prompt template; if empty, a default prompt is used.
+	EvaluationPrompt string  // Optional: custom prompt template; if empty, a default prompt is used.
+	Rubric           *Rubric // Optional: structured multi-criteria rubric.
+	MaxRetries       int     // Retries on malformed rubric responses; defaults to 2.
 }
 
 // Evaluate sends a request to the LLM to grade the output and parses its numerical response.
 func (m *ModelGradedEvaluator) Evaluate(ctx context.Context, input, output string) (float64, error) {
+	if m.Rubric != nil {
+		result, err := m.EvaluateRubric(ctx, input, output)
+		if err != nil {
+			return 0, err
+		}
+		return result.Score, nil
+	}
+
 	// Use a default prompt if none is provided.
 	prompt := m.EvaluationPrompt
 	if prompt == "" {
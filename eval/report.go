@@ -0,0 +1,289 @@
+package eval
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// WriteJSON serializes a report as indented JSON.
+func WriteJSON(w io.Writer, rep *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(rep)
+}
+
+// ReadJSON reads a report previously written with WriteJSON.
+func ReadJSON(r io.Reader) (*Report, error) {
+	var rep Report
+	if err := json.NewDecoder(r).Decode(&rep); err != nil {
+		return nil, fmt.Errorf("failed to decode report: %w", err)
+	}
+	return &rep, nil
+}
+
+// LoadReport reads a JSON report from a file, typically a baseline run to compare against.
+func LoadReport(path string) (*Report, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadJSON(f)
+}
+
+// CaseDiff describes how a single case changed relative to a baseline run.
+type CaseDiff struct {
+	ID            string             `json:"id"`
+	BaselinePass  bool               `json:"baseline_passed"`
+	CurrentPass   bool               `json:"current_passed"`
+	ScoreDeltas   map[string]float64 `json:"score_deltas"`
+	DurationDelta time.Duration      `json:"duration_delta"`
+}
+
+// Regressed reports whether the case passed in the baseline but fails now.
+func (d CaseDiff) Regressed() bool { return d.BaselinePass && !d.CurrentPass }
+
+// Fixed reports whether the case failed in the baseline but passes now.
+func (d CaseDiff) Fixed() bool { return !d.BaselinePass && d.CurrentPass }
+
+// Comparison summarizes the differences between a run and a baseline run.
+type Comparison struct {
+	PassRateDelta   float64            `json:"pass_rate_delta"`
+	MeanScoreDeltas map[string]float64 `json:"mean_score_deltas"`
+	TokenDelta      int                `json:"token_delta"`
+	DurationDelta   time.Duration      `json:"duration_delta"`
+	Cases           []CaseDiff         `json:"cases"`
+	Regressions     []string           `json:"regressions"` // IDs of cases that regressed.
+	Fixes           []string           `json:"fixes"`       // IDs of cases that were fixed.
+	Added           []string           `json:"added"`       // IDs of cases missing from the baseline.
+	Removed         []string           `json:"removed"`     // IDs of baseline cases missing from the run.
+	// BaselinePromptVersions and PromptVersions list the prompt versions used by each run.
+	BaselinePromptVersions []string `json:"baseline_prompt_versions,omitempty"`
+	PromptVersions         []string `json:"prompt_versions,omitempty"`
+}
+
+// Compare computes the differences between the current report and a baseline, matching cases by ID.
+func Compare(baseline, current *Report) *Comparison {
+	cmp := &Comparison{
+		PassRateDelta:   current.PassRate - baseline.PassRate,
+		MeanScoreDeltas: map[string]float64{},
+		TokenDelta:      current.Usage.TotalTokens - baseline.Usage.TotalTokens,
+		DurationDelta:   current.Duration - baseline.Duration,
+
+		BaselinePromptVersions: baseline.PromptVersions,
+		PromptVersions:         current.PromptVersions,
+	}
+	for name, score := range current.MeanScores {
+		if base, ok := baseline.MeanScores[name]; ok {
+			cmp.MeanScoreDeltas[name] = score - base
+		}
+	}
+
+	base := make(map[string]CaseResult, len(baseline.Results))
+	for _, res := range baseline.Results {
+		base[res.Case.ID] = res
+	}
+	seen := make(map[string]bool, len(current.Results))
+	for _, res := range current.Results {
+		seen[res.Case.ID] = true
+		b, ok := base[res.Case.ID]
+		if !ok {
+			cmp.Added = append(cmp.Added, res.Case.ID)
+			continue
+		}
+		diff := CaseDiff{
+			ID:            res.Case.ID,
+			BaselinePass:  b.Passed,
+			CurrentPass:   res.Passed,
+			ScoreDeltas:   map[string]float64{},
+			DurationDelta: res.Duration - b.Duration,
+		}
+		for name, score := range res.Scores {
+			if bs, ok := b.Scores[name]; ok {
+				diff.ScoreDeltas[name] = score - bs
+			}
+		}
+		if diff.Regressed() {
+			cmp.Regressions = append(cmp.Regressions, diff.ID)
+		}
+		if diff.Fixed() {
+			cmp.Fixes = append(cmp.Fixes, diff.ID)
+		}
+		cmp.Cases = append(cmp.Cases, diff)
+	}
+	for _, res := range baseline.Results {
+		if !seen[res.Case.ID] {
+			cmp.Removed = append(cmp.Removed, res.Case.ID)
+		}
+	}
+	return cmp
+}
+
+// WriteCSV writes one row per case with its scores, latency, and token usage.
+// If baseline is not nil, the baseline pass status and per-evaluator score deltas are included.
+func WriteCSV(w io.Writer, rep *Report, baseline *Report) error {
+	names := rep.EvaluatorNames()
+	var diffs map[string]CaseDiff
+	if baseline != nil {
+		diffs = map[string]CaseDiff{}
+		for _, d := range Compare(baseline, rep).Cases {
+			diffs[d.ID] = d
+		}
+	}
+
+	header := []string{"id", "input", "output", "passed", "error", "duration_ms", "total_tokens"}
+	for _, name := range names {
+		header = append(header, "score_"+name)
+	}
+	if baseline != nil {
+		header = append(header, "baseline_passed")
+		for _, name := range names {
+			header = append(header, "delta_"+name)
+		}
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, res := range rep.Results {
+		row := []string{
+			res.Case.ID,
+			res.Case.Input,
+			res.Output,
+			strconv.FormatBool(res.Passed),
+			res.Error,
+			strconv.FormatInt(res.Duration.Milliseconds(), 10),
+			strconv.Itoa(res.Usage.TotalTokens),
+		}
+		for _, name := range names {
+			row = append(row, formatScore(res.Scores, name))
+		}
+		if baseline != nil {
+			d, ok := diffs[res.Case.ID]
+			if ok {
+				row = append(row, strconv.FormatBool(d.BaselinePass))
+			} else {
+				row = append(row, "")
+			}
+			for _, name := range names {
+				row = append(row, formatScore(d.ScoreDeltas, name))
+			}
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func formatScore(scores map[string]float64, name string) string {
+	s, ok := scores[name]
+	if !ok {
+		return ""
+	}
+	return strconv.FormatFloat(s, 'f', 4, 64)
+}
+
+// WriteHTML writes a self-contained HTML dashboard for the report.
+// If baseline is not nil, regressions and score changes against it are highlighted.
+func WriteHTML(w io.Writer, rep *Report, baseline *Report) error {
+	data := htmlData{Report: rep, Names: rep.EvaluatorNames()}
+	if baseline != nil {
+		data.Comparison = Compare(baseline, rep)
+		data.Diffs = map[string]CaseDiff{}
+		for _, d := range data.Comparison.Cases {
+			data.Diffs[d.ID] = d
+		}
+		sort.Strings(data.Comparison.Regressions)
+		sort.Strings(data.Comparison.Fixes)
+	}
+	return htmlReport.Execute(w, data)
+}
+
+type htmlData struct {
+	Report     *Report
+	Names      []string
+	Comparison *Comparison
+	Diffs      map[string]CaseDiff
+}
+
+var htmlReport = template.Must(template.New("report").Funcs(template.FuncMap{
+	"pct":   func(f float64) string { return fmt.Sprintf("%.1f%%", f*100) },
+	"score": func(f float64) string { return fmt.Sprintf("%.3f", f) },
+	"delta": func(f float64) string { return fmt.Sprintf("%+.3f", f) },
+	"ms":    func(d time.Duration) int64 { return d.Milliseconds() },
+	"lookup": func(scores map[string]float64, name string) string {
+		if s, ok := scores[name]; ok {
+			return fmt.Sprintf("%.3f", s)
+		}
+		return "–"
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Evaluation report</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Roboto, sans-serif; margin: 2rem; color: #222; }
+h1 { margin-bottom: 0.2rem; }
+.meta { color: #666; margin-bottom: 1.5rem; }
+.cards { display: flex; gap: 1rem; flex-wrap: wrap; margin-bottom: 1.5rem; }
+.card { border: 1px solid #ddd; border-radius: 6px; padding: 0.8rem 1.2rem; min-width: 8rem; }
+.card .value { font-size: 1.5rem; font-weight: 600; }
+table { border-collapse: collapse; width: 100%; font-size: 0.9rem; }
+th, td { border-bottom: 1px solid #eee; padding: 0.4rem 0.6rem; text-align: left; vertical-align: top; }
+th { background: #f6f6f6; }
+.pass { color: #1a7f37; font-weight: 600; }
+.fail { color: #cf222e; font-weight: 600; }
+.regressed { background: #ffebe9; }
+.fixed { background: #dafbe1; }
+.up { color: #1a7f37; }
+.down { color: #cf222e; }
+td.text { max-width: 28rem; white-space: pre-wrap; word-break: break-word; }
+</style>
+</head>
+<body>
+<h1>Evaluation report</h1>
+<div class="meta">Started {{.Report.StartedAt.Format "2006-01-02 15:04:05"}} · {{.Report.Duration}} · {{.Report.Usage.TotalTokens}} tokens</div>
+
+<div class="cards">
+  <div class="card"><div>Pass rate</div><div class="value">{{pct .Report.PassRate}}</div>{{with .Comparison}}<div class="{{if lt .PassRateDelta 0.0}}down{{else}}up{{end}}">{{delta .PassRateDelta}}</div>{{end}}</div>
+  <div class="card"><div>Passed</div><div class="value pass">{{.Report.Passed}}</div></div>
+  <div class="card"><div>Failed</div><div class="value fail">{{.Report.Failed}}</div></div>
+  <div class="card"><div>Errors</div><div class="value">{{.Report.Errored}}</div></div>
+  {{range $name := .Names}}<div class="card"><div>Mean {{$name}}</div><div class="value">{{score (index $.Report.MeanScores $name)}}</div>{{with $.Comparison}}{{with index .MeanScoreDeltas $name}}<div class="{{if lt . 0.0}}down{{else}}up{{end}}">{{delta .}}</div>{{end}}{{end}}</div>{{end}}
+</div>
+
+{{with .Comparison}}
+<h2>Compared to baseline</h2>
+<p>{{len .Regressions}} regressions, {{len .Fixes}} fixes, {{len .Added}} new cases, {{len .Removed}} removed cases.</p>
+{{if .Regressions}}<p class="fail">Regressed: {{range $i, $id := .Regressions}}{{if $i}}, {{end}}{{$id}}{{end}}</p>{{end}}
+{{if .Fixes}}<p class="pass">Fixed: {{range $i, $id := .Fixes}}{{if $i}}, {{end}}{{$id}}{{end}}</p>{{end}}
+{{end}}
+
+<h2>Cases</h2>
+<table>
+<tr><th>ID</th><th>Status</th><th>Input</th><th>Output</th>{{range .Names}}<th>{{.}}</th>{{end}}<th>Latency (ms)</th><th>Tokens</th></tr>
+{{range .Report.Results}}{{$d := index $.Diffs .Case.ID}}
+<tr class="{{if $d.Regressed}}regressed{{else if $d.Fixed}}fixed{{end}}">
+<td>{{.Case.ID}}</td>
+<td>{{if .Error}}<span class="fail">error</span><br>{{.Error}}{{else if .Passed}}<span class="pass">pass</span>{{else}}<span class="fail">fail</span>{{end}}</td>
+<td class="text">{{.Case.Input}}</td>
+<td class="text">{{.Output}}</td>
+{{$scores := .Scores}}{{range $name := $.Names}}<td>{{lookup $scores $name}}{{with $d.ScoreDeltas}}{{with index . $name}} <span class="{{if lt . 0.0}}down{{else}}up{{end}}">({{delta .}})</span>{{end}}{{end}}</td>{{end}}
+<td>{{ms .Duration}}</td>
+<td>{{.Usage.TotalTokens}}</td>
+</tr>{{end}}
+</table>
+</body>
+</html>
+`))
@@ -0,0 +1,171 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/zakirkun/gatot-kaca/llm"
+)
+
+// Criterion is a single dimension of a rubric.
+type Criterion struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Weight      float64 `json:"weight,omitempty"` // Defaults to 1.
+}
+
+// Rubric describes how a judge model should grade an output.
+type Rubric struct {
+	Criteria []Criterion `json:"criteria"`
+	ScaleMin float64     `json:"scale_min"` // Lowest score on the scale; defaults to 1 when both bounds are zero.
+	ScaleMax float64     `json:"scale_max"` // Highest score on the scale; defaults to 5 when both bounds are zero.
+}
+
+// scale returns the configured scale bounds, applying the 1-5 default.
+func (r *Rubric) scale() (float64, float64) {
+	if r.ScaleMin == 0 && r.ScaleMax == 0 {
+		return 1, 5
+	}
+	return r.ScaleMin, r.ScaleMax
+}
+
+// CriterionScore is the judge's grade for a single criterion.
+type CriterionScore struct {
+	Score      float64 `json:"score"`      // Raw score on the rubric scale.
+	Normalized float64 `json:"normalized"` // Score mapped to the 0-1 range.
+	Reason     string  `json:"reason,omitempty"`
+}
+
+// RubricResult holds the per-criterion scores and their weighted, normalized average.
+type RubricResult struct {
+	Scores   map[string]CriterionScore `json:"scores"`
+	Score    float64                   `json:"score"`    // Weighted average of the normalized scores.
+	Attempts int                       `json:"attempts"` // Number of judge calls needed to get a valid response.
+}
+
+// judgeResponse is the JSON structure the judge is asked to produce.
+type judgeResponse struct {
+	Scores map[string]struct {
+		Score  float64 `json:"score"`
+		Reason string  `json:"reason"`
+	} `json:"scores"`
+}
+
+// EvaluateRubric grades the output against the evaluator's Rubric. The judge is asked for a JSON
+// object with a score and reason per criterion; malformed or incomplete responses are retried up
+// to MaxRetries times with the validation error appended to the prompt.
+func (m *ModelGradedEvaluator) EvaluateRubric(ctx context.Context, input, output string) (*RubricResult, error) {
+	if m.Rubric == nil || len(m.Rubric.Criteria) == 0 {
+		return nil, errors.New("no rubric criteria provided")
+	}
+	retries := m.MaxRetries
+	if retries <= 0 {
+		retries = 2
+	}
+
+	basePrompt := m.rubricPrompt(input, output)
+	prompt := basePrompt
+	var lastErr error
+	for attempt := 1; attempt <= retries+1; attempt++ {
+		resp, err := m.Client.Generate(ctx, m.ModelName, llm.ModelRequest{
+			Prompt:      prompt,
+			Temperature: 0.0, // Use deterministic output.
+			MaxTokens:   512,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := m.parseRubricResponse(resp.Text)
+		if err == nil {
+			result.Attempts = attempt
+			return result, nil
+		}
+		lastErr = err
+		prompt = fmt.Sprintf("%s\n\nYour previous response was invalid: %v\nPrevious response:\n%s\n\nRespond again with only the corrected JSON object.",
+			basePrompt, err, resp.Text)
+	}
+	return nil, fmt.Errorf("judge returned invalid rubric response after %d attempts: %w", retries+1, lastErr)
+}
+
+// rubricPrompt builds the judge prompt for the rubric.
+func (m *ModelGradedEvaluator) rubricPrompt(input, output string) string {
+	lo, hi := m.Rubric.scale()
+	var b strings.Builder
+	if m.EvaluationPrompt != "" {
+		b.WriteString(m.EvaluationPrompt)
+		b.WriteString("\n\n")
+	}
+	fmt.Fprintf(&b, "Grade the output below against each criterion on a scale from %g (worst) to %g (best).\n\nCriteria:\n", lo, hi)
+	for _, c := range m.Rubric.Criteria {
+		fmt.Fprintf(&b, "- %s: %s\n", c.Name, c.Description)
+	}
+	fmt.Fprintf(&b, "\nInput: %s\nOutput: %s\n\n", input, output)
+	b.WriteString(`Respond with only a JSON object of the form {"scores": {"<criterion name>": {"score": <number>, "reason": "<short explanation>"}}} containing every criterion.`)
+	return b.String()
+}
+
+// parseRubricResponse extracts and validates the judge's JSON response.
+func (m *ModelGradedEvaluator) parseRubricResponse(text string) (*RubricResult, error) {
+	start := strings.Index(text, "{")
+	end := strings.LastIndex(text, "}")
+	if start < 0 || end <= start {
+		return nil, errors.New("no JSON object found in response")
+	}
+	var jr judgeResponse
+	if err := json.Unmarshal([]byte(text[start:end+1]), &jr); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	lo, hi := m.Rubric.scale()
+	result := &RubricResult{Scores: make(map[string]CriterionScore, len(m.Rubric.Criteria))}
+	var total, totalWeight float64
+	for _, c := range m.Rubric.Criteria {
+		s, ok := jr.Scores[c.Name]
+		if !ok {
+			return nil, fmt.Errorf("missing score for criterion %q", c.Name)
+		}
+		if s.Score < lo || s.Score > hi {
+			return nil, fmt.Errorf("score %g for criterion %q is outside the scale %g-%g", s.Score, c.Name, lo, hi)
+		}
+		normalized := 1.0
+		if hi > lo {
+			normalized = (s.Score - lo) / (hi - lo)
+		}
+		result.Scores[c.Name] = CriterionScore{Score: s.Score, Normalized: normalized, Reason: s.Reason}
+
+		weight := c.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		total += normalized * weight
+		totalWeight += weight
+	}
+	if totalWeight == 0 {
+		return nil, errors.New("total weight is zero")
+	}
+	result.Score = total / totalWeight
+	return result, nil
+}
+
+// EvaluateWithRationale implements ExplainingEvaluator. With a rubric, the rationale lists the
+// score and reason per criterion; without one, it is empty.
+func (m *ModelGradedEvaluator) EvaluateWithRationale(ctx context.Context, input, output string) (float64, string, error) {
+	if m.Rubric == nil {
+		score, err := m.Evaluate(ctx, input, output)
+		return score, "", err
+	}
+	result, err := m.EvaluateRubric(ctx, input, output)
+	if err != nil {
+		return 0, "", err
+	}
+	parts := make([]string, 0, len(m.Rubric.Criteria))
+	for _, c := range m.Rubric.Criteria {
+		s := result.Scores[c.Name]
+		parts = append(parts, fmt.Sprintf("%s=%g (%s)", c.Name, s.Score, s.Reason))
+	}
+	return result.Score, strings.Join(parts, "; "), nil
+}
@@ -0,0 +1,297 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zakirkun/gatot-kaca/agent"
+	"github.com/zakirkun/gatot-kaca/llm"
+)
+
+// Target produces an output for a test case input. A workflow can be used directly
+// as a target via its Run method, e.g. eval.Target(flow.Run).
+type Target func(ctx context.Context, input string) (string, error)
+
+// AgentTarget returns a Target that sends each input to a fresh agent created by factory,
+// so that concurrent cases do not share conversation history. The agent's tool calls are
+// recorded for trajectory evaluation.
+func AgentTarget(factory func() *agent.Agent) Target {
+	return func(ctx context.Context, input string) (string, error) {
+		a := factory()
+		output, err := a.Send(ctx, input)
+		RecordToolCalls(ctx, a.ToolCalls())
+		if v := a.PromptVersion(); v != "" {
+			RecordPromptVersion(ctx, v)
+		}
+		return output, err
+	}
+}
+
+// Runner executes a dataset of test cases against a target and scores the outputs.
+//
+// Besides the configured Evaluators, every case with Keywords is scored by a
+// RuleBasedEvaluator under the name "keywords", every case with Labels is
+// scored under the name "labels" (1 if the output matches one of the labels, 0 otherwise),
+// and every case with a Trajectory is scored by a TrajectoryEvaluator under the name "trajectory".
+//
+// Since LLM outputs are nondeterministic, each case can be run Repeats times. The case's
+// Scores are then the mean of its samples, and Stats reports their spread.
+type Runner struct {
+	Target      Target
+	Evaluators  map[string]Evaluator
+	Concurrency int // Number of cases run in parallel; defaults to 1.
+	Repeats     int // Number of times each case is run; defaults to 1.
+	// PassThreshold is the minimum score every evaluator must give for a case to pass; defaults to 0.5.
+	PassThreshold float64
+}
+
+// CaseResult is the outcome of a single test case.
+type CaseResult struct {
+	Case     TestCase           `json:"case"`
+	Output   string             `json:"output"`
+	Scores   map[string]float64 `json:"scores"` // Mean score per evaluator across runs.
+	Passed   bool               `json:"passed"`
+	Err      error              `json:"-"`
+	Error    string             `json:"error,omitempty"` // Err as text, kept for serialized reports.
+	Duration time.Duration      `json:"duration"`
+	Usage    llm.Usage          `json:"usage"`
+	// PromptVersion is the stored prompt version ("name@version") that produced the output, if recorded.
+	PromptVersion string `json:"prompt_version,omitempty"`
+	// Runs is the number of times the case was run. Outputs, Samples and Stats are only set
+	// when it is greater than one; Output then holds the first output.
+	Runs    int                  `json:"runs"`
+	Outputs []string             `json:"outputs,omitempty"`
+	Samples map[string][]float64 `json:"samples,omitempty"` // Individual scores per evaluator.
+	Stats   map[string]Stats     `json:"stats,omitempty"`
+}
+
+// Report aggregates the results of a run.
+type Report struct {
+	Results    []CaseResult       `json:"results"`
+	Total      int                `json:"total"`
+	Passed     int                `json:"passed"`
+	Failed     int                `json:"failed"`
+	Errored    int                `json:"errored"` // Cases where the target or an evaluator returned an error.
+	PassRate   float64            `json:"pass_rate"`
+	MeanScores map[string]float64 `json:"mean_scores"`
+	// PromptVersions lists the distinct prompt versions recorded by the cases, sorted.
+	PromptVersions []string `json:"prompt_versions,omitempty"`
+	// Stats summarizes every individual sample per evaluator; only set when cases were run repeatedly.
+	Stats     map[string]Stats `json:"stats,omitempty"`
+	Usage     llm.Usage        `json:"usage"`
+	StartedAt time.Time        `json:"started_at"`
+	Duration  time.Duration    `json:"duration"`
+}
+
+// Run executes all cases and returns the aggregated report.
+// Errors from individual cases are recorded in their CaseResult instead of aborting the run.
+func (r *Runner) Run(ctx context.Context, cases []TestCase) (*Report, error) {
+	if r.Target == nil {
+		return nil, errors.New("runner: no target provided")
+	}
+	concurrency := r.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	report := &Report{StartedAt: time.Now(), Total: len(cases)}
+	results := make([]CaseResult, len(cases))
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, c := range cases {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, c TestCase) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = r.runCase(ctx, c)
+			if results[i].Err != nil {
+				results[i].Error = results[i].Err.Error()
+			}
+		}(i, c)
+	}
+	wg.Wait()
+
+	report.Results = results
+	report.Duration = time.Since(report.StartedAt)
+	report.aggregate()
+	return report, nil
+}
+
+// runCase executes and scores a single case, repeating it if configured.
+// A case passes when the mean score of every evaluator reaches the threshold.
+func (r *Runner) runCase(ctx context.Context, c TestCase) CaseResult {
+	repeats := r.Repeats
+	if repeats <= 0 {
+		repeats = 1
+	}
+	result := CaseResult{Case: c, Scores: map[string]float64{}, Runs: repeats}
+
+	usage := llm.NewUsageRecorder()
+	caseCtx := llm.ContextWithUsageRecorder(ctx, usage)
+
+	samples := map[string][]float64{}
+	start := time.Now()
+	for i := 0; i < repeats; i++ {
+		output, scores, version, err := r.runOnce(caseCtx, c)
+		if i == 0 {
+			result.Output = output
+			result.PromptVersion = version
+		}
+		if repeats > 1 {
+			result.Outputs = append(result.Outputs, output)
+		}
+		if err != nil && result.Err == nil {
+			result.Err = err
+		}
+		for name, score := range scores {
+			samples[name] = append(samples[name], score)
+		}
+	}
+	result.Duration = time.Since(start)
+	result.Usage = usage.Usage()
+
+	threshold := r.PassThreshold
+	if threshold == 0 {
+		threshold = 0.5
+	}
+
+	passed := result.Err == nil
+	if repeats > 1 {
+		result.Samples = samples
+		result.Stats = make(map[string]Stats, len(samples))
+	}
+	for name, values := range samples {
+		stats := ComputeStats(values)
+		result.Scores[name] = stats.Mean
+		if repeats > 1 {
+			result.Stats[name] = stats
+		}
+		if stats.Mean < threshold {
+			passed = false
+		}
+	}
+	result.Passed = passed
+	return result
+}
+
+// runOnce runs the target once and scores its output with every evaluator that applies to the case.
+// It also returns the prompt version recorded by the target, if any.
+func (r *Runner) runOnce(ctx context.Context, c TestCase) (string, map[string]float64, string, error) {
+	ctx, rec := withToolCallRecorder(ctx)
+	output, err := r.Target(ctx, c.Input)
+	rec.mu.Lock()
+	version := rec.promptVersion
+	rec.mu.Unlock()
+	if err != nil {
+		return output, nil, version, err
+	}
+
+	scores := map[string]float64{}
+	var evalErr error
+	for name, evaluator := range r.caseEvaluators(c) {
+		score, err := evaluator.Evaluate(ctx, c.Input, output)
+		if err != nil {
+			evalErr = fmt.Errorf("evaluator %s: %w", name, err)
+			continue
+		}
+		scores[name] = score
+	}
+	return output, scores, version, evalErr
+}
+
+// caseEvaluators returns the configured evaluators plus the ones implied by the case.
+func (r *Runner) caseEvaluators(c TestCase) map[string]Evaluator {
+	evaluators := make(map[string]Evaluator, len(r.Evaluators)+2)
+	for name, e := range r.Evaluators {
+		evaluators[name] = e
+	}
+	if len(c.Keywords) > 0 {
+		evaluators["keywords"] = &RuleBasedEvaluator{RequiredKeywords: c.Keywords}
+	}
+	if len(c.Labels) > 0 {
+		evaluators["labels"] = labelEvaluator(c.Labels)
+	}
+	if c.Trajectory != nil {
+		evaluators["trajectory"] = &TrajectoryEvaluator{Spec: *c.Trajectory}
+	}
+	return evaluators
+}
+
+// labelEvaluator scores 1 if the trimmed output equals one of the labels (case-insensitive).
+func labelEvaluator(labels []string) Evaluator {
+	return &CustomEvaluator{Eval: func(ctx context.Context, input, output string) (float64, error) {
+		normalized := strings.ToLower(strings.TrimSpace(output))
+		for _, label := range labels {
+			if normalized == strings.ToLower(strings.TrimSpace(label)) {
+				return 1, nil
+			}
+		}
+		return 0, nil
+	}}
+}
+
+// aggregate computes pass rates and mean scores from the case results.
+func (rep *Report) aggregate() {
+	sums := map[string]float64{}
+	counts := map[string]int{}
+	samples := map[string][]float64{}
+	repeated := false
+	versions := map[string]bool{}
+	for _, res := range rep.Results {
+		if res.PromptVersion != "" && !versions[res.PromptVersion] {
+			versions[res.PromptVersion] = true
+			rep.PromptVersions = append(rep.PromptVersions, res.PromptVersion)
+		}
+		switch {
+		case res.Err != nil || res.Error != "":
+			rep.Errored++
+		case res.Passed:
+			rep.Passed++
+		default:
+			rep.Failed++
+		}
+		for name, score := range res.Scores {
+			sums[name] += score
+			counts[name]++
+		}
+		if res.Runs > 1 {
+			repeated = true
+		}
+		for name, values := range res.Samples {
+			samples[name] = append(samples[name], values...)
+		}
+		rep.Usage = rep.Usage.Add(res.Usage)
+	}
+
+	rep.MeanScores = make(map[string]float64, len(sums))
+	for name, sum := range sums {
+		rep.MeanScores[name] = sum / float64(counts[name])
+	}
+	sort.Strings(rep.PromptVersions)
+	if repeated {
+		rep.Stats = make(map[string]Stats, len(samples))
+		for name, values := range samples {
+			rep.Stats[name] = ComputeStats(values)
+		}
+	}
+	if rep.Total > 0 {
+		rep.PassRate = float64(rep.Passed) / float64(rep.Total)
+	}
+}
+
+// EvaluatorNames returns the names of all evaluators that produced scores, sorted.
+func (rep *Report) EvaluatorNames() []string {
+	names := make([]string, 0, len(rep.MeanScores))
+	for name := range rep.MeanScores {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,73 @@
+package eval_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/zakirkun/gatot-kaca/agent"
+	"github.com/zakirkun/gatot-kaca/eval"
+	"github.com/zakirkun/gatot-kaca/llmtest"
+)
+
+func TestRunner(t *testing.T) {
+	cases, err := eval.ReadJSONL(strings.NewReader(`{"input": "What is the capital of France?", "keywords": ["Paris"]}
+
+{"id": "sentiment", "input": "Classify: I love it", "labels": ["positive"]}
+{"input": "Classify: meh", "labels": ["positive"]}
+{"input": "broken"}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cases) != 4 || cases[0].ID != "1" || cases[1].ID != "sentiment" {
+		t.Fatalf("cases = %+v", cases)
+	}
+
+	model := llmtest.NewMockModel("mock").
+		On("capital of France", "The capital is Paris.").
+		On("I love it", " Positive ").
+		On("meh", "neutral").
+		OnWith("broken", llmtest.Error(errors.New("model unavailable")))
+	r := &eval.Runner{
+		Target:      eval.AgentTarget(func() *agent.Agent { return llmtest.NewAgent(model) }),
+		Concurrency: 2,
+		Repeats:     2,
+	}
+	report, err := r.Run(context.Background(), cases)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Total != 4 || report.Passed != 2 || report.Failed != 1 || report.Errored != 1 {
+		t.Errorf("report: total %d, passed %d, failed %d, errored %d", report.Total, report.Passed, report.Failed, report.Errored)
+	}
+	if res := report.Results[0]; res.Scores["keywords"] != 1 || res.Runs != 2 || len(res.Outputs) != 2 {
+		t.Errorf("keywords case = %+v", res)
+	}
+	if res := report.Results[2]; res.Passed || res.Scores["labels"] != 0 {
+		t.Errorf("wrong label case = %+v", res)
+	}
+	if res := report.Results[3]; !strings.Contains(res.Error, "model unavailable") {
+		t.Errorf("failing case error = %q", res.Error)
+	}
+	if report.PassRate != 0.5 || report.MeanScores["labels"] != 0.5 || report.Stats == nil {
+		t.Errorf("pass rate %v, mean scores %v, stats %v", report.PassRate, report.MeanScores, report.Stats)
+	}
+	model.AssertCalled(t, 8)
+}
+
+func TestReadCSV(t *testing.T) {
+	cases, err := eval.ReadCSV(strings.NewReader("id,input,keywords,topic\nq1,\"Hello, world\",hello; world,greeting\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cases) != 1 {
+		t.Fatalf("cases = %+v", cases)
+	}
+	c := cases[0]
+	if c.ID != "q1" || c.Input != "Hello, world" || strings.Join(c.Keywords, "|") != "hello|world" || c.Metadata["topic"] != "greeting" {
+		t.Errorf("case = %+v", c)
+	}
+}
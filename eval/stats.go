@@ -0,0 +1,54 @@
+package eval
+
+import "math"
+
+// Stats summarizes repeated samples of a single metric.
+type Stats struct {
+	N      int     `json:"n"`
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"stddev"`  // Sample standard deviation.
+	CILow  float64 `json:"ci_low"`  // Lower bound of the 95% confidence interval of the mean.
+	CIHigh float64 `json:"ci_high"` // Upper bound of the 95% confidence interval of the mean.
+}
+
+// tCritical95 holds the two-sided 95% critical values of Student's t distribution
+// for 1 to 30 degrees of freedom.
+var tCritical95 = []float64{
+	12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228,
+	2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086,
+	2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045, 2.042,
+}
+
+// ComputeStats returns the mean, sample standard deviation and 95% confidence interval
+// of the samples. The interval uses Student's t distribution, falling back to the normal
+// approximation above 30 degrees of freedom. With fewer than two samples the interval
+// collapses to the mean.
+func ComputeStats(samples []float64) Stats {
+	s := Stats{N: len(samples)}
+	if s.N == 0 {
+		return s
+	}
+	var sum float64
+	for _, v := range samples {
+		sum += v
+	}
+	s.Mean = sum / float64(s.N)
+	s.CILow, s.CIHigh = s.Mean, s.Mean
+	if s.N < 2 {
+		return s
+	}
+
+	var sq float64
+	for _, v := range samples {
+		sq += (v - s.Mean) * (v - s.Mean)
+	}
+	s.StdDev = math.Sqrt(sq / float64(s.N-1))
+
+	t := 1.96
+	if df := s.N - 1; df <= len(tCritical95) {
+		t = tCritical95[df-1]
+	}
+	margin := t * s.StdDev / math.Sqrt(float64(s.N))
+	s.CILow, s.CIHigh = s.Mean-margin, s.Mean+margin
+	return s
+}
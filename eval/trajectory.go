@@ -0,0 +1,245 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/zakirkun/gatot-kaca/agent"
+)
+
+// TrajectoryOrder controls how the order of expected tool calls is checked.
+type TrajectoryOrder string
+
+const (
+	// OrderStrict requires the calls to match the expected sequence position by position.
+	OrderStrict TrajectoryOrder = "strict"
+	// OrderInOrder requires the expected calls to appear in order, possibly with other calls in between.
+	OrderInOrder TrajectoryOrder = "in_order"
+	// OrderAny only requires each expected call to appear somewhere.
+	OrderAny TrajectoryOrder = "any"
+)
+
+// ToolCallSpec describes an expected tool call.
+type ToolCallSpec struct {
+	Name string `json:"name"`
+	// Input is the expected tool input; empty matches any input.
+	Input string `json:"input,omitempty"`
+	// Match selects how Input is compared: "exact" (default), "contains", or "regex".
+	Match string `json:"match,omitempty"`
+}
+
+// matches reports whether the call satisfies the spec.
+func (s ToolCallSpec) matches(call agent.ToolCall) bool {
+	if s.Name != call.Name {
+		return false
+	}
+	if s.Input == "" {
+		return true
+	}
+	input := strings.TrimSpace(call.Input)
+	switch s.Match {
+	case "contains":
+		return strings.Contains(strings.ToLower(input), strings.ToLower(s.Input))
+	case "regex":
+		re, err := regexp.Compile(s.Input)
+		return err == nil && re.MatchString(input)
+	default:
+		return input == strings.TrimSpace(s.Input)
+	}
+}
+
+func (s ToolCallSpec) String() string {
+	if s.Input == "" {
+		return s.Name
+	}
+	return fmt.Sprintf("%s(%s)", s.Name, s.Input)
+}
+
+// TrajectorySpec describes the tool calls an agent run is expected to make.
+type TrajectorySpec struct {
+	Calls []ToolCallSpec  `json:"calls"`
+	Order TrajectoryOrder `json:"order,omitempty"` // Defaults to OrderInOrder.
+	// AllowExtra permits calls that are not in the spec without lowering the score.
+	AllowExtra bool `json:"allow_extra,omitempty"`
+}
+
+// TrajectoryResult is the detailed outcome of a trajectory evaluation.
+type TrajectoryResult struct {
+	Score      float64          `json:"score"`
+	Matched    int              `json:"matched"`
+	Missing    []ToolCallSpec   `json:"missing,omitempty"`    // Expected calls that were not made.
+	Unexpected []agent.ToolCall `json:"unexpected,omitempty"` // Calls that were not expected.
+}
+
+// TrajectoryEvaluator scores an agent run by comparing the tools it called, their order,
+// and their inputs against an expected trajectory.
+//
+// When used as an Evaluator, the tool calls are read from the context; the Runner provides
+// them automatically for targets created with AgentTarget.
+type TrajectoryEvaluator struct {
+	Spec TrajectorySpec
+}
+
+// Evaluate implements Evaluator using the tool calls recorded in ctx.
+func (t *TrajectoryEvaluator) Evaluate(ctx context.Context, input, output string) (float64, error) {
+	calls, ok := ToolCallsFromContext(ctx)
+	if !ok {
+		return 0, errors.New("trajectory evaluator: no tool calls recorded in context")
+	}
+	return t.EvaluateCalls(calls).Score, nil
+}
+
+// EvaluateWithRationale implements ExplainingEvaluator.
+func (t *TrajectoryEvaluator) EvaluateWithRationale(ctx context.Context, input, output string) (float64, string, error) {
+	calls, ok := ToolCallsFromContext(ctx)
+	if !ok {
+		return 0, "", errors.New("trajectory evaluator: no tool calls recorded in context")
+	}
+	res := t.EvaluateCalls(calls)
+	return res.Score, res.rationale(), nil
+}
+
+// EvaluateAgent scores the tool calls recorded in an agent's history.
+func (t *TrajectoryEvaluator) EvaluateAgent(a *agent.Agent) TrajectoryResult {
+	return t.EvaluateCalls(a.ToolCalls())
+}
+
+// EvaluateCalls scores a sequence of tool calls against the spec.
+func (t *TrajectoryEvaluator) EvaluateCalls(calls []agent.ToolCall) TrajectoryResult {
+	expected := t.Spec.Calls
+	used := make([]bool, len(calls))
+	var res TrajectoryResult
+
+	switch t.Spec.Order {
+	case OrderStrict:
+		for i, spec := range expected {
+			if i < len(calls) && spec.matches(calls[i]) {
+				used[i] = true
+				res.Matched++
+			} else {
+				res.Missing = append(res.Missing, spec)
+			}
+		}
+	case OrderAny:
+		for _, spec := range expected {
+			found := false
+			for i, call := range calls {
+				if !used[i] && spec.matches(call) {
+					used[i], found = true, true
+					res.Matched++
+					break
+				}
+			}
+			if !found {
+				res.Missing = append(res.Missing, spec)
+			}
+		}
+	default:
+		next := 0
+		for _, spec := range expected {
+			found := false
+			for i := next; i < len(calls); i++ {
+				if spec.matches(calls[i]) {
+					used[i], found = true, true
+					next = i + 1
+					res.Matched++
+					break
+				}
+			}
+			if !found {
+				res.Missing = append(res.Missing, spec)
+			}
+		}
+	}
+
+	for i, call := range calls {
+		if !used[i] {
+			res.Unexpected = append(res.Unexpected, call)
+		}
+	}
+
+	denominator := len(expected)
+	if !t.Spec.AllowExtra {
+		denominator += len(res.Unexpected)
+	}
+	if denominator == 0 {
+		res.Score = 1
+	} else {
+		res.Score = float64(res.Matched) / float64(denominator)
+	}
+	return res
+}
+
+func (r TrajectoryResult) rationale() string {
+	if len(r.Missing) == 0 && len(r.Unexpected) == 0 {
+		return "trajectory matches"
+	}
+	var parts []string
+	if len(r.Missing) > 0 {
+		names := make([]string, len(r.Missing))
+		for i, s := range r.Missing {
+			names[i] = s.String()
+		}
+		parts = append(parts, "missing: "+strings.Join(names, ", "))
+	}
+	if len(r.Unexpected) > 0 {
+		names := make([]string, len(r.Unexpected))
+		for i, c := range r.Unexpected {
+			names[i] = fmt.Sprintf("%s(%s)", c.Name, c.Input)
+		}
+		parts = append(parts, "unexpected: "+strings.Join(names, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// toolCallRecorder collects the tool calls and prompt version of a single case run.
+type toolCallRecorder struct {
+	mu            sync.Mutex
+	calls         []agent.ToolCall
+	set           bool
+	promptVersion string
+}
+
+type toolCallRecorderKey struct{}
+
+// withToolCallRecorder returns a context in which targets can record their tool calls.
+func withToolCallRecorder(ctx context.Context) (context.Context, *toolCallRecorder) {
+	rec := &toolCallRecorder{}
+	return context.WithValue(ctx, toolCallRecorderKey{}, rec), rec
+}
+
+// RecordToolCalls stores the tool calls of a run in ctx for trajectory evaluation.
+// Custom targets should call it after running an agent; AgentTarget does so automatically.
+func RecordToolCalls(ctx context.Context, calls []agent.ToolCall) {
+	if rec, ok := ctx.Value(toolCallRecorderKey{}).(*toolCallRecorder); ok {
+		rec.mu.Lock()
+		rec.calls = append(rec.calls, calls...)
+		rec.set = true
+		rec.mu.Unlock()
+	}
+}
+
+// RecordPromptVersion stores the stored prompt version used by a run in ctx, so that reports
+// can tell which prompt produced each output. AgentTarget does so automatically.
+func RecordPromptVersion(ctx context.Context, ref string) {
+	if rec, ok := ctx.Value(toolCallRecorderKey{}).(*toolCallRecorder); ok {
+		rec.mu.Lock()
+		rec.promptVersion = ref
+		rec.mu.Unlock()
+	}
+}
+
+// ToolCallsFromContext returns the tool calls recorded in ctx, if any were recorded.
+func ToolCallsFromContext(ctx context.Context) ([]agent.ToolCall, bool) {
+	rec, ok := ctx.Value(toolCallRecorderKey{}).(*toolCallRecorder)
+	if !ok {
+		return nil, false
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return rec.calls, rec.set
+}
@@ -0,0 +1,172 @@
+// Package guardrails provides composable validators that inspect agent and workflow
+// input and output text, and block, redact or flag anything they match.
+package guardrails
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Action determines what happens when a validator reports a finding.
+type Action int
+
+const (
+	// Flag records the finding but lets the text through unchanged.
+	Flag Action = iota
+	// Redact replaces the matched text and lets the rest through.
+	Redact
+	// Block rejects the text entirely.
+	Block
+)
+
+// String returns the name of the action.
+func (a Action) String() string {
+	switch a {
+	case Flag:
+		return "flag"
+	case Redact:
+		return "redact"
+	case Block:
+		return "block"
+	default:
+		return fmt.Sprintf("Action(%d)", int(a))
+	}
+}
+
+// Finding is a single match reported by a validator. Start and End are byte offsets
+// of the matched text, used for redaction.
+type Finding struct {
+	Validator string
+	Label     string // Kind of match, e.g. "EMAIL"; used as the redaction placeholder.
+	Message   string
+	Start     int
+	End       int
+}
+
+// Validator inspects text and reports what it finds.
+type Validator interface {
+	Name() string
+	Validate(ctx context.Context, text string) []Finding
+}
+
+// Rule attaches an action to a validator.
+type Rule struct {
+	Validator Validator
+	Action    Action
+}
+
+// Violation is a finding together with the action taken for it.
+type Violation struct {
+	Finding
+	Action Action
+}
+
+// Result describes the outcome of checking a piece of text.
+type Result struct {
+	Text       string // The text after redaction.
+	Violations []Violation
+	Blocked    bool
+}
+
+// Flagged reports whether any violations were found.
+func (r Result) Flagged() bool {
+	return len(r.Violations) > 0
+}
+
+// BlockedError is returned when a rule with the Block action matches.
+type BlockedError struct {
+	Violations []Violation
+}
+
+// Error implements the error interface.
+func (e *BlockedError) Error() string {
+	msgs := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		if v.Action == Block {
+			msgs = append(msgs, v.Validator+": "+v.Message)
+		}
+	}
+	return "blocked by guardrails: " + strings.Join(msgs, "; ")
+}
+
+// IsBlocked checks whether an error was caused by a guardrail blocking the text.
+func IsBlocked(err error) bool {
+	var bErr *BlockedError
+	return errors.As(err, &bErr)
+}
+
+// Guard runs a set of rules against text.
+type Guard struct {
+	Rules []Rule
+	// OnViolation is called with the result whenever any rule matches, regardless of its action.
+	OnViolation func(ctx context.Context, result Result)
+}
+
+// New creates a Guard from the given rules.
+func New(rules ...Rule) *Guard {
+	return &Guard{Rules: rules}
+}
+
+// Check runs every rule against the text. If any Block rule matches, it returns a
+// *BlockedError; otherwise the returned result holds the text with all Redact matches replaced.
+func (g *Guard) Check(ctx context.Context, text string) (Result, error) {
+	result := Result{Text: text}
+	var redactions []Finding
+	for _, rule := range g.Rules {
+		for _, f := range rule.Validator.Validate(ctx, text) {
+			result.Violations = append(result.Violations, Violation{Finding: f, Action: rule.Action})
+			switch rule.Action {
+			case Block:
+				result.Blocked = true
+			case Redact:
+				redactions = append(redactions, f)
+			}
+		}
+	}
+
+	if !result.Blocked {
+		result.Text = redact(text, redactions)
+	}
+	if result.Flagged() && g.OnViolation != nil {
+		g.OnViolation(ctx, result)
+	}
+	if result.Blocked {
+		return result, &BlockedError{Violations: result.Violations}
+	}
+	return result, nil
+}
+
+// redact replaces the spans of the findings with their placeholders, merging overlaps.
+func redact(text string, findings []Finding) string {
+	if len(findings) == 0 {
+		return text
+	}
+	sort.Slice(findings, func(i, j int) bool { return findings[i].Start < findings[j].Start })
+
+	var b strings.Builder
+	pos := 0
+	for _, f := range findings {
+		if f.End <= pos {
+			continue
+		}
+		if f.Start < pos {
+			f.Start = pos
+		}
+		b.WriteString(text[pos:f.Start])
+		b.WriteString(placeholder(f))
+		pos = f.End
+	}
+	b.WriteString(text[pos:])
+	return b.String()
+}
+
+// placeholder returns the text that replaces a redacted finding.
+func placeholder(f Finding) string {
+	if f.Label == "" {
+		return "[REDACTED]"
+	}
+	return "[" + f.Label + "]"
+}
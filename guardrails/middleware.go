@@ -0,0 +1,140 @@
+package guardrails
+
+import (
+	"context"
+
+	"github.com/zakirkun/gatot-kaca/agent"
+	"github.com/zakirkun/gatot-kaca/logging"
+	"github.com/zakirkun/gatot-kaca/workflow"
+)
+
+// DefaultBlockMessage replaces responses that were blocked by a guard.
+const DefaultBlockMessage = "I'm sorry, but I can't help with that request."
+
+// Middleware applies guards to an agent's input and output.
+//
+// Registered with an agent, it implements agent.RequestMiddleware: when the latest user message
+// is blocked the request is halted before the model is called, and a blocked response is
+// replaced, both with BlockMessage. Through the plain agent.Middleware methods, a blocked user
+// message is instead replaced with BlockMessage before being sent, and the model's response is
+// replaced with BlockMessage as well. Redactions only apply to the prompt sent to the model;
+// the agent's stored history keeps the original text. A Middleware keeps its per-request state
+// in the agent.RequestContext, so it may be shared by agents.
+type Middleware struct {
+	Input        *Guard // Checks the latest user message; optional.
+	Output       *Guard // Checks the model's response; optional.
+	BlockMessage string // Defaults to DefaultBlockMessage.
+}
+
+// inputBlockedKey is the request context key set when a Middleware blocked the user message.
+const inputBlockedKey = "guardrails.input_blocked"
+
+// NewMiddleware creates an agent middleware from the given input and output guards.
+func NewMiddleware(input, output *Guard) *Middleware {
+	return &Middleware{Input: input, Output: output}
+}
+
+func (m *Middleware) blockMessage() string {
+	if m.BlockMessage == "" {
+		return DefaultBlockMessage
+	}
+	return m.BlockMessage
+}
+
+// checkInput applies the input guard to the latest user message in history, replacing it with
+// the redacted text or BlockMessage, and reports whether it was blocked.
+func (m *Middleware) checkInput(ctx context.Context, history []agent.ConversationMessage) bool {
+	if m.Input == nil {
+		return false
+	}
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Role != "User" {
+			continue
+		}
+		result, err := m.Input.Check(ctx, history[i].Content)
+		if err != nil {
+			logging.FromContext(ctx).Warn("input blocked", logging.KeyComponent, "guardrails", logging.KeyError, err)
+			history[i].Content = m.blockMessage()
+			return true
+		}
+		history[i].Content = result.Text
+		break
+	}
+	return false
+}
+
+// checkOutput applies the output guard to response.
+func (m *Middleware) checkOutput(ctx context.Context, response string) string {
+	if m.Output == nil {
+		return response
+	}
+	result, err := m.Output.Check(ctx, response)
+	if err != nil {
+		logging.FromContext(ctx).Warn("output blocked", logging.KeyComponent, "guardrails", logging.KeyError, err)
+		return m.blockMessage()
+	}
+	return result.Text
+}
+
+// ProcessBeforeSend implements agent.Middleware.
+func (m *Middleware) ProcessBeforeSend(ctx context.Context, history []agent.ConversationMessage) []agent.ConversationMessage {
+	if m.checkInput(ctx, history) {
+		if rc := agent.RequestFromContext(ctx); rc != nil {
+			rc.Set(inputBlockedKey, true)
+		}
+	}
+	return history
+}
+
+// ProcessAfterReceive implements agent.Middleware.
+func (m *Middleware) ProcessAfterReceive(ctx context.Context, response string) string {
+	if rc := agent.RequestFromContext(ctx); rc != nil {
+		if blocked, _ := rc.Value(inputBlockedKey); blocked == true {
+			return m.blockMessage()
+		}
+	}
+	return m.checkOutput(ctx, response)
+}
+
+// BeforeSend implements agent.RequestMiddleware, halting the request if the latest user
+// message is blocked.
+func (m *Middleware) BeforeSend(ctx context.Context, rc *agent.RequestContext) error {
+	if m.checkInput(ctx, rc.Messages) {
+		return agent.Halt(m.blockMessage())
+	}
+	return nil
+}
+
+// AfterReceive implements agent.RequestMiddleware.
+func (m *Middleware) AfterReceive(ctx context.Context, rc *agent.RequestContext) error {
+	rc.Response = m.checkOutput(ctx, rc.Response)
+	return nil
+}
+
+// Node wraps a workflow node with input and output guards. Unlike the agent middleware,
+// a blocked input or output fails the node with a *BlockedError.
+type Node struct {
+	Next   workflow.Node
+	Input  *Guard // Optional.
+	Output *Guard // Optional.
+}
+
+// Execute checks the input, runs the wrapped node and checks its output.
+func (n *Node) Execute(ctx context.Context, input string) (string, error) {
+	if n.Input != nil {
+		result, err := n.Input.Check(ctx, input)
+		if err != nil {
+			return "", err
+		}
+		input = result.Text
+	}
+	output, err := n.Next.Execute(ctx, input)
+	if err != nil || n.Output == nil {
+		return output, err
+	}
+	result, err := n.Output.Check(ctx, output)
+	if err != nil {
+		return "", err
+	}
+	return result.Text, nil
+}
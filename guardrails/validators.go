@@ -0,0 +1,244 @@
+package guardrails
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// PIIType identifies a kind of personally identifiable information.
+type PIIType string
+
+const (
+	PIIEmail      PIIType = "EMAIL"
+	PIIPhone      PIIType = "PHONE"
+	PIICreditCard PIIType = "CREDIT_CARD"
+	PIISSN        PIIType = "SSN"
+	PIIIPAddress  PIIType = "IP_ADDRESS"
+)
+
+var piiPatterns = map[PIIType]*regexp.Regexp{
+	PIIEmail:      regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+	PIIPhone:      regexp.MustCompile(`(?:\+\d{1,3}[\s.-]?)?(?:\(\d{2,4}\)|\d{2,4})[\s.-]\d{3,4}[\s.-]\d{3,4}\b`),
+	PIICreditCard: regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`),
+	PIISSN:        regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`),
+	PIIIPAddress:  regexp.MustCompile(`\b(?:(?:25[0-5]|2[0-4]\d|1?\d?\d)\.){3}(?:25[0-5]|2[0-4]\d|1?\d?\d)\b`),
+}
+
+// piiOrder fixes the order in which PII types are checked so results are deterministic.
+var piiOrder = []PIIType{PIIEmail, PIICreditCard, PIISSN, PIIIPAddress, PIIPhone}
+
+// PII detects email addresses, phone numbers, credit card numbers, US social security
+// numbers and IPv4 addresses.
+type PII struct {
+	Types []PIIType // Types to detect; all types when empty.
+}
+
+// Name implements Validator.
+func (p *PII) Name() string { return "pii" }
+
+// Validate implements Validator.
+func (p *PII) Validate(ctx context.Context, text string) []Finding {
+	types := p.Types
+	if len(types) == 0 {
+		types = piiOrder
+	}
+	var findings []Finding
+	for _, t := range types {
+		re, ok := piiPatterns[t]
+		if !ok {
+			continue
+		}
+		for _, loc := range re.FindAllStringIndex(text, -1) {
+			if t == PIICreditCard && !luhnValid(text[loc[0]:loc[1]]) {
+				continue
+			}
+			findings = append(findings, Finding{
+				Validator: p.Name(),
+				Label:     string(t),
+				Message:   fmt.Sprintf("found %s", strings.ToLower(strings.ReplaceAll(string(t), "_", " "))),
+				Start:     loc[0],
+				End:       loc[1],
+			})
+		}
+	}
+	return findings
+}
+
+// luhnValid checks the Luhn checksum of the digits in s.
+func luhnValid(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && sum%10 == 0
+}
+
+// DefaultProfanity is the word list used by Profanity when none is given.
+var DefaultProfanity = []string{
+	"asshole", "bastard", "bitch", "bullshit", "cunt", "damn", "dickhead", "fuck", "fucking", "motherfucker", "shit",
+}
+
+// Profanity detects words from a list, matched case-insensitively on word boundaries.
+type Profanity struct {
+	Words []string // Words to detect; DefaultProfanity when empty.
+
+	once sync.Once
+	re   *regexp.Regexp
+}
+
+// Name implements Validator.
+func (p *Profanity) Name() string { return "profanity" }
+
+// Validate implements Validator.
+func (p *Profanity) Validate(ctx context.Context, text string) []Finding {
+	p.once.Do(func() {
+		words := p.Words
+		if len(words) == 0 {
+			words = DefaultProfanity
+		}
+		quoted := make([]string, len(words))
+		for i, w := range words {
+			quoted[i] = regexp.QuoteMeta(w)
+		}
+		p.re = regexp.MustCompile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)\b`)
+	})
+	var findings []Finding
+	for _, loc := range p.re.FindAllStringIndex(text, -1) {
+		findings = append(findings, Finding{
+			Validator: p.Name(),
+			Label:     "PROFANITY",
+			Message:   "found profanity",
+			Start:     loc[0],
+			End:       loc[1],
+		})
+	}
+	return findings
+}
+
+// injectionPatterns are common phrasings of prompt-injection attempts.
+var injectionPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\b(?:ignore|disregard|forget)\b.{0,20}\b(?:all\s+)?(?:previous|prior|above|earlier|preceding)\b.{0,20}\b(?:instructions?|prompts?|rules?|directions?)`),
+	regexp.MustCompile(`(?i)\b(?:reveal|show|print|repeat|output)\b.{0,20}\b(?:system|initial|hidden)\s+(?:prompt|instructions?|message)`),
+	regexp.MustCompile(`(?i)\byou\s+are\s+now\s+(?:in\s+)?(?:developer|dan|jailbreak|unrestricted)\b`),
+	regexp.MustCompile(`(?i)\b(?:do\s+anything\s+now|jailbreak(?:ed)?)\b`),
+	regexp.MustCompile(`(?i)\bpretend\b.{0,30}\b(?:no|without)\s+(?:restrictions|rules|filters|guidelines)`),
+	regexp.MustCompile(`(?i)^\s*(?:system|assistant)\s*:`),
+}
+
+// PromptInjection detects common prompt-injection phrasings using heuristics.
+// It is a cheap first line of defence and will miss paraphrased attacks.
+type PromptInjection struct {
+	// Extra holds additional patterns checked alongside the built-in ones.
+	Extra []*regexp.Regexp
+}
+
+// Name implements Validator.
+func (p *PromptInjection) Name() string { return "prompt_injection" }
+
+// Validate implements Validator.
+func (p *PromptInjection) Validate(ctx context.Context, text string) []Finding {
+	var findings []Finding
+	for _, re := range append(injectionPatterns[:len(injectionPatterns):len(injectionPatterns)], p.Extra...) {
+		for _, loc := range re.FindAllStringIndex(text, -1) {
+			findings = append(findings, Finding{
+				Validator: p.Name(),
+				Label:     "PROMPT_INJECTION",
+				Message:   fmt.Sprintf("possible prompt injection: %q", text[loc[0]:loc[1]]),
+				Start:     loc[0],
+				End:       loc[1],
+			})
+		}
+	}
+	return findings
+}
+
+// Denylist detects matches of any of its regular expressions.
+type Denylist struct {
+	Patterns []*regexp.Regexp
+	Label    string // Placeholder label for redaction; defaults to "DENIED".
+}
+
+// NewDenylist compiles the patterns into a Denylist.
+func NewDenylist(patterns ...string) (*Denylist, error) {
+	d := &Denylist{}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid denylist pattern %q: %w", p, err)
+		}
+		d.Patterns = append(d.Patterns, re)
+	}
+	return d, nil
+}
+
+// Name implements Validator.
+func (d *Denylist) Name() string { return "denylist" }
+
+// Validate implements Validator.
+func (d *Denylist) Validate(ctx context.Context, text string) []Finding {
+	label := d.Label
+	if label == "" {
+		label = "DENIED"
+	}
+	var findings []Finding
+	for _, re := range d.Patterns {
+		for _, loc := range re.FindAllStringIndex(text, -1) {
+			findings = append(findings, Finding{
+				Validator: d.Name(),
+				Label:     label,
+				Message:   fmt.Sprintf("matched denied pattern %s", re),
+				Start:     loc[0],
+				End:       loc[1],
+			})
+		}
+	}
+	return findings
+}
+
+// MaxLength detects text longer than Max characters. Redacting truncates the text.
+type MaxLength struct {
+	Max int
+}
+
+// Name implements Validator.
+func (m *MaxLength) Name() string { return "max_length" }
+
+// Validate implements Validator.
+func (m *MaxLength) Validate(ctx context.Context, text string) []Finding {
+	n := utf8.RuneCountInString(text)
+	if n <= m.Max {
+		return nil
+	}
+	// Find the byte offset of the first rune past the limit.
+	offset, count := 0, 0
+	for i := range text {
+		if count == m.Max {
+			offset = i
+			break
+		}
+		count++
+	}
+	return []Finding{{
+		Validator: m.Name(),
+		Label:     "TRUNCATED",
+		Message:   fmt.Sprintf("text is %d characters long, limit is %d", n, m.Max),
+		Start:     offset,
+		End:       len(text),
+	}}
+}
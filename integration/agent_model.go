@@ -3,19 +3,24 @@ package integration
 import (
 	"context"
 	"fmt"
-	"log"
 	"regexp"
 	"strings"
 
 	"github.com/zakirkun/gatot-kaca/agent"
 	"github.com/zakirkun/gatot-kaca/llm"
+	"github.com/zakirkun/gatot-kaca/logging"
 )
 
 // AgentModel is an integrated model that wraps an inner LLM model and uses an agent for enhanced processing.
 // It checks the generated response for embedded tool commands and, when found, automatically calls the tool.
+//
+// In iterative mode, tool outputs are fed back to the inner model, which may call further tools,
+// until it answers without tool commands or MaxRounds is reached.
 type AgentModel struct {
 	Agent      *agent.Agent // An agent instance that provides tool integration.
 	InnerModel llm.Model    // The underlying LLM model (e.g., OpenAI, Anthropic, Gemini, etc.)
+	Iterative  bool         // Feed tool outputs back to the inner model instead of returning them.
+	MaxRounds  int          // Maximum number of tool rounds in iterative mode; defaults to 5.
 }
 
 // NewAgentModel wraps an existing model with agent integration.
@@ -33,47 +38,111 @@ func (am *AgentModel) Generate(ctx context.Context, req llm.ModelRequest) (llm.M
 	// Generate the initial response from the inner model.
 	resp, err := am.InnerModel.Generate(ctx, req)
 	if err != nil {
-		log.Printf("[AgentModel] Error generating response: %v", err)
+		logging.FromContext(ctx).Warn("inner model failed", logging.KeyComponent, "integration", logging.KeyError, err)
 		return resp, err
 	}
 
+	if am.Iterative {
+		return am.generateIterative(ctx, req, resp)
+	}
+
 	// Enhance the response by processing all embedded tool commands.
-	resp.Text = am.processToolCommands(ctx, resp.Text)
+	resp.Text, _ = am.processToolCommands(ctx, resp.Text)
 	return resp, nil
 }
 
-// processToolCommands scans the provided text for any tool command patterns and replaces them with their outputs.
-// It supports multiple commands in a single response.
-func (am *AgentModel) processToolCommands(ctx context.Context, text string) string {
-	// Define the regex pattern for tool commands:
-	// Expected format: "CALL TOOL: <toolName> <toolInput>"
-	re := regexp.MustCompile(`(?i)CALL TOOL:\s*(\w+)\s+(.+?)(?:\n|$)`)
-
-	// Replace all matches using a function that calls the corresponding tool.
-	enhancedText := re.ReplaceAllStringFunc(text, func(match string) string {
-		submatches := re.FindStringSubmatch(match)
-		if len(submatches) < 3 {
-			// If parsing of command fails, preserve the original text.
-			return match
+// generateIterative runs tool rounds until the inner model stops emitting tool commands.
+// Each round appends the model's response and the tool outputs to the prompt. If MaxRounds
+// is reached, the model is asked once more for a final answer without further tool calls.
+func (am *AgentModel) generateIterative(ctx context.Context, req llm.ModelRequest, resp llm.ModelResponse) (llm.ModelResponse, error) {
+	maxRounds := am.MaxRounds
+	if maxRounds <= 0 {
+		maxRounds = 5
+	}
+	usage := resp.Usage
+
+	var transcript strings.Builder
+	transcript.WriteString(strings.TrimRight(req.Prompt, "\n") + "\n")
+	for round := 1; ; round++ {
+		_, calls := am.processToolCommands(ctx, resp.Text)
+		if len(calls) == 0 {
+			resp.Usage = usage
+			return resp, nil
 		}
-		toolName := submatches[1]
-		toolInput := strings.TrimSpace(submatches[2])
 
-		log.Printf("[AgentModel] Detected tool command: '%s' with input: '%s'", toolName, toolInput)
+		transcript.WriteString("Assistant: " + resp.Text + "\n")
+		for _, call := range calls {
+			if call.Error != "" {
+				fmt.Fprintf(&transcript, "Tool Error (%s): %s\n", call.Name, call.Error)
+			} else {
+				fmt.Fprintf(&transcript, "Tool Output (%s): %s\n", call.Name, call.Output)
+			}
+		}
 
-		// Invoke the tool via the agent.
-		toolOutput, err := am.Agent.CallTool(ctx, toolName, toolInput)
+		next := req
+		if round >= maxRounds {
+			logging.FromContext(ctx).Info("reached the tool round limit", logging.KeyComponent, "integration", "max_rounds", maxRounds)
+			next.Prompt = transcript.String() + "\nThe tool limit has been reached. Do not call any more tools; give your final answer using the tool outputs above.\n"
+		} else {
+			next.Prompt = transcript.String() + "\nUse the tool outputs above to continue. Call another tool if needed, otherwise give your final answer.\n"
+		}
+
+		var err error
+		resp, err = am.InnerModel.Generate(ctx, next)
 		if err != nil {
-			log.Printf("[AgentModel] Failed to execute tool '%s': %v", toolName, err)
-			// If execution fails, return the original command text.
-			return match
+			logging.FromContext(ctx).Warn("inner model failed", logging.KeyComponent, "integration", logging.KeyError, err)
+			return resp, err
 		}
+		usage = usage.Add(resp.Usage)
 
-		// Format the replacement text to include the tool's output.
-		replacement := fmt.Sprintf("Tool Output (%s): %s", toolName, toolOutput)
-		return replacement
-	})
-	return enhancedText
+		if round >= maxRounds {
+			// Tool commands emitted despite the limit are not executed.
+			resp.Usage = usage
+			return resp, nil
+		}
+	}
+}
+
+// toolCommandPattern matches embedded tool commands.
+// Expected format: "CALL TOOL: <toolName> <toolInput>"
+var toolCommandPattern = regexp.MustCompile(`(?i)CALL TOOL:\s*(\w+)\s+(.+?)(?:\n|$)`)
+
+// processToolCommands scans the provided text for any tool command patterns and replaces them with their outputs.
+// It supports multiple commands in a single response; they are executed concurrently through the agent,
+// bounded by the agent's MaxParallelTools, and the outputs are put back in the order the commands appear.
+// The executed calls are returned as well.
+func (am *AgentModel) processToolCommands(ctx context.Context, text string) (string, []agent.ToolCall) {
+	matches := toolCommandPattern.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return text, nil
+	}
+
+	calls := make([]agent.ToolCall, len(matches))
+	for i, m := range matches {
+		calls[i] = agent.ToolCall{Name: text[m[2]:m[3]], Input: strings.TrimSpace(text[m[4]:m[5]])}
+		logging.FromContext(ctx).Debug("detected tool command", logging.KeyComponent, "integration", logging.KeyTool, calls[i].Name)
+	}
+
+	// Invoke the tools via the agent.
+	results := am.Agent.CallTools(ctx, calls)
+
+	var b strings.Builder
+	last := 0
+	for i, m := range matches {
+		b.WriteString(text[last:m[0]])
+		if results[i].Error != "" {
+			logging.FromContext(ctx).Warn("tool failed", logging.KeyComponent, "integration", logging.KeyTool, results[i].Name,
+				logging.KeyError, results[i].Error)
+			// If execution fails, keep the original command text.
+			b.WriteString(text[m[0]:m[1]])
+		} else {
+			// Format the replacement text to include the tool's output.
+			fmt.Fprintf(&b, "Tool Output (%s): %s", results[i].Name, results[i].Output)
+		}
+		last = m[1]
+	}
+	b.WriteString(text[last:])
+	return b.String(), results
 }
 
 // GetProvider returns the underlying model's provider.
@@ -0,0 +1,354 @@
+// Package slack connects gatot-kaca agents to Slack through Socket Mode, so a bot can run
+// without a public HTTP endpoint.
+//
+// Every Slack thread is a separate session with its own agent: mentioning the bot starts a
+// thread, and later messages in the thread are answered without a mention. Direct messages are
+// answered in threads as well. While the agent works, tool calls are posted to the thread as
+// status messages that are updated when the tool finishes.
+//
+// The Slack app needs Socket Mode enabled, an app-level token with the connections:write scope,
+// and a bot token with the app_mentions:read, chat:write, channels:history and im:history
+// scopes, subscribed to the app_mention, message.channels and message.im events.
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zakirkun/gatot-kaca/agent"
+	"github.com/zakirkun/gatot-kaca/logging"
+	"golang.org/x/net/websocket"
+)
+
+// DefaultAPIURL is the base URL of the Slack Web API.
+const DefaultAPIURL = "https://slack.com/api"
+
+// AgentFactory creates the agent of a new session. The Clone method of a configured template
+// agent is a suitable factory.
+type AgentFactory func() *agent.Agent
+
+// Bot answers Slack messages with agents.
+type Bot struct {
+	AppToken string // App-level token (xapp-...) used to open Socket Mode connections.
+	BotToken string // Bot token (xoxb-...) used to post messages.
+	NewAgent AgentFactory
+	// NoToolStatus disables the status messages posted for tool calls.
+	NoToolStatus bool
+	// APIURL is the base URL of the Web API; defaults to DefaultAPIURL.
+	APIURL     string
+	HTTPClient *http.Client // Defaults to http.DefaultClient.
+
+	botUserID string
+	mention   *regexp.Regexp
+
+	mu       sync.Mutex
+	sessions map[string]*session
+}
+
+// session is the conversation of one Slack thread.
+type session struct {
+	mu    sync.Mutex // Messages in a thread are answered one at a time.
+	agent *agent.Agent
+}
+
+// NewBot creates a bot that answers with agents created by factory.
+func NewBot(appToken, botToken string, factory AgentFactory) *Bot {
+	return &Bot{AppToken: appToken, BotToken: botToken, NewAgent: factory}
+}
+
+// Run connects to Slack and answers messages until ctx is done. Dropped connections are
+// reopened. It returns ctx.Err() when ctx is done, or an error if the tokens are rejected.
+func (b *Bot) Run(ctx context.Context) error {
+	var auth struct {
+		UserID string `json:"user_id"`
+	}
+	if err := b.call(ctx, b.BotToken, "auth.test", nil, &auth); err != nil {
+		return fmt.Errorf("slack: auth: %w", err)
+	}
+	b.botUserID = auth.UserID
+	b.mention = regexp.MustCompile(`<@` + regexp.QuoteMeta(auth.UserID) + `(\|[^>]*)?>`)
+
+	delay := time.Second
+	for {
+		connected, err := b.serve(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if connected {
+			delay = time.Second
+		}
+		if err != nil {
+			logging.FromContext(ctx).Warn("connection lost, reconnecting", logging.KeyComponent, "slack", "delay", delay,
+				logging.KeyError, err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		if delay < 30*time.Second {
+			delay *= 2
+		}
+	}
+}
+
+// envelope is a Socket Mode message.
+type envelope struct {
+	EnvelopeID string          `json:"envelope_id"`
+	Type       string          `json:"type"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+// event is the part of an Events API event used by the bot.
+type event struct {
+	Type        string `json:"type"`
+	Subtype     string `json:"subtype"`
+	User        string `json:"user"`
+	BotID       string `json:"bot_id"`
+	Text        string `json:"text"`
+	Channel     string `json:"channel"`
+	ChannelType string `json:"channel_type"`
+	TS          string `json:"ts"`
+	ThreadTS    string `json:"thread_ts"`
+}
+
+// serve handles one Socket Mode connection until it is closed, reporting whether it was
+// established.
+func (b *Bot) serve(ctx context.Context) (bool, error) {
+	var open struct {
+		URL string `json:"url"`
+	}
+	if err := b.call(ctx, b.AppToken, "apps.connections.open", nil, &open); err != nil {
+		return false, err
+	}
+	conn, err := websocket.Dial(open.URL, "", b.apiURL())
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	for {
+		var env envelope
+		if err := websocket.JSON.Receive(conn, &env); err != nil {
+			return true, err
+		}
+		if env.EnvelopeID != "" {
+			ack := struct {
+				EnvelopeID string `json:"envelope_id"`
+			}{env.EnvelopeID}
+			if err := websocket.JSON.Send(conn, ack); err != nil {
+				return true, err
+			}
+		}
+		switch env.Type {
+		case "disconnect":
+			return true, nil
+		case "events_api":
+			var payload struct {
+				Event event `json:"event"`
+			}
+			if err := json.Unmarshal(env.Payload, &payload); err != nil {
+				logging.FromContext(ctx).Warn("invalid event", logging.KeyComponent, "slack", logging.KeyError, err)
+				continue
+			}
+			go b.handle(ctx, payload.Event)
+		}
+	}
+}
+
+// handle answers an event if it is addressed to the bot.
+func (b *Bot) handle(ctx context.Context, ev event) {
+	if ev.BotID != "" || ev.Subtype != "" || ev.User == "" || ev.User == b.botUserID {
+		return
+	}
+	thread := ev.ThreadTS
+	if thread == "" {
+		thread = ev.TS
+	}
+	key := ev.Channel + "/" + thread
+
+	var s *session
+	switch ev.Type {
+	case "app_mention":
+		s = b.session(key, true)
+	case "message":
+		if ev.ChannelType != "im" && b.mention.MatchString(ev.Text) {
+			// Answered through the app_mention event.
+			return
+		}
+		s = b.session(key, ev.ChannelType == "im")
+	}
+	if s == nil {
+		return
+	}
+
+	text := strings.TrimSpace(b.mention.ReplaceAllString(ev.Text, ""))
+	if text == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !b.NoToolStatus {
+		ctx = agent.ContextWithToolEvents(ctx, b.toolStatus(ctx, ev.Channel, thread))
+	}
+	reply, err := s.agent.Send(ctx, text)
+	if err != nil {
+		logging.FromContext(ctx).Warn("agent failed", logging.KeyComponent, "slack", "thread", key, logging.KeyError, err)
+		reply = "Sorry, something went wrong: " + err.Error()
+	}
+	if _, err := b.post(ctx, ev.Channel, thread, reply); err != nil {
+		logging.FromContext(ctx).Warn("failed to post reply", logging.KeyComponent, "slack", "thread", key, logging.KeyError, err)
+	}
+}
+
+// session returns the session of a thread, creating it if create is set.
+func (b *Bot) session(key string, create bool) *session {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if s, ok := b.sessions[key]; ok || !create {
+		return s
+	}
+	if b.sessions == nil {
+		b.sessions = make(map[string]*session)
+	}
+	s := &session{agent: b.NewAgent()}
+	b.sessions[key] = s
+	return s
+}
+
+// EndSession forgets the conversation of a thread; the next mention in it starts a new one.
+func (b *Bot) EndSession(channel, threadTS string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.sessions, channel+"/"+threadTS)
+}
+
+// toolStatus returns a ToolEventHandler that posts a status message to the thread when a tool
+// starts and updates it when the tool ends.
+func (b *Bot) toolStatus(ctx context.Context, channel, thread string) agent.ToolEventHandler {
+	var mu sync.Mutex
+	pending := make(map[agent.ToolCall][]string) // Status message timestamps of running calls.
+	return func(e agent.ToolEvent) {
+		switch e.Type {
+		case agent.ToolCallStart:
+			ts, err := b.post(ctx, channel, thread, fmt.Sprintf(":hammer_and_wrench: Running `%s`…", e.Call.Name))
+			if err != nil {
+				logging.FromContext(ctx).Warn("failed to post tool status", logging.KeyComponent, "slack", logging.KeyError, err)
+			}
+			mu.Lock()
+			pending[e.Call] = append(pending[e.Call], ts)
+			mu.Unlock()
+		case agent.ToolCallEnd:
+			started := agent.ToolCall{Name: e.Call.Name, Input: e.Call.Input}
+			mu.Lock()
+			var ts string
+			if list := pending[started]; len(list) > 0 {
+				ts, pending[started] = list[0], list[1:]
+			}
+			mu.Unlock()
+			text := fmt.Sprintf(":white_check_mark: `%s` finished in %v", e.Call.Name, e.Duration.Round(time.Millisecond))
+			if e.Call.Error != "" {
+				text = fmt.Sprintf(":x: `%s` failed: %s", e.Call.Name, e.Call.Error)
+			}
+			var err error
+			if ts != "" {
+				err = b.call(ctx, b.BotToken, "chat.update", map[string]string{"channel": channel, "ts": ts, "text": text}, nil)
+			} else {
+				_, err = b.post(ctx, channel, thread, text)
+			}
+			if err != nil {
+				logging.FromContext(ctx).Warn("failed to update tool status", logging.KeyComponent, "slack", logging.KeyError, err)
+			}
+		}
+	}
+}
+
+// post sends a message to a thread and returns its timestamp.
+func (b *Bot) post(ctx context.Context, channel, thread, text string) (string, error) {
+	var res struct {
+		TS string `json:"ts"`
+	}
+	body := map[string]string{"channel": channel, "thread_ts": thread, "text": text}
+	if err := b.call(ctx, b.BotToken, "chat.postMessage", body, &res); err != nil {
+		return "", err
+	}
+	return res.TS, nil
+}
+
+func (b *Bot) apiURL() string {
+	if b.APIURL == "" {
+		return DefaultAPIURL
+	}
+	return strings.TrimSuffix(b.APIURL, "/")
+}
+
+// call invokes a Web API method with a JSON body and decodes the response into out.
+func (b *Bot) call(ctx context.Context, token, method string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.apiURL()+"/"+method, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	client := b.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s: unexpected status %d: %s", method, resp.StatusCode, respBody)
+	}
+
+	var status struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(respBody, &status); err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	if !status.OK {
+		return fmt.Errorf("%s: %s", method, status.Error)
+	}
+	if out != nil {
+		return json.Unmarshal(respBody, out)
+	}
+	return nil
+}
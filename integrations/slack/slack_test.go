@@ -0,0 +1,148 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zakirkun/gatot-kaca/agent"
+	"github.com/zakirkun/gatot-kaca/llmtest"
+	"golang.org/x/net/websocket"
+)
+
+type echoTool struct{}
+
+func (echoTool) Name() string        { return "echo" }
+func (echoTool) Description() string { return "Echoes its input." }
+func (echoTool) Execute(ctx context.Context, input string) (string, error) {
+	return input, nil
+}
+
+// fakeSlack serves the Web API methods and the Socket Mode connection used by Bot.
+type fakeSlack struct {
+	*httptest.Server
+	events []string // Socket Mode payloads sent after connecting.
+
+	mu     sync.Mutex
+	acks   []string
+	posts  []map[string]string
+	posted chan struct{}
+}
+
+func newFakeSlack(events ...string) *fakeSlack {
+	f := &fakeSlack{events: events, posted: make(chan struct{}, 16)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/auth.test", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":true,"user_id":"UBOT"}`))
+	})
+	mux.HandleFunc("/api/apps.connections.open", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "url": "ws" + strings.TrimPrefix(f.URL, "http") + "/ws"})
+	})
+	post := func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		body["method"] = strings.TrimPrefix(r.URL.Path, "/api/")
+		f.mu.Lock()
+		f.posts = append(f.posts, body)
+		ts := "1700000000.00000" + string(rune('0'+len(f.posts)))
+		f.mu.Unlock()
+		w.Write([]byte(`{"ok":true,"ts":"` + ts + `"}`))
+		f.posted <- struct{}{}
+	}
+	mux.HandleFunc("/api/chat.postMessage", post)
+	mux.HandleFunc("/api/chat.update", post)
+	mux.Handle("/ws", websocket.Handler(func(conn *websocket.Conn) {
+		websocket.Message.Send(conn, `{"type":"hello"}`)
+		for i, payload := range f.events {
+			env := `{"envelope_id":"env-` + string(rune('0'+i)) + `","type":"events_api","payload":` + payload + `}`
+			websocket.Message.Send(conn, env)
+			var ack struct {
+				EnvelopeID string `json:"envelope_id"`
+			}
+			if err := websocket.JSON.Receive(conn, &ack); err != nil {
+				return
+			}
+			f.mu.Lock()
+			f.acks = append(f.acks, ack.EnvelopeID)
+			f.mu.Unlock()
+		}
+		var discard string
+		websocket.Message.Receive(conn, &discard)
+	}))
+	f.Server = httptest.NewServer(mux)
+	return f
+}
+
+// waitPosts waits for n Web API posts and returns all posts so far.
+func (f *fakeSlack) waitPosts(t *testing.T, n int) []map[string]string {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case <-f.posted:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for post %d of %d", i+1, n)
+		}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]map[string]string(nil), f.posts...)
+}
+
+func TestBotThreads(t *testing.T) {
+	f := newFakeSlack(
+		`{"event":{"type":"app_mention","user":"U1","text":"<@UBOT> please echo hi","channel":"C1","ts":"100.1"}}`,
+		// The message event of the mention is answered through app_mention only.
+		`{"event":{"type":"message","user":"U1","text":"<@UBOT> please echo hi","channel":"C1","channel_type":"channel","ts":"100.1"}}`,
+		// Messages in threads the bot was never mentioned in are ignored.
+		`{"event":{"type":"message","user":"U2","text":"unrelated","channel":"C1","channel_type":"channel","ts":"200.1"}}`,
+	)
+	defer f.Close()
+
+	model := llmtest.NewMockModel("slack-test").On("echo hi", "CALL TOOL: echo hi").Default("hello")
+	bot := NewBot("xapp-test", "xoxb-test", func() *agent.Agent { return llmtest.NewAgent(model, echoTool{}) })
+	bot.APIURL = f.URL + "/api"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go bot.Run(ctx)
+
+	posts := f.waitPosts(t, 3)
+	if posts[0]["method"] != "chat.postMessage" || !strings.Contains(posts[0]["text"], "Running `echo`") || posts[0]["thread_ts"] != "100.1" {
+		t.Errorf("tool start status = %v", posts[0])
+	}
+	if posts[1]["method"] != "chat.update" || posts[1]["ts"] != "1700000000.000001" || !strings.Contains(posts[1]["text"], "`echo` finished") {
+		t.Errorf("tool end status = %v", posts[1])
+	}
+	if posts[2]["method"] != "chat.postMessage" || posts[2]["thread_ts"] != "100.1" || !strings.Contains(posts[2]["text"], "Tool Output: hi") {
+		t.Errorf("reply = %v", posts[2])
+	}
+	if prompt := model.LastPrompt(); strings.Contains(prompt, "<@UBOT>") {
+		t.Errorf("mention was sent to the model: %q", prompt)
+	}
+
+	// Replies in the thread continue the session without a mention.
+	if s := bot.session("C1/100.1", false); s == nil {
+		t.Fatal("no session for the mentioned thread")
+	}
+	if s := bot.session("C1/200.1", false); s != nil {
+		t.Error("session created for an unrelated message")
+	}
+
+	var acks int
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		f.mu.Lock()
+		acks = len(f.acks)
+		f.mu.Unlock()
+		if acks == 3 {
+			break
+		}
+	}
+	if acks != 3 {
+		t.Errorf("acknowledged %d envelopes, want 3", acks)
+	}
+}
@@ -40,7 +40,9 @@ func NewAnthropicModel(config ModelConfig) (Model, error) {
 	}, nil
 }
 
-// AnthropicRequest adalah struktur permintaan untuk API Anthropic
+// AnthropicRequest adalah struktur permintaan untuk API Text Completions Anthropic.
+//
+// Deprecated: AnthropicModel memakai API Messages; lihat AnthropicMessagesRequest.
 type AnthropicRequest struct {
 	Model         string   `json:"model"`
 	Prompt        string   `json:"prompt"`
@@ -50,29 +52,95 @@ type AnthropicRequest struct {
 	StopSequences []string `json:"stop_sequences,omitempty"`
 }
 
-// AnthropicResponse adalah struktur respons dari API Anthropic
+// AnthropicResponse adalah struktur respons dari API Text Completions Anthropic.
+//
+// Deprecated: AnthropicModel memakai API Messages; lihat AnthropicMessagesResponse.
 type AnthropicResponse struct {
 	Completion string `json:"completion"`
 	StopReason string `json:"stop_reason"`
 	Model      string `json:"model"`
 }
 
-// Generate mengimplementasikan interface Model.Generate untuk Anthropic
-func (m *AnthropicModel) Generate(ctx context.Context, req ModelRequest) (ModelResponse, error) {
-	// Format prompt untuk Anthropic (Claude mengharapkan format tertentu)
-	prompt := fmt.Sprintf("\n\nHuman: %s\n\nAssistant:", req.Prompt)
-
-	// Konversi ModelRequest ke AnthropicRequest
-	anthropicReq := AnthropicRequest{
-		Model:       m.modelName,
-		Prompt:      prompt,
-		MaxTokens:   req.MaxTokens,
-		Temperature: req.Temperature,
-		TopP:        req.TopP,
+// DefaultAnthropicMaxTokens dipakai jika ModelRequest.MaxTokens nol, karena API Messages
+// mewajibkan max_tokens
+const DefaultAnthropicMaxTokens = 1024
+
+// AnthropicCacheControl menandai akhir prefix yang di-cache
+type AnthropicCacheControl struct {
+	Type string `json:"type"` // "ephemeral"
+}
+
+// AnthropicContentBlock adalah satu blok konten pesan atau instruksi sistem
+type AnthropicContentBlock struct {
+	Type         string                 `json:"type"`
+	Text         string                 `json:"text,omitempty"`
+	CacheControl *AnthropicCacheControl `json:"cache_control,omitempty"`
+}
+
+// AnthropicMessage adalah satu pesan percakapan untuk API Messages
+type AnthropicMessage struct {
+	Role    string                  `json:"role"`
+	Content []AnthropicContentBlock `json:"content"`
+}
+
+// AnthropicMessagesRequest adalah struktur permintaan untuk API Messages Anthropic
+type AnthropicMessagesRequest struct {
+	Model         string                  `json:"model"`
+	System        []AnthropicContentBlock `json:"system,omitempty"`
+	Messages      []AnthropicMessage      `json:"messages"`
+	MaxTokens     int                     `json:"max_tokens"`
+	Temperature   float64                 `json:"temperature,omitempty"`
+	TopP          float64                 `json:"top_p,omitempty"`
+	StopSequences []string                `json:"stop_sequences,omitempty"`
+}
+
+// AnthropicMessagesResponse adalah struktur respons dari API Messages Anthropic
+type AnthropicMessagesResponse struct {
+	Content    []AnthropicContentBlock `json:"content"`
+	StopReason string                  `json:"stop_reason"`
+	Model      string                  `json:"model"`
+	Usage      struct {
+		InputTokens              int `json:"input_tokens"`
+		OutputTokens             int `json:"output_tokens"`
+		CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+		CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+	} `json:"usage"`
+}
+
+// buildRequest mengonversi ModelRequest ke AnthropicMessagesRequest. Jika CacheSystem diaktifkan,
+// blok System ditandai dengan cache_control.
+func (m *AnthropicModel) buildRequest(req ModelRequest) AnthropicMessagesRequest {
+	maxTokens := req.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = DefaultAnthropicMaxTokens
+	}
+	anthropicReq := AnthropicMessagesRequest{
+		Model:         m.modelName,
+		MaxTokens:     maxTokens,
+		Temperature:   req.Temperature,
+		TopP:          req.TopP,
+		StopSequences: req.StopSequences,
+	}
+	for _, turn := range turns(req) {
+		anthropicReq.Messages = append(anthropicReq.Messages, AnthropicMessage{
+			Role:    turn.Role,
+			Content: []AnthropicContentBlock{{Type: "text", Text: turn.Content}},
+		})
 	}
+	if req.System != "" {
+		block := AnthropicContentBlock{Type: "text", Text: req.System}
+		if req.CacheSystem {
+			block.CacheControl = &AnthropicCacheControl{Type: "ephemeral"}
+		}
+		anthropicReq.System = []AnthropicContentBlock{block}
+	}
+	return anthropicReq
+}
 
+// Generate mengimplementasikan interface Model.Generate untuk Anthropic dengan API Messages
+func (m *AnthropicModel) Generate(ctx context.Context, req ModelRequest) (ModelResponse, error) {
 	// Serialize request body
-	reqBody, err := json.Marshal(anthropicReq)
+	reqBody, err := json.Marshal(m.buildRequest(req))
 	if err != nil {
 		return ModelResponse{}, err
 	}
@@ -81,7 +149,7 @@ func (m *AnthropicModel) Generate(ctx context.Context, req ModelRequest) (ModelR
 	httpReq, err := http.NewRequestWithContext(
 		ctx,
 		"POST",
-		fmt.Sprintf("%s/complete", m.baseURL),
+		fmt.Sprintf("%s/messages", m.baseURL),
 		strings.NewReader(string(reqBody)),
 	)
 	if err != nil {
@@ -109,33 +177,38 @@ func (m *AnthropicModel) Generate(ctx context.Context, req ModelRequest) (ModelR
 
 	// Periksa status code
 	if resp.StatusCode != http.StatusOK {
-		return ModelResponse{}, fmt.Errorf("error dari Anthropic API: %s", string(respBody))
+		return ModelResponse{}, newAPIError(Anthropic, resp, respBody)
 	}
 
 	// Unmarshal respons
-	var anthropicResp AnthropicResponse
+	var anthropicResp AnthropicMessagesResponse
 	if err := json.Unmarshal(respBody, &anthropicResp); err != nil {
 		return ModelResponse{}, err
 	}
 
-	// Hitung token usage secara kasar (karena Anthropic tidak memberikan info token)
-	// Ini hanya perkiraan kasar: ~4 karakter per token
-	promptChars := len(req.Prompt)
-	completionChars := len(anthropicResp.Completion)
+	var text strings.Builder
+	for _, block := range anthropicResp.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
 
-	promptTokens := promptChars / 4
-	completionTokens := completionChars / 4
+	// input_tokens tidak termasuk token yang ditulis ke atau dibaca dari cache
+	u := anthropicResp.Usage
+	promptTokens := u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
 
-	// Konversi AnthropicResponse ke ModelResponse
+	// Konversi AnthropicMessagesResponse ke ModelResponse
 	return ModelResponse{
-		Text:       anthropicResp.Completion,
+		Text:       text.String(),
 		ModelName:  m.modelName,
 		Provider:   Anthropic,
 		FinishType: anthropicResp.StopReason,
 		Usage: Usage{
 			PromptTokens:     promptTokens,
-			CompletionTokens: completionTokens,
-			TotalTokens:      promptTokens + completionTokens,
+			CompletionTokens: u.OutputTokens,
+			TotalTokens:      promptTokens + u.OutputTokens,
+			CachedTokens:     u.CacheReadInputTokens,
+			CacheWriteTokens: u.CacheCreationInputTokens,
 		},
 	}, nil
 }
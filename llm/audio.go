@@ -0,0 +1,247 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default untuk permintaan suara jika SpeechRequest tidak mengisinya
+const (
+	DefaultSpeechVoice  = "alloy"
+	DefaultSpeechFormat = "mp3"
+)
+
+// TranscriptionRequest adalah permintaan untuk mengubah audio menjadi teks
+type TranscriptionRequest struct {
+	Audio    []byte // Isi file audio
+	Filename string // Nama file; ekstensinya menentukan format audio (mis. "input.wav")
+	Language string // Kode bahasa ISO-639-1 opsional, mis. "id" atau "en"
+	// Prompt adalah teks opsional untuk mengarahkan gaya atau ejaan transkrip
+	Prompt      string
+	Temperature float64
+}
+
+// Transcription adalah hasil transkripsi audio
+type Transcription struct {
+	Text     string
+	Language string        // Bahasa yang terdeteksi, jika dilaporkan penyedia
+	Duration time.Duration // Durasi audio, jika dilaporkan penyedia
+}
+
+// Transcriber adalah model yang dapat mengubah audio menjadi teks
+type Transcriber interface {
+	Transcribe(ctx context.Context, req TranscriptionRequest) (Transcription, error)
+}
+
+// SpeechRequest adalah permintaan untuk mengubah teks menjadi suara
+type SpeechRequest struct {
+	Text   string
+	Voice  string  // Default DefaultSpeechVoice
+	Format string  // Format audio, mis. "mp3", "wav" atau "opus"; default DefaultSpeechFormat
+	Speed  float64 // Kecepatan bicara; nol memakai default penyedia
+	// Instructions adalah arahan opsional untuk nada atau gaya bicara
+	Instructions string
+}
+
+// Speech adalah audio hasil sintesis suara
+type Speech struct {
+	Audio  []byte
+	Format string
+}
+
+// Speaker adalah model yang dapat mengubah teks menjadi suara
+type Speaker interface {
+	Speak(ctx context.Context, req SpeechRequest) (Speech, error)
+}
+
+// Transcribe mengubah audio menjadi teks dengan model tertentu
+func (c *Client) Transcribe(ctx context.Context, modelName string, req TranscriptionRequest) (Transcription, error) {
+	model, err := c.GetModel(modelName)
+	if err != nil {
+		return Transcription{}, err
+	}
+	t, ok := model.(Transcriber)
+	if !ok {
+		return Transcription{}, fmt.Errorf("model %s tidak mendukung transkripsi audio", modelName)
+	}
+	if len(req.Audio) == 0 {
+		return Transcription{}, fmt.Errorf("audio kosong")
+	}
+	return t.Transcribe(ctx, req)
+}
+
+// Speak mengubah teks menjadi suara dengan model tertentu
+func (c *Client) Speak(ctx context.Context, modelName string, req SpeechRequest) (Speech, error) {
+	model, err := c.GetModel(modelName)
+	if err != nil {
+		return Speech{}, err
+	}
+	s, ok := model.(Speaker)
+	if !ok {
+		return Speech{}, fmt.Errorf("model %s tidak mendukung sintesis suara", modelName)
+	}
+	if req.Text == "" {
+		return Speech{}, fmt.Errorf("teks kosong")
+	}
+	return s.Speak(ctx, req)
+}
+
+// openAITranscriptionResponse adalah respons API transkripsi OpenAI
+type openAITranscriptionResponse struct {
+	Text     string  `json:"text"`
+	Language string  `json:"language"`
+	Duration float64 `json:"duration"`
+}
+
+// Transcribe mengimplementasikan Transcriber dengan API transkripsi OpenAI (mis. whisper-1)
+func (m *OpenAIModel) Transcribe(ctx context.Context, req TranscriptionRequest) (Transcription, error) {
+	filename := req.Filename
+	if filename == "" {
+		filename = "audio.wav"
+	}
+
+	// Susun body multipart
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, err := w.CreateFormFile("file", filename)
+	if err != nil {
+		return Transcription{}, err
+	}
+	if _, err := part.Write(req.Audio); err != nil {
+		return Transcription{}, err
+	}
+	temperature := ""
+	if req.Temperature != 0 {
+		temperature = strconv.FormatFloat(req.Temperature, 'f', -1, 64)
+	}
+	fields := [][2]string{
+		{"model", m.modelName},
+		{"response_format", "json"},
+		{"language", req.Language},
+		{"prompt", req.Prompt},
+		{"temperature", temperature},
+	}
+	for _, f := range fields {
+		if f[1] == "" {
+			continue
+		}
+		if err := w.WriteField(f[0], f[1]); err != nil {
+			return Transcription{}, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return Transcription{}, err
+	}
+
+	// Buat HTTP request
+	httpReq, err := http.NewRequestWithContext(
+		ctx,
+		"POST",
+		fmt.Sprintf("%s/audio/transcriptions", m.baseURL),
+		&body,
+	)
+	if err != nil {
+		return Transcription{}, err
+	}
+	httpReq.Header.Set("Content-Type", w.FormDataContentType())
+	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", m.apiKey))
+
+	// Kirim request
+	client := &http.Client{}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return Transcription{}, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return Transcription{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Transcription{}, newAPIError(OpenAI, resp, respBody)
+	}
+
+	var out openAITranscriptionResponse
+	if err := json.Unmarshal(respBody, &out); err != nil {
+		return Transcription{}, err
+	}
+	return Transcription{
+		Text:     strings.TrimSpace(out.Text),
+		Language: out.Language,
+		Duration: time.Duration(out.Duration * float64(time.Second)),
+	}, nil
+}
+
+// openAISpeechRequest adalah payload permintaan API suara OpenAI
+type openAISpeechRequest struct {
+	Model          string  `json:"model"`
+	Input          string  `json:"input"`
+	Voice          string  `json:"voice"`
+	ResponseFormat string  `json:"response_format,omitempty"`
+	Speed          float64 `json:"speed,omitempty"`
+	Instructions   string  `json:"instructions,omitempty"`
+}
+
+// Speak mengimplementasikan Speaker dengan API suara OpenAI (mis. tts-1)
+func (m *OpenAIModel) Speak(ctx context.Context, req SpeechRequest) (Speech, error) {
+	voice := req.Voice
+	if voice == "" {
+		voice = DefaultSpeechVoice
+	}
+	format := req.Format
+	if format == "" {
+		format = DefaultSpeechFormat
+	}
+
+	reqBody, err := json.Marshal(openAISpeechRequest{
+		Model:          m.modelName,
+		Input:          req.Text,
+		Voice:          voice,
+		ResponseFormat: format,
+		Speed:          req.Speed,
+		Instructions:   req.Instructions,
+	})
+	if err != nil {
+		return Speech{}, err
+	}
+
+	// Buat HTTP request
+	httpReq, err := http.NewRequestWithContext(
+		ctx,
+		"POST",
+		fmt.Sprintf("%s/audio/speech", m.baseURL),
+		bytes.NewReader(reqBody),
+	)
+	if err != nil {
+		return Speech{}, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", m.apiKey))
+
+	// Kirim request
+	client := &http.Client{}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return Speech{}, err
+	}
+	defer resp.Body.Close()
+
+	// Respons berisi audio mentah
+	audio, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return Speech{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Speech{}, newAPIError(OpenAI, resp, audio)
+	}
+	return Speech{Audio: audio, Format: format}, nil
+}
@@ -0,0 +1,25 @@
+package llm
+
+import "context"
+
+// fillCandidates melengkapi kandidat untuk penyedia yang tidak mendukung beberapa kandidat
+// dalam satu panggilan (mis. Anthropic) dengan memanggil model berulang kali. Penggunaan token
+// dari semua panggilan dijumlahkan.
+func (c *Client) fillCandidates(ctx context.Context, model Model, req ModelRequest, resp ModelResponse) (ModelResponse, error) {
+	if len(resp.Candidates) == 0 {
+		resp.Candidates = []Candidate{{Text: resp.Text, FinishType: resp.FinishType}}
+	}
+	single := req
+	single.N = 0
+	for len(resp.Candidates) < req.N {
+		next, err := c.withRetry(ctx, func() (ModelResponse, error) {
+			return c.generateWithFilterRetry(ctx, model, single)
+		}, nil)
+		if err != nil {
+			return resp, err
+		}
+		resp.Candidates = append(resp.Candidates, Candidate{Text: next.Text, FinishType: next.FinishType})
+		resp.Usage = resp.Usage.Add(next.Usage)
+	}
+	return resp, nil
+}
@@ -0,0 +1,96 @@
+package llm
+
+import (
+	"strings"
+	"sync"
+)
+
+// Capabilities menjelaskan kemampuan dan harga sebuah model. Nilai nol berarti tidak diketahui
+// atau tidak didukung.
+type Capabilities struct {
+	ContextWindow int     `json:"context_window"` // Jumlah token maksimum prompt + respons
+	Tools         bool    `json:"tools"`          // Mendukung function/tool calling native
+	Vision        bool    `json:"vision"`         // Menerima input gambar
+	JSONMode      bool    `json:"json_mode"`      // Dapat dipaksa menjawab dengan JSON valid
+	Streaming     bool    `json:"streaming"`      // Mendukung respons streaming
+	InputPrice    float64 `json:"input_price"`    // Harga per juta token prompt (USD)
+	OutputPrice   float64 `json:"output_price"`   // Harga per juta token respons (USD)
+	// CachedInputPrice adalah harga per juta token prompt yang dibaca dari cache; 0 berarti InputPrice
+	CachedInputPrice float64 `json:"cached_input_price,omitempty"`
+}
+
+// Cost menghitung biaya penggunaan token berdasarkan harga model
+func (c Capabilities) Cost(u Usage) float64 {
+	cachedPrice := c.CachedInputPrice
+	if cachedPrice == 0 {
+		cachedPrice = c.InputPrice
+	}
+	input := float64(u.PromptTokens-u.CachedTokens)*c.InputPrice + float64(u.CachedTokens)*cachedPrice
+	return (input + float64(u.CompletionTokens)*c.OutputPrice) / 1e6
+}
+
+var (
+	capabilitiesMu sync.RWMutex
+	// capabilities berisi nilai bawaan per prefix nama model. Harga adalah harga daftar
+	// perkiraan dan dapat diganti dengan RegisterCapabilities.
+	capabilities = map[string]Capabilities{
+		"gpt-4o":            {ContextWindow: 128000, Tools: true, Vision: true, JSONMode: true, Streaming: true, InputPrice: 2.5, OutputPrice: 10},
+		"gpt-4o-mini":       {ContextWindow: 128000, Tools: true, Vision: true, JSONMode: true, Streaming: true, InputPrice: 0.15, OutputPrice: 0.6},
+		"gpt-4.1":           {ContextWindow: 1047576, Tools: true, Vision: true, JSONMode: true, Streaming: true, InputPrice: 2, OutputPrice: 8},
+		"gpt-4.1-mini":      {ContextWindow: 1047576, Tools: true, Vision: true, JSONMode: true, Streaming: true, InputPrice: 0.4, OutputPrice: 1.6},
+		"gpt-4-turbo":       {ContextWindow: 128000, Tools: true, Vision: true, JSONMode: true, Streaming: true, InputPrice: 10, OutputPrice: 30},
+		"gpt-4-32k":         {ContextWindow: 32768, Tools: true, Streaming: true, InputPrice: 60, OutputPrice: 120},
+		"gpt-4":             {ContextWindow: 8192, Tools: true, Streaming: true, InputPrice: 30, OutputPrice: 60},
+		"gpt-3.5-turbo":     {ContextWindow: 16385, Tools: true, JSONMode: true, Streaming: true, InputPrice: 0.5, OutputPrice: 1.5},
+		"o1":                {ContextWindow: 200000, Tools: true, Vision: true, JSONMode: true, InputPrice: 15, OutputPrice: 60},
+		"o3":                {ContextWindow: 200000, Tools: true, Vision: true, JSONMode: true, Streaming: true, InputPrice: 2, OutputPrice: 8},
+		"o4-mini":           {ContextWindow: 200000, Tools: true, Vision: true, JSONMode: true, Streaming: true, InputPrice: 1.1, OutputPrice: 4.4},
+		"claude-":           {ContextWindow: 200000, Tools: true, Vision: true, Streaming: true, InputPrice: 3, OutputPrice: 15},
+		"claude-3-haiku":    {ContextWindow: 200000, Tools: true, Vision: true, Streaming: true, InputPrice: 0.25, OutputPrice: 1.25},
+		"claude-3-5-haiku":  {ContextWindow: 200000, Tools: true, Streaming: true, InputPrice: 0.8, OutputPrice: 4},
+		"claude-3-opus":     {ContextWindow: 200000, Tools: true, Vision: true, Streaming: true, InputPrice: 15, OutputPrice: 75},
+		"claude-opus":       {ContextWindow: 200000, Tools: true, Vision: true, Streaming: true, InputPrice: 15, OutputPrice: 75},
+		"gemini-1.5-pro":    {ContextWindow: 2097152, Tools: true, Vision: true, JSONMode: true, Streaming: true, InputPrice: 1.25, OutputPrice: 5},
+		"gemini-1.5-flash":  {ContextWindow: 1048576, Tools: true, Vision: true, JSONMode: true, Streaming: true, InputPrice: 0.075, OutputPrice: 0.3},
+		"gemini-2.0-flash":  {ContextWindow: 1048576, Tools: true, Vision: true, JSONMode: true, Streaming: true, InputPrice: 0.1, OutputPrice: 0.4},
+		"gemini-2.5-pro":    {ContextWindow: 1048576, Tools: true, Vision: true, JSONMode: true, Streaming: true, InputPrice: 1.25, OutputPrice: 10},
+		"gemini-2.5-flash":  {ContextWindow: 1048576, Tools: true, Vision: true, JSONMode: true, Streaming: true, InputPrice: 0.3, OutputPrice: 2.5},
+		"gemini-pro":        {ContextWindow: 32760, Tools: true, Streaming: true, InputPrice: 0.5, OutputPrice: 1.5},
+		"text-embedding-3-": {ContextWindow: 8191},
+	}
+)
+
+// RegisterCapabilities menetapkan kemampuan semua model yang namanya diawali prefix,
+// menggantikan nilai sebelumnya untuk prefix tersebut
+func RegisterCapabilities(prefix string, caps Capabilities) {
+	capabilitiesMu.Lock()
+	defer capabilitiesMu.Unlock()
+	capabilities[prefix] = caps
+}
+
+// LookupCapabilities mengembalikan kemampuan model berdasarkan prefix terpanjang yang cocok.
+// Nilai kedua bernilai false jika model tidak dikenal.
+func LookupCapabilities(model string) (Capabilities, bool) {
+	capabilitiesMu.RLock()
+	defer capabilitiesMu.RUnlock()
+	best := -1
+	var caps Capabilities
+	for prefix, c := range capabilities {
+		if strings.HasPrefix(model, prefix) && len(prefix) > best {
+			best, caps = len(prefix), c
+		}
+	}
+	return caps, best >= 0
+}
+
+// Capabilities mengembalikan kemampuan model yang terdaftar di client dengan nama name.
+// Nama model penyedia (GetModelName) dicari lebih dulu, lalu nama yang didaftarkan.
+func (c *Client) Capabilities(name string) (Capabilities, bool) {
+	model, err := c.GetModel(name)
+	if err == nil {
+		if caps, ok := LookupCapabilities(model.GetModelName()); ok {
+			return caps, true
+		}
+	}
+	return LookupCapabilities(name)
+}
@@ -2,22 +2,40 @@ package llm
 
 import (
 	"context"
-	"errors"
+	"fmt"
 	"sync"
+	"time"
+
+	"github.com/zakirkun/gatot-kaca/budget"
+	"github.com/zakirkun/gatot-kaca/logging"
 )
 
 // Client adalah klien untuk berinteraksi dengan berbagai model LLM
 type Client struct {
-	models   map[string]Model
-	fallback Model
-	mu       sync.RWMutex
+	models    map[string]Model
+	fallback  Model
+	sanitizer PromptSanitizer // Jika diisi, permintaan yang diblokir filter konten diulang sekali
+	// skipContextCheck menonaktifkan pemeriksaan context window (lihat SetContextCheck)
+	skipContextCheck bool
+	retry            *RetryPolicy // Jika diisi, panggilan yang gagal diulang
+	hooks            []Hooks
+	usage            usageTracker           // Penggunaan per model untuk Usage
+	health           map[string]ModelHealth // Hasil HealthCheck terakhir per nama model
+	defaults         map[string]ModelDefaults
+	mu               sync.RWMutex
+	logger           logging.Logger // Jika nil, logger dari context dipakai (lihat SetLogger)
 }
 
-// NewClient membuat instance baru Client LLM
-func NewClient() *Client {
-	return &Client{
+// NewClient membuat instance baru Client LLM dan menerapkan opsi yang diberikan
+func NewClient(opts ...ClientOption) *Client {
+	c := &Client{
 		models: make(map[string]Model),
+		usage:  usageTracker{since: time.Now()},
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
+	return c
 }
 
 // AddModel menambahkan model ke client
@@ -34,30 +52,98 @@ func (c *Client) SetFallbackModel(model Model) {
 	c.fallback = model
 }
 
-// GetModel mendapatkan model berdasarkan nama
+// WrapModels mengganti setiap model yang terdaftar, termasuk model fallback, dengan hasil wrap.
+// Untuk model fallback, name berisi nama dari GetModelName. Berguna untuk menambahkan lapisan
+// seperti perekaman atau logging ke semua model sekaligus.
+func (c *Client) WrapModels(wrap func(name string, model Model) Model) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for name, model := range c.models {
+		c.models[name] = wrap(name, model)
+	}
+	if c.fallback != nil {
+		c.fallback = wrap(c.fallback.GetModelName(), c.fallback)
+	}
+}
+
+// GetModel mendapatkan model berdasarkan nama. Model yang ditandai tidak sehat oleh HealthCheck
+// diganti dengan model fallback, jika ada dan tidak ditandai tidak sehat juga.
 func (c *Client) GetModel(name string) (Model, error) {
+	model, _, err := c.getModel(name)
+	return model, err
+}
+
+// getModel seperti GetModel, dan melaporkan apakah model fallback dipakai sebagai gantinya
+func (c *Client) getModel(name string) (Model, bool, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
 	model, exists := c.models[name]
+	if exists && c.fallback != nil && c.unhealthy(name) && !c.unhealthy(c.fallback.GetModelName()) {
+		return c.fallback, true, nil
+	}
 	if !exists {
 		if c.fallback != nil {
-			return c.fallback, nil
+			return c.fallback, true, nil
 		}
-		return nil, errors.New("model tidak ditemukan dan tidak ada fallback")
+		return nil, false, fmt.Errorf("%w dan tidak ada fallback: %s", ErrModelNotFound, name)
 	}
 
-	return model, nil
+	return model, false, nil
 }
 
-// Generate menggunakan model tertentu untuk menghasilkan respons
+// Generate menggunakan model tertentu untuk menghasilkan respons. Permintaan yang tidak muat
+// di context window model ditolak dengan ContextTooLongError sebelum dikirim. Panggilan yang
+// gagal diulang sesuai RetryPolicy client, jika ada. Field req yang bernilai nol diisi dengan
+// parameter bawaan model (lihat SetModelDefaults). Jika context membawa budget.Run, panggilan
+// dihitung terhadapnya dan ditolak dengan *budget.BudgetExceeded jika budget habis.
 func (c *Client) Generate(ctx context.Context, modelName string, req ModelRequest) (ModelResponse, error) {
-	model, err := c.GetModel(modelName)
+	run := budget.FromContext(ctx)
+	if err := run.BeginLLMCall(); err != nil {
+		return ModelResponse{}, err
+	}
+	req = c.applyDefaults(modelName, req)
+	start := time.Now()
+	resp, err := c.runHooks(ctx, modelName, req, func() (ModelResponse, error) {
+		return c.generate(ctx, modelName, req)
+	})
+	c.usage.record(c.servingName(modelName), resp.Usage, err)
+	c.logCall(ctx, modelName, start, resp, err)
+	run.AddTokens(resp.Usage.TotalTokens)
+	return resp, err
+}
+
+func (c *Client) generate(ctx context.Context, modelName string, req ModelRequest) (ModelResponse, error) {
+	model, substituted, err := c.getModel(modelName)
 	if err != nil {
 		return ModelResponse{}, err
 	}
 
-	return model.Generate(ctx, req)
+	if err := c.checkContext(modelName, model, req); err != nil {
+		return ModelResponse{}, err
+	}
+
+	resp, err := c.withRetry(ctx, func() (ModelResponse, error) {
+		return c.generateWithFilterRetry(ctx, model, req)
+	}, nil)
+	if err != nil {
+		return resp, err
+	}
+	if !substituted {
+		c.markHealthy(modelName)
+	}
+	if req.N > 1 && len(resp.Candidates) < req.N {
+		if resp, err = c.fillCandidates(ctx, model, req, resp); err != nil {
+			return resp, err
+		}
+	}
+
+	// Catat penggunaan token jika context membawa UsageRecorder
+	if rec := UsageRecorderFromContext(ctx); rec != nil {
+		rec.Add(resp.Usage)
+	}
+
+	return resp, nil
 }
 
 // Embedding
@@ -80,6 +166,7 @@ func (c *Client) ConfigureFromOptions(options []ModelConfig) error {
 
 		modelName := config.ModelName
 		c.AddModel(modelName, model)
+		c.SetModelDefaults(modelName, DefaultsFromOptions(config.Options))
 
 		// Set model pertama sebagai fallback jika belum ada fallback
 		if c.fallback == nil {
@@ -90,6 +177,48 @@ func (c *Client) ConfigureFromOptions(options []ModelConfig) error {
 	return nil
 }
 
+// Reconfigure mengganti semua model client secara atomik dengan model dari options. Model
+// fallback menjadi model bernama fallback, atau model pertama jika fallback kosong. Semua model
+// dibuat lebih dulu, sehingga jika ada yang gagal, konfigurasi lama tetap dipakai. Panggilan
+// yang sedang berjalan tetap memakai model lama. Model yang dibungkus dengan WrapModels perlu
+// dibungkus ulang.
+func (c *Client) Reconfigure(options []ModelConfig, fallback string) error {
+	models := make(map[string]Model, len(options))
+	defaults := make(map[string]ModelDefaults, len(options))
+	var first Model
+	for _, config := range options {
+		model, err := ModelFactory(config)
+		if err != nil {
+			return fmt.Errorf("model %s: %w", config.ModelName, err)
+		}
+		models[config.ModelName] = model
+		defaults[config.ModelName] = DefaultsFromOptions(config.Options)
+		if first == nil {
+			first = model
+		}
+	}
+	fallbackModel := first
+	if fallback != "" {
+		model, ok := models[fallback]
+		if !ok {
+			return fmt.Errorf("%w: model fallback %s", ErrModelNotFound, fallback)
+		}
+		fallbackModel = model
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.models = models
+	c.defaults = defaults
+	c.fallback = fallbackModel
+	for name := range c.health {
+		if _, ok := models[name]; !ok {
+			delete(c.health, name)
+		}
+	}
+	return nil
+}
+
 // ListModels mengembalikan daftar nama model yang tersedia
 func (c *Client) ListModels() []string {
 	c.mu.RLock()
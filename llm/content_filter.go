@@ -0,0 +1,174 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ContentFilterError dikembalikan ketika penyedia memblokir prompt atau respons
+// karena kebijakan konten (mis. blockReason Gemini, finish_reason content_filter OpenAI)
+type ContentFilterError struct {
+	Provider ModelProvider
+	Model    string
+	Reason   string
+	// PromptBlocked bernilai true jika prompt ditolak sebelum model menghasilkan jawaban
+	PromptBlocked bool
+	// Categories adalah kategori bahaya yang memicu pemblokiran, jika dilaporkan penyedia
+	Categories []string
+	// Retried bernilai true jika permintaan sudah diulang dengan prompt yang disanitasi
+	Retried bool
+}
+
+// Error mengimplementasikan interface error
+func (e *ContentFilterError) Error() string {
+	what := "respons"
+	if e.PromptBlocked {
+		what = "prompt"
+	}
+	msg := fmt.Sprintf("%s %s (%s) diblokir oleh filter konten: %s", what, e.Provider, e.Model, e.Reason)
+	if len(e.Categories) > 0 {
+		msg += " [" + strings.Join(e.Categories, ", ") + "]"
+	}
+	if e.Retried {
+		msg += " (tetap diblokir setelah prompt disanitasi)"
+	}
+	return msg
+}
+
+// Is membuat errors.Is(err, ErrContentFiltered) bernilai true, dan errors.Is(err, ErrBlocked)
+// jika prompt yang diblokir
+func (e *ContentFilterError) Is(target error) bool {
+	return target == ErrContentFiltered || (target == ErrBlocked && e.PromptBlocked)
+}
+
+// IsContentFiltered memeriksa apakah error disebabkan oleh filter konten penyedia
+func IsContentFiltered(err error) bool {
+	return errors.Is(err, ErrContentFiltered)
+}
+
+// FilterOutcome menjelaskan apa yang terjadi terhadap filter konten selama Generate
+type FilterOutcome string
+
+const (
+	// FilterPassed berarti respons tidak diblokir
+	FilterPassed FilterOutcome = "passed"
+	// FilterRetried berarti respons pertama diblokir, lalu berhasil dengan prompt yang disanitasi
+	FilterRetried FilterOutcome = "retried"
+	// FilterBlocked berarti respons tetap diblokir
+	FilterBlocked FilterOutcome = "blocked"
+)
+
+// ContentFilterResult mencatat hasil penanganan filter konten untuk satu permintaan
+type ContentFilterResult struct {
+	Outcome         FilterOutcome `json:"outcome"`
+	Reason          string        `json:"reason,omitempty"`
+	OriginalPrompt  string        `json:"original_prompt,omitempty"`
+	SanitizedPrompt string        `json:"sanitized_prompt,omitempty"`
+}
+
+// PromptSanitizer menulis ulang prompt yang diblokir agar dapat dicoba kembali
+type PromptSanitizer func(ctx context.Context, prompt string, reason string) (string, error)
+
+// DefaultPromptSanitizer membungkus prompt dengan instruksi agar model menjawab
+// secara aman dan menghindari konten yang melanggar kebijakan
+func DefaultPromptSanitizer(ctx context.Context, prompt string, reason string) (string, error) {
+	return fmt.Sprintf(
+		"Answer the following request in a safe, respectful and policy-compliant way. "+
+			"Omit any harmful, explicit or disallowed details and focus on helpful, general information.\n\n%s",
+		prompt), nil
+}
+
+// ModelPromptSanitizer menggunakan model LLM untuk menulis ulang prompt yang diblokir
+func ModelPromptSanitizer(model Model) PromptSanitizer {
+	return func(ctx context.Context, prompt string, reason string) (string, error) {
+		resp, err := model.Generate(ctx, ModelRequest{
+			Prompt: fmt.Sprintf(
+				"The following prompt was blocked by a content filter (reason: %s). "+
+					"Rewrite it so that it keeps the legitimate intent but removes anything that could violate content policies. "+
+					"Reply with the rewritten prompt only.\n\n%s",
+				reason, prompt),
+			Temperature: 0.0,
+		})
+		if err != nil {
+			return "", err
+		}
+		return resp.Text, nil
+	}
+}
+
+// SetContentFilterRetry mengaktifkan percobaan ulang satu kali ketika respons diblokir
+// filter konten. Jika sanitizer nil, DefaultPromptSanitizer digunakan.
+func (c *Client) SetContentFilterRetry(sanitizer PromptSanitizer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if sanitizer == nil {
+		sanitizer = DefaultPromptSanitizer
+	}
+	c.sanitizer = sanitizer
+}
+
+// DisableContentFilterRetry menonaktifkan percobaan ulang saat respons diblokir
+func (c *Client) DisableContentFilterRetry() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sanitizer = nil
+}
+
+// generateWithFilterRetry menjalankan Generate dan, jika diaktifkan, mengulang sekali
+// dengan prompt yang disanitasi saat respons diblokir filter konten
+func (c *Client) generateWithFilterRetry(ctx context.Context, model Model, req ModelRequest) (ModelResponse, error) {
+	resp, err := model.Generate(ctx, req)
+	if err == nil {
+		resp.ContentFilter = &ContentFilterResult{Outcome: FilterPassed}
+		return resp, nil
+	}
+
+	var cfErr *ContentFilterError
+	if !errors.As(err, &cfErr) {
+		return resp, err
+	}
+
+	blocked := ModelResponse{
+		ContentFilter: &ContentFilterResult{
+			Outcome:        FilterBlocked,
+			Reason:         cfErr.Reason,
+			OriginalPrompt: req.Prompt,
+		},
+	}
+
+	c.mu.RLock()
+	sanitizer := c.sanitizer
+	c.mu.RUnlock()
+	if sanitizer == nil {
+		return blocked, err
+	}
+
+	sanitized, serr := sanitizer(ctx, req.Prompt, cfErr.Reason)
+	if serr != nil {
+		return ModelResponse{}, fmt.Errorf("gagal mensanitasi prompt: %w", serr)
+	}
+
+	retryReq := req
+	retryReq.Prompt = sanitized
+	resp, err = model.Generate(ctx, retryReq)
+	if err != nil {
+		var retryErr *ContentFilterError
+		if errors.As(err, &retryErr) {
+			retryErr.Retried = true
+			blocked.ContentFilter.Reason = retryErr.Reason
+			blocked.ContentFilter.SanitizedPrompt = sanitized
+			return blocked, err
+		}
+		return resp, err
+	}
+
+	resp.ContentFilter = &ContentFilterResult{
+		Outcome:         FilterRetried,
+		Reason:          cfErr.Reason,
+		OriginalPrompt:  req.Prompt,
+		SanitizedPrompt: sanitized,
+	}
+	return resp, nil
+}
@@ -0,0 +1,90 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrContextTooLong dikembalikan (dibungkus ContextTooLongError) ketika prompt ditambah
+// MaxTokens melebihi context window model, sebelum permintaan dikirim ke penyedia
+var ErrContextTooLong = errors.New("prompt melebihi context window model")
+
+// ContextTooLongError menjelaskan permintaan yang ditolak oleh pemeriksaan context window
+type ContextTooLongError struct {
+	Model        string
+	PromptTokens int // Perkiraan jumlah token prompt
+	MaxTokens    int // Token yang diminta untuk respons
+	Limit        int // Context window model
+}
+
+// Error mengimplementasikan interface error
+func (e *ContextTooLongError) Error() string {
+	return fmt.Sprintf("prompt untuk %s membutuhkan %d token (+%d untuk respons), melebihi context window %d token",
+		e.Model, e.PromptTokens, e.MaxTokens, e.Limit)
+}
+
+// Is membuat errors.Is(err, ErrContextTooLong) bernilai true
+func (e *ContextTooLongError) Is(target error) bool {
+	return target == ErrContextTooLong
+}
+
+// TokenCounter menghitung jumlah token teks untuk sebuah model
+type TokenCounter func(model, text string) int
+
+// DefaultTokenCounter dipakai oleh pemeriksaan context window. Nilai bawaannya adalah perkiraan
+// kasar (4 karakter per token); paket tokenizer menggantinya dengan penghitung yang akurat
+// ketika di-import.
+var DefaultTokenCounter TokenCounter = func(model, text string) int {
+	return (len(text) + 3) / 4
+}
+
+// RegisterContextWindow menetapkan context window (dalam token) untuk semua model yang namanya
+// diawali prefix, dengan mempertahankan kemampuan lain yang sudah terdaftar untuk prefix itu.
+// Prefix terpanjang yang cocok yang dipakai.
+func RegisterContextWindow(prefix string, tokens int) {
+	capabilitiesMu.Lock()
+	defer capabilitiesMu.Unlock()
+	caps := capabilities[prefix]
+	caps.ContextWindow = tokens
+	capabilities[prefix] = caps
+}
+
+// ContextWindow mengembalikan context window model dalam token, atau 0 jika tidak diketahui
+func ContextWindow(model string) int {
+	caps, _ := LookupCapabilities(model)
+	return caps.ContextWindow
+}
+
+// SetContextCheck mengaktifkan atau menonaktifkan pemeriksaan context window sebelum
+// Generate dan GenerateStream. Pemeriksaan aktif secara bawaan dan hanya berlaku untuk model
+// yang context window-nya diketahui.
+func (c *Client) SetContextCheck(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.skipContextCheck = !enabled
+}
+
+// checkContext mengembalikan ContextTooLongError jika permintaan tidak muat di context window.
+// Nama model penyedia dicari lebih dulu, lalu nama yang didaftarkan di client.
+func (c *Client) checkContext(name string, model Model, req ModelRequest) error {
+	c.mu.RLock()
+	skip := c.skipContextCheck
+	c.mu.RUnlock()
+	if skip {
+		return nil
+	}
+	modelName := model.GetModelName()
+	limit := ContextWindow(modelName)
+	if limit == 0 {
+		modelName = name
+		limit = ContextWindow(name)
+	}
+	if limit == 0 {
+		return nil
+	}
+	tokens := DefaultTokenCounter(modelName, promptText(req))
+	if tokens+req.MaxTokens > limit {
+		return &ContextTooLongError{Model: modelName, PromptTokens: tokens, MaxTokens: req.MaxTokens, Limit: limit}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package llm
+
+// ModelDefaults adalah parameter generasi bawaan sebuah model, yang dipakai Client ketika
+// ModelRequest membiarkannya bernilai nol
+type ModelDefaults struct {
+	Temperature   float64
+	MaxTokens     int
+	TopP          float64
+	StopSequences []string
+}
+
+// DefaultsFromOptions membaca parameter bawaan dari ModelConfig.Options dengan key
+// "temperature", "max_tokens", "top_p", dan "stop" (atau "stop_sequences"). Key lain dan nilai
+// dengan tipe yang salah diabaikan.
+func DefaultsFromOptions(options map[string]interface{}) ModelDefaults {
+	var d ModelDefaults
+	if v, ok := optionNumber(options["temperature"]); ok {
+		d.Temperature = v
+	}
+	if v, ok := optionNumber(options["max_tokens"]); ok {
+		d.MaxTokens = int(v)
+	}
+	if v, ok := optionNumber(options["top_p"]); ok {
+		d.TopP = v
+	}
+	stop, ok := options["stop"]
+	if !ok {
+		stop = options["stop_sequences"]
+	}
+	switch v := stop.(type) {
+	case string:
+		d.StopSequences = []string{v}
+	case []string:
+		d.StopSequences = v
+	case []interface{}:
+		for _, s := range v {
+			if s, ok := s.(string); ok {
+				d.StopSequences = append(d.StopSequences, s)
+			}
+		}
+	}
+	return d
+}
+
+func optionNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+// apply mengisi field req yang bernilai nol dengan nilai bawaan
+func (d ModelDefaults) apply(req ModelRequest) ModelRequest {
+	if req.Temperature == 0 {
+		req.Temperature = d.Temperature
+	}
+	if req.MaxTokens == 0 {
+		req.MaxTokens = d.MaxTokens
+	}
+	if req.TopP == 0 {
+		req.TopP = d.TopP
+	}
+	if len(req.StopSequences) == 0 {
+		req.StopSequences = d.StopSequences
+	}
+	return req
+}
+
+// SetModelDefaults menetapkan parameter bawaan model bernama name. ConfigureFromOptions dan
+// Reconfigure menetapkannya dari ModelConfig.Options.
+func (c *Client) SetModelDefaults(name string, d ModelDefaults) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.defaults == nil {
+		c.defaults = make(map[string]ModelDefaults)
+	}
+	c.defaults[name] = d
+}
+
+// applyDefaults menerapkan parameter bawaan model yang akan melayani modelName ke req
+func (c *Client) applyDefaults(modelName string, req ModelRequest) ModelRequest {
+	name := c.servingName(modelName)
+	c.mu.RLock()
+	d, ok := c.defaults[name]
+	c.mu.RUnlock()
+	if !ok {
+		return req
+	}
+	return d.apply(req)
+}
@@ -0,0 +1,245 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// ModelLister diimplementasikan oleh model yang dapat menampilkan daftar model yang tersedia
+// di penyedianya. Model bawaan OpenAI, Anthropic, dan Gemini mengimplementasikannya.
+type ModelLister interface {
+	ListAvailableModels(ctx context.Context) ([]string, error)
+}
+
+// DiscoverModels memanggil API daftar model milik provider dengan kredensial model terdaftar
+// pertama dari penyedia tersebut, dan mengembalikan nama model yang tersedia secara berurutan
+func (c *Client) DiscoverModels(ctx context.Context, provider ModelProvider) ([]string, error) {
+	lister := c.lister(provider)
+	if lister == nil {
+		return nil, fmt.Errorf("tidak ada model %s terdaftar yang dapat menampilkan daftar model", provider)
+	}
+	names, err := lister.ListAvailableModels(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("gagal menampilkan daftar model %s: %w", provider, err)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// lister mengembalikan ModelLister untuk provider, atau nil jika tidak ada
+func (c *Client) lister(provider ModelProvider) ModelLister {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	names := make([]string, 0, len(c.models))
+	for name := range c.models {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if l, ok := c.models[name].(ModelLister); ok && c.models[name].GetProvider() == provider {
+			return l
+		}
+	}
+	if l, ok := c.fallback.(ModelLister); ok && c.fallback.GetProvider() == provider {
+		return l
+	}
+	return nil
+}
+
+// ValidateModels memastikan setiap model terdaftar tersedia di penyedianya, sehingga nama model
+// yang salah atau kredensial yang tidak valid terdeteksi saat startup, bukan pada Generate
+// pertama. Daftar model setiap penyedia diambil sekali; model yang tidak mengimplementasikan
+// ModelLister dilewati. Semua masalah digabungkan dalam satu error; nama model yang tidak ada
+// cocok dengan ErrModelNotFound.
+func (c *Client) ValidateModels(ctx context.Context) error {
+	c.mu.RLock()
+	models := make(map[string]Model, len(c.models))
+	for name, model := range c.models {
+		models[name] = model
+	}
+	c.mu.RUnlock()
+
+	names := make([]string, 0, len(models))
+	for name := range models {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	available := make(map[ModelProvider][]string)
+	failed := make(map[ModelProvider]bool)
+	var errs []error
+	for _, name := range names {
+		model := models[name]
+		if _, ok := model.(ModelLister); !ok {
+			continue
+		}
+		provider := model.GetProvider()
+		if failed[provider] {
+			continue
+		}
+		list, ok := available[provider]
+		if !ok {
+			var err error
+			if list, err = c.DiscoverModels(ctx, provider); err != nil {
+				failed[provider] = true
+				errs = append(errs, err)
+				continue
+			}
+			available[provider] = list
+		}
+		if !modelAvailable(list, model.GetModelName()) {
+			errs = append(errs, fmt.Errorf("%w: %q (terdaftar sebagai %q) tidak tersedia di %s",
+				ErrModelNotFound, model.GetModelName(), name, provider))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// modelAvailable melaporkan apakah name ada di daftar model. Alias "-latest" cocok dengan versi
+// bertanggal yang namanya diawali nama tanpa akhiran tersebut.
+func modelAvailable(list []string, name string) bool {
+	base := strings.TrimSuffix(name, "-latest")
+	for _, id := range list {
+		if id == name || (base != name && strings.HasPrefix(id, base)) {
+			return true
+		}
+	}
+	return false
+}
+
+// pingByListing memeriksa kredensial dan ketersediaan model melalui daftar model penyedia
+func pingByListing(ctx context.Context, l ModelLister, name string) error {
+	list, err := l.ListAvailableModels(ctx)
+	if err != nil {
+		return err
+	}
+	if !modelAvailable(list, name) {
+		return fmt.Errorf("%w: %s", ErrModelNotFound, name)
+	}
+	return nil
+}
+
+// getJSON mengirim permintaan GET dan mendekode respons JSON ke out
+func getJSON(ctx context.Context, provider ModelProvider, endpoint string, header http.Header, out interface{}) error {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	for key, values := range header {
+		httpReq.Header[key] = values
+	}
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return newAPIError(provider, resp, body)
+	}
+	return json.Unmarshal(body, out)
+}
+
+// ListAvailableModels mengimplementasikan ModelLister untuk OpenAI
+func (m *OpenAIModel) ListAvailableModels(ctx context.Context) ([]string, error) {
+	var resp struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	header := http.Header{"Authorization": {"Bearer " + m.apiKey}}
+	if err := getJSON(ctx, OpenAI, m.baseURL+"/models", header, &resp); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(resp.Data))
+	for _, d := range resp.Data {
+		names = append(names, d.ID)
+	}
+	return names, nil
+}
+
+// Ping mengimplementasikan Pinger untuk OpenAI
+func (m *OpenAIModel) Ping(ctx context.Context) error {
+	return pingByListing(ctx, m, m.modelName)
+}
+
+// ListAvailableModels mengimplementasikan ModelLister untuk Anthropic
+func (m *AnthropicModel) ListAvailableModels(ctx context.Context) ([]string, error) {
+	header := http.Header{"X-Api-Key": {m.apiKey}, "Anthropic-Version": {"2023-06-01"}}
+	var names []string
+	afterID := ""
+	for {
+		endpoint := m.baseURL + "/models?limit=1000"
+		if afterID != "" {
+			endpoint += "&after_id=" + url.QueryEscape(afterID)
+		}
+		var resp struct {
+			Data []struct {
+				ID string `json:"id"`
+			} `json:"data"`
+			HasMore bool   `json:"has_more"`
+			LastID  string `json:"last_id"`
+		}
+		if err := getJSON(ctx, Anthropic, endpoint, header, &resp); err != nil {
+			return nil, err
+		}
+		for _, d := range resp.Data {
+			names = append(names, d.ID)
+		}
+		if !resp.HasMore || resp.LastID == "" {
+			return names, nil
+		}
+		afterID = resp.LastID
+	}
+}
+
+// Ping mengimplementasikan Pinger untuk Anthropic
+func (m *AnthropicModel) Ping(ctx context.Context) error {
+	return pingByListing(ctx, m, m.modelName)
+}
+
+// ListAvailableModels mengimplementasikan ModelLister untuk Gemini. Awalan "models/" dihapus
+// dari nama model.
+func (m *GeminiModel) ListAvailableModels(ctx context.Context) ([]string, error) {
+	var names []string
+	pageToken := ""
+	for {
+		endpoint := fmt.Sprintf("%s/models?pageSize=1000&key=%s", m.baseURL, url.QueryEscape(m.apiKey))
+		if pageToken != "" {
+			endpoint += "&pageToken=" + url.QueryEscape(pageToken)
+		}
+		var resp struct {
+			Models []struct {
+				Name string `json:"name"`
+			} `json:"models"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		if err := getJSON(ctx, Gemini, endpoint, nil, &resp); err != nil {
+			return nil, err
+		}
+		for _, model := range resp.Models {
+			names = append(names, strings.TrimPrefix(model.Name, "models/"))
+		}
+		if resp.NextPageToken == "" {
+			return names, nil
+		}
+		pageToken = resp.NextPageToken
+	}
+}
+
+// Ping mengimplementasikan Pinger untuk Gemini
+func (m *GeminiModel) Ping(ctx context.Context) error {
+	return pingByListing(ctx, m, m.modelName)
+}
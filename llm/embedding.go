@@ -0,0 +1,111 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// BatchEmbeddingModel adalah Model yang dapat menghitung embedding beberapa teks
+// dalam satu permintaan ke penyedia
+type BatchEmbeddingModel interface {
+	Model
+	// GenerateEmbeddings mengembalikan satu embedding untuk setiap teks, dengan urutan yang sama
+	GenerateEmbeddings(ctx context.Context, texts []string) ([][]float64, error)
+}
+
+// Embeddings menghitung embedding beberapa teks sekaligus. Jika model tidak mendukung
+// permintaan batch, embedding dihitung satu per satu.
+func (c *Client) Embeddings(ctx context.Context, modelName string, texts []string) ([][]float64, error) {
+	model, err := c.GetModel(modelName)
+	if err != nil {
+		return nil, err
+	}
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	if bm, ok := model.(BatchEmbeddingModel); ok {
+		embeddings, err := bm.GenerateEmbeddings(ctx, texts)
+		if err != nil {
+			return nil, err
+		}
+		if len(embeddings) != len(texts) {
+			return nil, fmt.Errorf("jumlah embedding (%d) tidak sesuai dengan jumlah teks (%d)", len(embeddings), len(texts))
+		}
+		return embeddings, nil
+	}
+
+	embeddings := make([][]float64, len(texts))
+	for i, text := range texts {
+		if embeddings[i], err = model.GenerateEmbedding(ctx, text); err != nil {
+			return nil, err
+		}
+	}
+	return embeddings, nil
+}
+
+// BatchEmbeddingRequest adalah payload permintaan embedding OpenAI untuk beberapa teks
+type BatchEmbeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// GenerateEmbeddings mengimplementasikan BatchEmbeddingModel dengan mengirim semua teks
+// sebagai array dalam satu panggilan ke OpenAI Embeddings API
+func (m *OpenAIModel) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float64, error) {
+	reqBody, err := json.Marshal(BatchEmbeddingRequest{
+		Model: m.modelName,
+		Input: texts,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal embedding request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(
+		ctx,
+		"POST",
+		fmt.Sprintf("%s/v1/embeddings", m.baseURL),
+		strings.NewReader(string(reqBody)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", m.apiKey))
+
+	client := &http.Client{}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(OpenAI, resp, respBody)
+	}
+
+	var embResp EmbeddingResponse
+	if err := json.Unmarshal(respBody, &embResp); err != nil {
+		return nil, err
+	}
+	if len(embResp.Data) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(embResp.Data))
+	}
+
+	// Urutkan berdasarkan index karena API tidak menjamin urutan data
+	sort.Slice(embResp.Data, func(i, j int) bool { return embResp.Data[i].Index < embResp.Data[j].Index })
+	embeddings := make([][]float64, len(embResp.Data))
+	for i, d := range embResp.Data {
+		embeddings[i] = d.Embedding
+	}
+	return embeddings, nil
+}
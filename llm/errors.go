@@ -0,0 +1,165 @@
+package llm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kategori error penyedia. Gunakan errors.Is untuk memeriksanya; detailnya tersedia melalui
+// errors.As dengan *APIError.
+var (
+	// ErrAuth berarti API key tidak valid atau tidak memiliki izin
+	ErrAuth = errors.New("autentikasi ditolak")
+	// ErrRateLimited berarti batas laju atau kuota terlampaui; lihat APIError.RetryAfter
+	ErrRateLimited = errors.New("batas laju terlampaui")
+	// ErrContentFiltered berarti prompt atau respons diblokir kebijakan konten
+	ErrContentFiltered = errors.New("diblokir filter konten")
+	// ErrBlocked berarti prompt ditolak filter keamanan sebelum model menjawab (mis. blockReason
+	// Gemini); error ini juga memenuhi ErrContentFiltered
+	ErrBlocked = errors.New("prompt diblokir")
+	// ErrModelNotFound berarti model tidak dikenal oleh client atau penyedia
+	ErrModelNotFound = errors.New("model tidak ditemukan")
+	// ErrServerOverloaded berarti penyedia sedang kelebihan beban atau mengalami gangguan sementara
+	ErrServerOverloaded = errors.New("server penyedia kelebihan beban")
+	// ErrBadRequest berarti permintaan ditolak karena tidak valid
+	ErrBadRequest = errors.New("permintaan tidak valid")
+)
+
+// APIError adalah error yang dikembalikan API penyedia, diurai dari status HTTP dan body-nya
+type APIError struct {
+	Provider   ModelProvider
+	StatusCode int
+	Code       string // Tipe atau kode error dari penyedia, mis. "rate_limit_exceeded"
+	Message    string
+	// RetryAfter adalah jeda yang diminta penyedia sebelum mencoba lagi, jika ada
+	RetryAfter time.Duration
+	// Kind adalah salah satu error kategori di atas, atau nil jika tidak dikenali
+	Kind error
+}
+
+// Error mengimplementasikan interface error
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("error dari %s API (%d", providerTitle(e.Provider), e.StatusCode)
+	if e.Code != "" {
+		msg += " " + e.Code
+	}
+	return msg + "): " + e.Message
+}
+
+// Unwrap mengembalikan kategori error, sehingga errors.Is(err, ErrRateLimited) dapat dipakai
+func (e *APIError) Unwrap() error {
+	return e.Kind
+}
+
+// RetryAfter mengembalikan jeda yang diminta penyedia untuk error tersebut, jika ada
+func RetryAfter(err error) (time.Duration, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		return apiErr.RetryAfter, true
+	}
+	return 0, false
+}
+
+func providerTitle(p ModelProvider) string {
+	switch p {
+	case OpenAI:
+		return "OpenAI"
+	case Anthropic:
+		return "Anthropic"
+	case Gemini:
+		return "Gemini"
+	}
+	return string(p)
+}
+
+// apiErrorBody mencakup format error OpenAI, Anthropic, dan Gemini
+type apiErrorBody struct {
+	Error struct {
+		Message string          `json:"message"`
+		Type    string          `json:"type"`   // OpenAI, Anthropic
+		Code    json.RawMessage `json:"code"`   // OpenAI (string), Gemini (angka)
+		Status  string          `json:"status"` // Gemini
+		Details []struct {
+			Type       string `json:"@type"`
+			RetryDelay string `json:"retryDelay"`
+		} `json:"details"` // Gemini
+	} `json:"error"`
+}
+
+// newAPIError mengurai respons error HTTP dari penyedia
+func newAPIError(provider ModelProvider, resp *http.Response, body []byte) *APIError {
+	e := &APIError{Provider: provider, StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(body))}
+
+	var parsed apiErrorBody
+	if json.Unmarshal(body, &parsed) == nil && parsed.Error.Message != "" {
+		e.Message = parsed.Error.Message
+		var code string
+		if json.Unmarshal(parsed.Error.Code, &code) != nil {
+			code = ""
+		}
+		switch {
+		case code != "":
+			e.Code = code
+		case parsed.Error.Type != "":
+			e.Code = parsed.Error.Type
+		default:
+			e.Code = parsed.Error.Status
+		}
+		for _, d := range parsed.Error.Details {
+			if d, err := time.ParseDuration(d.RetryDelay); err == nil {
+				e.RetryAfter = d
+			}
+		}
+	}
+	if d, ok := parseRetryAfter(resp.Header); ok {
+		e.RetryAfter = d
+	}
+	e.Kind = classifyAPIError(resp.StatusCode, strings.ToLower(e.Code+" "+e.Message))
+	return e
+}
+
+// classifyAPIError menentukan kategori error dari status HTTP dan kode/pesan penyedia
+func classifyAPIError(status int, detail string) error {
+	switch {
+	case strings.Contains(detail, "content_policy") || strings.Contains(detail, "content_filter"):
+		return ErrContentFiltered
+	case status == http.StatusUnauthorized || status == http.StatusForbidden ||
+		strings.Contains(detail, "invalid_api_key") || strings.Contains(detail, "authentication") ||
+		strings.Contains(detail, "permission_denied"):
+		return ErrAuth
+	case status == http.StatusTooManyRequests || strings.Contains(detail, "resource_exhausted"):
+		return ErrRateLimited
+	case status == http.StatusNotFound || strings.Contains(detail, "model_not_found"):
+		return ErrModelNotFound
+	case status == 529 || status >= 500 || strings.Contains(detail, "overloaded"):
+		return ErrServerOverloaded
+	case status >= 400:
+		return ErrBadRequest
+	}
+	return nil
+}
+
+// parseRetryAfter membaca header retry-after-ms (OpenAI) atau Retry-After (detik atau tanggal HTTP)
+func parseRetryAfter(h http.Header) (time.Duration, bool) {
+	if ms, err := strconv.ParseFloat(h.Get("Retry-After-Ms"), 64); err == nil && ms > 0 {
+		return time.Duration(ms * float64(time.Millisecond)), true
+	}
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.ParseFloat(v, 64); err == nil && secs > 0 {
+		return time.Duration(secs * float64(time.Second)), true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d, true
+		}
+	}
+	return 0, false
+}
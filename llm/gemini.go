@@ -12,9 +12,11 @@ import (
 
 // GeminiModel mengimplementasikan interface Model untuk Google Gemini
 type GeminiModel struct {
-	apiKey    string
-	modelName string
-	baseURL   string
+	apiKey         string
+	modelName      string
+	baseURL        string
+	safetySettings []SafetySetting
+	topK           int // Dari ModelConfig.Options["top_k"]
 }
 
 // GenerateEmbedding implements Model.
@@ -34,21 +36,27 @@ func NewGeminiModel(config ModelConfig) (Model, error) {
 		baseURL = config.BaseURL
 	}
 
+	topK, _ := optionNumber(config.Options["top_k"])
 	return &GeminiModel{
-		apiKey:    config.APIKey,
-		modelName: config.ModelName,
-		baseURL:   baseURL,
+		apiKey:         config.APIKey,
+		modelName:      config.ModelName,
+		baseURL:        baseURL,
+		safetySettings: config.SafetySettings,
+		topK:           int(topK),
 	}, nil
 }
 
 // GeminiRequest adalah struktur permintaan untuk API Gemini
 type GeminiRequest struct {
-	Contents         []GeminiContent        `json:"contents"`
-	GenerationConfig GeminiGenerationConfig `json:"generationConfig,omitempty"`
+	SystemInstruction *GeminiContent         `json:"systemInstruction,omitempty"`
+	Contents          []GeminiContent        `json:"contents"`
+	SafetySettings    []SafetySetting        `json:"safetySettings,omitempty"`
+	GenerationConfig  GeminiGenerationConfig `json:"generationConfig,omitempty"`
 }
 
 // GeminiContent merepresentasikan konten dalam permintaan Gemini
 type GeminiContent struct {
+	Role  string       `json:"role,omitempty"` // "user" atau "model"
 	Parts []GeminiPart `json:"parts"`
 }
 
@@ -59,10 +67,15 @@ type GeminiPart struct {
 
 // GeminiGenerationConfig berisi konfigurasi untuk generasi Gemini
 type GeminiGenerationConfig struct {
-	MaxOutputTokens int     `json:"maxOutputTokens,omitempty"`
-	Temperature     float64 `json:"temperature,omitempty"`
-	TopP            float64 `json:"topP,omitempty"`
-	TopK            int     `json:"topK,omitempty"`
+	MaxOutputTokens  int      `json:"maxOutputTokens,omitempty"`
+	Temperature      float64  `json:"temperature,omitempty"`
+	TopP             float64  `json:"topP,omitempty"`
+	TopK             int      `json:"topK,omitempty"`
+	StopSequences    []string `json:"stopSequences,omitempty"`
+	FrequencyPenalty float64  `json:"frequencyPenalty,omitempty"`
+	PresencePenalty  float64  `json:"presencePenalty,omitempty"`
+	Seed             *int     `json:"seed,omitempty"`
+	CandidateCount   int      `json:"candidateCount,omitempty"`
 }
 
 // GeminiResponse adalah struktur respons dari API Gemini
@@ -74,13 +87,22 @@ type GeminiResponse struct {
 
 // GeminiCandidate merepresentasikan satu kandidat respons dari Gemini
 type GeminiCandidate struct {
-	Content      GeminiContent `json:"content"`
-	FinishReason string        `json:"finishReason"`
+	Content       GeminiContent        `json:"content"`
+	FinishReason  string               `json:"finishReason"`
+	SafetyRatings []GeminiSafetyRating `json:"safetyRatings,omitempty"`
 }
 
 // GeminiPromptFeedback berisi feedback tentang prompt
 type GeminiPromptFeedback struct {
-	BlockReason string `json:"blockReason,omitempty"`
+	BlockReason   string               `json:"blockReason,omitempty"`
+	SafetyRatings []GeminiSafetyRating `json:"safetyRatings,omitempty"`
+}
+
+// GeminiSafetyRating adalah penilaian keamanan Gemini untuk satu kategori
+type GeminiSafetyRating struct {
+	Category    HarmCategory `json:"category"`
+	Probability string       `json:"probability"`
+	Blocked     bool         `json:"blocked,omitempty"`
 }
 
 // GeminiUsageMetadata berisi informasi penggunaan token
@@ -88,27 +110,38 @@ type GeminiUsageMetadata struct {
 	PromptTokenCount     int `json:"promptTokenCount"`
 	CandidatesTokenCount int `json:"candidatesTokenCount"`
 	TotalTokenCount      int `json:"totalTokenCount"`
+	// CachedContentTokenCount adalah bagian prompt yang dibaca dari cache
+	CachedContentTokenCount int `json:"cachedContentTokenCount,omitempty"`
 }
 
 // Generate mengimplementasikan interface Model.Generate untuk Gemini
 func (m *GeminiModel) Generate(ctx context.Context, req ModelRequest) (ModelResponse, error) {
 	// Konversi ModelRequest ke GeminiRequest
 	geminiReq := GeminiRequest{
-		Contents: []GeminiContent{
-			{
-				Parts: []GeminiPart{
-					{
-						Text: req.Prompt,
-					},
-				},
-			},
-		},
+		SafetySettings: mergeSafetySettings(m.safetySettings, req.SafetySettings),
 		GenerationConfig: GeminiGenerationConfig{
-			MaxOutputTokens: req.MaxTokens,
-			Temperature:     req.Temperature,
-			TopP:            req.TopP,
+			MaxOutputTokens:  req.MaxTokens,
+			Temperature:      req.Temperature,
+			TopP:             req.TopP,
+			TopK:             m.topK,
+			StopSequences:    req.StopSequences,
+			FrequencyPenalty: req.FrequencyPenalty,
+			PresencePenalty:  req.PresencePenalty,
+			Seed:             req.Seed,
+			CandidateCount:   req.N,
 		},
 	}
+	if req.System != "" {
+		geminiReq.SystemInstruction = &GeminiContent{Parts: []GeminiPart{{Text: req.System}}}
+	}
+	// Riwayat percakapan dikirim sebagai giliran user dan model yang bergantian
+	for _, turn := range turns(req) {
+		role := "user"
+		if turn.Role == RoleAssistant {
+			role = "model"
+		}
+		geminiReq.Contents = append(geminiReq.Contents, GeminiContent{Role: role, Parts: []GeminiPart{{Text: turn.Content}}})
+	}
 
 	// Serialize request body
 	reqBody, err := json.Marshal(geminiReq)
@@ -149,7 +182,7 @@ func (m *GeminiModel) Generate(ctx context.Context, req ModelRequest) (ModelResp
 
 	// Periksa status code
 	if resp.StatusCode != http.StatusOK {
-		return ModelResponse{}, fmt.Errorf("error dari Gemini API: %s", string(respBody))
+		return ModelResponse{}, newAPIError(Gemini, resp, respBody)
 	}
 
 	// Unmarshal respons
@@ -158,19 +191,38 @@ func (m *GeminiModel) Generate(ctx context.Context, req ModelRequest) (ModelResp
 		return ModelResponse{}, err
 	}
 
+	// Prompt diblokir oleh filter keamanan Gemini
+	if geminiResp.PromptFeedback.BlockReason != "" {
+		return ModelResponse{}, &ContentFilterError{
+			Provider:      Gemini,
+			Model:         m.modelName,
+			Reason:        geminiResp.PromptFeedback.BlockReason,
+			PromptBlocked: true,
+			Categories:    flaggedCategories(geminiResp.PromptFeedback.SafetyRatings),
+		}
+	}
+
 	// Periksa apakah ada kandidat
 	if len(geminiResp.Candidates) == 0 {
 		return ModelResponse{}, errors.New("tidak ada respons dari model Gemini")
 	}
 
-	// Ekstrak teks dari respons
-	var responseText string
-	for _, part := range geminiResp.Candidates[0].Content.Parts {
-		responseText += part.Text
+	// Kandidat dihentikan karena alasan keamanan
+	switch geminiResp.Candidates[0].FinishReason {
+	case "SAFETY", "BLOCKLIST", "PROHIBITED_CONTENT", "SPII":
+		return ModelResponse{}, &ContentFilterError{
+			Provider:   Gemini,
+			Model:      m.modelName,
+			Reason:     geminiResp.Candidates[0].FinishReason,
+			Categories: flaggedCategories(geminiResp.Candidates[0].SafetyRatings),
+		}
 	}
 
+	// Ekstrak teks dari respons
+	responseText := geminiResp.Candidates[0].text()
+
 	// Konversi GeminiResponse ke ModelResponse
-	return ModelResponse{
+	res := ModelResponse{
 		Text:       responseText,
 		ModelName:  m.modelName,
 		Provider:   Gemini,
@@ -179,8 +231,24 @@ func (m *GeminiModel) Generate(ctx context.Context, req ModelRequest) (ModelResp
 			PromptTokens:     geminiResp.UsageMetadata.PromptTokenCount,
 			CompletionTokens: geminiResp.UsageMetadata.CandidatesTokenCount,
 			TotalTokens:      geminiResp.UsageMetadata.TotalTokenCount,
+			CachedTokens:     geminiResp.UsageMetadata.CachedContentTokenCount,
 		},
-	}, nil
+	}
+	if len(geminiResp.Candidates) > 1 {
+		for _, c := range geminiResp.Candidates {
+			res.Candidates = append(res.Candidates, Candidate{Text: c.text(), FinishType: c.FinishReason})
+		}
+	}
+	return res, nil
+}
+
+// text menggabungkan semua bagian teks kandidat
+func (c GeminiCandidate) text() string {
+	var text string
+	for _, part := range c.Content.Parts {
+		text += part.Text
+	}
+	return text
 }
 
 // GetProvider mengimplementasikan interface Model.GetProvider
@@ -192,3 +260,14 @@ func (m *GeminiModel) GetProvider() ModelProvider {
 func (m *GeminiModel) GetModelName() string {
 	return m.modelName
 }
+
+// flaggedCategories mengembalikan kategori yang diblokir atau berprobabilitas MEDIUM/HIGH
+func flaggedCategories(ratings []GeminiSafetyRating) []string {
+	var out []string
+	for _, r := range ratings {
+		if r.Blocked || r.Probability == "MEDIUM" || r.Probability == "HIGH" {
+			out = append(out, string(r.Category))
+		}
+	}
+	return out
+}
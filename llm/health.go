@@ -0,0 +1,119 @@
+package llm
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultHealthCheckTimeout membatasi durasi pemeriksaan satu model jika ctx tidak memiliki deadline
+const DefaultHealthCheckTimeout = 10 * time.Second
+
+// Pinger dapat diimplementasikan oleh model yang memiliki cara lebih murah untuk memeriksa
+// ketersediaannya, mis. endpoint daftar model penyedia seperti model bawaan OpenAI, Anthropic,
+// dan Gemini. Model lain diperiksa dengan permintaan kecil.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// ModelHealth adalah hasil pemeriksaan kesehatan satu model
+type ModelHealth struct {
+	Name      string        // Nama model yang didaftarkan di client
+	Healthy   bool          // true jika model merespons tanpa error
+	Latency   time.Duration // Durasi pemeriksaan
+	Err       error         // Error pemeriksaan, jika ada
+	CheckedAt time.Time
+}
+
+// HealthCheck memeriksa semua model yang terdaftar secara bersamaan dengan Ping, atau dengan
+// permintaan satu token jika model tidak mengimplementasikan Pinger. Pemeriksaan ini juga
+// berfungsi sebagai warmup koneksi. Model yang gagal ditandai tidak sehat sehingga GetModel
+// memakai model fallback sebagai gantinya, sampai pemeriksaan berikutnya atau panggilan Generate
+// yang berhasil. Hasil diurutkan berdasarkan nama.
+func (c *Client) HealthCheck(ctx context.Context) []ModelHealth {
+	c.mu.RLock()
+	models := make(map[string]Model, len(c.models))
+	for name, model := range c.models {
+		models[name] = model
+	}
+	c.mu.RUnlock()
+
+	results := make([]ModelHealth, 0, len(models))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, model := range models {
+		wg.Add(1)
+		go func(name string, model Model) {
+			defer wg.Done()
+			h := pingModel(ctx, name, model)
+			mu.Lock()
+			results = append(results, h)
+			mu.Unlock()
+		}(name, model)
+	}
+	wg.Wait()
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.health == nil {
+		c.health = make(map[string]ModelHealth)
+	}
+	for _, h := range results {
+		c.health[h.Name] = h
+	}
+	return results
+}
+
+// pingModel memeriksa satu model
+func pingModel(ctx context.Context, name string, model Model) ModelHealth {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultHealthCheckTimeout)
+		defer cancel()
+	}
+	start := time.Now()
+	var err error
+	if p, ok := model.(Pinger); ok {
+		err = p.Ping(ctx)
+	} else {
+		_, err = model.Generate(ctx, ModelRequest{Prompt: "ping", MaxTokens: 1})
+	}
+	return ModelHealth{Name: name, Healthy: err == nil, Latency: time.Since(start), Err: err, CheckedAt: start}
+}
+
+// Health mengembalikan hasil pemeriksaan terakhir setiap model yang sudah diperiksa, diurutkan
+// berdasarkan nama
+func (c *Client) Health() []ModelHealth {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	results := make([]ModelHealth, 0, len(c.health))
+	for _, h := range c.health {
+		results = append(results, h)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+	return results
+}
+
+// unhealthy melaporkan apakah model dengan nama name ditandai tidak sehat. Pemanggil harus
+// memegang c.mu.
+func (c *Client) unhealthy(name string) bool {
+	h, ok := c.health[name]
+	return ok && !h.Healthy
+}
+
+// markHealthy menandai model sehat kembali setelah panggilan yang berhasil
+func (c *Client) markHealthy(name string) {
+	c.mu.RLock()
+	bad := c.unhealthy(name)
+	c.mu.RUnlock()
+	if !bad {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	h := c.health[name]
+	h.Healthy, h.Err, h.CheckedAt = true, nil, time.Now()
+	c.health[name] = h
+}
@@ -0,0 +1,41 @@
+package llm
+
+import (
+	"context"
+	"time"
+
+	"github.com/zakirkun/gatot-kaca/logging"
+)
+
+// SetLogger menetapkan Logger untuk panggilan model client. Tanpa logger, client memakai logger
+// dari context panggilan (lihat logging.FromContext).
+func (c *Client) SetLogger(l logging.Logger) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.logger = l
+}
+
+// logFor mengembalikan logger client, atau logger dari ctx jika client tidak punya
+func (c *Client) logFor(ctx context.Context) logging.Logger {
+	c.mu.RLock()
+	l := c.logger
+	c.mu.RUnlock()
+	if l != nil {
+		return l
+	}
+	return logging.FromContext(ctx)
+}
+
+// logCall mencatat satu panggilan model beserta durasi dan penggunaan tokennya
+func (c *Client) logCall(ctx context.Context, modelName string, start time.Time, resp ModelResponse, err error) {
+	args := []any{
+		logging.KeyComponent, "llm",
+		logging.KeyModel, modelName,
+		logging.KeyDuration, time.Since(start),
+	}
+	if err != nil {
+		c.logFor(ctx).Debug("model call failed", append(args, logging.KeyError, err)...)
+		return
+	}
+	c.logFor(ctx).Debug("model call", append(args, logging.KeyTokens, resp.Usage.TotalTokens)...)
+}
@@ -0,0 +1,41 @@
+package llm
+
+import "math"
+
+// MetadataLogprobs adalah key ModelResponse.Metadata yang berisi []TokenLogprob jika
+// ModelRequest.Logprobs diaktifkan dan penyedia mengembalikannya
+const MetadataLogprobs = "logprobs"
+
+// TokenLogprob adalah log probability satu token yang dihasilkan, beserta alternatif teratas
+// jika ModelRequest.TopLogprobs diisi
+type TokenLogprob struct {
+	Token       string         `json:"token"`
+	Logprob     float64        `json:"logprob"`
+	Bytes       []int          `json:"bytes,omitempty"`
+	TopLogprobs []TokenLogprob `json:"top_logprobs,omitempty"`
+}
+
+// Logprobs mengembalikan log probability token dari resp, atau nil jika tidak ada
+func Logprobs(resp ModelResponse) []TokenLogprob {
+	tokens, _ := resp.Metadata[MetadataLogprobs].([]TokenLogprob)
+	return tokens
+}
+
+// MeanLogprob mengembalikan rata-rata log probability token, ukuran keyakinan model atas
+// jawabannya. Hasilnya 0 jika tokens kosong.
+func MeanLogprob(tokens []TokenLogprob) float64 {
+	if len(tokens) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, t := range tokens {
+		sum += t.Logprob
+	}
+	return sum / float64(len(tokens))
+}
+
+// Perplexity mengembalikan perplexity jawaban, exp(-MeanLogprob); 1 berarti model sepenuhnya
+// yakin
+func Perplexity(tokens []TokenLogprob) float64 {
+	return math.Exp(-MeanLogprob(tokens))
+}
@@ -3,6 +3,7 @@ package llm
 import (
 	"context"
 	"errors"
+	"strings"
 )
 
 // ModelProvider mendefinisikan penyedia model LLM
@@ -16,11 +17,34 @@ const (
 
 // ModelRequest mewakili permintaan ke model LLM
 type ModelRequest struct {
-	Prompt      string                 `json:"prompt"`
-	MaxTokens   int                    `json:"max_tokens,omitempty"`
-	Temperature float64                `json:"temperature,omitempty"`
-	TopP        float64                `json:"top_p,omitempty"`
-	Context     map[string]interface{} `json:"context,omitempty"`
+	Prompt           string                 `json:"prompt"`
+	MaxTokens        int                    `json:"max_tokens,omitempty"`
+	Temperature      float64                `json:"temperature,omitempty"`
+	TopP             float64                `json:"top_p,omitempty"`
+	StopSequences    []string               `json:"stop_sequences,omitempty"`
+	FrequencyPenalty float64                `json:"frequency_penalty,omitempty"`
+	PresencePenalty  float64                `json:"presence_penalty,omitempty"`
+	Seed             *int                   `json:"seed,omitempty"` // nil berarti tidak ditentukan
+	N                int                    `json:"n,omitempty"`    // Jumlah kandidat yang diminta
+	Context          map[string]interface{} `json:"context,omitempty"`
+	// SafetySettings menimpa pengaturan keamanan model per kategori (hanya Gemini)
+	SafetySettings []SafetySetting `json:"safety_settings,omitempty"`
+	// Logprobs meminta log probability setiap token jawaban (penyedia yang kompatibel dengan
+	// OpenAI); hasilnya ada di Metadata dengan key MetadataLogprobs, lihat Logprobs
+	Logprobs bool `json:"logprobs,omitempty"`
+	// TopLogprobs meminta sejumlah alternatif teratas untuk setiap token; mengaktifkan Logprobs
+	TopLogprobs int `json:"top_logprobs,omitempty"`
+	// System adalah instruksi sistem yang dikirim terpisah dari Prompt, mis. system prompt dan
+	// katalog tool yang jarang berubah. Penyedia meng-cache prefix yang sama antar panggilan.
+	System string `json:"system,omitempty"`
+	// Messages adalah riwayat percakapan sebelum Prompt, dikirim sebagai giliran user dan
+	// assistant terpisah. Prompt menjadi giliran user terakhir dan boleh kosong jika pesan
+	// terakhir sudah berasal dari user.
+	Messages []Message `json:"messages,omitempty"`
+	// CacheSystem menandai System dengan cache_control agar di-cache oleh Anthropic; OpenAI dan
+	// Gemini meng-cache prefix yang panjang secara otomatis. Token yang dibaca dari cache
+	// dilaporkan di Usage.CachedTokens.
+	CacheSystem bool `json:"cache_system,omitempty"`
 }
 
 // ModelResponse mewakili respons dari model LLM
@@ -31,6 +55,18 @@ type ModelResponse struct {
 	Provider   ModelProvider          `json:"provider"`
 	Metadata   map[string]interface{} `json:"metadata,omitempty"`
 	FinishType string                 `json:"finish_type,omitempty"`
+	// ContentFilter menjelaskan penanganan filter konten (diisi oleh Client.Generate)
+	ContentFilter *ContentFilterResult `json:"content_filter,omitempty"`
+	// Candidates berisi semua kandidat jika ModelRequest.N lebih dari 1; Text sama dengan
+	// kandidat pertama
+	Candidates []Candidate `json:"candidates,omitempty"`
+}
+
+// Candidate adalah satu dari beberapa jawaban yang dihasilkan untuk permintaan yang sama
+type Candidate struct {
+	Text       string         `json:"text"`
+	FinishType string         `json:"finish_type,omitempty"`
+	Logprobs   []TokenLogprob `json:"logprobs,omitempty"` // Diisi jika ModelRequest.Logprobs diaktifkan
 }
 
 // Usage mencatat penggunaan token
@@ -38,6 +74,21 @@ type Usage struct {
 	PromptTokens     int `json:"prompt_tokens"`
 	CompletionTokens int `json:"completion_tokens"`
 	TotalTokens      int `json:"total_tokens"`
+	// CachedTokens adalah bagian PromptTokens yang dibaca dari prompt cache penyedia
+	CachedTokens int `json:"cached_tokens,omitempty"`
+	// CacheWriteTokens adalah bagian PromptTokens yang ditulis ke prompt cache (Anthropic)
+	CacheWriteTokens int `json:"cache_write_tokens,omitempty"`
+}
+
+// Add mengembalikan jumlah u dan o
+func (u Usage) Add(o Usage) Usage {
+	return Usage{
+		PromptTokens:     u.PromptTokens + o.PromptTokens,
+		CompletionTokens: u.CompletionTokens + o.CompletionTokens,
+		TotalTokens:      u.TotalTokens + o.TotalTokens,
+		CachedTokens:     u.CachedTokens + o.CachedTokens,
+		CacheWriteTokens: u.CacheWriteTokens + o.CacheWriteTokens,
+	}
 }
 
 type Model interface {
@@ -61,6 +112,8 @@ type ModelConfig struct {
 	APIKey    string                 `json:"api_key"`
 	BaseURL   string                 `json:"base_url,omitempty"`
 	Options   map[string]interface{} `json:"options,omitempty"`
+	// SafetySettings adalah pengaturan keamanan bawaan model (hanya Gemini)
+	SafetySettings []SafetySetting `json:"safety_settings,omitempty"`
 }
 
 // ModelFactory membuat instance Model berdasarkan konfigurasi
@@ -76,3 +129,48 @@ func ModelFactory(config ModelConfig) (Model, error) {
 		return nil, errors.New("provider tidak didukung")
 	}
 }
+
+// Role pesan dalam ModelRequest.Messages
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// promptText mengembalikan seluruh teks permintaan, yaitu System, Messages, dan Prompt, untuk
+// menghitung token
+func promptText(req ModelRequest) string {
+	parts := make([]string, 0, len(req.Messages)+2)
+	if req.System != "" {
+		parts = append(parts, req.System)
+	}
+	for _, msg := range req.Messages {
+		parts = append(parts, msg.Content)
+	}
+	if req.Prompt != "" {
+		parts = append(parts, req.Prompt)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// turns mengembalikan Messages diikuti Prompt sebagai giliran user. Pesan berurutan dengan role
+// yang sama digabung, karena Anthropic dan Gemini mewajibkan giliran yang bergantian. Role
+// selain RoleAssistant dianggap RoleUser.
+func turns(req ModelRequest) []Message {
+	all := append([]Message(nil), req.Messages...)
+	if req.Prompt != "" || len(all) == 0 {
+		all = append(all, Message{Role: RoleUser, Content: req.Prompt})
+	}
+	var out []Message
+	for _, msg := range all {
+		role := RoleUser
+		if msg.Role == RoleAssistant {
+			role = RoleAssistant
+		}
+		if n := len(out); n > 0 && out[n-1].Role == role {
+			out[n-1].Content += "\n\n" + msg.Content
+			continue
+		}
+		out = append(out, Message{Role: role, Content: msg.Content})
+	}
+	return out
+}
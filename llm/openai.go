@@ -26,6 +26,7 @@ type EmbeddingRequest struct {
 // EmbeddingResponse represents the response from the OpenAI Embeddings API.
 type EmbeddingResponse struct {
 	Data []struct {
+		Index     int       `json:"index"`
 		Embedding []float64 `json:"embedding"`
 	} `json:"data"`
 }
@@ -74,7 +75,7 @@ func (m *OpenAIModel) GenerateEmbedding(ctx context.Context, text string) ([]flo
 
 	// Periksa status code
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("error dari OpenAI API: %s", string(respBody))
+		return nil, newAPIError(OpenAI, resp, respBody)
 	}
 
 	// Parse and return the embedding.
@@ -110,12 +111,26 @@ func NewOpenAIModel(config ModelConfig) (Model, error) {
 
 // OpenAIRequest adalah struktur permintaan untuk API OpenAI
 type OpenAIRequest struct {
-	Model       string    `json:"model"`
-	Messages    []Message `json:"messages"`
-	MaxTokens   int       `json:"max_tokens,omitempty"`
-	Temperature float64   `json:"temperature,omitempty"`
-	TopP        float64   `json:"top_p,omitempty"`
-	Stream      bool      `json:"stream,omitempty"`
+	Model            string    `json:"model"`
+	Messages         []Message `json:"messages"`
+	MaxTokens        int       `json:"max_tokens,omitempty"`
+	Temperature      float64   `json:"temperature,omitempty"`
+	TopP             float64   `json:"top_p,omitempty"`
+	Stop             []string  `json:"stop,omitempty"`
+	FrequencyPenalty float64   `json:"frequency_penalty,omitempty"`
+	PresencePenalty  float64   `json:"presence_penalty,omitempty"`
+	Seed             *int      `json:"seed,omitempty"`
+	N                int       `json:"n,omitempty"`
+	Logprobs         bool      `json:"logprobs,omitempty"`
+	TopLogprobs      int       `json:"top_logprobs,omitempty"`
+	Stream           bool      `json:"stream,omitempty"`
+
+	StreamOptions *OpenAIStreamOptions `json:"stream_options,omitempty"`
+}
+
+// OpenAIStreamOptions mengatur perilaku streaming OpenAI
+type OpenAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage,omitempty"`
 }
 
 // Message merepresentasikan format pesan untuk ChatGPT
@@ -126,16 +141,32 @@ type Message struct {
 
 // OpenAIResponse adalah struktur respons dari API OpenAI
 type OpenAIResponse struct {
-	ID      string   `json:"id"`
-	Object  string   `json:"object"`
-	Created int64    `json:"created"`
-	Model   string   `json:"model"`
-	Choices []Choice `json:"choices"`
-	Usage   struct {
-		PromptTokens     int `json:"prompt_tokens"`
-		CompletionTokens int `json:"completion_tokens"`
-		TotalTokens      int `json:"total_tokens"`
-	} `json:"usage"`
+	ID      string      `json:"id"`
+	Object  string      `json:"object"`
+	Created int64       `json:"created"`
+	Model   string      `json:"model"`
+	Choices []Choice    `json:"choices"`
+	Usage   OpenAIUsage `json:"usage"`
+}
+
+// OpenAIUsage adalah penggunaan token yang dilaporkan OpenAI
+type OpenAIUsage struct {
+	PromptTokens        int `json:"prompt_tokens"`
+	CompletionTokens    int `json:"completion_tokens"`
+	TotalTokens         int `json:"total_tokens"`
+	PromptTokensDetails struct {
+		CachedTokens int `json:"cached_tokens"`
+	} `json:"prompt_tokens_details"`
+}
+
+// usage mengonversi penggunaan OpenAI ke Usage
+func (u OpenAIUsage) usage() Usage {
+	return Usage{
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+		TotalTokens:      u.TotalTokens,
+		CachedTokens:     u.PromptTokensDetails.CachedTokens,
+	}
 }
 
 // Choice merepresentasikan pilihan respons dari OpenAI
@@ -143,23 +174,55 @@ type Choice struct {
 	Index        int     `json:"index"`
 	Message      Message `json:"message"`
 	FinishReason string  `json:"finish_reason"`
+	Logprobs     *struct {
+		Content []TokenLogprob `json:"content"`
+	} `json:"logprobs,omitempty"`
+}
+
+// tokenLogprobs mengembalikan log probability token pilihan, atau nil jika tidak ada
+func (c Choice) tokenLogprobs() []TokenLogprob {
+	if c.Logprobs == nil {
+		return nil
+	}
+	return c.Logprobs.Content
+}
+
+// buildRequest mengonversi ModelRequest ke OpenAIRequest. System dikirim sebagai pesan system
+// pertama sehingga prefix yang sama di-cache oleh OpenAI.
+func (m *OpenAIModel) buildRequest(req ModelRequest) OpenAIRequest {
+	var messages []Message
+	if req.System != "" {
+		messages = append(messages, Message{Role: "system", Content: req.System})
+	}
+	for _, msg := range req.Messages {
+		if msg.Role != RoleAssistant && msg.Role != "system" {
+			msg.Role = RoleUser
+		}
+		messages = append(messages, msg)
+	}
+	if req.Prompt != "" || len(req.Messages) == 0 {
+		messages = append(messages, Message{Role: RoleUser, Content: req.Prompt})
+	}
+	return OpenAIRequest{
+		Model:            m.modelName,
+		Messages:         messages,
+		MaxTokens:        req.MaxTokens,
+		Temperature:      req.Temperature,
+		TopP:             req.TopP,
+		Stop:             req.StopSequences,
+		FrequencyPenalty: req.FrequencyPenalty,
+		PresencePenalty:  req.PresencePenalty,
+		Seed:             req.Seed,
+		N:                req.N,
+		Logprobs:         req.Logprobs || req.TopLogprobs > 0,
+		TopLogprobs:      req.TopLogprobs,
+	}
 }
 
 // Generate mengimplementasikan interface Model.Generate untuk OpenAI
 func (m *OpenAIModel) Generate(ctx context.Context, req ModelRequest) (ModelResponse, error) {
 	// Konversi ModelRequest ke OpenAIRequest
-	openAIReq := OpenAIRequest{
-		Model: m.modelName,
-		Messages: []Message{
-			{
-				Role:    "user",
-				Content: req.Prompt,
-			},
-		},
-		MaxTokens:   req.MaxTokens,
-		Temperature: req.Temperature,
-		TopP:        req.TopP,
-	}
+	openAIReq := m.buildRequest(req)
 
 	// Serialize request body
 	reqBody, err := json.Marshal(openAIReq)
@@ -198,7 +261,7 @@ func (m *OpenAIModel) Generate(ctx context.Context, req ModelRequest) (ModelResp
 
 	// Periksa status code
 	if resp.StatusCode != http.StatusOK {
-		return ModelResponse{}, fmt.Errorf("error dari OpenAI API: %s", string(respBody))
+		return ModelResponse{}, newAPIError(OpenAI, resp, respBody)
 	}
 
 	// Unmarshal respons
@@ -212,17 +275,35 @@ func (m *OpenAIModel) Generate(ctx context.Context, req ModelRequest) (ModelResp
 		return ModelResponse{}, errors.New("tidak ada respons dari model")
 	}
 
-	return ModelResponse{
+	// Respons yang dipotong oleh filter konten dikembalikan sebagai error bertipe
+	if openAIResp.Choices[0].FinishReason == "content_filter" {
+		return ModelResponse{}, &ContentFilterError{
+			Provider: OpenAI,
+			Model:    m.modelName,
+			Reason:   "content_filter",
+		}
+	}
+
+	res := ModelResponse{
 		Text:       openAIResp.Choices[0].Message.Content,
 		ModelName:  m.modelName,
 		Provider:   OpenAI,
 		FinishType: openAIResp.Choices[0].FinishReason,
-		Usage: Usage{
-			PromptTokens:     openAIResp.Usage.PromptTokens,
-			CompletionTokens: openAIResp.Usage.CompletionTokens,
-			TotalTokens:      openAIResp.Usage.TotalTokens,
-		},
-	}, nil
+		Usage:      openAIResp.Usage.usage(),
+	}
+	if tokens := openAIResp.Choices[0].tokenLogprobs(); tokens != nil {
+		res.Metadata = map[string]interface{}{MetadataLogprobs: tokens}
+	}
+	if len(openAIResp.Choices) > 1 {
+		for _, choice := range openAIResp.Choices {
+			res.Candidates = append(res.Candidates, Candidate{
+				Text:       choice.Message.Content,
+				FinishType: choice.FinishReason,
+				Logprobs:   choice.tokenLogprobs(),
+			})
+		}
+	}
+	return res, nil
 }
 
 // GetProvider mengimplementasikan interface Model.GetProvider
@@ -0,0 +1,216 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/zakirkun/gatot-kaca/logging"
+)
+
+// ClientOption mengonfigurasi Client saat dibuat dengan NewClient
+type ClientOption func(*Client)
+
+// WithModel mendaftarkan model dengan nama tertentu
+func WithModel(name string, model Model) ClientOption {
+	return func(c *Client) {
+		c.models[name] = model
+	}
+}
+
+// WithFallbackModel menetapkan model yang dipakai jika nama model tidak ditemukan
+func WithFallbackModel(model Model) ClientOption {
+	return func(c *Client) {
+		c.fallback = model
+	}
+}
+
+// WithContentFilterRetry mengaktifkan pengulangan dengan prompt yang disanitasi ketika respons
+// diblokir filter konten; lihat SetContentFilterRetry
+func WithContentFilterRetry(sanitizer PromptSanitizer) ClientOption {
+	return func(c *Client) {
+		if sanitizer == nil {
+			sanitizer = DefaultPromptSanitizer
+		}
+		c.sanitizer = sanitizer
+	}
+}
+
+// WithContextCheck mengaktifkan atau menonaktifkan pemeriksaan context window; lihat SetContextCheck
+func WithContextCheck(enabled bool) ClientOption {
+	return func(c *Client) {
+		c.skipContextCheck = !enabled
+	}
+}
+
+// WithRetryPolicy mengulang panggilan Generate yang gagal sesuai kebijakan. Kebijakan dapat diganti
+// per panggilan dengan ContextWithRetryPolicy.
+func WithRetryPolicy(p RetryPolicy) ClientOption {
+	return func(c *Client) {
+		c.retry = &p
+	}
+}
+
+// WithLogger menetapkan Logger untuk panggilan model client (lihat Client.SetLogger)
+func WithLogger(l logging.Logger) ClientOption {
+	return func(c *Client) {
+		c.logger = l
+	}
+}
+
+// WithHooks memasang hook yang dipanggil di sekitar setiap panggilan Generate dan GenerateStream.
+// Hook untuk panggilan tertentu saja dapat dipasang dengan ContextWithHooks.
+func WithHooks(h Hooks) ClientOption {
+	return func(c *Client) {
+		c.hooks = append(c.hooks, h)
+	}
+}
+
+// AddHooks memasang hook pada client yang sudah dibuat (lihat WithHooks)
+func (c *Client) AddHooks(h Hooks) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hooks = append(c.hooks, h)
+}
+
+type callHooksKey struct{}
+
+type callRetryKey struct{}
+
+// ContextWithHooks mengembalikan context yang membawa h, yang dipanggil di sekitar setiap
+// panggilan Generate dan GenerateStream dengan context tersebut, setelah hook milik client.
+// Hook yang sudah dibawa context tetap dipanggil.
+func ContextWithHooks(ctx context.Context, h Hooks) context.Context {
+	hooks, _ := ctx.Value(callHooksKey{}).([]Hooks)
+	return context.WithValue(ctx, callHooksKey{}, append(hooks[:len(hooks):len(hooks)], h))
+}
+
+// ContextWithRetryPolicy mengembalikan context yang membuat panggilan Generate dan GenerateStream
+// dengan context tersebut diulang sesuai p, menggantikan RetryPolicy client
+func ContextWithRetryPolicy(ctx context.Context, p RetryPolicy) context.Context {
+	return context.WithValue(ctx, callRetryKey{}, &p)
+}
+
+// Hooks berisi callback opsional di sekitar panggilan model, mis. untuk logging atau tracing.
+// Field yang nil diabaikan.
+type Hooks struct {
+	// BeforeGenerate dipanggil sebelum permintaan dikirim
+	BeforeGenerate func(ctx context.Context, model string, req ModelRequest)
+	// AfterGenerate dipanggil setelah panggilan selesai, termasuk semua pengulangan
+	AfterGenerate func(ctx context.Context, model string, req ModelRequest, resp ModelResponse, err error, duration time.Duration)
+}
+
+// RetryPolicy menentukan bagaimana panggilan model yang gagal diulang dengan backoff eksponensial
+type RetryPolicy struct {
+	// MaxAttempts adalah jumlah percobaan total, termasuk yang pertama. Nilai <= 1 berarti tanpa pengulangan.
+	MaxAttempts int
+	// InitialBackoff adalah jeda sebelum pengulangan pertama; bawaan 500ms
+	InitialBackoff time.Duration
+	// MaxBackoff membatasi jeda; bawaan 30 detik
+	MaxBackoff time.Duration
+	// Multiplier mengalikan jeda setiap pengulangan; bawaan 2
+	Multiplier float64
+	// Retryable menentukan apakah error layak diulang; bawaan DefaultRetryable. Jeda yang diminta
+	// penyedia (lihat RetryAfter) dipakai jika lebih lama dari backoff.
+	Retryable func(err error) bool
+}
+
+// DefaultRetryable mengulang error sementara: batas laju, server yang kelebihan beban, dan error
+// jaringan. Error yang tidak akan berhasil jika diulang, seperti autentikasi, model tidak
+// ditemukan, permintaan tidak valid, filter konten, prompt terlalu panjang, dan pembatalan
+// context, tidak diulang.
+func DefaultRetryable(err error) bool {
+	for _, permanent := range []error{context.Canceled, context.DeadlineExceeded, ErrContextTooLong,
+		ErrContentFiltered, ErrAuth, ErrModelNotFound, ErrBadRequest} {
+		if errors.Is(err, permanent) {
+			return false
+		}
+	}
+	return true
+}
+
+// backoff mengembalikan jeda sebelum percobaan ke-attempt (dimulai dari 1), dengan jitter ±20%
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.InitialBackoff
+	if d <= 0 {
+		d = 500 * time.Millisecond
+	}
+	max := p.MaxBackoff
+	if max <= 0 {
+		max = 30 * time.Second
+	}
+	mult := p.Multiplier
+	if mult <= 0 {
+		mult = 2
+	}
+	for i := 1; i < attempt && d < max; i++ {
+		d = time.Duration(float64(d) * mult)
+	}
+	if d > max {
+		d = max
+	}
+	return time.Duration(float64(d) * (0.8 + 0.4*rand.Float64()))
+}
+
+// withRetry menjalankan call sesuai kebijakan pengulangan client. Jika canRetry tidak nil,
+// pengulangan juga hanya dilakukan selama canRetry mengembalikan true.
+func (c *Client) withRetry(ctx context.Context, call func() (ModelResponse, error), canRetry func() bool) (ModelResponse, error) {
+	c.mu.RLock()
+	policy := c.retry
+	c.mu.RUnlock()
+	if p, ok := ctx.Value(callRetryKey{}).(*RetryPolicy); ok {
+		policy = p
+	}
+	resp, err := call()
+	if policy == nil {
+		return resp, err
+	}
+	retryable := policy.Retryable
+	if retryable == nil {
+		retryable = DefaultRetryable
+	}
+	for attempt := 1; err != nil && attempt < policy.MaxAttempts && retryable(err); attempt++ {
+		if canRetry != nil && !canRetry() {
+			break
+		}
+		wait := policy.backoff(attempt)
+		if d, ok := RetryAfter(err); ok && d > wait {
+			wait = d
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return resp, err
+		}
+		c.logFor(ctx).Info("retrying model call", logging.KeyComponent, "llm", "attempt", attempt+1, logging.KeyError, err)
+		if rec := UsageRecorderFromContext(ctx); rec != nil {
+			rec.AddRetry()
+		}
+		resp, err = call()
+	}
+	return resp, err
+}
+
+// runHooks memanggil call di antara hook BeforeGenerate dan AfterGenerate
+func (c *Client) runHooks(ctx context.Context, model string, req ModelRequest, call func() (ModelResponse, error)) (ModelResponse, error) {
+	c.mu.RLock()
+	hooks := c.hooks
+	c.mu.RUnlock()
+	if extra, ok := ctx.Value(callHooksKey{}).([]Hooks); ok {
+		hooks = append(hooks[:len(hooks):len(hooks)], extra...)
+	}
+	for _, h := range hooks {
+		if h.BeforeGenerate != nil {
+			h.BeforeGenerate(ctx, model, req)
+		}
+	}
+	start := time.Now()
+	resp, err := call()
+	for _, h := range hooks {
+		if h.AfterGenerate != nil {
+			h.AfterGenerate(ctx, model, req, resp, err, time.Since(start))
+		}
+	}
+	return resp, err
+}
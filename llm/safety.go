@@ -0,0 +1,52 @@
+package llm
+
+// HarmCategory adalah kategori bahaya yang dinilai filter keamanan Gemini
+type HarmCategory string
+
+const (
+	HarmCategoryHarassment       HarmCategory = "HARM_CATEGORY_HARASSMENT"
+	HarmCategoryHateSpeech       HarmCategory = "HARM_CATEGORY_HATE_SPEECH"
+	HarmCategorySexuallyExplicit HarmCategory = "HARM_CATEGORY_SEXUALLY_EXPLICIT"
+	HarmCategoryDangerousContent HarmCategory = "HARM_CATEGORY_DANGEROUS_CONTENT"
+	HarmCategoryCivicIntegrity   HarmCategory = "HARM_CATEGORY_CIVIC_INTEGRITY"
+)
+
+// HarmBlockThreshold menentukan mulai probabilitas berapa konten diblokir
+type HarmBlockThreshold string
+
+const (
+	BlockLowAndAbove    HarmBlockThreshold = "BLOCK_LOW_AND_ABOVE"
+	BlockMediumAndAbove HarmBlockThreshold = "BLOCK_MEDIUM_AND_ABOVE"
+	BlockOnlyHigh       HarmBlockThreshold = "BLOCK_ONLY_HIGH"
+	BlockNone           HarmBlockThreshold = "BLOCK_NONE"
+	// BlockOff mematikan filter keamanan untuk kategori tersebut
+	BlockOff HarmBlockThreshold = "OFF"
+)
+
+// SafetySetting mengatur ambang pemblokiran untuk satu kategori bahaya
+type SafetySetting struct {
+	Category  HarmCategory       `json:"category"`
+	Threshold HarmBlockThreshold `json:"threshold"`
+}
+
+// mergeSafetySettings menggabungkan pengaturan bawaan model dengan pengaturan permintaan;
+// pengaturan permintaan menimpa kategori yang sama
+func mergeSafetySettings(base, override []SafetySetting) []SafetySetting {
+	if len(override) == 0 {
+		return base
+	}
+	out := make([]SafetySetting, 0, len(base)+len(override))
+	for _, s := range base {
+		overridden := false
+		for _, o := range override {
+			if o.Category == s.Category {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			out = append(out, s)
+		}
+	}
+	return append(out, override...)
+}
@@ -0,0 +1,195 @@
+package llm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/zakirkun/gatot-kaca/budget"
+)
+
+// StreamHandler dipanggil untuk setiap potongan teks yang diterima dari model.
+// Mengembalikan error akan menghentikan streaming.
+type StreamHandler func(chunk string) error
+
+// StreamingModel adalah Model yang dapat mengirim respons secara bertahap
+type StreamingModel interface {
+	Model
+	// GenerateStream menghasilkan respons sambil memanggil handler untuk setiap potongan teks.
+	// ModelResponse yang dikembalikan berisi teks lengkap.
+	GenerateStream(ctx context.Context, req ModelRequest, handler StreamHandler) (ModelResponse, error)
+}
+
+// GenerateStream menggunakan model tertentu untuk menghasilkan respons secara streaming.
+// Jika model tidak mendukung streaming, respons lengkap dikirim sebagai satu potongan.
+func (c *Client) GenerateStream(ctx context.Context, modelName string, req ModelRequest, handler StreamHandler) (ModelResponse, error) {
+	req = c.applyDefaults(modelName, req)
+	model, err := c.GetModel(modelName)
+	if err != nil {
+		return ModelResponse{}, err
+	}
+
+	sm, ok := model.(StreamingModel)
+	if !ok {
+		resp, err := c.Generate(ctx, modelName, req)
+		if err != nil {
+			return resp, err
+		}
+		if resp.Text != "" {
+			if err := handler(resp.Text); err != nil {
+				return resp, err
+			}
+		}
+		return resp, nil
+	}
+
+	if err := c.checkContext(modelName, model, req); err != nil {
+		return ModelResponse{}, err
+	}
+	run := budget.FromContext(ctx)
+	if err := run.BeginLLMCall(); err != nil {
+		return ModelResponse{}, err
+	}
+
+	// Pengulangan hanya aman selama belum ada potongan yang dikirim ke handler
+	started := false
+	start := time.Now()
+	resp, err := c.runHooks(ctx, modelName, req, func() (ModelResponse, error) {
+		return c.withRetry(ctx, func() (ModelResponse, error) {
+			return sm.GenerateStream(ctx, req, func(chunk string) error {
+				started = true
+				return handler(chunk)
+			})
+		}, func() bool { return !started })
+	})
+	c.usage.record(c.servingName(modelName), resp.Usage, err)
+	c.logCall(ctx, modelName, start, resp, err)
+	run.AddTokens(resp.Usage.TotalTokens)
+	if err != nil {
+		return resp, err
+	}
+
+	// Catat penggunaan token jika context membawa UsageRecorder
+	if rec := UsageRecorderFromContext(ctx); rec != nil {
+		rec.Add(resp.Usage)
+	}
+
+	return resp, nil
+}
+
+// openAIStreamChunk adalah satu event SSE dari API chat completions OpenAI
+type openAIStreamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+		FinishReason *string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *OpenAIUsage `json:"usage"`
+}
+
+// GenerateStream mengimplementasikan interface StreamingModel untuk OpenAI
+func (m *OpenAIModel) GenerateStream(ctx context.Context, req ModelRequest, handler StreamHandler) (ModelResponse, error) {
+	openAIReq := m.buildRequest(req)
+	openAIReq.Stream = true
+	openAIReq.StreamOptions = &OpenAIStreamOptions{IncludeUsage: true}
+
+	// Serialize request body
+	reqBody, err := json.Marshal(openAIReq)
+	if err != nil {
+		return ModelResponse{}, err
+	}
+
+	// Buat HTTP request
+	httpReq, err := http.NewRequestWithContext(
+		ctx,
+		"POST",
+		fmt.Sprintf("%s/chat/completions", m.baseURL),
+		strings.NewReader(string(reqBody)),
+	)
+	if err != nil {
+		return ModelResponse{}, err
+	}
+
+	// Set headers
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "text/event-stream")
+	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", m.apiKey))
+
+	// Kirim request
+	client := &http.Client{}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return ModelResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	// Periksa status code
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		return ModelResponse{}, newAPIError(OpenAI, resp, respBody)
+	}
+
+	result := ModelResponse{
+		ModelName: m.modelName,
+		Provider:  OpenAI,
+	}
+	var text strings.Builder
+
+	// Baca event SSE baris demi baris
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk openAIStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return ModelResponse{}, fmt.Errorf("gagal mem-parse potongan stream: %w", err)
+		}
+		if chunk.Usage != nil {
+			result.Usage = chunk.Usage.usage()
+		}
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+		if fr := chunk.Choices[0].FinishReason; fr != nil {
+			result.FinishType = *fr
+		}
+		if content := chunk.Choices[0].Delta.Content; content != "" {
+			text.WriteString(content)
+			if err := handler(content); err != nil {
+				return ModelResponse{}, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return ModelResponse{}, err
+	}
+
+	if result.FinishType == "content_filter" {
+		return ModelResponse{}, &ContentFilterError{
+			Provider: OpenAI,
+			Model:    m.modelName,
+			Reason:   "content_filter",
+		}
+	}
+	if text.Len() == 0 && result.FinishType == "" {
+		return ModelResponse{}, errors.New("tidak ada respons dari model")
+	}
+
+	result.Text = text.String()
+	return result, nil
+}
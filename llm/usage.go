@@ -0,0 +1,87 @@
+package llm
+
+import (
+	"context"
+	"sync"
+)
+
+// UsageRecorder mengakumulasi penggunaan token dari semua panggilan Client.Generate
+// yang dijalankan dengan context yang membawa recorder ini
+type UsageRecorder struct {
+	mu      sync.Mutex
+	usage   Usage
+	calls   int
+	retries int
+	parent  *UsageRecorder
+}
+
+type usageRecorderKey struct{}
+
+// NewUsageRecorder membuat UsageRecorder kosong
+func NewUsageRecorder() *UsageRecorder {
+	return &UsageRecorder{}
+}
+
+// ContextWithUsageRecorder mengembalikan context yang membawa recorder. Jika context
+// sudah membawa recorder lain, penggunaan juga diteruskan ke recorder tersebut.
+func ContextWithUsageRecorder(ctx context.Context, r *UsageRecorder) context.Context {
+	if parent := UsageRecorderFromContext(ctx); parent != nil && parent != r {
+		r.mu.Lock()
+		r.parent = parent
+		r.mu.Unlock()
+	}
+	return context.WithValue(ctx, usageRecorderKey{}, r)
+}
+
+// UsageRecorderFromContext mengambil recorder dari context, atau nil jika tidak ada
+func UsageRecorderFromContext(ctx context.Context) *UsageRecorder {
+	r, _ := ctx.Value(usageRecorderKey{}).(*UsageRecorder)
+	return r
+}
+
+// Add menambahkan penggunaan token satu panggilan ke recorder dan induknya
+func (r *UsageRecorder) Add(u Usage) {
+	r.mu.Lock()
+	r.usage = r.usage.Add(u)
+	r.calls++
+	parent := r.parent
+	r.mu.Unlock()
+
+	if parent != nil {
+		parent.Add(u)
+	}
+}
+
+// Usage mengembalikan total penggunaan token yang tercatat
+func (r *UsageRecorder) Usage() Usage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.usage
+}
+
+// Calls mengembalikan jumlah panggilan yang tercatat
+func (r *UsageRecorder) Calls() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.calls
+}
+
+// AddRetry mencatat satu percobaan ulang (oleh RetryPolicy client atau node retry workflow)
+// ke recorder dan induknya
+func (r *UsageRecorder) AddRetry() {
+	r.mu.Lock()
+	r.retries++
+	parent := r.parent
+	r.mu.Unlock()
+
+	if parent != nil {
+		parent.AddRetry()
+	}
+}
+
+// Retries mengembalikan jumlah percobaan ulang yang tercatat
+func (r *UsageRecorder) Retries() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.retries
+}
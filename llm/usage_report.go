@@ -0,0 +1,108 @@
+package llm
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ModelUsage adalah akumulasi penggunaan satu model
+type ModelUsage struct {
+	Usage
+	Requests int // Jumlah panggilan Generate dan GenerateStream
+	Errors   int // Jumlah panggilan yang gagal
+}
+
+func (u *ModelUsage) add(o ModelUsage) {
+	u.Usage = u.Usage.Add(o.Usage)
+	u.Requests += o.Requests
+	u.Errors += o.Errors
+}
+
+// UsageReport adalah ringkasan penggunaan client sejak Since
+type UsageReport struct {
+	Since  time.Time
+	Until  time.Time
+	Models map[string]ModelUsage // Per nama model yang melayani panggilan
+	Total  ModelUsage
+}
+
+// usageTracker mengakumulasi penggunaan per model untuk Client.Usage
+type usageTracker struct {
+	mu     sync.Mutex
+	since  time.Time
+	models map[string]*ModelUsage
+}
+
+func (t *usageTracker) record(name string, u Usage, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.models == nil {
+		t.models = make(map[string]*ModelUsage)
+	}
+	m, ok := t.models[name]
+	if !ok {
+		m = &ModelUsage{}
+		t.models[name] = m
+	}
+	entry := ModelUsage{Usage: u, Requests: 1}
+	if err != nil {
+		entry.Errors = 1
+	}
+	m.add(entry)
+}
+
+func (t *usageTracker) snapshot(reset bool) UsageReport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	report := UsageReport{Since: t.since, Until: now, Models: make(map[string]ModelUsage, len(t.models))}
+	for name, m := range t.models {
+		report.Models[name] = *m
+		report.Total.add(*m)
+	}
+	if reset {
+		t.models = nil
+		t.since = now
+	}
+	return report
+}
+
+// Usage mengembalikan penggunaan token, jumlah panggilan, dan jumlah error per model sejak client
+// dibuat atau sejak ResetUsage terakhir, tanpa perlu memasang UsageRecorder di setiap pemanggil
+func (c *Client) Usage() UsageReport {
+	return c.usage.snapshot(false)
+}
+
+// ResetUsage mengembalikan penggunaan seperti Usage lalu mengosongkannya
+func (c *Client) ResetUsage() UsageReport {
+	return c.usage.snapshot(true)
+}
+
+// ReportUsage memanggil fn dengan ringkasan penggunaan setiap interval sampai ctx dibatalkan,
+// mis. untuk mengirim metrik. Jika reset true, setiap ringkasan hanya berisi penggunaan sejak
+// ringkasan sebelumnya.
+func (c *Client) ReportUsage(ctx context.Context, interval time.Duration, reset bool, fn func(UsageReport)) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				fn(c.usage.snapshot(reset))
+			}
+		}
+	}()
+}
+
+// servingName mengembalikan nama model yang akan melayani panggilan ke modelName, yaitu nama
+// model fallback jika model tersebut dipakai sebagai gantinya
+func (c *Client) servingName(modelName string) string {
+	model, substituted, err := c.getModel(modelName)
+	if err == nil && substituted {
+		return model.GetModelName()
+	}
+	return modelName
+}
@@ -0,0 +1,61 @@
+package llmtest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/zakirkun/gatot-kaca/llm"
+)
+
+func TestMockModelScript(t *testing.T) {
+	model := NewMockModel("test", "first", "second").On("weather", "sunny")
+	a := NewAgent(model)
+	ctx := context.Background()
+
+	for _, tc := range []struct{ input, want string }{
+		{"hello", "first"},
+		{"what is the weather?", "sunny"},
+		{"again", "second"},
+	} {
+		a.Reset()
+		got, err := a.Send(ctx, tc.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("Send(%q) = %q, want %q", tc.input, got, tc.want)
+		}
+	}
+	if _, err := a.Send(ctx, "more"); !errors.Is(err, ErrNoResponse) {
+		t.Errorf("expected ErrNoResponse, got %v", err)
+	}
+	model.AssertCalled(t, 4)
+	model.AssertPromptContains(t, "weather")
+	model.AssertExhausted(t)
+}
+
+func TestMockModelLatencyHonorsContext(t *testing.T) {
+	model := NewMockModel("slow", "late").WithLatency(time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := NewClient(model).Generate(ctx, "slow", llm.ModelRequest{Prompt: "hi"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestMockTool(t *testing.T) {
+	tool := NewMockTool("search", "result").Fail(errors.New("down"))
+	a := NewAgent(NewMockModel("test"), tool)
+	ctx := context.Background()
+
+	if out, err := a.CallTool(ctx, "search", "golang"); err != nil || out != "result" {
+		t.Errorf("CallTool = %q, %v", out, err)
+	}
+	if _, err := a.CallTool(ctx, "search", "again"); err == nil {
+		t.Error("expected scripted error")
+	}
+	tool.AssertCalled(t, 2)
+	tool.AssertCalledWith(t, "golang")
+}
@@ -0,0 +1,339 @@
+// Package llmtest provides scriptable mock models and tools for testing agents and workflows
+// without calling LLM APIs.
+//
+//	model := llmtest.NewMockModel("gpt-test", "first answer", "second answer")
+//	model.On("weather", "It is sunny.")
+//	a := llmtest.NewAgent(model, llmtest.NewMockTool("search", "result"))
+//	...
+//	model.AssertCalled(t, 2)
+package llmtest
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zakirkun/gatot-kaca/agent"
+	"github.com/zakirkun/gatot-kaca/agent/tools"
+	"github.com/zakirkun/gatot-kaca/llm"
+	"github.com/zakirkun/gatot-kaca/tokenizer"
+)
+
+// ErrNoResponse is returned by a MockModel with no scripted response left and no default.
+var ErrNoResponse = errors.New("llmtest: no scripted response")
+
+// Provider is the provider reported by mock models.
+const Provider llm.ModelProvider = "mock"
+
+// Handler computes the response of a mock model to a request.
+type Handler func(ctx context.Context, req llm.ModelRequest) (llm.ModelResponse, error)
+
+// rule answers prompts containing a substring.
+type rule struct {
+	contains string
+	handler  Handler
+}
+
+// MockModel is an llm.Model and llm.StreamingModel returning scripted responses. Responses are
+// chosen in this order: the first rule matching the prompt, the next queued response, then the
+// default. It is safe for concurrent use and records every request.
+type MockModel struct {
+	name string
+
+	mu        sync.Mutex
+	queue     []Handler
+	rules     []rule
+	fallback  Handler
+	latency   time.Duration
+	embedding func(text string) []float64
+	calls     []llm.ModelRequest
+	embedded  []string
+}
+
+// NewMockModel creates a mock model that answers with the given responses in order.
+func NewMockModel(name string, responses ...string) *MockModel {
+	return (&MockModel{name: name}).Respond(responses...)
+}
+
+// Text returns a Handler answering with text. Token usage is estimated from the prompt and text.
+func Text(text string) Handler {
+	return func(ctx context.Context, req llm.ModelRequest) (llm.ModelResponse, error) {
+		prompt := tokenizer.Estimate(req.Prompt)
+		completion := tokenizer.Estimate(text)
+		return llm.ModelResponse{
+			Text:       text,
+			Usage:      llm.Usage{PromptTokens: prompt, CompletionTokens: completion, TotalTokens: prompt + completion},
+			FinishType: "stop",
+		}, nil
+	}
+}
+
+// Error returns a Handler failing with err.
+func Error(err error) Handler {
+	return func(ctx context.Context, req llm.ModelRequest) (llm.ModelResponse, error) {
+		return llm.ModelResponse{}, err
+	}
+}
+
+// Respond queues text responses.
+func (m *MockModel) Respond(responses ...string) *MockModel {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, text := range responses {
+		m.queue = append(m.queue, Text(text))
+	}
+	return m
+}
+
+// RespondWith queues a response computed by a handler.
+func (m *MockModel) RespondWith(h Handler) *MockModel {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.queue = append(m.queue, h)
+	return m
+}
+
+// Fail queues an error.
+func (m *MockModel) Fail(err error) *MockModel {
+	return m.RespondWith(Error(err))
+}
+
+// On answers every prompt containing substr with text, before queued responses are used.
+func (m *MockModel) On(substr, text string) *MockModel {
+	return m.OnWith(substr, Text(text))
+}
+
+// OnWith answers every prompt containing substr with a handler.
+func (m *MockModel) OnWith(substr string, h Handler) *MockModel {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rules = append(m.rules, rule{contains: substr, handler: h})
+	return m
+}
+
+// Default sets the response used once the queue is exhausted.
+func (m *MockModel) Default(text string) *MockModel {
+	return m.DefaultWith(Text(text))
+}
+
+// DefaultWith sets the handler used once the queue is exhausted, e.g. one echoing the prompt.
+func (m *MockModel) DefaultWith(h Handler) *MockModel {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fallback = h
+	return m
+}
+
+// WithLatency delays every response, honoring context cancellation.
+func (m *MockModel) WithLatency(d time.Duration) *MockModel {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.latency = d
+	return m
+}
+
+// WithEmbedding sets the function computing embeddings. By default a deterministic 8-dimension
+// vector derived from the words of the text is returned.
+func (m *MockModel) WithEmbedding(fn func(text string) []float64) *MockModel {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.embedding = fn
+	return m
+}
+
+// Generate implements llm.Model.
+func (m *MockModel) Generate(ctx context.Context, req llm.ModelRequest) (llm.ModelResponse, error) {
+	m.mu.Lock()
+	m.calls = append(m.calls, req)
+	h := m.next(req.Prompt)
+	latency := m.latency
+	m.mu.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-ctx.Done():
+			return llm.ModelResponse{}, ctx.Err()
+		}
+	}
+	if h == nil {
+		return llm.ModelResponse{}, ErrNoResponse
+	}
+	resp, err := h(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+	if resp.ModelName == "" {
+		resp.ModelName = m.name
+	}
+	if resp.Provider == "" {
+		resp.Provider = Provider
+	}
+	return resp, nil
+}
+
+// next picks the handler for a prompt. m.mu must be held.
+func (m *MockModel) next(prompt string) Handler {
+	for _, r := range m.rules {
+		if strings.Contains(prompt, r.contains) {
+			return r.handler
+		}
+	}
+	if len(m.queue) > 0 {
+		h := m.queue[0]
+		m.queue = m.queue[1:]
+		return h
+	}
+	return m.fallback
+}
+
+// GenerateStream implements llm.StreamingModel, sending the response word by word.
+func (m *MockModel) GenerateStream(ctx context.Context, req llm.ModelRequest, handler llm.StreamHandler) (llm.ModelResponse, error) {
+	resp, err := m.Generate(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+	for _, chunk := range strings.SplitAfter(resp.Text, " ") {
+		if chunk == "" {
+			continue
+		}
+		if err := handler(chunk); err != nil {
+			return resp, err
+		}
+	}
+	return resp, nil
+}
+
+// GenerateEmbedding implements llm.Model.
+func (m *MockModel) GenerateEmbedding(ctx context.Context, text string) ([]float64, error) {
+	m.mu.Lock()
+	m.embedded = append(m.embedded, text)
+	fn := m.embedding
+	m.mu.Unlock()
+	if fn != nil {
+		return fn(text), nil
+	}
+	return hashEmbedding(text), nil
+}
+
+// hashEmbedding returns a normalized bag-of-words vector, so that texts sharing words are similar.
+func hashEmbedding(text string) []float64 {
+	v := make([]float64, 8)
+	for _, w := range strings.Fields(strings.ToLower(text)) {
+		h := fnv.New32a()
+		h.Write([]byte(w))
+		v[h.Sum32()%8]++
+	}
+	var norm float64
+	for _, x := range v {
+		norm += x * x
+	}
+	if norm > 0 {
+		norm = math.Sqrt(norm)
+		for i := range v {
+			v[i] /= norm
+		}
+	}
+	return v
+}
+
+// GetProvider implements llm.Model.
+func (m *MockModel) GetProvider() llm.ModelProvider { return Provider }
+
+// GetModelName implements llm.Model.
+func (m *MockModel) GetModelName() string { return m.name }
+
+// Calls returns the requests received so far.
+func (m *MockModel) Calls() []llm.ModelRequest {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]llm.ModelRequest(nil), m.calls...)
+}
+
+// CallCount returns the number of Generate calls.
+func (m *MockModel) CallCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.calls)
+}
+
+// LastPrompt returns the prompt of the most recent request, or "" if there was none.
+func (m *MockModel) LastPrompt() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.calls) == 0 {
+		return ""
+	}
+	return m.calls[len(m.calls)-1].Prompt
+}
+
+// Embedded returns the texts passed to GenerateEmbedding so far.
+func (m *MockModel) Embedded() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.embedded...)
+}
+
+// Reset clears the recorded calls, keeping the script.
+func (m *MockModel) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls, m.embedded = nil, nil
+}
+
+// AssertCalled fails the test unless Generate was called exactly n times.
+func (m *MockModel) AssertCalled(t testing.TB, n int) {
+	t.Helper()
+	if got := m.CallCount(); got != n {
+		t.Errorf("%s: expected %d calls, got %d", m.name, n, got)
+	}
+}
+
+// AssertPromptContains fails the test unless some request's prompt contains substr.
+func (m *MockModel) AssertPromptContains(t testing.TB, substr string) {
+	t.Helper()
+	for _, req := range m.Calls() {
+		if strings.Contains(req.Prompt, substr) {
+			return
+		}
+	}
+	t.Errorf("%s: no prompt contains %q", m.name, substr)
+}
+
+// AssertExhausted fails the test if queued responses were not used.
+func (m *MockModel) AssertExhausted(t testing.TB) {
+	t.Helper()
+	m.mu.Lock()
+	left := len(m.queue)
+	m.mu.Unlock()
+	if left > 0 {
+		t.Errorf("%s: %d scripted responses were not used", m.name, left)
+	}
+}
+
+// NewClient returns an llm.Client with the models registered under their names. The first model
+// is also the fallback.
+func NewClient(models ...*MockModel) *llm.Client {
+	client := llm.NewClient()
+	for i, m := range models {
+		client.AddModel(m.name, m)
+		if i == 0 {
+			client.SetFallbackModel(m)
+		}
+	}
+	return client
+}
+
+// NewAgent returns an agent using the model through a new client, with the tools registered.
+func NewAgent(model *MockModel, ts ...tools.Tool) *agent.Agent {
+	a := agent.NewAgent(NewClient(model), model.name)
+	for _, t := range ts {
+		a.RegisterTool(t)
+	}
+	return a
+}
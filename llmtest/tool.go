@@ -0,0 +1,106 @@
+package llmtest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// ToolHandler computes the output of a mock tool.
+type ToolHandler func(ctx context.Context, input string) (string, error)
+
+// MockTool is a tools.Tool returning scripted outputs: queued outputs in order, then the
+// default. It is safe for concurrent use and records every input.
+type MockTool struct {
+	ToolName string
+	Desc     string
+
+	mu       sync.Mutex
+	queue    []ToolHandler
+	fallback ToolHandler
+	calls    []string
+}
+
+// NewMockTool creates a mock tool that returns the given outputs in order.
+func NewMockTool(name string, outputs ...string) *MockTool {
+	return (&MockTool{ToolName: name, Desc: "Mock tool " + name}).Respond(outputs...)
+}
+
+// Respond queues outputs.
+func (t *MockTool) Respond(outputs ...string) *MockTool {
+	for _, out := range outputs {
+		out := out
+		t.RespondWith(func(ctx context.Context, input string) (string, error) { return out, nil })
+	}
+	return t
+}
+
+// RespondWith queues an output computed by a handler.
+func (t *MockTool) RespondWith(h ToolHandler) *MockTool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.queue = append(t.queue, h)
+	return t
+}
+
+// Fail queues an error.
+func (t *MockTool) Fail(err error) *MockTool {
+	return t.RespondWith(func(ctx context.Context, input string) (string, error) { return "", err })
+}
+
+// Default sets the handler used once the queue is exhausted.
+func (t *MockTool) Default(h ToolHandler) *MockTool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.fallback = h
+	return t
+}
+
+// Name implements tools.Tool.
+func (t *MockTool) Name() string { return t.ToolName }
+
+// Description implements tools.Tool.
+func (t *MockTool) Description() string { return t.Desc }
+
+// Execute implements tools.Tool.
+func (t *MockTool) Execute(ctx context.Context, input string) (string, error) {
+	t.mu.Lock()
+	t.calls = append(t.calls, input)
+	h := t.fallback
+	if len(t.queue) > 0 {
+		h = t.queue[0]
+		t.queue = t.queue[1:]
+	}
+	t.mu.Unlock()
+	if h == nil {
+		return "", errors.New("llmtest: no scripted output for tool " + t.ToolName)
+	}
+	return h(ctx, input)
+}
+
+// Calls returns the inputs received so far.
+func (t *MockTool) Calls() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string(nil), t.calls...)
+}
+
+// AssertCalled fails the test unless the tool was called exactly n times.
+func (t *MockTool) AssertCalled(tb testing.TB, n int) {
+	tb.Helper()
+	if got := len(t.Calls()); got != n {
+		tb.Errorf("tool %s: expected %d calls, got %d", t.ToolName, n, got)
+	}
+}
+
+// AssertCalledWith fails the test unless the tool was called with input.
+func (t *MockTool) AssertCalledWith(tb testing.TB, input string) {
+	tb.Helper()
+	for _, in := range t.Calls() {
+		if in == input {
+			return
+		}
+	}
+	tb.Errorf("tool %s: never called with %q", t.ToolName, input)
+}
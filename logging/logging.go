@@ -0,0 +1,118 @@
+// Package logging defines the Logger through which gatot-kaca packages report what they do,
+// with levels and structured fields, instead of printing to stdout.
+//
+// *slog.Logger implements Logger, so any slog handler can be used:
+//
+//	logger := slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+//	logging.SetDefault(logger)
+//
+// Components such as llm.Client, agent.Agent, tools.Manager and workflow.Flow can also be given
+// their own logger, which they pass on to the calls they make through the context. Without one
+// they use the logger of the context, and otherwise Default.
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+)
+
+// Logger logs messages at four levels. The args are alternating keys and values, as with
+// log/slog, using the Key constants for the common fields.
+type Logger interface {
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// Keys of the structured fields used across the packages.
+const (
+	KeyComponent = "component" // The package or type logging, e.g. "agent" or "workflow".
+	KeyModel     = "model"
+	KeyNode      = "node"
+	KeyTool      = "tool"
+	KeyDuration  = "duration"
+	KeyTokens    = "tokens"
+	KeyError     = "error"
+)
+
+// NewSlog returns a Logger writing to h.
+func NewSlog(h slog.Handler) Logger {
+	return slog.New(h)
+}
+
+// Standard returns a Logger that passes messages at level and above to slog.Default, which
+// writes through the standard log package unless it was replaced with slog.SetDefault.
+func Standard(level slog.Level) Logger {
+	return standard{level: level}
+}
+
+type standard struct {
+	level slog.Level
+}
+
+func (l standard) log(level slog.Level, msg string, args []any) {
+	if level >= l.level {
+		slog.Default().Log(context.Background(), level, msg, args...)
+	}
+}
+
+func (l standard) Debug(msg string, args ...any) { l.log(slog.LevelDebug, msg, args) }
+func (l standard) Info(msg string, args ...any)  { l.log(slog.LevelInfo, msg, args) }
+func (l standard) Warn(msg string, args ...any)  { l.log(slog.LevelWarn, msg, args) }
+func (l standard) Error(msg string, args ...any) { l.log(slog.LevelError, msg, args) }
+
+// Discard returns a Logger that drops every message.
+func Discard() Logger {
+	return discard{}
+}
+
+type discard struct{}
+
+func (discard) Debug(string, ...any) {}
+func (discard) Info(string, ...any)  {}
+func (discard) Warn(string, ...any)  {}
+func (discard) Error(string, ...any) {}
+
+// holder lets a Logger of any dynamic type be stored in an atomic.Value.
+type holder struct {
+	Logger
+}
+
+var defaultLogger atomic.Value
+
+func init() {
+	defaultLogger.Store(holder{Standard(slog.LevelWarn)})
+}
+
+// Default returns the Logger used when no other is given. Unless changed with SetDefault, it
+// logs warnings and errors through slog.Default.
+func Default() Logger {
+	return defaultLogger.Load().(holder).Logger
+}
+
+// SetDefault replaces the default Logger; nil discards all messages.
+func SetDefault(l Logger) {
+	if l == nil {
+		l = Discard()
+	}
+	defaultLogger.Store(holder{l})
+}
+
+type contextKey struct{}
+
+// NewContext returns a context carrying l, used by the calls made with it.
+func NewContext(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the Logger of ctx, or Default if it has none.
+func FromContext(ctx context.Context) Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(contextKey{}).(Logger); ok && l != nil {
+			return l
+		}
+	}
+	return Default()
+}
@@ -0,0 +1,48 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/zakirkun/gatot-kaca/agent/tools"
+	"github.com/zakirkun/gatot-kaca/logging"
+)
+
+func TestContextLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logging.NewSlog(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	ctx := logging.NewContext(context.Background(), logger)
+
+	logging.FromContext(ctx).Debug("model call", logging.KeyModel, "gpt-4o", logging.KeyTokens, 42)
+	if out := buf.String(); !strings.Contains(out, "level=DEBUG") || !strings.Contains(out, "model=gpt-4o") || !strings.Contains(out, "tokens=42") {
+		t.Errorf("unexpected log output: %q", out)
+	}
+	if logging.FromContext(context.Background()) != logging.Default() {
+		t.Error("a context without a logger should use the default logger")
+	}
+}
+
+func TestSetDefault(t *testing.T) {
+	defer logging.SetDefault(logging.Default())
+
+	var buf bytes.Buffer
+	logging.SetDefault(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	tools.NewManager().RegisterTool(nopTool{})
+	if !strings.Contains(buf.String(), "tool=nop") {
+		t.Errorf("tool registration not logged to the default logger: %q", buf.String())
+	}
+
+	logging.SetDefault(nil)
+	logging.Default().Error("dropped")
+}
+
+type nopTool struct{}
+
+func (nopTool) Name() string        { return "nop" }
+func (nopTool) Description() string { return "Does nothing." }
+func (nopTool) Execute(ctx context.Context, input string) (string, error) {
+	return "", nil
+}
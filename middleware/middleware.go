@@ -0,0 +1,253 @@
+// Package middleware provides ready-made agent middlewares: history truncation, PII redaction,
+// prompt-injection detection, profanity filtering, language detection with translation, and
+// logging with redaction. WithStandardMiddlewares registers a sensible set of them at once.
+//
+// All middlewares implement agent.RequestMiddleware and are registered with Agent.Use or
+// agent.WithRequestMiddlewares. Unless noted otherwise they hold no per-request state and may be
+// shared by agents.
+package middleware
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/zakirkun/gatot-kaca/agent"
+	"github.com/zakirkun/gatot-kaca/guardrails"
+	"github.com/zakirkun/gatot-kaca/logging"
+)
+
+// DefaultBlockMessage is the reply to requests halted by a middleware.
+const DefaultBlockMessage = "I'm sorry, but I can't help with that request."
+
+// Request context keys set by the middlewares in this package.
+const (
+	InjectionKey  = "prompt_injection" // []guardrails.Violation found in the user's message.
+	LanguageKey   = "language"         // Detected language code of the user's message.
+	startKey      = "middleware.start"
+	translatedKey = "middleware.translated"
+)
+
+// latestUser returns the index of the latest user message, or -1.
+func latestUser(messages []agent.ConversationMessage) int {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "User" {
+			return i
+		}
+	}
+	return -1
+}
+
+// Truncate limits the conversation sent to the model to the latest messages, keeping the leading
+// system prompt. Unlike an agent.Memory it applies after the system prompt and the other
+// middlewares' changes, so it bounds the final prompt.
+type Truncate struct {
+	MaxMessages int // Maximum number of non-system messages; zero means no limit.
+	// MaxTokens bounds the non-system messages by their token count for Model (see
+	// agent.TokenWindowMemory); zero means no limit.
+	MaxTokens int
+	Model     string
+}
+
+// BeforeSend implements agent.RequestMiddleware.
+func (t Truncate) BeforeSend(ctx context.Context, rc *agent.RequestContext) error {
+	var system []agent.ConversationMessage
+	rest := rc.Messages
+	for len(rest) > 0 && rest[0].Role == "System" {
+		system = append(system, rest[0])
+		rest = rest[1:]
+	}
+	rest = agent.WindowMemory{MaxMessages: t.MaxMessages}.Messages(rest)
+	rest = agent.TokenWindowMemory{Model: t.Model, MaxTokens: t.MaxTokens}.Messages(rest)
+	rc.Messages = append(system, rest...)
+	return nil
+}
+
+// ModifiesResponse implements agent.ResponseModifier.
+func (Truncate) ModifiesResponse() bool { return false }
+
+// AfterReceive implements agent.RequestMiddleware.
+func (Truncate) AfterReceive(ctx context.Context, rc *agent.RequestContext) error {
+	return nil
+}
+
+// RedactPII replaces personally identifiable information in the user's messages with
+// placeholders such as [EMAIL] before they are sent to the model. The agent's stored history
+// keeps the original text.
+type RedactPII struct {
+	Types  []guardrails.PIIType // Types to redact; all types when empty.
+	Output bool                 // Also redact the model's responses.
+}
+
+func (r RedactPII) guard() *guardrails.Guard {
+	return guardrails.New(guardrails.Rule{Validator: &guardrails.PII{Types: r.Types}, Action: guardrails.Redact})
+}
+
+// BeforeSend implements agent.RequestMiddleware.
+func (r RedactPII) BeforeSend(ctx context.Context, rc *agent.RequestContext) error {
+	g := r.guard()
+	for i := range rc.Messages {
+		if rc.Messages[i].Role != "User" {
+			continue
+		}
+		result, _ := g.Check(ctx, rc.Messages[i].Content)
+		rc.Messages[i].Content = result.Text
+	}
+	return nil
+}
+
+// ModifiesResponse implements agent.ResponseModifier.
+func (r RedactPII) ModifiesResponse() bool { return r.Output }
+
+// AfterReceive implements agent.RequestMiddleware.
+func (r RedactPII) AfterReceive(ctx context.Context, rc *agent.RequestContext) error {
+	if r.Output {
+		result, _ := r.guard().Check(ctx, rc.Response)
+		rc.Response = result.Text
+	}
+	return nil
+}
+
+// InjectionDetector checks the user's latest message for prompt-injection phrasings with
+// guardrails.PromptInjection. Detected attempts are halted with BlockMessage, or, with FlagOnly,
+// logged and recorded in the request context under InjectionKey.
+type InjectionDetector struct {
+	FlagOnly     bool
+	BlockMessage string // Defaults to DefaultBlockMessage.
+	Validator    *guardrails.PromptInjection
+}
+
+// BeforeSend implements agent.RequestMiddleware.
+func (d InjectionDetector) BeforeSend(ctx context.Context, rc *agent.RequestContext) error {
+	i := latestUser(rc.Messages)
+	if i < 0 {
+		return nil
+	}
+	v := d.Validator
+	if v == nil {
+		v = &guardrails.PromptInjection{}
+	}
+	result, err := guardrails.New(guardrails.Rule{Validator: v, Action: guardrails.Block}).Check(ctx, rc.Messages[i].Content)
+	if err == nil {
+		return nil
+	}
+	rc.Set(InjectionKey, result.Violations)
+	if d.FlagOnly {
+		logging.FromContext(ctx).Warn("prompt injection flagged", logging.KeyComponent, "middleware", logging.KeyError, err)
+		return nil
+	}
+	if d.BlockMessage != "" {
+		return agent.Halt(d.BlockMessage)
+	}
+	return agent.Halt(DefaultBlockMessage)
+}
+
+// ModifiesResponse implements agent.ResponseModifier.
+func (InjectionDetector) ModifiesResponse() bool { return false }
+
+// AfterReceive implements agent.RequestMiddleware.
+func (InjectionDetector) AfterReceive(ctx context.Context, rc *agent.RequestContext) error {
+	return nil
+}
+
+// ProfanityFilter masks profanity in the model's responses, and optionally in the user's
+// messages, with [PROFANITY].
+type ProfanityFilter struct {
+	Words []string // Words to mask; guardrails.DefaultProfanity when empty.
+	Input bool     // Also mask the user's messages before they are sent.
+
+	once      sync.Once
+	validator *guardrails.Profanity
+}
+
+// NewProfanityFilter creates a profanity filter for the given words.
+func NewProfanityFilter(words ...string) *ProfanityFilter {
+	return &ProfanityFilter{Words: words}
+}
+
+func (p *ProfanityFilter) mask(ctx context.Context, text string) string {
+	p.once.Do(func() { p.validator = &guardrails.Profanity{Words: p.Words} })
+	result, _ := guardrails.New(guardrails.Rule{Validator: p.validator, Action: guardrails.Redact}).Check(ctx, text)
+	return result.Text
+}
+
+// BeforeSend implements agent.RequestMiddleware.
+func (p *ProfanityFilter) BeforeSend(ctx context.Context, rc *agent.RequestContext) error {
+	if !p.Input {
+		return nil
+	}
+	for i := range rc.Messages {
+		if rc.Messages[i].Role == "User" {
+			rc.Messages[i].Content = p.mask(ctx, rc.Messages[i].Content)
+		}
+	}
+	return nil
+}
+
+// AfterReceive implements agent.RequestMiddleware.
+func (p *ProfanityFilter) AfterReceive(ctx context.Context, rc *agent.RequestContext) error {
+	rc.Response = p.mask(ctx, rc.Response)
+	return nil
+}
+
+// Logger logs each request's user message and the response with its latency, with personally
+// identifiable information redacted unless NoRedact is set.
+type Logger struct {
+	// Logger, if set, receives the logs; otherwise the logger of the context is used (see
+	// logging.FromContext).
+	Logger   logging.Logger
+	NoRedact bool
+	// MaxLength truncates the logged text to this many bytes; defaults to 200.
+	MaxLength int
+}
+
+// logFor returns the middleware's logger, or that of ctx if it has none.
+func (l Logger) logFor(ctx context.Context) logging.Logger {
+	if l.Logger != nil {
+		return l.Logger
+	}
+	return logging.FromContext(ctx)
+}
+
+// text prepares text for logging.
+func (l Logger) text(ctx context.Context, text string) string {
+	if !l.NoRedact {
+		result, _ := RedactPII{}.guard().Check(ctx, text)
+		text = result.Text
+	}
+	max := l.MaxLength
+	if max <= 0 {
+		max = 200
+	}
+	if len(text) > max {
+		text = text[:max] + "..."
+	}
+	return text
+}
+
+// BeforeSend implements agent.RequestMiddleware.
+func (l Logger) BeforeSend(ctx context.Context, rc *agent.RequestContext) error {
+	if _, ok := rc.Value(startKey); ok {
+		// The prompt is being rebuilt for the same request, e.g. after truncation.
+		return nil
+	}
+	rc.Set(startKey, time.Now())
+	l.logFor(ctx).Info("agent request", logging.KeyComponent, "middleware", logging.KeyModel, rc.Agent.ModelName(),
+		"input", l.text(ctx, rc.Input))
+	return nil
+}
+
+// ModifiesResponse implements agent.ResponseModifier.
+func (Logger) ModifiesResponse() bool { return false }
+
+// AfterReceive implements agent.RequestMiddleware.
+func (l Logger) AfterReceive(ctx context.Context, rc *agent.RequestContext) error {
+	var elapsed time.Duration
+	if start, ok := rc.Value(startKey); ok {
+		elapsed = time.Since(start.(time.Time))
+	}
+	l.logFor(ctx).Info("agent response", logging.KeyComponent, "middleware", logging.KeyModel, rc.Agent.ModelName(),
+		logging.KeyDuration, elapsed.Round(time.Millisecond), logging.KeyTokens, rc.Result.Usage.TotalTokens,
+		"response", l.text(ctx, rc.Response))
+	return nil
+}
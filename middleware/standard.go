@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"github.com/zakirkun/gatot-kaca/agent"
+	"github.com/zakirkun/gatot-kaca/logging"
+)
+
+// DefaultMaxMessages is the history limit of the standard middlewares when
+// StandardConfig.MaxMessages is zero.
+const DefaultMaxMessages = 50
+
+// StandardConfig configures WithStandardMiddlewares. The zero value enables logging, prompt
+// injection blocking, PII redaction, profanity filtering of responses and truncation to
+// DefaultMaxMessages messages; translation is enabled by setting Translate.
+type StandardConfig struct {
+	Logger       logging.Logger // Defaults to the logger of the context of each request.
+	BlockMessage string         // Reply to halted prompt injections; defaults to DefaultBlockMessage.
+	MaxMessages  int            // Defaults to DefaultMaxMessages; negative disables truncation.
+	MaxTokens    int            // Token budget of the history; zero means no limit.
+	Translate    *Translate     // Optional language detection and translation.
+
+	DisableLogging      bool
+	DisableInjection    bool
+	DisablePIIRedaction bool
+	DisableProfanity    bool
+}
+
+// Standard returns the standard middlewares for cfg in the order they run: logging, injection
+// detection, translation, PII redaction, profanity filtering and truncation.
+func Standard(cfg StandardConfig) []agent.RequestMiddleware {
+	var ms []agent.RequestMiddleware
+	if !cfg.DisableLogging {
+		ms = append(ms, Logger{Logger: cfg.Logger})
+	}
+	if !cfg.DisableInjection {
+		ms = append(ms, InjectionDetector{BlockMessage: cfg.BlockMessage})
+	}
+	if cfg.Translate != nil {
+		ms = append(ms, *cfg.Translate)
+	}
+	if !cfg.DisablePIIRedaction {
+		ms = append(ms, RedactPII{})
+	}
+	if !cfg.DisableProfanity {
+		ms = append(ms, &ProfanityFilter{})
+	}
+	maxMessages := cfg.MaxMessages
+	if maxMessages == 0 {
+		maxMessages = DefaultMaxMessages
+	}
+	if maxMessages > 0 || cfg.MaxTokens > 0 {
+		if maxMessages < 0 {
+			maxMessages = 0
+		}
+		ms = append(ms, Truncate{MaxMessages: maxMessages, MaxTokens: cfg.MaxTokens})
+	}
+	return ms
+}
+
+// WithStandardMiddlewares registers the standard middlewares for cfg; see Standard.
+func WithStandardMiddlewares(cfg StandardConfig) agent.Option {
+	return agent.WithRequestMiddlewares(Standard(cfg)...)
+}
@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/zakirkun/gatot-kaca/agent"
+	"github.com/zakirkun/gatot-kaca/llm"
+)
+
+// stopwords are frequent short words used by DetectLanguage, keyed by ISO-639-1 code.
+var stopwords = map[string][]string{
+	"en": {"the", "and", "is", "are", "you", "what", "how", "to", "of", "in", "it", "this", "that", "with", "for", "can", "my"},
+	"id": {"yang", "dan", "di", "ke", "dari", "ini", "itu", "apa", "bagaimana", "saya", "anda", "tidak", "dengan", "untuk", "adalah", "bisa"},
+	"es": {"el", "la", "de", "que", "y", "es", "en", "los", "por", "para", "cómo", "qué", "una", "con", "mi", "puedes"},
+	"fr": {"le", "la", "les", "de", "et", "est", "que", "un", "une", "pour", "dans", "vous", "je", "comment", "avec", "pas"},
+	"de": {"der", "die", "das", "und", "ist", "ich", "nicht", "sie", "wie", "was", "mit", "ein", "eine", "zu", "für", "kann"},
+	"pt": {"o", "a", "de", "que", "e", "é", "não", "um", "uma", "para", "com", "como", "você", "os", "meu", "pode"},
+	"it": {"il", "di", "che", "e", "è", "non", "un", "una", "per", "con", "come", "sono", "gli", "della", "mi", "puoi"},
+	"nl": {"de", "het", "een", "en", "is", "van", "ik", "niet", "wat", "hoe", "met", "voor", "je", "dat", "zijn", "kan"},
+}
+
+// languageNames names the languages DetectLanguage knows, for translation prompts.
+var languageNames = map[string]string{
+	"en": "English", "id": "Indonesian", "es": "Spanish", "fr": "French",
+	"de": "German", "pt": "Portuguese", "it": "Italian", "nl": "Dutch",
+}
+
+// DetectLanguage guesses the ISO-639-1 code of text from common words, for English, Indonesian,
+// Spanish, French, German, Portuguese, Italian and Dutch. It returns "" when no language stands
+// out, e.g. for very short text.
+func DetectLanguage(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	scores := make(map[string]int)
+	for _, w := range words {
+		for lang, list := range stopwords {
+			for _, s := range list {
+				if w == s {
+					scores[lang]++
+					break
+				}
+			}
+		}
+	}
+	best, bestScore, second := "", 0, 0
+	for _, lang := range []string{"en", "id", "es", "fr", "de", "pt", "it", "nl"} {
+		if scores[lang] > bestScore {
+			best, bestScore, second = lang, scores[lang], bestScore
+		} else if scores[lang] > second {
+			second = scores[lang]
+		}
+	}
+	if bestScore < 2 || bestScore == second {
+		return ""
+	}
+	return best
+}
+
+// Translate lets an agent whose prompts are written in one language talk to users in others.
+// The language of the user's latest message is detected and recorded in the request context
+// under LanguageKey; if it differs from Language, the message is translated into Language
+// before it is sent and the response is translated back.
+type Translate struct {
+	Client *llm.Client
+	Model  string // The model used for translation.
+	// Language is the ISO-639-1 code of the agent's working language; defaults to "en".
+	Language string
+	// Detect overrides DetectLanguage, e.g. to use a dedicated language identification service.
+	// It returns "" when the language is unknown, in which case nothing is translated.
+	Detect func(ctx context.Context, text string) (string, error)
+}
+
+func (t Translate) language() string {
+	if t.Language == "" {
+		return "en"
+	}
+	return t.Language
+}
+
+// translate asks the model to translate text into the language with the given code.
+func (t Translate) translate(ctx context.Context, text, lang string) (string, error) {
+	name := languageNames[lang]
+	if name == "" {
+		name = lang
+	}
+	prompt := fmt.Sprintf("Translate the following text into %s. Keep the formatting, names and code unchanged "+
+		"and respond with only the translation.\n\n%s", name, text)
+	res, err := t.Client.Generate(ctx, t.Model, llm.ModelRequest{Prompt: prompt, Temperature: 0})
+	if err != nil {
+		return "", fmt.Errorf("translate: %w", err)
+	}
+	return strings.TrimSpace(res.Text), nil
+}
+
+// BeforeSend implements agent.RequestMiddleware.
+func (t Translate) BeforeSend(ctx context.Context, rc *agent.RequestContext) error {
+	i := latestUser(rc.Messages)
+	if i < 0 {
+		return nil
+	}
+	lang, ok := rc.Value(LanguageKey)
+	if !ok {
+		detect := t.Detect
+		if detect == nil {
+			detect = func(ctx context.Context, text string) (string, error) { return DetectLanguage(text), nil }
+		}
+		detected, err := detect(ctx, rc.Input)
+		if err != nil {
+			return fmt.Errorf("detect language: %w", err)
+		}
+		lang = detected
+		rc.Set(LanguageKey, lang)
+	}
+	if lang == "" || lang == t.language() {
+		return nil
+	}
+	// The prompt may be rebuilt several times per request, so the translation is reused.
+	if text, ok := rc.Value(translatedKey); ok {
+		rc.Messages[i].Content = text.(string)
+		return nil
+	}
+	text, err := t.translate(ctx, rc.Messages[i].Content, t.language())
+	if err != nil {
+		return err
+	}
+	rc.Set(translatedKey, text)
+	rc.Messages[i].Content = text
+	return nil
+}
+
+// AfterReceive implements agent.RequestMiddleware.
+func (t Translate) AfterReceive(ctx context.Context, rc *agent.RequestContext) error {
+	lang, _ := rc.Value(LanguageKey)
+	if lang == nil || lang == "" || lang == t.language() {
+		return nil
+	}
+	text, err := t.translate(ctx, rc.Response, lang.(string))
+	if err != nil {
+		return err
+	}
+	rc.Response = text
+	return nil
+}
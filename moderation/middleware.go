@@ -0,0 +1,175 @@
+package moderation
+
+import (
+	"context"
+
+	"github.com/zakirkun/gatot-kaca/agent"
+	"github.com/zakirkun/gatot-kaca/logging"
+)
+
+// DefaultBlockMessage replaces input and responses blocked by moderation.
+const DefaultBlockMessage = "I'm sorry, but I can't help with that request."
+
+// Stage identifies which side of the conversation was moderated.
+type Stage string
+
+const (
+	Input  Stage = "input"  // The latest user message.
+	Output Stage = "output" // The model's response.
+)
+
+// Middleware moderates an agent's latest user message and the model's responses.
+//
+// Registered with an agent, it implements agent.RequestMiddleware: when the user message is
+// blocked the request is halted before the model is called, and a blocked response is halted
+// as well, both replying with BlockMessage. Through the plain agent.Middleware methods, a
+// blocked user message is instead replaced with BlockMessage before being sent, and the model's
+// response is replaced with BlockMessage as well. Flagged text passes through unchanged and is
+// reported to OnFlag. A Middleware keeps its per-request state in the agent.RequestContext, so
+// it may be shared by agents.
+type Middleware struct {
+	Moderator Moderator
+	Policy    Policy
+	// SkipInput and SkipOutput disable moderation of user messages or model responses.
+	SkipInput  bool
+	SkipOutput bool
+	// FailOpen lets text through when the moderator returns an error; by default it is blocked.
+	FailOpen     bool
+	BlockMessage string // Defaults to DefaultBlockMessage.
+	// OnFlag is called for every decision that flags or blocks text.
+	OnFlag func(ctx context.Context, stage Stage, d Decision)
+}
+
+// inputStatesKey is the request context key of the input decisions of the request, a
+// map[*Middleware]*inputState.
+const inputStatesKey = "moderation.input"
+
+// inputState is the latest input decision of a Middleware in a request. The prompt may be built
+// several times per request, e.g. when it is truncated to fit the context window, so the
+// decision is reused for the same text.
+type inputState struct {
+	text     string
+	decision Decision
+	checked  bool
+	blocked  bool
+}
+
+// inputState returns the input state of m in the request of ctx, or a new one outside a request.
+func (m *Middleware) inputState(ctx context.Context) *inputState {
+	rc := agent.RequestFromContext(ctx)
+	if rc == nil {
+		return &inputState{}
+	}
+	states, _ := rc.Value(inputStatesKey)
+	byMiddleware, ok := states.(map[*Middleware]*inputState)
+	if !ok {
+		byMiddleware = make(map[*Middleware]*inputState)
+		rc.Set(inputStatesKey, byMiddleware)
+	}
+	st := byMiddleware[m]
+	if st == nil {
+		st = &inputState{}
+		byMiddleware[m] = st
+	}
+	return st
+}
+
+// NewMiddleware creates an agent middleware that applies policy to the verdicts of m.
+func NewMiddleware(m Moderator, policy Policy) *Middleware {
+	return &Middleware{Moderator: m, Policy: policy}
+}
+
+func (m *Middleware) blockMessage() string {
+	if m.BlockMessage == "" {
+		return DefaultBlockMessage
+	}
+	return m.BlockMessage
+}
+
+// check moderates text and applies the policy, reporting flagged and blocked text.
+func (m *Middleware) check(ctx context.Context, stage Stage, text string) Decision {
+	result, err := m.Moderator.Moderate(ctx, text)
+	if err != nil {
+		logging.FromContext(ctx).Warn("moderation check failed", logging.KeyComponent, "moderation", "stage", stage,
+			"fail_open", m.FailOpen, logging.KeyError, err)
+		if m.FailOpen {
+			return Decision{Action: Allow}
+		}
+		return Decision{Action: Block}
+	}
+	d := m.Policy.Evaluate(result)
+	if d.Action != Allow {
+		logging.FromContext(ctx).Warn("text flagged", logging.KeyComponent, "moderation", "stage", stage,
+			"action", d.Action, "categories", d.Categories)
+		if m.OnFlag != nil {
+			m.OnFlag(ctx, stage, d)
+		}
+	}
+	return d
+}
+
+// checkInput moderates the latest user message in history, replacing it with BlockMessage if
+// it is blocked, and reports whether it was.
+func (m *Middleware) checkInput(ctx context.Context, history []agent.ConversationMessage) bool {
+	st := m.inputState(ctx)
+	st.blocked = false
+	if m.SkipInput || m.Moderator == nil {
+		return false
+	}
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Role != "User" {
+			continue
+		}
+		if !st.checked || history[i].Content != st.text {
+			st.text = history[i].Content
+			st.decision = m.check(ctx, Input, history[i].Content)
+			st.checked = true
+		}
+		if st.decision.Action == Block {
+			st.blocked = true
+			history[i].Content = m.blockMessage()
+		}
+		break
+	}
+	return st.blocked
+}
+
+// outputBlocked moderates response and reports whether it is blocked.
+func (m *Middleware) outputBlocked(ctx context.Context, response string) bool {
+	if m.SkipOutput || m.Moderator == nil {
+		return false
+	}
+	return m.check(ctx, Output, response).Action == Block
+}
+
+// ProcessBeforeSend implements agent.Middleware.
+func (m *Middleware) ProcessBeforeSend(ctx context.Context, history []agent.ConversationMessage) []agent.ConversationMessage {
+	m.checkInput(ctx, history)
+	return history
+}
+
+// ProcessAfterReceive implements agent.Middleware.
+func (m *Middleware) ProcessAfterReceive(ctx context.Context, response string) string {
+	if m.inputState(ctx).blocked || m.outputBlocked(ctx, response) {
+		return m.blockMessage()
+	}
+	return response
+}
+
+// BeforeSend implements agent.RequestMiddleware, halting the request if the latest user
+// message is blocked.
+func (m *Middleware) BeforeSend(ctx context.Context, rc *agent.RequestContext) error {
+	if m.checkInput(ctx, rc.Messages) {
+		return agent.Halt(m.blockMessage())
+	}
+	return nil
+}
+
+// AfterReceive implements agent.RequestMiddleware, halting the request if the response is
+// blocked.
+func (m *Middleware) AfterReceive(ctx context.Context, rc *agent.RequestContext) error {
+	if m.outputBlocked(ctx, rc.Response) {
+		return agent.Halt(m.blockMessage())
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+// Package moderation checks agent input and output against a content moderation service,
+// such as OpenAI's moderation endpoint, and blocks or flags what the configured policy
+// disallows.
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// Categories reported by OpenAI's moderation endpoint. Other moderators may report their own.
+const (
+	Harassment            = "harassment"
+	HarassmentThreatening = "harassment/threatening"
+	Hate                  = "hate"
+	HateThreatening       = "hate/threatening"
+	Illicit               = "illicit"
+	IllicitViolent        = "illicit/violent"
+	SelfHarm              = "self-harm"
+	SelfHarmIntent        = "self-harm/intent"
+	SelfHarmInstructions  = "self-harm/instructions"
+	Sexual                = "sexual"
+	SexualMinors          = "sexual/minors"
+	Violence              = "violence"
+	ViolenceGraphic       = "violence/graphic"
+)
+
+// Result is the verdict of a moderator for a piece of text.
+type Result struct {
+	Flagged    bool
+	Categories map[string]bool    // Categories the moderator flagged.
+	Scores     map[string]float64 // Confidence per category, between 0 and 1.
+}
+
+// Moderator classifies text. Implementations exist for OpenAI; other providers can be
+// plugged in by implementing this interface or using ModeratorFunc.
+type Moderator interface {
+	Moderate(ctx context.Context, text string) (Result, error)
+}
+
+// ModeratorFunc adapts a function to the Moderator interface.
+type ModeratorFunc func(ctx context.Context, text string) (Result, error)
+
+// Moderate implements Moderator.
+func (f ModeratorFunc) Moderate(ctx context.Context, text string) (Result, error) {
+	return f(ctx, text)
+}
+
+// Action determines what happens to text in a triggered category.
+type Action int
+
+const (
+	// Block rejects the text. It is the zero value, so a zero Policy blocks anything flagged.
+	Block Action = iota
+	// Flag reports the text but lets it through.
+	Flag
+	// Allow ignores the category.
+	Allow
+)
+
+// String returns the name of the action.
+func (a Action) String() string {
+	switch a {
+	case Block:
+		return "block"
+	case Flag:
+		return "flag"
+	case Allow:
+		return "allow"
+	default:
+		return fmt.Sprintf("Action(%d)", int(a))
+	}
+}
+
+// severity orders actions from least to most severe.
+func (a Action) severity() int {
+	switch a {
+	case Block:
+		return 2
+	case Flag:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Policy decides what to do with a moderation result.
+type Policy struct {
+	// Default is the action for triggered categories without an entry in Categories.
+	Default Action
+	// Categories overrides the action per category.
+	Categories map[string]Action
+	// Thresholds triggers a category when its score reaches the threshold, even if the
+	// moderator did not flag it. Categories the moderator flagged are always triggered.
+	Thresholds map[string]float64
+}
+
+// Decision is the outcome of applying a policy to a moderation result.
+type Decision struct {
+	Action     Action
+	Categories []string // Triggered categories, sorted.
+	Result     Result
+}
+
+// Evaluate applies the policy to a result. The decision takes the most severe action of the
+// triggered categories, or Allow if none were triggered.
+func (p Policy) Evaluate(r Result) Decision {
+	triggered := make(map[string]bool)
+	for cat, flagged := range r.Categories {
+		if flagged {
+			triggered[cat] = true
+		}
+	}
+	for cat, threshold := range p.Thresholds {
+		if score, ok := r.Scores[cat]; ok && score >= threshold {
+			triggered[cat] = true
+		}
+	}
+	// Providers may flag text without naming a category.
+	if r.Flagged && len(triggered) == 0 {
+		return Decision{Action: p.Default, Result: r}
+	}
+
+	d := Decision{Action: Allow, Result: r}
+	for cat := range triggered {
+		action, ok := p.Categories[cat]
+		if !ok {
+			action = p.Default
+		}
+		if action == Allow {
+			continue
+		}
+		d.Categories = append(d.Categories, cat)
+		if action.severity() > d.Action.severity() {
+			d.Action = action
+		}
+	}
+	sort.Strings(d.Categories)
+	return d
+}
@@ -0,0 +1,87 @@
+package moderation_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/zakirkun/gatot-kaca/llmtest"
+	"github.com/zakirkun/gatot-kaca/moderation"
+)
+
+func TestOpenAIModerate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/moderations" || r.Header.Get("Authorization") != "Bearer key" {
+			t.Errorf("unexpected request %s %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		var req struct{ Model, Input string }
+		json.NewDecoder(r.Body).Decode(&req)
+		flagged := strings.Contains(req.Input, "hurt")
+		json.NewEncoder(w).Encode(map[string]any{
+			"results": []map[string]any{{
+				"flagged":         flagged,
+				"categories":      map[string]bool{"violence": flagged, "harassment": false},
+				"category_scores": map[string]float64{"violence": 0.9, "harassment": 0.4},
+			}},
+		})
+	}))
+	defer srv.Close()
+
+	m := &moderation.OpenAI{APIKey: "key", BaseURL: srv.URL}
+	r, err := m.Moderate(context.Background(), "I will hurt you")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Flagged || !r.Categories[moderation.Violence] || r.Scores[moderation.Harassment] != 0.4 {
+		t.Errorf("unexpected result %+v", r)
+	}
+
+	policy := moderation.Policy{
+		Categories: map[string]moderation.Action{moderation.Violence: moderation.Flag},
+		Thresholds: map[string]float64{moderation.Harassment: 0.3},
+	}
+	d := policy.Evaluate(r)
+	if d.Action != moderation.Block || strings.Join(d.Categories, ",") != "harassment,violence" {
+		t.Errorf("unexpected decision %v %v", d.Action, d.Categories)
+	}
+}
+
+func TestMiddlewareBlocksInputAndOutput(t *testing.T) {
+	mod := moderation.ModeratorFunc(func(ctx context.Context, text string) (moderation.Result, error) {
+		bad := strings.Contains(text, "forbidden")
+		return moderation.Result{Flagged: bad, Categories: map[string]bool{moderation.Illicit: bad}}, nil
+	})
+	var flags []moderation.Stage
+	mw := moderation.NewMiddleware(mod, moderation.Policy{})
+	mw.OnFlag = func(ctx context.Context, stage moderation.Stage, d moderation.Decision) {
+		flags = append(flags, stage)
+	}
+
+	model := llmtest.NewMockModel("mock").On("recipe", "here is the forbidden recipe").Default("hello")
+	a := llmtest.NewAgent(model)
+	a.RegisterMiddleware(mw)
+
+	out, err := a.Send(context.Background(), "tell me something forbidden")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != moderation.DefaultBlockMessage {
+		t.Errorf("blocked input: got %q", out)
+	}
+	model.AssertCalled(t, 0)
+
+	a.Reset()
+	if out, _ := a.Send(context.Background(), "give me a recipe"); out != moderation.DefaultBlockMessage {
+		t.Errorf("blocked output: got %q", out)
+	}
+	a.Reset()
+	if out, _ := a.Send(context.Background(), "hi"); out != "hello" {
+		t.Errorf("allowed: got %q", out)
+	}
+	if len(flags) != 2 || flags[0] != moderation.Input || flags[1] != moderation.Output {
+		t.Errorf("unexpected flags %v", flags)
+	}
+}
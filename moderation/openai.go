@@ -0,0 +1,97 @@
+package moderation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// DefaultOpenAIModel is the moderation model used when OpenAI.Model is empty.
+const DefaultOpenAIModel = "omni-moderation-latest"
+
+// OpenAI is a Moderator backed by OpenAI's moderation endpoint.
+type OpenAI struct {
+	APIKey     string
+	BaseURL    string       // Defaults to https://api.openai.com/v1.
+	Model      string       // Defaults to DefaultOpenAIModel.
+	HTTPClient *http.Client // Defaults to http.DefaultClient.
+}
+
+// NewOpenAI creates an OpenAI moderator with the given API key.
+func NewOpenAI(apiKey string) *OpenAI {
+	return &OpenAI{APIKey: apiKey}
+}
+
+type openAIRequest struct {
+	Model string `json:"model"`
+	Input string `json:"input"`
+}
+
+type openAIResponse struct {
+	Results []struct {
+		Flagged        bool               `json:"flagged"`
+		Categories     map[string]bool    `json:"categories"`
+		CategoryScores map[string]float64 `json:"category_scores"`
+	} `json:"results"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Moderate implements Moderator.
+func (o *OpenAI) Moderate(ctx context.Context, text string) (Result, error) {
+	baseURL := o.BaseURL
+	if baseURL == "" {
+		baseURL = "https://api.openai.com/v1"
+	}
+	model := o.Model
+	if model == "" {
+		model = DefaultOpenAIModel
+	}
+	client := o.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	body, err := json.Marshal(openAIRequest{Model: model, Input: text})
+	if err != nil {
+		return Result{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+"/moderations", bytes.NewReader(body))
+	if err != nil {
+		return Result{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+o.APIKey)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return Result{}, fmt.Errorf("moderation: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Result{}, fmt.Errorf("moderation: %w", err)
+	}
+
+	var out openAIResponse
+	if err := json.Unmarshal(data, &out); err != nil && resp.StatusCode == http.StatusOK {
+		return Result{}, fmt.Errorf("moderation: decode response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		msg := string(data)
+		if out.Error != nil && out.Error.Message != "" {
+			msg = out.Error.Message
+		}
+		return Result{}, fmt.Errorf("moderation: OpenAI API returned %d: %s", resp.StatusCode, msg)
+	}
+	if len(out.Results) == 0 {
+		return Result{}, fmt.Errorf("moderation: empty response")
+	}
+
+	r := out.Results[0]
+	return Result{Flagged: r.Flagged, Categories: r.Categories, Scores: r.CategoryScores}, nil
+}
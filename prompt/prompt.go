@@ -0,0 +1,223 @@
+// Package prompt renders reusable prompt templates built on text/template, with helpers
+// for conversation history, tool listings and few-shot examples.
+package prompt
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/zakirkun/gatot-kaca/agent/tools"
+)
+
+// Example is a single few-shot example.
+type Example struct {
+	Input  string
+	Output string
+}
+
+// Message is a conversation message as rendered by the history helper. Any struct with
+// string Role and Content fields, such as agent.ConversationMessage, is accepted as well.
+type Message struct {
+	Role    string
+	Content string
+}
+
+// Template is a named prompt template with optional partials.
+type Template struct {
+	tmpl *template.Template
+}
+
+// New parses text as a template with the given name. Missing variables are an error.
+func New(name, text string) (*Template, error) {
+	tmpl, err := template.New(name).Funcs(Funcs()).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse prompt %s: %w", name, err)
+	}
+	return &Template{tmpl: tmpl}, nil
+}
+
+// Must is like New but panics if the template cannot be parsed.
+func Must(name, text string) *Template {
+	t, err := New(name, text)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Name returns the template's name.
+func (t *Template) Name() string {
+	return t.tmpl.Name()
+}
+
+// Partial defines a named partial that can be included with {{template "name" .}}.
+func (t *Template) Partial(name, text string) error {
+	if _, err := t.tmpl.New(name).Parse(text); err != nil {
+		return fmt.Errorf("failed to parse partial %s: %w", name, err)
+	}
+	return nil
+}
+
+// Render executes the template with the given data.
+func (t *Template) Render(data any) (string, error) {
+	var b strings.Builder
+	if err := t.tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt %s: %w", t.Name(), err)
+	}
+	return b.String(), nil
+}
+
+// Typed is a template whose variables are given by the struct type T, so that callers
+// get compile-time checking of the values they inject.
+type Typed[T any] struct {
+	*Template
+}
+
+// NewTyped parses text as a template rendered with values of type T.
+func NewTyped[T any](name, text string) (*Typed[T], error) {
+	t, err := New(name, text)
+	if err != nil {
+		return nil, err
+	}
+	return &Typed[T]{Template: t}, nil
+}
+
+// Render executes the template with vars.
+func (t *Typed[T]) Render(vars T) (string, error) {
+	return t.Template.Render(vars)
+}
+
+// Funcs returns the helper functions available in every prompt template:
+//
+//	history   renders messages as "Role: Content" lines
+//	tools     renders a *tools.Manager or []tools.Tool as "- name: description" lines
+//	catalog   renders tools like "tools", adding the schema and help of EnhancedTools
+//	examples  renders []Example as "Input:/Output:" pairs
+//	join, upper, lower, trim, indent and default
+func Funcs() template.FuncMap {
+	return template.FuncMap{
+		"history":  RenderHistory,
+		"tools":    RenderTools,
+		"catalog":  RenderToolCatalog,
+		"examples": RenderExamples,
+		"join":     strings.Join,
+		"upper":    strings.ToUpper,
+		"lower":    strings.ToLower,
+		"trim":     strings.TrimSpace,
+		"indent":   indent,
+		"default":  defaultValue,
+	}
+}
+
+// RenderHistory renders a slice of messages as "Role: Content" lines. Elements must be
+// structs (or pointers to structs) with string Role and Content fields.
+func RenderHistory(messages any) (string, error) {
+	v := reflect.ValueOf(messages)
+	if !v.IsValid() {
+		return "", nil
+	}
+	if v.Kind() != reflect.Slice {
+		return "", fmt.Errorf("history: expected a slice of messages, got %T", messages)
+	}
+	var b strings.Builder
+	for i := 0; i < v.Len(); i++ {
+		m := reflect.Indirect(v.Index(i))
+		if m.Kind() != reflect.Struct {
+			return "", fmt.Errorf("history: expected message struct, got %s", m.Type())
+		}
+		role, content := m.FieldByName("Role"), m.FieldByName("Content")
+		if role.Kind() != reflect.String || content.Kind() != reflect.String {
+			return "", fmt.Errorf("history: %s has no string Role and Content fields", m.Type())
+		}
+		b.WriteString(role.String() + ": " + content.String() + "\n")
+	}
+	return b.String(), nil
+}
+
+// RenderTools renders tools as "- name: description" lines sorted by name.
+// It accepts a *tools.Manager or a []tools.Tool.
+func RenderTools(v any) (string, error) {
+	list, err := toolList(v)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, t := range list {
+		b.WriteString("- " + t.Name() + ": " + t.Description() + "\n")
+	}
+	return b.String(), nil
+}
+
+// RenderToolCatalog renders tools like RenderTools, followed for each tools.EnhancedTool by
+// its input schema and usage help on indented lines.
+func RenderToolCatalog(v any) (string, error) {
+	list, err := toolList(v)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, t := range list {
+		b.WriteString("- " + t.Name() + ": " + t.Description() + "\n")
+		if et, ok := t.(tools.EnhancedTool); ok {
+			if schema := strings.TrimSpace(et.Schema()); schema != "" {
+				b.WriteString("  Input schema: " + schema + "\n")
+			}
+			if help := strings.TrimSpace(et.Help()); help != "" {
+				b.WriteString("  Usage: " + help + "\n")
+			}
+		}
+	}
+	return b.String(), nil
+}
+
+// toolList returns the tools held by a *tools.Manager or []tools.Tool, sorted by name.
+func toolList(v any) ([]tools.Tool, error) {
+	var list []tools.Tool
+	switch ts := v.(type) {
+	case nil:
+	case []tools.Tool:
+		list = ts
+	case *tools.Manager:
+		for _, name := range ts.ListTools() {
+			t, err := ts.GetTool(name)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, t)
+		}
+	default:
+		return nil, fmt.Errorf("tools: unsupported type %T", v)
+	}
+	sorted := append([]tools.Tool(nil), list...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name() < sorted[j].Name() })
+	return sorted, nil
+}
+
+// RenderExamples renders few-shot examples separated by blank lines.
+func RenderExamples(examples []Example) string {
+	parts := make([]string, len(examples))
+	for i, ex := range examples {
+		parts[i] = "Input: " + ex.Input + "\nOutput: " + ex.Output
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// indent prefixes every line of s with n spaces.
+func indent(n int, s string) string {
+	pad := strings.Repeat(" ", n)
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
+
+// defaultValue returns def if value is empty.
+func defaultValue(def, value any) any {
+	if value == nil {
+		return def
+	}
+	if rv := reflect.ValueOf(value); rv.IsZero() {
+		return def
+	}
+	return value
+}
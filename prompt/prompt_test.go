@@ -0,0 +1,71 @@
+package prompt_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zakirkun/gatot-kaca/agent"
+	"github.com/zakirkun/gatot-kaca/agent/tools"
+	"github.com/zakirkun/gatot-kaca/llmtest"
+	"github.com/zakirkun/gatot-kaca/prompt"
+)
+
+func TestTemplateHelpers(t *testing.T) {
+	tmpl := prompt.Must("support", `You are {{default "an assistant" .Role}}.
+{{template "rules" .}}
+Tools:
+{{tools .Tools}}Examples:
+{{examples .Examples}}
+History:
+{{history .History}}`)
+	if err := tmpl.Partial("rules", `Answer in {{upper .Language}}.`); err != nil {
+		t.Fatal(err)
+	}
+	m := tools.NewManager()
+	m.RegisterTool(llmtest.NewMockTool("weather"))
+	m.RegisterTool(llmtest.NewMockTool("calculator"))
+
+	out, err := tmpl.Render(map[string]any{
+		"Role":     "",
+		"Language": "en",
+		"Tools":    m,
+		"Examples": []prompt.Example{{Input: "hi", Output: "hello"}},
+		"History":  []agent.ConversationMessage{{Role: "User", Content: "What is 2+2?"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"You are an assistant.",
+		"Answer in EN.",
+		"- calculator: ",
+		"Input: hi\nOutput: hello",
+		"User: What is 2+2?\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("rendered prompt is missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "- calculator") > strings.Index(out, "- weather") {
+		t.Errorf("tools are not sorted by name:\n%s", out)
+	}
+}
+
+func TestTemplateErrors(t *testing.T) {
+	if _, err := prompt.New("broken", "{{.Name"); err == nil {
+		t.Error("parsed an unterminated action")
+	}
+	tmpl := prompt.Must("greeting", "Hello {{.Name}}")
+	if _, err := tmpl.Render(map[string]any{}); err == nil {
+		t.Error("rendered a template with a missing variable")
+	}
+
+	type vars struct{ Name string }
+	typed, err := prompt.NewTyped[vars]("typed", "Hello {{.Name}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out, err := typed.Render(vars{Name: "Gatot"}); err != nil || out != "Hello Gatot" {
+		t.Errorf("typed render = %q, %v", out, err)
+	}
+}
@@ -0,0 +1,282 @@
+package prompt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned when a prompt or prompt version does not exist.
+var ErrNotFound = errors.New("prompt not found")
+
+// Version is a single stored revision of a named prompt.
+type Version struct {
+	Name      string    `json:"name"`
+	Version   int       `json:"version"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Ref returns the version's reference in "name@version" form.
+func (v Version) Ref() string {
+	return fmt.Sprintf("%s@%d", v.Name, v.Version)
+}
+
+// Template parses the version's text as a template named after its reference.
+func (v Version) Template() (*Template, error) {
+	return New(v.Ref(), v.Text)
+}
+
+// ParseRef splits a reference of the form "name", "name@latest" or "name@3" into its name
+// and version. A version of 0 means the latest version.
+func ParseRef(ref string) (string, int, error) {
+	name, version, found := strings.Cut(ref, "@")
+	if name == "" {
+		return "", 0, fmt.Errorf("invalid prompt reference %q", ref)
+	}
+	if !found || version == "latest" {
+		return name, 0, nil
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(version, "v"))
+	if err != nil || n < 1 {
+		return "", 0, fmt.Errorf("invalid prompt version in %q", ref)
+	}
+	return name, n, nil
+}
+
+// Store holds named, versioned prompt templates.
+type Store interface {
+	// Save stores text as the next version of the named prompt. If the text is identical to the
+	// latest version, that version is returned instead of creating a new one.
+	Save(name, text string) (Version, error)
+	// Get returns the version identified by ref ("name", "name@latest" or "name@3").
+	Get(ref string) (Version, error)
+	// Versions returns all versions of the named prompt, oldest first.
+	Versions(name string) ([]Version, error)
+	// Names returns the names of all stored prompts, sorted.
+	Names() ([]string, error)
+}
+
+// Load fetches ref from the store and parses it as a template.
+func Load(s Store, ref string) (*Template, Version, error) {
+	v, err := s.Get(ref)
+	if err != nil {
+		return nil, Version{}, err
+	}
+	t, err := v.Template()
+	return t, v, err
+}
+
+// MemoryStore is an in-memory Store.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	prompts map[string][]Version
+}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{prompts: make(map[string][]Version)}
+}
+
+// Save implements Store.
+func (s *MemoryStore) Save(name, text string) (Version, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	versions, v, err := appendVersion(s.prompts[name], name, text)
+	if err != nil {
+		return Version{}, err
+	}
+	s.prompts[name] = versions
+	return v, nil
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(ref string) (Version, error) {
+	name, version, err := ParseRef(ref)
+	if err != nil {
+		return Version{}, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return findVersion(s.prompts[name], name, version)
+}
+
+// Versions implements Store.
+func (s *MemoryStore) Versions(name string) ([]Version, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	versions, ok := s.prompts[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return append([]Version(nil), versions...), nil
+}
+
+// Names implements Store.
+func (s *MemoryStore) Names() ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	names := make([]string, 0, len(s.prompts))
+	for name := range s.prompts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// FileStore is a Store that keeps each prompt's versions in a JSON file named <name>.json
+// inside a directory.
+type FileStore struct {
+	Dir string
+	mu  sync.Mutex
+}
+
+// NewFileStore creates a file-backed store in dir, creating the directory if needed.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create prompt store: %w", err)
+	}
+	return &FileStore{Dir: dir}, nil
+}
+
+func (s *FileStore) path(name string) (string, error) {
+	if name == "" || strings.ContainsAny(name, `/\@`) || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid prompt name %q", name)
+	}
+	return filepath.Join(s.Dir, name+".json"), nil
+}
+
+func (s *FileStore) read(name string) ([]Version, error) {
+	path, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var versions []Version
+	if err := json.Unmarshal(data, &versions); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return versions, nil
+}
+
+// Save implements Store. The file is replaced atomically.
+func (s *FileStore) Save(name, text string) (Version, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, err := s.read(name)
+	if err != nil {
+		return Version{}, err
+	}
+	versions, v, err := appendVersion(existing, name, text)
+	if err != nil || len(versions) == len(existing) {
+		return v, err
+	}
+
+	data, err := json.MarshalIndent(versions, "", "  ")
+	if err != nil {
+		return Version{}, err
+	}
+	path, _ := s.path(name)
+	tmp, err := os.CreateTemp(s.Dir, name+".*.tmp")
+	if err != nil {
+		return Version{}, err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return Version{}, err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return Version{}, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return Version{}, err
+	}
+	return v, nil
+}
+
+// Get implements Store.
+func (s *FileStore) Get(ref string) (Version, error) {
+	name, version, err := ParseRef(ref)
+	if err != nil {
+		return Version{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	versions, err := s.read(name)
+	if err != nil {
+		return Version{}, err
+	}
+	return findVersion(versions, name, version)
+}
+
+// Versions implements Store.
+func (s *FileStore) Versions(name string) ([]Version, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	versions, err := s.read(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return versions, nil
+}
+
+// Names implements Store.
+func (s *FileStore) Names() ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(s.Dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(matches))
+	for i, m := range matches {
+		names[i] = strings.TrimSuffix(filepath.Base(m), ".json")
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// appendVersion validates text and appends it as the next version, unless it matches the latest.
+func appendVersion(versions []Version, name, text string) ([]Version, Version, error) {
+	if _, err := New(name, text); err != nil {
+		return versions, Version{}, err
+	}
+	if n := len(versions); n > 0 && versions[n-1].Text == text {
+		return versions, versions[n-1], nil
+	}
+	v := Version{Name: name, Version: len(versions) + 1, Text: text, CreatedAt: time.Now()}
+	return append(versions, v), v, nil
+}
+
+// findVersion returns the requested version, or the latest if version is 0.
+func findVersion(versions []Version, name string, version int) (Version, error) {
+	if len(versions) == 0 {
+		return Version{}, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	if version == 0 {
+		return versions[len(versions)-1], nil
+	}
+	for _, v := range versions {
+		if v.Version == version {
+			return v, nil
+		}
+	}
+	return Version{}, fmt.Errorf("%w: %s@%d", ErrNotFound, name, version)
+}
@@ -0,0 +1,58 @@
+package prompt_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/zakirkun/gatot-kaca/prompt"
+)
+
+func TestStores(t *testing.T) {
+	fileStore, err := prompt.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, s := range map[string]prompt.Store{"memory": prompt.NewMemoryStore(), "file": fileStore} {
+		t.Run(name, func(t *testing.T) {
+			v1, err := s.Save("greeting", "Hello {{.Name}}")
+			if err != nil || v1.Version != 1 {
+				t.Fatalf("first save = %+v, %v", v1, err)
+			}
+			if again, err := s.Save("greeting", "Hello {{.Name}}"); err != nil || again.Version != 1 {
+				t.Errorf("saving the same text = %+v, %v; want version 1", again, err)
+			}
+			if _, err := s.Save("greeting", "Hello {{.Name"); err == nil {
+				t.Error("saved an invalid template")
+			}
+			if v2, err := s.Save("greeting", "Hi {{.Name}}"); err != nil || v2.Ref() != "greeting@2" {
+				t.Fatalf("second save = %+v, %v", v2, err)
+			}
+
+			tmpl, v, err := prompt.Load(s, "greeting@1")
+			if err != nil || v.Version != 1 {
+				t.Fatalf("Load(greeting@1) = %+v, %v", v, err)
+			}
+			if out, err := tmpl.Render(map[string]string{"Name": "Gatot"}); err != nil || out != "Hello Gatot" {
+				t.Errorf("version 1 renders %q, %v", out, err)
+			}
+			for _, ref := range []string{"greeting", "greeting@latest", "greeting@v2"} {
+				if v, err := s.Get(ref); err != nil || v.Text != "Hi {{.Name}}" {
+					t.Errorf("Get(%s) = %+v, %v; want version 2", ref, v, err)
+				}
+			}
+			if _, err := s.Get("greeting@3"); !errors.Is(err, prompt.ErrNotFound) {
+				t.Errorf("Get(greeting@3) = %v, want ErrNotFound", err)
+			}
+			if _, err := s.Versions("missing"); !errors.Is(err, prompt.ErrNotFound) {
+				t.Errorf("Versions(missing) = %v, want ErrNotFound", err)
+			}
+			if names, err := s.Names(); err != nil || len(names) != 1 || names[0] != "greeting" {
+				t.Errorf("Names() = %v, %v", names, err)
+			}
+		})
+	}
+
+	if _, err := fileStore.Save("../escape", "text"); err == nil {
+		t.Error("file store accepted a name outside its directory")
+	}
+}
@@ -0,0 +1,106 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/zakirkun/gatot-kaca/logging"
+	"github.com/zakirkun/gatot-kaca/tokenizer"
+)
+
+// Default limits for AddDocuments batches. They stay below the OpenAI embeddings limits of
+// 2048 inputs and 300k tokens per request.
+const (
+	DefaultBatchSize      = 256
+	DefaultMaxBatchTokens = 250000
+)
+
+// AddDocuments adds several documents, computing their embeddings in batches so that each
+// batch is a single provider call when the model supports batch embeddings. Batches are
+// limited to BatchSize documents and MaxBatchTokens tokens. Texts found in the embedding
+// cache are not sent. Documents are only added once all embeddings have been computed.
+//
+// Documents replace existing documents with the same IDs. Documents whose text and metadata
+// are unchanged are skipped, so adding the same documents again is a no-op. If an ID appears
+// more than once, the last document wins.
+func (kb *KnowledgeBase) AddDocuments(ctx context.Context, docs []Document) error {
+	last := make(map[string]int, len(docs))
+	for i, doc := range docs {
+		last[doc.ID] = i
+	}
+	var changed []*Document
+	for i, doc := range docs {
+		if last[doc.ID] != i {
+			continue
+		}
+		d := &Document{ID: doc.ID, Text: doc.Text, Metadata: doc.Metadata}
+		if kb.prepare(d) {
+			changed = append(changed, d)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	var pending []int
+	for i, doc := range changed {
+		if kb.Cache != nil {
+			if embedding, ok := kb.Cache.Get(kb.ModelName, doc.Text); ok {
+				doc.Embedding = embedding
+				continue
+			}
+		}
+		pending = append(pending, i)
+	}
+
+	for _, batch := range kb.batches(changed, pending) {
+		texts := make([]string, len(batch))
+		for j, i := range batch {
+			texts[j] = changed[i].Text
+		}
+		result, err := kb.Client.Embeddings(ctx, kb.ModelName, texts)
+		if err != nil {
+			return fmt.Errorf("failed to compute embeddings for documents '%s' to '%s': %w",
+				changed[batch[0]].ID, changed[batch[len(batch)-1]].ID, err)
+		}
+		for j, i := range batch {
+			changed[i].Embedding = result[j]
+			if kb.Cache != nil && len(result[j]) > 0 {
+				if err := kb.Cache.Put(kb.ModelName, texts[j], result[j]); err != nil {
+					logging.FromContext(ctx).Warn("failed to cache embedding", logging.KeyComponent, "rag", logging.KeyError, err)
+				}
+			}
+		}
+	}
+	return kb.store(ctx, changed)
+}
+
+// batches groups the pending document indexes into batches within the size and token limits.
+// A single document larger than the token limit gets a batch of its own.
+func (kb *KnowledgeBase) batches(docs []*Document, pending []int) [][]int {
+	size := kb.BatchSize
+	if size <= 0 {
+		size = DefaultBatchSize
+	}
+	maxTokens := kb.MaxBatchTokens
+	if maxTokens <= 0 {
+		maxTokens = DefaultMaxBatchTokens
+	}
+
+	var batches [][]int
+	var current []int
+	tokens := 0
+	for _, i := range pending {
+		n := tokenizer.CountTokens(kb.ModelName, docs[i].Text)
+		if len(current) > 0 && (len(current) >= size || tokens+n > maxTokens) {
+			batches = append(batches, current)
+			current, tokens = nil, 0
+		}
+		current = append(current, i)
+		tokens += n
+	}
+	if len(current) > 0 {
+		batches = append(batches, current)
+	}
+	return batches
+}
@@ -0,0 +1,145 @@
+package rag
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/zakirkun/gatot-kaca/logging"
+)
+
+// EmbeddingCache stores embeddings keyed by model and text content, so that re-ingesting
+// unchanged documents or repeating queries does not call the embedding API again.
+type EmbeddingCache interface {
+	// Get returns the cached embedding of text for model, if present.
+	Get(model, text string) ([]float64, bool)
+	// Put stores the embedding of text for model.
+	Put(model, text string, embedding []float64) error
+}
+
+// CacheKey returns the key used for text embedded with model: the model name followed by
+// the hex-encoded SHA-256 of the text.
+func CacheKey(model, text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return model + ":" + hex.EncodeToString(sum[:])
+}
+
+// MemoryCache is an in-memory EmbeddingCache.
+type MemoryCache struct {
+	mu      sync.RWMutex
+	entries map[string][]float64
+}
+
+// NewMemoryCache creates an empty in-memory cache.
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: make(map[string][]float64)}
+}
+
+// Get implements EmbeddingCache.
+func (c *MemoryCache) Get(model, text string) ([]float64, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	embedding, ok := c.entries[CacheKey(model, text)]
+	return embedding, ok
+}
+
+// Put implements EmbeddingCache.
+func (c *MemoryCache) Put(model, text string, embedding []float64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[CacheKey(model, text)] = embedding
+	return nil
+}
+
+// Len returns the number of cached embeddings.
+func (c *MemoryCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.entries)
+}
+
+// DiskCache is an EmbeddingCache that stores each embedding as a JSON file under
+// Dir/<model>/<sha256>.json, so the cache survives restarts.
+type DiskCache struct {
+	Dir string
+}
+
+// NewDiskCache creates a disk cache in dir, creating the directory if needed.
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create embedding cache: %w", err)
+	}
+	return &DiskCache{Dir: dir}, nil
+}
+
+func (c *DiskCache) path(model, text string) string {
+	sum := sha256.Sum256([]byte(text))
+	// Keep model names such as "org/model" from creating nested or escaping paths.
+	dir := strings.NewReplacer("/", "_", "\\", "_", "..", "_").Replace(model)
+	return filepath.Join(c.Dir, dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Get implements EmbeddingCache.
+func (c *DiskCache) Get(model, text string) ([]float64, bool) {
+	data, err := os.ReadFile(c.path(model, text))
+	if err != nil {
+		return nil, false
+	}
+	var embedding []float64
+	if err := json.Unmarshal(data, &embedding); err != nil {
+		return nil, false
+	}
+	return embedding, true
+}
+
+// Put implements EmbeddingCache. The file is written atomically.
+func (c *DiskCache) Put(model, text string, embedding []float64) error {
+	path := c.path(model, text)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(embedding)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// embed returns the embedding of text, using the knowledge base's cache if one is set.
+// Failing to store an embedding in the cache is logged but not returned.
+func (kb *KnowledgeBase) embed(ctx context.Context, text string) ([]float64, error) {
+	if kb.Cache != nil {
+		if embedding, ok := kb.Cache.Get(kb.ModelName, text); ok {
+			return embedding, nil
+		}
+	}
+	embedding, err := kb.Client.Embedding(ctx, kb.ModelName, text)
+	if err != nil {
+		return nil, err
+	}
+	if kb.Cache != nil && len(embedding) > 0 {
+		if err := kb.Cache.Put(kb.ModelName, text, embedding); err != nil {
+			logging.FromContext(ctx).Warn("failed to cache embedding", logging.KeyComponent, "rag", logging.KeyError, err)
+		}
+	}
+	return embedding, nil
+}
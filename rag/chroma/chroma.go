@@ -0,0 +1,183 @@
+// Package chroma implements a rag.VectorStore backed by a Chroma collection,
+// using the Chroma v1 HTTP API.
+package chroma
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/zakirkun/gatot-kaca/rag"
+	"github.com/zakirkun/gatot-kaca/rag/internal/httpjson"
+)
+
+// Config configures a Store.
+type Config struct {
+	URL        string // Base URL of the Chroma server, e.g. "http://localhost:8000".
+	Token      string // Optional bearer token for servers with token authentication.
+	Collection string
+	// HTTPClient is optional; defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// Store is a rag.VectorStore backed by a Chroma collection. The collection is created with
+// cosine distance on first use if it does not exist.
+type Store struct {
+	cfg Config
+
+	mu           sync.Mutex
+	collectionID string
+}
+
+var _ rag.VectorStore = (*Store)(nil)
+
+// New creates a Store for the configured collection.
+func New(cfg Config) (*Store, error) {
+	if cfg.URL == "" || cfg.Collection == "" {
+		return nil, errors.New("chroma: URL and collection are required")
+	}
+	cfg.URL = strings.TrimRight(cfg.URL, "/")
+	return &Store{cfg: cfg}, nil
+}
+
+func (s *Store) do(ctx context.Context, path string, body, out interface{}) error {
+	headers := map[string]string{}
+	if s.cfg.Token != "" {
+		headers["Authorization"] = "Bearer " + s.cfg.Token
+	}
+	if err := httpjson.Do(ctx, s.cfg.HTTPClient, http.MethodPost, s.cfg.URL+"/api/v1"+path, headers, body, out); err != nil {
+		return fmt.Errorf("chroma: %w", err)
+	}
+	return nil
+}
+
+// collection returns the ID of the collection, creating it if needed.
+func (s *Store) collection(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.collectionID != "" {
+		return s.collectionID, nil
+	}
+	var resp struct {
+		ID string `json:"id"`
+	}
+	body := map[string]interface{}{
+		"name":          s.cfg.Collection,
+		"get_or_create": true,
+		"metadata":      map[string]interface{}{"hnsw:space": "cosine"},
+	}
+	if err := s.do(ctx, "/collections", body, &resp); err != nil {
+		return "", err
+	}
+	s.collectionID = resp.ID
+	return resp.ID, nil
+}
+
+// Upsert implements rag.VectorStore.
+func (s *Store) Upsert(ctx context.Context, docs []*rag.Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	id, err := s.collection(ctx)
+	if err != nil {
+		return err
+	}
+	ids := make([]string, len(docs))
+	embeddings := make([][]float64, len(docs))
+	texts := make([]string, len(docs))
+	metadatas := make([]map[string]interface{}, len(docs))
+	for i, doc := range docs {
+		ids[i] = doc.ID
+		embeddings[i] = doc.Embedding
+		texts[i] = doc.Text
+		// Chroma rejects empty metadata objects.
+		if len(doc.Metadata) > 0 {
+			metadatas[i] = doc.Metadata
+		}
+	}
+	body := map[string]interface{}{
+		"ids":        ids,
+		"embeddings": embeddings,
+		"documents":  texts,
+		"metadatas":  metadatas,
+	}
+	return s.do(ctx, "/collections/"+id+"/upsert", body, nil)
+}
+
+// Search implements rag.VectorStore. Scores are cosine similarities (1 - cosine distance).
+func (s *Store) Search(ctx context.Context, embedding []float64, k int, filter rag.Filter) ([]rag.RetrievalResult, error) {
+	id, err := s.collection(ctx)
+	if err != nil {
+		return nil, err
+	}
+	body := map[string]interface{}{
+		"query_embeddings": [][]float64{embedding},
+		"n_results":        k,
+		"include":          []string{"documents", "metadatas", "distances"},
+	}
+	if where := whereClause(filter); where != nil {
+		body["where"] = where
+	}
+
+	var resp struct {
+		IDs       [][]string                 `json:"ids"`
+		Documents [][]string                 `json:"documents"`
+		Metadatas [][]map[string]interface{} `json:"metadatas"`
+		Distances [][]float64                `json:"distances"`
+	}
+	if err := s.do(ctx, "/collections/"+id+"/query", body, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.IDs) == 0 {
+		return nil, nil
+	}
+
+	results := make([]rag.RetrievalResult, 0, len(resp.IDs[0]))
+	for i, docID := range resp.IDs[0] {
+		doc := &rag.Document{ID: docID}
+		if len(resp.Documents) > 0 && i < len(resp.Documents[0]) {
+			doc.Text = resp.Documents[0][i]
+		}
+		if len(resp.Metadatas) > 0 && i < len(resp.Metadatas[0]) {
+			doc.Metadata = resp.Metadatas[0][i]
+		}
+		var score float64
+		if len(resp.Distances) > 0 && i < len(resp.Distances[0]) {
+			score = 1 - resp.Distances[0][i]
+		}
+		results = append(results, rag.RetrievalResult{Doc: doc, Score: score})
+	}
+	return results, nil
+}
+
+// whereClause converts a filter into a Chroma where clause.
+func whereClause(filter rag.Filter) map[string]interface{} {
+	switch len(filter) {
+	case 0:
+		return nil
+	case 1:
+		for key, value := range filter {
+			return map[string]interface{}{key: value}
+		}
+	}
+	conds := make([]map[string]interface{}, 0, len(filter))
+	for key, value := range filter {
+		conds = append(conds, map[string]interface{}{key: value})
+	}
+	return map[string]interface{}{"$and": conds}
+}
+
+// Delete implements rag.VectorStore.
+func (s *Store) Delete(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	id, err := s.collection(ctx)
+	if err != nil {
+		return err
+	}
+	return s.do(ctx, "/collections/"+id+"/delete", map[string]interface{}{"ids": ids}, nil)
+}
@@ -0,0 +1,168 @@
+package chroma_test
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/zakirkun/gatot-kaca/rag"
+	"github.com/zakirkun/gatot-kaca/rag/chroma"
+)
+
+type fakeRecord struct {
+	embedding []float64
+	text      string
+	metadata  map[string]interface{}
+}
+
+// fakeChroma serves the parts of the Chroma v1 HTTP API used by the store for one collection.
+type fakeChroma struct {
+	t *testing.T
+
+	mu      sync.Mutex
+	created map[string]interface{} // Body of the collection creation request.
+	records map[string]fakeRecord
+}
+
+func (f *fakeChroma) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.Header.Get("Authorization") != "Bearer secret" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var req struct {
+		IDs             []string                 `json:"ids"`
+		Embeddings      [][]float64              `json:"embeddings"`
+		Documents       []string                 `json:"documents"`
+		Metadatas       []map[string]interface{} `json:"metadatas"`
+		QueryEmbeddings [][]float64              `json:"query_embeddings"`
+		NResults        int                      `json:"n_results"`
+		Where           map[string]interface{}   `json:"where"`
+	}
+	if r.URL.Path == "/api/v1/collections" {
+		json.NewDecoder(r.Body).Decode(&f.created)
+		json.NewEncoder(w).Encode(map[string]string{"id": "c1", "name": "docs"})
+		return
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+
+	switch r.URL.Path {
+	case "/api/v1/collections/c1/upsert":
+		for i, id := range req.IDs {
+			f.records[id] = fakeRecord{embedding: req.Embeddings[i], text: req.Documents[i], metadata: req.Metadatas[i]}
+		}
+	case "/api/v1/collections/c1/delete":
+		for _, id := range req.IDs {
+			delete(f.records, id)
+		}
+	case "/api/v1/collections/c1/query":
+		type match struct {
+			id       string
+			distance float64
+			record   fakeRecord
+		}
+		var matches []match
+	records:
+		for id, rec := range f.records {
+			for key, value := range req.Where {
+				if rec.metadata[key] != value {
+					continue records
+				}
+			}
+			matches = append(matches, match{id, 1 - cosine(req.QueryEmbeddings[0], rec.embedding), rec})
+		}
+		sort.Slice(matches, func(i, j int) bool { return matches[i].distance < matches[j].distance })
+		if len(matches) > req.NResults {
+			matches = matches[:req.NResults]
+		}
+		ids, docs, metas, dists := []string{}, []string{}, []map[string]interface{}{}, []float64{}
+		for _, m := range matches {
+			ids = append(ids, m.id)
+			docs = append(docs, m.record.text)
+			metas = append(metas, m.record.metadata)
+			dists = append(dists, m.distance)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"ids":       [][]string{ids},
+			"documents": [][]string{docs},
+			"metadatas": [][]map[string]interface{}{metas},
+			"distances": [][]float64{dists},
+		})
+		return
+	default:
+		f.t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		http.NotFound(w, r)
+		return
+	}
+	w.Write([]byte("true"))
+}
+
+func cosine(a, b []float64) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	return dot / math.Sqrt(na*nb)
+}
+
+// TestStoreWithFakeServer runs without a Chroma instance; TestStore covers a real one.
+func TestStoreWithFakeServer(t *testing.T) {
+	fake := &fakeChroma{t: t, records: map[string]fakeRecord{}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	store, err := chroma.New(chroma.Config{URL: srv.URL + "/", Token: "secret", Collection: "docs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	docs := []*rag.Document{
+		{ID: "a", Text: "alpha", Embedding: []float64{1, 0, 0}, Metadata: map[string]interface{}{"lang": "en"}},
+		{ID: "b", Text: "beta", Embedding: []float64{0.9, 0.1, 0}, Metadata: map[string]interface{}{"lang": "id"}},
+		{ID: "c", Text: "gamma", Embedding: []float64{0, 0, 1}},
+	}
+	if err := store.Upsert(ctx, docs); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+	if fake.created["name"] != "docs" || fake.created["get_or_create"] != true {
+		t.Errorf("collection created with %v", fake.created)
+	}
+	if meta := fake.records["c"].metadata; meta != nil {
+		t.Errorf("empty metadata sent as %v, want null", meta)
+	}
+
+	results, err := store.Search(ctx, []float64{1, 0, 0}, 2, nil)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 2 || results[0].Doc.ID != "a" || results[0].Doc.Text != "alpha" ||
+		results[0].Doc.Metadata["lang"] != "en" || math.Abs(results[0].Score-1) > 1e-9 || results[1].Doc.ID != "b" {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+
+	results, err = store.Search(ctx, []float64{1, 0, 0}, 3, rag.Filter{"lang": "id"})
+	if err != nil || len(results) != 1 || results[0].Doc.ID != "b" {
+		t.Fatalf("filtered Search = %+v, %v", results, err)
+	}
+
+	if err := store.Delete(ctx, []string{"a"}); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	results, err = store.Search(ctx, []float64{1, 0, 0}, 1, nil)
+	if err != nil || len(results) != 1 || results[0].Doc.ID != "b" {
+		t.Fatalf("Search after delete = %+v, %v", results, err)
+	}
+
+	unauthorized, _ := chroma.New(chroma.Config{URL: srv.URL, Collection: "docs"})
+	if _, err := unauthorized.Search(ctx, []float64{1, 0, 0}, 1, nil); err == nil {
+		t.Error("Search without the token succeeded")
+	}
+}
@@ -0,0 +1,109 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/zakirkun/gatot-kaca/llm"
+)
+
+// SourceKey is the metadata key holding a document's source, such as a file path or URL.
+const SourceKey = "source"
+
+// Source returns the source of a document shown in citations: its SourceKey metadata if set,
+// otherwise its ID.
+func Source(doc *Document) string {
+	if source, ok := doc.Metadata[SourceKey].(string); ok && source != "" {
+		return source
+	}
+	return doc.ID
+}
+
+// Citation describes a retrieved document by the number it was given in an augmented prompt.
+type Citation struct {
+	Number   int                    `json:"number"` // 1-based, as in [1].
+	ID       string                 `json:"id"`
+	Source   string                 `json:"source"`
+	Text     string                 `json:"text"`
+	Score    float64                `json:"score"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+	// Cited reports whether the answer referenced the document.
+	Cited bool `json:"cited"`
+}
+
+// Citations returns the citations for results, numbered as in AugmentPrompt.
+func Citations(results []RetrievalResult) []Citation {
+	citations := make([]Citation, len(results))
+	for i, res := range results {
+		citations[i] = Citation{
+			Number:   i + 1,
+			ID:       res.Doc.ID,
+			Source:   Source(res.Doc),
+			Text:     res.Doc.Text,
+			Score:    res.Score,
+			Metadata: res.Doc.Metadata,
+		}
+	}
+	return citations
+}
+
+// Answer is a generated answer together with the documents it was based on.
+type Answer struct {
+	Text string `json:"text"`
+	// Sources lists every retrieved document in prompt order; Cited marks those the answer references.
+	Sources []Citation `json:"sources"`
+	Usage   llm.Usage  `json:"usage"`
+}
+
+// Cited returns the sources referenced by the answer.
+func (a *Answer) Cited() []Citation {
+	var cited []Citation
+	for _, c := range a.Sources {
+		if c.Cited {
+			cited = append(cited, c)
+		}
+	}
+	return cited
+}
+
+// citationPattern matches references such as [1] or [1, 3].
+var citationPattern = regexp.MustCompile(`\[(\d+(?:\s*,\s*\d+)*)\]`)
+
+// citedNumbers returns the set of citation numbers referenced in text.
+func citedNumbers(text string) map[int]bool {
+	numbers := map[int]bool{}
+	for _, m := range citationPattern.FindAllStringSubmatch(text, -1) {
+		for _, part := range strings.Split(m[1], ",") {
+			if n, err := strconv.Atoi(strings.TrimSpace(part)); err == nil {
+				numbers[n] = true
+			}
+		}
+	}
+	return numbers
+}
+
+// AnswerWithSources retrieves the top k documents for the query, asks the model to answer
+// using them with numbered citations, and returns the answer with the retrieved documents.
+func (kb *KnowledgeBase) AnswerWithSources(ctx context.Context, model, query string, k int, opts ...QueryOption) (*Answer, error) {
+	results, err := kb.Query(ctx, query, k, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	prompt := AugmentPrompt(query, results) +
+		"\n\nCite the information you use by its number in square brackets, for example [1]."
+	resp, err := kb.Client.Generate(ctx, model, llm.ModelRequest{Prompt: prompt})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate answer: %w", err)
+	}
+
+	answer := &Answer{Text: resp.Text, Sources: Citations(results), Usage: resp.Usage}
+	cited := citedNumbers(resp.Text)
+	for i := range answer.Sources {
+		answer.Sources[i].Cited = cited[answer.Sources[i].Number]
+	}
+	return answer, nil
+}
@@ -0,0 +1,94 @@
+package rag
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrDocumentNotFound is returned when a document ID is not in the knowledge base.
+var ErrDocumentNotFound = errors.New("rag: document not found")
+
+// contentHash returns the hex-encoded SHA-256 of the document text and metadata.
+func contentHash(text string, metadata map[string]interface{}) string {
+	h := sha256.New()
+	h.Write([]byte(text))
+	if len(metadata) > 0 {
+		// Map keys are marshaled in sorted order, so equal metadata hashes equally.
+		data, _ := json.Marshal(metadata)
+		h.Write([]byte{0})
+		h.Write(data)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// current returns the stored state of the document with the given ID, or nil if it is unknown.
+// With a Store, only documents written through this knowledge base are known; their
+// embeddings are not kept.
+func (kb *KnowledgeBase) current(id string) *Document {
+	if kb.Store != nil {
+		return kb.tracked[id]
+	}
+	for _, doc := range kb.Documents {
+		if doc.ID == id {
+			return doc
+		}
+	}
+	return nil
+}
+
+// prepare sets the hash and version of doc and reports whether it must be written, that is,
+// whether it is new or its text or metadata changed.
+func (kb *KnowledgeBase) prepare(doc *Document) bool {
+	doc.Hash = contentHash(doc.Text, doc.Metadata)
+	doc.Version = 1
+	if prev := kb.current(doc.ID); prev != nil {
+		if prev.Hash == doc.Hash {
+			return false
+		}
+		doc.Version = prev.Version + 1
+	}
+	return true
+}
+
+// DocumentVersion returns the version of the document with the given ID, or 0 if it is unknown.
+// Versions start at 1 and are incremented every time the document's text or metadata changes.
+func (kb *KnowledgeBase) DocumentVersion(id string) int {
+	if doc := kb.current(id); doc != nil {
+		return doc.Version
+	}
+	return 0
+}
+
+// UpdateDocument replaces the text of an existing document, keeping its metadata. It returns
+// ErrDocumentNotFound if the document is unknown.
+func (kb *KnowledgeBase) UpdateDocument(ctx context.Context, id, text string) error {
+	prev := kb.current(id)
+	if prev == nil {
+		return fmt.Errorf("%w: %s", ErrDocumentNotFound, id)
+	}
+	return kb.AddDocuments(ctx, []Document{{ID: id, Text: text, Metadata: prev.Metadata}})
+}
+
+// DeleteDocument removes the document with the given ID. Without a Store, it returns
+// ErrDocumentNotFound if the document is unknown; a Store ignores missing IDs.
+func (kb *KnowledgeBase) DeleteDocument(ctx context.Context, id string) error {
+	if kb.Store != nil {
+		if err := kb.Store.Delete(ctx, []string{id}); err != nil {
+			return fmt.Errorf("failed to delete document '%s': %w", id, err)
+		}
+		delete(kb.tracked, id)
+		return nil
+	}
+	for i, doc := range kb.Documents {
+		if doc.ID == id {
+			kb.Documents = append(kb.Documents[:i], kb.Documents[i+1:]...)
+			kb.ResetIndex()
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrDocumentNotFound, id)
+}
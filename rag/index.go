@@ -0,0 +1,47 @@
+package rag
+
+import "github.com/zakirkun/gatot-kaca/vectors"
+
+// DefaultExactSearchThreshold is the store size below which Query searches exactly even when
+// ANN is enabled, since brute force is fast and precise for small stores.
+const DefaultExactSearchThreshold = 10000
+
+// search returns the k documents most similar to the query embedding, using the HNSW index
+// when ANN is enabled and the store is large enough.
+func (kb *KnowledgeBase) search(query []float64, k int) []vectors.Scored {
+	threshold := kb.ExactSearchThreshold
+	if threshold <= 0 {
+		threshold = DefaultExactSearchThreshold
+	}
+	if kb.ANN == nil || len(kb.Documents) < threshold {
+		embeddings := make([][]float64, len(kb.Documents))
+		for i, doc := range kb.Documents {
+			embeddings[i] = doc.Embedding
+		}
+		return vectors.TopKCosine(query, embeddings, k)
+	}
+	return kb.syncIndex().Search(query, k)
+}
+
+// syncIndex brings the HNSW index up to date with the documents. Documents are indexed by
+// position, so new documents appended to the store are inserted incrementally; if the store
+// shrank, the index is rebuilt.
+func (kb *KnowledgeBase) syncIndex() *vectors.HNSW {
+	kb.indexMu.Lock()
+	defer kb.indexMu.Unlock()
+	if kb.index == nil || kb.index.Len() > len(kb.Documents) {
+		kb.index = vectors.NewHNSW(*kb.ANN)
+	}
+	for i := kb.index.Len(); i < len(kb.Documents); i++ {
+		kb.index.Insert(kb.Documents[i].Embedding)
+	}
+	return kb.index
+}
+
+// ResetIndex discards the HNSW index so it is rebuilt on the next query. Call it after
+// modifying or reordering Documents directly.
+func (kb *KnowledgeBase) ResetIndex() {
+	kb.indexMu.Lock()
+	defer kb.indexMu.Unlock()
+	kb.index = nil
+}
@@ -0,0 +1,15 @@
+// Package docid maps arbitrary document IDs to the UUIDs required by some vector stores.
+package docid
+
+import (
+	"crypto/sha1"
+	"fmt"
+)
+
+// UUID hashes a document ID into a deterministic, name-based (version 5 style) UUID.
+func UUID(id string) string {
+	sum := sha1.Sum([]byte(id))
+	sum[6] = (sum[6] & 0x0f) | 0x50 // Version 5.
+	sum[8] = (sum[8] & 0x3f) | 0x80 // RFC 4122 variant.
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
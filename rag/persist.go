@@ -0,0 +1,151 @@
+package rag
+
+import (
+	"bufio"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// snapshotMagic starts every file written by Save. Files starting with snapshotMagicV1, written
+// by earlier versions, are still loaded.
+const (
+	snapshotMagic   = "GKRAG2\n"
+	snapshotMagicV1 = "GKRAG1\n"
+)
+
+// snapshot is the gob-encoded content of a saved knowledge base.
+type snapshot struct {
+	ModelName string
+	Documents []snapshotDocument
+}
+
+// snapshotDocument is a saved document. Its metadata is encoded as JSON, since gob cannot
+// encode the values of a map[string]interface{} whose types were not registered, such as the
+// nested maps and slices of documents loaded from JSON.
+type snapshotDocument struct {
+	ID        string
+	Text      string
+	Embedding []float64
+	Metadata  []byte
+	Version   int
+	Hash      string
+}
+
+// snapshotV1 is the content of a file written with snapshotMagicV1.
+type snapshotV1 struct {
+	ModelName string
+	Documents []*Document
+}
+
+// Save writes the in-memory documents, including their embeddings, to w in a compact binary
+// format. Documents kept in an external Store are not saved. Metadata values must be
+// encodable as JSON.
+func (kb *KnowledgeBase) Save(w io.Writer) error {
+	snap := snapshot{ModelName: kb.ModelName, Documents: make([]snapshotDocument, len(kb.Documents))}
+	for i, doc := range kb.Documents {
+		sd := snapshotDocument{ID: doc.ID, Text: doc.Text, Embedding: doc.Embedding, Version: doc.Version, Hash: doc.Hash}
+		if doc.Metadata != nil {
+			data, err := json.Marshal(doc.Metadata)
+			if err != nil {
+				return fmt.Errorf("failed to save knowledge base: metadata of document %q: %w", doc.ID, err)
+			}
+			sd.Metadata = data
+		}
+		snap.Documents[i] = sd
+	}
+	if _, err := io.WriteString(w, snapshotMagic); err != nil {
+		return err
+	}
+	if err := gob.NewEncoder(w).Encode(snap); err != nil {
+		return fmt.Errorf("failed to save knowledge base: %w", err)
+	}
+	return nil
+}
+
+// Load replaces the in-memory documents with those read from r. It reads files written by
+// Save as well as JSON arrays of documents. Loading documents embedded with a different
+// model than ModelName is an error, since their embeddings are not comparable.
+func (kb *KnowledgeBase) Load(r io.Reader) error {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(len(snapshotMagic))
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("failed to load knowledge base: %w", err)
+	}
+
+	var snap snapshotV1
+	switch string(magic) {
+	case snapshotMagic:
+		br.Discard(len(snapshotMagic))
+		var s snapshot
+		if err := gob.NewDecoder(br).Decode(&s); err != nil {
+			return fmt.Errorf("failed to load knowledge base: %w", err)
+		}
+		snap.ModelName = s.ModelName
+		snap.Documents = make([]*Document, len(s.Documents))
+		for i, sd := range s.Documents {
+			doc := &Document{ID: sd.ID, Text: sd.Text, Embedding: sd.Embedding, Version: sd.Version, Hash: sd.Hash}
+			if sd.Metadata != nil {
+				if err := json.Unmarshal(sd.Metadata, &doc.Metadata); err != nil {
+					return fmt.Errorf("failed to load knowledge base: metadata of document %q: %w", sd.ID, err)
+				}
+			}
+			snap.Documents[i] = doc
+		}
+	case snapshotMagicV1:
+		br.Discard(len(snapshotMagicV1))
+		if err := gob.NewDecoder(br).Decode(&snap); err != nil {
+			return fmt.Errorf("failed to load knowledge base: %w", err)
+		}
+	default:
+		if err := json.NewDecoder(br).Decode(&snap.Documents); err != nil {
+			return fmt.Errorf("failed to load knowledge base: %w", err)
+		}
+	}
+
+	if snap.ModelName != "" && kb.ModelName != "" && snap.ModelName != kb.ModelName {
+		return fmt.Errorf("failed to load knowledge base: embeddings were computed with model %q, not %q",
+			snap.ModelName, kb.ModelName)
+	}
+	if snap.Documents == nil {
+		snap.Documents = []*Document{}
+	}
+	kb.Documents = snap.Documents
+	kb.ResetIndex()
+	return nil
+}
+
+// SaveFile saves the knowledge base to path, replacing the file atomically.
+func (kb *KnowledgeBase) SaveFile(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	w := bufio.NewWriter(tmp)
+	if err := kb.Save(w); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadFile loads the knowledge base from a file written by SaveFile.
+func (kb *KnowledgeBase) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return kb.Load(f)
+}
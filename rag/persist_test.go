@@ -0,0 +1,74 @@
+package rag
+
+import (
+	"bytes"
+	"encoding/gob"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	// Documents loaded from JSON hold nested maps and slices in their metadata.
+	src := &KnowledgeBase{ModelName: "embed"}
+	err := src.Load(strings.NewReader(`[{"ID":"a","Text":"alpha","Embedding":[0.5,1],` +
+		`"Metadata":{"tags":["x","y"],"source":{"name":"wiki","page":3}},"Version":2,"Hash":"h"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src.Documents = append(src.Documents, &Document{ID: "b", Text: "beta",
+		Metadata: map[string]interface{}{"updated": time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)}})
+
+	var buf bytes.Buffer
+	if err := src.Save(&buf); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	dst := &KnowledgeBase{ModelName: "embed"}
+	if err := dst.Load(&buf); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	if len(dst.Documents) != 2 {
+		t.Fatalf("loaded %d documents, want 2", len(dst.Documents))
+	}
+	if !reflect.DeepEqual(dst.Documents[0], src.Documents[0]) {
+		t.Errorf("document a = %+v, want %+v", dst.Documents[0], src.Documents[0])
+	}
+	// Times come back in their JSON form.
+	if got := dst.Documents[1].Metadata["updated"]; got != "2026-01-02T03:04:05Z" {
+		t.Errorf("updated = %#v", got)
+	}
+
+	// Saving again what was loaded works too.
+	if err := dst.Save(&bytes.Buffer{}); err != nil {
+		t.Errorf("save after load: %v", err)
+	}
+}
+
+func TestLoadV1Snapshot(t *testing.T) {
+	var buf bytes.Buffer
+	buf.WriteString(snapshotMagicV1)
+	docs := []*Document{{ID: "a", Text: "alpha", Embedding: []float64{1}, Metadata: map[string]interface{}{"lang": "en"}}}
+	if err := gob.NewEncoder(&buf).Encode(snapshotV1{ModelName: "embed", Documents: docs}); err != nil {
+		t.Fatal(err)
+	}
+
+	kb := &KnowledgeBase{}
+	if err := kb.Load(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if len(kb.Documents) != 1 || !reflect.DeepEqual(kb.Documents[0], docs[0]) {
+		t.Errorf("documents = %+v", kb.Documents)
+	}
+}
+
+func TestLoadModelMismatch(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (&KnowledgeBase{ModelName: "a"}).Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := (&KnowledgeBase{ModelName: "b"}).Load(&buf); err == nil {
+		t.Error("loading embeddings of another model succeeded")
+	}
+}
@@ -0,0 +1,142 @@
+// Package pinecone implements a rag.VectorStore backed by a Pinecone index,
+// using the Pinecone data plane REST API.
+package pinecone
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/zakirkun/gatot-kaca/rag"
+	"github.com/zakirkun/gatot-kaca/rag/internal/httpjson"
+)
+
+// metadataText is the metadata key under which the document text is stored.
+const metadataText = "text"
+
+// upsertBatchSize is the number of vectors sent per upsert request, as recommended by Pinecone.
+const upsertBatchSize = 100
+
+// Config configures a Store.
+type Config struct {
+	// Host is the index host shown in the Pinecone console, e.g. "https://my-index-abc123.svc.us-east1-gcp.pinecone.io".
+	Host      string
+	APIKey    string
+	Namespace string // Optional namespace within the index.
+	// HTTPClient is optional; defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// Store is a rag.VectorStore backed by an existing Pinecone index.
+type Store struct {
+	cfg Config
+}
+
+var _ rag.VectorStore = (*Store)(nil)
+
+// New creates a Store for the configured index.
+func New(cfg Config) (*Store, error) {
+	if cfg.Host == "" || cfg.APIKey == "" {
+		return nil, errors.New("pinecone: host and API key are required")
+	}
+	if !strings.Contains(cfg.Host, "://") {
+		cfg.Host = "https://" + cfg.Host
+	}
+	cfg.Host = strings.TrimRight(cfg.Host, "/")
+	return &Store{cfg: cfg}, nil
+}
+
+// WithNamespace returns a Store for the same index using another namespace.
+func (s *Store) WithNamespace(namespace string) *Store {
+	cfg := s.cfg
+	cfg.Namespace = namespace
+	return &Store{cfg: cfg}
+}
+
+func (s *Store) do(ctx context.Context, path string, body, out interface{}) error {
+	headers := map[string]string{"Api-Key": s.cfg.APIKey}
+	if err := httpjson.Do(ctx, s.cfg.HTTPClient, http.MethodPost, s.cfg.Host+path, headers, body, out); err != nil {
+		return fmt.Errorf("pinecone: %w", err)
+	}
+	return nil
+}
+
+type vector struct {
+	ID       string                 `json:"id"`
+	Values   []float64              `json:"values"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// Upsert implements rag.VectorStore. The document text is stored in the vector metadata
+// next to the document's own metadata.
+func (s *Store) Upsert(ctx context.Context, docs []*rag.Document) error {
+	for start := 0; start < len(docs); start += upsertBatchSize {
+		end := min(start+upsertBatchSize, len(docs))
+		vectors := make([]vector, 0, end-start)
+		for _, doc := range docs[start:end] {
+			metadata := make(map[string]interface{}, len(doc.Metadata)+1)
+			for k, v := range doc.Metadata {
+				metadata[k] = v
+			}
+			metadata[metadataText] = doc.Text
+			vectors = append(vectors, vector{ID: doc.ID, Values: doc.Embedding, Metadata: metadata})
+		}
+		body := map[string]interface{}{"vectors": vectors, "namespace": s.cfg.Namespace}
+		if err := s.do(ctx, "/vectors/upsert", body, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Search implements rag.VectorStore. Filter values are matched with Pinecone's $eq operator.
+func (s *Store) Search(ctx context.Context, embedding []float64, k int, filter rag.Filter) ([]rag.RetrievalResult, error) {
+	body := map[string]interface{}{
+		"vector":          embedding,
+		"topK":            k,
+		"includeMetadata": true,
+		"namespace":       s.cfg.Namespace,
+	}
+	if len(filter) > 0 {
+		f := make(map[string]interface{}, len(filter))
+		for key, value := range filter {
+			f[key] = map[string]interface{}{"$eq": value}
+		}
+		body["filter"] = f
+	}
+
+	var resp struct {
+		Matches []struct {
+			ID       string                 `json:"id"`
+			Score    float64                `json:"score"`
+			Metadata map[string]interface{} `json:"metadata"`
+		} `json:"matches"`
+	}
+	if err := s.do(ctx, "/query", body, &resp); err != nil {
+		return nil, err
+	}
+
+	results := make([]rag.RetrievalResult, 0, len(resp.Matches))
+	for _, m := range resp.Matches {
+		doc := &rag.Document{ID: m.ID, Metadata: map[string]interface{}{}}
+		for k, v := range m.Metadata {
+			if k == metadataText {
+				doc.Text, _ = v.(string)
+				continue
+			}
+			doc.Metadata[k] = v
+		}
+		results = append(results, rag.RetrievalResult{Doc: doc, Score: m.Score})
+	}
+	return results, nil
+}
+
+// Delete implements rag.VectorStore.
+func (s *Store) Delete(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return s.do(ctx, "/vectors/delete", map[string]interface{}{"ids": ids, "namespace": s.cfg.Namespace}, nil)
+}
@@ -0,0 +1,158 @@
+package pinecone_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/zakirkun/gatot-kaca/rag"
+	"github.com/zakirkun/gatot-kaca/rag/pinecone"
+)
+
+type fakeVector struct {
+	ID       string                 `json:"id"`
+	Values   []float64              `json:"values"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// fakePinecone serves the parts of the Pinecone data plane API used by the store.
+type fakePinecone struct {
+	t *testing.T
+
+	mu         sync.Mutex
+	namespaces map[string]map[string]fakeVector
+	upserts    int // Number of upsert requests.
+}
+
+func (f *fakePinecone) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.Header.Get("Api-Key") != "secret" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var req struct {
+		Namespace string                            `json:"namespace"`
+		Vectors   []fakeVector                      `json:"vectors"`
+		IDs       []string                          `json:"ids"`
+		Vector    []float64                         `json:"vector"`
+		TopK      int                               `json:"topK"`
+		Filter    map[string]map[string]interface{} `json:"filter"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	ns := f.namespaces[req.Namespace]
+	if ns == nil {
+		ns = map[string]fakeVector{}
+		f.namespaces[req.Namespace] = ns
+	}
+
+	switch r.URL.Path {
+	case "/vectors/upsert":
+		f.upserts++
+		for _, v := range req.Vectors {
+			ns[v.ID] = v
+		}
+	case "/vectors/delete":
+		for _, id := range req.IDs {
+			delete(ns, id)
+		}
+	case "/query":
+		type match struct {
+			ID       string                 `json:"id"`
+			Score    float64                `json:"score"`
+			Metadata map[string]interface{} `json:"metadata"`
+		}
+		var matches []match
+	vectors:
+		for _, v := range ns {
+			for key, cond := range req.Filter {
+				if v.Metadata[key] != cond["$eq"] {
+					continue vectors
+				}
+			}
+			matches = append(matches, match{ID: v.ID, Score: cosine(req.Vector, v.Values), Metadata: v.Metadata})
+		}
+		sort.Slice(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+		if len(matches) > req.TopK {
+			matches = matches[:req.TopK]
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"matches": matches})
+		return
+	default:
+		f.t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		http.NotFound(w, r)
+		return
+	}
+	fmt.Fprint(w, "{}")
+}
+
+func cosine(a, b []float64) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	return dot / math.Sqrt(na*nb)
+}
+
+func TestStore(t *testing.T) {
+	fake := &fakePinecone{t: t, namespaces: map[string]map[string]fakeVector{}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	store, err := pinecone.New(pinecone.Config{Host: srv.URL, APIKey: "secret", Namespace: "main"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	docs := []*rag.Document{
+		{ID: "a", Text: "alpha", Embedding: []float64{1, 0, 0}, Metadata: map[string]interface{}{"lang": "en"}},
+		{ID: "b", Text: "beta", Embedding: []float64{0.9, 0.1, 0}, Metadata: map[string]interface{}{"lang": "id"}},
+	}
+	// Fill up the first upsert batch so that the documents are sent in two requests.
+	for i := 0; i < 99; i++ {
+		docs = append(docs, &rag.Document{ID: fmt.Sprintf("filler-%d", i), Text: "filler", Embedding: []float64{0, 0, 1}})
+	}
+	if err := store.Upsert(ctx, docs); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+	if fake.upserts != 2 || len(fake.namespaces["main"]) != 101 {
+		t.Errorf("%d upserts stored %d vectors, want 2 upserts of 101 vectors", fake.upserts, len(fake.namespaces["main"]))
+	}
+
+	results, err := store.Search(ctx, []float64{1, 0, 0}, 2, nil)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 2 || results[0].Doc.ID != "a" || results[0].Doc.Text != "alpha" ||
+		results[0].Doc.Metadata["lang"] != "en" || results[1].Doc.ID != "b" {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	results, err = store.Search(ctx, []float64{1, 0, 0}, 3, rag.Filter{"lang": "id"})
+	if err != nil || len(results) != 1 || results[0].Doc.ID != "b" {
+		t.Fatalf("filtered Search = %+v, %v", results, err)
+	}
+
+	if results, err := store.WithNamespace("other").Search(ctx, []float64{1, 0, 0}, 2, nil); err != nil || len(results) != 0 {
+		t.Errorf("Search in another namespace = %+v, %v; want no results", results, err)
+	}
+
+	if err := store.Delete(ctx, []string{"a"}); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	results, err = store.Search(ctx, []float64{1, 0, 0}, 1, nil)
+	if err != nil || len(results) != 1 || results[0].Doc.ID != "b" {
+		t.Fatalf("Search after delete = %+v, %v", results, err)
+	}
+
+	if _, err := pinecone.New(pinecone.Config{Host: srv.URL}); err == nil {
+		t.Error("New accepted a config without an API key")
+	}
+}
@@ -0,0 +1,178 @@
+// Package qdrant implements a rag.VectorStore backed by a Qdrant collection,
+// using the Qdrant REST API.
+package qdrant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/zakirkun/gatot-kaca/rag"
+	"github.com/zakirkun/gatot-kaca/rag/internal/docid"
+	"github.com/zakirkun/gatot-kaca/rag/internal/httpjson"
+)
+
+// Payload keys used to store the document fields next to its metadata.
+const (
+	payloadID   = "doc_id"
+	payloadText = "text"
+)
+
+// Config configures a Store.
+type Config struct {
+	URL        string // Base URL of the Qdrant server, e.g. "http://localhost:6333".
+	APIKey     string // Optional API key, sent in the api-key header.
+	Collection string
+	// Distance is the metric used when the collection is created; defaults to "Cosine".
+	Distance   string
+	HTTPClient *http.Client // Optional; defaults to http.DefaultClient.
+}
+
+// Store is a rag.VectorStore backed by a Qdrant collection. The collection is created on the
+// first upsert if it does not exist, sized from the first embedding.
+type Store struct {
+	cfg Config
+
+	mu      sync.Mutex
+	created bool
+}
+
+var _ rag.VectorStore = (*Store)(nil)
+
+// New creates a Store for the configured collection.
+func New(cfg Config) (*Store, error) {
+	if cfg.URL == "" || cfg.Collection == "" {
+		return nil, errors.New("qdrant: URL and collection are required")
+	}
+	cfg.URL = strings.TrimRight(cfg.URL, "/")
+	if cfg.Distance == "" {
+		cfg.Distance = "Cosine"
+	}
+	return &Store{cfg: cfg}, nil
+}
+
+func (s *Store) do(ctx context.Context, method, path string, body, out interface{}) error {
+	headers := map[string]string{}
+	if s.cfg.APIKey != "" {
+		headers["api-key"] = s.cfg.APIKey
+	}
+	url := s.cfg.URL + "/collections/" + s.cfg.Collection + path
+	if err := httpjson.Do(ctx, s.cfg.HTTPClient, method, url, headers, body, out); err != nil {
+		return fmt.Errorf("qdrant: %w", err)
+	}
+	return nil
+}
+
+// EnsureCollection creates the collection with the given vector size if it does not exist.
+func (s *Store) EnsureCollection(ctx context.Context, size int) error {
+	err := s.do(ctx, http.MethodGet, "", nil, nil)
+	var statusErr *httpjson.StatusError
+	if err == nil {
+		return nil
+	}
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		return err
+	}
+	body := map[string]interface{}{
+		"vectors": map[string]interface{}{"size": size, "distance": s.cfg.Distance},
+	}
+	return s.do(ctx, http.MethodPut, "", body, nil)
+}
+
+type point struct {
+	ID      string                 `json:"id"`
+	Vector  []float64              `json:"vector,omitempty"`
+	Payload map[string]interface{} `json:"payload,omitempty"`
+	Score   float64                `json:"score,omitempty"`
+}
+
+// PointID returns the Qdrant point ID used for a document ID. Qdrant only accepts unsigned
+// integers and UUIDs, so the document ID is hashed into a deterministic UUID.
+func PointID(docID string) string {
+	return docid.UUID(docID)
+}
+
+// Upsert implements rag.VectorStore. The document text and metadata are stored as payload.
+func (s *Store) Upsert(ctx context.Context, docs []*rag.Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	if !s.created {
+		if err := s.EnsureCollection(ctx, len(docs[0].Embedding)); err != nil {
+			s.mu.Unlock()
+			return err
+		}
+		s.created = true
+	}
+	s.mu.Unlock()
+	points := make([]point, len(docs))
+	for i, doc := range docs {
+		payload := make(map[string]interface{}, len(doc.Metadata)+2)
+		for k, v := range doc.Metadata {
+			payload[k] = v
+		}
+		payload[payloadID] = doc.ID
+		payload[payloadText] = doc.Text
+		points[i] = point{ID: PointID(doc.ID), Vector: doc.Embedding, Payload: payload}
+	}
+	return s.do(ctx, http.MethodPut, "/points?wait=true", map[string]interface{}{"points": points}, nil)
+}
+
+// Search implements rag.VectorStore. Filter values are matched exactly against payload fields.
+func (s *Store) Search(ctx context.Context, embedding []float64, k int, filter rag.Filter) ([]rag.RetrievalResult, error) {
+	body := map[string]interface{}{
+		"vector":       embedding,
+		"limit":        k,
+		"with_payload": true,
+	}
+	if len(filter) > 0 {
+		must := make([]map[string]interface{}, 0, len(filter))
+		for key, value := range filter {
+			must = append(must, map[string]interface{}{
+				"key":   key,
+				"match": map[string]interface{}{"value": value},
+			})
+		}
+		body["filter"] = map[string]interface{}{"must": must}
+	}
+
+	var resp struct {
+		Result []point `json:"result"`
+	}
+	if err := s.do(ctx, http.MethodPost, "/points/search", body, &resp); err != nil {
+		return nil, err
+	}
+
+	results := make([]rag.RetrievalResult, 0, len(resp.Result))
+	for _, p := range resp.Result {
+		doc := &rag.Document{Metadata: map[string]interface{}{}}
+		for k, v := range p.Payload {
+			switch k {
+			case payloadID:
+				doc.ID, _ = v.(string)
+			case payloadText:
+				doc.Text, _ = v.(string)
+			default:
+				doc.Metadata[k] = v
+			}
+		}
+		results = append(results, rag.RetrievalResult{Doc: doc, Score: p.Score})
+	}
+	return results, nil
+}
+
+// Delete implements rag.VectorStore.
+func (s *Store) Delete(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	points := make([]string, len(ids))
+	for i, id := range ids {
+		points[i] = PointID(id)
+	}
+	return s.do(ctx, http.MethodPost, "/points/delete?wait=true", map[string]interface{}{"points": points}, nil)
+}
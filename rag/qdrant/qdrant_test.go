@@ -0,0 +1,163 @@
+package qdrant_test
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/zakirkun/gatot-kaca/rag"
+	"github.com/zakirkun/gatot-kaca/rag/qdrant"
+)
+
+type fakePoint struct {
+	ID      string                 `json:"id"`
+	Vector  []float64              `json:"vector,omitempty"`
+	Payload map[string]interface{} `json:"payload,omitempty"`
+	Score   float64                `json:"score,omitempty"`
+}
+
+// fakeQdrant serves the parts of the Qdrant REST API used by the store for one collection.
+type fakeQdrant struct {
+	t *testing.T
+
+	mu      sync.Mutex
+	created map[string]interface{} // Body of the collection creation request.
+	points  map[string]fakePoint
+}
+
+func (f *fakeQdrant) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.Header.Get("api-key") != "secret" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var body map[string]json.RawMessage
+	json.NewDecoder(r.Body).Decode(&body)
+
+	switch r.Method + " " + r.URL.Path {
+	case "GET /collections/docs":
+		if f.created == nil {
+			http.NotFound(w, r)
+			return
+		}
+	case "PUT /collections/docs":
+		json.Unmarshal(body["vectors"], &f.created)
+	case "PUT /collections/docs/points":
+		var points []fakePoint
+		json.Unmarshal(body["points"], &points)
+		for _, p := range points {
+			f.points[p.ID] = p
+		}
+	case "POST /collections/docs/points/delete":
+		var ids []string
+		json.Unmarshal(body["points"], &ids)
+		for _, id := range ids {
+			delete(f.points, id)
+		}
+	case "POST /collections/docs/points/search":
+		var req struct {
+			Vector []float64 `json:"vector"`
+			Limit  int       `json:"limit"`
+			Filter struct {
+				Must []struct {
+					Key   string `json:"key"`
+					Match struct {
+						Value interface{} `json:"value"`
+					} `json:"match"`
+				} `json:"must"`
+			} `json:"filter"`
+		}
+		raw, _ := json.Marshal(body)
+		json.Unmarshal(raw, &req)
+		var result []fakePoint
+	points:
+		for _, p := range f.points {
+			for _, cond := range req.Filter.Must {
+				if p.Payload[cond.Key] != cond.Match.Value {
+					continue points
+				}
+			}
+			result = append(result, fakePoint{ID: p.ID, Payload: p.Payload, Score: cosine(req.Vector, p.Vector)})
+		}
+		sort.Slice(result, func(i, j int) bool { return result[i].Score > result[j].Score })
+		if len(result) > req.Limit {
+			result = result[:req.Limit]
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": result})
+		return
+	default:
+		f.t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		http.NotFound(w, r)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"result": true})
+}
+
+func cosine(a, b []float64) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	return dot / math.Sqrt(na*nb)
+}
+
+func TestStore(t *testing.T) {
+	fake := &fakeQdrant{t: t, points: map[string]fakePoint{}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	store, err := qdrant.New(qdrant.Config{URL: srv.URL + "/", APIKey: "secret", Collection: "docs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	docs := []*rag.Document{
+		{ID: "a", Text: "alpha", Embedding: []float64{1, 0, 0}, Metadata: map[string]interface{}{"lang": "en"}},
+		{ID: "b", Text: "beta", Embedding: []float64{0.9, 0.1, 0}, Metadata: map[string]interface{}{"lang": "id"}},
+		{ID: "c", Text: "gamma", Embedding: []float64{0, 0, 1}, Metadata: map[string]interface{}{"lang": "en"}},
+	}
+	if err := store.Upsert(ctx, docs); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+	if fake.created["size"] != 3.0 || fake.created["distance"] != "Cosine" {
+		t.Errorf("collection created with %v", fake.created)
+	}
+	if _, ok := fake.points[qdrant.PointID("a")]; !ok {
+		t.Errorf("points are not stored under their UUID: %v", fake.points)
+	}
+
+	results, err := store.Search(ctx, []float64{1, 0, 0}, 2, nil)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 2 || results[0].Doc.ID != "a" || results[0].Doc.Text != "alpha" ||
+		results[0].Doc.Metadata["lang"] != "en" || results[1].Doc.ID != "b" {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+
+	results, err = store.Search(ctx, []float64{1, 0, 0}, 3, rag.Filter{"lang": "id"})
+	if err != nil || len(results) != 1 || results[0].Doc.ID != "b" {
+		t.Fatalf("filtered Search = %+v, %v", results, err)
+	}
+
+	if err := store.Delete(ctx, []string{"a"}); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	results, err = store.Search(ctx, []float64{1, 0, 0}, 1, nil)
+	if err != nil || len(results) != 1 || results[0].Doc.ID != "b" {
+		t.Fatalf("Search after delete = %+v, %v", results, err)
+	}
+
+	unauthorized, _ := qdrant.New(qdrant.Config{URL: srv.URL, Collection: "docs"})
+	if _, err := unauthorized.Search(ctx, []float64{1, 0, 0}, 1, nil); err == nil {
+		t.Error("Search without the API key succeeded")
+	}
+}
@@ -0,0 +1,222 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/zakirkun/gatot-kaca/logging"
+	"github.com/zakirkun/gatot-kaca/vectors"
+)
+
+// DefaultMMRLambda balances relevance against diversity in MMR re-ranking.
+const DefaultMMRLambda = 0.5
+
+// QueryOption configures a Query.
+type QueryOption func(*queryOptions)
+
+type queryOptions struct {
+	filter       Filter
+	mmr          bool
+	lambda       float64
+	fetchK       int
+	minScore     float64
+	hasMinScore  bool
+	maxPerSource int
+	rewriter     QueryRewriter
+}
+
+// WithFilter only considers documents whose metadata matches filter.
+func WithFilter(filter Filter) QueryOption {
+	return func(o *queryOptions) {
+		o.filter = filter
+	}
+}
+
+// WithMMR re-ranks the candidates with Maximal Marginal Relevance, so that near-duplicate
+// documents are not all returned. Lambda weighs similarity to the query against dissimilarity
+// to the documents already selected: 1 ranks by relevance only, 0 by diversity only.
+// A lambda outside [0, 1] uses DefaultMMRLambda.
+func WithMMR(lambda float64) QueryOption {
+	return func(o *queryOptions) {
+		o.mmr = true
+		o.lambda = lambda
+	}
+}
+
+// WithFetchK sets the number of candidates retrieved for each query before MMR re-ranking,
+// score thresholds and source caps are applied. It defaults to four times k when any of them
+// or query rewriting is used.
+func WithFetchK(n int) QueryOption {
+	return func(o *queryOptions) {
+		o.fetchK = n
+	}
+}
+
+// WithMinScore drops documents whose similarity to the query is below score, so fewer than
+// k documents may be returned.
+func WithMinScore(score float64) QueryOption {
+	return func(o *queryOptions) {
+		o.minScore = score
+		o.hasMinScore = true
+	}
+}
+
+// WithMaxPerSource limits the number of documents returned from the same source (see Source).
+func WithMaxPerSource(n int) QueryOption {
+	return func(o *queryOptions) {
+		o.maxPerSource = n
+	}
+}
+
+// Query returns the top k documents that are most similar to the provided query text.
+func (kb *KnowledgeBase) Query(ctx context.Context, query string, k int, opts ...QueryOption) ([]RetrievalResult, error) {
+	var o queryOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if !o.mmr && !o.hasMinScore && o.maxPerSource <= 0 && o.rewriter == nil {
+		queryEmbedding, err := kb.embed(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute embedding for query: %w", err)
+		}
+		return kb.retrieve(ctx, queryEmbedding, k, o.filter)
+	}
+
+	fetchK := o.fetchK
+	if fetchK < k {
+		fetchK = 4 * k
+	}
+	queries := []string{query}
+	if o.rewriter != nil {
+		rewritten, err := o.rewriter.Rewrite(ctx, query)
+		if err != nil {
+			logging.FromContext(ctx).Warn("query rewrite failed", logging.KeyComponent, "rag", logging.KeyError, err)
+		}
+		queries = append(queries, rewritten...)
+	}
+	candidates, err := kb.retrieveAll(ctx, queries, fetchK, o.filter)
+	if err != nil {
+		return nil, err
+	}
+	if o.hasMinScore {
+		kept := candidates[:0]
+		for _, c := range candidates {
+			if c.Score >= o.minScore {
+				kept = append(kept, c)
+			}
+		}
+		candidates = kept
+	}
+	if o.mmr {
+		return kb.rerankMMR(ctx, candidates, k, o)
+	}
+	return capPerSource(candidates, k, o.maxPerSource), nil
+}
+
+// retrieveAll retrieves k candidates for each query and merges them by document ID, keeping
+// the best score, in descending order of score.
+func (kb *KnowledgeBase) retrieveAll(ctx context.Context, queries []string, k int, filter Filter) ([]RetrievalResult, error) {
+	var merged []RetrievalResult
+	positions := map[string]int{}
+	for _, q := range queries {
+		queryEmbedding, err := kb.embed(ctx, q)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute embedding for query: %w", err)
+		}
+		results, err := kb.retrieve(ctx, queryEmbedding, k, filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, res := range results {
+			if i, ok := positions[res.Doc.ID]; ok {
+				if res.Score > merged[i].Score {
+					merged[i].Score = res.Score
+				}
+				continue
+			}
+			positions[res.Doc.ID] = len(merged)
+			merged = append(merged, res)
+		}
+	}
+	if len(queries) > 1 {
+		sort.SliceStable(merged, func(i, j int) bool { return merged[i].Score > merged[j].Score })
+	}
+	return merged, nil
+}
+
+// capPerSource returns up to k results in order, skipping results whose source already has
+// max results. A max of zero means no cap.
+func capPerSource(results []RetrievalResult, k, max int) []RetrievalResult {
+	counts := map[string]int{}
+	selected := make([]RetrievalResult, 0, k)
+	for _, res := range results {
+		if len(selected) == k {
+			break
+		}
+		source := Source(res.Doc)
+		if max > 0 && counts[source] >= max {
+			continue
+		}
+		counts[source]++
+		selected = append(selected, res)
+	}
+	return selected
+}
+
+// rerankMMR greedily selects up to k candidates, each maximizing
+// lambda*relevance - (1-lambda)*(max similarity to the selected documents).
+// Candidates returned by a Store without embeddings are embedded again, using the cache.
+func (kb *KnowledgeBase) rerankMMR(ctx context.Context, candidates []RetrievalResult, k int, o queryOptions) ([]RetrievalResult, error) {
+	lambda := o.lambda
+	if lambda < 0 || lambda > 1 {
+		lambda = DefaultMMRLambda
+	}
+
+	embeddings := make([][]float64, len(candidates))
+	for i, c := range candidates {
+		embeddings[i] = c.Doc.Embedding
+		if len(embeddings[i]) == 0 {
+			embedding, err := kb.embed(ctx, c.Doc.Text)
+			if err != nil {
+				return nil, fmt.Errorf("failed to compute embedding for document '%s': %w", c.Doc.ID, err)
+			}
+			embeddings[i] = embedding
+		}
+		embeddings[i] = vectors.Normalize(embeddings[i])
+	}
+
+	counts := map[string]int{}
+	used := make([]bool, len(candidates))
+	// redundancy[i] is the highest similarity of candidate i to a selected document.
+	redundancy := make([]float64, len(candidates))
+	var selected []RetrievalResult
+	for len(selected) < k {
+		best := -1
+		var bestScore float64
+		for i, c := range candidates {
+			if used[i] || (o.maxPerSource > 0 && counts[Source(c.Doc)] >= o.maxPerSource) {
+				continue
+			}
+			score := lambda*c.Score - (1-lambda)*redundancy[i]
+			if best < 0 || score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		if best < 0 {
+			break
+		}
+		used[best] = true
+		counts[Source(candidates[best].Doc)]++
+		selected = append(selected, candidates[best])
+		for i := range candidates {
+			if !used[i] {
+				if sim := vectors.Dot(embeddings[i], embeddings[best]); len(selected) == 1 || sim > redundancy[i] {
+					redundancy[i] = sim
+				}
+			}
+		}
+	}
+	return selected, nil
+}
@@ -3,11 +3,11 @@ package rag
 import (
 	"context"
 	"fmt"
-	"math"
-	"sort"
 	"strings"
+	"sync"
 
 	"github.com/zakirkun/gatot-kaca/llm"
+	"github.com/zakirkun/gatot-kaca/vectors"
 )
 
 // Document represents a piece of text stored in the knowledge base.
@@ -15,6 +15,12 @@ type Document struct {
 	ID        string
 	Text      string
 	Embedding []float64
+	// Metadata holds arbitrary attributes used for filtering and stored as payload by vector stores.
+	Metadata map[string]interface{} `json:",omitempty"`
+	// Version is 1 when the document is first added and is incremented whenever its text or
+	// metadata changes. Hash is the content hash used to detect those changes.
+	Version int    `json:",omitempty"`
+	Hash    string `json:",omitempty"`
 }
 
 // KnowledgeBase is an in‑memory store for documents. It uses an llm.Client and a designated model
@@ -23,6 +29,24 @@ type KnowledgeBase struct {
 	Documents []*Document
 	Client    *llm.Client
 	ModelName string
+	Cache     EmbeddingCache // Optional: reuses embeddings of previously seen texts.
+	Store     VectorStore    // Optional: external backend used instead of Documents.
+
+	// BatchSize and MaxBatchTokens limit the batches sent by AddDocuments;
+	// they default to DefaultBatchSize and DefaultMaxBatchTokens.
+	BatchSize      int
+	MaxBatchTokens int
+
+	// ANN enables approximate nearest neighbor search with an HNSW index for large stores.
+	// Stores with fewer than ExactSearchThreshold documents (DefaultExactSearchThreshold if
+	// zero) are still searched exactly. Nil always uses exact search.
+	ANN                  *vectors.HNSWConfig
+	ExactSearchThreshold int
+
+	indexMu sync.Mutex
+	index   *vectors.HNSW
+
+	tracked map[string]*Document // Documents written to Store, without embeddings.
 }
 
 // NewKnowledgeBase creates a new empty knowledge base.
@@ -34,37 +58,23 @@ func NewKnowledgeBase(client *llm.Client, modelName string) *KnowledgeBase {
 	}
 }
 
-// AddDocument adds a new document to the knowledge base using an embedding from the llm client.
+// AddDocument adds a document to the knowledge base using an embedding from the llm client.
+// A document with the same ID is replaced; if its text is unchanged, nothing is done.
 func (kb *KnowledgeBase) AddDocument(ctx context.Context, id, text string) error {
-	embedding, err := kb.Client.Embedding(ctx, kb.ModelName, text)
-	if err != nil {
-		return fmt.Errorf("failed to compute embedding for document '%s': %w", id, err)
-	}
-
 	doc := &Document{
-		ID:        id,
-		Text:      text,
-		Embedding: embedding,
-	}
-	kb.Documents = append(kb.Documents, doc)
-	return nil
-}
-
-// cosineSimilarity calculates the cosine similarity between two vectors.
-func cosineSimilarity(a, b []float64) float64 {
-	if len(a) != len(b) {
-		return 0.0
+		ID:   id,
+		Text: text,
 	}
-	var dot, normA, normB float64
-	for i := range a {
-		dot += a[i] * b[i]
-		normA += a[i] * a[i]
-		normB += b[i] * b[i]
+	if !kb.prepare(doc) {
+		return nil
 	}
-	if normA == 0 || normB == 0 {
-		return 0.0
+
+	embedding, err := kb.embed(ctx, text)
+	if err != nil {
+		return fmt.Errorf("failed to compute embedding for document '%s': %w", id, err)
 	}
-	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+	doc.Embedding = embedding
+	return kb.store(ctx, []*Document{doc})
 }
 
 // RetrievalResult holds a document along with its similarity score for a query.
@@ -73,37 +83,13 @@ type RetrievalResult struct {
 	Score float64
 }
 
-// Query returns the top k documents that are most similar to the provided query text.
-func (kb *KnowledgeBase) Query(ctx context.Context, query string, k int) ([]RetrievalResult, error) {
-	queryEmbedding, err := kb.Client.Embedding(ctx, kb.ModelName, query)
-	if err != nil {
-		return nil, fmt.Errorf("failed to compute embedding for query: %w", err)
-	}
-
-	results := []RetrievalResult{}
-	for _, doc := range kb.Documents {
-		score := cosineSimilarity(queryEmbedding, doc.Embedding)
-		results = append(results, RetrievalResult{
-			Doc:   doc,
-			Score: score,
-		})
-	}
-
-	// Sort results by similarity score in descending order.
-	sort.Slice(results, func(i, j int) bool {
-		return results[i].Score > results[j].Score
-	})
-	if k > len(results) {
-		k = len(results)
-	}
-	return results[:k], nil
-}
-
 // AugmentPrompt constructs a new prompt by prepending the retrieved documents to the query.
+// Each document is numbered, as in "[1] (source: guide.md) ...", so that answers can cite it;
+// see Citations for the matching list.
 func AugmentPrompt(query string, results []RetrievalResult) string {
 	augmented := "The following information might be useful:\n"
-	for _, res := range results {
-		augmented += "- " + strings.TrimSpace(res.Doc.Text) + "\n"
+	for i, res := range results {
+		augmented += fmt.Sprintf("[%d] (source: %s) %s\n", i+1, Source(res.Doc), strings.TrimSpace(res.Doc.Text))
 	}
 	augmented += "\nBased on the above, please answer the following question:\n" + query
 	return augmented
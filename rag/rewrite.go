@@ -0,0 +1,74 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/zakirkun/gatot-kaca/llm"
+)
+
+// QueryRewriter expands a user query into alternative search queries.
+type QueryRewriter interface {
+	// Rewrite returns search queries for query, not including query itself.
+	Rewrite(ctx context.Context, query string) ([]string, error)
+}
+
+// DefaultRewritePrompt is the prompt used by LLMRewriter. It is formatted with the number of
+// queries and the user query.
+const DefaultRewritePrompt = `Rewrite the following question into %d different search queries that together cover what the user might be looking for. Use different wording and make implicit details explicit.
+Return only the queries, one per line, without numbering or explanations.
+
+Question: %s`
+
+// LLMRewriter is a QueryRewriter that asks a model to rewrite the query.
+type LLMRewriter struct {
+	Client *llm.Client
+	Model  string
+	N      int    // Number of queries to generate; defaults to 3.
+	Prompt string // Prompt format with the number of queries and the query; defaults to DefaultRewritePrompt.
+}
+
+// listMarker matches bullets and numbering at the start of a line.
+var listMarker = regexp.MustCompile(`^\s*(?:[-*•]|\d+[.)])\s*`)
+
+// Rewrite implements QueryRewriter.
+func (r *LLMRewriter) Rewrite(ctx context.Context, query string) ([]string, error) {
+	n := r.N
+	if n <= 0 {
+		n = 3
+	}
+	format := r.Prompt
+	if format == "" {
+		format = DefaultRewritePrompt
+	}
+	resp, err := r.Client.Generate(ctx, r.Model, llm.ModelRequest{Prompt: fmt.Sprintf(format, n, query)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to rewrite query: %w", err)
+	}
+
+	seen := map[string]bool{strings.ToLower(strings.TrimSpace(query)): true}
+	var queries []string
+	for _, line := range strings.Split(resp.Text, "\n") {
+		q := strings.Trim(strings.TrimSpace(listMarker.ReplaceAllString(line, "")), `"`)
+		if q == "" || seen[strings.ToLower(q)] {
+			continue
+		}
+		seen[strings.ToLower(q)] = true
+		queries = append(queries, q)
+		if len(queries) == n {
+			break
+		}
+	}
+	return queries, nil
+}
+
+// WithRewriter expands the query with r and searches for every resulting query as well as the
+// original one. The results are merged, keeping each document once with its best score, before
+// ranking. If rewriting fails, the error is logged and only the original query is used.
+func WithRewriter(r QueryRewriter) QueryOption {
+	return func(o *queryOptions) {
+		o.rewriter = r
+	}
+}
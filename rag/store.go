@@ -0,0 +1,135 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/zakirkun/gatot-kaca/vectors"
+)
+
+// VectorStore is an external backend that stores document embeddings and searches them by
+// similarity. When a KnowledgeBase has a Store, documents are written to it instead of being
+// kept in memory, and queries are answered by it.
+type VectorStore interface {
+	// Upsert inserts the documents, replacing any existing documents with the same IDs.
+	Upsert(ctx context.Context, docs []*Document) error
+	// Search returns the k documents most similar to the embedding whose metadata matches filter,
+	// in descending order of score. A nil filter matches every document.
+	Search(ctx context.Context, embedding []float64, k int, filter Filter) ([]RetrievalResult, error)
+	// Delete removes the documents with the given IDs. Missing IDs are ignored.
+	Delete(ctx context.Context, ids []string) error
+}
+
+// Filter restricts a search to documents whose metadata has the given values for every key.
+type Filter map[string]interface{}
+
+// Matches reports whether the metadata satisfies the filter.
+func (f Filter) Matches(metadata map[string]interface{}) bool {
+	for key, want := range f {
+		got, ok := metadata[key]
+		if !ok || !valuesEqual(got, want) {
+			return false
+		}
+	}
+	return true
+}
+
+// valuesEqual compares metadata values, treating all numeric types as float64 so that values
+// decoded from JSON match values set in Go.
+func valuesEqual(a, b interface{}) bool {
+	if fa, ok := toFloat(a); ok {
+		fb, ok := toFloat(b)
+		return ok && fa == fb
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+// QueryFilter is like Query but only considers documents whose metadata matches filter.
+func (kb *KnowledgeBase) QueryFilter(ctx context.Context, query string, k int, filter Filter) ([]RetrievalResult, error) {
+	return kb.Query(ctx, query, k, WithFilter(filter))
+}
+
+// retrieve returns the k documents most similar to the query embedding whose metadata matches filter.
+func (kb *KnowledgeBase) retrieve(ctx context.Context, queryEmbedding []float64, k int, filter Filter) ([]RetrievalResult, error) {
+	if kb.Store != nil {
+		return kb.Store.Search(ctx, queryEmbedding, k, filter)
+	}
+
+	// Select the top k documents by similarity score in descending order.
+	var top []vectors.Scored
+	var docs []*Document
+	if len(filter) == 0 {
+		docs = kb.Documents
+		top = kb.search(queryEmbedding, k)
+	} else {
+		embeddings := make([][]float64, 0, len(kb.Documents))
+		for _, doc := range kb.Documents {
+			if filter.Matches(doc.Metadata) {
+				docs = append(docs, doc)
+				embeddings = append(embeddings, doc.Embedding)
+			}
+		}
+		top = vectors.TopKCosine(queryEmbedding, embeddings, k)
+	}
+
+	results := make([]RetrievalResult, 0, len(top))
+	for _, s := range top {
+		results = append(results, RetrievalResult{
+			Doc:   docs[s.Index],
+			Score: s.Score,
+		})
+	}
+	return results, nil
+}
+
+// store saves documents with computed embeddings to the vector store, or keeps them in memory,
+// replacing documents with the same IDs.
+func (kb *KnowledgeBase) store(ctx context.Context, docs []*Document) error {
+	if kb.Store != nil {
+		if err := kb.Store.Upsert(ctx, docs); err != nil {
+			return fmt.Errorf("failed to store documents: %w", err)
+		}
+		if kb.tracked == nil {
+			kb.tracked = make(map[string]*Document)
+		}
+		for _, doc := range docs {
+			kb.tracked[doc.ID] = &Document{ID: doc.ID, Metadata: doc.Metadata, Version: doc.Version, Hash: doc.Hash}
+		}
+		return nil
+	}
+
+	positions := make(map[string]int, len(kb.Documents))
+	for i, doc := range kb.Documents {
+		positions[doc.ID] = i
+	}
+	replaced := false
+	for _, doc := range docs {
+		if i, ok := positions[doc.ID]; ok {
+			kb.Documents[i] = doc
+			replaced = true
+		} else {
+			positions[doc.ID] = len(kb.Documents)
+			kb.Documents = append(kb.Documents, doc)
+		}
+	}
+	if replaced {
+		// The index is positional, so replaced embeddings require a rebuild.
+		kb.ResetIndex()
+	}
+	return nil
+}
@@ -0,0 +1,292 @@
+// Package weaviate implements a rag.VectorStore backed by a Weaviate class, using the
+// REST API for schema and writes and the GraphQL API for search.
+package weaviate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/zakirkun/gatot-kaca/rag"
+	"github.com/zakirkun/gatot-kaca/rag/internal/docid"
+	"github.com/zakirkun/gatot-kaca/rag/internal/httpjson"
+)
+
+// Property names used to store the document fields. Metadata keys become additional
+// properties and must be valid GraphQL names.
+const (
+	propID   = "docId"
+	propText = "text"
+)
+
+// Config configures a Store.
+type Config struct {
+	URL    string // Base URL of the Weaviate server, e.g. "http://localhost:8080".
+	APIKey string // Optional API key, sent as a bearer token.
+	Class  string // Class name; must start with an uppercase letter.
+	// HTTPClient is optional; defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// Store is a rag.VectorStore backed by a Weaviate class with externally provided vectors.
+// The class is created with cosine distance on first upsert if it does not exist.
+type Store struct {
+	cfg Config
+
+	mu         sync.Mutex
+	created    bool
+	properties []string // Metadata properties returned by Search; nil until loaded.
+}
+
+var _ rag.VectorStore = (*Store)(nil)
+
+// New creates a Store for the configured class.
+func New(cfg Config) (*Store, error) {
+	if cfg.URL == "" || cfg.Class == "" {
+		return nil, errors.New("weaviate: URL and class are required")
+	}
+	cfg.URL = strings.TrimRight(cfg.URL, "/")
+	return &Store{cfg: cfg}, nil
+}
+
+func (s *Store) do(ctx context.Context, method, path string, body, out interface{}) error {
+	headers := map[string]string{}
+	if s.cfg.APIKey != "" {
+		headers["Authorization"] = "Bearer " + s.cfg.APIKey
+	}
+	if err := httpjson.Do(ctx, s.cfg.HTTPClient, method, s.cfg.URL+"/v1"+path, headers, body, out); err != nil {
+		return fmt.Errorf("weaviate: %w", err)
+	}
+	return nil
+}
+
+type classSchema struct {
+	Properties []struct {
+		Name string `json:"name"`
+	} `json:"properties"`
+}
+
+// ensureClass creates the class if it does not exist. Callers must hold s.mu.
+func (s *Store) ensureClass(ctx context.Context) error {
+	if s.created {
+		return nil
+	}
+	err := s.do(ctx, http.MethodGet, "/schema/"+s.cfg.Class, nil, nil)
+	var statusErr *httpjson.StatusError
+	if err != nil && (!errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound) {
+		return err
+	}
+	if err != nil {
+		body := map[string]interface{}{
+			"class":             s.cfg.Class,
+			"vectorizer":        "none",
+			"vectorIndexConfig": map[string]interface{}{"distance": "cosine"},
+			"properties": []map[string]interface{}{
+				{"name": propID, "dataType": []string{"text"}},
+				{"name": propText, "dataType": []string{"text"}},
+			},
+		}
+		if err := s.do(ctx, http.MethodPost, "/schema", body, nil); err != nil {
+			return err
+		}
+	}
+	s.created = true
+	return nil
+}
+
+// metadataProperties returns the class's metadata properties, loading them from the schema
+// if they are not cached.
+func (s *Store) metadataProperties(ctx context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.properties != nil {
+		return s.properties, nil
+	}
+	var schema classSchema
+	if err := s.do(ctx, http.MethodGet, "/schema/"+s.cfg.Class, nil, &schema); err != nil {
+		return nil, err
+	}
+	props := []string{}
+	for _, p := range schema.Properties {
+		if p.Name != propID && p.Name != propText {
+			props = append(props, p.Name)
+		}
+	}
+	sort.Strings(props)
+	s.properties = props
+	return props, nil
+}
+
+// Upsert implements rag.VectorStore. Objects are keyed by a UUID derived from the document ID,
+// so writing a document again replaces it.
+func (s *Store) Upsert(ctx context.Context, docs []*rag.Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	err := s.ensureClass(ctx)
+	// New metadata keys add properties through auto-schema, so reload them on the next search.
+	s.properties = nil
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	objects := make([]map[string]interface{}, len(docs))
+	for i, doc := range docs {
+		props := make(map[string]interface{}, len(doc.Metadata)+2)
+		for k, v := range doc.Metadata {
+			props[k] = v
+		}
+		props[propID] = doc.ID
+		props[propText] = doc.Text
+		objects[i] = map[string]interface{}{
+			"class":      s.cfg.Class,
+			"id":         docid.UUID(doc.ID),
+			"properties": props,
+			"vector":     doc.Embedding,
+		}
+	}
+
+	var resp []struct {
+		Result struct {
+			Errors *struct {
+				Error []struct {
+					Message string `json:"message"`
+				} `json:"error"`
+			} `json:"errors"`
+		} `json:"result"`
+	}
+	if err := s.do(ctx, http.MethodPost, "/batch/objects", map[string]interface{}{"objects": objects}, &resp); err != nil {
+		return err
+	}
+	for i, r := range resp {
+		if r.Result.Errors != nil && len(r.Result.Errors.Error) > 0 {
+			return fmt.Errorf("weaviate: failed to upsert document %q: %s", docs[i].ID, r.Result.Errors.Error[0].Message)
+		}
+	}
+	return nil
+}
+
+// Search implements rag.VectorStore. Scores are cosine similarities (1 - cosine distance).
+func (s *Store) Search(ctx context.Context, embedding []float64, k int, filter rag.Filter) ([]rag.RetrievalResult, error) {
+	props, err := s.metadataProperties(ctx)
+	if err != nil {
+		return nil, err
+	}
+	vector, err := json.Marshal(embedding)
+	if err != nil {
+		return nil, err
+	}
+
+	args := fmt.Sprintf("nearVector: {vector: %s}, limit: %d", vector, k)
+	if len(filter) > 0 {
+		where, err := whereClause(filter)
+		if err != nil {
+			return nil, err
+		}
+		args += ", where: " + where
+	}
+	fields := strings.Join(append([]string{propID, propText}, props...), " ")
+	query := fmt.Sprintf("{ Get { %s(%s) { %s _additional { distance } } } }", s.cfg.Class, args, fields)
+
+	var resp struct {
+		Data struct {
+			Get map[string][]map[string]interface{} `json:"Get"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := s.do(ctx, http.MethodPost, "/graphql", map[string]string{"query": query}, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("weaviate: %s", resp.Errors[0].Message)
+	}
+
+	objects := resp.Data.Get[s.cfg.Class]
+	results := make([]rag.RetrievalResult, 0, len(objects))
+	for _, obj := range objects {
+		doc := &rag.Document{Metadata: map[string]interface{}{}}
+		var score float64
+		for key, value := range obj {
+			switch key {
+			case propID:
+				doc.ID, _ = value.(string)
+			case propText:
+				doc.Text, _ = value.(string)
+			case "_additional":
+				if add, ok := value.(map[string]interface{}); ok {
+					if d, ok := add["distance"].(float64); ok {
+						score = 1 - d
+					}
+				}
+			default:
+				if value != nil {
+					doc.Metadata[key] = value
+				}
+			}
+		}
+		results = append(results, rag.RetrievalResult{Doc: doc, Score: score})
+	}
+	return results, nil
+}
+
+// whereClause converts a filter into a GraphQL where argument.
+func whereClause(filter rag.Filter) (string, error) {
+	keys := make([]string, 0, len(filter))
+	for key := range filter {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	operands := make([]string, 0, len(keys))
+	for _, key := range keys {
+		field, literal, err := valueLiteral(filter[key])
+		if err != nil {
+			return "", fmt.Errorf("weaviate: filter %s: %w", key, err)
+		}
+		name, _ := json.Marshal(key)
+		operands = append(operands, fmt.Sprintf("{path: [%s], operator: Equal, %s: %s}", name, field, literal))
+	}
+	if len(operands) == 1 {
+		return operands[0], nil
+	}
+	return "{operator: And, operands: [" + strings.Join(operands, ", ") + "]}", nil
+}
+
+// valueLiteral returns the where operand field and GraphQL literal for a filter value.
+func valueLiteral(v interface{}) (string, string, error) {
+	switch v.(type) {
+	case string:
+		b, _ := json.Marshal(v)
+		return "valueText", string(b), nil
+	case bool:
+		return "valueBoolean", fmt.Sprint(v), nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return "valueInt", fmt.Sprint(v), nil
+	case float32, float64:
+		b, _ := json.Marshal(v)
+		return "valueNumber", string(b), nil
+	default:
+		return "", "", fmt.Errorf("unsupported value type %T", v)
+	}
+}
+
+// Delete implements rag.VectorStore.
+func (s *Store) Delete(ctx context.Context, ids []string) error {
+	for _, id := range ids {
+		err := s.do(ctx, http.MethodDelete, "/objects/"+s.cfg.Class+"/"+docid.UUID(id), nil, nil)
+		var statusErr *httpjson.StatusError
+		if err != nil && !(errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+//go:build integration
+
+// Integration tests against a local weaviate instance, e.g. started with:
+//
+//	docker run -p 8080:8080 semitechnologies/weaviate
+//
+// Run with: go test -tags integration ./rag/weaviate
+package weaviate_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/zakirkun/gatot-kaca/rag"
+	"github.com/zakirkun/gatot-kaca/rag/weaviate"
+)
+
+func TestStore(t *testing.T) {
+	url := os.Getenv("WEAVIATE_URL")
+	if url == "" {
+		url = "http://localhost:8080"
+	}
+	if _, err := http.Get(url); err != nil {
+		t.Skipf("weaviate not reachable at %s: %v", url, err)
+	}
+
+	store, err := weaviate.New(weaviate.Config{URL: url, Class: fmt.Sprintf("GatotKacaTest%d", time.Now().UnixNano())})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	docs := []*rag.Document{
+		{ID: "a", Text: "alpha", Embedding: []float64{1, 0, 0}, Metadata: map[string]interface{}{"lang": "en"}},
+		{ID: "b", Text: "beta", Embedding: []float64{0.9, 0.1, 0}, Metadata: map[string]interface{}{"lang": "id"}},
+		{ID: "c", Text: "gamma", Embedding: []float64{0, 0, 1}, Metadata: map[string]interface{}{"lang": "en"}},
+	}
+	if err := store.Upsert(ctx, docs); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+
+	results, err := store.Search(ctx, []float64{1, 0, 0}, 2, nil)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 2 || results[0].Doc.ID != "a" || results[0].Doc.Text != "alpha" {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+
+	results, err = store.Search(ctx, []float64{1, 0, 0}, 3, rag.Filter{"lang": "id"})
+	if err != nil {
+		t.Fatalf("filtered Search: %v", err)
+	}
+	if len(results) != 1 || results[0].Doc.ID != "b" {
+		t.Fatalf("unexpected filtered results: %+v", results)
+	}
+
+	if err := store.Delete(ctx, []string{"a"}); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	results, err = store.Search(ctx, []float64{1, 0, 0}, 1, nil)
+	if err != nil {
+		t.Fatalf("Search after delete: %v", err)
+	}
+	if len(results) != 1 || results[0].Doc.ID != "b" {
+		t.Fatalf("unexpected results after delete: %+v", results)
+	}
+}
@@ -0,0 +1,215 @@
+package weaviate_test
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/zakirkun/gatot-kaca/rag"
+	"github.com/zakirkun/gatot-kaca/rag/weaviate"
+)
+
+type fakeObject struct {
+	ID         string                 `json:"id"`
+	Properties map[string]interface{} `json:"properties"`
+	Vector     []float64              `json:"vector"`
+}
+
+var (
+	vectorArg = regexp.MustCompile(`nearVector: \{vector: (\[[^\]]*\])\}`)
+	limitArg  = regexp.MustCompile(`limit: (\d+)`)
+	whereArg  = regexp.MustCompile(`\{path: \["(\w+)"\], operator: Equal, value\w+: ("[^"]*"|[^}\s]+)\}`)
+	fieldList = regexp.MustCompile(`\) \{ (.*) _additional`)
+)
+
+// fakeWeaviate serves the parts of the Weaviate REST and GraphQL APIs used by the store for
+// the Doc class. Its GraphQL support is limited to the queries the store builds.
+type fakeWeaviate struct {
+	t *testing.T
+
+	mu         sync.Mutex
+	properties []string // Nil until the class is created.
+	objects    map[string]fakeObject
+}
+
+func (f *fakeWeaviate) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.Header.Get("Authorization") != "Bearer secret" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/v1/schema/Doc":
+		if f.properties == nil {
+			http.NotFound(w, r)
+			return
+		}
+		props := []map[string]string{}
+		for _, name := range f.properties {
+			props = append(props, map[string]string{"name": name})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"class": "Doc", "properties": props})
+	case r.Method == http.MethodPost && r.URL.Path == "/v1/schema":
+		var class struct {
+			Properties []struct {
+				Name string `json:"name"`
+			} `json:"properties"`
+		}
+		json.NewDecoder(r.Body).Decode(&class)
+		f.properties = []string{}
+		for _, p := range class.Properties {
+			f.properties = append(f.properties, p.Name)
+		}
+		w.Write([]byte("{}"))
+	case r.Method == http.MethodPost && r.URL.Path == "/v1/batch/objects":
+		var req struct {
+			Objects []fakeObject `json:"objects"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		results := []map[string]interface{}{}
+		for _, obj := range req.Objects {
+			// Emulate auto-schema by adding unknown properties to the class.
+			for name := range obj.Properties {
+				if !f.hasProperty(name) {
+					f.properties = append(f.properties, name)
+				}
+			}
+			f.objects[obj.ID] = obj
+			results = append(results, map[string]interface{}{"id": obj.ID, "result": map[string]interface{}{}})
+		}
+		json.NewEncoder(w).Encode(results)
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/v1/objects/Doc/"):
+		id := strings.TrimPrefix(r.URL.Path, "/v1/objects/Doc/")
+		if _, ok := f.objects[id]; !ok {
+			http.NotFound(w, r)
+			return
+		}
+		delete(f.objects, id)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPost && r.URL.Path == "/v1/graphql":
+		var req struct {
+			Query string `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"Get": map[string]interface{}{"Doc": f.search(req.Query)}}})
+	default:
+		f.t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeWeaviate) hasProperty(name string) bool {
+	for _, p := range f.properties {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
+
+// search answers a Get query with nearVector, limit and an optional single Equal filter.
+func (f *fakeWeaviate) search(query string) []map[string]interface{} {
+	var vector []float64
+	json.Unmarshal([]byte(vectorArg.FindStringSubmatch(query)[1]), &vector)
+	limit, _ := strconv.Atoi(limitArg.FindStringSubmatch(query)[1])
+	fields := strings.Fields(fieldList.FindStringSubmatch(query)[1])
+
+	var results []map[string]interface{}
+	for _, obj := range f.objects {
+		if m := whereArg.FindStringSubmatch(query); m != nil {
+			var value interface{}
+			json.Unmarshal([]byte(m[2]), &value)
+			if obj.Properties[m[1]] != value {
+				continue
+			}
+		}
+		res := map[string]interface{}{"_additional": map[string]interface{}{"distance": 1 - cosine(vector, obj.Vector)}}
+		for _, field := range fields {
+			res[field] = obj.Properties[field]
+		}
+		results = append(results, res)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i]["_additional"].(map[string]interface{})["distance"].(float64) <
+			results[j]["_additional"].(map[string]interface{})["distance"].(float64)
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+func cosine(a, b []float64) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	return dot / math.Sqrt(na*nb)
+}
+
+// TestStoreWithFakeServer runs without a Weaviate instance; TestStore covers a real one.
+func TestStoreWithFakeServer(t *testing.T) {
+	fake := &fakeWeaviate{t: t, objects: map[string]fakeObject{}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	store, err := weaviate.New(weaviate.Config{URL: srv.URL + "/", APIKey: "secret", Class: "Doc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	docs := []*rag.Document{
+		{ID: "a", Text: "alpha", Embedding: []float64{1, 0, 0}, Metadata: map[string]interface{}{"lang": "en"}},
+		{ID: "b", Text: "beta", Embedding: []float64{0.9, 0.1, 0}, Metadata: map[string]interface{}{"lang": "id"}},
+		{ID: "c", Text: "gamma", Embedding: []float64{0, 0, 1}, Metadata: map[string]interface{}{"lang": "en"}},
+	}
+	if err := store.Upsert(ctx, docs); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+	if len(fake.objects) != 3 || !fake.hasProperty("docId") || !fake.hasProperty("lang") {
+		t.Errorf("stored %d objects with properties %v", len(fake.objects), fake.properties)
+	}
+
+	results, err := store.Search(ctx, []float64{1, 0, 0}, 2, nil)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 2 || results[0].Doc.ID != "a" || results[0].Doc.Text != "alpha" ||
+		results[0].Doc.Metadata["lang"] != "en" || math.Abs(results[0].Score-1) > 1e-9 || results[1].Doc.ID != "b" {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+
+	results, err = store.Search(ctx, []float64{1, 0, 0}, 3, rag.Filter{"lang": "id"})
+	if err != nil || len(results) != 1 || results[0].Doc.ID != "b" {
+		t.Fatalf("filtered Search = %+v, %v", results, err)
+	}
+	if _, err := store.Search(ctx, []float64{1, 0, 0}, 3, rag.Filter{"tags": []string{"x"}}); err == nil {
+		t.Error("Search accepted a filter value of an unsupported type")
+	}
+
+	// Deleting a missing document is not an error.
+	if err := store.Delete(ctx, []string{"a", "missing"}); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	results, err = store.Search(ctx, []float64{1, 0, 0}, 1, nil)
+	if err != nil || len(results) != 1 || results[0].Doc.ID != "b" {
+		t.Fatalf("Search after delete = %+v, %v", results, err)
+	}
+
+	unauthorized, _ := weaviate.New(weaviate.Config{URL: srv.URL, Class: "Doc"})
+	if _, err := unauthorized.Search(ctx, []float64{1, 0, 0}, 1, nil); err == nil {
+		t.Error("Search without the API key succeeded")
+	}
+}
@@ -0,0 +1,922 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v5.28.3
+// source: gatotkaca.proto
+
+package gatotkacapb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// ChatMessage is a single message of a conversation.
+type ChatMessage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Role          string                 `protobuf:"bytes,1,opt,name=role,proto3" json:"role,omitempty"`
+	Content       string                 `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChatMessage) Reset() {
+	*x = ChatMessage{}
+	mi := &file_gatotkaca_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChatMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChatMessage) ProtoMessage() {}
+
+func (x *ChatMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_gatotkaca_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChatMessage.ProtoReflect.Descriptor instead.
+func (*ChatMessage) Descriptor() ([]byte, []int) {
+	return file_gatotkaca_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ChatMessage) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *ChatMessage) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+// Usage reports token usage.
+type Usage struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	PromptTokens     int32                  `protobuf:"varint,1,opt,name=prompt_tokens,json=promptTokens,proto3" json:"prompt_tokens,omitempty"`
+	CompletionTokens int32                  `protobuf:"varint,2,opt,name=completion_tokens,json=completionTokens,proto3" json:"completion_tokens,omitempty"`
+	TotalTokens      int32                  `protobuf:"varint,3,opt,name=total_tokens,json=totalTokens,proto3" json:"total_tokens,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Usage) Reset() {
+	*x = Usage{}
+	mi := &file_gatotkaca_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Usage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Usage) ProtoMessage() {}
+
+func (x *Usage) ProtoReflect() protoreflect.Message {
+	mi := &file_gatotkaca_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Usage.ProtoReflect.Descriptor instead.
+func (*Usage) Descriptor() ([]byte, []int) {
+	return file_gatotkaca_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Usage) GetPromptTokens() int32 {
+	if x != nil {
+		return x.PromptTokens
+	}
+	return 0
+}
+
+func (x *Usage) GetCompletionTokens() int32 {
+	if x != nil {
+		return x.CompletionTokens
+	}
+	return 0
+}
+
+func (x *Usage) GetTotalTokens() int32 {
+	if x != nil {
+		return x.TotalTokens
+	}
+	return 0
+}
+
+type AgentChatRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Name of the registered agent.
+	Agent string `protobuf:"bytes,1,opt,name=agent,proto3" json:"agent,omitempty"`
+	// Previous conversation, loaded into the agent before the message is sent.
+	History []*ChatMessage `protobuf:"bytes,2,rep,name=history,proto3" json:"history,omitempty"`
+	// The user message.
+	Message       string `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AgentChatRequest) Reset() {
+	*x = AgentChatRequest{}
+	mi := &file_gatotkaca_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentChatRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentChatRequest) ProtoMessage() {}
+
+func (x *AgentChatRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gatotkaca_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentChatRequest.ProtoReflect.Descriptor instead.
+func (*AgentChatRequest) Descriptor() ([]byte, []int) {
+	return file_gatotkaca_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *AgentChatRequest) GetAgent() string {
+	if x != nil {
+		return x.Agent
+	}
+	return ""
+}
+
+func (x *AgentChatRequest) GetHistory() []*ChatMessage {
+	if x != nil {
+		return x.History
+	}
+	return nil
+}
+
+func (x *AgentChatRequest) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type AgentChatResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*AgentChatResponse_Delta
+	//	*AgentChatResponse_Result
+	Event         isAgentChatResponse_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AgentChatResponse) Reset() {
+	*x = AgentChatResponse{}
+	mi := &file_gatotkaca_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentChatResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentChatResponse) ProtoMessage() {}
+
+func (x *AgentChatResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gatotkaca_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentChatResponse.ProtoReflect.Descriptor instead.
+func (*AgentChatResponse) Descriptor() ([]byte, []int) {
+	return file_gatotkaca_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *AgentChatResponse) GetEvent() isAgentChatResponse_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *AgentChatResponse) GetDelta() string {
+	if x != nil {
+		if x, ok := x.Event.(*AgentChatResponse_Delta); ok {
+			return x.Delta
+		}
+	}
+	return ""
+}
+
+func (x *AgentChatResponse) GetResult() *AgentChatResult {
+	if x != nil {
+		if x, ok := x.Event.(*AgentChatResponse_Result); ok {
+			return x.Result
+		}
+	}
+	return nil
+}
+
+type isAgentChatResponse_Event interface {
+	isAgentChatResponse_Event()
+}
+
+type AgentChatResponse_Delta struct {
+	// A chunk of the response text as it is generated.
+	Delta string `protobuf:"bytes,1,opt,name=delta,proto3,oneof"`
+}
+
+type AgentChatResponse_Result struct {
+	// The final response, sent once as the last event.
+	Result *AgentChatResult `protobuf:"bytes,2,opt,name=result,proto3,oneof"`
+}
+
+func (*AgentChatResponse_Delta) isAgentChatResponse_Event() {}
+
+func (*AgentChatResponse_Result) isAgentChatResponse_Event() {}
+
+type AgentChatResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Text          string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	Usage         *Usage                 `protobuf:"bytes,2,opt,name=usage,proto3" json:"usage,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AgentChatResult) Reset() {
+	*x = AgentChatResult{}
+	mi := &file_gatotkaca_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentChatResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentChatResult) ProtoMessage() {}
+
+func (x *AgentChatResult) ProtoReflect() protoreflect.Message {
+	mi := &file_gatotkaca_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentChatResult.ProtoReflect.Descriptor instead.
+func (*AgentChatResult) Descriptor() ([]byte, []int) {
+	return file_gatotkaca_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *AgentChatResult) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *AgentChatResult) GetUsage() *Usage {
+	if x != nil {
+		return x.Usage
+	}
+	return nil
+}
+
+type RunWorkflowRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Name of the registered workflow.
+	Workflow      string `protobuf:"bytes,1,opt,name=workflow,proto3" json:"workflow,omitempty"`
+	Input         string `protobuf:"bytes,2,opt,name=input,proto3" json:"input,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RunWorkflowRequest) Reset() {
+	*x = RunWorkflowRequest{}
+	mi := &file_gatotkaca_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunWorkflowRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunWorkflowRequest) ProtoMessage() {}
+
+func (x *RunWorkflowRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gatotkaca_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunWorkflowRequest.ProtoReflect.Descriptor instead.
+func (*RunWorkflowRequest) Descriptor() ([]byte, []int) {
+	return file_gatotkaca_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *RunWorkflowRequest) GetWorkflow() string {
+	if x != nil {
+		return x.Workflow
+	}
+	return ""
+}
+
+func (x *RunWorkflowRequest) GetInput() string {
+	if x != nil {
+		return x.Input
+	}
+	return ""
+}
+
+type RunWorkflowResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*RunWorkflowResponse_Step
+	//	*RunWorkflowResponse_Result
+	Event         isRunWorkflowResponse_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RunWorkflowResponse) Reset() {
+	*x = RunWorkflowResponse{}
+	mi := &file_gatotkaca_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunWorkflowResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunWorkflowResponse) ProtoMessage() {}
+
+func (x *RunWorkflowResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gatotkaca_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunWorkflowResponse.ProtoReflect.Descriptor instead.
+func (*RunWorkflowResponse) Descriptor() ([]byte, []int) {
+	return file_gatotkaca_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *RunWorkflowResponse) GetEvent() isRunWorkflowResponse_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *RunWorkflowResponse) GetStep() *StepResult {
+	if x != nil {
+		if x, ok := x.Event.(*RunWorkflowResponse_Step); ok {
+			return x.Step
+		}
+	}
+	return nil
+}
+
+func (x *RunWorkflowResponse) GetResult() *WorkflowResult {
+	if x != nil {
+		if x, ok := x.Event.(*RunWorkflowResponse_Result); ok {
+			return x.Result
+		}
+	}
+	return nil
+}
+
+type isRunWorkflowResponse_Event interface {
+	isRunWorkflowResponse_Event()
+}
+
+type RunWorkflowResponse_Step struct {
+	// The result of a completed step.
+	Step *StepResult `protobuf:"bytes,1,opt,name=step,proto3,oneof"`
+}
+
+type RunWorkflowResponse_Result struct {
+	// The final result, sent once as the last event.
+	Result *WorkflowResult `protobuf:"bytes,2,opt,name=result,proto3,oneof"`
+}
+
+func (*RunWorkflowResponse_Step) isRunWorkflowResponse_Event() {}
+
+func (*RunWorkflowResponse_Result) isRunWorkflowResponse_Event() {}
+
+type StepResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Index         int32                  `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Input         string                 `protobuf:"bytes,2,opt,name=input,proto3" json:"input,omitempty"`
+	Output        string                 `protobuf:"bytes,3,opt,name=output,proto3" json:"output,omitempty"`
+	DurationMs    int64                  `protobuf:"varint,4,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+	Usage         *Usage                 `protobuf:"bytes,5,opt,name=usage,proto3" json:"usage,omitempty"`
+	Error         string                 `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StepResult) Reset() {
+	*x = StepResult{}
+	mi := &file_gatotkaca_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StepResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StepResult) ProtoMessage() {}
+
+func (x *StepResult) ProtoReflect() protoreflect.Message {
+	mi := &file_gatotkaca_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StepResult.ProtoReflect.Descriptor instead.
+func (*StepResult) Descriptor() ([]byte, []int) {
+	return file_gatotkaca_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *StepResult) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *StepResult) GetInput() string {
+	if x != nil {
+		return x.Input
+	}
+	return ""
+}
+
+func (x *StepResult) GetOutput() string {
+	if x != nil {
+		return x.Output
+	}
+	return ""
+}
+
+func (x *StepResult) GetDurationMs() int64 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+func (x *StepResult) GetUsage() *Usage {
+	if x != nil {
+		return x.Usage
+	}
+	return nil
+}
+
+func (x *StepResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type WorkflowResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RunId         string                 `protobuf:"bytes,1,opt,name=run_id,json=runId,proto3" json:"run_id,omitempty"`
+	Output        string                 `protobuf:"bytes,2,opt,name=output,proto3" json:"output,omitempty"`
+	Usage         *Usage                 `protobuf:"bytes,3,opt,name=usage,proto3" json:"usage,omitempty"`
+	DurationMs    int64                  `protobuf:"varint,4,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WorkflowResult) Reset() {
+	*x = WorkflowResult{}
+	mi := &file_gatotkaca_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WorkflowResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WorkflowResult) ProtoMessage() {}
+
+func (x *WorkflowResult) ProtoReflect() protoreflect.Message {
+	mi := &file_gatotkaca_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WorkflowResult.ProtoReflect.Descriptor instead.
+func (*WorkflowResult) Descriptor() ([]byte, []int) {
+	return file_gatotkaca_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *WorkflowResult) GetRunId() string {
+	if x != nil {
+		return x.RunId
+	}
+	return ""
+}
+
+func (x *WorkflowResult) GetOutput() string {
+	if x != nil {
+		return x.Output
+	}
+	return ""
+}
+
+func (x *WorkflowResult) GetUsage() *Usage {
+	if x != nil {
+		return x.Usage
+	}
+	return nil
+}
+
+func (x *WorkflowResult) GetDurationMs() int64 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+type ListToolsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Name of the registered agent.
+	Agent         string `protobuf:"bytes,1,opt,name=agent,proto3" json:"agent,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListToolsRequest) Reset() {
+	*x = ListToolsRequest{}
+	mi := &file_gatotkaca_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListToolsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListToolsRequest) ProtoMessage() {}
+
+func (x *ListToolsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gatotkaca_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListToolsRequest.ProtoReflect.Descriptor instead.
+func (*ListToolsRequest) Descriptor() ([]byte, []int) {
+	return file_gatotkaca_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ListToolsRequest) GetAgent() string {
+	if x != nil {
+		return x.Agent
+	}
+	return ""
+}
+
+type Tool struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Name        string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Description string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	// JSON schema of the input, for tools that provide one.
+	Schema        string `protobuf:"bytes,3,opt,name=schema,proto3" json:"schema,omitempty"`
+	Help          string `protobuf:"bytes,4,opt,name=help,proto3" json:"help,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Tool) Reset() {
+	*x = Tool{}
+	mi := &file_gatotkaca_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Tool) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Tool) ProtoMessage() {}
+
+func (x *Tool) ProtoReflect() protoreflect.Message {
+	mi := &file_gatotkaca_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Tool.ProtoReflect.Descriptor instead.
+func (*Tool) Descriptor() ([]byte, []int) {
+	return file_gatotkaca_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Tool) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Tool) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Tool) GetSchema() string {
+	if x != nil {
+		return x.Schema
+	}
+	return ""
+}
+
+func (x *Tool) GetHelp() string {
+	if x != nil {
+		return x.Help
+	}
+	return ""
+}
+
+type ListToolsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tools         []*Tool                `protobuf:"bytes,1,rep,name=tools,proto3" json:"tools,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListToolsResponse) Reset() {
+	*x = ListToolsResponse{}
+	mi := &file_gatotkaca_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListToolsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListToolsResponse) ProtoMessage() {}
+
+func (x *ListToolsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gatotkaca_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListToolsResponse.ProtoReflect.Descriptor instead.
+func (*ListToolsResponse) Descriptor() ([]byte, []int) {
+	return file_gatotkaca_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ListToolsResponse) GetTools() []*Tool {
+	if x != nil {
+		return x.Tools
+	}
+	return nil
+}
+
+var File_gatotkaca_proto protoreflect.FileDescriptor
+
+const file_gatotkaca_proto_rawDesc = "" +
+	"\n" +
+	"\x0fgatotkaca.proto\x12\fgatotkaca.v1\";\n" +
+	"\vChatMessage\x12\x12\n" +
+	"\x04role\x18\x01 \x01(\tR\x04role\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\tR\acontent\"|\n" +
+	"\x05Usage\x12#\n" +
+	"\rprompt_tokens\x18\x01 \x01(\x05R\fpromptTokens\x12+\n" +
+	"\x11completion_tokens\x18\x02 \x01(\x05R\x10completionTokens\x12!\n" +
+	"\ftotal_tokens\x18\x03 \x01(\x05R\vtotalTokens\"w\n" +
+	"\x10AgentChatRequest\x12\x14\n" +
+	"\x05agent\x18\x01 \x01(\tR\x05agent\x123\n" +
+	"\ahistory\x18\x02 \x03(\v2\x19.gatotkaca.v1.ChatMessageR\ahistory\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\"m\n" +
+	"\x11AgentChatResponse\x12\x16\n" +
+	"\x05delta\x18\x01 \x01(\tH\x00R\x05delta\x127\n" +
+	"\x06result\x18\x02 \x01(\v2\x1d.gatotkaca.v1.AgentChatResultH\x00R\x06resultB\a\n" +
+	"\x05event\"P\n" +
+	"\x0fAgentChatResult\x12\x12\n" +
+	"\x04text\x18\x01 \x01(\tR\x04text\x12)\n" +
+	"\x05usage\x18\x02 \x01(\v2\x13.gatotkaca.v1.UsageR\x05usage\"F\n" +
+	"\x12RunWorkflowRequest\x12\x1a\n" +
+	"\bworkflow\x18\x01 \x01(\tR\bworkflow\x12\x14\n" +
+	"\x05input\x18\x02 \x01(\tR\x05input\"\x86\x01\n" +
+	"\x13RunWorkflowResponse\x12.\n" +
+	"\x04step\x18\x01 \x01(\v2\x18.gatotkaca.v1.StepResultH\x00R\x04step\x126\n" +
+	"\x06result\x18\x02 \x01(\v2\x1c.gatotkaca.v1.WorkflowResultH\x00R\x06resultB\a\n" +
+	"\x05event\"\xb2\x01\n" +
+	"\n" +
+	"StepResult\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x05R\x05index\x12\x14\n" +
+	"\x05input\x18\x02 \x01(\tR\x05input\x12\x16\n" +
+	"\x06output\x18\x03 \x01(\tR\x06output\x12\x1f\n" +
+	"\vduration_ms\x18\x04 \x01(\x03R\n" +
+	"durationMs\x12)\n" +
+	"\x05usage\x18\x05 \x01(\v2\x13.gatotkaca.v1.UsageR\x05usage\x12\x14\n" +
+	"\x05error\x18\x06 \x01(\tR\x05error\"\x8b\x01\n" +
+	"\x0eWorkflowResult\x12\x15\n" +
+	"\x06run_id\x18\x01 \x01(\tR\x05runId\x12\x16\n" +
+	"\x06output\x18\x02 \x01(\tR\x06output\x12)\n" +
+	"\x05usage\x18\x03 \x01(\v2\x13.gatotkaca.v1.UsageR\x05usage\x12\x1f\n" +
+	"\vduration_ms\x18\x04 \x01(\x03R\n" +
+	"durationMs\"(\n" +
+	"\x10ListToolsRequest\x12\x14\n" +
+	"\x05agent\x18\x01 \x01(\tR\x05agent\"h\n" +
+	"\x04Tool\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\x12\x16\n" +
+	"\x06schema\x18\x03 \x01(\tR\x06schema\x12\x12\n" +
+	"\x04help\x18\x04 \x01(\tR\x04help\"=\n" +
+	"\x11ListToolsResponse\x12(\n" +
+	"\x05tools\x18\x01 \x03(\v2\x12.gatotkaca.v1.ToolR\x05tools2\xff\x01\n" +
+	"\tGatotKaca\x12N\n" +
+	"\tAgentChat\x12\x1e.gatotkaca.v1.AgentChatRequest\x1a\x1f.gatotkaca.v1.AgentChatResponse0\x01\x12T\n" +
+	"\vRunWorkflow\x12 .gatotkaca.v1.RunWorkflowRequest\x1a!.gatotkaca.v1.RunWorkflowResponse0\x01\x12L\n" +
+	"\tListTools\x12\x1e.gatotkaca.v1.ListToolsRequest\x1a\x1f.gatotkaca.v1.ListToolsResponseB0Z.github.com/zakirkun/gatot-kaca/rpc/gatotkacapbb\x06proto3"
+
+var (
+	file_gatotkaca_proto_rawDescOnce sync.Once
+	file_gatotkaca_proto_rawDescData []byte
+)
+
+func file_gatotkaca_proto_rawDescGZIP() []byte {
+	file_gatotkaca_proto_rawDescOnce.Do(func() {
+		file_gatotkaca_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_gatotkaca_proto_rawDesc), len(file_gatotkaca_proto_rawDesc)))
+	})
+	return file_gatotkaca_proto_rawDescData
+}
+
+var file_gatotkaca_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_gatotkaca_proto_goTypes = []any{
+	(*ChatMessage)(nil),         // 0: gatotkaca.v1.ChatMessage
+	(*Usage)(nil),               // 1: gatotkaca.v1.Usage
+	(*AgentChatRequest)(nil),    // 2: gatotkaca.v1.AgentChatRequest
+	(*AgentChatResponse)(nil),   // 3: gatotkaca.v1.AgentChatResponse
+	(*AgentChatResult)(nil),     // 4: gatotkaca.v1.AgentChatResult
+	(*RunWorkflowRequest)(nil),  // 5: gatotkaca.v1.RunWorkflowRequest
+	(*RunWorkflowResponse)(nil), // 6: gatotkaca.v1.RunWorkflowResponse
+	(*StepResult)(nil),          // 7: gatotkaca.v1.StepResult
+	(*WorkflowResult)(nil),      // 8: gatotkaca.v1.WorkflowResult
+	(*ListToolsRequest)(nil),    // 9: gatotkaca.v1.ListToolsRequest
+	(*Tool)(nil),                // 10: gatotkaca.v1.Tool
+	(*ListToolsResponse)(nil),   // 11: gatotkaca.v1.ListToolsResponse
+}
+var file_gatotkaca_proto_depIdxs = []int32{
+	0,  // 0: gatotkaca.v1.AgentChatRequest.history:type_name -> gatotkaca.v1.ChatMessage
+	4,  // 1: gatotkaca.v1.AgentChatResponse.result:type_name -> gatotkaca.v1.AgentChatResult
+	1,  // 2: gatotkaca.v1.AgentChatResult.usage:type_name -> gatotkaca.v1.Usage
+	7,  // 3: gatotkaca.v1.RunWorkflowResponse.step:type_name -> gatotkaca.v1.StepResult
+	8,  // 4: gatotkaca.v1.RunWorkflowResponse.result:type_name -> gatotkaca.v1.WorkflowResult
+	1,  // 5: gatotkaca.v1.StepResult.usage:type_name -> gatotkaca.v1.Usage
+	1,  // 6: gatotkaca.v1.WorkflowResult.usage:type_name -> gatotkaca.v1.Usage
+	10, // 7: gatotkaca.v1.ListToolsResponse.tools:type_name -> gatotkaca.v1.Tool
+	2,  // 8: gatotkaca.v1.GatotKaca.AgentChat:input_type -> gatotkaca.v1.AgentChatRequest
+	5,  // 9: gatotkaca.v1.GatotKaca.RunWorkflow:input_type -> gatotkaca.v1.RunWorkflowRequest
+	9,  // 10: gatotkaca.v1.GatotKaca.ListTools:input_type -> gatotkaca.v1.ListToolsRequest
+	3,  // 11: gatotkaca.v1.GatotKaca.AgentChat:output_type -> gatotkaca.v1.AgentChatResponse
+	6,  // 12: gatotkaca.v1.GatotKaca.RunWorkflow:output_type -> gatotkaca.v1.RunWorkflowResponse
+	11, // 13: gatotkaca.v1.GatotKaca.ListTools:output_type -> gatotkaca.v1.ListToolsResponse
+	11, // [11:14] is the sub-list for method output_type
+	8,  // [8:11] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_gatotkaca_proto_init() }
+func file_gatotkaca_proto_init() {
+	if File_gatotkaca_proto != nil {
+		return
+	}
+	file_gatotkaca_proto_msgTypes[3].OneofWrappers = []any{
+		(*AgentChatResponse_Delta)(nil),
+		(*AgentChatResponse_Result)(nil),
+	}
+	file_gatotkaca_proto_msgTypes[6].OneofWrappers = []any{
+		(*RunWorkflowResponse_Step)(nil),
+		(*RunWorkflowResponse_Result)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_gatotkaca_proto_rawDesc), len(file_gatotkaca_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   12,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_gatotkaca_proto_goTypes,
+		DependencyIndexes: file_gatotkaca_proto_depIdxs,
+		MessageInfos:      file_gatotkaca_proto_msgTypes,
+	}.Build()
+	File_gatotkaca_proto = out.File
+	file_gatotkaca_proto_goTypes = nil
+	file_gatotkaca_proto_depIdxs = nil
+}
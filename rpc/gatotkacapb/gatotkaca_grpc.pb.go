@@ -0,0 +1,214 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             v5.28.3
+// source: gatotkaca.proto
+
+package gatotkacapb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	GatotKaca_AgentChat_FullMethodName   = "/gatotkaca.v1.GatotKaca/AgentChat"
+	GatotKaca_RunWorkflow_FullMethodName = "/gatotkaca.v1.GatotKaca/RunWorkflow"
+	GatotKaca_ListTools_FullMethodName   = "/gatotkaca.v1.GatotKaca/ListTools"
+)
+
+// GatotKacaClient is the client API for GatotKaca service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// GatotKaca exposes registered agents and workflows to non-Go services.
+type GatotKacaClient interface {
+	// AgentChat sends a message to a registered agent and streams the response.
+	AgentChat(ctx context.Context, in *AgentChatRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AgentChatResponse], error)
+	// RunWorkflow executes a registered workflow and streams the result of every step.
+	RunWorkflow(ctx context.Context, in *RunWorkflowRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RunWorkflowResponse], error)
+	// ListTools lists the tools registered with an agent.
+	ListTools(ctx context.Context, in *ListToolsRequest, opts ...grpc.CallOption) (*ListToolsResponse, error)
+}
+
+type gatotKacaClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewGatotKacaClient(cc grpc.ClientConnInterface) GatotKacaClient {
+	return &gatotKacaClient{cc}
+}
+
+func (c *gatotKacaClient) AgentChat(ctx context.Context, in *AgentChatRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AgentChatResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &GatotKaca_ServiceDesc.Streams[0], GatotKaca_AgentChat_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[AgentChatRequest, AgentChatResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GatotKaca_AgentChatClient = grpc.ServerStreamingClient[AgentChatResponse]
+
+func (c *gatotKacaClient) RunWorkflow(ctx context.Context, in *RunWorkflowRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RunWorkflowResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &GatotKaca_ServiceDesc.Streams[1], GatotKaca_RunWorkflow_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[RunWorkflowRequest, RunWorkflowResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GatotKaca_RunWorkflowClient = grpc.ServerStreamingClient[RunWorkflowResponse]
+
+func (c *gatotKacaClient) ListTools(ctx context.Context, in *ListToolsRequest, opts ...grpc.CallOption) (*ListToolsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListToolsResponse)
+	err := c.cc.Invoke(ctx, GatotKaca_ListTools_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GatotKacaServer is the server API for GatotKaca service.
+// All implementations must embed UnimplementedGatotKacaServer
+// for forward compatibility.
+//
+// GatotKaca exposes registered agents and workflows to non-Go services.
+type GatotKacaServer interface {
+	// AgentChat sends a message to a registered agent and streams the response.
+	AgentChat(*AgentChatRequest, grpc.ServerStreamingServer[AgentChatResponse]) error
+	// RunWorkflow executes a registered workflow and streams the result of every step.
+	RunWorkflow(*RunWorkflowRequest, grpc.ServerStreamingServer[RunWorkflowResponse]) error
+	// ListTools lists the tools registered with an agent.
+	ListTools(context.Context, *ListToolsRequest) (*ListToolsResponse, error)
+	mustEmbedUnimplementedGatotKacaServer()
+}
+
+// UnimplementedGatotKacaServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedGatotKacaServer struct{}
+
+func (UnimplementedGatotKacaServer) AgentChat(*AgentChatRequest, grpc.ServerStreamingServer[AgentChatResponse]) error {
+	return status.Error(codes.Unimplemented, "method AgentChat not implemented")
+}
+func (UnimplementedGatotKacaServer) RunWorkflow(*RunWorkflowRequest, grpc.ServerStreamingServer[RunWorkflowResponse]) error {
+	return status.Error(codes.Unimplemented, "method RunWorkflow not implemented")
+}
+func (UnimplementedGatotKacaServer) ListTools(context.Context, *ListToolsRequest) (*ListToolsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListTools not implemented")
+}
+func (UnimplementedGatotKacaServer) mustEmbedUnimplementedGatotKacaServer() {}
+func (UnimplementedGatotKacaServer) testEmbeddedByValue()                   {}
+
+// UnsafeGatotKacaServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to GatotKacaServer will
+// result in compilation errors.
+type UnsafeGatotKacaServer interface {
+	mustEmbedUnimplementedGatotKacaServer()
+}
+
+func RegisterGatotKacaServer(s grpc.ServiceRegistrar, srv GatotKacaServer) {
+	// If the following call panics, it indicates UnimplementedGatotKacaServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&GatotKaca_ServiceDesc, srv)
+}
+
+func _GatotKaca_AgentChat_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(AgentChatRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GatotKacaServer).AgentChat(m, &grpc.GenericServerStream[AgentChatRequest, AgentChatResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GatotKaca_AgentChatServer = grpc.ServerStreamingServer[AgentChatResponse]
+
+func _GatotKaca_RunWorkflow_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(RunWorkflowRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GatotKacaServer).RunWorkflow(m, &grpc.GenericServerStream[RunWorkflowRequest, RunWorkflowResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GatotKaca_RunWorkflowServer = grpc.ServerStreamingServer[RunWorkflowResponse]
+
+func _GatotKaca_ListTools_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListToolsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GatotKacaServer).ListTools(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GatotKaca_ListTools_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GatotKacaServer).ListTools(ctx, req.(*ListToolsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// GatotKaca_ServiceDesc is the grpc.ServiceDesc for GatotKaca service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var GatotKaca_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "gatotkaca.v1.GatotKaca",
+	HandlerType: (*GatotKacaServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListTools",
+			Handler:    _GatotKaca_ListTools_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "AgentChat",
+			Handler:       _GatotKaca_AgentChat_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "RunWorkflow",
+			Handler:       _GatotKaca_RunWorkflow_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "gatotkaca.proto",
+}
@@ -0,0 +1,4 @@
+// Package rpc exposes gatot-kaca agents and workflows as a gRPC service.
+package rpc
+
+//go:generate protoc --go_out=. --go_opt=module=github.com/zakirkun/gatot-kaca/rpc --go-grpc_out=. --go-grpc_opt=module=github.com/zakirkun/gatot-kaca/rpc gatotkaca.proto
@@ -0,0 +1,180 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"sort"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/zakirkun/gatot-kaca/agent"
+	"github.com/zakirkun/gatot-kaca/agent/tools"
+	"github.com/zakirkun/gatot-kaca/llm"
+	pb "github.com/zakirkun/gatot-kaca/rpc/gatotkacapb"
+	"github.com/zakirkun/gatot-kaca/workflow"
+)
+
+// Server implements the GatotKaca gRPC service on top of registered agents and workflows.
+type Server struct {
+	pb.UnimplementedGatotKacaServer
+
+	mu        sync.RWMutex
+	agents    map[string]func() *agent.Agent
+	workflows map[string]*workflow.Flow
+}
+
+// NewServer creates a new Server with no registered agents or workflows.
+func NewServer() *Server {
+	return &Server{
+		agents:    make(map[string]func() *agent.Agent),
+		workflows: make(map[string]*workflow.Flow),
+	}
+}
+
+// RegisterAgent exposes the agents created by factory under the given name.
+// Agents keep conversation state, so every call gets its own instance.
+func (s *Server) RegisterAgent(name string, factory func() *agent.Agent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.agents[name] = factory
+}
+
+// RegisterWorkflow exposes a flow under the given name.
+func (s *Server) RegisterWorkflow(name string, flow *workflow.Flow) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.workflows[name] = flow
+}
+
+// Serve registers the service on a new grpc.Server and serves it on lis.
+func (s *Server) Serve(lis net.Listener, opts ...grpc.ServerOption) error {
+	gs := grpc.NewServer(opts...)
+	pb.RegisterGatotKacaServer(gs, s)
+	return gs.Serve(lis)
+}
+
+// AgentChat sends a message to a registered agent and streams the response.
+func (s *Server) AgentChat(req *pb.AgentChatRequest, stream grpc.ServerStreamingServer[pb.AgentChatResponse]) error {
+	a, err := s.newAgent(req.GetAgent())
+	if err != nil {
+		return err
+	}
+	for _, msg := range req.GetHistory() {
+		a.AppendMessage(msg.GetRole(), msg.GetContent())
+	}
+
+	usage := llm.NewUsageRecorder()
+	ctx := llm.ContextWithUsageRecorder(stream.Context(), usage)
+
+	text, err := a.SendStream(ctx, req.GetMessage(), func(chunk string) error {
+		return stream.Send(&pb.AgentChatResponse{Event: &pb.AgentChatResponse_Delta{Delta: chunk}})
+	})
+	if err != nil {
+		return status.Errorf(codes.Internal, "agent chat failed: %v", err)
+	}
+
+	return stream.Send(&pb.AgentChatResponse{
+		Event: &pb.AgentChatResponse_Result{
+			Result: &pb.AgentChatResult{Text: text, Usage: toUsage(usage.Usage())},
+		},
+	})
+}
+
+// RunWorkflow executes a registered workflow and streams the result of every step.
+func (s *Server) RunWorkflow(req *pb.RunWorkflowRequest, stream grpc.ServerStreamingServer[pb.RunWorkflowResponse]) error {
+	s.mu.RLock()
+	flow, ok := s.workflows[req.GetWorkflow()]
+	s.mu.RUnlock()
+	if !ok {
+		return status.Errorf(codes.NotFound, "workflow '%s' not found", req.GetWorkflow())
+	}
+
+	var sendErr error
+	result, err := flow.RunDetailedWithCallback(stream.Context(), req.GetInput(), func(step workflow.StepResult) {
+		if sendErr != nil {
+			return
+		}
+		sendErr = stream.Send(&pb.RunWorkflowResponse{
+			Event: &pb.RunWorkflowResponse_Step{Step: toStep(step)},
+		})
+	})
+	if sendErr != nil {
+		return sendErr
+	}
+	if err != nil {
+		return status.Errorf(codes.Internal, "workflow failed: %v", err)
+	}
+
+	return stream.Send(&pb.RunWorkflowResponse{
+		Event: &pb.RunWorkflowResponse_Result{
+			Result: &pb.WorkflowResult{
+				RunId:      result.RunID,
+				Output:     result.Output,
+				Usage:      toUsage(result.Usage),
+				DurationMs: result.Duration.Milliseconds(),
+			},
+		},
+	})
+}
+
+// ListTools lists the tools registered with an agent.
+func (s *Server) ListTools(ctx context.Context, req *pb.ListToolsRequest) (*pb.ListToolsResponse, error) {
+	a, err := s.newAgent(req.GetAgent())
+	if err != nil {
+		return nil, err
+	}
+
+	manager := a.Tools()
+	names := manager.ListTools()
+	sort.Strings(names)
+
+	resp := &pb.ListToolsResponse{}
+	for _, name := range names {
+		t, err := manager.GetTool(name)
+		if err != nil {
+			continue
+		}
+		info := &pb.Tool{Name: t.Name(), Description: t.Description()}
+		if et, ok := t.(tools.EnhancedTool); ok {
+			info.Schema = et.Schema()
+			info.Help = et.Help()
+		}
+		resp.Tools = append(resp.Tools, info)
+	}
+	return resp, nil
+}
+
+func (s *Server) newAgent(name string) (*agent.Agent, error) {
+	s.mu.RLock()
+	factory, ok := s.agents[name]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "agent '%s' not found", name)
+	}
+	return factory(), nil
+}
+
+func toUsage(u llm.Usage) *pb.Usage {
+	return &pb.Usage{
+		PromptTokens:     int32(u.PromptTokens),
+		CompletionTokens: int32(u.CompletionTokens),
+		TotalTokens:      int32(u.TotalTokens),
+	}
+}
+
+func toStep(step workflow.StepResult) *pb.StepResult {
+	out := &pb.StepResult{
+		Index:      int32(step.Index),
+		Input:      step.Input,
+		Output:     step.Output,
+		DurationMs: step.Duration.Milliseconds(),
+		Usage:      toUsage(step.Usage),
+	}
+	if step.Err != nil {
+		out.Error = step.Err.Error()
+	}
+	return out
+}
@@ -0,0 +1,129 @@
+package rpc_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"github.com/zakirkun/gatot-kaca/agent"
+	"github.com/zakirkun/gatot-kaca/llmtest"
+	"github.com/zakirkun/gatot-kaca/rpc"
+	pb "github.com/zakirkun/gatot-kaca/rpc/gatotkacapb"
+	"github.com/zakirkun/gatot-kaca/workflow"
+)
+
+// newClient serves s on a local port and returns a client connected to it.
+func newClient(t *testing.T, s *rpc.Server) pb.GatotKacaClient {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.Serve(lis)
+	t.Cleanup(func() { lis.Close() })
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewGatotKacaClient(conn)
+}
+
+func TestAgentChat(t *testing.T) {
+	model := llmtest.NewMockModel("mock").Default("Jakarta is the capital.")
+	s := rpc.NewServer()
+	s.RegisterAgent("assistant", func() *agent.Agent {
+		return llmtest.NewAgent(model, llmtest.NewMockTool("search", "result"))
+	})
+	client := newClient(t, s)
+	ctx := context.Background()
+
+	stream, err := client.AgentChat(ctx, &pb.AgentChatRequest{
+		Agent:   "assistant",
+		Message: "What is its capital?",
+		History: []*pb.ChatMessage{{Role: "User", Content: "I am visiting Indonesia."}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var deltas strings.Builder
+	var result *pb.AgentChatResult
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		deltas.WriteString(resp.GetDelta())
+		if r := resp.GetResult(); r != nil {
+			result = r
+		}
+	}
+	if result.GetText() != "Jakarta is the capital." || deltas.String() != result.GetText() {
+		t.Errorf("streamed %q, result %v", deltas.String(), result)
+	}
+	model.AssertPromptContains(t, "visiting Indonesia")
+
+	tools, err := client.ListTools(ctx, &pb.ListToolsRequest{Agent: "assistant"})
+	if err != nil || len(tools.GetTools()) != 1 || tools.GetTools()[0].GetName() != "search" {
+		t.Errorf("ListTools = %v, %v", tools, err)
+	}
+	if _, err := client.ListTools(ctx, &pb.ListToolsRequest{Agent: "missing"}); status.Code(err) != codes.NotFound {
+		t.Errorf("unknown agent: got %v, want NotFound", err)
+	}
+}
+
+func TestRunWorkflow(t *testing.T) {
+	upper := &workflow.FuncNode{Process: func(ctx context.Context, input string) (string, error) {
+		return strings.ToUpper(input), nil
+	}}
+	exclaim := &workflow.FuncNode{Process: func(ctx context.Context, input string) (string, error) {
+		return input + "!", nil
+	}}
+	s := rpc.NewServer()
+	s.RegisterWorkflow("shout", workflow.NewFlow([]workflow.Node{upper, exclaim}))
+	client := newClient(t, s)
+
+	stream, err := client.RunWorkflow(context.Background(), &pb.RunWorkflowRequest{Workflow: "shout", Input: "hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var steps []string
+	var output string
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if step := resp.GetStep(); step != nil {
+			steps = append(steps, step.GetOutput())
+		}
+		if r := resp.GetResult(); r != nil {
+			output = r.GetOutput()
+		}
+	}
+	if strings.Join(steps, ",") != "HI,HI!" || output != "HI!" {
+		t.Errorf("steps %q, output %q", steps, output)
+	}
+
+	stream, err = client.RunWorkflow(context.Background(), &pb.RunWorkflowRequest{Workflow: "missing"})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("unknown workflow: got %v, want NotFound", err)
+	}
+}
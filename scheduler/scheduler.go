@@ -0,0 +1,328 @@
+// Package scheduler runs workflows and agent prompts on recurring schedules, such as cron
+// expressions or fixed intervals, for periodic jobs like report generation.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/zakirkun/gatot-kaca/agent"
+	"github.com/zakirkun/gatot-kaca/logging"
+	"github.com/zakirkun/gatot-kaca/workflow"
+)
+
+// Task is the work performed by a job. It returns the run's output.
+type Task func(ctx context.Context) (string, error)
+
+// FlowTask returns a task that runs the flow with the given input.
+func FlowTask(f *workflow.Flow, input string) Task {
+	return func(ctx context.Context) (string, error) {
+		return f.Run(ctx, input)
+	}
+}
+
+// AgentTask returns a task that sends prompt to the agent in a fresh conversation.
+// The agent must not be used elsewhere while the task runs.
+func AgentTask(a *agent.Agent, prompt string) Task {
+	return func(ctx context.Context) (string, error) {
+		a.Reset()
+		return a.Send(ctx, prompt)
+	}
+}
+
+// OverlapPolicy decides what happens when a job is due while its previous run is still going.
+type OverlapPolicy int
+
+const (
+	// Skip drops the activation. It is the default.
+	Skip OverlapPolicy = iota
+	// Queue runs the job once more as soon as the current run finishes; further activations
+	// while a run is queued are dropped.
+	Queue
+	// Allow starts a concurrent run.
+	Allow
+)
+
+// DefaultHistoryLimit is the number of runs kept per job when Job.HistoryLimit is zero.
+const DefaultHistoryLimit = 50
+
+// Job is a task run on a schedule.
+type Job struct {
+	Name     string
+	Schedule Schedule
+	Task     Task
+	Overlap  OverlapPolicy
+	// Jitter delays each activation by a random duration in [0, Jitter), so that jobs sharing a
+	// schedule do not all start at the same moment.
+	Jitter time.Duration
+	// Timeout bounds each run; zero means no limit.
+	Timeout      time.Duration
+	HistoryLimit int
+}
+
+// RunRecord describes a single activation of a job.
+type RunRecord struct {
+	Job       string        `json:"job"`
+	Scheduled time.Time     `json:"scheduled"` // Activation time, before jitter.
+	Started   time.Time     `json:"started,omitempty"`
+	Duration  time.Duration `json:"duration"`
+	Output    string        `json:"output,omitempty"`
+	Error     string        `json:"error,omitempty"`
+	// Skipped is set when the activation was dropped because of the overlap policy.
+	Skipped bool `json:"skipped,omitempty"`
+}
+
+// jobState is the runtime state of a registered job.
+type jobState struct {
+	job     Job
+	running int
+	queued  bool
+	history []RunRecord
+	next    time.Time
+}
+
+// Scheduler runs jobs on their schedules. Jobs can be added before or after Start.
+type Scheduler struct {
+	// Logger reports failed runs and is passed to the tasks through their context; defaults to
+	// logging.Default. Set it before Start.
+	Logger logging.Logger
+
+	mu      sync.Mutex
+	jobs    map[string]*jobState
+	ctx     context.Context
+	cancel  context.CancelFunc
+	stop    chan struct{}
+	started bool
+	loops   sync.WaitGroup // Scheduling loops.
+	runs    sync.WaitGroup // Job runs in progress.
+}
+
+// New creates an empty scheduler.
+func New() *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		jobs:   make(map[string]*jobState),
+		ctx:    ctx,
+		cancel: cancel,
+		stop:   make(chan struct{}),
+	}
+}
+
+// Add registers a job. Job names must be unique.
+func (s *Scheduler) Add(job Job) error {
+	if job.Name == "" || job.Schedule == nil || job.Task == nil {
+		return errors.New("scheduler: job name, schedule and task are required")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[job.Name]; ok {
+		return fmt.Errorf("scheduler: job %q already exists", job.Name)
+	}
+	st := &jobState{job: job}
+	s.jobs[job.Name] = st
+	if s.started {
+		s.loops.Add(1)
+		go s.loop(st)
+	}
+	return nil
+}
+
+// Start begins running the jobs on their schedules. It returns immediately.
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return
+	}
+	s.started = true
+	for _, st := range s.jobs {
+		s.loops.Add(1)
+		go s.loop(st)
+	}
+}
+
+// Stop stops scheduling new runs and waits for the runs in progress to finish. If ctx ends
+// first, the runs' contexts are canceled and Stop returns ctx's error without waiting further.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	select {
+	case <-s.stop:
+	default:
+		close(s.stop)
+	}
+	s.mu.Unlock()
+	s.loops.Wait()
+
+	done := make(chan struct{})
+	go func() {
+		s.runs.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		s.cancel()
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		return ctx.Err()
+	}
+}
+
+// RunNow triggers a job immediately, subject to its overlap policy.
+func (s *Scheduler) RunNow(name string) error {
+	s.mu.Lock()
+	st, ok := s.jobs[name]
+	s.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("scheduler: unknown job %q", name)
+	}
+	s.trigger(st, time.Now())
+	return nil
+}
+
+// History returns the recorded runs of a job, oldest first.
+func (s *Scheduler) History(name string) []RunRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if st, ok := s.jobs[name]; ok {
+		return append([]RunRecord(nil), st.history...)
+	}
+	return nil
+}
+
+// JobInfo describes a registered job.
+type JobInfo struct {
+	Name    string    `json:"name"`
+	Next    time.Time `json:"next,omitempty"` // Next activation, before jitter.
+	Running int       `json:"running"`
+}
+
+// Jobs returns the registered jobs.
+func (s *Scheduler) Jobs() []JobInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	infos := make([]JobInfo, 0, len(s.jobs))
+	for name, st := range s.jobs {
+		infos = append(infos, JobInfo{Name: name, Next: st.next, Running: st.running})
+	}
+	return infos
+}
+
+// loop waits for each activation of a job and triggers it until the scheduler stops.
+func (s *Scheduler) loop(st *jobState) {
+	defer s.loops.Done()
+	for {
+		now := time.Now()
+		next := st.job.Schedule.Next(now)
+		if next.IsZero() {
+			return
+		}
+		s.mu.Lock()
+		st.next = next
+		s.mu.Unlock()
+
+		wait := next.Sub(now)
+		if st.job.Jitter > 0 {
+			wait += time.Duration(rand.Int63n(int64(st.job.Jitter)))
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-s.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+			s.trigger(st, next)
+		}
+	}
+}
+
+// trigger starts a run of the job unless the overlap policy drops or queues it.
+func (s *Scheduler) trigger(st *jobState, scheduled time.Time) {
+	s.mu.Lock()
+	if st.running > 0 {
+		switch st.job.Overlap {
+		case Queue:
+			st.queued = true
+			s.mu.Unlock()
+			return
+		case Skip:
+			s.record(st, RunRecord{Job: st.job.Name, Scheduled: scheduled, Skipped: true})
+			s.mu.Unlock()
+			return
+		}
+	}
+	st.running++
+	s.runs.Add(1)
+	s.mu.Unlock()
+	go s.run(st, scheduled)
+}
+
+// run executes the job, records the result, and starts a queued run if there is one.
+func (s *Scheduler) run(st *jobState, scheduled time.Time) {
+	defer s.runs.Done()
+	for {
+		ctx := s.ctx
+		if s.Logger != nil {
+			ctx = logging.NewContext(ctx, s.Logger)
+		}
+		var cancel context.CancelFunc = func() {}
+		if st.job.Timeout > 0 {
+			ctx, cancel = context.WithTimeout(ctx, st.job.Timeout)
+		}
+		rec := RunRecord{Job: st.job.Name, Scheduled: scheduled, Started: time.Now()}
+		output, err := safeRun(ctx, st.job.Task)
+		cancel()
+		rec.Duration = time.Since(rec.Started)
+		rec.Output = output
+		if err != nil {
+			rec.Error = err.Error()
+			logging.FromContext(ctx).Warn("job failed", logging.KeyComponent, "scheduler", "job", st.job.Name,
+				logging.KeyError, err)
+		}
+
+		s.mu.Lock()
+		s.record(st, rec)
+		stopping := false
+		select {
+		case <-s.stop:
+			stopping = true
+		default:
+		}
+		if st.queued && !stopping {
+			st.queued = false
+			scheduled = time.Now()
+			s.mu.Unlock()
+			continue
+		}
+		st.queued = false
+		st.running--
+		s.mu.Unlock()
+		return
+	}
+}
+
+// safeRun runs the task, converting a panic into an error.
+func safeRun(ctx context.Context, task Task) (output string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return task(ctx)
+}
+
+// record appends a run to the job's history. Callers must hold s.mu.
+func (s *Scheduler) record(st *jobState, rec RunRecord) {
+	limit := st.job.HistoryLimit
+	if limit <= 0 {
+		limit = DefaultHistoryLimit
+	}
+	st.history = append(st.history, rec)
+	if len(st.history) > limit {
+		st.history = st.history[len(st.history)-limit:]
+	}
+}
@@ -0,0 +1,151 @@
+package scheduler_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zakirkun/gatot-kaca/scheduler"
+)
+
+func TestCronNext(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	for _, tc := range []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{
+			name: "every 15 minutes",
+			expr: "*/15 * * * *",
+			from: time.Date(2026, 1, 5, 10, 7, 30, 0, time.UTC),
+			want: time.Date(2026, 1, 5, 10, 15, 0, 0, time.UTC),
+		},
+		{
+			name: "weekdays",
+			expr: "0 9 * * MON-FRI",
+			from: time.Date(2026, 1, 9, 12, 0, 0, 0, time.UTC), // Friday.
+			want: time.Date(2026, 1, 12, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "across spring forward",
+			expr: "0 9 * * *",
+			from: time.Date(2026, 3, 7, 12, 0, 0, 0, ny),
+			want: time.Date(2026, 3, 8, 9, 0, 0, 0, ny),
+		},
+		{
+			name: "hour skipped by spring forward",
+			expr: "30 2 * * *",
+			from: time.Date(2026, 3, 7, 12, 0, 0, 0, ny),
+			want: time.Date(2026, 3, 9, 2, 30, 0, 0, ny),
+		},
+		{
+			name: "across fall back",
+			expr: "0 3 * * *",
+			from: time.Date(2026, 10, 31, 12, 0, 0, 0, ny),
+			want: time.Date(2026, 11, 1, 3, 0, 0, 0, ny),
+		},
+		{
+			name: "impossible date",
+			expr: "0 0 30 2 *",
+			from: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := scheduler.CronIn(tc.expr, tc.from.Location())
+			if err != nil {
+				t.Fatal(err)
+			}
+			done := make(chan time.Time, 1)
+			go func() { done <- s.Next(tc.from) }()
+			select {
+			case got := <-done:
+				if !got.Equal(tc.want) {
+					t.Errorf("Next(%v) = %v, want %v", tc.from, got, tc.want)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("Next(%v) did not return", tc.from)
+			}
+		})
+	}
+}
+
+func TestCronInvalid(t *testing.T) {
+	for _, expr := range []string{"* * * *", "60 * * * *", "*/0 * * * *", "5-1 * * * *", "* * * FOO *"} {
+		if _, err := scheduler.Cron(expr); err == nil {
+			t.Errorf("Cron(%q) succeeded, want an error", expr)
+		}
+	}
+}
+
+// blockingJob returns a job whose runs block until release is closed, counting its runs.
+func blockingJob(overlap scheduler.OverlapPolicy, runs *atomic.Int32, started chan<- struct{}, release <-chan struct{}) scheduler.Job {
+	return scheduler.Job{
+		Name:     "job",
+		Schedule: scheduler.Every(time.Hour),
+		Overlap:  overlap,
+		Task: func(ctx context.Context) (string, error) {
+			runs.Add(1)
+			started <- struct{}{}
+			<-release
+			return "done", nil
+		},
+	}
+}
+
+func TestOverlapSkip(t *testing.T) {
+	var runs atomic.Int32
+	started, release := make(chan struct{}, 4), make(chan struct{})
+	s := scheduler.New()
+	if err := s.Add(blockingJob(scheduler.Skip, &runs, started, release)); err != nil {
+		t.Fatal(err)
+	}
+	s.RunNow("job")
+	<-started
+	s.RunNow("job")
+	close(release)
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := runs.Load(); got != 1 {
+		t.Errorf("ran %d times, want 1", got)
+	}
+	history := s.History("job")
+	if len(history) != 2 || !history[0].Skipped || history[1].Output != "done" {
+		t.Errorf("history = %+v, want a skipped activation and a completed run", history)
+	}
+}
+
+func TestOverlapQueue(t *testing.T) {
+	var runs atomic.Int32
+	started, release := make(chan struct{}, 4), make(chan struct{})
+	s := scheduler.New()
+	if err := s.Add(blockingJob(scheduler.Queue, &runs, started, release)); err != nil {
+		t.Fatal(err)
+	}
+	s.RunNow("job")
+	<-started
+	// Further activations while one is queued are dropped.
+	s.RunNow("job")
+	s.RunNow("job")
+	close(release)
+	<-started
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := runs.Load(); got != 2 {
+		t.Errorf("ran %d times, want 2", got)
+	}
+	for _, rec := range s.History("job") {
+		if rec.Skipped {
+			t.Errorf("queued activation recorded as skipped: %+v", rec)
+		}
+	}
+}
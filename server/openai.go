@@ -0,0 +1,93 @@
+package server
+
+// ChatCompletionRequest is the subset of the OpenAI chat completions request understood by the server.
+type ChatCompletionRequest struct {
+	Model       string        `json:"model"`
+	Messages    []ChatMessage `json:"messages"`
+	Stream      bool          `json:"stream,omitempty"`
+	MaxTokens   int           `json:"max_tokens,omitempty"`
+	Temperature *float64      `json:"temperature,omitempty"`
+	TopP        *float64      `json:"top_p,omitempty"`
+}
+
+// ChatMessage is a single message in an OpenAI-style conversation.
+type ChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ChatCompletionResponse is an OpenAI-compatible non-streaming response.
+type ChatCompletionResponse struct {
+	ID      string                 `json:"id"`
+	Object  string                 `json:"object"`
+	Created int64                  `json:"created"`
+	Model   string                 `json:"model"`
+	Choices []ChatCompletionChoice `json:"choices"`
+	Usage   ChatCompletionUsage    `json:"usage"`
+}
+
+// ChatCompletionChoice is a single choice in a non-streaming response.
+type ChatCompletionChoice struct {
+	Index        int         `json:"index"`
+	Message      ChatMessage `json:"message"`
+	FinishReason string      `json:"finish_reason"`
+}
+
+// ChatCompletionUsage reports token usage in OpenAI format.
+type ChatCompletionUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// ChatCompletionChunk is an OpenAI-compatible streaming event.
+type ChatCompletionChunk struct {
+	ID      string                `json:"id"`
+	Object  string                `json:"object"`
+	Created int64                 `json:"created"`
+	Model   string                `json:"model"`
+	Choices []ChatCompletionDelta `json:"choices"`
+	Usage   *ChatCompletionUsage  `json:"usage,omitempty"`
+}
+
+// ChatCompletionDelta is a single choice in a streaming event.
+type ChatCompletionDelta struct {
+	Index        int       `json:"index"`
+	Delta        ChatDelta `json:"delta"`
+	FinishReason *string   `json:"finish_reason"`
+}
+
+// ChatDelta holds the incremental content of a streaming event.
+type ChatDelta struct {
+	Role    string `json:"role,omitempty"`
+	Content string `json:"content,omitempty"`
+	// Replace is set, as an extension of the OpenAI API, when the streamed text was not the final
+	// response, e.g. the reasoning steps of a ReAct agent: Content then holds the whole response,
+	// which replaces the content received so far.
+	Replace bool `json:"replace,omitempty"`
+}
+
+// ModelList is the response of the /v1/models endpoint.
+type ModelList struct {
+	Object string      `json:"object"`
+	Data   []ModelInfo `json:"data"`
+}
+
+// ModelInfo describes a registered agent as an OpenAI model.
+type ModelInfo struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+}
+
+// ErrorResponse is an OpenAI-compatible error body.
+type ErrorResponse struct {
+	Error ErrorDetail `json:"error"`
+}
+
+// ErrorDetail describes an error in OpenAI format.
+type ErrorDetail struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+}
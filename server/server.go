@@ -0,0 +1,284 @@
+// Package server exposes gatot-kaca agents over HTTP using an OpenAI-compatible API,
+// so existing chat UIs and SDKs can talk to them directly, and over WebSocket with a JSON
+// protocol that streams tool events alongside the response for richer frontends.
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zakirkun/gatot-kaca/agent"
+	"github.com/zakirkun/gatot-kaca/llm"
+)
+
+// AgentFactory creates a fresh agent for a single request.
+// Agents keep conversation state, so every request gets its own instance. The Clone method of a
+// configured template agent is a suitable factory.
+type AgentFactory func() *agent.Agent
+
+// Server serves registered agents through an OpenAI-compatible /v1/chat/completions endpoint
+// and a WebSocket endpoint at /v1/ws.
+type Server struct {
+	mu     sync.RWMutex
+	agents map[string]AgentFactory
+	mux    *http.ServeMux
+}
+
+// NewServer creates a new Server with no registered agents.
+func NewServer() *Server {
+	s := &Server{
+		agents: make(map[string]AgentFactory),
+		mux:    http.NewServeMux(),
+	}
+	s.mux.HandleFunc("/v1/chat/completions", s.handleChatCompletions)
+	s.mux.HandleFunc("/v1/models", s.handleModels)
+	s.mux.HandleFunc("/v1/ws", s.handleWebSocket)
+	return s
+}
+
+// Register exposes the agents created by factory under the given model name.
+func (s *Server) Register(name string, factory AgentFactory) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.agents[name] = factory
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// ListenAndServe starts an HTTP server on addr that serves the registered agents.
+func (s *Server) ListenAndServe(addr string) error {
+	return http.ListenAndServe(addr, s)
+}
+
+func (s *Server) handleModels(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "invalid_request_error", "method not allowed")
+		return
+	}
+
+	s.mu.RLock()
+	names := make([]string, 0, len(s.agents))
+	for name := range s.agents {
+		names = append(names, name)
+	}
+	s.mu.RUnlock()
+	sort.Strings(names)
+
+	list := ModelList{Object: "list", Data: make([]ModelInfo, 0, len(names))}
+	for _, name := range names {
+		list.Data = append(list.Data, ModelInfo{ID: name, Object: "model", OwnedBy: "gatot-kaca"})
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "invalid_request_error", "method not allowed")
+		return
+	}
+
+	var req ChatCompletionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+
+	s.mu.RLock()
+	factory, ok := s.agents[req.Model]
+	s.mu.RUnlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "invalid_request_error", fmt.Sprintf("model '%s' not found", req.Model))
+		return
+	}
+
+	a := factory()
+	input, err := prepareAgent(a, req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+
+	usage := llm.NewUsageRecorder()
+	ctx := llm.ContextWithUsageRecorder(r.Context(), usage)
+
+	if req.Stream {
+		s.streamCompletion(ctx, w, a, req.Model, input, usage)
+		return
+	}
+
+	output, err := a.Send(ctx, input)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ChatCompletionResponse{
+		ID:      newCompletionID(),
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   req.Model,
+		Choices: []ChatCompletionChoice{
+			{
+				Index:        0,
+				Message:      ChatMessage{Role: "assistant", Content: output},
+				FinishReason: "stop",
+			},
+		},
+		Usage: toUsage(usage.Usage()),
+	})
+}
+
+// streamCompletion sends the agent response as OpenAI-style server-sent events.
+func (s *Server) streamCompletion(ctx context.Context, w http.ResponseWriter, a *agent.Agent, model, input string, usage *llm.UsageRecorder) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "server_error", "streaming not supported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	id := newCompletionID()
+	created := time.Now().Unix()
+	send := func(delta ChatDelta, finish *string, u *ChatCompletionUsage) error {
+		chunk := ChatCompletionChunk{
+			ID:      id,
+			Object:  "chat.completion.chunk",
+			Created: created,
+			Model:   model,
+			Choices: []ChatCompletionDelta{{Index: 0, Delta: delta, FinishReason: finish}},
+			Usage:   u,
+		}
+		data, err := json.Marshal(chunk)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+
+	if err := send(ChatDelta{Role: "assistant"}, nil, nil); err != nil {
+		return
+	}
+
+	var streamed strings.Builder
+	output, err := a.SendStream(ctx, input, func(chunk string) error {
+		streamed.WriteString(chunk)
+		return send(ChatDelta{Content: chunk}, nil, nil)
+	})
+	if err != nil {
+		data, _ := json.Marshal(ErrorResponse{Error: ErrorDetail{Message: err.Error(), Type: "server_error"}})
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		flusher.Flush()
+		return
+	}
+
+	if rest, replace := finalDelta(output, streamed.String()); rest != "" || replace {
+		if err := send(ChatDelta{Content: rest, Replace: replace}, nil, nil); err != nil {
+			return
+		}
+	}
+
+	stop := "stop"
+	u := toUsage(usage.Usage())
+	if err := send(ChatDelta{}, &stop, &u); err != nil {
+		return
+	}
+	fmt.Fprint(w, "data: [DONE]\n\n")
+	flusher.Flush()
+}
+
+// finalDelta returns the text to send once the response has been streamed, so that the client
+// ends up with output. Tool output may extend the streamed text, in which case the rest is
+// returned; if nothing was streamed, it is all of output. If the streamed text was not the final
+// response, e.g. the reasoning steps of a ReAct agent, output does not extend it, and it is
+// returned whole with replace set. Agents whose middlewares may rewrite responses only stream
+// them once processed (see agent.Agent.SendStream), so unapproved text never reaches the client.
+func finalDelta(output, streamed string) (rest string, replace bool) {
+	if rest, ok := strings.CutPrefix(output, streamed); ok {
+		return rest, false
+	}
+	return output, true
+}
+
+// prepareAgent loads the request conversation into the agent and returns the final user message.
+func prepareAgent(a *agent.Agent, req ChatCompletionRequest) (string, error) {
+	if len(req.Messages) == 0 {
+		return "", fmt.Errorf("messages must not be empty")
+	}
+	last := req.Messages[len(req.Messages)-1]
+	if last.Role != "user" {
+		return "", fmt.Errorf("last message must have role 'user'")
+	}
+
+	for _, msg := range req.Messages[:len(req.Messages)-1] {
+		a.AppendMessage(agentRole(msg.Role), msg.Content)
+	}
+	if req.MaxTokens > 0 {
+		a.MaxTokens = req.MaxTokens
+	}
+	if req.Temperature != nil {
+		a.Temperature = *req.Temperature
+	}
+	if req.TopP != nil {
+		a.TopP = *req.TopP
+	}
+	return last.Content, nil
+}
+
+// agentRole maps an OpenAI role to the role names used in agent history.
+func agentRole(role string) string {
+	switch role {
+	case "system":
+		return "System"
+	case "assistant":
+		return "Assistant"
+	case "tool":
+		return "Tool Response"
+	default:
+		return "User"
+	}
+}
+
+func toUsage(u llm.Usage) ChatCompletionUsage {
+	return ChatCompletionUsage{
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+		TotalTokens:      u.TotalTokens,
+	}
+}
+
+func newCompletionID() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("chatcmpl-%d", time.Now().UnixNano())
+	}
+	return "chatcmpl-" + hex.EncodeToString(b)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, errType, message string) {
+	writeJSON(w, status, ErrorResponse{Error: ErrorDetail{Message: message, Type: errType}})
+}
@@ -0,0 +1,155 @@
+package server_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/zakirkun/gatot-kaca/agent"
+	"github.com/zakirkun/gatot-kaca/llmtest"
+	"github.com/zakirkun/gatot-kaca/server"
+	"golang.org/x/net/websocket"
+)
+
+func newTestServer(t *testing.T, model *llmtest.MockModel) *httptest.Server {
+	s := server.NewServer()
+	s.Register("assistant", func() *agent.Agent { return llmtest.NewAgent(model) })
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func postCompletion(t *testing.T, url string, req server.ChatCompletionRequest) *http.Response {
+	body, _ := json.Marshal(req)
+	resp, err := http.Post(url+"/v1/chat/completions", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestChatCompletions(t *testing.T) {
+	model := llmtest.NewMockModel("mock").Default("Jakarta is the capital.")
+	srv := newTestServer(t, model)
+
+	resp := postCompletion(t, srv.URL, server.ChatCompletionRequest{
+		Model: "assistant",
+		Messages: []server.ChatMessage{
+			{Role: "user", Content: "I am planning a trip to Indonesia."},
+			{Role: "assistant", Content: "Sounds great!"},
+			{Role: "user", Content: "What is its capital?"},
+		},
+	})
+	var out server.ChatCompletionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || len(out.Choices) != 1 || out.Choices[0].Message.Content != "Jakarta is the capital." {
+		t.Fatalf("got %d %+v", resp.StatusCode, out)
+	}
+	model.AssertPromptContains(t, "planning a trip")
+	model.AssertPromptContains(t, "Sounds great!")
+
+	resp = postCompletion(t, srv.URL, server.ChatCompletionRequest{Model: "missing",
+		Messages: []server.ChatMessage{{Role: "user", Content: "hi"}}})
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown model: status %d, want 404", resp.StatusCode)
+	}
+	resp = postCompletion(t, srv.URL, server.ChatCompletionRequest{Model: "assistant",
+		Messages: []server.ChatMessage{{Role: "assistant", Content: "hi"}}})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("no user message: status %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestChatCompletionsStream(t *testing.T) {
+	srv := newTestServer(t, llmtest.NewMockModel("mock").Default("Jakarta is the capital."))
+
+	resp := postCompletion(t, srv.URL, server.ChatCompletionRequest{Model: "assistant", Stream: true,
+		Messages: []server.ChatMessage{{Role: "user", Content: "What is the capital of Indonesia?"}}})
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+
+	var content strings.Builder
+	var finish string
+	done := false
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		if data == "[DONE]" {
+			done = true
+			break
+		}
+		var chunk server.ChatCompletionChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			t.Fatalf("invalid chunk %q: %v", data, err)
+		}
+		content.WriteString(chunk.Choices[0].Delta.Content)
+		if chunk.Choices[0].FinishReason != nil {
+			finish = *chunk.Choices[0].FinishReason
+		}
+	}
+	if !done || finish != "stop" || content.String() != "Jakarta is the capital." {
+		t.Errorf("streamed %q, finish %q, done %v", content.String(), finish, done)
+	}
+}
+
+func TestWebSocket(t *testing.T) {
+	srv := newTestServer(t, llmtest.NewMockModel("mock", "First answer.", "Second answer."))
+	conn, err := websocket.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/v1/ws?model=assistant", "", srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	var frame server.Frame
+	if err := websocket.JSON.Receive(conn, &frame); err != nil || frame.Type != server.FrameSession {
+		t.Fatalf("first frame %+v, %v; want a session frame", frame, err)
+	}
+	for _, msg := range []server.ClientFrame{
+		{Type: server.FrameMessage, ID: "m1", Content: "hello"},
+		{Type: server.FrameMessage, ID: "m2", Content: "again"},
+	} {
+		if err := websocket.JSON.Send(conn, msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deltas := map[string]string{}
+	var done []server.Frame
+	for len(done) < 2 {
+		var frame server.Frame
+		if err := websocket.JSON.Receive(conn, &frame); err != nil {
+			t.Fatal(err)
+		}
+		switch frame.Type {
+		case server.FrameDelta:
+			deltas[frame.ID] += frame.Content
+		case server.FrameDone:
+			done = append(done, frame)
+		case server.FrameError:
+			t.Fatalf("error frame %+v", frame)
+		}
+	}
+	if done[0].ID != "m1" || done[0].Content != "First answer." || done[1].ID != "m2" || done[1].Content != "Second answer." {
+		t.Errorf("done frames %+v", done)
+	}
+	if deltas["m1"] != "First answer." || deltas["m2"] != "Second answer." {
+		t.Errorf("deltas %q", deltas)
+	}
+
+	other, err := websocket.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/v1/ws?model=assistant", "", "http://evil.example")
+	if err == nil {
+		other.Close()
+		t.Error("accepted a connection from another origin")
+	}
+}
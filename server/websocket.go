@@ -0,0 +1,242 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/zakirkun/gatot-kaca/agent"
+	"github.com/zakirkun/gatot-kaca/llm"
+	"golang.org/x/net/websocket"
+)
+
+// WebSocket protocol
+//
+// Clients connect to /v1/ws?model=<name>, where name is a registered agent. Each connection
+// gets its own agent, so the conversation continues across the messages sent on it. Every frame
+// is a JSON text frame with a "type" field.
+//
+// Client frames:
+//
+//	{"type":"message","id":"m1","content":"What is 2+3?"}  send a user message; id is optional
+//	{"type":"cancel"}                                      stop answering the current message
+//	{"type":"reset"}                                       clear the conversation
+//
+// Messages sent while one is being answered are queued and answered in order.
+//
+// Server frames:
+//
+//	{"type":"session","model":"assistant"}                 once, after connecting
+//	{"type":"delta","id":"m1","content":"The answer"}      a piece of the response text
+//	{"type":"replace","id":"m1","content":"..."}           the whole response, replacing the
+//	                                                       deltas, when they were not the final
+//	                                                       response, e.g. ReAct reasoning
+//	{"type":"tool_call_start","id":"m1","tool":{"name":"calculator","input":"2+3"}}
+//	{"type":"tool_call_end","id":"m1","tool":{"name":"calculator","input":"2+3","output":"5"},"duration_ms":2}
+//	{"type":"usage","id":"m1","usage":{"prompt_tokens":40,"completion_tokens":8,"total_tokens":48}}
+//	{"type":"done","id":"m1","content":"The answer is 5."}
+//	{"type":"error","id":"m1","error":"..."}
+//
+// Frames answering a message carry its id. A message ends with usage and done frames, or with
+// an error frame. A failed tool call has "error" set in its tool_call_end frame instead of
+// "output". Error frames without an id report malformed client frames.
+
+// WebSocket frame types.
+const (
+	FrameMessage       = "message"
+	FrameCancel        = "cancel"
+	FrameReset         = "reset"
+	FrameSession       = "session"
+	FrameDelta         = "delta"
+	FrameReplace       = "replace"
+	FrameToolCallStart = agent.ToolCallStart
+	FrameToolCallEnd   = agent.ToolCallEnd
+	FrameUsage         = "usage"
+	FrameDone          = "done"
+	FrameError         = "error"
+)
+
+// ClientFrame is a frame sent by a WebSocket client.
+type ClientFrame struct {
+	Type    string `json:"type"`
+	ID      string `json:"id,omitempty"`
+	Content string `json:"content,omitempty"`
+}
+
+// Frame is a frame sent by the server over WebSocket.
+type Frame struct {
+	Type       string               `json:"type"`
+	ID         string               `json:"id,omitempty"`
+	Model      string               `json:"model,omitempty"`
+	Content    string               `json:"content,omitempty"`
+	Tool       *agent.ToolCall      `json:"tool,omitempty"`
+	DurationMS int64                `json:"duration_ms,omitempty"`
+	Usage      *ChatCompletionUsage `json:"usage,omitempty"`
+	Error      string               `json:"error,omitempty"`
+}
+
+// handleWebSocket upgrades the request and serves a chat session with the requested agent.
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	model := r.URL.Query().Get("model")
+	s.mu.RLock()
+	factory, ok := s.agents[model]
+	s.mu.RUnlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "invalid_request_error", fmt.Sprintf("model '%s' not found", model))
+		return
+	}
+
+	ws := websocket.Server{
+		Handshake: checkSameOrigin,
+		Handler: func(conn *websocket.Conn) {
+			newSession(conn, model, factory()).serve()
+		},
+	}
+	ws.ServeHTTP(w, r)
+}
+
+// checkSameOrigin rejects browser connections from other sites. Clients that send no Origin
+// header, such as non-browser clients, are accepted.
+func checkSameOrigin(config *websocket.Config, r *http.Request) error {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(u.Host, r.Host) {
+		return fmt.Errorf("origin %s not allowed", origin)
+	}
+	return nil
+}
+
+// session is a chat over a single WebSocket connection.
+type session struct {
+	conn  *websocket.Conn
+	model string
+	agent *agent.Agent
+
+	writeMu sync.Mutex // Tool events may be reported from several goroutines.
+
+	mu     sync.Mutex
+	cancel context.CancelFunc // Cancels the message being answered.
+}
+
+func newSession(conn *websocket.Conn, model string, a *agent.Agent) *session {
+	return &session{conn: conn, model: model, agent: a}
+}
+
+// serve reads client frames and answers their messages in order until the connection closes.
+func (s *session) serve() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	queue := make(chan ClientFrame, 16)
+	go func() {
+		defer close(queue)
+		for {
+			var data string
+			if err := websocket.Message.Receive(s.conn, &data); err != nil {
+				// The connection is gone; stop the message being answered.
+				cancel()
+				return
+			}
+			var frame ClientFrame
+			if err := json.Unmarshal([]byte(data), &frame); err != nil {
+				s.send(Frame{Type: FrameError, Error: fmt.Sprintf("invalid frame: %v", err)})
+				continue
+			}
+			if frame.Type == FrameCancel {
+				s.cancelCurrent()
+				continue
+			}
+			select {
+			case queue <- frame:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	if err := s.send(Frame{Type: FrameSession, Model: s.model}); err != nil {
+		return
+	}
+	for frame := range queue {
+		switch frame.Type {
+		case FrameMessage:
+			s.answer(ctx, frame)
+		case FrameReset:
+			s.agent.Reset()
+		default:
+			s.send(Frame{Type: FrameError, ID: frame.ID, Error: fmt.Sprintf("unknown frame type '%s'", frame.Type)})
+		}
+	}
+}
+
+// answer streams the agent's response to a message frame.
+func (s *session) answer(ctx context.Context, frame ClientFrame) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	s.mu.Lock()
+	s.cancel = cancel
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.cancel = nil
+		s.mu.Unlock()
+	}()
+
+	usage := llm.NewUsageRecorder()
+	ctx = llm.ContextWithUsageRecorder(ctx, usage)
+	ctx = agent.ContextWithToolEvents(ctx, func(e agent.ToolEvent) {
+		call := e.Call
+		s.send(Frame{Type: e.Type, ID: frame.ID, Tool: &call, DurationMS: e.Duration.Milliseconds()})
+	})
+
+	var streamed strings.Builder
+	output, err := s.agent.SendStream(ctx, frame.Content, func(chunk string) error {
+		streamed.WriteString(chunk)
+		return s.send(Frame{Type: FrameDelta, ID: frame.ID, Content: chunk})
+	})
+	if err != nil {
+		s.send(Frame{Type: FrameError, ID: frame.ID, Error: err.Error()})
+		return
+	}
+
+	if rest, replace := finalDelta(output, streamed.String()); replace {
+		if err := s.send(Frame{Type: FrameReplace, ID: frame.ID, Content: rest}); err != nil {
+			return
+		}
+	} else if rest != "" {
+		if err := s.send(Frame{Type: FrameDelta, ID: frame.ID, Content: rest}); err != nil {
+			return
+		}
+	}
+	u := toUsage(usage.Usage())
+	if err := s.send(Frame{Type: FrameUsage, ID: frame.ID, Usage: &u}); err != nil {
+		return
+	}
+	s.send(Frame{Type: FrameDone, ID: frame.ID, Content: output})
+}
+
+// cancelCurrent stops the message being answered, if any.
+func (s *session) cancelCurrent() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancel != nil {
+		s.cancel()
+	}
+}
+
+// send writes a frame to the client.
+func (s *session) send(frame Frame) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return websocket.JSON.Send(s.conn, frame)
+}
@@ -0,0 +1,105 @@
+package tokenizer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// GeminiAPICounter counts tokens with the Gemini countTokens endpoint.
+type GeminiAPICounter struct {
+	APIKey  string
+	BaseURL string // Defaults to https://generativelanguage.googleapis.com/v1
+	Client  *http.Client
+}
+
+// Count implements Counter.
+func (g GeminiAPICounter) Count(ctx context.Context, model, text string) (int, error) {
+	baseURL := g.BaseURL
+	if baseURL == "" {
+		baseURL = "https://generativelanguage.googleapis.com/v1"
+	}
+	payload := map[string]interface{}{
+		"contents": []map[string]interface{}{
+			{"parts": []map[string]string{{"text": text}}},
+		},
+	}
+	url := fmt.Sprintf("%s/models/%s:countTokens?key=%s", baseURL, model, g.APIKey)
+
+	var out struct {
+		TotalTokens int `json:"totalTokens"`
+	}
+	if err := postJSON(ctx, g.Client, url, nil, payload, &out); err != nil {
+		return 0, fmt.Errorf("gemini countTokens: %w", err)
+	}
+	return out.TotalTokens, nil
+}
+
+// AnthropicAPICounter counts tokens with the Anthropic messages count_tokens endpoint.
+type AnthropicAPICounter struct {
+	APIKey  string
+	BaseURL string // Defaults to https://api.anthropic.com/v1
+	Client  *http.Client
+}
+
+// Count implements Counter.
+func (a AnthropicAPICounter) Count(ctx context.Context, model, text string) (int, error) {
+	baseURL := a.BaseURL
+	if baseURL == "" {
+		baseURL = "https://api.anthropic.com/v1"
+	}
+	payload := map[string]interface{}{
+		"model": model,
+		"messages": []map[string]string{
+			{"role": "user", "content": text},
+		},
+	}
+	headers := map[string]string{
+		"X-API-Key":         a.APIKey,
+		"Anthropic-Version": "2023-06-01",
+	}
+
+	var out struct {
+		InputTokens int `json:"input_tokens"`
+	}
+	if err := postJSON(ctx, a.Client, baseURL+"/messages/count_tokens", headers, payload, &out); err != nil {
+		return 0, fmt.Errorf("anthropic count_tokens: %w", err)
+	}
+	return out.InputTokens, nil
+}
+
+// postJSON sends a JSON POST request and decodes the JSON response into out.
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, payload, out interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(string(body)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(respBody))
+	}
+	return json.Unmarshal(respBody, out)
+}
@@ -0,0 +1,180 @@
+// Package tokenizer provides token counting for the LLM providers supported by gatot-kaca.
+// OpenAI models are counted with a tiktoken-compatible BPE encoder; Anthropic and Gemini
+// models use character-based heuristics by default, and can be switched to the providers'
+// token counting APIs with Register.
+package tokenizer
+
+import (
+	"context"
+	"math"
+	"strings"
+	"sync"
+
+	tiktoken "github.com/pkoukk/tiktoken-go"
+	tiktokenloader "github.com/pkoukk/tiktoken-go-loader"
+
+	"github.com/zakirkun/gatot-kaca/llm"
+)
+
+// Counter counts the number of tokens in a piece of text for a specific model family.
+type Counter interface {
+	Count(ctx context.Context, model, text string) (int, error)
+}
+
+// CounterFunc adapts a plain function to the Counter interface.
+type CounterFunc func(ctx context.Context, model, text string) (int, error)
+
+// Count calls the wrapped function.
+func (f CounterFunc) Count(ctx context.Context, model, text string) (int, error) {
+	return f(ctx, model, text)
+}
+
+var (
+	mu       sync.RWMutex
+	counters = map[llm.ModelProvider]Counter{}
+	prefixes = map[string]Counter{}
+
+	encodings sync.Map // model name -> *tiktoken.Tiktoken
+)
+
+func init() {
+	// Use the embedded BPE ranks so counting never needs network access.
+	tiktoken.SetBpeLoader(tiktokenloader.NewOfflineLoader())
+
+	counters[llm.OpenAI] = CounterFunc(countOpenAI)
+	counters[llm.Anthropic] = HeuristicCounter{CharsPerToken: 3.5}
+	counters[llm.Gemini] = HeuristicCounter{CharsPerToken: 4}
+
+	// Let the context window check in llm.Client count tokens accurately.
+	llm.DefaultTokenCounter = CountTokens
+}
+
+// Register installs a counter for every model whose name starts with prefix.
+// Registered prefixes take precedence over the provider defaults; the longest
+// matching prefix wins.
+func Register(prefix string, c Counter) {
+	mu.Lock()
+	defer mu.Unlock()
+	prefixes[prefix] = c
+}
+
+// RegisterProvider replaces the default counter used for a provider.
+func RegisterProvider(provider llm.ModelProvider, c Counter) {
+	mu.Lock()
+	defer mu.Unlock()
+	counters[provider] = c
+}
+
+// CountTokens returns the number of tokens text occupies for the given model.
+// It never fails: if the model-specific counter returns an error, a heuristic
+// estimate is returned instead.
+func CountTokens(model, text string) int {
+	n, err := CountTokensContext(context.Background(), model, text)
+	if err != nil {
+		return Estimate(text)
+	}
+	return n
+}
+
+// CountTokensContext returns the number of tokens text occupies for the given model,
+// propagating errors from API-based counters.
+func CountTokensContext(ctx context.Context, model, text string) (int, error) {
+	if text == "" {
+		return 0, nil
+	}
+	return counterFor(model).Count(ctx, model, text)
+}
+
+// ProviderForModel guesses the provider of a model from its name.
+// Unknown names are reported as OpenAI, whose tokenizer is the most common baseline.
+func ProviderForModel(model string) llm.ModelProvider {
+	name := strings.ToLower(model)
+	switch {
+	case strings.HasPrefix(name, "claude"):
+		return llm.Anthropic
+	case strings.HasPrefix(name, "gemini"), strings.HasPrefix(name, "models/gemini"):
+		return llm.Gemini
+	default:
+		return llm.OpenAI
+	}
+}
+
+// counterFor resolves the counter for a model name.
+func counterFor(model string) Counter {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	var best Counter
+	bestLen := -1
+	for prefix, c := range prefixes {
+		if strings.HasPrefix(model, prefix) && len(prefix) > bestLen {
+			best, bestLen = c, len(prefix)
+		}
+	}
+	if best != nil {
+		return best
+	}
+	if c, ok := counters[ProviderForModel(model)]; ok {
+		return c
+	}
+	return HeuristicCounter{}
+}
+
+// countOpenAI counts tokens with the tiktoken encoding used by the model,
+// falling back to cl100k_base for unknown model names.
+func countOpenAI(ctx context.Context, model, text string) (int, error) {
+	enc, err := encodingFor(model)
+	if err != nil {
+		return 0, err
+	}
+	return len(enc.Encode(text, nil, nil)), nil
+}
+
+func encodingFor(model string) (*tiktoken.Tiktoken, error) {
+	if enc, ok := encodings.Load(model); ok {
+		return enc.(*tiktoken.Tiktoken), nil
+	}
+	enc, err := tiktoken.EncodingForModel(model)
+	if err != nil {
+		enc, err = tiktoken.GetEncoding(tiktoken.MODEL_CL100K_BASE)
+		if err != nil {
+			return nil, err
+		}
+	}
+	encodings.Store(model, enc)
+	return enc, nil
+}
+
+// HeuristicCounter estimates tokens from the character count of the text.
+// CharsPerToken defaults to 4, the usual rule of thumb for English text.
+type HeuristicCounter struct {
+	CharsPerToken float64
+}
+
+// Count returns the estimated number of tokens.
+func (h HeuristicCounter) Count(ctx context.Context, model, text string) (int, error) {
+	cpt := h.CharsPerToken
+	if cpt <= 0 {
+		cpt = 4
+	}
+	return estimate(text, cpt), nil
+}
+
+// Estimate returns a provider-independent token estimate for text.
+func Estimate(text string) int {
+	return estimate(text, 4)
+}
+
+func estimate(text string, charsPerToken float64) int {
+	if text == "" {
+		return 0
+	}
+	// Count runes rather than bytes so non-Latin scripts are not overestimated,
+	// and never report less than one token per word.
+	byChars := int(math.Ceil(float64(len([]rune(text))) / charsPerToken))
+	words := len(strings.Fields(text))
+	if words > byChars {
+		return words
+	}
+	return byChars
+}
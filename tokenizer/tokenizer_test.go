@@ -0,0 +1,39 @@
+package tokenizer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/zakirkun/gatot-kaca/llm"
+)
+
+func TestCountTokensOpenAI(t *testing.T) {
+	if got := CountTokens("gpt-4", "hello world"); got != 2 {
+		t.Errorf("expected 2 tokens for 'hello world', got %d", got)
+	}
+	if got := CountTokens("gpt-4", ""); got != 0 {
+		t.Errorf("expected 0 tokens for empty text, got %d", got)
+	}
+}
+
+func TestProviderForModel(t *testing.T) {
+	cases := map[string]llm.ModelProvider{
+		"gpt-4o":                 llm.OpenAI,
+		"claude-3-opus-20240229": llm.Anthropic,
+		"gemini-pro":             llm.Gemini,
+	}
+	for model, want := range cases {
+		if got := ProviderForModel(model); got != want {
+			t.Errorf("ProviderForModel(%q) = %s, want %s", model, got, want)
+		}
+	}
+}
+
+func TestRegisterPrefix(t *testing.T) {
+	Register("custom-", CounterFunc(func(ctx context.Context, model, text string) (int, error) {
+		return 42, nil
+	}))
+	if got := CountTokens("custom-model", "anything"); got != 42 {
+		t.Errorf("expected registered counter to be used, got %d", got)
+	}
+}
@@ -0,0 +1,735 @@
+package trigger
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/zakirkun/gatot-kaca/logging"
+)
+
+// Kafka API keys and the versions used.
+const (
+	kafkaFetch       = 1 // Version 4, the first with record batches and isolation levels.
+	kafkaListOffsets = 2 // Version 1.
+	kafkaMetadata    = 3 // Version 1.
+)
+
+var (
+	errKafkaUnsupported = errors.New("kafka: unsupported")
+	errKafkaMalformed   = errors.New("kafka: malformed response")
+	castagnoli          = crc32.MakeTable(crc32.Castagnoli)
+)
+
+// KafkaSource consumes a Kafka topic using the Kafka wire protocol over plain TCP.
+//
+// It reads the partitions of the topic directly from their leaders rather than through a
+// consumer group, keeping the offsets in memory: a new source starts at the newest messages, or
+// with FromOldest at the oldest retained ones, and every source receives every message. The
+// partitions are polled in turn, so with several brokers a quiet one can delay the messages of
+// the others by up to MaxWait. When a broker fails or a partition moves, the source reconnects
+// and continues at the next offset. Messages of aborted transactions are delivered as well.
+//
+// Only uncompressed record batches (message format v2, Kafka 0.11 and later) are supported;
+// TLS, SASL and compression are not. For those, or to commit offsets with a consumer group,
+// wrap the consumer of a client library in a SourceFunc.
+type KafkaSource struct {
+	Brokers []string // Bootstrap addresses, e.g. "localhost:9092".
+	Topic   string
+	// Partitions lists the partitions to consume; all partitions of the topic when empty.
+	Partitions []int32
+	// FromOldest starts at the oldest retained message of each partition instead of the newest.
+	FromOldest bool
+	ClientID   string // Defaults to "gatot-kaca".
+	// MaxWait is how long a fetch waits for new messages; defaults to 500 milliseconds.
+	MaxWait time.Duration
+	// MaxBytes limits the data fetched from a partition at a time; defaults to 1 MiB.
+	MaxBytes int32
+	// ReconnectWait is the delay before retrying after a failure; defaults to 2 seconds.
+	ReconnectWait time.Duration
+	// MaxReconnects is the number of failed attempts in a row after which Receive fails;
+	// defaults to 10.
+	MaxReconnects int
+
+	mu       sync.Mutex // Serializes Receive and Close.
+	conns    map[string]*kafkaConn
+	brokers  map[int32]string // Address by node ID.
+	leaders  map[int32]int32  // Node ID of the leader by partition; nil until metadata is loaded.
+	offsets  map[int32]int64  // Next offset to fetch by partition.
+	pending  []Message
+	failures int
+	closed   bool
+}
+
+// NewKafkaSource connects to the brokers and looks up the partitions of the topic.
+func NewKafkaSource(ctx context.Context, brokers []string, topic string) (*KafkaSource, error) {
+	s := &KafkaSource{Brokers: brokers, Topic: topic}
+	if err := s.Connect(ctx); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Connect connects to the brokers, looks up the partitions of the topic and their starting
+// offsets. Set the fields before calling it. Receive connects on first use if Connect was not
+// called.
+func (s *KafkaSource) Connect(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load(ctx)
+}
+
+// Receive implements Source. Failures that may be temporary, such as a lost connection or a
+// leader election, are retried; they are logged to the logger of ctx.
+func (s *KafkaSource) Receive(ctx context.Context) (Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for len(s.pending) == 0 {
+		if s.closed {
+			return Message{}, fmt.Errorf("kafka: %w", net.ErrClosed)
+		}
+		if err := ctx.Err(); err != nil {
+			return Message{}, err
+		}
+		err := s.poll(ctx)
+		if err == nil {
+			s.failures = 0
+			continue
+		}
+		if ctx.Err() != nil {
+			return Message{}, ctx.Err()
+		}
+		s.failures++
+		if !kafkaRetriable(err) || s.failures > s.maxReconnects() {
+			return Message{}, err
+		}
+		logging.FromContext(ctx).Warn("kafka fetch failed, reconnecting", logging.KeyComponent, "trigger",
+			"topic", s.Topic, logging.KeyError, err)
+		s.disconnect()
+		select {
+		case <-time.After(s.reconnectWait()):
+		case <-ctx.Done():
+			return Message{}, ctx.Err()
+		}
+	}
+	msg := s.pending[0]
+	s.pending = s.pending[1:]
+	return msg, nil
+}
+
+// Close closes the connections to the brokers, after a fetch in progress. It is safe to call
+// more than once.
+func (s *KafkaSource) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	s.disconnect()
+	return nil
+}
+
+func (s *KafkaSource) maxWait() time.Duration {
+	if s.MaxWait <= 0 {
+		return 500 * time.Millisecond
+	}
+	return s.MaxWait
+}
+
+func (s *KafkaSource) maxBytes() int32 {
+	if s.MaxBytes <= 0 {
+		return 1 << 20
+	}
+	return s.MaxBytes
+}
+
+func (s *KafkaSource) reconnectWait() time.Duration {
+	if s.ReconnectWait <= 0 {
+		return 2 * time.Second
+	}
+	return s.ReconnectWait
+}
+
+func (s *KafkaSource) maxReconnects() int {
+	if s.MaxReconnects <= 0 {
+		return 10
+	}
+	return s.MaxReconnects
+}
+
+// disconnect closes the connections and forgets the partition leaders, keeping the offsets.
+func (s *KafkaSource) disconnect() {
+	for _, c := range s.conns {
+		c.conn.Close()
+	}
+	s.conns = nil
+	s.leaders = nil
+}
+
+// load looks up the brokers and partition leaders, and the starting offset of partitions that
+// have none yet.
+func (s *KafkaSource) load(ctx context.Context) error {
+	if s.closed {
+		return fmt.Errorf("kafka: %w", net.ErrClosed)
+	}
+	if len(s.Brokers) == 0 || s.Topic == "" {
+		return errors.New("kafka: brokers and topic are required")
+	}
+	var err error
+	for _, addr := range s.Brokers {
+		if err = s.loadMetadata(ctx, addr); err == nil {
+			break
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return s.loadOffsets(ctx)
+}
+
+// loadMetadata requests the metadata of the topic from the broker at addr.
+func (s *KafkaSource) loadMetadata(ctx context.Context, addr string) error {
+	var req kafkaEncoder
+	req.int32(1)
+	req.string(s.Topic)
+	resp, err := s.request(ctx, addr, kafkaMetadata, 1, req.b)
+	if err != nil {
+		return err
+	}
+
+	d := kafkaDecoder{b: resp}
+	brokers := make(map[int32]string)
+	for n := d.arrayLen(); n > 0; n-- {
+		id := d.int32()
+		host := d.string()
+		port := d.int32()
+		d.string() // Rack.
+		brokers[id] = net.JoinHostPort(host, strconv.Itoa(int(port)))
+	}
+	d.int32() // Controller ID.
+	leaders := make(map[int32]int32)
+	found := false
+	for n := d.arrayLen(); n > 0; n-- {
+		topicErr := d.int16()
+		name := d.string()
+		d.int8() // Internal.
+		if name == s.Topic {
+			found = true
+			if topicErr != 0 {
+				return kafkaError(topicErr)
+			}
+		}
+		for p := d.arrayLen(); p > 0; p-- {
+			d.int16() // Partition error; leader errors are reported by fetches.
+			partition := d.int32()
+			leader := d.int32()
+			d.int32s() // Replicas.
+			d.int32s() // In-sync replicas.
+			if name == s.Topic {
+				leaders[partition] = leader
+			}
+		}
+	}
+	if d.err != nil {
+		return d.err
+	}
+	if !found {
+		return kafkaError(3) // Unknown topic or partition.
+	}
+
+	if len(s.Partitions) > 0 {
+		selected := make(map[int32]int32, len(s.Partitions))
+		for _, p := range s.Partitions {
+			leader, ok := leaders[p]
+			if !ok {
+				return fmt.Errorf("kafka: topic %s has no partition %d", s.Topic, p)
+			}
+			selected[p] = leader
+		}
+		leaders = selected
+	}
+	for p, leader := range leaders {
+		if _, ok := brokers[leader]; !ok {
+			return fmt.Errorf("kafka: partition %d: %w", p, kafkaError(5)) // Leader not available.
+		}
+	}
+	s.brokers, s.leaders = brokers, leaders
+	return nil
+}
+
+// loadOffsets looks up the starting offset of the partitions that have none yet.
+func (s *KafkaSource) loadOffsets(ctx context.Context) error {
+	timestamp := int64(-1) // Newest.
+	if s.FromOldest {
+		timestamp = -2
+	}
+	for node, partitions := range s.partitionsByLeader() {
+		var missing []int32
+		for _, p := range partitions {
+			if _, ok := s.offsets[p]; !ok {
+				missing = append(missing, p)
+			}
+		}
+		if len(missing) == 0 {
+			continue
+		}
+
+		var req kafkaEncoder
+		req.int32(-1) // Replica ID of a consumer.
+		req.int32(1)
+		req.string(s.Topic)
+		req.int32(int32(len(missing)))
+		for _, p := range missing {
+			req.int32(p)
+			req.int64(timestamp)
+		}
+		resp, err := s.request(ctx, s.brokers[node], kafkaListOffsets, 1, req.b)
+		if err != nil {
+			return err
+		}
+		d := kafkaDecoder{b: resp}
+		for n := d.arrayLen(); n > 0; n-- {
+			name := d.string()
+			for p := d.arrayLen(); p > 0; p-- {
+				partition := d.int32()
+				code := d.int16()
+				d.int64() // Timestamp.
+				offset := d.int64()
+				if d.err != nil || name != s.Topic {
+					continue
+				}
+				if code != 0 {
+					return fmt.Errorf("kafka: partition %d: %w", partition, kafkaError(code))
+				}
+				if s.offsets == nil {
+					s.offsets = make(map[int32]int64)
+				}
+				s.offsets[partition] = offset
+			}
+		}
+		if d.err != nil {
+			return d.err
+		}
+		for _, p := range missing {
+			if _, ok := s.offsets[p]; !ok {
+				return fmt.Errorf("kafka: no offset for partition %d", p)
+			}
+		}
+	}
+	return nil
+}
+
+// partitionsByLeader groups the partitions by the node ID of their leader.
+func (s *KafkaSource) partitionsByLeader() map[int32][]int32 {
+	byLeader := make(map[int32][]int32)
+	for p, node := range s.leaders {
+		byLeader[node] = append(byLeader[node], p)
+	}
+	for _, partitions := range byLeader {
+		sort.Slice(partitions, func(i, j int) bool { return partitions[i] < partitions[j] })
+	}
+	return byLeader
+}
+
+// poll fetches new messages from every leader into pending. Once messages are pending, the
+// remaining leaders are asked not to wait for more.
+func (s *KafkaSource) poll(ctx context.Context) error {
+	if s.leaders == nil {
+		if err := s.load(ctx); err != nil {
+			return err
+		}
+	}
+	byLeader := s.partitionsByLeader()
+	nodes := make([]int32, 0, len(byLeader))
+	for node := range byLeader {
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i] < nodes[j] })
+	for _, node := range nodes {
+		if err := s.fetch(ctx, node, byLeader[node]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fetch fetches new messages of partitions from their leader node.
+func (s *KafkaSource) fetch(ctx context.Context, node int32, partitions []int32) error {
+	wait := s.maxWait()
+	if len(s.pending) > 0 {
+		wait = 0
+	}
+	var req kafkaEncoder
+	req.int32(-1) // Replica ID of a consumer.
+	req.int32(int32(wait / time.Millisecond))
+	req.int32(1) // Minimum bytes.
+	req.int32(s.maxBytes() * int32(len(partitions)))
+	req.int8(0) // Read uncommitted.
+	req.int32(1)
+	req.string(s.Topic)
+	req.int32(int32(len(partitions)))
+	for _, p := range partitions {
+		req.int32(p)
+		req.int64(s.offsets[p])
+		req.int32(s.maxBytes())
+	}
+	resp, err := s.request(ctx, s.brokers[node], kafkaFetch, 4, req.b)
+	if err != nil {
+		return err
+	}
+
+	d := kafkaDecoder{b: resp}
+	d.int32() // Throttle time.
+	for n := d.arrayLen(); n > 0; n-- {
+		name := d.string()
+		for p := d.arrayLen(); p > 0; p-- {
+			partition := d.int32()
+			code := d.int16()
+			d.int64() // High watermark.
+			d.int64() // Last stable offset.
+			for a := d.arrayLen(); a > 0; a-- {
+				d.int64() // Producer ID.
+				d.int64() // First offset.
+			}
+			records := d.bytes()
+			if d.err != nil {
+				return d.err
+			}
+			offset, ok := s.offsets[partition]
+			if name != s.Topic || !ok {
+				continue
+			}
+			switch code {
+			case 0:
+			case 1: // Offset out of range: the messages were deleted, start again.
+				delete(s.offsets, partition)
+				if err := s.loadOffsets(ctx); err != nil {
+					return err
+				}
+				continue
+			default:
+				return fmt.Errorf("kafka: partition %d: %w", partition, kafkaError(code))
+			}
+			msgs, next, err := parseRecordBatches(records, s.Topic, offset)
+			if err != nil {
+				return fmt.Errorf("kafka: partition %d: %w", partition, err)
+			}
+			s.pending = append(s.pending, msgs...)
+			s.offsets[partition] = next
+		}
+	}
+	return d.err
+}
+
+// parseRecordBatches returns the messages at or after offset in the record batches of data,
+// and the offset following the last complete batch. A partial batch at the end is ignored.
+func parseRecordBatches(data []byte, topic string, offset int64) ([]Message, int64, error) {
+	var msgs []Message
+	next := offset
+	for len(data) >= 12 {
+		base := int64(binary.BigEndian.Uint64(data))
+		size := int32(binary.BigEndian.Uint32(data[8:]))
+		if size < 0 || int64(size) > int64(len(data)-12) {
+			break
+		}
+		d := kafkaDecoder{b: data[12 : 12+size]}
+		data = data[12+size:]
+
+		d.int32() // Partition leader epoch.
+		if magic := d.int8(); magic != 2 {
+			return nil, 0, fmt.Errorf("%w message format v%d", errKafkaUnsupported, magic)
+		}
+		crc := uint32(d.int32())
+		if d.err == nil && crc32.Checksum(d.b, castagnoli) != crc {
+			return nil, 0, fmt.Errorf("%w: record batch checksum mismatch", errKafkaMalformed)
+		}
+		attributes := d.int16()
+		lastDelta := d.int32()
+		d.int64() // First timestamp.
+		d.int64() // Max timestamp.
+		d.int64() // Producer ID.
+		d.int16() // Producer epoch.
+		d.int32() // Base sequence.
+		count := d.int32()
+		if d.err != nil {
+			return nil, 0, d.err
+		}
+		if end := base + int64(lastDelta) + 1; end > next {
+			next = end
+		}
+		if attributes&0x20 != 0 {
+			continue // Control batch, e.g. a transaction marker.
+		}
+		if attributes&0x07 != 0 {
+			return nil, 0, fmt.Errorf("%w compression codec %d", errKafkaUnsupported, attributes&0x07)
+		}
+		for i := int32(0); i < count; i++ {
+			r := kafkaDecoder{b: d.take(int(d.varint()))}
+			r.int8()   // Attributes.
+			r.varint() // Timestamp delta.
+			delta := r.varint()
+			key := r.varBytes()
+			value := r.varBytes()
+			msg := Message{Subject: topic, Key: string(key), Data: value}
+			for h := r.varint(); h > 0; h-- {
+				k := r.varBytes()
+				v := r.varBytes()
+				if msg.Headers == nil {
+					msg.Headers = make(map[string]string)
+				}
+				if _, ok := msg.Headers[string(k)]; !ok {
+					msg.Headers[string(k)] = string(v)
+				}
+			}
+			if d.err != nil || r.err != nil {
+				return nil, 0, errKafkaMalformed
+			}
+			if base+delta >= offset {
+				msgs = append(msgs, msg)
+			}
+		}
+	}
+	return msgs, next, nil
+}
+
+// kafkaConn is a connection to a broker.
+type kafkaConn struct {
+	conn        net.Conn
+	r           *bufio.Reader
+	correlation int32
+}
+
+// request sends a request to the broker at addr and returns the response body.
+func (s *KafkaSource) request(ctx context.Context, addr string, apiKey, version int16, body []byte) ([]byte, error) {
+	c := s.conns[addr]
+	if c == nil {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return nil, fmt.Errorf("kafka: %w", err)
+		}
+		c = &kafkaConn{conn: conn, r: bufio.NewReader(conn)}
+		if s.conns == nil {
+			s.conns = make(map[string]*kafkaConn)
+		}
+		s.conns[addr] = c
+	}
+	resp, err := c.roundTrip(ctx, s.clientID(), apiKey, version, body, s.maxWait()+10*time.Second)
+	if err != nil {
+		c.conn.Close()
+		delete(s.conns, addr)
+		return nil, fmt.Errorf("kafka: %w", err)
+	}
+	return resp, nil
+}
+
+func (s *KafkaSource) clientID() string {
+	if s.ClientID == "" {
+		return "gatot-kaca"
+	}
+	return s.ClientID
+}
+
+// roundTrip writes a request and reads its response, failing after timeout or when ctx is done.
+func (c *kafkaConn) roundTrip(ctx context.Context, clientID string, apiKey, version int16, body []byte, timeout time.Duration) ([]byte, error) {
+	c.correlation++
+	var req kafkaEncoder
+	req.int32(0) // Size, set below.
+	req.int16(apiKey)
+	req.int16(version)
+	req.int32(c.correlation)
+	req.string(clientID)
+	req.b = append(req.b, body...)
+	binary.BigEndian.PutUint32(req.b, uint32(len(req.b)-4))
+
+	c.conn.SetDeadline(time.Now().Add(timeout))
+	stop := context.AfterFunc(ctx, func() { c.conn.SetDeadline(time.Now()) })
+	defer stop()
+	if _, err := c.conn.Write(req.b); err != nil {
+		return nil, err
+	}
+	var header [8]byte
+	if _, err := io.ReadFull(c.r, header[:]); err != nil {
+		return nil, err
+	}
+	size := int32(binary.BigEndian.Uint32(header[:]))
+	if size < 4 || size > 256<<20 {
+		return nil, errKafkaMalformed
+	}
+	if id := int32(binary.BigEndian.Uint32(header[4:])); id != c.correlation {
+		return nil, fmt.Errorf("%w: correlation ID %d, want %d", errKafkaMalformed, id, c.correlation)
+	}
+	resp := make([]byte, size-4)
+	if _, err := io.ReadFull(c.r, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// kafkaError is an error code returned by a broker.
+type kafkaError int16
+
+var kafkaErrorNames = map[kafkaError]string{
+	1: "offset out of range", 3: "unknown topic or partition", 5: "leader not available",
+	6: "not leader for partition", 7: "request timed out", 13: "network exception",
+	29: "topic authorization failed", 58: "SASL authentication failed",
+}
+
+func (e kafkaError) Error() string {
+	if name, ok := kafkaErrorNames[e]; ok {
+		return "kafka: " + name
+	}
+	return fmt.Sprintf("kafka: broker error %d", int16(e))
+}
+
+// kafkaRetriable reports whether err may go away by reconnecting, such as network errors and
+// leader elections.
+func kafkaRetriable(err error) bool {
+	var code kafkaError
+	if errors.As(err, &code) {
+		switch code {
+		case 3, 5, 6, 7, 13, 19, 56, 74, 75:
+			return true
+		}
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, errKafkaMalformed)
+}
+
+// kafkaEncoder builds a request body in the Kafka protocol encoding.
+type kafkaEncoder struct {
+	b []byte
+}
+
+func (e *kafkaEncoder) int8(v int8)   { e.b = append(e.b, byte(v)) }
+func (e *kafkaEncoder) int16(v int16) { e.b = binary.BigEndian.AppendUint16(e.b, uint16(v)) }
+func (e *kafkaEncoder) int32(v int32) { e.b = binary.BigEndian.AppendUint32(e.b, uint32(v)) }
+func (e *kafkaEncoder) int64(v int64) { e.b = binary.BigEndian.AppendUint64(e.b, uint64(v)) }
+
+func (e *kafkaEncoder) string(v string) {
+	e.int16(int16(len(v)))
+	e.b = append(e.b, v...)
+}
+
+// kafkaDecoder reads a response body in the Kafka protocol encoding. After the first error,
+// reads return zero values and err is set.
+type kafkaDecoder struct {
+	b   []byte
+	err error
+}
+
+func (d *kafkaDecoder) take(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(d.b) {
+		d.err = errKafkaMalformed
+		return nil
+	}
+	v := d.b[:n]
+	d.b = d.b[n:]
+	return v
+}
+
+func (d *kafkaDecoder) int8() int8 {
+	if b := d.take(1); b != nil {
+		return int8(b[0])
+	}
+	return 0
+}
+
+func (d *kafkaDecoder) int16() int16 {
+	if b := d.take(2); b != nil {
+		return int16(binary.BigEndian.Uint16(b))
+	}
+	return 0
+}
+
+func (d *kafkaDecoder) int32() int32 {
+	if b := d.take(4); b != nil {
+		return int32(binary.BigEndian.Uint32(b))
+	}
+	return 0
+}
+
+func (d *kafkaDecoder) int64() int64 {
+	if b := d.take(8); b != nil {
+		return int64(binary.BigEndian.Uint64(b))
+	}
+	return 0
+}
+
+// string reads a nullable string, returning "" for null.
+func (d *kafkaDecoder) string() string {
+	n := d.int16()
+	if n < 0 {
+		return ""
+	}
+	return string(d.take(int(n)))
+}
+
+// bytes reads nullable bytes with an int32 length.
+func (d *kafkaDecoder) bytes() []byte {
+	n := d.int32()
+	if n < 0 {
+		return nil
+	}
+	return d.take(int(n))
+}
+
+// arrayLen reads the length of an array, returning 0 for a null array.
+func (d *kafkaDecoder) arrayLen() int32 {
+	n := d.int32()
+	if n < 0 || int64(n) > int64(len(d.b)) {
+		if n > 0 {
+			d.err = errKafkaMalformed
+		}
+		return 0
+	}
+	return n
+}
+
+func (d *kafkaDecoder) int32s() {
+	for n := d.arrayLen(); n > 0; n-- {
+		d.int32()
+	}
+}
+
+// varint reads a zigzag-encoded variable-length integer, as used in record batches.
+func (d *kafkaDecoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(d.b)
+	if n <= 0 {
+		d.err = errKafkaMalformed
+		return 0
+	}
+	d.b = d.b[n:]
+	return v
+}
+
+// varBytes reads bytes with a varint length, returning nil for a negative length.
+func (d *kafkaDecoder) varBytes() []byte {
+	n := d.varint()
+	if n < 0 {
+		return nil
+	}
+	if n > int64(len(d.b)) {
+		d.err = errKafkaMalformed
+		return nil
+	}
+	return d.take(int(n))
+}
@@ -0,0 +1,379 @@
+package trigger_test
+
+import (
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zakirkun/gatot-kaca/logging"
+	"github.com/zakirkun/gatot-kaca/trigger"
+)
+
+type fakeRecord struct {
+	key, value string
+	headers    map[string]string
+}
+
+// fakeKafka is a single Kafka broker serving the two partitions of the "events" topic through
+// Metadata v1, ListOffsets v1 and Fetch v4.
+type fakeKafka struct {
+	ln net.Listener
+
+	mu         sync.Mutex
+	logs       map[int32][]fakeRecord
+	conns      []net.Conn
+	compressed bool // Mark fetched batches as gzip-compressed.
+}
+
+func newFakeKafka(t *testing.T) *fakeKafka {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeKafka{ln: ln, logs: map[int32][]fakeRecord{0: nil, 1: nil}}
+	go f.serve()
+	t.Cleanup(func() {
+		ln.Close()
+		f.dropConns()
+	})
+	return f
+}
+
+func (f *fakeKafka) produce(partition int32, r fakeRecord) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.logs[partition] = append(f.logs[partition], r)
+}
+
+// dropConns closes the open client connections, as a restarting broker would.
+func (f *fakeKafka) dropConns() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, c := range f.conns {
+		c.Close()
+	}
+	f.conns = nil
+}
+
+func (f *fakeKafka) serve() {
+	for {
+		conn, err := f.ln.Accept()
+		if err != nil {
+			return
+		}
+		f.mu.Lock()
+		f.conns = append(f.conns, conn)
+		f.mu.Unlock()
+		go f.handle(conn)
+	}
+}
+
+func (f *fakeKafka) handle(conn net.Conn) {
+	defer conn.Close()
+	for {
+		var size [4]byte
+		if _, err := io.ReadFull(conn, size[:]); err != nil {
+			return
+		}
+		req := make([]byte, binary.BigEndian.Uint32(size[:]))
+		if _, err := io.ReadFull(conn, req); err != nil {
+			return
+		}
+		r := &reader{b: req}
+		apiKey, _ := r.int16(), r.int16()
+		correlation := r.int32()
+		r.string() // Client ID.
+
+		var body []byte
+		switch apiKey {
+		case 3:
+			body = f.metadata(r)
+		case 2:
+			body = f.listOffsets(r)
+		case 1:
+			body = f.fetch(r)
+		default:
+			return
+		}
+		resp := binary.BigEndian.AppendUint32(nil, uint32(4+len(body)))
+		resp = binary.BigEndian.AppendUint32(resp, uint32(correlation))
+		if _, err := conn.Write(append(resp, body...)); err != nil {
+			return
+		}
+	}
+}
+
+func (f *fakeKafka) metadata(r *reader) []byte {
+	r.int32()
+	topic := r.string()
+	host, port, _ := net.SplitHostPort(f.ln.Addr().String())
+	var w writer
+	w.int32(1)
+	w.int32(1) // Node ID.
+	w.string(host)
+	var p int32
+	for _, c := range port {
+		p = p*10 + int32(c-'0')
+	}
+	w.int32(p)
+	w.int16(-1) // No rack.
+	w.int32(1)  // Controller.
+	w.int32(1)
+	if topic != "events" {
+		w.int16(3) // Unknown topic.
+		w.string(topic)
+		w.int8(0)
+		w.int32(0)
+		return w.b
+	}
+	w.int16(0)
+	w.string(topic)
+	w.int8(0)
+	w.int32(2)
+	for partition := int32(0); partition < 2; partition++ {
+		w.int16(0)
+		w.int32(partition)
+		w.int32(1) // Leader.
+		w.int32(1)
+		w.int32(1) // Replicas.
+		w.int32(1)
+		w.int32(1) // In-sync replicas.
+	}
+	return w.b
+}
+
+func (f *fakeKafka) listOffsets(r *reader) []byte {
+	r.int32() // Replica ID.
+	r.int32()
+	topic := r.string()
+	n := r.int32()
+	var w writer
+	w.int32(1)
+	w.string(topic)
+	w.int32(n)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := int32(0); i < n; i++ {
+		partition, timestamp := r.int32(), r.int64()
+		offset := int64(len(f.logs[partition]))
+		if timestamp == -2 {
+			offset = 0
+		}
+		w.int32(partition)
+		w.int16(0)
+		w.int64(-1)
+		w.int64(offset)
+	}
+	return w.b
+}
+
+func (f *fakeKafka) fetch(r *reader) []byte {
+	r.int32() // Replica ID.
+	maxWait := time.Duration(r.int32()) * time.Millisecond
+	r.int32() // Min bytes.
+	r.int32() // Max bytes.
+	r.int8()  // Isolation level.
+	r.int32()
+	topic := r.string()
+	n := r.int32()
+	offsets := make(map[int32]int64, n)
+	order := make([]int32, 0, n)
+	for i := int32(0); i < n; i++ {
+		partition, offset := r.int32(), r.int64()
+		r.int32() // Partition max bytes.
+		offsets[partition] = offset
+		order = append(order, partition)
+	}
+
+	// Wait for new records like a broker would, up to maxWait.
+	deadline := time.Now().Add(maxWait)
+	for {
+		f.mu.Lock()
+		ready := false
+		for partition, offset := range offsets {
+			ready = ready || int64(len(f.logs[partition])) > offset
+		}
+		f.mu.Unlock()
+		if ready || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var w writer
+	w.int32(0) // Throttle time.
+	w.int32(1)
+	w.string(topic)
+	w.int32(n)
+	for _, partition := range order {
+		log := f.logs[partition]
+		w.int32(partition)
+		w.int16(0)
+		w.int64(int64(len(log))) // High watermark.
+		w.int64(int64(len(log))) // Last stable offset.
+		w.int32(-1)              // No aborted transactions.
+		var records []byte
+		if offset := offsets[partition]; offset < int64(len(log)) {
+			records = recordBatch(offset, log[offset:], f.compressed)
+		}
+		w.int32(int32(len(records)))
+		w.b = append(w.b, records...)
+	}
+	return w.b
+}
+
+// recordBatch encodes records as a v2 record batch starting at offset base.
+func recordBatch(base int64, records []fakeRecord, compressed bool) []byte {
+	appendVarBytes := func(b []byte, s string) []byte {
+		b = binary.AppendVarint(b, int64(len(s)))
+		return append(b, s...)
+	}
+	var encoded []byte
+	for i, r := range records {
+		rec := []byte{0}                  // Attributes.
+		rec = binary.AppendVarint(rec, 0) // Timestamp delta.
+		rec = binary.AppendVarint(rec, int64(i))
+		if r.key == "" {
+			rec = binary.AppendVarint(rec, -1)
+		} else {
+			rec = appendVarBytes(rec, r.key)
+		}
+		rec = appendVarBytes(rec, r.value)
+		rec = binary.AppendVarint(rec, int64(len(r.headers)))
+		for k, v := range r.headers {
+			rec = appendVarBytes(appendVarBytes(rec, k), v)
+		}
+		encoded = binary.AppendVarint(encoded, int64(len(rec)))
+		encoded = append(encoded, rec...)
+	}
+
+	var tail writer
+	if compressed {
+		tail.int16(1)
+	} else {
+		tail.int16(0)
+	}
+	tail.int32(int32(len(records) - 1)) // Last offset delta.
+	tail.int64(0)                       // First timestamp.
+	tail.int64(0)                       // Max timestamp.
+	tail.int64(-1)                      // Producer ID.
+	tail.int16(-1)                      // Producer epoch.
+	tail.int32(-1)                      // Base sequence.
+	tail.int32(int32(len(records)))
+	tail.b = append(tail.b, encoded...)
+
+	var batch writer
+	batch.int64(base)
+	batch.int32(int32(4 + 1 + 4 + len(tail.b)))
+	batch.int32(0) // Partition leader epoch.
+	batch.int8(2)  // Magic.
+	batch.int32(int32(crc32.Checksum(tail.b, crc32.MakeTable(crc32.Castagnoli))))
+	return append(batch.b, tail.b...)
+}
+
+type writer struct{ b []byte }
+
+func (w *writer) int8(v int8)   { w.b = append(w.b, byte(v)) }
+func (w *writer) int16(v int16) { w.b = binary.BigEndian.AppendUint16(w.b, uint16(v)) }
+func (w *writer) int32(v int32) { w.b = binary.BigEndian.AppendUint32(w.b, uint32(v)) }
+func (w *writer) int64(v int64) { w.b = binary.BigEndian.AppendUint64(w.b, uint64(v)) }
+func (w *writer) string(s string) {
+	w.int16(int16(len(s)))
+	w.b = append(w.b, s...)
+}
+
+type reader struct{ b []byte }
+
+func (r *reader) next(n int) []byte {
+	v := r.b[:n]
+	r.b = r.b[n:]
+	return v
+}
+func (r *reader) int8() int8   { return int8(r.next(1)[0]) }
+func (r *reader) int16() int16 { return int16(binary.BigEndian.Uint16(r.next(2))) }
+func (r *reader) int32() int32 { return int32(binary.BigEndian.Uint32(r.next(4))) }
+func (r *reader) int64() int64 { return int64(binary.BigEndian.Uint64(r.next(8))) }
+func (r *reader) string() string {
+	return string(r.next(int(r.int16())))
+}
+
+func TestKafkaSource(t *testing.T) {
+	ctx, cancel := context.WithTimeout(logging.NewContext(context.Background(), logging.Discard()), 10*time.Second)
+	defer cancel()
+	f := newFakeKafka(t)
+	f.produce(0, fakeRecord{key: "k1", value: "one", headers: map[string]string{"source": "test"}})
+	f.produce(1, fakeRecord{value: "two"})
+	f.produce(0, fakeRecord{value: "three"})
+
+	oldest := &trigger.KafkaSource{Brokers: []string{f.ln.Addr().String()}, Topic: "events", FromOldest: true,
+		MaxWait: 50 * time.Millisecond, ReconnectWait: 10 * time.Millisecond}
+	if err := oldest.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer oldest.Close()
+	newest, err := trigger.NewKafkaSource(ctx, []string{f.ln.Addr().String()}, "events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer newest.Close()
+	newest.MaxWait = 50 * time.Millisecond
+
+	var values []string
+	byValue := map[string]trigger.Message{}
+	for i := 0; i < 3; i++ {
+		msg, err := oldest.Receive(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		values = append(values, string(msg.Data))
+		byValue[string(msg.Data)] = msg
+	}
+	if one := byValue["one"]; one.Subject != "events" || one.Key != "k1" || one.Headers["source"] != "test" {
+		t.Errorf("message one = %+v", one)
+	}
+	if _, ok := byValue["two"]; !ok || strings.Index(strings.Join(values, ","), "one") > strings.Index(strings.Join(values, ","), "three") {
+		t.Errorf("received %q, want one before three and two", values)
+	}
+
+	// Both sources continue with new messages, also after the broker drops the connections.
+	f.dropConns()
+	f.produce(1, fakeRecord{value: "four"})
+	for name, src := range map[string]*trigger.KafkaSource{"oldest": oldest, "newest": newest} {
+		msg, err := src.Receive(ctx)
+		if err != nil || string(msg.Data) != "four" {
+			t.Errorf("%s source received %q, %v; want four", name, msg.Data, err)
+		}
+	}
+}
+
+func TestKafkaSourceErrors(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	f := newFakeKafka(t)
+
+	if _, err := trigger.NewKafkaSource(ctx, []string{f.ln.Addr().String()}, "missing"); err == nil ||
+		!strings.Contains(err.Error(), "unknown topic") {
+		t.Errorf("unknown topic: got %v", err)
+	}
+	src := &trigger.KafkaSource{Brokers: []string{f.ln.Addr().String()}, Topic: "events", Partitions: []int32{7}}
+	if err := src.Connect(ctx); err == nil || !strings.Contains(err.Error(), "no partition 7") {
+		t.Errorf("unknown partition: got %v", err)
+	}
+
+	f.mu.Lock()
+	f.compressed = true
+	f.mu.Unlock()
+	f.produce(0, fakeRecord{value: "zipped"})
+	src = &trigger.KafkaSource{Brokers: []string{f.ln.Addr().String()}, Topic: "events", FromOldest: true}
+	if _, err := src.Receive(ctx); err == nil || !strings.Contains(err.Error(), "compression") {
+		t.Errorf("compressed batch: got %v", err)
+	}
+}
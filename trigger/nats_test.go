@@ -0,0 +1,161 @@
+package trigger_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zakirkun/gatot-kaca/logging"
+	"github.com/zakirkun/gatot-kaca/trigger"
+)
+
+// fakeNATS is a NATS server that accepts connections, completes the handshake and hands each
+// subscribed connection to the test.
+type fakeNATS struct {
+	ln    net.Listener
+	conns chan net.Conn
+	subs  chan string
+}
+
+func newFakeNATS(t *testing.T) *fakeNATS {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeNATS{ln: ln, conns: make(chan net.Conn, 4), subs: make(chan string, 4)}
+	go f.serve()
+	t.Cleanup(func() { ln.Close() })
+	return f
+}
+
+func (f *fakeNATS) url() string {
+	return "nats://" + f.ln.Addr().String()
+}
+
+func (f *fakeNATS) serve() {
+	for {
+		conn, err := f.ln.Accept()
+		if err != nil {
+			return
+		}
+		go f.handshake(conn)
+	}
+}
+
+func (f *fakeNATS) handshake(conn net.Conn) {
+	fmt.Fprint(conn, "INFO {\"server_id\":\"fake\",\"headers\":true}\r\n")
+	r := bufio.NewReader(conn)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			conn.Close()
+			return
+		}
+		switch line = strings.TrimSpace(line); {
+		case line == "PING":
+			fmt.Fprint(conn, "PONG\r\n")
+		case strings.HasPrefix(line, "SUB "):
+			f.subs <- line
+			f.conns <- conn
+			return
+		}
+	}
+}
+
+func (f *fakeNATS) accept(t *testing.T) net.Conn {
+	t.Helper()
+	select {
+	case conn := <-f.conns:
+		return conn
+	case <-time.After(5 * time.Second):
+		t.Fatal("no subscription")
+		return nil
+	}
+}
+
+func receive(t *testing.T, src trigger.Source) trigger.Message {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	msg, err := src.Receive(ctx)
+	if err != nil {
+		t.Fatalf("receive: %v", err)
+	}
+	return msg
+}
+
+func TestNATSSource(t *testing.T) {
+	server := newFakeNATS(t)
+	src := &trigger.NATSSource{URL: server.url(), Subject: "events.>", Queue: "workers", ReconnectWait: 10 * time.Millisecond}
+	if err := src.Connect(logging.NewContext(context.Background(), logging.Discard())); err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+	conn := server.accept(t)
+	if sub := <-server.subs; sub != "SUB events.> workers 1" {
+		t.Errorf("subscription = %q", sub)
+	}
+
+	fmt.Fprint(conn, "PING\r\nMSG events.created 1 5\r\nhello\r\n")
+	if msg := receive(t, src); msg.Subject != "events.created" || string(msg.Data) != "hello" {
+		t.Errorf("message = %+v", msg)
+	}
+
+	headers := "NATS/1.0\r\nX-Trace: abc\r\n\r\n"
+	fmt.Fprintf(conn, "HMSG events.updated 1 reply.to %d %d\r\n%sworld\r\n", len(headers), len(headers)+5, headers)
+	msg := receive(t, src)
+	if msg.Subject != "events.updated" || string(msg.Data) != "world" || msg.Headers["X-Trace"] != "abc" {
+		t.Errorf("message with headers = %+v", msg)
+	}
+
+	// The source reconnects and subscribes again when the connection drops.
+	conn.Close()
+	conn = server.accept(t)
+	<-server.subs
+	fmt.Fprint(conn, "MSG events.created 1 5\r\nagain\r\n")
+	if msg := receive(t, src); string(msg.Data) != "again" {
+		t.Errorf("message after reconnecting = %+v", msg)
+	}
+
+	if err := src.Close(); err != nil {
+		t.Errorf("close: %v", err)
+	}
+	if err := src.Close(); err != nil {
+		t.Errorf("second close: %v", err)
+	}
+	if _, err := src.Receive(context.Background()); err == nil {
+		t.Error("receive after close succeeded")
+	}
+}
+
+func TestNATSSourceReconnectFails(t *testing.T) {
+	server := newFakeNATS(t)
+	src := &trigger.NATSSource{URL: server.url(), Subject: "events", ReconnectWait: time.Millisecond, MaxReconnects: 2}
+	if err := src.Connect(logging.NewContext(context.Background(), logging.Discard())); err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+	conn := server.accept(t)
+	server.ln.Close()
+	conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := src.Receive(ctx); err == nil || ctx.Err() != nil {
+		t.Errorf("receive = %v, want a reconnection error", err)
+	}
+}
+
+func TestNATSSourceCloseUnconnected(t *testing.T) {
+	var src trigger.NATSSource
+	if err := src.Close(); err != nil {
+		t.Errorf("close: %v", err)
+	}
+	if _, err := src.Receive(context.Background()); err == nil {
+		t.Error("receive on an unconnected source succeeded")
+	}
+}
@@ -0,0 +1,91 @@
+package trigger
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/zakirkun/gatot-kaca/logging"
+)
+
+// Message is an event received from a queue.
+type Message struct {
+	Subject string // NATS subject or Kafka topic.
+	Key     string // Kafka message key, if any.
+	Data    []byte
+	Headers map[string]string
+}
+
+// Source delivers queue messages. NATSSource and KafkaSource are built in; wrap the client of
+// any other queue in a SourceFunc.
+type Source interface {
+	// Receive blocks until a message is available or ctx is done.
+	Receive(ctx context.Context) (Message, error)
+}
+
+// SourceFunc adapts a function to the Source interface.
+type SourceFunc func(ctx context.Context) (Message, error)
+
+// Receive implements Source.
+func (f SourceFunc) Receive(ctx context.Context) (Message, error) {
+	return f(ctx)
+}
+
+// Listener starts a run for every message received from a Source.
+type Listener struct {
+	Source Source
+	Run    Runner
+	Map    Mapper // Defaults to Raw.
+	// Concurrency is the number of runs in progress at the same time; defaults to 1, which
+	// processes messages in order.
+	Concurrency int
+	// OnResult, if set, is called with the outcome of every run. Otherwise failures are logged.
+	OnResult func(msg Message, output string, err error)
+}
+
+// Listen receives messages until ctx is done or the source fails, and waits for the runs in
+// progress before returning. It returns nil when ctx is done.
+func (l *Listener) Listen(ctx context.Context) error {
+	concurrency := l.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	mapper := l.Map
+	if mapper == nil {
+		mapper = Raw
+	}
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		msg, err := l.Source.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, context.Canceled) {
+				return nil
+			}
+			return err
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			return nil
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			input, err := mapper(msg.Data)
+			var output string
+			if err == nil {
+				output, err = l.Run(ctx, input)
+			}
+			if l.OnResult != nil {
+				l.OnResult(msg, output, err)
+			} else if err != nil {
+				logging.FromContext(ctx).Warn("run failed", logging.KeyComponent, "trigger", "subject", msg.Subject,
+					logging.KeyError, err)
+			}
+		}()
+	}
+}
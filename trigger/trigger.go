@@ -0,0 +1,85 @@
+// Package trigger starts workflow runs from external events: HTTP webhooks and messages from
+// queues. NATS subjects and Kafka topics are supported out of the box; other queues are
+// consumed through a SourceFunc wrapping their client.
+package trigger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/zakirkun/gatot-kaca/workflow"
+)
+
+// Runner starts a run with the given initial input and returns its output.
+type Runner func(ctx context.Context, input string) (string, error)
+
+// FlowRunner returns a Runner that runs the flow.
+func FlowRunner(f *workflow.Flow) Runner {
+	return f.Run
+}
+
+// Mapper converts an event payload into the initial input of a run.
+type Mapper func(payload []byte) (string, error)
+
+// Raw passes the payload through unchanged. It is the default Mapper.
+func Raw(payload []byte) (string, error) {
+	return string(payload), nil
+}
+
+// Field returns a Mapper that decodes a JSON payload and uses the value at path, a dot-separated
+// list of object keys and array indexes such as "message.text" or "items.0.title". String values
+// are used as is; other values are encoded as JSON.
+func Field(path string) Mapper {
+	return func(payload []byte) (string, error) {
+		var v interface{}
+		if err := json.Unmarshal(payload, &v); err != nil {
+			return "", fmt.Errorf("trigger: invalid JSON payload: %w", err)
+		}
+		for _, key := range strings.Split(path, ".") {
+			switch node := v.(type) {
+			case map[string]interface{}:
+				var ok bool
+				if v, ok = node[key]; !ok {
+					return "", fmt.Errorf("trigger: payload has no field %q", path)
+				}
+			case []interface{}:
+				var i int
+				if _, err := fmt.Sscan(key, &i); err != nil || i < 0 || i >= len(node) {
+					return "", fmt.Errorf("trigger: payload has no field %q", path)
+				}
+				v = node[i]
+			default:
+				return "", fmt.Errorf("trigger: payload has no field %q", path)
+			}
+		}
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+		data, err := json.Marshal(v)
+		return string(data), err
+	}
+}
+
+// Template returns a Mapper that renders a text/template with the decoded JSON payload as data,
+// e.g. "Summarize the issue {{.issue.title}}: {{.issue.body}}".
+func Template(text string) (Mapper, error) {
+	t, err := template.New("trigger").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return func(payload []byte) (string, error) {
+		var data interface{}
+		if err := json.Unmarshal(payload, &data); err != nil {
+			return "", fmt.Errorf("trigger: invalid JSON payload: %w", err)
+		}
+		var b bytes.Buffer
+		if err := t.Execute(&b, data); err != nil {
+			return "", fmt.Errorf("trigger: %w", err)
+		}
+		return b.String(), nil
+	}, nil
+}
@@ -0,0 +1,107 @@
+package trigger
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/zakirkun/gatot-kaca/logging"
+)
+
+// SignatureHeader is the header carrying the webhook signature: "sha256=" followed by the
+// hex-encoded HMAC-SHA256 of the request body, as sent by GitHub and similar services.
+const SignatureHeader = "X-Hub-Signature-256"
+
+// Webhook is an http.Handler that starts a run for every POST request, using the request
+// body as the payload.
+type Webhook struct {
+	Run Runner
+	Map Mapper // Defaults to Raw.
+	// Secret, if set, requires requests to be signed with it; see SignatureHeader.
+	Secret string
+	// Async responds with 202 Accepted immediately and runs in the background; otherwise the
+	// response holds the run's output.
+	Async bool
+	// MaxBodyBytes limits the request body; defaults to 1 MiB.
+	MaxBodyBytes int64
+}
+
+// webhookResponse is the JSON response of a webhook request.
+type webhookResponse struct {
+	Output string `json:"output,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Webhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, webhookResponse{Error: "method not allowed"})
+		return
+	}
+	limit := h.MaxBodyBytes
+	if limit <= 0 {
+		limit = 1 << 20
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+	if err != nil {
+		writeJSON(w, http.StatusRequestEntityTooLarge, webhookResponse{Error: err.Error()})
+		return
+	}
+	if h.Secret != "" && !validSignature(h.Secret, body, r.Header.Get(SignatureHeader)) {
+		writeJSON(w, http.StatusUnauthorized, webhookResponse{Error: "invalid signature"})
+		return
+	}
+
+	mapper := h.Map
+	if mapper == nil {
+		mapper = Raw
+	}
+	input, err := mapper(body)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, webhookResponse{Error: err.Error()})
+		return
+	}
+
+	if h.Async {
+		logger := logging.FromContext(r.Context())
+		go func() {
+			if _, err := h.Run(logging.NewContext(context.Background(), logger), input); err != nil {
+				logger.Warn("webhook run failed", logging.KeyComponent, "trigger", logging.KeyError, err)
+			}
+		}()
+		writeJSON(w, http.StatusAccepted, webhookResponse{})
+		return
+	}
+	output, err := h.Run(r.Context(), input)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, webhookResponse{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, webhookResponse{Output: output})
+}
+
+// validSignature checks a "sha256=<hex>" HMAC signature of body.
+func validSignature(secret string, body []byte, signature string) bool {
+	sig, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return false
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,132 @@
+package trigger_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zakirkun/gatot-kaca/trigger"
+)
+
+func sign(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func post(t *testing.T, h http.Handler, body, signature string) (int, map[string]string) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/hook", strings.NewReader(body))
+	if signature != "" {
+		req.Header.Set(trigger.SignatureHeader, signature)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	var resp map[string]string
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	return rec.Code, resp
+}
+
+func TestWebhook(t *testing.T) {
+	h := &trigger.Webhook{
+		Run: func(ctx context.Context, input string) (string, error) {
+			if input == "fail" {
+				return "", errors.New("run failed")
+			}
+			return "handled " + input, nil
+		},
+		Map:    trigger.Field("issue.title"),
+		Secret: "s3cret",
+	}
+
+	body := `{"issue":{"title":"crash"}}`
+	if code, resp := post(t, h, body, sign("s3cret", body)); code != http.StatusOK || resp["output"] != "handled crash" {
+		t.Errorf("signed request: %d %v", code, resp)
+	}
+	if code, _ := post(t, h, body, sign("other", body)); code != http.StatusUnauthorized {
+		t.Errorf("wrongly signed request: %d, want 401", code)
+	}
+	if code, _ := post(t, h, body, ""); code != http.StatusUnauthorized {
+		t.Errorf("unsigned request: %d, want 401", code)
+	}
+	bad := `{"issue":{}}`
+	if code, _ := post(t, h, bad, sign("s3cret", bad)); code != http.StatusBadRequest {
+		t.Errorf("payload without the field: %d, want 400", code)
+	}
+	failing := `{"issue":{"title":"fail"}}`
+	if code, resp := post(t, h, failing, sign("s3cret", failing)); code != http.StatusInternalServerError || resp["error"] != "run failed" {
+		t.Errorf("failed run: %d %v", code, resp)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/hook", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET: %d, want 405", rec.Code)
+	}
+}
+
+func TestWebhookAsync(t *testing.T) {
+	inputs := make(chan string, 1)
+	h := &trigger.Webhook{
+		Async: true,
+		Run: func(ctx context.Context, input string) (string, error) {
+			inputs <- input
+			return "", nil
+		},
+	}
+	if code, _ := post(t, h, "payload", ""); code != http.StatusAccepted {
+		t.Errorf("async request: %d, want 202", code)
+	}
+	select {
+	case input := <-inputs:
+		if input != "payload" {
+			t.Errorf("input = %q", input)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("run did not start")
+	}
+}
+
+func TestListener(t *testing.T) {
+	msgs := make(chan trigger.Message, 2)
+	msgs <- trigger.Message{Subject: "a", Data: []byte(`{"text":"one"}`)}
+	msgs <- trigger.Message{Subject: "b", Data: []byte(`{"text":"two"}`)}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var outputs []string
+	l := &trigger.Listener{
+		Source: trigger.SourceFunc(func(ctx context.Context) (trigger.Message, error) {
+			select {
+			case msg := <-msgs:
+				return msg, nil
+			case <-ctx.Done():
+				return trigger.Message{}, ctx.Err()
+			}
+		}),
+		Map: trigger.Field("text"),
+		Run: func(ctx context.Context, input string) (string, error) {
+			return strings.ToUpper(input), nil
+		},
+		OnResult: func(msg trigger.Message, output string, err error) {
+			outputs = append(outputs, msg.Subject+"="+output)
+			if len(outputs) == 2 {
+				cancel()
+			}
+		},
+	}
+	if err := l.Listen(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(outputs, ","); got != "a=ONE,b=TWO" {
+		t.Errorf("outputs = %s", got)
+	}
+}
@@ -0,0 +1,259 @@
+package vectors
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+// HNSWConfig holds the parameters of an HNSW index. Zero values select the defaults.
+type HNSWConfig struct {
+	M              int   // Maximum neighbors per node on upper layers (twice that on layer 0); defaults to 16.
+	EfConstruction int   // Candidate list size while inserting; defaults to 200.
+	EfSearch       int   // Candidate list size while searching; defaults to 64, and at least k.
+	Seed           int64 // Seed for level assignment, making builds reproducible; defaults to 1.
+}
+
+func (c HNSWConfig) withDefaults() HNSWConfig {
+	if c.M <= 0 {
+		c.M = 16
+	}
+	if c.EfConstruction <= 0 {
+		c.EfConstruction = 200
+	}
+	if c.EfSearch <= 0 {
+		c.EfSearch = 64
+	}
+	if c.Seed == 0 {
+		c.Seed = 1
+	}
+	return c
+}
+
+// HNSW is a Hierarchical Navigable Small World graph for approximate nearest neighbor
+// search by cosine similarity. Vectors are identified by their insertion order, so the
+// index of a vector matches its position when built from a slice. It is safe for
+// concurrent use.
+type HNSW struct {
+	cfg      HNSWConfig
+	levelMul float64
+	rng      *rand.Rand
+
+	mu       sync.RWMutex
+	nodes    []hnswNode
+	entry    int
+	maxLevel int
+}
+
+type hnswNode struct {
+	vec       []float64 // Normalized copy of the inserted vector.
+	neighbors [][]int   // Neighbor ids per layer, from layer 0 up to the node's level.
+}
+
+// NewHNSW creates an empty index.
+func NewHNSW(cfg HNSWConfig) *HNSW {
+	cfg = cfg.withDefaults()
+	return &HNSW{
+		cfg:      cfg,
+		levelMul: 1 / math.Log(float64(cfg.M)),
+		rng:      rand.New(rand.NewSource(cfg.Seed)),
+		entry:    -1,
+	}
+}
+
+// BuildHNSW creates an index containing vecs, in order.
+func BuildHNSW(vecs [][]float64, cfg HNSWConfig) *HNSW {
+	h := NewHNSW(cfg)
+	for _, v := range vecs {
+		h.Insert(v)
+	}
+	return h
+}
+
+// Len returns the number of vectors in the index.
+func (h *HNSW) Len() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.nodes)
+}
+
+// Insert adds a vector to the index and returns its id.
+func (h *HNSW) Insert(v []float64) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	id := len(h.nodes)
+	level := int(math.Floor(-math.Log(1-h.rng.Float64()) * h.levelMul))
+	node := hnswNode{vec: Normalize(v), neighbors: make([][]int, level+1)}
+	h.nodes = append(h.nodes, node)
+	if h.entry < 0 {
+		h.entry, h.maxLevel = id, level
+		return id
+	}
+
+	// Descend greedily through the layers above the new node's level.
+	ep := h.entry
+	for l := h.maxLevel; l > level; l-- {
+		ep = h.greedy(node.vec, ep, l)
+	}
+
+	// Connect the node on each of its layers, using the closest candidates found.
+	entries := []int{ep}
+	for l := min(level, h.maxLevel); l >= 0; l-- {
+		candidates := h.searchLayer(node.vec, entries, h.cfg.EfConstruction, l)
+		neighbors := h.selectNeighbors(candidates, h.maxNeighbors(l))
+		h.nodes[id].neighbors[l] = neighbors
+		for _, n := range neighbors {
+			h.connect(n, id, l)
+		}
+		entries = entries[:0]
+		for _, c := range candidates {
+			entries = append(entries, c.Index)
+		}
+	}
+
+	if level > h.maxLevel {
+		h.entry, h.maxLevel = id, level
+	}
+	return id
+}
+
+// Search returns the k vectors most similar to query, in descending order of cosine similarity.
+// The results are approximate; raise EfSearch for better recall at the cost of speed.
+func (h *HNSW) Search(query []float64, k int) []Scored {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if k <= 0 || h.entry < 0 {
+		return nil
+	}
+
+	q := Normalize(query)
+	ep := h.entry
+	for l := h.maxLevel; l > 0; l-- {
+		ep = h.greedy(q, ep, l)
+	}
+	ef := h.cfg.EfSearch
+	if ef < k {
+		ef = k
+	}
+	results := h.searchLayer(q, []int{ep}, ef, 0)
+	if len(results) > k {
+		results = results[:k]
+	}
+	return results
+}
+
+func (h *HNSW) maxNeighbors(layer int) int {
+	if layer == 0 {
+		return 2 * h.cfg.M
+	}
+	return h.cfg.M
+}
+
+func (h *HNSW) similarity(q []float64, id int) float64 {
+	return Dot(q, h.nodes[id].vec)
+}
+
+// greedy walks to the neighbor most similar to q until no neighbor improves on the current node.
+func (h *HNSW) greedy(q []float64, ep, layer int) int {
+	best := h.similarity(q, ep)
+	for changed := true; changed; {
+		changed = false
+		for _, n := range h.nodes[ep].neighbors[layer] {
+			if s := h.similarity(q, n); s > best {
+				best, ep, changed = s, n, true
+			}
+		}
+	}
+	return ep
+}
+
+// searchLayer returns up to ef nodes on the layer closest to q, in descending order of similarity.
+func (h *HNSW) searchLayer(q []float64, entries []int, ef, layer int) []Scored {
+	visited := make(map[int]bool, ef*4)
+	candidates := &maxHeap{}
+	results := &minHeap{}
+	for _, ep := range entries {
+		if visited[ep] {
+			continue
+		}
+		visited[ep] = true
+		item := Scored{Index: ep, Score: h.similarity(q, ep)}
+		heap.Push(candidates, item)
+		heap.Push(results, item)
+	}
+	for results.Len() > ef {
+		heap.Pop(results)
+	}
+
+	for candidates.Len() > 0 {
+		c := heap.Pop(candidates).(Scored)
+		if results.Len() >= ef && less(c, (*results)[0]) {
+			break
+		}
+		for _, n := range h.nodes[c.Index].neighbors[layer] {
+			if visited[n] {
+				continue
+			}
+			visited[n] = true
+			item := Scored{Index: n, Score: h.similarity(q, n)}
+			if results.Len() < ef || less((*results)[0], item) {
+				heap.Push(candidates, item)
+				heap.Push(results, item)
+				if results.Len() > ef {
+					heap.Pop(results)
+				}
+			}
+		}
+	}
+
+	out := []Scored(*results)
+	sort.Slice(out, func(i, j int) bool { return less(out[j], out[i]) })
+	return out
+}
+
+// selectNeighbors keeps the m most similar candidates, which are already sorted.
+func (h *HNSW) selectNeighbors(candidates []Scored, m int) []int {
+	if len(candidates) > m {
+		candidates = candidates[:m]
+	}
+	ids := make([]int, len(candidates))
+	for i, c := range candidates {
+		ids[i] = c.Index
+	}
+	return ids
+}
+
+// connect adds a link from node to target on the layer, pruning the node's links to the
+// most similar ones if it has too many.
+func (h *HNSW) connect(node, target, layer int) {
+	links := append(h.nodes[node].neighbors[layer], target)
+	limit := h.maxNeighbors(layer)
+	if len(links) > limit {
+		vec := h.nodes[node].vec
+		scored := make([]Scored, len(links))
+		for i, l := range links {
+			scored[i] = Scored{Index: l, Score: h.similarity(vec, l)}
+		}
+		sort.Slice(scored, func(i, j int) bool { return less(scored[j], scored[i]) })
+		links = h.selectNeighbors(scored, limit)
+	}
+	h.nodes[node].neighbors[layer] = links
+}
+
+// maxHeap keeps the highest-ranked item at the root.
+type maxHeap []Scored
+
+func (h maxHeap) Len() int            { return len(h) }
+func (h maxHeap) Less(i, j int) bool  { return less(h[j], h[i]) }
+func (h maxHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *maxHeap) Push(x interface{}) { *h = append(*h, x.(Scored)) }
+func (h *maxHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
@@ -0,0 +1,150 @@
+// Package vectors provides provider-agnostic helpers for working with embeddings:
+// normalization, dot products, cosine similarity, batch scoring, and top-k selection.
+// The loops are manually unrolled so the compiler can keep them in registers and
+// they stay fast on the large batches produced during retrieval.
+package vectors
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+)
+
+// Dot returns the dot product of a and b. It returns 0 if the lengths differ.
+func Dot(a, b []float64) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var s0, s1, s2, s3 float64
+	n := len(a)
+	i := 0
+	for ; i <= n-4; i += 4 {
+		s0 += a[i] * b[i]
+		s1 += a[i+1] * b[i+1]
+		s2 += a[i+2] * b[i+2]
+		s3 += a[i+3] * b[i+3]
+	}
+	for ; i < n; i++ {
+		s0 += a[i] * b[i]
+	}
+	return s0 + s1 + s2 + s3
+}
+
+// Norm returns the Euclidean (L2) norm of v.
+func Norm(v []float64) float64 {
+	return math.Sqrt(Dot(v, v))
+}
+
+// Normalize returns a unit-length copy of v. A zero vector is returned unchanged.
+func Normalize(v []float64) []float64 {
+	out := make([]float64, len(v))
+	copy(out, v)
+	NormalizeInPlace(out)
+	return out
+}
+
+// NormalizeInPlace scales v to unit length. A zero vector is left unchanged.
+func NormalizeInPlace(v []float64) {
+	norm := Norm(v)
+	if norm == 0 {
+		return
+	}
+	inv := 1 / norm
+	for i := range v {
+		v[i] *= inv
+	}
+}
+
+// Cosine returns the cosine similarity between a and b.
+// It returns 0 if the lengths differ or either vector is zero.
+func Cosine(a, b []float64) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	normA, normB := Norm(a), Norm(b)
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return Dot(a, b) / (normA * normB)
+}
+
+// BatchCosine returns the cosine similarity between query and every vector in batch.
+// The query norm is computed once for the whole batch.
+func BatchCosine(query []float64, batch [][]float64) []float64 {
+	scores := make([]float64, len(batch))
+	qNorm := Norm(query)
+	if qNorm == 0 {
+		return scores
+	}
+	for i, v := range batch {
+		if len(v) != len(query) {
+			continue
+		}
+		vNorm := Norm(v)
+		if vNorm == 0 {
+			continue
+		}
+		scores[i] = Dot(query, v) / (qNorm * vNorm)
+	}
+	return scores
+}
+
+// Scored pairs an index into a batch with its similarity score.
+type Scored struct {
+	Index int
+	Score float64
+}
+
+// TopK returns the k highest scores with their indices, in descending order of score.
+// Ties are broken by the lower index first. It runs in O(n log k).
+func TopK(scores []float64, k int) []Scored {
+	if k <= 0 || len(scores) == 0 {
+		return nil
+	}
+	if k > len(scores) {
+		k = len(scores)
+	}
+
+	h := make(minHeap, 0, k)
+	for i, s := range scores {
+		item := Scored{Index: i, Score: s}
+		if len(h) < k {
+			heap.Push(&h, item)
+		} else if less(h[0], item) {
+			h[0] = item
+			heap.Fix(&h, 0)
+		}
+	}
+
+	out := []Scored(h)
+	sort.Slice(out, func(i, j int) bool { return less(out[j], out[i]) })
+	return out
+}
+
+// TopKCosine scores every vector in batch against query and returns the k most similar.
+func TopKCosine(query []float64, batch [][]float64, k int) []Scored {
+	return TopK(BatchCosine(query, batch), k)
+}
+
+// less reports whether a ranks below b.
+func less(a, b Scored) bool {
+	if a.Score != b.Score {
+		return a.Score < b.Score
+	}
+	return a.Index > b.Index
+}
+
+// minHeap keeps the lowest-ranked item at the root.
+type minHeap []Scored
+
+func (h minHeap) Len() int            { return len(h) }
+func (h minHeap) Less(i, j int) bool  { return less(h[i], h[j]) }
+func (h minHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *minHeap) Push(x interface{}) { *h = append(*h, x.(Scored)) }
+func (h *minHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
@@ -0,0 +1,71 @@
+package vectors
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestCosine(t *testing.T) {
+	if got := Cosine([]float64{1, 0}, []float64{1, 0}); math.Abs(got-1) > 1e-9 {
+		t.Errorf("expected 1 for identical vectors, got %v", got)
+	}
+	if got := Cosine([]float64{1, 0}, []float64{0, 1}); got != 0 {
+		t.Errorf("expected 0 for orthogonal vectors, got %v", got)
+	}
+	if got := Cosine([]float64{1, 0}, []float64{1, 0, 0}); got != 0 {
+		t.Errorf("expected 0 for mismatched lengths, got %v", got)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	v := Normalize([]float64{3, 4, 0, 0, 0})
+	if math.Abs(Norm(v)-1) > 1e-9 {
+		t.Errorf("expected unit norm, got %v", Norm(v))
+	}
+}
+
+func TestTopK(t *testing.T) {
+	got := TopK([]float64{0.1, 0.9, 0.5, 0.9, 0.3}, 3)
+	want := []int{1, 3, 2}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d results, got %d", len(want), len(got))
+	}
+	for i, idx := range want {
+		if got[i].Index != idx {
+			t.Errorf("position %d: expected index %d, got %d", i, idx, got[i].Index)
+		}
+	}
+}
+
+func TestHNSWRecall(t *testing.T) {
+	rng := rand.New(rand.NewSource(42))
+	vecs := make([][]float64, 2000)
+	for i := range vecs {
+		vecs[i] = make([]float64, 16)
+		for j := range vecs[i] {
+			vecs[i][j] = rng.NormFloat64()
+		}
+	}
+	index := BuildHNSW(vecs, HNSWConfig{})
+
+	hits, total := 0, 0
+	for q := 0; q < 50; q++ {
+		query := vecs[rng.Intn(len(vecs))]
+		exact := TopKCosine(query, vecs, 10)
+		approx := index.Search(query, 10)
+		found := map[int]bool{}
+		for _, s := range approx {
+			found[s.Index] = true
+		}
+		for _, s := range exact {
+			if found[s.Index] {
+				hits++
+			}
+			total++
+		}
+	}
+	if recall := float64(hits) / float64(total); recall < 0.9 {
+		t.Errorf("expected recall of at least 0.9, got %.2f", recall)
+	}
+}
@@ -0,0 +1,105 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/zakirkun/gatot-kaca/llm"
+)
+
+// TranscribeNode is a workflow node that takes the path of an audio file as input and outputs
+// its transcript, so a voice message can feed an LLMNode.
+type TranscribeNode struct {
+	Client *llm.Client
+	Model  string // A model that implements llm.Transcriber, such as OpenAI's whisper-1.
+	// Language is an optional ISO-639-1 code that improves accuracy when the language is known.
+	Language string
+	Prompt   string // Optional text that guides the transcript's style or spelling.
+}
+
+// Execute reads the audio file at the input path and returns its transcript.
+func (n *TranscribeNode) Execute(ctx context.Context, input string) (string, error) {
+	audio, err := os.ReadFile(input)
+	if err != nil {
+		return "", fmt.Errorf("transcribe node: %w", err)
+	}
+	t, err := n.Transcribe(ctx, llm.TranscriptionRequest{Audio: audio, Filename: filepath.Base(input)})
+	if err != nil {
+		return "", err
+	}
+	return t.Text, nil
+}
+
+// Transcribe transcribes audio held in memory, filling in the node's Language and Prompt when
+// the request leaves them empty. As a TypedFunc it is a typed node:
+//
+//	transcribe := workflow.TypedFunc[llm.TranscriptionRequest, llm.Transcription](tn.Transcribe)
+func (n *TranscribeNode) Transcribe(ctx context.Context, req llm.TranscriptionRequest) (llm.Transcription, error) {
+	if req.Language == "" {
+		req.Language = n.Language
+	}
+	if req.Prompt == "" {
+		req.Prompt = n.Prompt
+	}
+	t, err := n.Client.Transcribe(ctx, n.Model, req)
+	if err != nil {
+		return llm.Transcription{}, fmt.Errorf("transcribe node: %w", err)
+	}
+	return t, nil
+}
+
+// SpeakNode is a workflow node that turns its input text into speech, writes the audio to a
+// new file and outputs the file's path, so an agent's reply can be played back.
+type SpeakNode struct {
+	Client *llm.Client
+	Model  string // A model that implements llm.Speaker, such as OpenAI's tts-1.
+	Voice  string // Defaults to llm.DefaultSpeechVoice.
+	Format string // Audio format and file extension; defaults to llm.DefaultSpeechFormat.
+	Speed  float64
+	// Instructions optionally describe the tone or style of the voice.
+	Instructions string
+	// Dir is the directory audio files are written to; defaults to os.TempDir(). The caller is
+	// responsible for removing the files.
+	Dir string
+}
+
+// Execute synthesizes the input text and returns the path of the written audio file.
+func (n *SpeakNode) Execute(ctx context.Context, input string) (string, error) {
+	speech, err := n.Speak(ctx, input)
+	if err != nil {
+		return "", err
+	}
+	f, err := os.CreateTemp(n.Dir, "speech-*."+speech.Format)
+	if err != nil {
+		return "", fmt.Errorf("speak node: %w", err)
+	}
+	if _, err := f.Write(speech.Audio); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", fmt.Errorf("speak node: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("speak node: %w", err)
+	}
+	return f.Name(), nil
+}
+
+// Speak synthesizes text and returns the audio in memory. As a TypedFunc it is a typed node:
+//
+//	speak := workflow.TypedFunc[string, llm.Speech](sn.Speak)
+func (n *SpeakNode) Speak(ctx context.Context, text string) (llm.Speech, error) {
+	speech, err := n.Client.Speak(ctx, n.Model, llm.SpeechRequest{
+		Text:         text,
+		Voice:        n.Voice,
+		Format:       n.Format,
+		Speed:        n.Speed,
+		Instructions: n.Instructions,
+	})
+	if err != nil {
+		return llm.Speech{}, fmt.Errorf("speak node: %w", err)
+	}
+	return speech, nil
+}
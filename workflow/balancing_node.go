@@ -3,25 +3,32 @@ package workflow
 import (
 	"context"
 	"errors"
-	"log"
-	"math/rand"
-	"sync/atomic"
+	"sync"
 	"time"
+
+	"github.com/zakirkun/gatot-kaca/llm"
+	"github.com/zakirkun/gatot-kaca/logging"
 )
 
 // BalancingNode is a workflow node that selects one out of multiple nodes based on a balancing algorithm.
-// If Weights is provided (its length equals len(Nodes)), weighted random selection is used;
-// otherwise, a round-robin algorithm is applied.
+// If Strategy is set it picks the node; otherwise, if Weights is provided (its length equals
+// len(Nodes)), weighted random selection is used, and round-robin if not. It is safe for
+// concurrent use.
 type BalancingNode struct {
 	Nodes   []Node // Available child nodes.
 	Weights []int  // Optional: if provided and len(Weights)==len(Nodes), use weighted random selection.
+	// Strategy optionally selects the node from live statistics, e.g. LeastLatency,
+	// LeastPending, StickyHash, or ErrorAware wrapping any of them. Inject a SelectorFunc to
+	// make the selection deterministic in tests.
+	Strategy Selector
+	// Seed, if non-zero, seeds the random source of the weighted selection, so that the
+	// sequence of selected nodes is reproducible.
+	Seed int64
 
-	rrCounter uint64 // Internal counter for round-robin selection.
-}
-
-// init seeds the random number generator.
-func init() {
-	rand.Seed(time.Now().UnixNano())
+	mu    sync.Mutex
+	stats []NodeStats
+	rr    RoundRobin  // Default strategy without weights.
+	rand  *LockedRand // Random source of the weighted selection, created on first use.
 }
 
 // Execute selects one child node based on the balancing algorithm and then executes it with the input.
@@ -30,44 +37,104 @@ func (bn *BalancingNode) Execute(ctx context.Context, input string) (string, err
 		return "", errors.New("balancing node: no nodes available")
 	}
 
-	var selected Node
+	idx := bn.selector(ctx).Select(input, bn.Stats())
+	if idx < 0 || idx >= len(bn.Nodes) {
+		return "", errors.New("balancing node: strategy selected no node")
+	}
+	logging.FromContext(ctx).Debug("balancing node selected child", logging.KeyComponent, "workflow",
+		logging.KeyNode, nodeLabel(bn.Nodes[idx]), "index", idx)
 
+	bn.begin(idx)
+	start := time.Now()
+	output, err := bn.Nodes[idx].Execute(ctx, input)
+	bn.end(idx, time.Since(start), err)
+	return output, err
+}
+
+// selector returns the configured strategy or the default one.
+func (bn *BalancingNode) selector(ctx context.Context) Selector {
+	if bn.Strategy != nil {
+		return bn.Strategy
+	}
 	if len(bn.Weights) == len(bn.Nodes) {
-		// Use weighted random selection.
 		total := 0
 		for _, w := range bn.Weights {
 			total += w
 		}
-		if total <= 0 {
-			// If total weight is non-positive, fall back to round-robin.
-			log.Printf("BalancingNode: total weight %d is non-positive; falling back to round-robin", total)
-			idx := int(atomic.AddUint64(&bn.rrCounter, 1)-1) % len(bn.Nodes)
-			selected = bn.Nodes[idx]
-			log.Printf("BalancingNode (fallback round-robin) selected node at index %d", idx)
+		if total > 0 {
+			return WeightedRandom{Weights: bn.Weights, Rand: bn.random()}
+		}
+		// If total weight is non-positive, fall back to round-robin.
+		logging.FromContext(ctx).Warn("balancing node weights are non-positive, falling back to round-robin",
+			logging.KeyComponent, "workflow", "total_weight", total)
+	}
+	return &bn.rr
+}
+
+// random returns the node's random source.
+func (bn *BalancingNode) random() *LockedRand {
+	bn.mu.Lock()
+	defer bn.mu.Unlock()
+	if bn.rand == nil {
+		seed := bn.Seed
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+		bn.rand = NewLockedRand(seed)
+	}
+	return bn.rand
+}
+
+// Stats returns a snapshot of the statistics of every child node.
+func (bn *BalancingNode) Stats() []NodeStats {
+	bn.mu.Lock()
+	defer bn.mu.Unlock()
+	bn.initStats()
+	return append([]NodeStats(nil), bn.stats...)
+}
+
+func (bn *BalancingNode) initStats() {
+	for i := len(bn.stats); i < len(bn.Nodes); i++ {
+		bn.stats = append(bn.stats, NodeStats{Index: i})
+	}
+}
+
+func (bn *BalancingNode) begin(idx int) {
+	bn.mu.Lock()
+	defer bn.mu.Unlock()
+	bn.initStats()
+	bn.stats[idx].Pending++
+}
+
+// ewmaWeight is the weight of the latest observation in the latency and error rate averages.
+const ewmaWeight = 0.3
+
+func (bn *BalancingNode) end(idx int, d time.Duration, err error) {
+	bn.mu.Lock()
+	defer bn.mu.Unlock()
+	s := &bn.stats[idx]
+	s.Pending--
+	s.Requests++
+	failed := 0.0
+	if err != nil && !errors.Is(err, context.Canceled) {
+		failed = 1
+		s.Failures++
+		s.ConsecutiveFailures++
+		s.LastFailure = time.Now()
+		if wait, ok := llm.RetryAfter(err); ok {
+			s.BlockedUntil = s.LastFailure.Add(wait)
+		}
+	} else if err == nil {
+		s.ConsecutiveFailures = 0
+		if s.Latency == 0 {
+			s.Latency = d
 		} else {
-			r := rand.Intn(total)
-			selectedIndex := -1
-			for i, w := range bn.Weights {
-				if r < w {
-					selected = bn.Nodes[i]
-					selectedIndex = i
-					break
-				}
-				r -= w
-			}
-			// Fallback to the last node if none selected.
-			if selected == nil {
-				selected = bn.Nodes[len(bn.Nodes)-1]
-				selectedIndex = len(bn.Nodes) - 1
-			}
-			log.Printf("BalancingNode (weighted) selected node at index %d", selectedIndex)
+			s.Latency = time.Duration(ewmaWeight*float64(d) + (1-ewmaWeight)*float64(s.Latency))
 		}
+	}
+	if s.Requests == 1 {
+		s.ErrorRate = failed
 	} else {
-		// Use round-robin selection.
-		idx := int(atomic.AddUint64(&bn.rrCounter, 1)-1) % len(bn.Nodes)
-		selected = bn.Nodes[idx]
-		log.Printf("BalancingNode (round-robin) selected node at index %d", idx)
+		s.ErrorRate = ewmaWeight*failed + (1-ewmaWeight)*s.ErrorRate
 	}
-
-	return selected.Execute(ctx, input)
 }
@@ -0,0 +1,216 @@
+package workflow
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// NodeStats are the live statistics of a child node of a BalancingNode.
+type NodeStats struct {
+	Index               int           // Position of the node in BalancingNode.Nodes.
+	Pending             int           // Executions in progress.
+	Requests            int           // Completed executions.
+	Failures            int           // Failed executions.
+	ConsecutiveFailures int           // Failures since the last success.
+	Latency             time.Duration // Moving average of successful execution times; 0 if none yet.
+	ErrorRate           float64       // Moving average of the failure rate, between 0 and 1.
+	LastFailure         time.Time
+	// BlockedUntil is set when the node failed with a rate limit error asking to retry later
+	// (see llm.RetryAfter).
+	BlockedUntil time.Time
+}
+
+// Selector picks the node a BalancingNode runs for an input. Candidates holds the statistics of
+// the nodes to choose from, which may be a subset of all nodes; Select returns the Index of the
+// chosen one.
+type Selector interface {
+	Select(input string, candidates []NodeStats) int
+}
+
+// SelectorFunc adapts a function to the Selector interface.
+type SelectorFunc func(input string, candidates []NodeStats) int
+
+// Select calls the function.
+func (f SelectorFunc) Select(input string, candidates []NodeStats) int {
+	return f(input, candidates)
+}
+
+// RoundRobin selects the candidates in turn.
+type RoundRobin struct {
+	counter uint64
+}
+
+// Select implements Selector.
+func (r *RoundRobin) Select(input string, candidates []NodeStats) int {
+	n := atomic.AddUint64(&r.counter, 1) - 1
+	return candidates[n%uint64(len(candidates))].Index
+}
+
+// LockedRand is a source of random numbers that is safe for concurrent use.
+type LockedRand struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+// NewLockedRand creates a source seeded with seed; a fixed seed gives reproducible numbers.
+func NewLockedRand(seed int64) *LockedRand {
+	return &LockedRand{r: rand.New(rand.NewSource(seed))}
+}
+
+// Intn returns a random number in [0, n).
+func (l *LockedRand) Intn(n int) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Intn(n)
+}
+
+// WeightedRandom selects a candidate at random in proportion to its weight, indexed by node
+// index. Candidates without a positive weight are never selected, unless none has one.
+type WeightedRandom struct {
+	Weights []int
+	// Rand draws the random numbers; defaults to the global source of math/rand. Use a
+	// LockedRand with a fixed seed for reproducible selections.
+	Rand *LockedRand
+}
+
+// Select implements Selector.
+func (w WeightedRandom) Select(input string, candidates []NodeStats) int {
+	intn := rand.Intn
+	if w.Rand != nil {
+		intn = w.Rand.Intn
+	}
+	total := 0
+	for _, c := range candidates {
+		total += w.weight(c.Index)
+	}
+	if total <= 0 {
+		return candidates[intn(len(candidates))].Index
+	}
+	r := intn(total)
+	for _, c := range candidates {
+		if r < w.weight(c.Index) {
+			return c.Index
+		}
+		r -= w.weight(c.Index)
+	}
+	return candidates[len(candidates)-1].Index
+}
+
+func (w WeightedRandom) weight(i int) int {
+	if i < len(w.Weights) && w.Weights[i] > 0 {
+		return w.Weights[i]
+	}
+	return 0
+}
+
+// LeastLatency selects the candidate with the lowest average latency. Nodes that have not
+// completed a request yet are tried first.
+type LeastLatency struct{}
+
+// Select implements Selector.
+func (LeastLatency) Select(input string, candidates []NodeStats) int {
+	best := candidates[0]
+	for _, c := range candidates[1:] {
+		if c.Latency < best.Latency {
+			best = c
+		}
+	}
+	return best.Index
+}
+
+// LeastPending selects the candidate with the fewest executions in progress, preferring the
+// lower latency on ties.
+type LeastPending struct{}
+
+// Select implements Selector.
+func (LeastPending) Select(input string, candidates []NodeStats) int {
+	best := candidates[0]
+	for _, c := range candidates[1:] {
+		if c.Pending < best.Pending || (c.Pending == best.Pending && c.Latency < best.Latency) {
+			best = c
+		}
+	}
+	return best.Index
+}
+
+// StickyHash sends the same input to the same node, e.g. to benefit from a provider's prompt
+// cache. It uses rendezvous hashing, so when a node becomes unavailable only its inputs move.
+type StickyHash struct {
+	// Key derives the hash key from the input; defaults to the whole input.
+	Key func(input string) string
+}
+
+// Select implements Selector.
+func (s StickyHash) Select(input string, candidates []NodeStats) int {
+	key := input
+	if s.Key != nil {
+		key = s.Key(input)
+	}
+	best, bestScore := candidates[0].Index, uint64(0)
+	for _, c := range candidates {
+		h := fnv.New64a()
+		h.Write([]byte(strconv.Itoa(c.Index) + "\x00" + key))
+		if score := h.Sum64(); score >= bestScore {
+			best, bestScore = c.Index, score
+		}
+	}
+	return best
+}
+
+// ErrorAware skips nodes that are failing and delegates the choice among the others to Next.
+// A node trips the breaker after MaxConsecutiveFailures failures in a row, or when its error
+// rate exceeds MaxErrorRate, and is retried after Cooldown. Nodes that hit a rate limit are
+// skipped for as long as the provider asked. If every node is skipped, all are candidates.
+type ErrorAware struct {
+	Next                   Selector      // Defaults to round-robin.
+	MaxConsecutiveFailures int           // Defaults to 3.
+	MaxErrorRate           float64       // Defaults to 0.5.
+	Cooldown               time.Duration // Defaults to 30 seconds.
+
+	rr RoundRobin
+}
+
+// Select implements Selector.
+func (e *ErrorAware) Select(input string, candidates []NodeStats) int {
+	now := time.Now()
+	healthy := make([]NodeStats, 0, len(candidates))
+	for _, c := range candidates {
+		if !e.tripped(c, now) {
+			healthy = append(healthy, c)
+		}
+	}
+	if len(healthy) == 0 {
+		healthy = candidates
+	}
+	next := e.Next
+	if next == nil {
+		next = &e.rr
+	}
+	return next.Select(input, healthy)
+}
+
+func (e *ErrorAware) tripped(c NodeStats, now time.Time) bool {
+	if now.Before(c.BlockedUntil) {
+		return true
+	}
+	cooldown := e.Cooldown
+	if cooldown <= 0 {
+		cooldown = 30 * time.Second
+	}
+	if c.Failures == 0 || now.Sub(c.LastFailure) >= cooldown {
+		return false
+	}
+	maxFailures := e.MaxConsecutiveFailures
+	if maxFailures <= 0 {
+		maxFailures = 3
+	}
+	maxRate := e.MaxErrorRate
+	if maxRate <= 0 {
+		maxRate = 0.5
+	}
+	return c.ConsecutiveFailures >= maxFailures || c.ErrorRate > maxRate
+}
@@ -0,0 +1,134 @@
+package workflow_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/zakirkun/gatot-kaca/workflow"
+)
+
+func TestLockedRandSeeded(t *testing.T) {
+	a, b := workflow.NewLockedRand(42), workflow.NewLockedRand(42)
+	other := workflow.NewLockedRand(43)
+	same := true
+	for i := 0; i < 100; i++ {
+		x := a.Intn(1000)
+		if y := b.Intn(1000); x != y {
+			t.Fatalf("draw %d: %d and %d from the same seed", i, x, y)
+		}
+		if other.Intn(1000) != x {
+			same = false
+		}
+	}
+	if same {
+		t.Error("different seeds gave the same sequence")
+	}
+}
+
+// candidates returns the statistics of nodes with the given indexes.
+func candidates(indexes ...int) []workflow.NodeStats {
+	stats := make([]workflow.NodeStats, len(indexes))
+	for i, idx := range indexes {
+		stats[i].Index = idx
+	}
+	return stats
+}
+
+// selections returns n selections of s among the candidates.
+func selections(s workflow.Selector, n int, cands []workflow.NodeStats) []int {
+	picks := make([]int, n)
+	for i := range picks {
+		picks[i] = s.Select("input", cands)
+	}
+	return picks
+}
+
+func TestWeightedRandomSeeded(t *testing.T) {
+	weights := []int{1, 0, 3}
+	cands := candidates(0, 1, 2)
+	first := selections(workflow.WeightedRandom{Weights: weights, Rand: workflow.NewLockedRand(7)}, 1000, cands)
+	second := selections(workflow.WeightedRandom{Weights: weights, Rand: workflow.NewLockedRand(7)}, 1000, cands)
+	if !reflect.DeepEqual(first, second) {
+		t.Fatal("the same seed gave different selections")
+	}
+
+	counts := make([]int, len(weights))
+	for _, idx := range first {
+		counts[idx]++
+	}
+	if counts[1] != 0 {
+		t.Errorf("node without weight selected %d times", counts[1])
+	}
+	// Node 2 has three quarters of the weight.
+	if counts[2] < 700 || counts[2] > 800 {
+		t.Errorf("selection counts = %v, want about 250/0/750", counts)
+	}
+}
+
+func TestWeightedRandomSubset(t *testing.T) {
+	w := workflow.WeightedRandom{Weights: []int{5, 1, 0}, Rand: workflow.NewLockedRand(1)}
+	// Only nodes 1 and 2 are candidates, so node 1, the only one with a weight, is always chosen.
+	for _, idx := range selections(w, 50, candidates(2, 1)) {
+		if idx != 1 {
+			t.Fatalf("selected node %d, want 1", idx)
+		}
+	}
+	// Without any weight among the candidates, they are chosen uniformly.
+	seen := map[int]bool{}
+	for _, idx := range selections(w, 50, candidates(2)) {
+		seen[idx] = true
+	}
+	if !reflect.DeepEqual(seen, map[int]bool{2: true}) {
+		t.Errorf("selected %v among candidates without weight, want only node 2", seen)
+	}
+}
+
+// recordingNodes returns n nodes that append their index to order when executed.
+func recordingNodes(n int, order *[]int) []workflow.Node {
+	nodes := make([]workflow.Node, n)
+	for i := range nodes {
+		i := i
+		nodes[i] = &workflow.FuncNode{Process: func(ctx context.Context, input string) (string, error) {
+			*order = append(*order, i)
+			return input, nil
+		}}
+	}
+	return nodes
+}
+
+func TestBalancingNodeSeed(t *testing.T) {
+	run := func(seed int64) []int {
+		var order []int
+		bn := &workflow.BalancingNode{Nodes: recordingNodes(3, &order), Weights: []int{2, 1, 1}, Seed: seed}
+		for i := 0; i < 50; i++ {
+			if _, err := bn.Execute(context.Background(), "input"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return order
+	}
+	first, second := run(12345), run(12345)
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("the same seed selected\n%v\nthen\n%v", first, second)
+	}
+	if reflect.DeepEqual(first, run(54321)) {
+		t.Error("different seeds selected the same nodes")
+	}
+}
+
+func TestBalancingNodeRoundRobin(t *testing.T) {
+	for _, weights := range [][]int{nil, {0, 0, 0}} {
+		var order []int
+		bn := &workflow.BalancingNode{Nodes: recordingNodes(3, &order), Weights: weights}
+		for i := 0; i < 5; i++ {
+			bn.Execute(context.Background(), "input")
+		}
+		if want := []int{0, 1, 2, 0, 1}; !reflect.DeepEqual(order, want) {
+			t.Errorf("weights %v: selected %v, want round-robin %v", weights, order, want)
+		}
+		if stats := bn.Stats(); stats[0].Requests != 2 || stats[2].Requests != 1 {
+			t.Errorf("weights %v: stats = %+v", weights, stats)
+		}
+	}
+}
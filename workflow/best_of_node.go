@@ -0,0 +1,92 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/zakirkun/gatot-kaca/eval"
+)
+
+// BestOfNode is an LLM step that asks the model for N candidate responses in one request and
+// outputs the one the evaluator scores highest. The prompt is built like an LLMNode's.
+type BestOfNode struct {
+	LLMNode
+	// N is the number of candidates to generate; defaults to 3.
+	N int
+	// Evaluator scores each candidate against the node input.
+	Evaluator eval.Evaluator
+}
+
+// ScoredCandidate is a candidate response with its evaluation score.
+type ScoredCandidate struct {
+	Text  string
+	Score float64
+	Err   error // Error from the evaluator; the candidate is then never selected.
+}
+
+// BestOfResult holds every candidate of a BestOfNode execution and the index of the best one.
+type BestOfResult struct {
+	Candidates []ScoredCandidate
+	Best       int
+}
+
+// Output returns the text of the best candidate.
+func (r BestOfResult) Output() string {
+	return r.Candidates[r.Best].Text
+}
+
+// Label implements Labeler.
+func (n *BestOfNode) Label() string {
+	return fmt.Sprintf("LLM (best of %d)", n.candidates())
+}
+
+func (n *BestOfNode) candidates() int {
+	if n.N <= 0 {
+		return 3
+	}
+	return n.N
+}
+
+// Execute generates the candidates and returns the best one.
+func (n *BestOfNode) Execute(ctx context.Context, input string) (string, error) {
+	result, err := n.ExecuteDetailed(ctx, input)
+	if err != nil {
+		return "", err
+	}
+	return result.Output(), nil
+}
+
+// ExecuteDetailed generates the candidates, scores each of them and reports all scores. Ties
+// go to the earlier candidate. It fails only if no candidate could be scored.
+func (n *BestOfNode) ExecuteDetailed(ctx context.Context, input string) (BestOfResult, error) {
+	if n.Evaluator == nil {
+		return BestOfResult{}, fmt.Errorf("best of node: no evaluator provided")
+	}
+	n.Agent.Reset()
+	text, err := n.message(ctx, input)
+	if err != nil {
+		return BestOfResult{}, err
+	}
+	texts, err := n.Agent.SendN(ctx, text, n.candidates())
+	if err != nil {
+		return BestOfResult{}, err
+	}
+
+	result := BestOfResult{Candidates: make([]ScoredCandidate, len(texts)), Best: -1}
+	var lastErr error
+	for i, t := range texts {
+		score, err := n.Evaluator.Evaluate(ctx, input, t)
+		result.Candidates[i] = ScoredCandidate{Text: t, Score: score, Err: err}
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if result.Best < 0 || score > result.Candidates[result.Best].Score {
+			result.Best = i
+		}
+	}
+	if result.Best < 0 {
+		return result, fmt.Errorf("best of node: no candidate could be evaluated: %w", lastErr)
+	}
+	return result, nil
+}
@@ -0,0 +1,302 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/zakirkun/gatot-kaca/agent"
+	"github.com/zakirkun/gatot-kaca/llm"
+	"github.com/zakirkun/gatot-kaca/tokenizer"
+)
+
+// StubFunc replaces the execution of a node during a dry run.
+type StubFunc func(input string) (string, error)
+
+// Price is the cost of a model in currency units per million tokens.
+type Price struct {
+	Prompt     float64
+	Completion float64
+}
+
+// Cost returns the cost of the given usage.
+func (p Price) Cost(u llm.Usage) float64 {
+	return (float64(u.PromptTokens)*p.Prompt + float64(u.CompletionTokens)*p.Completion) / 1e6
+}
+
+// DryRunOption configures Flow.DryRun.
+type DryRunOption func(*dryRunOptions)
+
+type dryRunOptions struct {
+	stubs            map[Node]StubFunc
+	fixture          *RunRecord
+	prices           map[string]Price
+	completionTokens int
+	executeFuncs     bool
+}
+
+// WithStub makes the dry run use fn instead of the node. Stubs are also the way to steer
+// conditional nodes down a particular branch.
+func WithStub(node Node, fn StubFunc) DryRunOption {
+	return func(o *dryRunOptions) {
+		o.stubs[node] = fn
+	}
+}
+
+// WithFixture replays the outputs and token usage recorded for the top-level nodes of a
+// previous run, e.g. one loaded from a RunStore.
+func WithFixture(rec *RunRecord) DryRunOption {
+	return func(o *dryRunOptions) {
+		o.fixture = rec
+	}
+}
+
+// WithPrice sets the price of a model used to estimate the cost of LLM nodes. Models without a
+// price use the one in the llm capability registry, if any.
+func WithPrice(model string, p Price) DryRunOption {
+	return func(o *dryRunOptions) {
+		o.prices[model] = p
+	}
+}
+
+// WithCompletionTokens sets the number of completion tokens estimated for an LLM node without a
+// stub or fixture. By default the agent's MaxTokens is used, giving an upper bound.
+func WithCompletionTokens(n int) DryRunOption {
+	return func(o *dryRunOptions) {
+		o.completionTokens = n
+	}
+}
+
+// ExecuteFuncs makes the dry run execute FuncNodes instead of passing their input through.
+// Use it when the functions are pure transformations that later nodes depend on.
+func ExecuteFuncs() DryRunOption {
+	return func(o *dryRunOptions) {
+		o.executeFuncs = true
+	}
+}
+
+// Sources of the output of a planned step.
+const (
+	SourceStub        = "stub"
+	SourceFixture     = "fixture"
+	SourcePlaceholder = "placeholder"
+	SourceExecuted    = "executed"
+)
+
+// PlannedStep is a node visited by a dry run.
+type PlannedStep struct {
+	Path  string // Position in the topology, e.g. "2" or "2.1" for the first child of node 2.
+	Node  string // Label of the node, as in Flow.Visualize.
+	Model string // Model of LLM nodes.
+	Input string
+	// Output and Source are empty for nodes that only route to their children, such as
+	// conditional and parallel nodes; their children are listed as separate steps.
+	Output string
+	Source string    // How Output was obtained: SourceStub, SourceFixture, SourcePlaceholder or SourceExecuted.
+	Usage  llm.Usage // Estimated token usage.
+	Cost   float64   // Estimated cost; zero if the model has no known price.
+}
+
+// DryRunReport is the outcome of Flow.DryRun.
+type DryRunReport struct {
+	Steps  []PlannedStep // Visited nodes, in execution order.
+	Output string        // Final output of the simulated run.
+	Usage  llm.Usage     // Total estimated token usage.
+	Cost   float64       // Total estimated cost.
+}
+
+// DryRun walks the flow like Run without calling LLMs or tools. LLM and tool nodes return a
+// placeholder unless stubbed or replayed from a fixture; conditions, merges and templates are
+// evaluated for real. The report lists the execution order with the estimated tokens and cost
+// of every LLM call; prompt tokens are counted on the prompt the agent would send. Balancing
+// nodes are planned with their first child and retry nodes with a single attempt.
+func (f *Flow) DryRun(ctx context.Context, input string, opts ...DryRunOption) (*DryRunReport, error) {
+	o := dryRunOptions{stubs: make(map[Node]StubFunc), prices: make(map[string]Price)}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	d := &dryRun{opts: o, report: &DryRunReport{}}
+	ctx = withState(ctx, f.Vars)
+	output := input
+	for i, node := range f.Nodes {
+		var step *StepRecord
+		if o.fixture != nil && i < len(o.fixture.Steps) {
+			step = &o.fixture.Steps[i]
+		}
+		var err error
+		if output, err = d.visit(ctx, node, output, strconv.Itoa(i+1), step); err != nil {
+			return d.report, fmt.Errorf("dry run: error at step %d: %w", i, err)
+		}
+	}
+	d.report.Output = output
+	return d.report, nil
+}
+
+type dryRun struct {
+	opts   dryRunOptions
+	report *DryRunReport
+}
+
+// visit simulates node. fixture is the recorded result of the node, if any.
+func (d *dryRun) visit(ctx context.Context, node Node, input, path string, fixture *StepRecord) (string, error) {
+	step := PlannedStep{Path: path, Node: nodeLabel(node), Input: input}
+	if n, ok := node.(*LLMNode); ok {
+		step.Model = n.Agent.ModelName()
+	}
+
+	if stub, ok := d.opts.stubs[node]; ok {
+		output, err := stub(input)
+		if err != nil {
+			return "", err
+		}
+		step.Output, step.Source = output, SourceStub
+		d.add(ctx, node, step, nil)
+		return output, nil
+	}
+	if fixture != nil {
+		if fixture.Error != "" {
+			return "", fmt.Errorf("recorded error: %s", fixture.Error)
+		}
+		step.Output, step.Source = fixture.Output, SourceFixture
+		d.add(ctx, node, step, &fixture.Usage)
+		return fixture.Output, nil
+	}
+
+	switch n := node.(type) {
+	case *NamedNode:
+		output, err := d.visit(ctx, n.Node, input, path, nil)
+		if err == nil {
+			StateFromContext(ctx).SetNode(n.Name, input, output)
+		}
+		return output, err
+
+	case *FallbackNode:
+		output, err := d.visit(ctx, n.Node, input, path, nil)
+		if n.handles(ctx, err) {
+			return d.visit(contextWithError(ctx, err), n.Fallback, input, path+".error", nil)
+		}
+		return output, err
+
+	case *FinallyNode:
+		output, err := d.visit(ctx, n.Node, input, path, nil)
+		if n.Finally != nil {
+			finallyInput := output
+			if err != nil {
+				finallyInput = input
+			}
+			d.visit(ctx, n.Finally, finallyInput, path+".finally", nil)
+		}
+		return output, err
+
+	case *LLMNode, *ToolNode, *RetrievalNode:
+		step.Output, step.Source = fmt.Sprintf("<%s output of step %s>", step.Node, path), SourcePlaceholder
+		d.add(ctx, node, step, nil)
+		return step.Output, nil
+
+	case *FuncNode:
+		step.Output, step.Source = input, SourcePlaceholder
+		if d.opts.executeFuncs {
+			output, err := n.Process(ctx, input)
+			if err != nil {
+				return "", err
+			}
+			step.Output, step.Source = output, SourceExecuted
+		}
+		d.add(ctx, node, step, nil)
+		return step.Output, nil
+
+	case *ConditionalNode:
+		d.add(ctx, node, step, nil)
+		if n.Condition(input) {
+			return d.visit(ctx, n.TrueNode, input, path+".1", nil)
+		}
+		if n.FalseNode != nil {
+			return d.visit(ctx, n.FalseNode, input, path+".2", nil)
+		}
+		return input, nil
+
+	case *ParallelNode:
+		d.add(ctx, node, step, nil)
+		results := make([]NodeResult, len(n.Nodes))
+		for i, child := range n.Nodes {
+			output, err := d.visit(ctx, child, input, path+"."+strconv.Itoa(i+1), nil)
+			if err != nil && n.FailFast {
+				return "", err
+			}
+			results[i] = NodeResult{Index: i, Name: nodeLabel(child), Output: output, Err: err}
+		}
+		switch n.Merger.(type) {
+		case Synthesize, *Synthesize:
+			// The merge would call the model.
+			return fmt.Sprintf("<synthesized output of step %s>", path), nil
+		}
+		return n.merge(ctx, input, results)
+
+	case *BalancingNode:
+		d.add(ctx, node, step, nil)
+		if len(n.Nodes) == 0 {
+			return "", fmt.Errorf("balancing node: no nodes provided")
+		}
+		return d.visit(ctx, n.Nodes[0], input, path+".1", nil)
+
+	case *RetryNode:
+		d.add(ctx, node, step, nil)
+		return d.visit(ctx, n.Node, input, path+".1", nil)
+
+	case *Flow:
+		d.add(ctx, node, step, nil)
+		ctx = withState(ctx, n.Vars)
+		output := input
+		for i, child := range n.Nodes {
+			var err error
+			if output, err = d.visit(ctx, child, output, path+"."+strconv.Itoa(i+1), nil); err != nil {
+				return "", err
+			}
+		}
+		return output, nil
+	}
+
+	// Unknown nodes are opaque and may call anything, so they are not executed.
+	step.Output, step.Source = input, SourcePlaceholder
+	d.add(ctx, node, step, nil)
+	return input, nil
+}
+
+// add appends a step to the report, estimating the usage of LLM nodes unless it is known.
+func (d *dryRun) add(ctx context.Context, node Node, step PlannedStep, usage *llm.Usage) {
+	if n, ok := node.(*LLMNode); ok {
+		if usage != nil {
+			step.Usage = *usage
+		} else {
+			step.Usage = d.estimate(ctx, n, step)
+		}
+		if price, ok := d.opts.prices[step.Model]; ok {
+			step.Cost = price.Cost(step.Usage)
+		} else if caps, ok := n.Agent.Capabilities(); ok {
+			step.Cost = caps.Cost(step.Usage)
+		}
+	}
+	d.report.Steps = append(d.report.Steps, step)
+	d.report.Usage = d.report.Usage.Add(step.Usage)
+	d.report.Cost += step.Cost
+}
+
+// estimate counts the tokens of the prompt the node's agent would send, and of the output if it
+// is known.
+func (d *dryRun) estimate(ctx context.Context, n *LLMNode, step PlannedStep) llm.Usage {
+	var u llm.Usage
+	if msg, err := n.message(ctx, step.Input); err == nil {
+		prompt := n.Agent.PreviewPrompt(ctx, []agent.ConversationMessage{{Role: "User", Content: msg}})
+		u.PromptTokens = tokenizer.CountTokens(step.Model, prompt)
+	}
+	switch {
+	case step.Source == SourceStub:
+		u.CompletionTokens = tokenizer.CountTokens(step.Model, step.Output)
+	case d.opts.completionTokens > 0:
+		u.CompletionTokens = d.opts.completionTokens
+	default:
+		u.CompletionTokens = n.Agent.MaxTokens
+	}
+	u.TotalTokens = u.PromptTokens + u.CompletionTokens
+	return u
+}
@@ -0,0 +1,105 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+
+	"github.com/zakirkun/gatot-kaca/logging"
+)
+
+type errorKey struct{}
+
+// contextWithError returns a copy of ctx carrying the error being handled.
+func contextWithError(ctx context.Context, err error) context.Context {
+	return context.WithValue(ctx, errorKey{}, err)
+}
+
+// ErrorFromContext returns the error being handled by a fallback or finally node, or nil.
+func ErrorFromContext(ctx context.Context) error {
+	err, _ := ctx.Value(errorKey{}).(error)
+	return err
+}
+
+// Static returns a node that always outputs text, e.g. as a fallback.
+func Static(text string) Node {
+	return &FuncNode{Process: func(ctx context.Context, input string) (string, error) {
+		return text, nil
+	}}
+}
+
+// FallbackNode runs Node and, if it fails, runs Fallback with the same input instead, so that a
+// failing step degrades gracefully rather than aborting the flow. The fallback can read the
+// error with ErrorFromContext; use Static for a fixed fallback output.
+type FallbackNode struct {
+	Node     Node
+	Fallback Node
+	// When, if set, limits the fallback to the errors it returns true for.
+	When func(err error) bool
+}
+
+// Execute runs the node, falling back on failure. Cancellation is never handled by the fallback.
+func (n *FallbackNode) Execute(ctx context.Context, input string) (string, error) {
+	output, err := n.Node.Execute(ctx, input)
+	if !n.handles(ctx, err) {
+		return output, err
+	}
+	logging.FromContext(ctx).Info("node failed, running fallback", logging.KeyComponent, "workflow",
+		logging.KeyNode, nodeLabel(n.Node), logging.KeyError, err)
+	return n.Fallback.Execute(contextWithError(ctx, err), input)
+}
+
+func (n *FallbackNode) handles(ctx context.Context, err error) bool {
+	if err == nil || n.Fallback == nil || ctx.Err() != nil {
+		return false
+	}
+	return n.When == nil || n.When(err)
+}
+
+// FinallyNode runs Node and then always runs Finally, for cleanup. Finally receives the output
+// of Node, or its input if it failed, and can read the error with ErrorFromContext. The result
+// of Node is returned; an error from Finally is only returned if Node succeeded.
+type FinallyNode struct {
+	Node    Node
+	Finally Node
+}
+
+// Execute runs the node and then the cleanup node.
+func (n *FinallyNode) Execute(ctx context.Context, input string) (string, error) {
+	output, err := n.Node.Execute(ctx, input)
+	if ferr := runFinally(ctx, n.Finally, input, output, err); ferr != nil && err == nil {
+		return "", ferr
+	}
+	return output, err
+}
+
+// runFinally runs a cleanup node after a node or flow that produced output or failed with err.
+// It runs even if ctx was cancelled.
+func runFinally(ctx context.Context, finally Node, input, output string, err error) error {
+	if finally == nil {
+		return nil
+	}
+	ctx = context.WithoutCancel(ctx)
+	if err != nil {
+		ctx = contextWithError(ctx, err)
+		output = input
+	}
+	if _, ferr := finally.Execute(ctx, output); ferr != nil {
+		logging.FromContext(ctx).Warn("finally node failed", logging.KeyComponent, "workflow", logging.KeyError, ferr)
+		return ferr
+	}
+	return nil
+}
+
+// handleError runs the flow's OnError node for a step that failed with err on input. It returns
+// the fallback output and true if the error was handled.
+func (f *Flow) handleError(ctx context.Context, input string, err error) (string, bool) {
+	if f.OnError == nil || errors.Is(err, context.Canceled) || ctx.Err() != nil {
+		return "", false
+	}
+	output, ferr := f.OnError.Execute(contextWithError(ctx, err), input)
+	if ferr != nil {
+		logging.FromContext(ctx).Warn("error handler node failed", logging.KeyComponent, "workflow", logging.KeyError, ferr)
+		return "", false
+	}
+	return output, true
+}
@@ -4,11 +4,47 @@ import (
 	"context"
 	"fmt"
 	"time"
+
+	"github.com/zakirkun/gatot-kaca/budget"
+	"github.com/zakirkun/gatot-kaca/logging"
 )
 
 // Flow represents a sequence of workflow nodes executed in order.
 type Flow struct {
 	Nodes []Node
+	// Name optionally identifies the flow in logs and runtime introspection.
+	Name string
+	// Tracker optionally records in-progress runs of the flow.
+	Tracker *RunTracker
+	// Store optionally persists every run of the flow, with per-node details.
+	Store RunStore
+	// Vars are variables available to node templates as {{.vars.<key>}}. Nested flows add
+	// their variables to the state of the enclosing run without overriding existing ones.
+	Vars map[string]any
+	// OnError, if set, runs with the input of a node that fails, and its output becomes the
+	// output of the run instead of the error; the remaining nodes are skipped. It can read the
+	// error with ErrorFromContext. Use FallbackNode to handle the errors of a single node.
+	OnError Node
+	// Finally, if set, always runs at the end of a run for cleanup, with the output of the run
+	// or, if it failed, the input of the failed node. Its output is ignored. A failed run first
+	// runs the compensations registered by its nodes (see CompensableNode) in reverse order;
+	// failures handled by OnError are not compensated.
+	Finally Node
+	// StreamBuffer is the number of chunks buffered between nodes by RunStream; defaults to
+	// DefaultStreamBuffer.
+	StreamBuffer int
+	// Logger, if set, receives the flow's logs and is passed to its nodes through the context;
+	// otherwise the logger of the context is used (see logging.FromContext).
+	Logger logging.Logger
+	// Budget limits every run of the flow: its wall time and the LLM calls, tool calls and
+	// tokens of its nodes. The budget is checked before every node; a run that exceeds it fails
+	// with a *budget.BudgetExceeded whose Partial is the output of the last completed node.
+	Budget budget.RunBudget
+	// PartialResults makes Run return, when a node fails, the output of the last completed node
+	// (or the initial input) together with a *StepError holding the outputs of the completed
+	// nodes and the run state, instead of an empty output and the node's error. The run can be
+	// resumed from the failed node with RunFrom.
+	PartialResults bool
 }
 
 // NewFlow creates a new Flow instance with the provided nodes.
@@ -20,20 +56,84 @@ func NewFlow(nodes []Node) *Flow {
 
 // Run executes each node in the flow sequentially.
 // The output from one node is passed as input to the next.
+// If the flow has a Store, the run is executed with RunDetailed so that it can be recorded.
+// A failed node's error is wrapped in a *StepError only with PartialResults, whether or not
+// the flow has a Store.
 func (f *Flow) Run(ctx context.Context, initialInput string) (string, error) {
+	if f.Store != nil {
+		result, err := f.RunDetailed(ctx, initialInput)
+		if stepErr, ok := err.(*StepError); ok {
+			if f.PartialResults {
+				return stepErr.Input, err
+			}
+			return "", stepErr.Err
+		}
+		return result.Output, err
+	}
+	return f.RunFrom(ctx, 0, initialInput)
+}
+
+// RunFrom executes the nodes of the flow from the node at index step on, with input as the
+// input of that node. It resumes a run that failed with a *StepError from its Step and Input;
+// pass the error's State with ContextWithState to restore the outputs of the named nodes that
+// completed before. RunFrom does not save the run to the flow's Store.
+func (f *Flow) RunFrom(ctx context.Context, step int, input string) (string, error) {
+	if step < 0 || step > len(f.Nodes) {
+		return "", fmt.Errorf("step %d out of range for a flow of %d nodes", step, len(f.Nodes))
+	}
+	initialInput := input
+
+	ctx = f.runContext(ctx)
+	ctx, run := budget.Start(ctx, f.Budget)
+	defer run.Stop()
+	ctx, saga, ownSaga := withSaga(ctx)
+	runID := newRunID()
+	f.Tracker.start(runID, f.Name, len(f.Nodes))
+	defer f.Tracker.finish(runID)
+
 	currentInput := initialInput
-	var err error
-	for _, node := range f.Nodes {
-		currentInput, err = node.Execute(ctx, currentInput)
+	var completed []StepResult
+	for i := step; i < len(f.Nodes); i++ {
+		node := f.Nodes[i]
+		f.Tracker.step(runID, i)
+		start := time.Now()
+		output, err := executeStep(ctx, node, currentInput)
+		duration := time.Since(start)
+		f.logStep(ctx, node, duration, -1, err)
 		if err != nil {
+			if output, ok := f.handleError(ctx, currentInput, err); ok {
+				runFinally(ctx, f.Finally, initialInput, output, nil)
+				return output, nil
+			}
+			if ownSaga {
+				saga.compensate(ctx, err)
+			}
+			runFinally(ctx, f.Finally, currentInput, "", err)
+			err = run.Err(err, currentInput)
+			if f.PartialResults {
+				return currentInput, &StepError{Step: i, Node: nodeLabel(node), Input: currentInput,
+					Completed: completed, State: StateFromContext(ctx), Err: err}
+			}
 			return "", err
 		}
+		completed = append(completed, StepResult{Index: i, Node: nodeLabel(node), Input: currentInput, Output: output, Duration: duration})
+		currentInput = output
 	}
+	runFinally(ctx, f.Finally, initialInput, currentInput, nil)
 	return currentInput, nil
 }
 
+// Execute runs the flow, so that a Flow can be used as a node of another flow.
+func (f *Flow) Execute(ctx context.Context, input string) (string, error) {
+	return f.Run(ctx, input)
+}
+
 // RunWithLogging is an enhanced version of Run that logs the output of each node.
+//
+// Deprecated: Use RunDetailedWithCallback, which reports every step as a StepResult with its
+// duration, token usage, retries and error.
 func (f *Flow) RunWithLogging(ctx context.Context, initialInput string, logger func(step int, output string)) (string, error) {
+	ctx = f.runContext(ctx)
 	currentInput := initialInput
 	var err error
 	for i, node := range f.Nodes {
@@ -49,7 +149,11 @@ func (f *Flow) RunWithLogging(ctx context.Context, initialInput string, logger f
 }
 
 // RunWithDetailedLogging logs the output and the execution duration of each node.
+//
+// Deprecated: Use RunDetailedWithCallback, which reports every step as a StepResult with its
+// duration, token usage, retries and error.
 func (f *Flow) RunWithDetailedLogging(ctx context.Context, initialInput string, logger func(step int, output string, duration time.Duration)) (string, error) {
+	ctx = f.runContext(ctx)
 	currentInput := initialInput
 	var err error
 	for i, node := range f.Nodes {
@@ -65,3 +169,45 @@ func (f *Flow) RunWithDetailedLogging(ctx context.Context, initialInput string,
 	}
 	return currentInput, nil
 }
+
+// saveRun records a run in the flow's store, if any. Failures are logged rather than returned,
+// so that persistence problems do not fail the run itself.
+func (f *Flow) saveRun(ctx context.Context, result *FlowResult) {
+	if f.Store == nil {
+		return
+	}
+	if err := f.Store.SaveRun(context.WithoutCancel(ctx), NewRunRecord(f.Name, result)); err != nil {
+		logging.FromContext(ctx).Warn("failed to save run", logging.KeyComponent, "workflow", "flow", f.Name,
+			"run_id", result.RunID, logging.KeyError, err)
+	}
+}
+
+// runContext returns ctx prepared for a run of the flow, with its variables and logger.
+func (f *Flow) runContext(ctx context.Context) context.Context {
+	ctx = withState(ctx, f.Vars)
+	if f.Logger != nil {
+		ctx = logging.NewContext(ctx, f.Logger)
+	}
+	return ctx
+}
+
+// executeStep executes a node of a run, unless the run is out of budget.
+func executeStep(ctx context.Context, node Node, input string) (string, error) {
+	if err := budget.FromContext(ctx).Check(); err != nil {
+		return "", err
+	}
+	return node.Execute(ctx, input)
+}
+
+// logStep logs the execution of a node of the flow. Negative tokens are left out.
+func (f *Flow) logStep(ctx context.Context, node Node, d time.Duration, tokens int, err error) {
+	args := []any{logging.KeyComponent, "workflow", "flow", f.Name, logging.KeyNode, nodeLabel(node), logging.KeyDuration, d}
+	if tokens >= 0 {
+		args = append(args, logging.KeyTokens, tokens)
+	}
+	if err != nil {
+		logging.FromContext(ctx).Debug("node failed", append(args, logging.KeyError, err)...)
+		return
+	}
+	logging.FromContext(ctx).Debug("node finished", args...)
+}
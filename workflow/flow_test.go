@@ -0,0 +1,39 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestFlowRunStepError(t *testing.T) {
+	boom := errors.New("boom")
+	for _, store := range []bool{false, true} {
+		for _, partial := range []bool{false, true} {
+			f := NewFlow([]Node{
+				&FuncNode{Process: func(ctx context.Context, input string) (string, error) { return input + "!", nil }},
+				&failingNode{failures: 1, err: boom},
+			})
+			f.PartialResults = partial
+			if store {
+				f.Store = NewMemoryRunStore()
+			}
+
+			out, err := f.Run(context.Background(), "hi")
+			if !errors.Is(err, boom) {
+				t.Fatalf("store=%v partial=%v: got %v, want the node's error", store, partial, err)
+			}
+			var stepErr *StepError
+			wrapped := errors.As(err, &stepErr)
+			if !partial {
+				if wrapped || out != "" {
+					t.Errorf("store=%v: got %q, %#v; want no output and the node's error", store, out, err)
+				}
+				continue
+			}
+			if !wrapped || out != "hi!" || stepErr.Step != 1 || len(stepErr.Completed) != 1 {
+				t.Errorf("store=%v partial: got %q, %#v; want the last output and a *StepError", store, out, err)
+			}
+		}
+	}
+}
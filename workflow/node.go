@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/zakirkun/gatot-kaca/agent"
+	"github.com/zakirkun/gatot-kaca/prompt"
 )
 
 // Node defines an interface for a step in the wordflow workflow.
@@ -17,16 +18,33 @@ type LLMNode struct {
 	Agent *agent.Agent
 	// Message is a static instruction or prefix for the node.
 	Message string
+	// Template, if set, replaces Message. It is rendered with Vars plus the node input as "Input",
+	// the named node results of the run as "nodes" and the flow variables as "vars" (see
+	// RunState), and the input is not appended automatically.
+	Template *prompt.Template
+	Vars     map[string]any
 }
 
 // Execute resets the agent’s conversation, sends the prompt, and returns its response.
 func (n *LLMNode) Execute(ctx context.Context, input string) (string, error) {
 	n.Agent.Reset()
+	text, err := n.message(ctx, input)
+	if err != nil {
+		return "", err
+	}
+	return n.Agent.Send(ctx, text)
+}
+
+// message returns the user message the node sends for input.
+func (n *LLMNode) message(ctx context.Context, input string) (string, error) {
+	if n.Template != nil {
+		return renderNodeTemplate(ctx, n.Template, n.Vars, input)
+	}
 	prompt := n.Message
 	if input != "" {
 		prompt += "\n" + input
 	}
-	return n.Agent.Send(ctx, prompt)
+	return prompt, nil
 }
 
 // ToolNode is a workflow step that calls a registered tool via the agent.
@@ -37,12 +55,23 @@ type ToolNode struct {
 	ToolName string
 	// Instruction is an optional static instruction to accompany the input.
 	Instruction string
+	// Template, if set, replaces Instruction and is rendered like an LLMNode template; the
+	// input is not appended automatically.
+	Template *prompt.Template
+	Vars     map[string]any
 }
 
 // Execute resets the agent’s conversation, calls the tool with the provided instruction and input,
 // and then returns the tool’s response.
 func (n *ToolNode) Execute(ctx context.Context, input string) (string, error) {
 	n.Agent.Reset()
+	if n.Template != nil {
+		instruct, err := renderNodeTemplate(ctx, n.Template, n.Vars, input)
+		if err != nil {
+			return "", err
+		}
+		return n.Agent.CallTool(ctx, n.ToolName, instruct)
+	}
 	instruct := n.Instruction
 	if input != "" {
 		instruct += "\n" + input
@@ -80,3 +109,16 @@ func (n *ConditionalNode) Execute(ctx context.Context, input string) (string, er
 	// If no false branch is provided, return the input unchanged.
 	return input, nil
 }
+
+// renderNodeTemplate renders a node template with vars, the input as "Input" and the run state.
+func renderNodeTemplate(ctx context.Context, t *prompt.Template, vars map[string]any, input any) (string, error) {
+	nodes, flowVars := StateFromContext(ctx).templateData()
+	data := make(map[string]any, len(vars)+3)
+	for k, v := range vars {
+		data[k] = v
+	}
+	data["Input"] = input
+	data["nodes"] = nodes
+	data["vars"] = flowVars
+	return t.Render(data)
+}
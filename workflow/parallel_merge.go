@@ -0,0 +1,168 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/zakirkun/gatot-kaca/agent"
+	"github.com/zakirkun/gatot-kaca/prompt"
+)
+
+// Merger combines the results of the children of a ParallelNode into its output. Results are in
+// the order of ParallelNode.Nodes and include the failed children, at least one of which
+// succeeded.
+type Merger interface {
+	Merge(ctx context.Context, input string, results []NodeResult) (string, error)
+}
+
+// MergerFunc adapts a function to the Merger interface.
+type MergerFunc func(ctx context.Context, input string, results []NodeResult) (string, error)
+
+// Merge calls the function.
+func (f MergerFunc) Merge(ctx context.Context, input string, results []NodeResult) (string, error) {
+	return f(ctx, input, results)
+}
+
+// errNoSuccess is returned by the mergers when every child failed.
+var errNoSuccess = errors.New("no successful results to merge")
+
+// Join joins the successful outputs with Separator, which defaults to a newline. It is the
+// default merge of a ParallelNode.
+type Join struct {
+	Separator string
+}
+
+// Merge implements Merger.
+func (j Join) Merge(ctx context.Context, input string, results []NodeResult) (string, error) {
+	sep := j.Separator
+	if sep == "" {
+		sep = "\n"
+	}
+	outputs := make([]string, 0, len(results))
+	for _, res := range results {
+		if !res.Failed() {
+			outputs = append(outputs, res.Output)
+		}
+	}
+	return strings.Join(outputs, sep), nil
+}
+
+// FirstSuccess outputs the result of the first child, in node order, that succeeded. With
+// Fastest, it outputs that of the child that finished first instead.
+type FirstSuccess struct {
+	Fastest bool
+}
+
+// Merge implements Merger.
+func (f FirstSuccess) Merge(ctx context.Context, input string, results []NodeResult) (string, error) {
+	best := -1
+	for i, res := range results {
+		if res.Failed() {
+			continue
+		}
+		if best < 0 || f.Fastest && res.Duration < results[best].Duration {
+			best = i
+		}
+	}
+	if best < 0 {
+		return "", errNoSuccess
+	}
+	return results[best].Output, nil
+}
+
+// Longest outputs the longest successful output. Ties go to the earlier child.
+type Longest struct{}
+
+// Merge implements Merger.
+func (Longest) Merge(ctx context.Context, input string, results []NodeResult) (string, error) {
+	best := -1
+	for i, res := range results {
+		if !res.Failed() && (best < 0 || len(res.Output) > len(results[best].Output)) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return "", errNoSuccess
+	}
+	return results[best].Output, nil
+}
+
+// JSONArray outputs a JSON array with an object for every successful child holding its
+// "index", "node" label and "output". With IncludeFailed, failed children are listed too, with
+// their "error" instead of an output.
+type JSONArray struct {
+	IncludeFailed bool
+}
+
+// jsonResult is the JSON form of a NodeResult.
+type jsonResult struct {
+	Index  int    `json:"index"`
+	Node   string `json:"node"`
+	Output string `json:"output,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Merge implements Merger.
+func (j JSONArray) Merge(ctx context.Context, input string, results []NodeResult) (string, error) {
+	items := make([]jsonResult, 0, len(results))
+	for _, res := range results {
+		item := jsonResult{Index: res.Index, Node: res.Name, Output: res.Output}
+		if res.Failed() {
+			if !j.IncludeFailed {
+				continue
+			}
+			item.Error = res.Err.Error()
+		}
+		items = append(items, item)
+	}
+	data, err := json.Marshal(items)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// DefaultSynthesizeTemplate asks the model to combine the responses of the children into one
+// answer. It is rendered with the node "Input" and the successful "Results", a []NodeResult.
+var DefaultSynthesizeTemplate = prompt.Must("synthesize", `Several responses were written for the task below. Combine them into a single answer that keeps every correct and relevant point, resolves contradictions and leaves out repetition. Reply with the combined answer only.
+
+Task:
+{{.Input}}
+{{range .Results}}
+Response of node {{.Index}} ({{.Name}}):
+{{.Output}}
+{{end}}`)
+
+// Synthesize asks a model to write the output from the successful results of the children.
+type Synthesize struct {
+	// Agent writes the merged answer in a fresh conversation, so it must not be shared with
+	// a child of the node.
+	Agent *agent.Agent
+	// Template defaults to DefaultSynthesizeTemplate.
+	Template *prompt.Template
+}
+
+// Merge implements Merger.
+func (s Synthesize) Merge(ctx context.Context, input string, results []NodeResult) (string, error) {
+	tmpl := s.Template
+	if tmpl == nil {
+		tmpl = DefaultSynthesizeTemplate
+	}
+	var succeeded []NodeResult
+	for _, res := range results {
+		if !res.Failed() {
+			succeeded = append(succeeded, res)
+		}
+	}
+	if len(succeeded) == 0 {
+		return "", errNoSuccess
+	}
+	text, err := tmpl.Render(map[string]any{"Input": input, "Results": succeeded})
+	if err != nil {
+		return "", err
+	}
+	s.Agent.Reset()
+	return s.Agent.Send(ctx, text)
+}
@@ -5,59 +5,158 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"time"
+
+	"github.com/zakirkun/gatot-kaca/logging"
 )
 
 // ParallelNode is a workflow node that executes multiple child nodes concurrently and merges their outputs.
-// The MergeFunc function combines the outputs of individual nodes; if not provided, outputs are joined with newlines.
+// The Merger combines the results of individual nodes; if not provided, outputs are joined with newlines.
 // The FailFast flag indicates whether to return immediately as soon as one child node fails.
+//
+// Without FailFast, failed children are logged and passed to the merge with their errors, but if
+// every child fails the node returns a *ParallelError without merging. Earlier versions merged
+// the empty outputs and succeeded instead.
 type ParallelNode struct {
-	Nodes     []Node
-	MergeFunc func([]string) string // Optional merge function.
-	FailFast  bool                  // If true, stops execution as soon as a child node returns an error.
+	Nodes []Node
+	// MergeFunc is an optional merge function receiving the outputs of the children.
+	//
+	// Deprecated: Use Merger, which receives the status of each child.
+	MergeFunc func([]string) string
+	FailFast  bool // If true, stops execution as soon as a child node returns an error.
+	// Merger optionally combines the results of the children, e.g. FirstSuccess, Longest,
+	// JSONArray or Synthesize, or a MergerFunc receiving the status of each child; defaults to
+	// Join. When set, it takes precedence over MergeFunc.
+	Merger Merger
+}
+
+// NodeResult holds the outcome of a single child node executed by a ParallelNode.
+type NodeResult struct {
+	Index    int           // Position of the child in ParallelNode.Nodes.
+	Name     string        // Label of the child, as shown by Visualize.
+	Output   string        // Output of the child; empty if it failed.
+	Err      error         // Error returned by the child, if any.
+	Duration time.Duration // Wall time spent executing the child.
+}
+
+// Failed reports whether the child node returned an error.
+func (r NodeResult) Failed() bool {
+	return r.Err != nil
+}
+
+// ParallelResult is the structured outcome of a ParallelNode execution.
+type ParallelResult struct {
+	Output  string       // The merged output.
+	Results []NodeResult // Per-child results, in the order of ParallelNode.Nodes.
+}
+
+// Failed returns the results of the children that returned an error.
+func (r ParallelResult) Failed() []NodeResult {
+	var failed []NodeResult
+	for _, res := range r.Results {
+		if res.Failed() {
+			failed = append(failed, res)
+		}
+	}
+	return failed
+}
+
+// Succeeded returns the results of the children that completed without error.
+func (r ParallelResult) Succeeded() []NodeResult {
+	var ok []NodeResult
+	for _, res := range r.Results {
+		if !res.Failed() {
+			ok = append(ok, res)
+		}
+	}
+	return ok
+}
+
+// Partial reports whether some, but not all, children failed.
+func (r ParallelResult) Partial() bool {
+	failed := len(r.Failed())
+	return failed > 0 && failed < len(r.Results)
+}
+
+// ParallelError is returned when every child of a ParallelNode fails.
+type ParallelError struct {
+	Results []NodeResult
+}
+
+// Error implements the error interface.
+func (e *ParallelError) Error() string {
+	msgs := make([]string, 0, len(e.Results))
+	for _, res := range e.Results {
+		msgs = append(msgs, fmt.Sprintf("node %d: %v", res.Index, res.Err))
+	}
+	return "parallel node: all nodes failed: " + strings.Join(msgs, "; ")
 }
 
 // Execute runs all child nodes concurrently with the given input and merges their results.
 func (pn *ParallelNode) Execute(ctx context.Context, input string) (string, error) {
+	res, err := pn.ExecuteDetailed(ctx, input)
+	if err != nil {
+		return "", err
+	}
+	return res.Output, nil
+}
+
+// ExecuteDetailed runs all child nodes concurrently and returns the merged output together
+// with the status of every child. When FailFast is false, failed children are reported in
+// the result instead of aborting the node; an error is only returned if every child fails.
+func (pn *ParallelNode) ExecuteDetailed(ctx context.Context, input string) (ParallelResult, error) {
 	if len(pn.Nodes) == 0 {
-		return "", fmt.Errorf("parallel node: no nodes provided")
+		return ParallelResult{}, fmt.Errorf("parallel node: no nodes provided")
 	}
 
-	results := make([]string, len(pn.Nodes))
-	errs := make([]error, len(pn.Nodes))
+	results := make([]NodeResult, len(pn.Nodes))
 	var wg sync.WaitGroup
 	wg.Add(len(pn.Nodes))
 
 	for i, node := range pn.Nodes {
 		go func(i int, n Node) {
 			defer wg.Done()
+			start := time.Now()
 			res, err := n.Execute(ctx, input)
-			results[i] = res
-			errs[i] = err
+			results[i] = NodeResult{Index: i, Name: nodeLabel(n), Output: res, Err: err, Duration: time.Since(start)}
 		}(i, node)
 	}
 
 	wg.Wait()
 
-	if pn.FailFast {
-		for _, err := range errs {
-			if err != nil {
-				return "", err
-			}
-		}
-	} else {
-		// Log warnings for errors but continue.
-		for i, err := range errs {
-			if err != nil {
-				fmt.Printf("Warning: node %d returned error: %v\n", i, err)
+	failed := 0
+	for _, res := range results {
+		if res.Failed() {
+			if pn.FailFast {
+				return ParallelResult{Results: results}, res.Err
 			}
+			logging.FromContext(ctx).Warn("parallel branch failed", logging.KeyComponent, "workflow",
+				logging.KeyNode, nodeLabel(pn.Nodes[res.Index]), "index", res.Index, logging.KeyError, res.Err)
+			failed++
 		}
 	}
+	if failed == len(results) {
+		return ParallelResult{Results: results}, &ParallelError{Results: results}
+	}
 
-	// Merge the results.
-	if pn.MergeFunc != nil {
-		return pn.MergeFunc(results), nil
+	output, err := pn.merge(ctx, input, results)
+	if err != nil {
+		return ParallelResult{Results: results}, fmt.Errorf("parallel node: merge: %w", err)
 	}
+	return ParallelResult{Output: output, Results: results}, nil
+}
 
-	// Default merge: combine outputs with newline delimiters.
-	return strings.Join(results, "\n"), nil
+// merge combines the child results using the configured merge function.
+func (pn *ParallelNode) merge(ctx context.Context, input string, results []NodeResult) (string, error) {
+	switch {
+	case pn.Merger != nil:
+		return pn.Merger.Merge(ctx, input, results)
+	case pn.MergeFunc != nil:
+		outputs := make([]string, len(results))
+		for i, res := range results {
+			outputs[i] = res.Output
+		}
+		return pn.MergeFunc(outputs), nil
+	}
+	return Join{}.Merge(ctx, input, results)
 }
@@ -0,0 +1,63 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParallelNodeMerger(t *testing.T) {
+	boom := errors.New("boom")
+	pn := &ParallelNode{
+		Nodes: []Node{
+			&FuncNode{Process: func(ctx context.Context, input string) (string, error) { return "a:" + input, nil }},
+			&failingNode{failures: 1, err: boom},
+		},
+		Merger: MergerFunc(func(ctx context.Context, input string, results []NodeResult) (string, error) {
+			var parts []string
+			for _, res := range results {
+				if res.Failed() {
+					parts = append(parts, "failed:"+res.Err.Error())
+				} else {
+					parts = append(parts, res.Output)
+				}
+			}
+			return strings.Join(parts, ","), nil
+		}),
+	}
+
+	res, err := pn.ExecuteDetailed(context.Background(), "x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Output != "a:x,failed:boom" || !res.Partial() || len(res.Failed()) != 1 {
+		t.Errorf("got %+v", res)
+	}
+}
+
+func TestParallelNodeAllFailed(t *testing.T) {
+	boom := errors.New("boom")
+	merged := false
+	pn := &ParallelNode{
+		Nodes: []Node{&failingNode{failures: 1, err: boom}, &failingNode{failures: 1, err: boom}},
+		MergeFunc: func(outputs []string) string {
+			merged = true
+			return strings.Join(outputs, "")
+		},
+	}
+
+	_, err := pn.Execute(context.Background(), "x")
+	var perr *ParallelError
+	if !errors.As(err, &perr) || len(perr.Results) != 2 {
+		t.Fatalf("got %v, want a *ParallelError with both results", err)
+	}
+	if merged {
+		t.Error("merged the outputs of failed children")
+	}
+
+	pn = &ParallelNode{Nodes: []Node{&failingNode{failures: 1, err: boom}}, FailFast: true}
+	if _, err := pn.Execute(context.Background(), "x"); !errors.Is(err, boom) {
+		t.Errorf("FailFast: got %v, want the child's error", err)
+	}
+}
@@ -0,0 +1,209 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zakirkun/gatot-kaca/agent"
+)
+
+// Plan is the structured plan a PlannerNode asks the model for.
+type Plan struct {
+	Steps []PlanStep `json:"steps" description:"The steps to carry out in order"`
+}
+
+// PlanStep is a single step of a plan.
+type PlanStep struct {
+	Description string `json:"description" description:"What the step must achieve"`
+	Tool        string `json:"tool,omitempty" description:"Name of the tool to call for this step, if any"`
+	Input       string `json:"input,omitempty" description:"Input for the tool"`
+}
+
+// StepStatus is the execution status of a plan step.
+type StepStatus string
+
+const (
+	StepPending StepStatus = "pending"
+	StepRunning StepStatus = "running"
+	StepDone    StepStatus = "done"
+	StepFailed  StepStatus = "failed"
+	StepSkipped StepStatus = "skipped"
+)
+
+// PlanStepResult reports the status of a plan step.
+type PlanStepResult struct {
+	Index    int
+	Step     PlanStep
+	Status   StepStatus
+	Output   string
+	Err      error
+	Duration time.Duration
+}
+
+// PlanResult holds the plan of a PlannerNode execution and the result of every step.
+type PlanResult struct {
+	Plan   Plan
+	Steps  []PlanStepResult
+	Output string // Output of the last successful step.
+}
+
+// PlannerNode decomposes its input into a plan of steps and executes them in order. The model
+// is asked for a structured plan listing, for every step, an optional tool to call. Steps with
+// a known tool run as a ToolNode, the others as an LLMNode that sees the task and the results
+// of the earlier steps. The planning prompt is built like an LLMNode's.
+type PlannerNode struct {
+	LLMNode
+	// Executor runs the steps and provides the tools; defaults to the node's agent.
+	Executor *agent.Agent
+	// MaxSteps limits the number of steps executed; defaults to 10.
+	MaxSteps int
+	// ContinueOnError runs the remaining steps after a step fails instead of skipping them.
+	ContinueOnError bool
+	// OnStep is called whenever the status of a step changes.
+	OnStep func(PlanStepResult)
+}
+
+// Label implements Labeler.
+func (n *PlannerNode) Label() string {
+	return "Planner"
+}
+
+func (n *PlannerNode) executor() *agent.Agent {
+	if n.Executor != nil {
+		return n.Executor
+	}
+	return n.Agent
+}
+
+// Execute plans and executes the steps and returns the output of the last successful step.
+func (n *PlannerNode) Execute(ctx context.Context, input string) (string, error) {
+	result, err := n.ExecuteDetailed(ctx, input)
+	if err != nil {
+		return "", err
+	}
+	return result.Output, nil
+}
+
+// ExecuteDetailed plans and executes the steps and reports the status of every step. It fails if
+// no plan can be made, or if a step fails and ContinueOnError is not set.
+func (n *PlannerNode) ExecuteDetailed(ctx context.Context, input string) (PlanResult, error) {
+	task, err := n.message(ctx, input)
+	if err != nil {
+		return PlanResult{}, err
+	}
+	plan, err := n.MakePlan(ctx, task)
+	if err != nil {
+		return PlanResult{}, err
+	}
+
+	result := PlanResult{Plan: plan, Steps: make([]PlanStepResult, len(plan.Steps))}
+	for i, step := range plan.Steps {
+		result.Steps[i] = PlanStepResult{Index: i, Step: step, Status: StepPending}
+	}
+
+	var firstErr error
+	for i := range result.Steps {
+		step := &result.Steps[i]
+		if firstErr != nil && !n.ContinueOnError {
+			step.Status = StepSkipped
+			n.report(*step)
+			continue
+		}
+
+		step.Status = StepRunning
+		n.report(*step)
+		start := time.Now()
+		step.Output, step.Err = n.stepNode(task, result.Steps[:i], step.Step).Execute(ctx, step.Step.Input)
+		step.Duration = time.Since(start)
+		if step.Err != nil {
+			step.Status = StepFailed
+			if firstErr == nil {
+				firstErr = fmt.Errorf("planner node: step %d (%s): %w", i+1, step.Step.Description, step.Err)
+			}
+		} else {
+			step.Status = StepDone
+			result.Output = step.Output
+		}
+		n.report(*step)
+	}
+	if firstErr != nil && !n.ContinueOnError {
+		return result, firstErr
+	}
+	return result, nil
+}
+
+// MakePlan asks the model for a plan for task.
+func (n *PlannerNode) MakePlan(ctx context.Context, task string) (Plan, error) {
+	var b strings.Builder
+	b.WriteString("Break the following task down into a short list of concrete steps.")
+	if tools := n.toolList(); tools != "" {
+		b.WriteString(" When a step is best done with one of these tools, name the tool and give its input:\n")
+		b.WriteString(tools)
+	} else {
+		b.WriteString(" No tools are available, so leave the tool empty.\n")
+	}
+	b.WriteString("\nTask:\n" + task)
+
+	var plan Plan
+	n.Agent.Reset()
+	if err := n.Agent.SendStructured(ctx, b.String(), &plan); err != nil {
+		return Plan{}, fmt.Errorf("planner node: %w", err)
+	}
+	if len(plan.Steps) == 0 {
+		return Plan{}, fmt.Errorf("planner node: the plan has no steps")
+	}
+	maxSteps := n.MaxSteps
+	if maxSteps <= 0 {
+		maxSteps = 10
+	}
+	if len(plan.Steps) > maxSteps {
+		plan.Steps = plan.Steps[:maxSteps]
+	}
+	return plan, nil
+}
+
+// toolList lists the executor's tools, one per line.
+func (n *PlannerNode) toolList() string {
+	manager := n.executor().Tools()
+	names := manager.ListTools()
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		if t, err := manager.GetTool(name); err == nil {
+			fmt.Fprintf(&b, "- %s: %s\n", name, t.Description())
+		}
+	}
+	return b.String()
+}
+
+// stepNode builds the node that executes step, given the results of the steps before it.
+func (n *PlannerNode) stepNode(task string, done []PlanStepResult, step PlanStep) Node {
+	exec := n.executor()
+	if step.Tool != "" {
+		if _, err := exec.Tools().GetTool(step.Tool); err == nil {
+			return &ToolNode{Agent: exec, ToolName: step.Tool}
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("You are carrying out a plan for this task:\n" + task + "\n")
+	for _, d := range done {
+		if d.Status == StepDone {
+			fmt.Fprintf(&b, "\nResult of step %d (%s):\n%s\n", d.Index+1, d.Step.Description, d.Output)
+		}
+	}
+	fmt.Fprintf(&b, "\nNow complete step %d: %s", len(done)+1, step.Description)
+	if step.Tool != "" {
+		fmt.Fprintf(&b, "\n(The suggested tool %q is not available; do the step yourself.)", step.Tool)
+	}
+	return &LLMNode{Agent: exec, Message: b.String()}
+}
+
+func (n *PlannerNode) report(step PlanStepResult) {
+	if n.OnStep != nil {
+		n.OnStep(step)
+	}
+}
@@ -0,0 +1,168 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/zakirkun/gatot-kaca/agent"
+	"github.com/zakirkun/gatot-kaca/eval"
+	"github.com/zakirkun/gatot-kaca/prompt"
+)
+
+// NoIssues is the reply the default critique prompt asks for when the answer has no flaws.
+const NoIssues = "NO ISSUES"
+
+// DefaultCritiqueTemplate asks the critic to list the flaws of an answer. It is rendered with
+// "Task" and "Answer".
+var DefaultCritiqueTemplate = prompt.Must("critique", `Review the answer to the task below. List every factual error, gap, unclear passage or failure to follow the task, one per line, with how to fix it. If the answer has no flaws, reply with "`+NoIssues+`" only.
+
+Task:
+{{.Task}}
+
+Answer:
+{{.Answer}}`)
+
+// DefaultReviseTemplate asks for an improved answer given a critique. It is rendered with
+// "Task", "Answer" and "Critique".
+var DefaultReviseTemplate = prompt.Must("revise", `Improve your answer to the task below by addressing every point of the critique. Reply with the improved answer only.
+
+Task:
+{{.Task}}
+
+Previous answer:
+{{.Answer}}
+
+Critique:
+{{.Critique}}`)
+
+// ReflectionNode is an LLM step that generates an answer, has a critic list its flaws and
+// regenerates the answer with the critique, for up to MaxRounds rounds. It stops early when the
+// critic replies NoIssues or the Evaluator scores the answer at or above Threshold. The first
+// answer is generated like an LLMNode's.
+type ReflectionNode struct {
+	LLMNode
+	// Critic reviews the answers; defaults to the node's agent. A second model often gives
+	// more useful critiques.
+	Critic *agent.Agent
+	// CritiqueTemplate and ReviseTemplate default to DefaultCritiqueTemplate and
+	// DefaultReviseTemplate.
+	CritiqueTemplate *prompt.Template
+	ReviseTemplate   *prompt.Template
+	// MaxRounds is the maximum number of critique and revise rounds; defaults to 2.
+	MaxRounds int
+	// Evaluator, if set, scores every answer against the node input.
+	Evaluator eval.Evaluator
+	// Threshold is the score at which an answer is accepted; defaults to 0.8.
+	Threshold float64
+}
+
+// ReflectionRound is one answer together with its score and the critique it received.
+type ReflectionRound struct {
+	Answer   string
+	Score    float64 // Zero without an Evaluator.
+	Critique string  // Empty if the answer was accepted before it was critiqued.
+}
+
+// ReflectionResult holds every round of a ReflectionNode execution.
+type ReflectionResult struct {
+	Rounds []ReflectionRound
+	Answer string // The last answer.
+}
+
+// Label implements Labeler.
+func (n *ReflectionNode) Label() string {
+	return fmt.Sprintf("LLM (reflect x%d)", n.maxRounds())
+}
+
+func (n *ReflectionNode) maxRounds() int {
+	if n.MaxRounds <= 0 {
+		return 2
+	}
+	return n.MaxRounds
+}
+
+// Execute generates, critiques and revises the answer and returns the final one.
+func (n *ReflectionNode) Execute(ctx context.Context, input string) (string, error) {
+	result, err := n.ExecuteDetailed(ctx, input)
+	if err != nil {
+		return "", err
+	}
+	return result.Answer, nil
+}
+
+// ExecuteDetailed generates, critiques and revises the answer and reports every round.
+func (n *ReflectionNode) ExecuteDetailed(ctx context.Context, input string) (ReflectionResult, error) {
+	critic := n.Critic
+	if critic == nil {
+		critic = n.Agent
+	}
+	critiqueTmpl := n.CritiqueTemplate
+	if critiqueTmpl == nil {
+		critiqueTmpl = DefaultCritiqueTemplate
+	}
+	reviseTmpl := n.ReviseTemplate
+	if reviseTmpl == nil {
+		reviseTmpl = DefaultReviseTemplate
+	}
+	threshold := n.Threshold
+	if threshold == 0 {
+		threshold = 0.8
+	}
+
+	task, err := n.message(ctx, input)
+	if err != nil {
+		return ReflectionResult{}, err
+	}
+	n.Agent.Reset()
+	answer, err := n.Agent.Send(ctx, task)
+	if err != nil {
+		return ReflectionResult{}, err
+	}
+
+	var result ReflectionResult
+	for round := 0; ; round++ {
+		current := ReflectionRound{Answer: answer}
+		if n.Evaluator != nil {
+			if current.Score, err = n.Evaluator.Evaluate(ctx, input, answer); err != nil {
+				return result, fmt.Errorf("reflection node: evaluate round %d: %w", round+1, err)
+			}
+			if current.Score >= threshold {
+				result.Rounds = append(result.Rounds, current)
+				break
+			}
+		}
+		if round == n.maxRounds() {
+			result.Rounds = append(result.Rounds, current)
+			break
+		}
+
+		data := map[string]any{"Task": task, "Answer": answer}
+		critique, err := n.ask(ctx, critic, critiqueTmpl, data)
+		if err != nil {
+			return result, fmt.Errorf("reflection node: critique round %d: %w", round+1, err)
+		}
+		current.Critique = critique
+		result.Rounds = append(result.Rounds, current)
+		if strings.Contains(strings.ToUpper(critique), NoIssues) {
+			break
+		}
+
+		data["Critique"] = critique
+		if answer, err = n.ask(ctx, n.Agent, reviseTmpl, data); err != nil {
+			return result, fmt.Errorf("reflection node: revise round %d: %w", round+1, err)
+		}
+	}
+	result.Answer = answer
+	return result, nil
+}
+
+// ask renders tmpl and sends it to a in a fresh conversation.
+func (n *ReflectionNode) ask(ctx context.Context, a *agent.Agent, tmpl *prompt.Template, data map[string]any) (string, error) {
+	text, err := tmpl.Render(data)
+	if err != nil {
+		return "", err
+	}
+	a.Reset()
+	return a.Send(ctx, text)
+}
@@ -0,0 +1,149 @@
+package workflow
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/zakirkun/gatot-kaca/budget"
+	"github.com/zakirkun/gatot-kaca/llm"
+)
+
+// StepResult captures the execution details of a single node in a flow run.
+type StepResult struct {
+	Index    int           // Position of the node in the flow.
+	Node     string        // Label of the node, as shown by Visualize.
+	Input    string        // Input passed to the node.
+	Output   string        // Output produced by the node.
+	Duration time.Duration // Wall time spent executing the node.
+	Usage    llm.Usage     // Token usage of LLM calls made by the node.
+	Retries  int           // Retries made by retry nodes and LLM retry policies within the node.
+	Err      error         // Error returned by the node, if any.
+}
+
+// StepError reports a node of a flow run that failed, together with the progress the run made
+// before, so that callers can show it and resume the run with Flow.RunFrom.
+type StepError struct {
+	Step      int          // Index of the failed node.
+	Node      string       // Label of the failed node.
+	Input     string       // Input of the failed node: the output of the last completed node, or the run's input.
+	Completed []StepResult // Results of the nodes that completed before, in execution order.
+	State     *RunState    // State of the run, holding the outputs of its named nodes and its variables.
+	Err       error        // Error returned by the node.
+}
+
+func (e *StepError) Error() string {
+	return fmt.Sprintf("error at step %d: %v", e.Step, e.Err)
+}
+
+// Unwrap returns the node's error.
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+// FlowResult is the structured outcome of a flow run.
+type FlowResult struct {
+	RunID     string       // Unique identifier of the run.
+	Input     string       // Initial input of the flow.
+	Output    string       // Final output of the flow; empty if the run failed.
+	Steps     []StepResult // Per-node results, in execution order.
+	Usage     llm.Usage    // Total token usage of the run.
+	Retries   int          // Total retries of the run.
+	StartedAt time.Time    // Time the run started.
+	Duration  time.Duration
+	Err       error // Error that stopped the run, if any.
+	// Compensations reports the compensations run because the run failed, in execution order.
+	Compensations []CompensationResult
+}
+
+// RunDetailed executes the flow like Run, but returns a FlowResult containing the
+// final output together with per-node outputs, durations, token usage, and errors.
+// The result is returned even when a node fails, so completed steps remain available. A
+// failure handled by OnError is kept in Steps while the run itself succeeds. A failed run
+// returns a *StepError, from which the run can be resumed with RunFrom.
+// If the flow has a Store, the run is saved to it.
+func (f *Flow) RunDetailed(ctx context.Context, initialInput string) (*FlowResult, error) {
+	return f.RunDetailedWithCallback(ctx, initialInput, nil)
+}
+
+// RunDetailedWithCallback is like RunDetailed, but calls onStep with the result of every
+// node as soon as it completes, which allows progress to be reported while the flow runs.
+func (f *Flow) RunDetailedWithCallback(ctx context.Context, initialInput string, onStep func(StepResult)) (*FlowResult, error) {
+	result := &FlowResult{
+		RunID:     newRunID(),
+		Input:     initialInput,
+		StartedAt: time.Now(),
+	}
+
+	f.Tracker.start(result.RunID, f.Name, len(f.Nodes))
+	defer f.Tracker.finish(result.RunID)
+
+	flowUsage := llm.NewUsageRecorder()
+	ctx = llm.ContextWithUsageRecorder(f.runContext(ctx), flowUsage)
+	ctx, run := budget.Start(ctx, f.Budget)
+	defer run.Stop()
+	ctx, saga, ownSaga := withSaga(ctx)
+
+	currentInput := initialInput
+	for i, node := range f.Nodes {
+		f.Tracker.step(result.RunID, i)
+		stepUsage := llm.NewUsageRecorder()
+		stepCtx := llm.ContextWithUsageRecorder(ctx, stepUsage)
+
+		start := time.Now()
+		output, err := executeStep(stepCtx, node, currentInput)
+		step := StepResult{
+			Index:    i,
+			Node:     nodeLabel(node),
+			Input:    currentInput,
+			Output:   output,
+			Duration: time.Since(start),
+			Usage:    stepUsage.Usage(),
+			Retries:  stepUsage.Retries(),
+			Err:      err,
+		}
+		result.Steps = append(result.Steps, step)
+		f.logStep(ctx, node, step.Duration, step.Usage.TotalTokens, err)
+		if onStep != nil {
+			onStep(step)
+		}
+
+		if err != nil {
+			if output, ok := f.handleError(ctx, currentInput, err); ok {
+				currentInput = output
+				break
+			}
+			result.Err = &StepError{Step: i, Node: step.Node, Input: currentInput, Completed: result.Steps[:len(result.Steps)-1],
+				State: StateFromContext(ctx), Err: run.Err(err, currentInput)}
+			if ownSaga {
+				result.Compensations = saga.compensate(ctx, result.Err)
+			}
+			runFinally(ctx, f.Finally, currentInput, "", result.Err)
+			result.Usage = flowUsage.Usage()
+			result.Retries = flowUsage.Retries()
+			result.Duration = time.Since(result.StartedAt)
+			f.saveRun(ctx, result)
+			return result, result.Err
+		}
+		currentInput = output
+	}
+	runFinally(ctx, f.Finally, initialInput, currentInput, nil)
+
+	result.Output = currentInput
+	result.Usage = flowUsage.Usage()
+	result.Retries = flowUsage.Retries()
+	result.Duration = time.Since(result.StartedAt)
+	f.saveRun(ctx, result)
+	return result, nil
+}
+
+// newRunID returns a random identifier for a flow run.
+func newRunID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("run-%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,49 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/zakirkun/gatot-kaca/rag"
+)
+
+// RetrievalNode is a workflow node that uses its input as a query against a knowledge base and
+// outputs the input augmented with the retrieved documents, ready for a following LLMNode.
+// To search an external vector store, set it as the knowledge base's Store.
+type RetrievalNode struct {
+	KB     *rag.KnowledgeBase // The knowledge base to query.
+	K      int                // Number of documents to retrieve; defaults to 3.
+	Filter rag.Filter         // Optional metadata filter.
+	// Options are additional query options, such as rag.WithMMR or rag.WithMinScore.
+	Options []rag.QueryOption
+}
+
+// Execute retrieves the top K documents for the input and returns the augmented prompt.
+// If nothing is retrieved, the input is returned unchanged.
+func (rn *RetrievalNode) Execute(ctx context.Context, input string) (string, error) {
+	results, err := rn.Retrieve(ctx, input)
+	if err != nil {
+		return "", err
+	}
+	if len(results) == 0 {
+		return input, nil
+	}
+	return rag.AugmentPrompt(input, results), nil
+}
+
+// Retrieve returns the top K documents for query. As a TypedFunc it is a typed node that passes
+// the results on as structs:
+//
+//	retrieve := workflow.TypedFunc[string, []rag.RetrievalResult](rn.Retrieve)
+func (rn *RetrievalNode) Retrieve(ctx context.Context, query string) ([]rag.RetrievalResult, error) {
+	k := rn.K
+	if k <= 0 {
+		k = 3
+	}
+	opts := append([]rag.QueryOption{rag.WithFilter(rn.Filter)}, rn.Options...)
+	results, err := rn.KB.Query(ctx, query, k, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("retrieval node: %w", err)
+	}
+	return results, nil
+}
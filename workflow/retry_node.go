@@ -3,7 +3,11 @@ package workflow
 import (
 	"context"
 	"fmt"
+	"math/rand"
 	"time"
+
+	"github.com/zakirkun/gatot-kaca/llm"
+	"github.com/zakirkun/gatot-kaca/logging"
 )
 
 // RetryNode is a workflow node that wraps another node and attempts to retry its execution a specified number of times upon failure.
@@ -11,20 +15,66 @@ type RetryNode struct {
 	Node       Node          // The child node to execute.
 	MaxRetries int           // Maximum number of retries.
 	Delay      time.Duration // Delay between retries.
+	// Backoff multiplies the delay after every retry for exponential backoff, e.g. 2. Values
+	// of 1 or less keep the delay fixed.
+	Backoff float64
+	// MaxDelay caps the delay between retries, jitter included; zero means no cap.
+	MaxDelay time.Duration
+	// Jitter randomizes every delay by up to this fraction of it, e.g. 0.2 for ±20%, so that
+	// clients failing together do not retry in lockstep.
+	Jitter float64
+	// ShouldRetry reports whether an error is worth retrying; defaults to retrying every error.
+	// Use llm.DefaultRetryable to give up on errors that cannot succeed on retry, such as
+	// authentication or invalid request errors.
+	ShouldRetry func(err error) bool
 }
 
-// Execute attempts to execute the wrapped node. If it fails, it retries up to MaxRetries times with Delay between attempts.
+// Execute attempts to execute the wrapped node. If it fails with an error worth retrying, it
+// retries up to MaxRetries times, waiting the backoff delay between attempts or longer if a
+// rate limited provider asked for it (see llm.RetryAfter). It stops waiting when ctx is done.
 func (rn *RetryNode) Execute(ctx context.Context, input string) (string, error) {
 	var result string
 	var err error
-	for attempt := 0; attempt <= rn.MaxRetries; attempt++ {
+	attempt := 0
+	for ; attempt <= rn.MaxRetries; attempt++ {
 		result, err = rn.Node.Execute(ctx, input)
 		if err == nil {
 			return result, nil
 		}
-		if attempt < rn.MaxRetries {
-			time.Sleep(rn.Delay)
+		if attempt == rn.MaxRetries || (rn.ShouldRetry != nil && !rn.ShouldRetry(err)) {
+			break
+		}
+		wait := rn.delay(attempt)
+		if d, ok := llm.RetryAfter(err); ok && d > wait {
+			wait = d
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return "", fmt.Errorf("retry node: canceled after %d attempts: %w", attempt+1, ctx.Err())
+		}
+		logging.FromContext(ctx).Info("retrying node", logging.KeyComponent, "workflow", logging.KeyNode, nodeLabel(rn.Node),
+			"attempt", attempt+2, logging.KeyError, err)
+		if rec := llm.UsageRecorderFromContext(ctx); rec != nil {
+			rec.AddRetry()
+		}
+	}
+	return "", fmt.Errorf("retry node: failed after %d attempts, last error: %w", attempt+1, err)
+}
+
+// delay returns the delay after the given attempt, counted from 0.
+func (rn *RetryNode) delay(attempt int) time.Duration {
+	d := rn.Delay
+	if rn.Backoff > 1 {
+		for i := 0; i < attempt && (rn.MaxDelay <= 0 || d < rn.MaxDelay); i++ {
+			d = time.Duration(float64(d) * rn.Backoff)
 		}
 	}
-	return "", fmt.Errorf("retry node: failed after %d attempts, last error: %w", rn.MaxRetries+1, err)
+	if rn.Jitter > 0 {
+		d = time.Duration(float64(d) * (1 + rn.Jitter*(2*rand.Float64()-1)))
+	}
+	if rn.MaxDelay > 0 && d > rn.MaxDelay {
+		d = rn.MaxDelay
+	}
+	return d
 }
@@ -0,0 +1,142 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/zakirkun/gatot-kaca/llm"
+)
+
+// failingNode fails its first failures executions, then echoes its input.
+type failingNode struct {
+	failures int
+	err      error
+	calls    int
+}
+
+func (n *failingNode) Execute(ctx context.Context, input string) (string, error) {
+	n.calls++
+	if n.calls <= n.failures {
+		return "", n.err
+	}
+	return input, nil
+}
+
+func TestRetryNodeDelay(t *testing.T) {
+	tests := []struct {
+		name string
+		rn   RetryNode
+		want []time.Duration
+	}{
+		{"fixed", RetryNode{Delay: 10 * time.Millisecond},
+			[]time.Duration{10 * time.Millisecond, 10 * time.Millisecond, 10 * time.Millisecond}},
+		{"exponential", RetryNode{Delay: 10 * time.Millisecond, Backoff: 2},
+			[]time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 80 * time.Millisecond}},
+		{"capped", RetryNode{Delay: 10 * time.Millisecond, Backoff: 3, MaxDelay: 50 * time.Millisecond},
+			[]time.Duration{10 * time.Millisecond, 30 * time.Millisecond, 50 * time.Millisecond, 50 * time.Millisecond}},
+		{"backoff of 1 or less is fixed", RetryNode{Delay: 10 * time.Millisecond, Backoff: 0.5},
+			[]time.Duration{10 * time.Millisecond, 10 * time.Millisecond}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for attempt, want := range tt.want {
+				if got := tt.rn.delay(attempt); got != want {
+					t.Errorf("delay(%d) = %v, want %v", attempt, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryNodeJitter(t *testing.T) {
+	rn := RetryNode{Delay: 100 * time.Millisecond, Backoff: 2, Jitter: 0.2, MaxDelay: 300 * time.Millisecond}
+	varied := false
+	for i := 0; i < 200; i++ {
+		d := rn.delay(1)
+		if d < 160*time.Millisecond || d > 240*time.Millisecond {
+			t.Fatalf("delay(1) = %v, want 200ms ±20%%", d)
+		}
+		if d != 200*time.Millisecond {
+			varied = true
+		}
+		if d := rn.delay(3); d > rn.MaxDelay || d < 240*time.Millisecond {
+			t.Fatalf("delay(3) = %v, want between 240ms and the 300ms cap", d)
+		}
+	}
+	if !varied {
+		t.Error("jitter never changed the delay")
+	}
+}
+
+func TestRetryNodeRetries(t *testing.T) {
+	child := &failingNode{failures: 2, err: errors.New("temporary")}
+	rn := &RetryNode{Node: child, MaxRetries: 3, Delay: time.Millisecond}
+	usage := llm.NewUsageRecorder()
+	out, err := rn.Execute(llm.ContextWithUsageRecorder(context.Background(), usage), "input")
+	if err != nil || out != "input" {
+		t.Fatalf("Execute = %q, %v", out, err)
+	}
+	if child.calls != 3 || usage.Retries() != 2 {
+		t.Errorf("%d calls and %d recorded retries, want 3 and 2", child.calls, usage.Retries())
+	}
+}
+
+func TestRetryNodeGivesUp(t *testing.T) {
+	errTemporary := errors.New("temporary")
+	child := &failingNode{failures: 10, err: errTemporary}
+	rn := &RetryNode{Node: child, MaxRetries: 2, Delay: time.Millisecond}
+	if _, err := rn.Execute(context.Background(), "input"); !errors.Is(err, errTemporary) {
+		t.Errorf("error = %v, want the last error of the child", err)
+	}
+	if child.calls != 3 {
+		t.Errorf("%d calls, want 3", child.calls)
+	}
+
+	child = &failingNode{failures: 10, err: llm.ErrAuth}
+	rn = &RetryNode{Node: child, MaxRetries: 5, Delay: time.Millisecond, ShouldRetry: llm.DefaultRetryable}
+	if _, err := rn.Execute(context.Background(), "input"); !errors.Is(err, llm.ErrAuth) || child.calls != 1 {
+		t.Errorf("permanent error: %v after %d calls, want no retry", err, child.calls)
+	}
+}
+
+func TestRetryNodeRetryAfter(t *testing.T) {
+	rateLimited := &llm.APIError{StatusCode: 429, RetryAfter: 50 * time.Millisecond, Kind: llm.ErrRateLimited}
+	child := &failingNode{failures: 1, err: rateLimited}
+	rn := &RetryNode{Node: child, MaxRetries: 1, Delay: time.Millisecond}
+	start := time.Now()
+	if _, err := rn.Execute(context.Background(), "input"); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("retried after %v, want the 50ms the provider asked for", elapsed)
+	}
+}
+
+func TestRetryNodeCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	child := &failingNode{failures: 10, err: errors.New("temporary")}
+	rn := &RetryNode{Node: &FuncNode{Process: func(ctx context.Context, input string) (string, error) {
+		// Cancel while the node waits before the first retry.
+		time.AfterFunc(10*time.Millisecond, cancel)
+		return child.Execute(ctx, input)
+	}}, MaxRetries: 3, Delay: time.Hour}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := rn.Execute(ctx, "input")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("error = %v, want context.Canceled", err)
+		}
+		if child.calls != 1 {
+			t.Errorf("%d calls, want 1", child.calls)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Execute kept waiting after the context was canceled")
+	}
+}
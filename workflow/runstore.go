@@ -0,0 +1,167 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/zakirkun/gatot-kaca/llm"
+)
+
+// ErrRunNotFound is returned by RunStore.GetRun for unknown run IDs.
+var ErrRunNotFound = errors.New("workflow: run not found")
+
+// StepRecord is the stored form of a StepResult.
+type StepRecord struct {
+	Index    int           `json:"index"`
+	Node     string        `json:"node,omitempty"`
+	Input    string        `json:"input"`
+	Output   string        `json:"output"`
+	Duration time.Duration `json:"duration"`
+	Usage    llm.Usage     `json:"usage"`
+	Retries  int           `json:"retries,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// RunRecord is the stored form of a flow run.
+type RunRecord struct {
+	RunID     string        `json:"run_id"`
+	Flow      string        `json:"flow,omitempty"`
+	Input     string        `json:"input"`
+	Output    string        `json:"output"`
+	Error     string        `json:"error,omitempty"`
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration"`
+	Usage     llm.Usage     `json:"usage"`
+	Retries   int           `json:"retries,omitempty"`
+	// Steps holds the per-node results. It is empty in the records returned by ListRuns.
+	Steps []StepRecord `json:"steps,omitempty"`
+}
+
+// NewRunRecord converts the result of a run of the named flow into a RunRecord.
+func NewRunRecord(flow string, result *FlowResult) *RunRecord {
+	rec := &RunRecord{
+		RunID:     result.RunID,
+		Flow:      flow,
+		Input:     result.Input,
+		Output:    result.Output,
+		StartedAt: result.StartedAt,
+		Duration:  result.Duration,
+		Usage:     result.Usage,
+		Retries:   result.Retries,
+		Steps:     make([]StepRecord, len(result.Steps)),
+	}
+	if result.Err != nil {
+		rec.Error = result.Err.Error()
+	}
+	for i, step := range result.Steps {
+		rec.Steps[i] = StepRecord{
+			Index:    step.Index,
+			Node:     step.Node,
+			Input:    step.Input,
+			Output:   step.Output,
+			Duration: step.Duration,
+			Usage:    step.Usage,
+			Retries:  step.Retries,
+		}
+		if step.Err != nil {
+			rec.Steps[i].Error = step.Err.Error()
+		}
+	}
+	return rec
+}
+
+// RunFilter selects the runs returned by RunStore.ListRuns. Zero fields match every run.
+type RunFilter struct {
+	Flow       string
+	OnlyFailed bool
+	Since      time.Time // Runs started at or after Since.
+	Until      time.Time // Runs started before Until.
+	Limit      int       // Defaults to DefaultRunListLimit.
+	Offset     int
+}
+
+// DefaultRunListLimit is the number of runs ListRuns returns unless RunFilter.Limit is set.
+const DefaultRunListLimit = 50
+
+// Matches reports whether the run is selected by the filter, ignoring Limit and Offset.
+func (f RunFilter) Matches(rec *RunRecord) bool {
+	return (f.Flow == "" || rec.Flow == f.Flow) &&
+		(!f.OnlyFailed || rec.Error != "") &&
+		(f.Since.IsZero() || !rec.StartedAt.Before(f.Since)) &&
+		(f.Until.IsZero() || rec.StartedAt.Before(f.Until))
+}
+
+// RunStore persists flow runs. Assign one to Flow.Store to have every run of the flow recorded.
+type RunStore interface {
+	// SaveRun stores a run, replacing any run with the same ID.
+	SaveRun(ctx context.Context, rec *RunRecord) error
+	// ListRuns returns the runs selected by the filter, most recent first, without their steps.
+	ListRuns(ctx context.Context, filter RunFilter) ([]RunRecord, error)
+	// GetRun returns a run with its steps, or ErrRunNotFound.
+	GetRun(ctx context.Context, runID string) (*RunRecord, error)
+}
+
+// MemoryRunStore is a RunStore keeping runs in memory, useful for tests and development.
+type MemoryRunStore struct {
+	mu   sync.RWMutex
+	runs map[string]*RunRecord
+}
+
+// NewMemoryRunStore creates an empty MemoryRunStore.
+func NewMemoryRunStore() *MemoryRunStore {
+	return &MemoryRunStore{runs: make(map[string]*RunRecord)}
+}
+
+// SaveRun implements RunStore.
+func (s *MemoryRunStore) SaveRun(ctx context.Context, rec *RunRecord) error {
+	cp := *rec
+	cp.Steps = append([]StepRecord(nil), rec.Steps...)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runs[rec.RunID] = &cp
+	return nil
+}
+
+// ListRuns implements RunStore.
+func (s *MemoryRunStore) ListRuns(ctx context.Context, filter RunFilter) ([]RunRecord, error) {
+	s.mu.RLock()
+	runs := []RunRecord{}
+	for _, rec := range s.runs {
+		if filter.Matches(rec) {
+			summary := *rec
+			summary.Steps = nil
+			runs = append(runs, summary)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(runs, func(i, j int) bool { return runs[i].StartedAt.After(runs[j].StartedAt) })
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultRunListLimit
+	}
+	if filter.Offset >= len(runs) {
+		return []RunRecord{}, nil
+	}
+	runs = runs[filter.Offset:]
+	if len(runs) > limit {
+		runs = runs[:limit]
+	}
+	return runs, nil
+}
+
+// GetRun implements RunStore.
+func (s *MemoryRunStore) GetRun(ctx context.Context, runID string) (*RunRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rec, ok := s.runs[runID]
+	if !ok {
+		return nil, ErrRunNotFound
+	}
+	cp := *rec
+	cp.Steps = append([]StepRecord(nil), rec.Steps...)
+	return &cp, nil
+}
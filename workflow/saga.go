@@ -0,0 +1,98 @@
+package workflow
+
+import (
+	"context"
+	"sync"
+
+	"github.com/zakirkun/gatot-kaca/logging"
+)
+
+// CompensationResult reports a compensation run after a failed flow.
+type CompensationResult struct {
+	Name string
+	Err  error
+}
+
+type compensation struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// saga collects the compensations registered during a run.
+type saga struct {
+	mu    sync.Mutex
+	steps []compensation
+}
+
+type sagaKey struct{}
+
+// withSaga returns ctx carrying the saga of the run and whether the caller created it and so is
+// responsible for compensating. Nested flows share the saga of the enclosing run.
+func withSaga(ctx context.Context) (context.Context, *saga, bool) {
+	if s, ok := ctx.Value(sagaKey{}).(*saga); ok {
+		return ctx, s, false
+	}
+	s := &saga{}
+	return context.WithValue(ctx, sagaKey{}, s), s, true
+}
+
+// RegisterCompensation registers fn to undo a side effect of the current step, such as deleting
+// a created ticket. If the flow run fails, the compensations registered so far run in reverse
+// order. It reports false if ctx does not belong to a flow run, in which case fn is never run.
+func RegisterCompensation(ctx context.Context, name string, fn func(ctx context.Context) error) bool {
+	s, ok := ctx.Value(sagaKey{}).(*saga)
+	if !ok {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.steps = append(s.steps, compensation{name: name, fn: fn})
+	return true
+}
+
+// compensate runs the registered compensations in reverse order, even if ctx was cancelled, and
+// reports their results. A failed compensation does not stop the others.
+func (s *saga) compensate(ctx context.Context, cause error) []CompensationResult {
+	s.mu.Lock()
+	steps := s.steps
+	s.steps = nil
+	s.mu.Unlock()
+
+	ctx = contextWithError(context.WithoutCancel(ctx), cause)
+	results := make([]CompensationResult, 0, len(steps))
+	for i := len(steps) - 1; i >= 0; i-- {
+		err := steps[i].fn(ctx)
+		if err != nil {
+			logging.FromContext(ctx).Warn("compensation failed", logging.KeyComponent, "workflow",
+				"compensation", steps[i].name, logging.KeyError, err)
+		}
+		results = append(results, CompensationResult{Name: steps[i].name, Err: err})
+	}
+	return results
+}
+
+// CompensableNode runs Node and, if it succeeds, registers Compensate to undo it should a later
+// step of the run fail. Compensate receives the output of Node, e.g. the ID of a created ticket,
+// and can read the error that failed the run with ErrorFromContext.
+type CompensableNode struct {
+	Node       Node
+	Compensate Node
+}
+
+// Label implements Labeler.
+func (n *CompensableNode) Label() string {
+	return nodeLabel(n.Node) + " (compensable)"
+}
+
+// Execute runs the node and registers its compensation.
+func (n *CompensableNode) Execute(ctx context.Context, input string) (string, error) {
+	output, err := n.Node.Execute(ctx, input)
+	if err != nil || n.Compensate == nil {
+		return output, err
+	}
+	RegisterCompensation(ctx, nodeLabel(n.Node), func(ctx context.Context) error {
+		_, err := n.Compensate.Execute(ctx, output)
+		return err
+	})
+	return output, nil
+}
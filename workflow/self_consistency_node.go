@@ -0,0 +1,134 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// SelfConsistencyNode is an LLM step that samples several responses to the same prompt, extracts
+// the final answer from each and outputs the response whose answer the most samples agree on.
+// It improves reliability on reasoning tasks where a single sample may go astray. The prompt is
+// built like an LLMNode's and the samples are requested in one call where the provider allows.
+type SelfConsistencyNode struct {
+	LLMNode
+	// Samples is the number of responses to sample; defaults to 5.
+	Samples int
+	// Temperature overrides the agent's temperature while sampling, so the samples differ;
+	// defaults to 0.8. A negative value keeps the agent's temperature.
+	Temperature float64
+	// Extract returns the final answer of a response; defaults to ExtractFinalAnswer.
+	Extract func(response string) string
+	// MinAgreement fails the node when the winning answer has fewer than this fraction of the
+	// votes, e.g. 0.5 for a strict majority. Zero accepts any plurality.
+	MinAgreement float64
+	// ReturnAnswer outputs the extracted answer instead of the full response.
+	ReturnAnswer bool
+}
+
+// VoteResult holds the samples of a SelfConsistencyNode execution and the outcome of the vote.
+type VoteResult struct {
+	Samples   []string
+	Answers   []string       // Extracted answer of each sample.
+	Votes     map[string]int // Votes per normalized answer.
+	Answer    string         // The winning answer.
+	Response  string         // The first sample that gave the winning answer.
+	Agreement float64        // Fraction of the samples that gave the winning answer.
+}
+
+var finalAnswerPattern = regexp.MustCompile(`(?im)^\W*(?:final answer|answer)\W*[:=]\s*(.+)$`)
+
+// ExtractFinalAnswer returns the text after the last "Final Answer:" or "Answer:" line of a
+// response, or its last non-empty line.
+func ExtractFinalAnswer(response string) string {
+	if matches := finalAnswerPattern.FindAllStringSubmatch(response, -1); len(matches) > 0 {
+		return strings.TrimSpace(matches[len(matches)-1][1])
+	}
+	lines := strings.Split(strings.TrimSpace(response), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+// normalizeAnswer makes answers that differ only in case, spacing, emphasis or trailing
+// punctuation vote together.
+func normalizeAnswer(answer string) string {
+	answer = strings.ToLower(strings.Join(strings.Fields(answer), " "))
+	return strings.Trim(answer, " *_`\"'.!")
+}
+
+// Label implements Labeler.
+func (n *SelfConsistencyNode) Label() string {
+	return fmt.Sprintf("LLM (vote of %d)", n.samples())
+}
+
+func (n *SelfConsistencyNode) samples() int {
+	if n.Samples <= 0 {
+		return 5
+	}
+	return n.Samples
+}
+
+// Execute samples the responses and returns the most consistent one.
+func (n *SelfConsistencyNode) Execute(ctx context.Context, input string) (string, error) {
+	result, err := n.ExecuteDetailed(ctx, input)
+	if err != nil {
+		return "", err
+	}
+	if n.ReturnAnswer {
+		return result.Answer, nil
+	}
+	return result.Response, nil
+}
+
+// ExecuteDetailed samples the responses and reports the vote. Ties go to the answer given first.
+func (n *SelfConsistencyNode) ExecuteDetailed(ctx context.Context, input string) (VoteResult, error) {
+	n.Agent.Reset()
+	text, err := n.message(ctx, input)
+	if err != nil {
+		return VoteResult{}, err
+	}
+
+	temperature := n.Temperature
+	if temperature == 0 {
+		temperature = 0.8
+	}
+	if temperature > 0 {
+		defer func(t float64) { n.Agent.Temperature = t }(n.Agent.Temperature)
+		n.Agent.Temperature = temperature
+	}
+	samples, err := n.Agent.SendN(ctx, text, n.samples())
+	if err != nil {
+		return VoteResult{}, err
+	}
+
+	extract := n.Extract
+	if extract == nil {
+		extract = ExtractFinalAnswer
+	}
+	result := VoteResult{Samples: samples, Answers: make([]string, len(samples)), Votes: make(map[string]int)}
+	best, bestVotes := -1, 0
+	for i, s := range samples {
+		result.Answers[i] = extract(s)
+		key := normalizeAnswer(result.Answers[i])
+		result.Votes[key]++
+		if v := result.Votes[key]; v > bestVotes {
+			best, bestVotes = i, v
+		}
+	}
+	// Keep the first sample of the winning answer.
+	for i := range samples {
+		if normalizeAnswer(result.Answers[i]) == normalizeAnswer(result.Answers[best]) {
+			best = i
+			break
+		}
+	}
+	result.Answer = result.Answers[best]
+	result.Response = samples[best]
+	result.Agreement = float64(bestVotes) / float64(len(samples))
+
+	if result.Agreement < n.MinAgreement {
+		return result, fmt.Errorf("self-consistency node: only %.0f%% of %d samples agree on %q",
+			result.Agreement*100, len(samples), result.Answer)
+	}
+	return result, nil
+}
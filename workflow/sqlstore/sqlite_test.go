@@ -0,0 +1,15 @@
+//go:build sqlite
+
+// Tests against a real SQLite database, using the pure Go driver. Add it to the module and run
+// them with:
+//
+//	go get modernc.org/sqlite
+//	go test -tags sqlite ./workflow/sqlstore
+
+package sqlstore_test
+
+import _ "modernc.org/sqlite"
+
+func init() {
+	sqliteDriver = "sqlite"
+}
@@ -0,0 +1,302 @@
+// Package sqlstore implements workflow.RunStore on top of database/sql, for SQLite and
+// PostgreSQL. The driver is not imported; open the *sql.DB with the driver of your choice,
+// e.g. modernc.org/sqlite, github.com/mattn/go-sqlite3, or github.com/jackc/pgx/v5/stdlib.
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/zakirkun/gatot-kaca/workflow"
+)
+
+// Dialect describes the SQL differences between the supported databases.
+type Dialect int
+
+const (
+	SQLite   Dialect = iota // "?" placeholders.
+	Postgres                // "$1" placeholders.
+)
+
+// placeholder returns the bind parameter for the n-th (1-based) argument.
+func (d Dialect) placeholder(n int) string {
+	if d == Postgres {
+		return fmt.Sprintf("$%d", n)
+	}
+	return "?"
+}
+
+// Store is a workflow.RunStore persisting runs in two tables, <prefix>runs and <prefix>run_steps.
+// Times are stored as Unix nanoseconds and durations as nanoseconds, so the schema is identical
+// on every database.
+type Store struct {
+	DB      *sql.DB
+	Dialect Dialect
+	// Prefix is prepended to the table names; defaults to "gatotkaca_".
+	Prefix string
+}
+
+// New creates a Store and its tables if they do not exist yet.
+func New(ctx context.Context, db *sql.DB, dialect Dialect) (*Store, error) {
+	s := &Store{DB: db, Dialect: dialect}
+	if err := s.CreateTables(ctx); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Store) table(name string) string {
+	prefix := s.Prefix
+	if prefix == "" {
+		prefix = "gatotkaca_"
+	}
+	return prefix + name
+}
+
+// migrations lists the columns added after the tables were first released. CREATE TABLE IF NOT
+// EXISTS leaves existing tables unchanged, so CreateTables adds them to tables that lack them.
+var migrations = []struct {
+	table, column, definition string
+}{
+	{"runs", "retries", "INTEGER NOT NULL DEFAULT 0"},
+	{"run_steps", "node", "TEXT NOT NULL DEFAULT ''"},
+	{"run_steps", "retries", "INTEGER NOT NULL DEFAULT 0"},
+}
+
+// CreateTables creates the tables and indexes used by the store if they do not exist, and adds
+// the columns of newer versions to tables created by older ones.
+func (s *Store) CreateTables(ctx context.Context) error {
+	runs, steps := s.table("runs"), s.table("run_steps")
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS ` + runs + ` (
+	run_id TEXT PRIMARY KEY,
+	flow TEXT NOT NULL,
+	input TEXT NOT NULL,
+	output TEXT NOT NULL,
+	error TEXT NOT NULL,
+	started_at BIGINT NOT NULL,
+	duration BIGINT NOT NULL,
+	prompt_tokens BIGINT NOT NULL,
+	completion_tokens BIGINT NOT NULL,
+	total_tokens BIGINT NOT NULL,
+	retries INTEGER NOT NULL DEFAULT 0
+)`,
+		`CREATE INDEX IF NOT EXISTS ` + runs + `_started_at ON ` + runs + ` (started_at)`,
+		`CREATE INDEX IF NOT EXISTS ` + runs + `_flow ON ` + runs + ` (flow, started_at)`,
+		`CREATE TABLE IF NOT EXISTS ` + steps + ` (
+	run_id TEXT NOT NULL,
+	step_index INTEGER NOT NULL,
+	node TEXT NOT NULL DEFAULT '',
+	input TEXT NOT NULL,
+	output TEXT NOT NULL,
+	error TEXT NOT NULL,
+	duration BIGINT NOT NULL,
+	prompt_tokens BIGINT NOT NULL,
+	completion_tokens BIGINT NOT NULL,
+	total_tokens BIGINT NOT NULL,
+	retries INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (run_id, step_index)
+)`,
+	}
+	for _, stmt := range stmts {
+		if _, err := s.DB.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("sqlstore: %w", err)
+		}
+	}
+	return s.migrate(ctx)
+}
+
+// migrate adds the columns listed in migrations that are missing from the tables.
+func (s *Store) migrate(ctx context.Context) error {
+	columns := make(map[string]map[string]bool)
+	for _, m := range migrations {
+		table := s.table(m.table)
+		if columns[table] == nil {
+			cols, err := s.columns(ctx, table)
+			if err != nil {
+				return err
+			}
+			columns[table] = cols
+		}
+		if columns[table][m.column] {
+			continue
+		}
+		if _, err := s.DB.ExecContext(ctx, `ALTER TABLE `+table+` ADD COLUMN `+m.column+` `+m.definition); err != nil {
+			return fmt.Errorf("sqlstore: adding column %s.%s: %w", table, m.column, err)
+		}
+		columns[table][m.column] = true
+	}
+	return nil
+}
+
+// columns returns the lowercase names of the columns of a table.
+func (s *Store) columns(ctx context.Context, table string) (map[string]bool, error) {
+	rows, err := s.DB.QueryContext(ctx, `SELECT * FROM `+table+` WHERE 1 = 0`)
+	if err != nil {
+		return nil, fmt.Errorf("sqlstore: %w", err)
+	}
+	defer rows.Close()
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("sqlstore: %w", err)
+	}
+	cols := make(map[string]bool, len(names))
+	for _, name := range names {
+		cols[strings.ToLower(name)] = true
+	}
+	return cols, nil
+}
+
+// params returns n placeholders separated by commas, starting at the first argument.
+func (s *Store) params(n int) string {
+	p := make([]string, n)
+	for i := range p {
+		p[i] = s.Dialect.placeholder(i + 1)
+	}
+	return strings.Join(p, ", ")
+}
+
+// SaveRun implements workflow.RunStore.
+func (s *Store) SaveRun(ctx context.Context, rec *workflow.RunRecord) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("sqlstore: %w", err)
+	}
+	defer tx.Rollback()
+
+	runs, steps := s.table("runs"), s.table("run_steps")
+	p1 := s.Dialect.placeholder(1)
+	if _, err := tx.ExecContext(ctx, `DELETE FROM `+steps+` WHERE run_id = `+p1, rec.RunID); err != nil {
+		return fmt.Errorf("sqlstore: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM `+runs+` WHERE run_id = `+p1, rec.RunID); err != nil {
+		return fmt.Errorf("sqlstore: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO `+runs+` (run_id, flow, input, output, error, started_at, duration, `+
+		`prompt_tokens, completion_tokens, total_tokens, retries) VALUES (`+s.params(11)+`)`,
+		rec.RunID, rec.Flow, rec.Input, rec.Output, rec.Error, rec.StartedAt.UnixNano(), int64(rec.Duration),
+		rec.Usage.PromptTokens, rec.Usage.CompletionTokens, rec.Usage.TotalTokens, rec.Retries)
+	if err != nil {
+		return fmt.Errorf("sqlstore: %w", err)
+	}
+	for _, step := range rec.Steps {
+		_, err := tx.ExecContext(ctx, `INSERT INTO `+steps+` (run_id, step_index, node, input, output, error, duration, `+
+			`prompt_tokens, completion_tokens, total_tokens, retries) VALUES (`+s.params(11)+`)`,
+			rec.RunID, step.Index, step.Node, step.Input, step.Output, step.Error, int64(step.Duration),
+			step.Usage.PromptTokens, step.Usage.CompletionTokens, step.Usage.TotalTokens, step.Retries)
+		if err != nil {
+			return fmt.Errorf("sqlstore: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("sqlstore: %w", err)
+	}
+	return nil
+}
+
+const runColumns = `run_id, flow, input, output, error, started_at, duration, prompt_tokens, completion_tokens, total_tokens, retries`
+
+// scanRun reads a row selected with runColumns.
+func scanRun(row interface{ Scan(...interface{}) error }) (*workflow.RunRecord, error) {
+	var rec workflow.RunRecord
+	var startedAt, duration int64
+	err := row.Scan(&rec.RunID, &rec.Flow, &rec.Input, &rec.Output, &rec.Error, &startedAt, &duration,
+		&rec.Usage.PromptTokens, &rec.Usage.CompletionTokens, &rec.Usage.TotalTokens, &rec.Retries)
+	if err != nil {
+		return nil, err
+	}
+	rec.StartedAt = time.Unix(0, startedAt)
+	rec.Duration = time.Duration(duration)
+	return &rec, nil
+}
+
+// ListRuns implements workflow.RunStore.
+func (s *Store) ListRuns(ctx context.Context, filter workflow.RunFilter) ([]workflow.RunRecord, error) {
+	var where []string
+	var args []interface{}
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		where = append(where, fmt.Sprintf(cond, s.Dialect.placeholder(len(args))))
+	}
+	if filter.Flow != "" {
+		add("flow = %s", filter.Flow)
+	}
+	if filter.OnlyFailed {
+		where = append(where, "error <> ''")
+	}
+	if !filter.Since.IsZero() {
+		add("started_at >= %s", filter.Since.UnixNano())
+	}
+	if !filter.Until.IsZero() {
+		add("started_at < %s", filter.Until.UnixNano())
+	}
+
+	query := `SELECT ` + runColumns + ` FROM ` + s.table("runs")
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = workflow.DefaultRunListLimit
+	}
+	args = append(args, limit, filter.Offset)
+	query += fmt.Sprintf(` ORDER BY started_at DESC LIMIT %s OFFSET %s`,
+		s.Dialect.placeholder(len(args)-1), s.Dialect.placeholder(len(args)))
+
+	rows, err := s.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlstore: %w", err)
+	}
+	defer rows.Close()
+	runs := []workflow.RunRecord{}
+	for rows.Next() {
+		rec, err := scanRun(rows)
+		if err != nil {
+			return nil, fmt.Errorf("sqlstore: %w", err)
+		}
+		runs = append(runs, *rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sqlstore: %w", err)
+	}
+	return runs, nil
+}
+
+// GetRun implements workflow.RunStore.
+func (s *Store) GetRun(ctx context.Context, runID string) (*workflow.RunRecord, error) {
+	p1 := s.Dialect.placeholder(1)
+	rec, err := scanRun(s.DB.QueryRowContext(ctx,
+		`SELECT `+runColumns+` FROM `+s.table("runs")+` WHERE run_id = `+p1, runID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, workflow.ErrRunNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("sqlstore: %w", err)
+	}
+
+	rows, err := s.DB.QueryContext(ctx, `SELECT step_index, node, input, output, error, duration, prompt_tokens, `+
+		`completion_tokens, total_tokens, retries FROM `+s.table("run_steps")+` WHERE run_id = `+p1+` ORDER BY step_index`, runID)
+	if err != nil {
+		return nil, fmt.Errorf("sqlstore: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var step workflow.StepRecord
+		var duration int64
+		err := rows.Scan(&step.Index, &step.Node, &step.Input, &step.Output, &step.Error, &duration,
+			&step.Usage.PromptTokens, &step.Usage.CompletionTokens, &step.Usage.TotalTokens, &step.Retries)
+		if err != nil {
+			return nil, fmt.Errorf("sqlstore: %w", err)
+		}
+		step.Duration = time.Duration(duration)
+		rec.Steps = append(rec.Steps, step)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sqlstore: %w", err)
+	}
+	return rec, nil
+}
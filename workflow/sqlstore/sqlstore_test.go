@@ -0,0 +1,334 @@
+package sqlstore_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zakirkun/gatot-kaca/llm"
+	"github.com/zakirkun/gatot-kaca/workflow"
+	"github.com/zakirkun/gatot-kaca/workflow/sqlstore"
+)
+
+// sqliteDriver is the name of the SQLite driver used by the tests against a real database. It
+// is set by sqlite_test.go, built with the sqlite tag; without it those tests are skipped.
+var sqliteDriver string
+
+// openSQLite opens an empty in-memory SQLite database.
+func openSQLite(t *testing.T) *sql.DB {
+	t.Helper()
+	if sqliteDriver == "" {
+		t.Skip("no SQLite driver; run with -tags sqlite")
+	}
+	db, err := sql.Open(sqliteDriver, ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every connection to ":memory:" opens a separate database.
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func testRun(id, flow, errText string, started time.Time) *workflow.RunRecord {
+	return &workflow.RunRecord{
+		RunID: id, Flow: flow, Input: "in " + id, Output: "out " + id, Error: errText,
+		StartedAt: started, Duration: 3 * time.Second, Retries: 1,
+		Usage: llm.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+		Steps: []workflow.StepRecord{
+			{Index: 0, Node: "llm", Input: "in " + id, Output: "mid", Duration: time.Second,
+				Usage: llm.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}, Retries: 1},
+			{Index: 1, Node: "tool", Input: "mid", Output: "out " + id, Duration: 2 * time.Second, Error: errText},
+		},
+	}
+}
+
+func TestSQLiteRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store, err := sqlstore.New(ctx, openSQLite(t), sqlstore.SQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := time.Unix(1700000000, 123)
+	runs := []*workflow.RunRecord{
+		testRun("a", "summarize", "", base),
+		testRun("b", "summarize", "tool failed", base.Add(time.Minute)),
+		testRun("c", "translate", "", base.Add(2*time.Minute)),
+	}
+	for _, rec := range runs {
+		if err := store.SaveRun(ctx, rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := store.GetRun(ctx, "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.StartedAt.Equal(runs[1].StartedAt) {
+		t.Errorf("started at %v, want %v", got.StartedAt, runs[1].StartedAt)
+	}
+	got.StartedAt = runs[1].StartedAt
+	if !reflect.DeepEqual(got, runs[1]) {
+		t.Errorf("GetRun = %+v, want %+v", got, runs[1])
+	}
+	if _, err := store.GetRun(ctx, "missing"); !errors.Is(err, workflow.ErrRunNotFound) {
+		t.Errorf("GetRun of a missing run: %v, want ErrRunNotFound", err)
+	}
+
+	// Saving a run again replaces it and its steps.
+	replaced := testRun("a", "summarize", "", base)
+	replaced.Output = "new output"
+	replaced.Steps = replaced.Steps[:1]
+	if err := store.SaveRun(ctx, replaced); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := store.GetRun(ctx, "a"); err != nil || got.Output != "new output" || len(got.Steps) != 1 {
+		t.Errorf("replaced run = %+v, %v", got, err)
+	}
+
+	for _, tt := range []struct {
+		name   string
+		filter workflow.RunFilter
+		want   []string
+	}{
+		{"all, newest first", workflow.RunFilter{}, []string{"c", "b", "a"}},
+		{"flow", workflow.RunFilter{Flow: "summarize"}, []string{"b", "a"}},
+		{"only failed", workflow.RunFilter{OnlyFailed: true}, []string{"b"}},
+		{"since", workflow.RunFilter{Since: base.Add(time.Minute)}, []string{"c", "b"}},
+		{"until", workflow.RunFilter{Until: base.Add(time.Minute)}, []string{"a"}},
+		{"limit and offset", workflow.RunFilter{Limit: 1, Offset: 1}, []string{"b"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := store.ListRuns(ctx, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, rec := range list {
+				ids = append(ids, rec.RunID)
+				if len(rec.Steps) != 0 {
+					t.Errorf("run %s listed with steps", rec.RunID)
+				}
+			}
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("ListRuns = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestSQLiteMigration(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+	// The tables as created before the node and retries columns were added.
+	for _, stmt := range []string{
+		`CREATE TABLE gatotkaca_runs (run_id TEXT PRIMARY KEY, flow TEXT NOT NULL, input TEXT NOT NULL,
+	output TEXT NOT NULL, error TEXT NOT NULL, started_at BIGINT NOT NULL, duration BIGINT NOT NULL,
+	prompt_tokens BIGINT NOT NULL, completion_tokens BIGINT NOT NULL, total_tokens BIGINT NOT NULL)`,
+		`CREATE TABLE gatotkaca_run_steps (run_id TEXT NOT NULL, step_index INTEGER NOT NULL,
+	input TEXT NOT NULL, output TEXT NOT NULL, error TEXT NOT NULL, duration BIGINT NOT NULL,
+	prompt_tokens BIGINT NOT NULL, completion_tokens BIGINT NOT NULL, total_tokens BIGINT NOT NULL,
+	PRIMARY KEY (run_id, step_index))`,
+		`INSERT INTO gatotkaca_runs VALUES ('old', 'flow', 'in', 'out', '', 1, 2, 3, 4, 7)`,
+		`INSERT INTO gatotkaca_run_steps VALUES ('old', 0, 'in', 'out', '', 2, 3, 4, 7)`,
+	} {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store, err := sqlstore.New(ctx, db, sqlstore.SQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old, err := store.GetRun(ctx, "old")
+	if err != nil || old.Retries != 0 || len(old.Steps) != 1 || old.Steps[0].Node != "" {
+		t.Fatalf("old run = %+v, %v", old, err)
+	}
+	rec := testRun("new", "flow", "", time.Unix(1700000000, 0))
+	if err := store.SaveRun(ctx, rec); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := store.GetRun(ctx, "new"); err != nil || got.Retries != 1 || got.Steps[0].Node != "llm" {
+		t.Errorf("new run = %+v, %v", got, err)
+	}
+	// Migrating again is a no-op.
+	if err := store.CreateTables(ctx); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMigrateAddsMissingColumns(t *testing.T) {
+	db := &fakeDB{columns: map[string][]string{
+		"gatotkaca_runs": {"run_id", "flow", "input", "output", "error", "started_at", "duration",
+			"prompt_tokens", "completion_tokens", "total_tokens"},
+		"gatotkaca_run_steps": {"run_id", "step_index", "input", "output", "error", "duration",
+			"prompt_tokens", "completion_tokens", "total_tokens"},
+	}}
+	ctx := context.Background()
+	store, err := sqlstore.New(ctx, sql.OpenDB(db), sqlstore.SQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"ALTER TABLE gatotkaca_runs ADD COLUMN retries INTEGER NOT NULL DEFAULT 0",
+		"ALTER TABLE gatotkaca_run_steps ADD COLUMN node TEXT NOT NULL DEFAULT ''",
+		"ALTER TABLE gatotkaca_run_steps ADD COLUMN retries INTEGER NOT NULL DEFAULT 0",
+	}
+	if got := db.alters(); !reflect.DeepEqual(got, want) {
+		t.Errorf("alterations = %q, want %q", got, want)
+	}
+
+	if err := store.CreateTables(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := db.alters(); len(got) != len(want) {
+		t.Errorf("second migration altered the tables again: %q", got[len(want):])
+	}
+}
+
+func TestCreateTablesFresh(t *testing.T) {
+	db := &fakeDB{columns: map[string][]string{}}
+	store, err := sqlstore.New(context.Background(), sql.OpenDB(db), sqlstore.Postgres)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := db.alters(); len(got) != 0 {
+		t.Errorf("new tables altered: %q", got)
+	}
+	store.Prefix = "app_"
+	if err := store.CreateTables(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := db.columns["app_run_steps"]; !ok {
+		t.Errorf("tables with prefix not created: %v", db.columns)
+	}
+}
+
+func TestPostgresPlaceholders(t *testing.T) {
+	db := &fakeDB{columns: map[string][]string{}}
+	ctx := context.Background()
+	store, err := sqlstore.New(ctx, sql.OpenDB(db), sqlstore.Postgres)
+	if err != nil {
+		t.Fatal(err)
+	}
+	since := time.Unix(1700000000, 0)
+	if _, err := store.ListRuns(ctx, workflow.RunFilter{Flow: "f", OnlyFailed: true, Since: since, Limit: 5}); err != nil {
+		t.Fatal(err)
+	}
+	db.mu.Lock()
+	query, args := db.lastQuery, db.lastArgs
+	db.mu.Unlock()
+	if !strings.Contains(query, "WHERE flow = $1 AND error <> '' AND started_at >= $2 ORDER BY started_at DESC LIMIT $3 OFFSET $4") {
+		t.Errorf("query = %s", query)
+	}
+	if want := []driver.Value{"f", since.UnixNano(), int64(5), int64(0)}; !reflect.DeepEqual(args, want) {
+		t.Errorf("args = %v, want %v", args, want)
+	}
+}
+
+// fakeDB is a database/sql connector that records statements. It knows the columns of the
+// tables it created or was given, and answers every other query with no rows.
+type fakeDB struct {
+	mu        sync.Mutex
+	columns   map[string][]string
+	execs     []string
+	lastQuery string
+	lastArgs  []driver.Value
+}
+
+func (db *fakeDB) Connect(context.Context) (driver.Conn, error) { return fakeConn{db}, nil }
+func (db *fakeDB) Driver() driver.Driver                        { return nil }
+
+// alters returns the ALTER TABLE statements executed so far.
+func (db *fakeDB) alters() []string {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var alters []string
+	for _, stmt := range db.execs {
+		if strings.HasPrefix(stmt, "ALTER TABLE") {
+			alters = append(alters, stmt)
+		}
+	}
+	return alters
+}
+
+func (db *fakeDB) exec(query string) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.execs = append(db.execs, query)
+	fields := strings.Fields(query)
+	switch {
+	case strings.HasPrefix(query, "CREATE TABLE IF NOT EXISTS "):
+		table := fields[5]
+		if _, ok := db.columns[table]; ok {
+			return
+		}
+		var cols []string
+		for _, line := range strings.Split(query, "\n")[1:] {
+			if f := strings.Fields(line); len(f) > 1 && f[0] != "PRIMARY" {
+				cols = append(cols, f[0])
+			}
+		}
+		db.columns[table] = cols
+	case strings.HasPrefix(query, "ALTER TABLE "):
+		db.columns[fields[2]] = append(db.columns[fields[2]], fields[5])
+	}
+}
+
+func (db *fakeDB) query(query string, args []driver.Value) (driver.Rows, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if table, ok := strings.CutPrefix(query, "SELECT * FROM "); ok {
+		table = strings.Fields(table)[0]
+		cols, ok := db.columns[table]
+		if !ok {
+			return nil, errors.New("no such table: " + table)
+		}
+		return &fakeRows{columns: cols}, nil
+	}
+	db.lastQuery, db.lastArgs = query, args
+	return &fakeRows{}, nil
+}
+
+type fakeConn struct{ db *fakeDB }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{c.db, query}, nil }
+func (c fakeConn) Close() error                              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)                 { return fakeTx{}, nil }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.db.exec(s.query)
+	return driver.RowsAffected(0), nil
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.db.query(s.query, args)
+}
+
+type fakeRows struct{ columns []string }
+
+func (r *fakeRows) Columns() []string              { return r.columns }
+func (r *fakeRows) Close() error                   { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error { return io.EOF }
@@ -0,0 +1,134 @@
+package workflow
+
+import (
+	"context"
+	"sync"
+)
+
+// NodeState is the recorded input and output of a named node.
+type NodeState struct {
+	Input  string
+	Output string
+}
+
+// RunState holds the outputs of the named nodes of a run and the flow's variables, so that
+// node templates can refer to earlier results, e.g. {{.nodes.retrieve.output}} or
+// {{.vars.language}}. A flow creates a state for each run and shares it with nested flows.
+// It is safe for concurrent use by parallel nodes.
+type RunState struct {
+	mu    sync.RWMutex
+	nodes map[string]NodeState
+	vars  map[string]any
+}
+
+// NewRunState creates a state holding a copy of vars.
+func NewRunState(vars map[string]any) *RunState {
+	s := &RunState{nodes: make(map[string]NodeState), vars: make(map[string]any, len(vars))}
+	for k, v := range vars {
+		s.vars[k] = v
+	}
+	return s
+}
+
+type stateKey struct{}
+
+// ContextWithState returns a copy of ctx carrying s.
+func ContextWithState(ctx context.Context, s *RunState) context.Context {
+	return context.WithValue(ctx, stateKey{}, s)
+}
+
+// StateFromContext returns the run state carried by ctx, or nil.
+func StateFromContext(ctx context.Context) *RunState {
+	s, _ := ctx.Value(stateKey{}).(*RunState)
+	return s
+}
+
+// withState returns ctx carrying the existing run state, or a new one holding vars.
+func withState(ctx context.Context, vars map[string]any) context.Context {
+	if s := StateFromContext(ctx); s != nil {
+		for k, v := range vars {
+			if _, ok := s.Var(k); !ok {
+				s.SetVar(k, v)
+			}
+		}
+		return ctx
+	}
+	return ContextWithState(ctx, NewRunState(vars))
+}
+
+// Node returns the recorded state of the named node.
+func (s *RunState) Node(name string) (NodeState, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	n, ok := s.nodes[name]
+	return n, ok
+}
+
+// SetNode records the input and output of the named node.
+func (s *RunState) SetNode(name, input, output string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nodes[name] = NodeState{Input: input, Output: output}
+}
+
+// Var returns the variable stored under key.
+func (s *RunState) Var(key string) (any, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.vars[key]
+	return v, ok
+}
+
+// SetVar stores a variable, e.g. from a FuncNode, for later nodes to use.
+func (s *RunState) SetVar(key string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.vars[key] = value
+}
+
+// templateData returns the state as template data: "nodes" maps every node name to its
+// "input" and "output", and "vars" holds the variables.
+func (s *RunState) templateData() (nodes map[string]any, vars map[string]any) {
+	nodes = make(map[string]any)
+	vars = make(map[string]any)
+	if s == nil {
+		return nodes, vars
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for name, n := range s.nodes {
+		nodes[name] = map[string]string{"input": n.Input, "output": n.Output}
+	}
+	for k, v := range s.vars {
+		vars[k] = v
+	}
+	return nodes, vars
+}
+
+// NamedNode records the input and output of a node in the run state under a name.
+type NamedNode struct {
+	Name string
+	Node Node
+}
+
+// Named wraps node so that its result is available to later templates as
+// {{.nodes.<name>.output}}.
+func Named(name string, node Node) *NamedNode {
+	return &NamedNode{Name: name, Node: node}
+}
+
+// Label implements Labeler.
+func (n *NamedNode) Label() string {
+	return n.Name + ": " + nodeLabel(n.Node)
+}
+
+// Execute runs the wrapped node and records its result if it succeeds.
+func (n *NamedNode) Execute(ctx context.Context, input string) (string, error) {
+	output, err := n.Node.Execute(ctx, input)
+	if err == nil {
+		if s := StateFromContext(ctx); s != nil {
+			s.SetNode(n.Name, input, output)
+		}
+	}
+	return output, err
+}
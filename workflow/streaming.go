@@ -0,0 +1,245 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/zakirkun/gatot-kaca/budget"
+)
+
+// DefaultStreamBuffer is the number of chunks buffered between streaming nodes when
+// Flow.StreamBuffer is zero.
+const DefaultStreamBuffer = 16
+
+// StreamingNode is a node that can consume its input and produce its output incrementally, so
+// that a following node can start on the first chunks before the node has finished, e.g. an
+// LLM feeding a text-to-speech or incremental parser node.
+//
+// Stream reads input chunks until the input channel is closed and sends output chunks to
+// output; it must not close output. The channels are bounded, so a slow consumer holds back its
+// producer. Sends should select on ctx.Done, as the run is cancelled when any node fails.
+type StreamingNode interface {
+	Node
+	Stream(ctx context.Context, input <-chan string, output chan<- string) error
+}
+
+// Emit sends chunk to output, or returns the context error if ctx is cancelled first.
+func Emit(ctx context.Context, output chan<- string, chunk string) error {
+	select {
+	case output <- chunk:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// collect reads input until it is closed and returns the concatenated chunks.
+func collect(ctx context.Context, input <-chan string) (string, error) {
+	var b strings.Builder
+	for {
+		select {
+		case chunk, ok := <-input:
+			if !ok {
+				return b.String(), nil
+			}
+			b.WriteString(chunk)
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+}
+
+// streamNode runs node as a stream stage. Nodes that do not stream get their whole input at once
+// and send their output as a single chunk.
+func streamNode(ctx context.Context, node Node, input <-chan string, output chan<- string) error {
+	if sn, ok := node.(StreamingNode); ok {
+		return sn.Stream(ctx, input, output)
+	}
+	in, err := collect(ctx, input)
+	if err != nil {
+		return err
+	}
+	out, err := node.Execute(ctx, in)
+	if err != nil {
+		return err
+	}
+	return Emit(ctx, output, out)
+}
+
+// RunStream executes the flow with the nodes connected by channels, calling onChunk with the
+// chunks of the final output as they are produced, and returns the full output. Streaming nodes
+// pass chunks on as they go; other nodes wait for their whole input. All nodes run concurrently,
+// so a node must not share its agent with another node of the flow. RunStream does not record
+// step results or save the run.
+func (f *Flow) RunStream(ctx context.Context, input string, onChunk func(chunk string) error) (string, error) {
+	ctx = f.runContext(ctx)
+	ctx, run := budget.Start(ctx, f.Budget)
+	defer run.Stop()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	buffer := f.StreamBuffer
+	if buffer <= 0 {
+		buffer = DefaultStreamBuffer
+	}
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	source := make(chan string, 1)
+	source <- input
+	close(source)
+
+	var in <-chan string = source
+	for i, node := range f.Nodes {
+		out := make(chan string, buffer)
+		wg.Add(1)
+		go func(i int, node Node, in <-chan string, out chan<- string) {
+			defer wg.Done()
+			defer close(out)
+			if err := streamNode(ctx, node, in, out); err != nil {
+				fail(fmt.Errorf("error at step %d: %w", i, err))
+			}
+			// Unblock the previous node if this one stopped reading early.
+			for range in {
+			}
+		}(i, node, in, out)
+		in = out
+	}
+
+	var b strings.Builder
+	for chunk := range in {
+		if ctx.Err() != nil {
+			continue
+		}
+		b.WriteString(chunk)
+		if onChunk != nil {
+			if err := onChunk(chunk); err != nil {
+				fail(err)
+			}
+		}
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return "", run.Err(firstErr, b.String())
+	}
+	return b.String(), nil
+}
+
+// Stream implements StreamingNode. The prompt needs the whole input, so the node waits for it
+// and then streams the model's response.
+func (n *LLMNode) Stream(ctx context.Context, input <-chan string, output chan<- string) error {
+	in, err := collect(ctx, input)
+	if err != nil {
+		return err
+	}
+	n.Agent.Reset()
+	text, err := n.message(ctx, in)
+	if err != nil {
+		return err
+	}
+	var streamed strings.Builder
+	result, err := n.Agent.SendStream(ctx, text, func(chunk string) error {
+		streamed.WriteString(chunk)
+		return Emit(ctx, output, chunk)
+	})
+	if err != nil {
+		return err
+	}
+	// Tool output may extend the streamed text. If the streamed text was not the final response
+	// instead, e.g. the reasoning steps of a ReAct agent, the chunks already passed on cannot be
+	// taken back.
+	rest, ok := strings.CutPrefix(result, streamed.String())
+	if !ok {
+		return errors.New("llm node: the final response differs from the streamed text")
+	}
+	if rest != "" {
+		return Emit(ctx, output, rest)
+	}
+	return nil
+}
+
+// StreamFuncNode is a streaming step that executes a custom function, such as an incremental
+// parser. Used with Execute, the function receives the input as a single chunk.
+type StreamFuncNode struct {
+	Process func(ctx context.Context, input <-chan string, output chan<- string) error
+}
+
+// Execute runs the function on the whole input and returns the concatenated output.
+func (n *StreamFuncNode) Execute(ctx context.Context, input string) (string, error) {
+	in := make(chan string, 1)
+	in <- input
+	close(in)
+	out := make(chan string, DefaultStreamBuffer)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(out)
+		errc <- n.Process(ctx, in, out)
+	}()
+	var b strings.Builder
+	for chunk := range out {
+		b.WriteString(chunk)
+	}
+	if err := <-errc; err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// Stream implements StreamingNode.
+func (n *StreamFuncNode) Stream(ctx context.Context, input <-chan string, output chan<- string) error {
+	return n.Process(ctx, input, output)
+}
+
+// Stream implements StreamingNode, passing the chunks of the wrapped node through and recording
+// its full input and output in the run state.
+func (n *NamedNode) Stream(ctx context.Context, input <-chan string, output chan<- string) error {
+	var inText, outText strings.Builder
+	in := make(chan string)
+	out := make(chan string)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(out)
+		errc <- streamNode(ctx, n.Node, in, out)
+		for range in {
+		}
+	}()
+	go func() {
+		defer close(in)
+		for chunk := range input {
+			inText.WriteString(chunk)
+			if Emit(ctx, in, chunk) != nil {
+				return
+			}
+		}
+	}()
+	var emitErr error
+	for chunk := range out {
+		outText.WriteString(chunk)
+		if emitErr == nil {
+			emitErr = Emit(ctx, output, chunk)
+		}
+	}
+	if err := <-errc; err != nil {
+		return err
+	}
+	if emitErr != nil {
+		return emitErr
+	}
+	if s := StateFromContext(ctx); s != nil {
+		s.SetNode(n.Name, inText.String(), outText.String())
+	}
+	return nil
+}
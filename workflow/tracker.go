@@ -0,0 +1,69 @@
+package workflow
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// RunInfo describes a flow run that is currently in progress.
+type RunInfo struct {
+	RunID      string    `json:"run_id"`
+	Flow       string    `json:"flow,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	Step       int       `json:"step"` // Index of the node currently executing.
+	TotalSteps int       `json:"total_steps"`
+}
+
+// RunTracker keeps track of flow runs that are in progress, so they can be inspected at runtime.
+// Assign a tracker to Flow.Tracker to have the flow's runs recorded.
+type RunTracker struct {
+	mu   sync.RWMutex
+	runs map[string]*RunInfo
+}
+
+// NewRunTracker creates an empty RunTracker.
+func NewRunTracker() *RunTracker {
+	return &RunTracker{runs: make(map[string]*RunInfo)}
+}
+
+// Active returns the runs that are currently in progress, oldest first.
+func (t *RunTracker) Active() []RunInfo {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	runs := make([]RunInfo, 0, len(t.runs))
+	for _, r := range t.runs {
+		runs = append(runs, *r)
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].StartedAt.Before(runs[j].StartedAt) })
+	return runs
+}
+
+func (t *RunTracker) start(runID, flow string, totalSteps int) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.runs[runID] = &RunInfo{RunID: runID, Flow: flow, StartedAt: time.Now(), TotalSteps: totalSteps}
+}
+
+func (t *RunTracker) step(runID string, step int) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if r, ok := t.runs[runID]; ok {
+		r.Step = step
+	}
+}
+
+func (t *RunTracker) finish(runID string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.runs, runID)
+}
@@ -0,0 +1,130 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/zakirkun/gatot-kaca/agent"
+	"github.com/zakirkun/gatot-kaca/prompt"
+)
+
+// TypedNode is a workflow step with typed input and output. Typed nodes are chained with Then,
+// so pipelines that pass structs such as retrieval results or parsed JSON keep their types
+// instead of being serialized to strings between steps. AsNode and JSONNode turn a typed node
+// into a Node for use in a Flow, and Adapt turns a Node into a typed node.
+type TypedNode[I, O any] interface {
+	Execute(ctx context.Context, input I) (O, error)
+}
+
+// TypedFunc adapts a function to the TypedNode interface.
+type TypedFunc[I, O any] func(ctx context.Context, input I) (O, error)
+
+// Execute calls the function.
+func (f TypedFunc[I, O]) Execute(ctx context.Context, input I) (O, error) {
+	return f(ctx, input)
+}
+
+// Then chains two typed nodes: the output of first is the input of second.
+func Then[I, M, O any](first TypedNode[I, M], second TypedNode[M, O]) TypedNode[I, O] {
+	return TypedFunc[I, O](func(ctx context.Context, input I) (O, error) {
+		mid, err := first.Execute(ctx, input)
+		if err != nil {
+			var zero O
+			return zero, err
+		}
+		return second.Execute(ctx, mid)
+	})
+}
+
+// Adapt turns a string-based node into a typed node, encoding its input and decoding its output
+// with the given functions. EncodeJSON and DecodeJSON are suitable defaults.
+func Adapt[I, O any](node Node, encode func(I) (string, error), decode func(string) (O, error)) TypedNode[I, O] {
+	return TypedFunc[I, O](func(ctx context.Context, input I) (O, error) {
+		var zero O
+		in, err := encode(input)
+		if err != nil {
+			return zero, fmt.Errorf("typed node: encode input: %w", err)
+		}
+		out, err := node.Execute(ctx, in)
+		if err != nil {
+			return zero, err
+		}
+		result, err := decode(out)
+		if err != nil {
+			return zero, fmt.Errorf("typed node: decode output: %w", err)
+		}
+		return result, nil
+	})
+}
+
+// AsNode turns a typed node into a string-based node, decoding its input and encoding its output
+// with the given functions.
+func AsNode[I, O any](node TypedNode[I, O], decode func(string) (I, error), encode func(O) (string, error)) Node {
+	return &FuncNode{Process: func(ctx context.Context, input string) (string, error) {
+		in, err := decode(input)
+		if err != nil {
+			return "", fmt.Errorf("typed node: decode input: %w", err)
+		}
+		out, err := node.Execute(ctx, in)
+		if err != nil {
+			return "", err
+		}
+		result, err := encode(out)
+		if err != nil {
+			return "", fmt.Errorf("typed node: encode output: %w", err)
+		}
+		return result, nil
+	}}
+}
+
+// JSONNode is AsNode with DecodeJSON and EncodeJSON.
+func JSONNode[I, O any](node TypedNode[I, O]) Node {
+	return AsNode(node, DecodeJSON[I], EncodeJSON[O])
+}
+
+// EncodeJSON encodes v as JSON. Strings are passed through unchanged.
+func EncodeJSON[T any](v T) (string, error) {
+	if s, ok := any(v).(string); ok {
+		return s, nil
+	}
+	data, err := json.Marshal(v)
+	return string(data), err
+}
+
+// DecodeJSON decodes the JSON value in s, which may be wrapped in Markdown code fences or prose
+// as LLMs tend to do. Strings are passed through unchanged.
+func DecodeJSON[T any](s string) (T, error) {
+	var v T
+	if p, ok := any(&v).(*string); ok {
+		*p = s
+		return v, nil
+	}
+	data := agent.ExtractJSON(s)
+	if data == "" {
+		return v, fmt.Errorf("no JSON value found in %q", s)
+	}
+	err := json.Unmarshal([]byte(data), &v)
+	return v, err
+}
+
+// StructuredNode is a typed LLM step. It renders Template with Vars, the input as "Input" and
+// the run state (see LLMNode), and decodes the agent's response into O with
+// agent.SendStructured, retrying when the response does not match the schema of O.
+type StructuredNode[I, O any] struct {
+	Agent    *agent.Agent
+	Template *prompt.Template
+	Vars     map[string]any
+}
+
+// Execute resets the agent's conversation, sends the rendered prompt and decodes the response.
+func (n *StructuredNode[I, O]) Execute(ctx context.Context, input I) (O, error) {
+	var out O
+	text, err := renderNodeTemplate(ctx, n.Template, n.Vars, input)
+	if err != nil {
+		return out, err
+	}
+	n.Agent.Reset()
+	err = n.Agent.SendStructured(ctx, text, &out)
+	return out, err
+}
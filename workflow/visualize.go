@@ -0,0 +1,371 @@
+package workflow
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// DiagramFormat selects the output of Flow.Visualize.
+type DiagramFormat string
+
+const (
+	DOT     DiagramFormat = "dot"     // Graphviz DOT.
+	Mermaid DiagramFormat = "mermaid" // Mermaid flowchart.
+)
+
+// Labeler may be implemented by custom nodes to control their label in diagrams.
+type Labeler interface {
+	Label() string
+}
+
+// VisualizeOption configures Flow.Visualize.
+type VisualizeOption func(*visualizeOptions)
+
+type visualizeOptions struct {
+	run *RunRecord
+}
+
+// WithRun annotates the top-level nodes with the durations and errors of a recorded run, such
+// as the last run of the flow from its RunStore or NewRunRecord(name, result).
+func WithRun(rec *RunRecord) VisualizeOption {
+	return func(o *visualizeOptions) {
+		o.run = rec
+	}
+}
+
+// Visualize renders the node topology of the flow as a DOT or Mermaid diagram, including the
+// branches of conditional, parallel and balancing nodes, retried nodes, and flows used as nodes.
+// Unknown formats render DOT.
+func (f *Flow) Visualize(format DiagramFormat, opts ...VisualizeOption) string {
+	var o visualizeOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	d := &diagram{visiting: map[*Flow]bool{f: true}}
+	start := d.vertex("start", shapeTerminal)
+	exits := []exit{{id: start}}
+	for i, node := range f.Nodes {
+		entry, nodeExits := d.build(node, 0)
+		if o.run != nil && i < len(o.run.Steps) {
+			d.annotate(entry, o.run.Steps[i])
+		}
+		d.connect(exits, entry)
+		exits = nodeExits
+	}
+	d.connect(exits, d.vertex("end", shapeTerminal))
+
+	name := f.Name
+	if name == "" {
+		name = "flow"
+	}
+	if format == Mermaid {
+		return d.mermaid()
+	}
+	return d.dot(name)
+}
+
+type shape int
+
+const (
+	shapeBox shape = iota
+	shapeDecision
+	shapeTerminal
+)
+
+type vertex struct {
+	id      string
+	label   string
+	shape   shape
+	cluster int // 0 for the top level.
+	failed  bool
+}
+
+type edge struct {
+	from, to, label string
+}
+
+type cluster struct {
+	label  string
+	parent int
+}
+
+// exit is a vertex that continues to the next node, with the label of that edge.
+type exit struct {
+	id    string
+	label string
+}
+
+type diagram struct {
+	vertices []*vertex
+	edges    []edge
+	clusters []cluster // Cluster n is clusters[n-1].
+	visiting map[*Flow]bool
+}
+
+func (d *diagram) vertex(label string, s shape) string {
+	return d.vertexIn(label, s, 0)
+}
+
+func (d *diagram) vertexIn(label string, s shape, c int) string {
+	id := fmt.Sprintf("n%d", len(d.vertices))
+	d.vertices = append(d.vertices, &vertex{id: id, label: label, shape: s, cluster: c})
+	return id
+}
+
+func (d *diagram) cluster(label string, parent int) int {
+	d.clusters = append(d.clusters, cluster{label: label, parent: parent})
+	return len(d.clusters)
+}
+
+func (d *diagram) connect(exits []exit, to string) {
+	for _, e := range exits {
+		d.edges = append(d.edges, edge{from: e.id, to: to, label: e.label})
+	}
+}
+
+// build adds the vertices of node to cluster c and returns its entry vertex and exits.
+func (d *diagram) build(node Node, c int) (string, []exit) {
+	switch n := node.(type) {
+	case *ConditionalNode:
+		id := d.vertexIn(nodeLabel(node), shapeDecision, c)
+		entry, exits := d.build(n.TrueNode, c)
+		d.edges = append(d.edges, edge{from: id, to: entry, label: "true"})
+		if n.FalseNode == nil {
+			return id, append(exits, exit{id: id, label: "false"})
+		}
+		entry, falseExits := d.build(n.FalseNode, c)
+		d.edges = append(d.edges, edge{from: id, to: entry, label: "false"})
+		return id, append(exits, falseExits...)
+
+	case *ParallelNode:
+		fork := d.vertexIn(nodeLabel(node), shapeDecision, c)
+		join := d.vertexIn("merge", shapeBox, c)
+		for _, child := range n.Nodes {
+			entry, exits := d.build(child, c)
+			d.edges = append(d.edges, edge{from: fork, to: entry})
+			d.connect(exits, join)
+		}
+		return fork, []exit{{id: join}}
+
+	case *BalancingNode:
+		id := d.vertexIn(nodeLabel(node), shapeDecision, c)
+		var exits []exit
+		for i, child := range n.Nodes {
+			entry, childExits := d.build(child, c)
+			label := ""
+			if len(n.Weights) == len(n.Nodes) {
+				label = fmt.Sprintf("weight %d", n.Weights[i])
+			}
+			d.edges = append(d.edges, edge{from: id, to: entry, label: label})
+			exits = append(exits, childExits...)
+		}
+		return id, exits
+
+	case *RetryNode:
+		return d.build(n.Node, d.cluster(nodeLabel(node), c))
+
+	case *FallbackNode:
+		entry, exits := d.build(n.Node, c)
+		if n.Fallback != nil {
+			fallback, fallbackExits := d.build(n.Fallback, c)
+			d.edges = append(d.edges, edge{from: entry, to: fallback, label: "error"})
+			exits = append(exits, fallbackExits...)
+		}
+		return entry, exits
+
+	case *FinallyNode:
+		entry, exits := d.build(n.Node, c)
+		if n.Finally == nil {
+			return entry, exits
+		}
+		finally, finallyExits := d.build(n.Finally, c)
+		d.connect(exits, finally)
+		return entry, finallyExits
+
+	case *NamedNode:
+		entry, exits := d.build(n.Node, c)
+		for _, v := range d.vertices {
+			if v.id == entry {
+				v.label = n.Name + ": " + v.label
+			}
+		}
+		return entry, exits
+
+	case *Flow:
+		if d.visiting[n] {
+			id := d.vertexIn(nodeLabel(node)+" (recursive)", shapeBox, c)
+			return id, []exit{{id: id}}
+		}
+		d.visiting[n] = true
+		defer delete(d.visiting, n)
+		sub := d.cluster(nodeLabel(node), c)
+		if len(n.Nodes) == 0 {
+			id := d.vertexIn("empty", shapeBox, sub)
+			return id, []exit{{id: id}}
+		}
+		entry, exits := d.build(n.Nodes[0], sub)
+		for _, child := range n.Nodes[1:] {
+			next, childExits := d.build(child, sub)
+			d.connect(exits, next)
+			exits = childExits
+		}
+		return entry, exits
+	}
+	id := d.vertexIn(nodeLabel(node), shapeBox, c)
+	return id, []exit{{id: id}}
+}
+
+// annotate adds the duration and error of a recorded step to a vertex.
+func (d *diagram) annotate(id string, step StepRecord) {
+	for _, v := range d.vertices {
+		if v.id != id {
+			continue
+		}
+		duration := step.Duration
+		if duration >= time.Millisecond {
+			duration = duration.Round(time.Millisecond)
+		}
+		v.label += "\n" + duration.String()
+		if step.Error != "" {
+			v.label += "\nerror: " + step.Error
+			v.failed = true
+		}
+	}
+}
+
+// nodeLabel describes a node in a diagram.
+func nodeLabel(node Node) string {
+	switch n := node.(type) {
+	case Labeler:
+		return n.Label()
+	case *LLMNode:
+		return "LLM"
+	case *ToolNode:
+		return "Tool: " + n.ToolName
+	case *FuncNode:
+		return "Func"
+	case *RetrievalNode:
+		return "Retrieval"
+	case *ConditionalNode:
+		return "condition"
+	case *ParallelNode:
+		return "parallel"
+	case *BalancingNode:
+		return "balance"
+	case *RetryNode:
+		return fmt.Sprintf("retry (max %d)", n.MaxRetries)
+	case *Flow:
+		if n.Name != "" {
+			return "flow: " + n.Name
+		}
+		return "flow"
+	}
+	t := reflect.TypeOf(node)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil {
+		return "nil"
+	}
+	return t.Name()
+}
+
+func (d *diagram) dot(name string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n\trankdir=TB;\n\tnode [shape=box];\n", dotQuote(name))
+	d.dotCluster(&b, 0, "\t")
+	for _, e := range d.edges {
+		fmt.Fprintf(&b, "\t%s -> %s", e.from, e.to)
+		if e.label != "" {
+			fmt.Fprintf(&b, " [label=%s]", dotQuote(e.label))
+		}
+		b.WriteString(";\n")
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func (d *diagram) dotCluster(b *strings.Builder, c int, indent string) {
+	for _, v := range d.vertices {
+		if v.cluster != c {
+			continue
+		}
+		attrs := "label=" + dotQuote(v.label)
+		switch v.shape {
+		case shapeDecision:
+			attrs += ", shape=diamond"
+		case shapeTerminal:
+			attrs += ", shape=ellipse"
+		}
+		if v.failed {
+			attrs += ", color=red"
+		}
+		fmt.Fprintf(b, "%s%s [%s];\n", indent, v.id, attrs)
+	}
+	for i, cl := range d.clusters {
+		if cl.parent != c {
+			continue
+		}
+		fmt.Fprintf(b, "%ssubgraph cluster_%d {\n%s\tlabel=%s;\n", indent, i+1, indent, dotQuote(cl.label))
+		d.dotCluster(b, i+1, indent+"\t")
+		fmt.Fprintf(b, "%s}\n", indent)
+	}
+}
+
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + strings.ReplaceAll(s, "\n", `\n`) + `"`
+}
+
+func (d *diagram) mermaid() string {
+	var b strings.Builder
+	b.WriteString("flowchart TD\n")
+	d.mermaidCluster(&b, 0, "    ")
+	for _, e := range d.edges {
+		if e.label != "" {
+			fmt.Fprintf(&b, "    %s -->|%s| %s\n", e.from, mermaidQuote(e.label), e.to)
+		} else {
+			fmt.Fprintf(&b, "    %s --> %s\n", e.from, e.to)
+		}
+	}
+	for _, v := range d.vertices {
+		if v.failed {
+			fmt.Fprintf(&b, "    style %s stroke:#d00\n", v.id)
+		}
+	}
+	return b.String()
+}
+
+func (d *diagram) mermaidCluster(b *strings.Builder, c int, indent string) {
+	for _, v := range d.vertices {
+		if v.cluster != c {
+			continue
+		}
+		label := mermaidQuote(v.label)
+		switch v.shape {
+		case shapeDecision:
+			fmt.Fprintf(b, "%s%s{%s}\n", indent, v.id, label)
+		case shapeTerminal:
+			fmt.Fprintf(b, "%s%s([%s])\n", indent, v.id, label)
+		default:
+			fmt.Fprintf(b, "%s%s[%s]\n", indent, v.id, label)
+		}
+	}
+	for i, cl := range d.clusters {
+		if cl.parent != c {
+			continue
+		}
+		fmt.Fprintf(b, "%ssubgraph c%d[%s]\n", indent, i+1, mermaidQuote(cl.label))
+		d.mermaidCluster(b, i+1, indent+"    ")
+		fmt.Fprintf(b, "%send\n", indent)
+	}
+}
+
+func mermaidQuote(s string) string {
+	s = strings.ReplaceAll(s, `"`, "#quot;")
+	return `"` + strings.ReplaceAll(s, "\n", "<br/>") + `"`
+}